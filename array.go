@@ -22,6 +22,7 @@ package zap
 
 import (
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/toujourser/zap/zapcore"
@@ -122,6 +123,8 @@ func Int8s(key string, nums []int8) Field {
 //	var requests []*Request = ...
 //	logger.Info("sending requests", zap.Objects("requests", requests))
 //
+// Nil elements in the slice are encoded as nulls.
+//
 // If instead, you have a slice of values of such an object, use the
 // ObjectValues constructor.
 //
@@ -135,6 +138,10 @@ type objects[T zapcore.ObjectMarshaler] []T
 
 func (os objects[T]) MarshalLogArray(arr zapcore.ArrayEncoder) error {
 	for _, o := range os {
+		if isNilElement(o) {
+			arr.AppendReflected(nil)
+			continue
+		}
 		if err := arr.AppendObject(o); err != nil {
 			return err
 		}
@@ -213,6 +220,8 @@ func Strings(key string, ss []string) Field {
 // Note that these objects must implement fmt.Stringer directly.
 // That is, if you're trying to marshal a []Request, the String method
 // must be declared on the Request type, not its pointer (*Request).
+//
+// Nil elements in the slice are encoded as nulls.
 func Stringers[T fmt.Stringer](key string, values []T) Field {
 	return Array(key, stringers[T](values))
 }
@@ -221,11 +230,26 @@ type stringers[T fmt.Stringer] []T
 
 func (os stringers[T]) MarshalLogArray(arr zapcore.ArrayEncoder) error {
 	for _, o := range os {
+		if isNilElement(o) {
+			arr.AppendReflected(nil)
+			continue
+		}
 		arr.AppendString(o.String())
 	}
 	return nil
 }
 
+// isNilElement reports whether v is a nil interface or a nil pointer. Calling
+// methods on either is likely to panic, so generic array helpers encode such
+// elements as nulls instead.
+func isNilElement(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
 // Times constructs a field that carries a slice of time.Times.
 func Times(key string, ts []time.Time) Field {
 	return Array(key, times(ts))
@@ -154,6 +154,30 @@ func TestObjectsAndObjectValues(t *testing.T) {
 				map[string]any{"value": "baz"},
 			},
 		},
+		{
+			desc: "Objects/nil elements",
+			give: Objects("", []*fakeObject{
+				{value: "foo"},
+				nil,
+				{value: "baz"},
+			}),
+			want: []any{
+				map[string]any{"value": "foo"},
+				nil,
+				map[string]any{"value": "baz"},
+			},
+		},
+		{
+			desc: "Objects/nil interface elements",
+			give: Objects("", []zapcore.ObjectMarshaler{
+				nil,
+				&fakeObject{value: "bar"},
+			}),
+			want: []any{
+				nil,
+				map[string]any{"value": "bar"},
+			},
+		},
 		{
 			desc: "ObjectValues/multiple different objects",
 			give: ObjectValues("", []fakeObject{
@@ -299,6 +323,19 @@ func TestStringers(t *testing.T) {
 				"baz",
 			},
 		},
+		{
+			desc: "Stringers with nil elements",
+			give: Stringers("", []fmt.Stringer{
+				stringerObject{value: "foo"},
+				nil,
+				(*stringerObject)(nil),
+			}),
+			want: []any{
+				"foo",
+				nil,
+				nil,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -314,3 +351,19 @@ func TestStringers(t *testing.T) {
 		})
 	}
 }
+
+func TestArrayNilElementsJSON(t *testing.T) {
+	t.Parallel()
+
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{})
+	buf, err := enc.EncodeEntry(zapcore.Entry{}, []Field{
+		Objects("objects", []*fakeObject{{value: "foo"}, nil}),
+		Stringers("stringers", []*stringerObject{nil, {value: "bar"}}),
+	})
+	require.NoError(t, err)
+	defer buf.Free()
+
+	assert.JSONEq(t,
+		`{"objects": [{"value": "foo"}, null], "stringers": [null, "bar"]}`,
+		buf.String())
+}
@@ -26,6 +26,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zapcore/clock"
 	"github.com/toujourser/zap/zaptest/observer"
 )
 
@@ -45,3 +47,95 @@ func TestWithClock(t *testing.T) {
 		assert.Equal(t, date, logs.All()[0].Time, "Unexpected entry time.")
 	})
 }
+
+func TestWithClockPropagatesToCore(t *testing.T) {
+	clk := clock.NewMock()
+	obs, logs := observer.New(DebugLevel)
+	core := New(zapcore.NewSamplerWithOptions(obs, time.Second, 1, 0), WithClock(clk)).Core()
+
+	// Write entries without timestamps directly to the core, so that only
+	// the propagated clock can move them into a new tick.
+	write := func() {
+		if ce := core.Check(zapcore.Entry{Level: InfoLevel, Message: "foo"}, nil); ce != nil {
+			ce.Write()
+		}
+	}
+
+	write()
+	write()
+	assert.Equal(t, 1, logs.Len(), "Expected the second entry to be dropped within the tick.")
+
+	clk.Add(time.Second)
+	write()
+	assert.Equal(t, 2, logs.Len(), "Expected the sampler to follow the logger's clock.")
+}
+
+func TestWithClockLeavesParentUnchanged(t *testing.T) {
+	clk := clock.NewMock()
+	obs, logs := observer.New(DebugLevel)
+	parent := New(zapcore.NewSamplerWithOptions(obs, time.Second, 1, 0))
+	child := parent.WithOptions(WithClock(clk))
+	require.NotSame(t, parent.Core(), child.Core(), "Expected the child to get its own core.")
+
+	// Without a clock of its own, the parent's sampler uses the entries'
+	// timestamps, which are all zero here.
+	write := func() {
+		if ce := parent.Core().Check(zapcore.Entry{Level: InfoLevel, Message: "foo"}, nil); ce != nil {
+			ce.Write()
+		}
+	}
+
+	write()
+	clk.Add(time.Second)
+	write()
+	assert.Equal(t, 1, logs.Len(), "Expected the parent's sampler not to follow the child's clock.")
+}
+
+// clockedCore is a user-defined Core that stamps entries with its own Clock.
+type clockedCore struct {
+	zapcore.Core
+
+	clock zapcore.Clock
+}
+
+func (c clockedCore) WithClock(clock zapcore.Clock) zapcore.Core {
+	return clockedCore{Core: zapcore.WithClock(c.Core, clock), clock: clock}
+}
+
+func (c clockedCore) With(fields []zapcore.Field) zapcore.Core {
+	return clockedCore{Core: c.Core.With(fields), clock: c.clock}
+}
+
+func (c clockedCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c clockedCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.clock != nil {
+		ent.Time = c.clock.Now()
+	}
+	return c.Core.Write(ent, fields)
+}
+
+func TestWithClockUserDefinedCore(t *testing.T) {
+	date := time.Date(2077, 1, 23, 10, 15, 13, 441, time.UTC)
+	obs, logs := observer.New(DebugLevel)
+	parent := New(clockedCore{Core: obs})
+	child := parent.WithOptions(WithClock(constantClock(date)))
+
+	// Write directly to the cores, so that only their own clocks can set
+	// the entries' timestamps.
+	for _, core := range []zapcore.Core{child.Core(), parent.Core()} {
+		if ce := core.Check(zapcore.Entry{Level: InfoLevel, Message: "foo"}, nil); ce != nil {
+			ce.Write()
+		}
+	}
+
+	entries := logs.All()
+	require.Len(t, entries, 2, "Unexpected number of entries.")
+	assert.Equal(t, date, entries[0].Time, "Expected the user-defined core to receive the clock.")
+	assert.True(t, entries[1].Time.IsZero(), "Expected the parent's core to keep its clock.")
+}
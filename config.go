@@ -22,10 +22,14 @@ package zap
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
 	"sort"
 	"time"
 
 	"github.com/toujourser/zap/zapcore"
+
+	"go.uber.org/multierr"
 )
 
 // SamplingConfig sets a sampling strategy for the logger. Sampling caps the
@@ -36,12 +40,70 @@ import (
 //
 // Values configured here are per-second. See zapcore.NewSamplerWithOptions for
 // details.
+//
+// Levels overrides Initial and Thereafter for individual levels, keyed by
+// level name (e.g. "warn"). Levels without an entry use the defaults set by
+// Initial and Thereafter. For example, the following samples Info logs
+// aggressively, but never drops Warn or Error logs:
+//
+//	sampling:
+//	  initial: 100
+//	  thereafter: 100
+//	  levels:
+//	    info: {initial: 10, thereafter: 1000}
+//	    warn: {thereafter: 0}
+//	    error: {thereafter: 0}
 type SamplingConfig struct {
 	Initial    int                                           `json:"initial" yaml:"initial"`
 	Thereafter int                                           `json:"thereafter" yaml:"thereafter"`
+	Levels     map[string]SamplingLevelConfig                `json:"levels" yaml:"levels"`
 	Hook       func(zapcore.Entry, zapcore.SamplingDecision) `json:"-" yaml:"-"`
 }
 
+// SamplingLevelConfig sets the sampling rate for a single level within a
+// SamplingConfig.
+//
+// Unlike SamplingConfig's own Thereafter, a Thereafter of zero means that
+// entries at the level are never dropped, so that a level can be exempted
+// from sampling without listing a rate.
+type SamplingLevelConfig struct {
+	Initial    int `json:"initial" yaml:"initial"`
+	Thereafter int `json:"thereafter" yaml:"thereafter"`
+}
+
+// levelOptions returns a zapcore.SamplerLevel option for each entry in
+// Levels, sorted by level name.
+func (scfg *SamplingConfig) levelOptions() ([]zapcore.SamplerOption, error) {
+	names := make([]string, 0, len(scfg.Levels))
+	for name := range scfg.Levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs error
+	opts := make([]zapcore.SamplerOption, 0, len(names))
+	for _, name := range names {
+		lvl, err := zapcore.ParseLevel(name)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("invalid Levels: %w", err))
+			continue
+		}
+		lcfg := scfg.Levels[name]
+		if lcfg.Initial < 0 || lcfg.Thereafter < 0 {
+			errs = multierr.Append(errs, fmt.Errorf("invalid Levels[%q]: negative Initial or Thereafter", name))
+			continue
+		}
+
+		first, thereafter := lcfg.Initial, lcfg.Thereafter
+		if thereafter == 0 {
+			// Log every entry past the first, i.e. all of them.
+			first, thereafter = 0, 1
+		}
+		opts = append(opts, zapcore.SamplerLevel(lvl, first, thereafter))
+	}
+	return opts, errs
+}
+
 // Config offers a declarative way to construct a logger. It doesn't do
 // anything that can't be done with New, Options, and the various
 // zapcore.WriteSyncer and zapcore.Core wrappers, but it's a simpler way to
@@ -72,16 +134,54 @@ type Config struct {
 	DisableStacktrace bool `json:"disableStacktrace" yaml:"disableStacktrace"`
 	// Sampling sets a sampling policy. A nil SamplingConfig disables sampling.
 	Sampling *SamplingConfig `json:"sampling" yaml:"sampling"`
-	// Encoding sets the logger's encoding. Valid values are "json" and
-	// "console", as well as any third-party encodings registered via
-	// RegisterEncoder.
+	// Encoding sets the logger's encoding. Valid values are "json",
+	// "console", "logfmt", "gelf", "journald", and "ecs", as well as any
+	// third-party encodings registered via RegisterEncoder.
 	Encoding string `json:"encoding" yaml:"encoding"`
 	// EncoderConfig sets options for the chosen encoder. See
 	// zapcore.EncoderConfig for details.
 	EncoderConfig zapcore.EncoderConfig `json:"encoderConfig" yaml:"encoderConfig"`
+	// DisableColor and ForceColor override the console encoder's colors,
+	// setting EncoderConfig.Console.Color to zapcore.ColorNever or
+	// zapcore.ColorAlways for every output. They can't both be set.
+	//
+	// Otherwise, if the level encoder adds colors, as
+	// zapcore.CapitalColorLevelEncoder does, and Console.Color is unset, it's
+	// treated as zapcore.ColorAuto: levels are only colored when writing to a
+	// terminal, subject to the NO_COLOR and FORCE_COLOR environment
+	// variables.
+	DisableColor bool `json:"disableColor" yaml:"disableColor"`
+	ForceColor   bool `json:"forceColor" yaml:"forceColor"`
 	// OutputPaths is a list of URLs or file paths to write logging output to.
 	// See Open for details.
 	OutputPaths []string `json:"outputPaths" yaml:"outputPaths"`
+	// LevelOutputs routes logs at or above a level to their own outputs. Keys
+	// are level names (e.g. "error") and values are URLs or file paths, as
+	// in OutputPaths.
+	//
+	// An entry is written to the outputs of every routed level at or below
+	// its own level, and to each output at most once. Entries below the
+	// lowest routed level are written to OutputPaths.
+	LevelOutputs map[string][]string `json:"levelOutputs" yaml:"levelOutputs"`
+	// Outputs configures groups of outputs that may each use their own
+	// encoding and level range, e.g. JSON to a file and console output to
+	// standard error. Each entry is written to by its own core, and the
+	// cores are teed together.
+	//
+	// Outputs can't be combined with OutputPaths or LevelOutputs; set
+	// OutputPaths to nil when starting from NewProductionConfig or
+	// NewDevelopmentConfig.
+	Outputs []OutputConfig `json:"outputs" yaml:"outputs"`
+	// BufferSize and FlushInterval enable in-memory buffering of writes to
+	// OutputPaths and LevelOutputs if either is positive. Buffered logs are
+	// flushed when the buffer is full, when the logger is synced, and at
+	// least once per FlushInterval. They default to 256 kB and 30 seconds.
+	// See zapcore.BufferedWriteSyncer for details.
+	//
+	// Buffered logs may be lost if the process exits without calling
+	// Logger.Sync.
+	BufferSize    int           `json:"bufferSize" yaml:"bufferSize"`
+	FlushInterval time.Duration `json:"flushInterval" yaml:"flushInterval"`
 	// ErrorOutputPaths is a list of URLs to write internal logger errors to.
 	// The default is standard error.
 	//
@@ -91,6 +191,36 @@ type Config struct {
 	ErrorOutputPaths []string `json:"errorOutputPaths" yaml:"errorOutputPaths"`
 	// InitialFields is a collection of fields to add to the root logger.
 	InitialFields map[string]interface{} `json:"initialFields" yaml:"initialFields"`
+	// IncludeHostname, IncludePID, and IncludeBuildInfo add fields
+	// describing the process to the root logger: the hostname, the process
+	// ID, and the main module's version and VCS revision. Their keys are set
+	// by EncoderConfig.Process. If the hostname can't be determined, it's
+	// logged as "unknown" and the error is written to ErrorOutputPaths.
+	// Build information is only added if the binary embeds it.
+	//
+	// InitialFields take precedence: a process field is omitted if
+	// InitialFields has a field with the same key.
+	IncludeHostname  bool `json:"includeHostname" yaml:"includeHostname"`
+	IncludePID       bool `json:"includePID" yaml:"includePID"`
+	IncludeBuildInfo bool `json:"includeBuildInfo" yaml:"includeBuildInfo"`
+}
+
+// OutputConfig configures a group of outputs within a Config's Outputs.
+type OutputConfig struct {
+	// Paths is a list of URLs or file paths to write logging output to.
+	// See Open for details.
+	Paths []string `json:"paths" yaml:"paths"`
+	// Encoding overrides the Config's Encoding for these outputs.
+	Encoding string `json:"encoding" yaml:"encoding"`
+	// EncoderConfig overrides options of the Config's EncoderConfig for
+	// these outputs. Only non-zero fields are applied, so keys omitted by
+	// the Config's EncoderConfig can be added, but not the reverse.
+	EncoderConfig *zapcore.EncoderConfig `json:"encoderConfig" yaml:"encoderConfig"`
+	// MinLevel and MaxLevel restrict these outputs to entries within an
+	// inclusive range of levels. Entries must also be enabled by the
+	// Config's Level.
+	MinLevel *zapcore.Level `json:"minLevel" yaml:"minLevel"`
+	MaxLevel *zapcore.Level `json:"maxLevel" yaml:"maxLevel"`
 }
 
 // NewProductionEncoderConfig returns an opinionated EncoderConfig for
@@ -138,6 +268,44 @@ func NewProductionEncoderConfig() zapcore.EncoderConfig {
 	}
 }
 
+// NewECSEncoderConfig returns an EncoderConfig for the "ecs" encoding, which
+// writes entries in the layout of the Elastic Common Schema (ECS) for
+// ingestion into Elasticsearch:
+//
+//   - The time is written as "@timestamp", in RFC 3339 format in UTC with
+//     millisecond precision.
+//   - The level is written as "log.level", in lower case.
+//   - The message is written as "message".
+//   - The logger name is written as "log.logger".
+//   - The caller is written as "log.origin.file.name" and
+//     "log.origin.file.line", and its function as "log.origin.function".
+//   - Stacktraces are written as "error.stack_trace".
+//   - Durations are written as integer nanoseconds, like ECS's
+//     event.duration.
+//
+// See zapcore.NewECSEncoder for how fields are written. To use it in a
+// Config:
+//
+//	cfg := zap.NewProductionConfig()
+//	cfg.Encoding = "ecs"
+//	cfg.EncoderConfig = zap.NewECSEncoderConfig()
+func NewECSEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		TimeKey:        "@timestamp",
+		LevelKey:       "log.level",
+		NameKey:        "log.logger",
+		CallerKey:      "log.origin.file.name",
+		FunctionKey:    "log.origin.function",
+		MessageKey:     "message",
+		StacktraceKey:  "error.stack_trace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ECSTimeEncoder,
+		EncodeDuration: zapcore.NanosDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+}
+
 // NewProductionConfig builds a reasonable default production logging
 // configuration.
 // Logging is enabled at InfoLevel and above, and uses a JSON encoder.
@@ -235,35 +403,212 @@ func NewDevelopmentConfig() Config {
 	}
 }
 
-// Build constructs a logger from the Config and Options.
+// ConfigSection identifies the part of a Config that a ConfigError is about.
+type ConfigSection string
+
+// The sections of a Config that can fail to build.
+const (
+	// ConfigSectionLevel is the Level.
+	ConfigSectionLevel ConfigSection = "level"
+	// ConfigSectionSampling is the Sampling.
+	ConfigSectionSampling ConfigSection = "sampling"
+	// ConfigSectionEncoder is the Encoding and EncoderConfig, including
+	// those of individual Outputs.
+	ConfigSectionEncoder ConfigSection = "encoder"
+	// ConfigSectionOutputs is the OutputPaths, LevelOutputs, and Outputs.
+	ConfigSectionOutputs ConfigSection = "outputs"
+	// ConfigSectionErrorOutputs is the ErrorOutputPaths.
+	ConfigSectionErrorOutputs ConfigSection = "error outputs"
+)
+
+// ConfigError is the error returned when a Config can't be built. It names
+// the section of the Config at fault, so that callers can handle failures
+// without inspecting error messages:
+//
+//	var cfgErr *zap.ConfigError
+//	if errors.As(err, &cfgErr) && cfgErr.Section == zap.ConfigSectionOutputs {
+//		// Fall back to logging to stderr.
+//	}
+//
+// Its message is that of the underlying error.
+type ConfigError struct {
+	Section ConfigSection
+	Err     error
+}
+
+func (e *ConfigError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the underlying error.
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// Build constructs a logger from the Config and Options. If the Config is
+// invalid, each problem is reported as a *ConfigError; see Validate. Failures
+// to open outputs are also reported as *ConfigErrors.
 func (cfg Config) Build(opts ...Option) (*Logger, error) {
-	enc, err := cfg.buildEncoder()
+	core, errSink, err := cfg.buildCore()
 	if err != nil {
 		return nil, err
 	}
 
-	sink, errSink, err := cfg.openSinks()
+	log := New(core, cfg.buildOptions(errSink)...)
+	if len(opts) > 0 {
+		log = log.WithOptions(opts...)
+	}
+	return log, nil
+}
+
+// BuildSugared constructs a SugaredLogger from the Config and Options. It's
+// equivalent to calling Build and then Sugar.
+func (cfg Config) BuildSugared(opts ...Option) (*SugaredLogger, error) {
+	log, err := cfg.Build(opts...)
 	if err != nil {
 		return nil, err
 	}
+	return log.Sugar(), nil
+}
+
+// MustBuild is like Build, but panics if the Config is invalid. It's
+// intended for use in main functions and variable initialization, where
+// there's nothing better to do with the error:
+//
+//	var logger = zap.NewProductionConfig().MustBuild()
+func (cfg Config) MustBuild(opts ...Option) *Logger {
+	log, err := cfg.Build(opts...)
+	if err != nil {
+		var cfgErr *ConfigError
+		if errors.As(err, &cfgErr) {
+			panic(fmt.Errorf("can't build logger: invalid %s config: %w", cfgErr.Section, err))
+		}
+		panic(fmt.Errorf("can't build logger: %w", err))
+	}
+	return log
+}
+
+// Validate reports every problem that would make Build reject the Config: a
+// missing level, negative sampling parameters, an unknown encoding or an
+// incomplete EncoderConfig, invalid output routing, and output URLs that
+// can't be parsed or use an unregistered scheme. Each problem is a
+// *ConfigError, and they're combined with go.uber.org/multierr.
+//
+// Validate performs no I/O, so it's suitable for linting configurations:
+// outputs are never opened, and Build may still fail if, for example, a
+// log file's directory doesn't exist.
+func (cfg Config) Validate() error {
+	return multierr.Combine(cfg.validate()...)
+}
+
+// validate returns a *ConfigError for each problem found in the Config.
+// Build uses it too, so that it rejects the same Configs as Validate.
+func (cfg Config) validate() []error {
+	var errs []error
+	report := func(section ConfigSection, err error) {
+		errs = append(errs, &ConfigError{Section: section, Err: err})
+	}
 
 	if cfg.Level == (AtomicLevel{}) {
-		return nil, errors.New("missing Level")
+		report(ConfigSectionLevel, errors.New("missing Level"))
 	}
 
-	log := New(
-		zapcore.NewCore(enc, sink, cfg.Level),
-		cfg.buildOptions(errSink)...,
-	)
-	if len(opts) > 0 {
-		log = log.WithOptions(opts...)
+	if s := cfg.Sampling; s != nil {
+		if s.Initial < 0 {
+			report(ConfigSectionSampling, fmt.Errorf("negative Initial %d", s.Initial))
+		}
+		if s.Thereafter < 0 {
+			report(ConfigSectionSampling, fmt.Errorf("negative Thereafter %d", s.Thereafter))
+		}
+		if _, err := s.levelOptions(); err != nil {
+			report(ConfigSectionSampling, err)
+		}
 	}
-	return log, nil
+
+	if cfg.DisableColor && cfg.ForceColor {
+		report(ConfigSectionEncoder, errors.New("can't use both DisableColor and ForceColor"))
+	}
+
+	routes, err := cfg.outputRoutes()
+	if err != nil {
+		report(ConfigSectionOutputs, err)
+	} else {
+		if _, err := cfg.buildEncoders(routes); err != nil {
+			report(ConfigSectionEncoder, err)
+		}
+		for _, r := range routes {
+			if err := checkSinks(r.paths); err != nil {
+				report(ConfigSectionOutputs, err)
+			}
+		}
+	}
+
+	if err := checkSinks(cfg.ErrorOutputPaths); err != nil {
+		report(ConfigSectionErrorOutputs, err)
+	}
+	return errs
+}
+
+// buildCore opens the configured outputs and returns a core that writes to
+// them, along with the sink for internal errors. Sampling and initial fields
+// are not applied to the returned core. Errors are *ConfigErrors.
+func (cfg Config) buildCore() (zapcore.Core, zapcore.WriteSyncer, error) {
+	// Validate first so that nothing is opened for a Config that's bound to
+	// fail.
+	if errs := cfg.validate(); len(errs) > 0 {
+		return nil, nil, multierr.Combine(errs...)
+	}
+
+	routes, err := cfg.outputRoutes()
+	if err != nil {
+		return nil, nil, &ConfigError{Section: ConfigSectionOutputs, Err: err}
+	}
+	encs, err := cfg.buildEncoders(routes)
+	if err != nil {
+		return nil, nil, &ConfigError{Section: ConfigSectionEncoder, Err: err}
+	}
+
+	sinks, errSink, err := cfg.openSinks(routes)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg.wrapCSVHeaders(routes, sinks)
+
+	cores := make([]zapcore.Core, len(routes))
+	for i, r := range routes {
+		var enab zapcore.LevelEnabler = cfg.Level
+		if enabled := r.enabled; enabled != nil {
+			enab = LevelEnablerFunc(func(lvl zapcore.Level) bool {
+				return cfg.Level.Enabled(lvl) && enabled(lvl)
+			})
+		}
+		cores[i] = zapcore.NewCore(encs[i], sinks[i], enab)
+	}
+	return zapcore.NewTee(cores...), errSink, nil
 }
 
 func (cfg Config) buildOptions(errSink zapcore.WriteSyncer) []Option {
+	opts := cfg.buildLoggerOptions(errSink)
+
+	if cfg.Sampling != nil {
+		opts = append(opts, WrapCore(cfg.buildSampler))
+	}
+
+	if fs := cfg.buildInitialFields(errSink); len(fs) > 0 {
+		opts = append(opts, Fields(fs...))
+	}
+
+	return opts
+}
+
+// buildLoggerOptions returns the options that configure the Logger itself,
+// rather than its core.
+func (cfg Config) buildLoggerOptions(errSink zapcore.WriteSyncer) []Option {
 	opts := []Option{ErrorOutput(errSink)}
 
+	// Report write failures in the same format as the logs themselves. If
+	// the Config's own encoder can't be built (every output overrides it),
+	// keep the plain-text default.
+	if enc, err := cfg.buildEncoder(); err == nil {
+		opts = append(opts, ErrorHandler(newEncodedErrorHandler(enc, errSink)))
+	}
+
 	if cfg.Development {
 		opts = append(opts, Development())
 	}
@@ -280,51 +625,315 @@ func (cfg Config) buildOptions(errSink zapcore.WriteSyncer) []Option {
 		opts = append(opts, AddStacktrace(stackLevel))
 	}
 
-	if scfg := cfg.Sampling; scfg != nil {
-		opts = append(opts, WrapCore(func(core zapcore.Core) zapcore.Core {
-			var samplerOpts []zapcore.SamplerOption
-			if scfg.Hook != nil {
-				samplerOpts = append(samplerOpts, zapcore.SamplerHook(scfg.Hook))
+	return opts
+}
+
+// buildSampler wraps the core in a sampler configured by cfg.Sampling, or
+// returns it unchanged if sampling is disabled.
+func (cfg Config) buildSampler(core zapcore.Core) zapcore.Core {
+	scfg := cfg.Sampling
+	if scfg == nil {
+		return core
+	}
+
+	// Levels were checked by validate.
+	samplerOpts, _ := scfg.levelOptions()
+	if scfg.Hook != nil {
+		samplerOpts = append(samplerOpts, zapcore.SamplerHook(scfg.Hook))
+	}
+	return zapcore.NewSamplerWithOptions(
+		core,
+		time.Second,
+		scfg.Initial,
+		scfg.Thereafter,
+		samplerOpts...,
+	)
+}
+
+// buildInitialFields returns cfg.InitialFields as fields, sorted by key.
+func (cfg Config) buildInitialFields(errSink zapcore.WriteSyncer) []Field {
+	var fs []Field
+	for _, f := range cfg.buildProcessFields(errSink) {
+		if _, ok := cfg.InitialFields[f.Key]; !ok {
+			fs = append(fs, f)
+		}
+	}
+
+	keys := make([]string, 0, len(cfg.InitialFields))
+	for k := range cfg.InitialFields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fs = append(fs, Any(k, cfg.InitialFields[k]))
+	}
+	return fs
+}
+
+// outputRoute is a group of output paths that receive the same levels.
+type outputRoute struct {
+	paths []string
+
+	// enabled reports whether entries at a level are written to paths.
+	// If nil, all levels are written.
+	enabled func(zapcore.Level) bool
+
+	// encoding and encoderConfig override the Config's encoder settings
+	// for paths if set.
+	encoding      string
+	encoderConfig *zapcore.EncoderConfig
+}
+
+// outputRoutes groups OutputPaths and LevelOutputs by the levels each path
+// receives so that every path is opened, and written to, only once.
+func (cfg Config) outputRoutes() ([]outputRoute, error) {
+	if len(cfg.Outputs) > 0 {
+		return cfg.configuredOutputRoutes()
+	}
+	if len(cfg.LevelOutputs) == 0 {
+		return []outputRoute{{paths: cfg.OutputPaths}}, nil
+	}
+
+	names := make([]string, 0, len(cfg.LevelOutputs))
+	for name := range cfg.LevelOutputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var levels []zapcore.Level
+	byLevel := make(map[zapcore.Level][]string, len(names))
+	for _, name := range names {
+		lvl, err := zapcore.ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LevelOutputs: %w", err)
+		}
+		if _, ok := byLevel[lvl]; !ok {
+			levels = append(levels, lvl)
+		}
+		byLevel[lvl] = append(byLevel[lvl], cfg.LevelOutputs[name]...)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+	lowest := levels[0]
+
+	// routing describes the levels written to a single path.
+	type routing struct {
+		catchAll bool          // levels below the lowest routed level
+		routed   bool          // levels at or above minLevel
+		minLevel zapcore.Level // lowest level routed to the path
+	}
+
+	var paths []string // in order of first appearance
+	routings := make(map[string]routing)
+	for _, path := range cfg.OutputPaths {
+		r, ok := routings[path]
+		if !ok {
+			paths = append(paths, path)
+		}
+		r.catchAll = true
+		routings[path] = r
+	}
+	for _, lvl := range levels {
+		for _, path := range byLevel[lvl] {
+			r, ok := routings[path]
+			if !ok {
+				paths = append(paths, path)
 			}
-			return zapcore.NewSamplerWithOptions(
-				core,
-				time.Second,
-				cfg.Sampling.Initial,
-				cfg.Sampling.Thereafter,
-				samplerOpts...,
-			)
-		}))
-	}
-
-	if len(cfg.InitialFields) > 0 {
-		fs := make([]Field, 0, len(cfg.InitialFields))
-		keys := make([]string, 0, len(cfg.InitialFields))
-		for k := range cfg.InitialFields {
-			keys = append(keys, k)
+			if !r.routed {
+				r.routed = true
+				r.minLevel = lvl
+			}
+			routings[path] = r
 		}
-		sort.Strings(keys)
-		for _, k := range keys {
-			fs = append(fs, Any(k, cfg.InitialFields[k]))
+	}
+
+	var routes []outputRoute
+	routeIdx := make(map[routing]int)
+	for _, path := range paths {
+		r := routings[path]
+		i, ok := routeIdx[r]
+		if !ok {
+			i = len(routes)
+			routeIdx[r] = i
+			routes = append(routes, outputRoute{
+				enabled: func(lvl zapcore.Level) bool {
+					return (r.catchAll && lvl < lowest) ||
+						(r.routed && lvl >= r.minLevel)
+				},
+			})
 		}
-		opts = append(opts, Fields(fs...))
+		routes[i].paths = append(routes[i].paths, path)
+	}
+	return routes, nil
+}
+
+// configuredOutputRoutes returns a route for each of cfg.Outputs.
+func (cfg Config) configuredOutputRoutes() ([]outputRoute, error) {
+	if len(cfg.OutputPaths) > 0 {
+		return nil, errors.New("can't use both Outputs and OutputPaths")
+	}
+	if len(cfg.LevelOutputs) > 0 {
+		return nil, errors.New("can't use both Outputs and LevelOutputs")
 	}
 
-	return opts
+	routes := make([]outputRoute, len(cfg.Outputs))
+	for i, out := range cfg.Outputs {
+		if len(out.Paths) == 0 {
+			return nil, fmt.Errorf("invalid Outputs[%d]: missing Paths", i)
+		}
+		if out.MinLevel != nil && out.MaxLevel != nil && *out.MinLevel > *out.MaxLevel {
+			return nil, fmt.Errorf("invalid Outputs[%d]: MinLevel %v is above MaxLevel %v", i, *out.MinLevel, *out.MaxLevel)
+		}
+
+		routes[i] = outputRoute{
+			paths:         out.Paths,
+			encoding:      out.Encoding,
+			encoderConfig: out.EncoderConfig,
+		}
+		if minLvl, maxLvl := out.MinLevel, out.MaxLevel; minLvl != nil || maxLvl != nil {
+			routes[i].enabled = func(lvl zapcore.Level) bool {
+				return (minLvl == nil || lvl >= *minLvl) &&
+					(maxLvl == nil || lvl <= *maxLvl)
+			}
+		}
+	}
+	return routes, nil
 }
 
-func (cfg Config) openSinks() (zapcore.WriteSyncer, zapcore.WriteSyncer, error) {
-	sink, closeOut, err := Open(cfg.OutputPaths...)
-	if err != nil {
-		return nil, nil, err
+// openSinks opens the sinks for each route and the sink for internal errors.
+// Errors are *ConfigErrors.
+func (cfg Config) openSinks(routes []outputRoute) ([]zapcore.WriteSyncer, zapcore.WriteSyncer, error) {
+	sinks := make([]zapcore.WriteSyncer, 0, len(routes))
+	closers := make([]func(), 0, len(routes))
+	closeAll := func() {
+		for _, closeOut := range closers {
+			closeOut()
+		}
+	}
+
+	for _, r := range routes {
+		sink, closeOut, err := Open(r.paths...)
+		if err != nil {
+			closeAll()
+			return nil, nil, &ConfigError{Section: ConfigSectionOutputs, Err: err}
+		}
+		if cfg.BufferSize > 0 || cfg.FlushInterval > 0 {
+			sink = &zapcore.BufferedWriteSyncer{
+				WS:            sink,
+				Size:          cfg.BufferSize,
+				FlushInterval: cfg.FlushInterval,
+			}
+		}
+		sinks = append(sinks, sink)
+		closers = append(closers, closeOut)
 	}
+
 	errSink, _, err := Open(cfg.ErrorOutputPaths...)
 	if err != nil {
-		closeOut()
-		return nil, nil, err
+		closeAll()
+		return nil, nil, &ConfigError{Section: ConfigSectionErrorOutputs, Err: err}
 	}
-	return sink, errSink, nil
+	return sinks, errSink, nil
 }
 
 func (cfg Config) buildEncoder() (zapcore.Encoder, error) {
-	return newEncoder(cfg.Encoding, cfg.EncoderConfig)
+	return newEncoder(cfg.Encoding, cfg.colorEncoderConfig(cfg.EncoderConfig))
+}
+
+// colorEncoderConfig applies DisableColor and ForceColor to an encoder
+// configuration, and resolves colored level encoders against the output if
+// the color mode is unset.
+func (cfg Config) colorEncoderConfig(encCfg zapcore.EncoderConfig) zapcore.EncoderConfig {
+	switch {
+	case cfg.DisableColor:
+		encCfg.Console.Color = zapcore.ColorNever
+	case cfg.ForceColor:
+		encCfg.Console.Color = zapcore.ColorAlways
+	case encCfg.Console.Color == "" && isColorLevelEncoder(encCfg.EncodeLevel):
+		encCfg.Console.Color = zapcore.ColorAuto
+	}
+	return encCfg
+}
+
+// isColorLevelEncoder reports whether e is one of zapcore's level encoders
+// that add colors.
+func isColorLevelEncoder(e zapcore.LevelEncoder) bool {
+	if e == nil {
+		return false
+	}
+	ptr := reflect.ValueOf(e).Pointer()
+	return ptr == reflect.ValueOf(zapcore.CapitalColorLevelEncoder).Pointer() ||
+		ptr == reflect.ValueOf(zapcore.LowercaseColorLevelEncoder).Pointer()
+}
+
+// buildEncoders returns the encoder for each route. Routes without
+// overrides share the Config's encoder, which is only built if needed.
+func (cfg Config) buildEncoders(routes []outputRoute) ([]zapcore.Encoder, error) {
+	var shared zapcore.Encoder
+	encs := make([]zapcore.Encoder, len(routes))
+	for i, r := range routes {
+		if r.encoding == "" && r.encoderConfig == nil {
+			if shared == nil {
+				enc, err := cfg.buildEncoder()
+				if err != nil {
+					return nil, err
+				}
+				shared = enc
+			}
+			encs[i] = shared
+			continue
+		}
+
+		enc, err := newEncoder(cfg.routeEncoding(r))
+		if err != nil {
+			return nil, err
+		}
+		encs[i] = enc
+	}
+	return encs, nil
+}
+
+// routeEncoding returns the name and configuration of the encoder for a
+// route.
+func (cfg Config) routeEncoding(r outputRoute) (string, zapcore.EncoderConfig) {
+	encoding := cfg.Encoding
+	if r.encoding != "" {
+		encoding = r.encoding
+	}
+	encCfg := cfg.EncoderConfig
+	if r.encoderConfig != nil {
+		encCfg = mergeEncoderConfig(encCfg, *r.encoderConfig)
+	}
+	return encoding, cfg.colorEncoderConfig(encCfg)
+}
+
+// wrapCSVHeaders makes the sinks of routes using a CSV encoder with
+// headers enabled write the header row first.
+func (cfg Config) wrapCSVHeaders(routes []outputRoute, sinks []zapcore.WriteSyncer) {
+	for i, r := range routes {
+		encoding, encCfg := cfg.routeEncoding(r)
+		if !encCfg.CSV.Header {
+			continue
+		}
+		switch encoding {
+		case "tsv":
+			encCfg = tsvEncoderConfig(encCfg)
+		case "csv":
+		default:
+			continue
+		}
+		sinks[i] = zapcore.NewCSVHeaderWriter(sinks[i], encCfg)
+	}
+}
+
+// mergeEncoderConfig returns base with the non-zero fields of override
+// applied to it.
+func mergeEncoderConfig(base, override zapcore.EncoderConfig) zapcore.EncoderConfig {
+	dst := reflect.ValueOf(&base).Elem()
+	src := reflect.ValueOf(override)
+	for i := 0; i < src.NumField(); i++ {
+		if f := src.Field(i); !f.IsZero() {
+			dst.Field(i).Set(f)
+		}
+	}
+	return base
 }
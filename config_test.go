@@ -21,14 +21,20 @@
 package zap
 
 import (
+	"encoding/json"
+	"errors"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap/internal/ztest"
 	"github.com/toujourser/zap/zapcore"
+	"go.uber.org/multierr"
 )
 
 func TestConfig(t *testing.T) {
@@ -54,6 +60,13 @@ func TestConfig(t *testing.T) {
 				"WARN\t[a-z0-9_-]+/config_test.go:" + `\d+` + "\twarn\t" + `{"k": "v", "z": "zz"}` + "\n" +
 				`github.com/toujourser/zap.TestConfig.\w+`,
 		},
+		{
+			desc:    "logfmt",
+			cfg:     logfmtConfig(),
+			expectN: 2 + 100 + 1, // same sampling as production
+			expectRe: `level=info caller=[a-z0-9_-]+/config_test.go:\d+ msg=info k=v z=zz` + "\n" +
+				`level=warn caller=[a-z0-9_-]+/config_test.go:\d+ msg=warn k=v z=zz` + "\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -85,6 +98,300 @@ func TestConfig(t *testing.T) {
 	}
 }
 
+func logfmtConfig() Config {
+	cfg := NewProductionConfig()
+	cfg.Encoding = "logfmt"
+	return cfg
+}
+
+func TestConfigLevelOutputs(t *testing.T) {
+	dir := t.TempDir()
+	appLog := filepath.Join(dir, "app.log")
+	warnLog := filepath.Join(dir, "warn.log")
+	errLog := filepath.Join(dir, "error.log")
+	auditLog := filepath.Join(dir, "audit.log")
+
+	cfg := NewProductionConfig()
+	cfg.Level = NewAtomicLevelAt(DebugLevel)
+	cfg.Encoding = "logfmt"
+	cfg.EncoderConfig.TimeKey = ""
+	cfg.DisableCaller = true
+	cfg.DisableStacktrace = true
+	cfg.OutputPaths = []string{appLog, auditLog}
+	cfg.LevelOutputs = map[string][]string{
+		"warn":  {warnLog, errLog},
+		"error": {errLog, auditLog},
+	}
+
+	logger, err := cfg.Build()
+	require.NoError(t, err, "Unexpected error constructing logger.")
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+	require.NoError(t, logger.Sync(), "Unexpected error syncing logger.")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{appLog, "level=debug msg=debug\nlevel=info msg=info\n"},
+		{warnLog, "level=warn msg=warn\nlevel=error msg=error\n"},
+		{errLog, "level=warn msg=warn\nlevel=error msg=error\n"},
+		{auditLog, "level=debug msg=debug\nlevel=info msg=info\nlevel=error msg=error\n"},
+	}
+	for _, tt := range tests {
+		contents, err := os.ReadFile(tt.path)
+		require.NoError(t, err, "Couldn't read log contents from %v.", tt.path)
+		assert.Equal(t, tt.want, string(contents), "Unexpected log output in %v.", tt.path)
+	}
+
+	t.Run("respects Level", func(t *testing.T) {
+		cfg.Level.SetLevel(ErrorLevel)
+		defer cfg.Level.SetLevel(DebugLevel)
+
+		assert.Nil(t, logger.Check(WarnLevel, "warn"), "Expected warn to be disabled.")
+		assert.NotNil(t, logger.Check(ErrorLevel, "error"), "Expected error to be enabled.")
+	})
+}
+
+func TestConfigWithInvalidLevelOutputs(t *testing.T) {
+	cfg := NewProductionConfig()
+	cfg.OutputPaths = []string{"stderr"}
+	cfg.LevelOutputs = map[string][]string{"loud": {"stderr"}}
+
+	_, err := cfg.Build()
+	require.Error(t, err, "Expected an error for an unknown level.")
+	assert.Contains(t, err.Error(), `"loud"`, "Unexpected error message.")
+}
+
+func TestConfigOutputs(t *testing.T) {
+	dir := t.TempDir()
+	jsonLog := filepath.Join(dir, "app.json")
+	consoleLog := filepath.Join(dir, "app.log")
+	errLog := filepath.Join(dir, "error.log")
+
+	warn, info := WarnLevel, InfoLevel
+	cfg := NewProductionConfig()
+	cfg.Level = NewAtomicLevelAt(DebugLevel)
+	cfg.EncoderConfig.TimeKey = ""
+	cfg.DisableCaller = true
+	cfg.DisableStacktrace = true
+	cfg.OutputPaths = nil
+	cfg.Outputs = []OutputConfig{
+		{Paths: []string{jsonLog}},
+		{
+			Paths:         []string{consoleLog},
+			Encoding:      "console",
+			EncoderConfig: &zapcore.EncoderConfig{EncodeLevel: zapcore.CapitalLevelEncoder},
+			MaxLevel:      &info,
+		},
+		{
+			Paths:         []string{errLog},
+			Encoding:      "logfmt",
+			EncoderConfig: &zapcore.EncoderConfig{MessageKey: "message"},
+			MinLevel:      &warn,
+		},
+	}
+
+	logger, err := cfg.Build()
+	require.NoError(t, err, "Unexpected error constructing logger.")
+
+	logger.Debug("debug", String("k", "v"))
+	logger.Info("info")
+	logger.Warn("warn")
+	require.NoError(t, logger.Sync(), "Unexpected error syncing logger.")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{
+			jsonLog,
+			`{"level":"debug","msg":"debug","k":"v"}` + "\n" +
+				`{"level":"info","msg":"info"}` + "\n" +
+				`{"level":"warn","msg":"warn"}` + "\n",
+		},
+		{consoleLog, "DEBUG\tdebug\t{\"k\": \"v\"}\nINFO\tinfo\n"},
+		{errLog, "level=warn message=warn\n"},
+	}
+	for _, tt := range tests {
+		contents, err := os.ReadFile(tt.path)
+		require.NoError(t, err, "Couldn't read log contents from %v.", tt.path)
+		assert.Equal(t, tt.want, string(contents), "Unexpected log output in %v.", tt.path)
+	}
+}
+
+func TestConfigCSVHeaders(t *testing.T) {
+	dir := t.TempDir()
+	csvLog := filepath.Join(dir, "app.csv")
+	tsvLog := filepath.Join(dir, "app.tsv")
+	jsonLog := filepath.Join(dir, "app.json")
+
+	cfg := NewProductionConfig()
+	cfg.Encoding = "csv"
+	cfg.EncoderConfig.TimeKey = ""
+	cfg.EncoderConfig.CSV = zapcore.CSVConfig{
+		Columns: []string{"level", "msg", "user"},
+		Header:  true,
+	}
+	cfg.DisableCaller = true
+	cfg.DisableStacktrace = true
+	cfg.OutputPaths = nil
+	cfg.Outputs = []OutputConfig{
+		{Paths: []string{csvLog}},
+		{Paths: []string{tsvLog}, Encoding: "tsv"},
+		{Paths: []string{jsonLog}, Encoding: "json"},
+	}
+
+	logger, err := cfg.Build()
+	require.NoError(t, err, "Unexpected error constructing logger.")
+	logger.Info("hello", String("user", "alice"), Int("n", 1))
+	logger.Warn("bye")
+	require.NoError(t, logger.Sync(), "Unexpected error syncing logger.")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{
+			csvLog,
+			"level,msg,user,extras\n" +
+				`info,hello,alice,"{""n"":1}"` + "\n" +
+				"warn,bye,,\n",
+		},
+		{
+			tsvLog,
+			"level\tmsg\tuser\textras\n" +
+				"info\thello\talice\t\"{\"\"n\"\":1}\"\n" +
+				"warn\tbye\t\t\n",
+		},
+		{
+			jsonLog,
+			`{"level":"info","msg":"hello","user":"alice","n":1}` + "\n" +
+				`{"level":"warn","msg":"bye"}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		contents, err := os.ReadFile(tt.path)
+		require.NoError(t, err, "Couldn't read log contents from %v.", tt.path)
+		assert.Equal(t, tt.want, string(contents), "Unexpected log output in %v.", tt.path)
+	}
+}
+
+func TestConfigECS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+
+	cfg := NewProductionConfig()
+	cfg.Encoding = "ecs"
+	cfg.EncoderConfig = NewECSEncoderConfig()
+	cfg.EncoderConfig.TimeKey = ""
+	cfg.EncoderConfig.ECSFieldPrefix = "labels."
+	cfg.OutputPaths = []string{path}
+
+	logger, err := cfg.Build()
+	require.NoError(t, err, "Unexpected error constructing logger.")
+	logger.Named("app").Error("failed", Error(errors.New("boom")), String("user", "alice"))
+	require.NoError(t, logger.Sync(), "Unexpected error syncing logger.")
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err, "Couldn't read log contents.")
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(contents, &got), "Expected a JSON entry.")
+
+	assert.Equal(t, "error", got["log.level"], "Unexpected level.")
+	assert.Equal(t, "failed", got["message"], "Unexpected message.")
+	assert.Equal(t, "1.6.0", got["ecs.version"], "Unexpected ECS version.")
+	assert.Equal(t, "app", got["log.logger"], "Unexpected logger name.")
+	assert.Contains(t, got["log.origin.file.name"], "/config_test.go", "Unexpected caller.")
+	assert.Contains(t, got, "log.origin.file.line", "Expected the caller's line.")
+	assert.Equal(t, "boom", got["error.message"], "Unexpected error message.")
+	assert.Contains(t, got["error.stack_trace"], "TestConfigECS", "Expected a stacktrace.")
+	assert.Equal(t, "alice", got["labels.user"], "Expected other fields to be prefixed.")
+}
+
+func TestConfigWithInvalidOutputs(t *testing.T) {
+	warn, info := WarnLevel, InfoLevel
+	tests := []struct {
+		desc    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{
+			desc:    "with OutputPaths",
+			modify:  func(cfg *Config) { cfg.OutputPaths = []string{"stderr"} },
+			wantErr: "can't use both Outputs and OutputPaths",
+		},
+		{
+			desc:    "with LevelOutputs",
+			modify:  func(cfg *Config) { cfg.LevelOutputs = map[string][]string{"error": {"stderr"}} },
+			wantErr: "can't use both Outputs and LevelOutputs",
+		},
+		{
+			desc:    "missing paths",
+			modify:  func(cfg *Config) { cfg.Outputs = append(cfg.Outputs, OutputConfig{}) },
+			wantErr: "invalid Outputs[1]: missing Paths",
+		},
+		{
+			desc: "empty level range",
+			modify: func(cfg *Config) {
+				cfg.Outputs[0].MinLevel = &warn
+				cfg.Outputs[0].MaxLevel = &info
+			},
+			wantErr: "invalid Outputs[0]: MinLevel warn is above MaxLevel info",
+		},
+		{
+			desc:    "unknown encoding",
+			modify:  func(cfg *Config) { cfg.Outputs[0].Encoding = "foo" },
+			wantErr: `no encoder registered for name "foo"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			cfg := NewProductionConfig()
+			cfg.OutputPaths = nil
+			cfg.Outputs = []OutputConfig{{Paths: []string{"stderr"}}}
+			tt.modify(&cfg)
+
+			_, err := cfg.Build()
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestConfigBuffering(t *testing.T) {
+	logOut := filepath.Join(t.TempDir(), "test.log")
+
+	cfg := NewProductionConfig()
+	cfg.Encoding = "logfmt"
+	cfg.EncoderConfig.TimeKey = ""
+	cfg.DisableCaller = true
+	cfg.OutputPaths = []string{logOut}
+	cfg.BufferSize = 1024
+	cfg.FlushInterval = 10 * time.Millisecond
+
+	logger, err := cfg.Build()
+	require.NoError(t, err, "Unexpected error constructing logger.")
+
+	readLog := func() string {
+		contents, err := os.ReadFile(logOut)
+		require.NoError(t, err, "Couldn't read log contents from temp file.")
+		return string(contents)
+	}
+
+	logger.Info("synced")
+	assert.Empty(t, readLog(), "Expected log to be buffered.")
+	require.NoError(t, logger.Sync(), "Unexpected error syncing logger.")
+	assert.Equal(t, "level=info msg=synced\n", readLog(), "Expected Sync to flush the buffer.")
+
+	logger.Info("flushed")
+	assert.Eventually(t, func() bool {
+		return readLog() == "level=info msg=synced\nlevel=info msg=flushed\n"
+	}, time.Second, time.Millisecond, "Expected buffer to be flushed periodically.")
+}
+
 func TestConfigWithInvalidPaths(t *testing.T) {
 	tests := []struct {
 		desc      string
@@ -143,6 +450,318 @@ func TestConfigWithMissingAttributes(t *testing.T) {
 	}
 }
 
+func TestConfigErrorSections(t *testing.T) {
+	missingDir := filepath.Join(t.TempDir(), "not-there", "foo.log")
+
+	tests := []struct {
+		desc    string
+		modify  func(*Config)
+		section ConfigSection
+	}{
+		{
+			desc:    "level",
+			modify:  func(cfg *Config) { cfg.Level = AtomicLevel{} },
+			section: ConfigSectionLevel,
+		},
+		{
+			desc:    "encoding",
+			modify:  func(cfg *Config) { cfg.Encoding = "foo" },
+			section: ConfigSectionEncoder,
+		},
+		{
+			desc:    "encoder config",
+			modify:  func(cfg *Config) { cfg.EncoderConfig.EncodeTime = nil },
+			section: ConfigSectionEncoder,
+		},
+		{
+			desc:    "output paths",
+			modify:  func(cfg *Config) { cfg.OutputPaths = []string{missingDir} },
+			section: ConfigSectionOutputs,
+		},
+		{
+			desc:    "level outputs",
+			modify:  func(cfg *Config) { cfg.LevelOutputs = map[string][]string{"foo": {"stderr"}} },
+			section: ConfigSectionOutputs,
+		},
+		{
+			desc:    "error output paths",
+			modify:  func(cfg *Config) { cfg.ErrorOutputPaths = []string{missingDir} },
+			section: ConfigSectionErrorOutputs,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			cfg := NewProductionConfig()
+			tt.modify(&cfg)
+
+			_, err := cfg.Build()
+			var cfgErr *ConfigError
+			require.True(t, errors.As(err, &cfgErr), "Expected a *ConfigError, got %T.", err)
+			assert.Equal(t, tt.section, cfgErr.Section, "Unexpected section.")
+			assert.Equal(t, cfgErr.Err.Error(), err.Error(), "Expected the underlying error's message.")
+			assert.Equal(t, cfgErr.Err, errors.Unwrap(err), "Expected the underlying error to be unwrapped.")
+		})
+	}
+}
+
+func TestConfigWithPerLevelSampling(t *testing.T) {
+	var dropped, sampled [zapcore.FatalLevel + 1]atomic.Int64
+	hook := func(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+		if dec&zapcore.LogDropped > 0 {
+			dropped[ent.Level].Add(1)
+		} else if dec&zapcore.LogSampled > 0 {
+			sampled[ent.Level].Add(1)
+		}
+	}
+
+	cfg := NewProductionConfig()
+	cfg.OutputPaths = []string{filepath.Join(t.TempDir(), "test.log")}
+	cfg.Sampling = &SamplingConfig{
+		Initial:    100,
+		Thereafter: 100,
+		Levels: map[string]SamplingLevelConfig{
+			"info":  {Initial: 10, Thereafter: 50},
+			"error": {Initial: 1, Thereafter: 0},
+		},
+		Hook: hook,
+	}
+
+	logger, err := cfg.Build()
+	require.NoError(t, err, "Unexpected error constructing logger.")
+
+	for i := 0; i < 200; i++ {
+		logger.Info("sampling")
+		logger.Warn("sampling")
+		logger.Error("sampling")
+	}
+
+	tests := []struct {
+		lvl              zapcore.Level
+		sampled, dropped int64
+	}{
+		{InfoLevel, 13, 187}, // 10 initial + 190 / 50 thereafter
+		{WarnLevel, 101, 99}, // 100 initial + 100 / 100 thereafter
+		{ErrorLevel, 200, 0}, // never dropped
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.sampled, sampled[tt.lvl].Load(), "Unexpected sampled count at %v.", tt.lvl)
+		assert.Equal(t, tt.dropped, dropped[tt.lvl].Load(), "Unexpected dropped count at %v.", tt.lvl)
+	}
+}
+
+func TestConfigSamplingLevelsErrors(t *testing.T) {
+	cfg := NewProductionConfig()
+	cfg.Sampling = &SamplingConfig{
+		Levels: map[string]SamplingLevelConfig{
+			"foo":  {Initial: 1},
+			"warn": {Initial: -1},
+		},
+	}
+	err := cfg.Validate()
+	assert.EqualError(t, err, `invalid Levels: unrecognized level: "foo"; invalid Levels["warn"]: negative Initial or Thereafter`)
+
+	var cfgErr *ConfigError
+	require.True(t, errors.As(err, &cfgErr), "Expected a *ConfigError, got %T.", err)
+	assert.Equal(t, ConfigSectionSampling, cfgErr.Section, "Unexpected section.")
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		cfg := NewProductionConfig()
+		cfg.OutputPaths = []string{"stdout", filepath.Join(t.TempDir(), "not-there", "foo.log")}
+		assert.NoError(t, cfg.Validate(), "Expected missing directories to be left to Build.")
+	})
+
+	t.Run("no I/O", func(t *testing.T) {
+		var opened atomic.Bool
+		stubSinkRegistry(t)
+		require.NoError(t, RegisterSink("validate-test", func(*url.URL) (Sink, error) {
+			opened.Store(true)
+			return nopCloserSink{zapcore.AddSync(&ztest.Buffer{})}, nil
+		}))
+
+		cfg := NewProductionConfig()
+		cfg.OutputPaths = []string{"validate-test://out"}
+		cfg.ErrorOutputPaths = []string{"validate-test://err"}
+		require.NoError(t, cfg.Validate())
+		assert.False(t, opened.Load(), "Expected Validate not to open any sinks.")
+	})
+
+	t.Run("all problems", func(t *testing.T) {
+		cfg := NewProductionConfig()
+		cfg.Level = AtomicLevel{}
+		cfg.Sampling = &SamplingConfig{Initial: -1, Thereafter: -2}
+		cfg.Encoding = "foo"
+		cfg.OutputPaths = []string{"stdout", "unknown://foo", "%zz"}
+		cfg.ErrorOutputPaths = []string{"other://bar"}
+
+		err := cfg.Validate()
+		assert.EqualError(t, err, "missing Level; "+
+			"negative Initial -1; "+
+			"negative Thereafter -2; "+
+			`no encoder registered for name "foo"; `+
+			`invalid sink "unknown://foo": no sink found for scheme "unknown"; `+
+			`invalid sink "%zz": can't parse "%zz" as a URL: parse "%zz": invalid URL escape "%zz"; `+
+			`invalid sink "other://bar": no sink found for scheme "other"`)
+
+		var sections []ConfigSection
+		for _, e := range multierr.Errors(err) {
+			var cfgErr *ConfigError
+			require.True(t, errors.As(e, &cfgErr), "Expected a *ConfigError, got %T.", e)
+			sections = append(sections, cfgErr.Section)
+		}
+		assert.Equal(t, []ConfigSection{
+			ConfigSectionLevel,
+			ConfigSectionSampling,
+			ConfigSectionSampling,
+			ConfigSectionEncoder,
+			ConfigSectionOutputs,
+			ConfigSectionErrorOutputs,
+		}, sections, "Unexpected sections.")
+
+		_, buildErr := cfg.Build()
+		assert.Equal(t, err, buildErr, "Expected Build to report the same problems.")
+	})
+
+	t.Run("invalid routing", func(t *testing.T) {
+		cfg := NewProductionConfig()
+		cfg.LevelOutputs = map[string][]string{"foo": {"stderr"}}
+		cfg.ErrorOutputPaths = []string{"other://bar"}
+
+		err := cfg.Validate()
+		assert.Len(t, multierr.Errors(err), 2, "Expected the error outputs to be checked too.")
+		assert.ErrorContains(t, err, `unrecognized level: "foo"`)
+	})
+}
+
+func TestConfigBuildSugared(t *testing.T) {
+	cfg := NewProductionConfig()
+	cfg.OutputPaths = []string{filepath.Join(t.TempDir(), "out.log")}
+	sugar, err := cfg.BuildSugared(Fields(String("k", "v")))
+	require.NoError(t, err)
+	assert.True(t, sugar.Desugar().Core().Enabled(InfoLevel), "Expected the configured level to be used.")
+
+	cfg.Level = AtomicLevel{}
+	_, err = cfg.BuildSugared()
+	assert.EqualError(t, err, "missing Level")
+}
+
+func TestConfigMustBuild(t *testing.T) {
+	cfg := NewProductionConfig()
+	cfg.OutputPaths = []string{filepath.Join(t.TempDir(), "out.log")}
+	assert.NotNil(t, cfg.MustBuild(), "Expected a logger from a valid Config.")
+
+	cfg.Encoding = "foo"
+	assert.PanicsWithError(t,
+		`can't build logger: invalid encoder config: no encoder registered for name "foo"`,
+		func() { cfg.MustBuild() },
+	)
+}
+
+// terminalSink is a Sink that reports whether it's a terminal.
+type terminalSink struct {
+	ztest.Buffer
+	tty bool
+}
+
+func (s *terminalSink) IsTerminal() bool { return s.tty }
+func (*terminalSink) Close() error       { return nil }
+
+func TestConfigColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+
+	const (
+		plain   = "INFO\thello\n"
+		colored = "\x1b[34mINFO\x1b[0m\thello\n"
+	)
+
+	tests := []struct {
+		desc       string
+		tty        bool
+		levelEnc   zapcore.LevelEncoder
+		modify     func(*Config)
+		forceColor string
+		want       string
+		wantErr    string
+	}{
+		{desc: "color level encoder on a terminal", tty: true, levelEnc: zapcore.CapitalColorLevelEncoder, want: colored},
+		{desc: "color level encoder elsewhere", levelEnc: zapcore.CapitalColorLevelEncoder, want: plain},
+		{desc: "FORCE_COLOR", levelEnc: zapcore.CapitalColorLevelEncoder, forceColor: "1", want: colored},
+		{desc: "plain level encoder on a terminal", tty: true, levelEnc: zapcore.CapitalLevelEncoder, want: plain},
+		{
+			desc:     "DisableColor",
+			tty:      true,
+			levelEnc: zapcore.CapitalColorLevelEncoder,
+			modify:   func(cfg *Config) { cfg.DisableColor = true },
+			want:     plain,
+		},
+		{
+			desc:     "ForceColor",
+			levelEnc: zapcore.CapitalLevelEncoder,
+			modify:   func(cfg *Config) { cfg.ForceColor = true },
+			want:     colored,
+		},
+		{
+			desc:     "ForceColor in Outputs",
+			levelEnc: zapcore.CapitalLevelEncoder,
+			modify: func(cfg *Config) {
+				cfg.ForceColor = true
+				cfg.Outputs = []OutputConfig{{Paths: cfg.OutputPaths, Encoding: "console"}}
+				cfg.OutputPaths = nil
+			},
+			want: colored,
+		},
+		{
+			desc:     "both",
+			levelEnc: zapcore.CapitalLevelEncoder,
+			modify: func(cfg *Config) {
+				cfg.DisableColor = true
+				cfg.ForceColor = true
+			},
+			wantErr: "can't use both DisableColor and ForceColor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Setenv("FORCE_COLOR", tt.forceColor)
+			sink := &terminalSink{tty: tt.tty}
+			stubSinkRegistry(t)
+			require.NoError(t, RegisterSink("tty", func(*url.URL) (Sink, error) { return sink, nil }))
+
+			cfg := NewDevelopmentConfig()
+			cfg.EncoderConfig.TimeKey = ""
+			cfg.EncoderConfig.CallerKey = ""
+			cfg.EncoderConfig.EncodeLevel = tt.levelEnc
+			cfg.OutputPaths = []string{"tty://"}
+			if tt.modify != nil {
+				tt.modify(&cfg)
+			}
+
+			logger, err := cfg.Build()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			logger.Info("hello")
+			assert.Equal(t, tt.want, sink.String())
+		})
+	}
+}
+
+func TestNopCloserSinkIsTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	assert.False(t, nopCloserSink{w}.IsTerminal(), "Expected a pipe not to be a terminal.")
+	assert.False(t, nopCloserSink{&ztest.Buffer{}}.IsTerminal(), "Expected a buffer not to be a terminal.")
+}
+
 func makeSamplerCountingHook() (h func(zapcore.Entry, zapcore.SamplingDecision),
 	dropped, sampled *atomic.Int64,
 ) {
@@ -0,0 +1,132 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zaptest/observer"
+)
+
+type testCtxKey string
+
+// ctxValueField returns a ContextFields extractor that logs the value stored
+// under the given context key, if any.
+func ctxValueField(key testCtxKey) func(context.Context) []Field {
+	return func(ctx context.Context) []Field {
+		if v, ok := ctx.Value(key).(string); ok {
+			return []Field{String(string(key), v)}
+		}
+		return nil
+	}
+}
+
+func TestLoggerCtx(t *testing.T) {
+	ctx := context.WithValue(context.Background(), testCtxKey("traceID"), "abc")
+	ctx = context.WithValue(ctx, testCtxKey("requestID"), "123")
+
+	t.Run("no extractors", func(t *testing.T) {
+		withLogger(t, DebugLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+			assert.Same(t, logger, logger.Ctx(ctx), "Expected the logger to be returned unchanged.")
+		})
+	})
+
+	t.Run("nothing extracted", func(t *testing.T) {
+		opts := opts(ContextFields(ctxValueField("traceID")))
+		withLogger(t, DebugLevel, opts, func(logger *Logger, logs *observer.ObservedLogs) {
+			assert.Same(t, logger, logger.Ctx(context.Background()), "Expected the logger to be returned unchanged.")
+			var nilCtx context.Context
+			assert.Same(t, logger, logger.Ctx(nilCtx), "Expected the logger to be returned unchanged.")
+		})
+	})
+
+	t.Run("composed extractors", func(t *testing.T) {
+		opts := opts(
+			ContextFields(ctxValueField("traceID")),
+			ContextFields(ctxValueField("requestID"), ctxValueField("userID")),
+		)
+		withLogger(t, DebugLevel, opts, func(logger *Logger, logs *observer.ObservedLogs) {
+			logger.Ctx(ctx).Info("hello", Int("n", 1))
+			logger.Info("no context")
+
+			require.Equal(t, 2, logs.Len(), "Expected two entries.")
+			assert.Equal(t, []Field{
+				String("traceID", "abc"),
+				String("requestID", "123"),
+				Int("n", 1),
+			}, logs.AllUntimed()[0].Context, "Unexpected context fields.")
+			assert.Empty(t, logs.AllUntimed()[1].Context, "Expected parent logger to be unaffected.")
+		})
+	})
+
+	t.Run("children don't share extractors", func(t *testing.T) {
+		withLogger(t, DebugLevel, opts(ContextFields(ctxValueField("traceID"))), func(logger *Logger, logs *observer.ObservedLogs) {
+			a := logger.WithOptions(ContextFields(ctxValueField("requestID")))
+			b := logger.WithOptions(ContextFields(ctxValueField("userID")))
+
+			a.Ctx(ctx).Info("a")
+			b.Ctx(ctx).Info("b")
+
+			require.Equal(t, 2, logs.Len(), "Expected two entries.")
+			assert.Equal(t, []Field{String("traceID", "abc"), String("requestID", "123")}, logs.AllUntimed()[0].Context)
+			assert.Equal(t, []Field{String("traceID", "abc")}, logs.AllUntimed()[1].Context)
+		})
+	})
+}
+
+func TestSugaredLoggerCtx(t *testing.T) {
+	ctx := context.WithValue(context.Background(), testCtxKey("traceID"), "abc")
+
+	withSugar(t, DebugLevel, opts(ContextFields(ctxValueField("traceID"))), func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+		assert.Same(t, logger, logger.Ctx(context.Background()), "Expected the logger to be returned unchanged.")
+
+		logger.Ctx(ctx).Infow("hello", "n", 1)
+		require.Equal(t, 1, logs.Len(), "Expected one entry.")
+		assert.Equal(t, []Field{String("traceID", "abc"), Int("n", 1)}, logs.AllUntimed()[0].Context)
+	})
+}
+
+func TestNewContextFromContext(t *testing.T) {
+	t.Run("stored logger", func(t *testing.T) {
+		withLogger(t, DebugLevel, opts(ContextFields(ctxValueField("traceID"))), func(logger *Logger, logs *observer.ObservedLogs) {
+			ctx := NewContext(context.Background(), logger)
+			ctx = context.WithValue(ctx, testCtxKey("traceID"), "abc")
+
+			FromContext(ctx).Info("hello")
+			require.Equal(t, 1, logs.Len(), "Expected one entry.")
+			assert.Equal(t, []Field{String("traceID", "abc")}, logs.AllUntimed()[0].Context)
+		})
+	})
+
+	t.Run("global fallback", func(t *testing.T) {
+		core, logs := observer.New(zapcore.DebugLevel)
+		defer ReplaceGlobals(New(core))()
+
+		FromContext(context.Background()).Info("hello")
+		var nilCtx context.Context
+		FromContext(nilCtx).Info("nil")
+		assert.Equal(t, 2, logs.Len(), "Expected global logger to be used.")
+	})
+}
@@ -38,22 +38,45 @@ var (
 		"json": func(encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
 			return zapcore.NewJSONEncoder(encoderConfig), nil
 		},
+		"logfmt": func(encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			return zapcore.NewLogfmtEncoder(encoderConfig), nil
+		},
+		"gelf": func(encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			return zapcore.NewGELFEncoder(encoderConfig), nil
+		},
+		"journald": func(encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			return zapcore.NewJournaldEncoder(encoderConfig), nil
+		},
+		"ecs": func(encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			return zapcore.NewECSEncoder(encoderConfig), nil
+		},
+		"csv": func(encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			return zapcore.NewCSVEncoder(encoderConfig), nil
+		},
+		"tsv": func(encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			return zapcore.NewCSVEncoder(tsvEncoderConfig(encoderConfig)), nil
+		},
 	}
 	_encoderMutex sync.RWMutex
 )
 
 // RegisterEncoder registers an encoder constructor, which the Config struct
-// can then reference. By default, the "json" and "console" encoders are
-// registered.
+// can then reference. By default, the "json", "console", "logfmt", "gelf",
+// "journald", "ecs", "csv", and "tsv" encoders are registered.
 //
-// Attempting to register an encoder whose name is already taken returns an
-// error.
+// Attempting to register an encoder whose name is already taken, including
+// the names of the default encoders, returns an error. Encoders can't be
+// replaced or unregistered, so a name refers to the same encoder for the
+// life of the process.
 func RegisterEncoder(name string, constructor func(zapcore.EncoderConfig) (zapcore.Encoder, error)) error {
 	_encoderMutex.Lock()
 	defer _encoderMutex.Unlock()
 	if name == "" {
 		return errNoEncoderNameSpecified
 	}
+	if constructor == nil {
+		return fmt.Errorf("can't register a nil encoder constructor for name %q", name)
+	}
 	if _, ok := _encoderNameToConstructor[name]; ok {
 		return fmt.Errorf("encoder already registered for name %q", name)
 	}
@@ -77,3 +100,12 @@ func newEncoder(name string, encoderConfig zapcore.EncoderConfig) (zapcore.Encod
 	}
 	return constructor(encoderConfig)
 }
+
+// tsvEncoderConfig returns the configuration of the "tsv" encoder: the CSV
+// encoder with tab-separated columns, unless another delimiter is set.
+func tsvEncoderConfig(cfg zapcore.EncoderConfig) zapcore.EncoderConfig {
+	if cfg.CSV.Delimiter == "" {
+		cfg.CSV.Delimiter = "\t"
+	}
+	return cfg
+}
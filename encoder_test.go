@@ -21,15 +21,21 @@
 package zap
 
 import (
+	"bytes"
+	"errors"
+	"net/url"
 	"testing"
 
+	"github.com/toujourser/zap/buffer"
+	"github.com/toujourser/zap/internal/ztest"
 	"github.com/toujourser/zap/zapcore"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRegisterDefaultEncoders(t *testing.T) {
-	testEncodersRegistered(t, "console", "json")
+	testEncodersRegistered(t, "console", "json", "logfmt", "gelf", "journald", "ecs", "csv", "tsv")
 }
 
 func TestRegisterEncoder(t *testing.T) {
@@ -46,6 +52,80 @@ func TestDuplicateRegisterEncoder(t *testing.T) {
 	})
 }
 
+func TestRegisterEncoderNilConstructor(t *testing.T) {
+	testEncoders(func() {
+		assert.Error(t, RegisterEncoder("foo", nil), "expected an error when registering a nil constructor")
+		testEncodersRegistered(t)
+	})
+}
+
+func TestRegisterEncoderOverrideDefault(t *testing.T) {
+	for _, name := range []string{"console", "json", "logfmt", "gelf", "journald", "ecs", "csv", "tsv"} {
+		assert.Error(t, RegisterEncoder(name, newNilEncoder), "expected an error when overriding the %s encoder", name)
+	}
+	testEncodersRegistered(t, "console", "json", "logfmt", "gelf", "journald", "ecs", "csv", "tsv")
+}
+
+func TestConfigWithRegisteredEncoder(t *testing.T) {
+	testEncoders(func() {
+		var got zapcore.EncoderConfig
+		require.NoError(t, RegisterEncoder("upper", func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			got = cfg
+			return upperEncoder{zapcore.NewConsoleEncoder(cfg)}, nil
+		}))
+
+		sink := &ztest.Buffer{}
+		stubSinkRegistry(t)
+		require.NoError(t, RegisterSink("upper-test", func(*url.URL) (Sink, error) { return nopCloserSink{sink}, nil }))
+
+		cfg := NewProductionConfig()
+		cfg.Encoding = "upper"
+		cfg.EncoderConfig.TimeKey = ""
+		cfg.EncoderConfig.CallerKey = ""
+		cfg.OutputPaths = []string{"upper-test://"}
+		cfg.Sampling = nil
+
+		logger, err := cfg.Build()
+		require.NoError(t, err, "unexpected error building a logger with a registered encoder")
+		logger.Info("hello", String("k", "v"))
+
+		assert.Equal(t, "msg", got.MessageKey, "expected the constructor to receive the EncoderConfig")
+		assert.Equal(t, []string{`INFO	HELLO	{"K": "V"}`}, sink.Lines())
+	})
+
+	t.Run("constructor error", func(t *testing.T) {
+		testEncoders(func() {
+			require.NoError(t, RegisterEncoder("broken", func(zapcore.EncoderConfig) (zapcore.Encoder, error) {
+				return nil, errors.New("can't build encoder")
+			}))
+			cfg := NewProductionConfig()
+			cfg.Encoding = "broken"
+			_, err := cfg.Build()
+			assert.ErrorContains(t, err, "can't build encoder")
+		})
+	})
+}
+
+// upperEncoder upper-cases the entries encoded by another Encoder.
+type upperEncoder struct {
+	zapcore.Encoder
+}
+
+func (e upperEncoder) Clone() zapcore.Encoder {
+	return upperEncoder{e.Encoder.Clone()}
+}
+
+func (e upperEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+	upper := bytes.ToUpper(buf.Bytes())
+	buf.Reset()
+	buf.AppendBytes(upper)
+	return buf, nil
+}
+
 func TestRegisterEncoderNoName(t *testing.T) {
 	assert.Equal(t, errNoEncoderNameSpecified, RegisterEncoder("", newNilEncoder), "expected an error when registering an encoder with no name")
 }
@@ -21,10 +21,20 @@
 package zap
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
 	"github.com/toujourser/zap/internal/pool"
+	"github.com/toujourser/zap/internal/stacktrace"
 	"github.com/toujourser/zap/zapcore"
 )
 
+// _defaultErrorChainDepth is the number of errors ErrorChain logs along any
+// path through an error tree.
+const _defaultErrorChainDepth = 10
+
 var _errArrayElemPool = pool.New(func() *errArrayElem {
 	return &errArrayElem{}
 })
@@ -48,6 +58,70 @@ func NamedError(key string, err error) Field {
 	return Field{Key: key, Type: zapcore.ErrorType, Interface: err}
 }
 
+// ErrorWithStack is like Error, but ensures that the error is logged with a
+// stack trace under "errorStack". If err, or an error it wraps, already
+// records where it was created (see zapcore.StackTracer), that stack trace is
+// used; otherwise, the stack of the caller of ErrorWithStack is captured.
+// If passed a nil error, the field is a no-op.
+func ErrorWithStack(err error) Field {
+	if err == nil {
+		return Skip()
+	}
+	if len(stacktrace.FromError(err)) == 0 {
+		err = withStack(err, stacktrace.Callers(1))
+	}
+	return NamedError("error", err)
+}
+
+// withStack attaches a stack trace to an error that doesn't have one,
+// preserving its verbose form and, for error groups, its causes.
+func withStack(err error, pcs []uintptr) error {
+	se := stackError{err: err, pcs: pcs}
+	if group, ok := err.(errorGroup); ok {
+		return &stackErrorGroup{stackError: se, group: group}
+	}
+	return &se
+}
+
+type stackError struct {
+	err error
+	pcs []uintptr
+}
+
+var _ zapcore.StackTracer = (*stackError)(nil)
+
+func (e *stackError) Error() string         { return e.err.Error() }
+func (e *stackError) Unwrap() error         { return e.err }
+func (e *stackError) StackTrace() []uintptr { return e.pcs }
+
+// Format defers to the wrapped error so that its verbose form, if any, is
+// still logged.
+func (e *stackError) Format(s fmt.State, verb rune) {
+	if f, ok := e.err.(fmt.Formatter); ok {
+		f.Format(s, verb)
+		return
+	}
+	switch verb {
+	case 'v', 's':
+		_, _ = io.WriteString(s, e.err.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.err.Error())
+	}
+}
+
+// errorGroup matches the multierr interface that zapcore logs as causes.
+type errorGroup interface {
+	Errors() []error
+}
+
+type stackErrorGroup struct {
+	stackError
+
+	group errorGroup
+}
+
+func (e *stackErrorGroup) Errors() []error { return e.group.Errors() }
+
 type errArray []error
 
 func (errs errArray) MarshalLogArray(arr zapcore.ArrayEncoder) error {
@@ -80,3 +154,189 @@ func (e *errArrayElem) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	Error(e.error).AddTo(enc)
 	return nil
 }
+
+// ErrorsDetailed is like Errors, but logs each error as an object with its
+// index in errs, its type name, and its message. Errors that implement
+// zapcore.ObjectMarshaler also add their own fields to their object. Nil
+// errors are logged as null rather than skipped, so the array stays aligned
+// with errs.
+//
+//	[
+//	  {"index": 0, "message": "timeout", "type": "*errors.errorString"},
+//	  null,
+//	  {"index": 2, "message": "open app.yaml: permission denied", "type": "*fs.PathError"}
+//	]
+func ErrorsDetailed(key string, errs []error) Field {
+	return Array(key, errDetailedArray(errs))
+}
+
+type errDetailedArray []error
+
+func (errs errDetailedArray) MarshalLogArray(arr zapcore.ArrayEncoder) error {
+	for i, err := range errs {
+		if err == nil {
+			if aerr := arr.AppendReflected(nil); aerr != nil {
+				return aerr
+			}
+			continue
+		}
+		if aerr := arr.AppendObject(errDetailedElem{index: i, err: err}); aerr != nil {
+			return aerr
+		}
+	}
+	return nil
+}
+
+// errDetailedElem encodes a single element of ErrorsDetailed.
+type errDetailedElem struct {
+	index int
+	err   error
+}
+
+func (e errDetailedElem) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("index", e.index)
+	return errChainElem{e.err}.MarshalLogObject(enc)
+}
+
+// ErrorChain constructs a field that carries err and every error it wraps,
+// as an array of objects with each error's message and type name. Errors
+// that implement zapcore.ObjectMarshaler also add their own fields to their
+// object.
+//
+// The chain is followed through Unwrap() error. Errors that wrap several
+// errors, such as those produced by errors.Join or go.uber.org/multierr, are
+// logged as an object whose "causes" array holds the chain of each wrapped
+// error, rather than as one concatenated message.
+//
+//	[
+//	  {"message": "read config: open app.yaml: permission denied", "type": "*fmt.wrapError"},
+//	  {"message": "open app.yaml: permission denied", "type": "*fs.PathError"},
+//	  {"message": "permission denied", "type": "syscall.Errno"}
+//	]
+//
+// At most 10 errors are logged along any path through the chain, which
+// guards against cycles; use ErrorChainDepth to change the limit. If passed
+// a nil error, the field is a no-op.
+func ErrorChain(key string, err error) Field {
+	return ErrorChainDepth(key, err, _defaultErrorChainDepth)
+}
+
+// ErrorChainDepth is like ErrorChain, but logs at most maxDepth errors along
+// any path through the chain. If the chain is longer, a {"truncated": true}
+// object is logged in place of the remaining errors. Non-positive values use
+// the default limit.
+func ErrorChainDepth(key string, err error, maxDepth int) Field {
+	if err == nil {
+		return Skip()
+	}
+	if maxDepth <= 0 {
+		maxDepth = _defaultErrorChainDepth
+	}
+	return Array(key, errChain{err: err, depth: maxDepth})
+}
+
+// errChain encodes an error and the errors it wraps as an array of objects.
+type errChain struct {
+	err   error
+	depth int // remaining number of errors to log
+}
+
+func (c errChain) MarshalLogArray(arr zapcore.ArrayEncoder) error {
+	for err, depth := c.err, c.depth; err != nil; err, depth = errors.Unwrap(err), depth-1 {
+		if depth == 0 {
+			return arr.AppendObject(errChainTruncated{})
+		}
+
+		if errs, ok := unwrapAll(err); ok {
+			// Errors that wrap several errors end the chain; each wrapped error
+			// starts a chain of its own.
+			return arr.AppendObject(errChainGroup{err: err, errs: errs, depth: depth - 1})
+		}
+
+		if err := arr.AppendObject(errChainElem{err}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unwrapAll returns the errors wrapped by an error that wraps several errors.
+func unwrapAll(err error) ([]error, bool) {
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		return e.Unwrap(), true
+	case interface{ Errors() []error }:
+		return e.Errors(), true
+	}
+	return nil, false
+}
+
+// errChainElem encodes a single error in a chain.
+type errChainElem struct {
+	err error
+}
+
+func (e errChainElem) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	msg, isNil := errorMessage(e.err)
+	enc.AddString("message", msg)
+	enc.AddString("type", fmt.Sprintf("%T", e.err))
+	if m, ok := e.err.(zapcore.ObjectMarshaler); ok && !isNil {
+		return m.MarshalLogObject(enc)
+	}
+	return nil
+}
+
+// errChainGroup encodes an error that wraps several errors.
+type errChainGroup struct {
+	err   error
+	errs  []error
+	depth int
+}
+
+func (g errChainGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("type", fmt.Sprintf("%T", g.err))
+	if m, ok := g.err.(zapcore.ObjectMarshaler); ok {
+		if err := m.MarshalLogObject(enc); err != nil {
+			return err
+		}
+	}
+	return enc.AddArray("causes", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		for _, err := range g.errs {
+			if err == nil {
+				continue
+			}
+			if g.depth == 0 {
+				return arr.AppendObject(errChainTruncated{})
+			}
+			if err := arr.AppendArray(errChain{err: err, depth: g.depth}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// errChainTruncated marks the point at which a chain exceeded its depth.
+type errChainTruncated struct{}
+
+func (errChainTruncated) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddBool("truncated", true)
+	return nil
+}
+
+// errorMessage returns err.Error(), recovering from panics in the same way
+// as zapcore's error encoding: "<nil>" for nil pointers, and the panic
+// value otherwise. isNil reports whether err was a nil pointer that
+// panicked.
+func errorMessage(err error) (msg string, isNil bool) {
+	defer func() {
+		if rerr := recover(); rerr != nil {
+			if v := reflect.ValueOf(err); v.Kind() == reflect.Ptr && v.IsNil() {
+				msg, isNil = "<nil>", true
+				return
+			}
+			msg = fmt.Sprintf("PANIC=%v", rerr)
+		}
+	}()
+	return err.Error(), false
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/toujourser/zap/zapcore"
+
+	"go.uber.org/multierr"
+)
+
+// newEncodedErrorHandler returns an ErrorHandler that encodes each failure
+// with enc and writes it to out as an Error-level entry. The failed entry's
+// metadata is nested under the "entry" key; its fields are omitted, since
+// they may be what failed to encode.
+//
+// Errors from Logger.Sync are reported as "sync error" entries, without the
+// "entry" key, and entries that Logger.Check rejects, such as those with an
+// invalid level, as "check error" entries.
+func newEncodedErrorHandler(enc zapcore.Encoder, out zapcore.WriteSyncer) func(error, zapcore.Entry) {
+	return func(err error, ent zapcore.Entry) {
+		report := zapcore.Entry{
+			LoggerName: ent.LoggerName,
+			Time:       ent.Time,
+			Level:      zapcore.ErrorLevel,
+			Message:    "write error",
+		}
+		fields := []Field{Error(err), Object("entry", failedEntry(ent))}
+		var (
+			serr syncError
+			cerr checkError
+		)
+		switch {
+		case errors.As(err, &serr):
+			report.Message = "sync error"
+			fields = []Field{Error(serr.error)}
+		case errors.As(err, &cerr):
+			report.Message = "check error"
+			fields[0] = Error(cerr.error)
+		}
+		buf, encErr := enc.EncodeEntry(report, fields)
+		if encErr != nil {
+			// Don't try anything clever: fall back to the plain-text format.
+			_, _ = fmt.Fprintf(out, "%v %s: %v\n", ent.Time, report.Message, multierr.Append(err, encErr))
+			_ = out.Sync()
+			return
+		}
+		_, _ = out.Write(buf.Bytes())
+		buf.Free()
+		_ = out.Sync()
+	}
+}
+
+// syncError marks the errors from Logger.Sync passed to an ErrorHandler, so
+// that handlers can tell them apart from write errors.
+type syncError struct{ error }
+
+func (e syncError) Unwrap() error { return e.error }
+
+// checkError marks the errors found by Logger.Check passed to an
+// ErrorHandler, such as invalid levels.
+type checkError struct{ error }
+
+func (e checkError) Unwrap() error { return e.error }
+
+// isIgnorableSyncError reports whether err only indicates that some sinks
+// can't be synced, such as terminals and pipes.
+func isIgnorableSyncError(err error) bool {
+	var serr *zapcore.SyncError
+	return errors.As(err, &serr) && serr.Ignorable()
+}
+
+// failedEntry marshals the metadata of an entry that couldn't be written.
+type failedEntry zapcore.Entry
+
+func (e failedEntry) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("level", e.Level.String())
+	enc.AddTime("time", e.Time)
+	if e.LoggerName != "" {
+		enc.AddString("logger", e.LoggerName)
+	}
+	if e.Caller.Defined {
+		enc.AddString("caller", e.Caller.TrimmedPath())
+	}
+	enc.AddString("msg", e.Message)
+	return nil
+}
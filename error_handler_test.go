@@ -0,0 +1,287 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toujourser/zap/internal/ztest"
+	"github.com/toujourser/zap/zapcore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFailingLogger returns a Logger whose only sink always fails to write,
+// reporting internal errors to errOut.
+func newFailingLogger(errOut zapcore.WriteSyncer, opts ...Option) *Logger {
+	core := zapcore.NewCore(
+		zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"}),
+		&ztest.FailWriter{},
+		DebugLevel,
+	)
+	return New(core, append([]Option{ErrorOutput(errOut)}, opts...)...)
+}
+
+func TestErrorHandler(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		errOut := &ztest.Buffer{}
+		newFailingLogger(errOut).Info("hello")
+
+		lines := errOut.Lines()
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], "write error: failed")
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		var (
+			gotErr error
+			gotEnt zapcore.Entry
+		)
+		errOut := &ztest.Buffer{}
+		logger := newFailingLogger(errOut, ErrorHandler(func(err error, ent zapcore.Entry) {
+			gotErr = err
+			gotEnt = ent
+		}))
+		logger.Named("svc").Warn("hello")
+
+		assert.Empty(t, errOut.Lines(), "Expected no plain-text error output.")
+		assert.EqualError(t, gotErr, "failed")
+		assert.Equal(t, "svc", gotEnt.LoggerName)
+		assert.Equal(t, WarnLevel, gotEnt.Level)
+		assert.Equal(t, "hello", gotEnt.Message)
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		var (
+			gotErr error
+			gotEnt zapcore.Entry
+		)
+		ws := &ztest.FailWriter{}
+		ws.SetError(errors.New("sync failed"))
+		core := zapcore.NewCore(zapcore.NewJSONEncoder(zapcore.EncoderConfig{}), ws, DebugLevel)
+		logger := New(core, ErrorHandler(func(err error, ent zapcore.Entry) {
+			gotErr = err
+			gotEnt = ent
+		})).Named("svc")
+
+		assert.EqualError(t, logger.Sync(), "sync failed", "Expected Sync to return the error.")
+		assert.EqualError(t, gotErr, "sync failed", "Expected the handler to receive the error.")
+		assert.Equal(t, "svc", gotEnt.LoggerName)
+		assert.False(t, gotEnt.Time.IsZero(), "Expected the time of the failure.")
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		var (
+			gotErr error
+			gotEnt zapcore.Entry
+		)
+		errOut := &ztest.Buffer{}
+		logger := New(zapcore.NewNopCore(), ErrorOutput(errOut), ErrorHandler(func(err error, ent zapcore.Entry) {
+			gotErr = err
+			gotEnt = ent
+		})).Named("svc")
+
+		assert.Nil(t, logger.Check(zapcore.Level(42), "hello"), "Expected no CheckedEntry for an invalid level.")
+		assert.Empty(t, errOut.Lines(), "Expected no plain-text error output.")
+		assert.EqualError(t, gotErr, `invalid level Level(42) for message "hello"`)
+		assert.Equal(t, "svc", gotEnt.LoggerName)
+		assert.Equal(t, zapcore.Level(42), gotEnt.Level)
+		assert.Equal(t, "hello", gotEnt.Message)
+	})
+
+	t.Run("sync handler panics", func(t *testing.T) {
+		errOut := &ztest.Buffer{}
+		ws := &ztest.FailWriter{}
+		ws.SetError(errors.New("sync failed"))
+		core := zapcore.NewCore(zapcore.NewJSONEncoder(zapcore.EncoderConfig{}), ws, DebugLevel)
+		logger := New(core, ErrorOutput(errOut), ErrorHandler(func(error, zapcore.Entry) {
+			panic("oh no")
+		}))
+
+		assert.NotPanics(t, func() { assert.Error(t, logger.Sync()) })
+		lines := errOut.Lines()
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], "sync error: sync failed; error handler panicked: oh no")
+	})
+
+	t.Run("handler logs to failing logger", func(t *testing.T) {
+		errOut := &ztest.Buffer{}
+		var (
+			logger *Logger
+			calls  int
+		)
+		logger = newFailingLogger(errOut, ErrorHandler(func(err error, ent zapcore.Entry) {
+			calls++
+			logger.Error("couldn't log", Error(err))
+		}))
+		logger.Info("hello")
+
+		assert.Equal(t, 1, calls, "Expected the handler not to recurse.")
+		lines := errOut.Lines()
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], "write error: failed")
+	})
+
+	t.Run("handler panics", func(t *testing.T) {
+		errOut := &ztest.Buffer{}
+		logger := newFailingLogger(errOut, ErrorHandler(func(error, zapcore.Entry) {
+			panic("oh no")
+		}))
+		assert.NotPanics(t, func() { logger.Info("hello") })
+
+		lines := errOut.Lines()
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], "write error: failed; error handler panicked: oh no")
+	})
+}
+
+func TestConfigErrorHandler(t *testing.T) {
+	stubSinkRegistry(t)
+
+	errOut := &ztest.Buffer{}
+	require.NoError(t, RegisterSink("failing", func(*url.URL) (Sink, error) {
+		return nopCloserSink{&ztest.FailWriter{}}, nil
+	}))
+	require.NoError(t, RegisterSink("errors", func(*url.URL) (Sink, error) {
+		return nopCloserSink{errOut}, nil
+	}))
+
+	cfg := NewProductionConfig()
+	cfg.OutputPaths = []string{"failing://"}
+	cfg.ErrorOutputPaths = []string{"errors://"}
+	cfg.EncoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
+	cfg.DisableCaller = true
+	logger, err := cfg.Build(WithClock(constantClock(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC))))
+	require.NoError(t, err)
+
+	logger.Named("svc").Info("hello", String("k", "v"))
+
+	lines := errOut.Lines()
+	require.Len(t, lines, 1)
+	assert.JSONEq(t, `{
+		"level": "error",
+		"ts": "2023-01-02T03:04:05Z",
+		"logger": "svc",
+		"msg": "write error",
+		"error": "failed",
+		"entry": {
+			"level": "info",
+			"time": "2023-01-02T03:04:05Z",
+			"logger": "svc",
+			"msg": "hello"
+		}
+	}`, lines[0])
+	assert.False(t, strings.Contains(lines[0], `"k"`), "Expected the entry's fields to be omitted.")
+
+	t.Run("overridden", func(t *testing.T) {
+		var called bool
+		logger, err := cfg.Build(ErrorHandler(func(error, zapcore.Entry) { called = true }))
+		require.NoError(t, err)
+		logger.Info("hello")
+		assert.True(t, called, "Expected the handler passed to Build to replace the default.")
+	})
+
+	t.Run("sync error", func(t *testing.T) {
+		errOut.Reset()
+		ws := &ztest.Buffer{}
+		ws.SetError(errors.New("disk full"))
+		require.NoError(t, RegisterSink("unsyncable", func(*url.URL) (Sink, error) {
+			return nopCloserSink{ws}, nil
+		}))
+		cfg := cfg
+		cfg.OutputPaths = []string{"unsyncable://"}
+		logger, err := cfg.Build(WithClock(constantClock(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC))))
+		require.NoError(t, err)
+
+		assert.Error(t, logger.Named("svc").Sync())
+		lines := errOut.Lines()
+		require.Len(t, lines, 1)
+		assert.JSONEq(t, `{
+			"level": "error",
+			"ts": "2023-01-02T03:04:05Z",
+			"logger": "svc",
+			"msg": "sync error",
+			"error": "disk full"
+		}`, lines[0])
+	})
+
+	t.Run("check error", func(t *testing.T) {
+		errOut.Reset()
+		logger.Named("svc").Log(zapcore.Level(42), "hello")
+		lines := errOut.Lines()
+		require.Len(t, lines, 1)
+		assert.JSONEq(t, `{
+			"level": "error",
+			"ts": "2023-01-02T03:04:05Z",
+			"logger": "svc",
+			"msg": "check error",
+			"error": "invalid level Level(42) for message \"hello\"",
+			"entry": {
+				"level": "Level(42)",
+				"time": "2023-01-02T03:04:05Z",
+				"logger": "svc",
+				"msg": "hello"
+			}
+		}`, lines[0])
+	})
+
+	t.Run("handler write fails", func(t *testing.T) {
+		handle := newEncodedErrorHandler(zapcore.NewJSONEncoder(cfg.EncoderConfig), &ztest.FailWriter{})
+		assert.NotPanics(t, func() { handle(errors.New("sadness"), zapcore.Entry{}) })
+	})
+}
+
+func TestConfigSyncStdoutIsQuiet(t *testing.T) {
+	stubSinkRegistry(t)
+
+	// Make sure stdout is a pipe, which can't be synced.
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	errOut := &ztest.Buffer{}
+	require.NoError(t, RegisterSink("errors", func(*url.URL) (Sink, error) {
+		return nopCloserSink{errOut}, nil
+	}))
+
+	cfg := NewProductionConfig()
+	cfg.OutputPaths = []string{"stdout"}
+	cfg.ErrorOutputPaths = []string{"errors://"}
+	logger, err := cfg.Build()
+	require.NoError(t, err)
+
+	var serr *zapcore.SyncError
+	if err := logger.Sync(); err != nil {
+		require.ErrorAs(t, err, &serr)
+		assert.True(t, serr.Ignorable(), "Expected only ignorable sync errors.")
+	}
+	assert.Empty(t, errOut.String(), "Expected nothing written to the error output.")
+}
@@ -23,12 +23,15 @@ package zap
 import (
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/toujourser/zap/zapcore"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
 )
 
 func TestErrorConstructors(t *testing.T) {
@@ -55,6 +58,62 @@ func TestErrorConstructors(t *testing.T) {
 	}
 }
 
+func TestErrorWithStack(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		assert.Equal(t, Skip(), ErrorWithStack(nil))
+	})
+
+	t.Run("captures stack", func(t *testing.T) {
+		fail := errors.New("fail")
+		f := ErrorWithStack(fail)
+		assert.True(t, errors.Is(f.Interface.(error), fail), "Expected the error to wrap the original.")
+
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		assert.Equal(t, "fail", enc.Fields["error"], "Unexpected error message.")
+		stack, ok := enc.Fields["errorStack"].(string)
+		require.True(t, ok, "Expected a stack trace, got %v.", enc.Fields)
+		assert.True(t, strings.HasPrefix(stack, "github.com/toujourser/zap.TestErrorWithStack.func2\n"),
+			"Expected the stack to start at the caller, got:\n%s", stack)
+	})
+
+	t.Run("keeps existing stack", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", &stackError{err: errors.New("fail"), pcs: []uintptr{1}})
+		f := ErrorWithStack(err)
+		assert.Equal(t, NamedError("error", err), f, "Expected an error with a stack to be logged as-is.")
+	})
+
+	t.Run("preserves verbose and causes", func(t *testing.T) {
+		enc := zapcore.NewMapObjectEncoder()
+		ErrorWithStack(multierr.Combine(errors.New("foo"), errors.New("bar"))).AddTo(enc)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"error": "foo"},
+			map[string]interface{}{"error": "bar"},
+		}, enc.Fields["errorCauses"], "Expected causes to be logged.")
+		assert.Contains(t, enc.Fields, "errorStack", "Expected a stack trace.")
+
+		enc = zapcore.NewMapObjectEncoder()
+		ErrorWithStack(verboseError{}).AddTo(enc)
+		assert.Equal(t, "verbose error", enc.Fields["errorVerbose"], "Expected the verbose form to be logged.")
+		assert.Contains(t, enc.Fields, "errorStack", "Expected a stack trace.")
+
+		enc = zapcore.NewMapObjectEncoder()
+		ErrorWithStack(errors.New("plain")).AddTo(enc)
+		assert.NotContains(t, enc.Fields, "errorVerbose", "Unexpected verbose form for a plain error.")
+	})
+}
+
+type verboseError struct{}
+
+func (verboseError) Error() string { return "error" }
+
+func (e verboseError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		_, _ = io.WriteString(s, "verbose ")
+	}
+	_, _ = io.WriteString(s, e.Error())
+}
+
 func TestErrorArrayConstructor(t *testing.T) {
 	tests := []struct {
 		desc     string
@@ -96,6 +155,67 @@ func TestErrorsArraysHandleRichErrors(t *testing.T) {
 	assert.Equal(t, "egad", errMap["error"], "Unexpected standard error string.")
 }
 
+func TestErrorsDetailed(t *testing.T) {
+	base := errors.New("base")
+	elem := func(i int, msg, typ string) map[string]interface{} {
+		return map[string]interface{}{"index": i, "message": msg, "type": typ}
+	}
+
+	tests := []struct {
+		desc string
+		errs []error
+		want interface{}
+	}{
+		{"nil slice", nil, []interface{}{}},
+		{"all nil", []error{nil, nil}, []interface{}{nil, nil}},
+		{
+			desc: "mixed",
+			errs: []error{
+				nil,
+				fmt.Errorf("wrapped: %w", base),
+				&chainTestError{code: 42},
+				nil,
+				(*chainTestError)(nil),
+			},
+			want: []interface{}{
+				nil,
+				elem(1, "wrapped: base", "*fmt.wrapError"),
+				map[string]interface{}{"index": 2, "message": "code 42", "type": "*zap.chainTestError", "code": 42},
+				nil,
+				elem(4, "<nil>", "*zap.chainTestError"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := zapcore.NewMapObjectEncoder()
+			ErrorsDetailed("k", tt.errs).AddTo(enc)
+			assert.Equal(t, tt.want, enc.Fields["k"], "Unexpected detailed errors.")
+			assert.Len(t, enc.Fields, 1, "Found extra keys in map: %v", enc.Fields)
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{})
+		buf, err := enc.EncodeEntry(zapcore.Entry{}, []Field{
+			ErrorsDetailed("errs", []error{base, nil, &chainTestError{code: 7}}),
+		})
+		require.NoError(t, err, "Unexpected encoding error.")
+		assert.Equal(t,
+			`{"errs":[{"index":0,"message":"base","type":"*errors.errorString"},null,`+
+				`{"index":2,"message":"code 7","type":"*zap.chainTestError","code":7}]}`+"\n",
+			buf.String(), "Unexpected JSON output.")
+	})
+
+	t.Run("compact default", func(t *testing.T) {
+		enc := zapcore.NewMapObjectEncoder()
+		Errors("k", []error{nil, base}).AddTo(enc)
+		assert.Equal(t, []interface{}{map[string]interface{}{"error": "base"}}, enc.Fields["k"],
+			"Expected Errors to keep skipping nil errors.")
+	})
+}
+
 func TestErrArrayBrokenEncoder(t *testing.T) {
 	t.Parallel()
 
@@ -131,3 +251,145 @@ func (enc brokenArrayObjectEncoder) AddArray(key string, marshaler zapcore.Array
 func (enc brokenArrayObjectEncoder) AppendObject(zapcore.ObjectMarshaler) error {
 	return enc.Err
 }
+
+type chainTestError struct {
+	code int
+}
+
+func (e *chainTestError) Error() string {
+	return fmt.Sprintf("code %d", e.code)
+}
+
+func (e *chainTestError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("code", e.code)
+	return nil
+}
+
+type chainJoinError []error
+
+func (e chainJoinError) Error() string   { return "joined" }
+func (e chainJoinError) Unwrap() []error { return e }
+
+// cyclicError wraps itself.
+type cyclicError struct{}
+
+func (e *cyclicError) Error() string { return "cycle" }
+func (e *cyclicError) Unwrap() error { return e }
+
+func TestErrorChain(t *testing.T) {
+	base := errors.New("base")
+	wrapped := fmt.Errorf("wrapped: %w", base)
+
+	entry := func(msg, typ string) map[string]interface{} {
+		return map[string]interface{}{"message": msg, "type": typ}
+	}
+
+	tests := []struct {
+		desc  string
+		field Field
+		want  interface{}
+	}{
+		{
+			desc:  "single error",
+			field: ErrorChain("k", base),
+			want:  []interface{}{entry("base", "*errors.errorString")},
+		},
+		{
+			desc:  "wrapped",
+			field: ErrorChain("k", fmt.Errorf("outer: %w", wrapped)),
+			want: []interface{}{
+				entry("outer: wrapped: base", "*fmt.wrapError"),
+				entry("wrapped: base", "*fmt.wrapError"),
+				entry("base", "*errors.errorString"),
+			},
+		},
+		{
+			desc:  "object marshaler",
+			field: ErrorChain("k", fmt.Errorf("outer: %w", &chainTestError{code: 42})),
+			want: []interface{}{
+				entry("outer: code 42", "*fmt.wrapError"),
+				map[string]interface{}{"message": "code 42", "type": "*zap.chainTestError", "code": 42},
+			},
+		},
+		{
+			desc:  "joined",
+			field: ErrorChain("k", fmt.Errorf("outer: %w", chainJoinError{wrapped, nil, errors.New("other")})),
+			want: []interface{}{
+				entry("outer: joined", "*fmt.wrapError"),
+				map[string]interface{}{
+					"type": "zap.chainJoinError",
+					"causes": []interface{}{
+						[]interface{}{
+							entry("wrapped: base", "*fmt.wrapError"),
+							entry("base", "*errors.errorString"),
+						},
+						[]interface{}{entry("other", "*errors.errorString")},
+					},
+				},
+			},
+		},
+		{
+			desc:  "multierr",
+			field: ErrorChain("k", multierr.Combine(base, errors.New("other"))),
+			want: []interface{}{
+				map[string]interface{}{
+					"type": "*multierr.multiError",
+					"causes": []interface{}{
+						[]interface{}{entry("base", "*errors.errorString")},
+						[]interface{}{entry("other", "*errors.errorString")},
+					},
+				},
+			},
+		},
+		{
+			desc:  "cycle",
+			field: ErrorChainDepth("k", &cyclicError{}, 2),
+			want: []interface{}{
+				entry("cycle", "*zap.cyclicError"),
+				entry("cycle", "*zap.cyclicError"),
+				map[string]interface{}{"truncated": true},
+			},
+		},
+		{
+			desc:  "truncated in group",
+			field: ErrorChainDepth("k", chainJoinError{wrapped}, 2),
+			want: []interface{}{
+				map[string]interface{}{
+					"type": "zap.chainJoinError",
+					"causes": []interface{}{
+						[]interface{}{
+							entry("wrapped: base", "*fmt.wrapError"),
+							map[string]interface{}{"truncated": true},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:  "nil pointer",
+			field: ErrorChain("k", (*chainTestError)(nil)),
+			want:  []interface{}{entry("<nil>", "*zap.chainTestError")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := zapcore.NewMapObjectEncoder()
+			tt.field.AddTo(enc)
+			assert.Equal(t, tt.want, enc.Fields["k"], "Unexpected error chain.")
+			assert.Len(t, enc.Fields, 1, "Found extra keys in map: %v", enc.Fields)
+		})
+	}
+}
+
+func TestErrorChainDefaultDepth(t *testing.T) {
+	assert.Equal(t, Skip(), ErrorChain("k", nil), "Expected nil errors to be skipped.")
+	assert.Equal(t, ErrorChain("k", &cyclicError{}), ErrorChainDepth("k", &cyclicError{}, 0),
+		"Expected non-positive depths to use the default.")
+
+	enc := zapcore.NewMapObjectEncoder()
+	ErrorChain("k", &cyclicError{}).AddTo(enc)
+	chain, ok := enc.Fields["k"].([]interface{})
+	require.True(t, ok, "Expected an array of errors.")
+	assert.Len(t, chain, _defaultErrorChainDepth+1, "Expected the default depth and a truncation marker.")
+}
@@ -22,6 +22,8 @@
 package zapfield
 
 import (
+	"sort"
+
 	"github.com/toujourser/zap"
 	"github.com/toujourser/zap/zapcore"
 )
@@ -44,3 +46,146 @@ func (a stringArray[T]) MarshalLogArray(enc zapcore.ArrayEncoder) error {
 func Strs[K ~string, V ~[]S, S ~string](k K, v V) zap.Field {
 	return zap.Array(string(k), stringArray[S](v))
 }
+
+// Num constructs a field with the given string-like key and a value of a
+// numeric named type, using the zap field constructor for the value's
+// underlying type rather than reflection.
+func Num[K ~string, V ~int | ~int64 | ~uint64 | ~float64](k K, v V) zap.Field {
+	switch numKindOf[V]() {
+	case floatKind:
+		return zap.Float64(string(k), float64(v))
+	case uintKind:
+		return zap.Uint64(string(k), uint64(v))
+	default:
+		return zap.Int64(string(k), int64(v))
+	}
+}
+
+// Nums constructs a field that carries a slice of values of a numeric named
+// type.
+func Nums[K ~string, V ~[]N, N ~int | ~int64 | ~uint64 | ~float64](k K, v V) zap.Field {
+	return zap.Array(string(k), numArray[N](v))
+}
+
+type numArray[N ~int | ~int64 | ~uint64 | ~float64] []N
+
+func (a numArray[N]) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	switch numKindOf[N]() {
+	case floatKind:
+		for i := range a {
+			enc.AppendFloat64(float64(a[i]))
+		}
+	case uintKind:
+		for i := range a {
+			enc.AppendUint64(uint64(a[i]))
+		}
+	default:
+		for i := range a {
+			enc.AppendInt64(int64(a[i]))
+		}
+	}
+	return nil
+}
+
+type numKind int
+
+const (
+	intKind numKind = iota
+	uintKind
+	floatKind
+)
+
+// numKindOf reports whether N's underlying type is a signed integer, an
+// unsigned integer, or a floating-point number, without reflection.
+func numKindOf[N ~int | ~int64 | ~uint64 | ~float64]() numKind {
+	var zero, one N = 0, 1
+	switch {
+	case one/2 != zero:
+		return floatKind
+	case zero-one > zero:
+		return uintKind
+	default:
+		return intKind
+	}
+}
+
+// Enum constructs a field with the given string-like key and the string
+// form of an enum value. Unlike zap.Stringer, Enum calls v.String
+// immediately, which avoids allocating for the common case of enums with
+// constant names.
+func Enum[K ~string, V interface{ String() string }](k K, v V) zap.Field {
+	return zap.String(string(k), v.String())
+}
+
+// EnumCode is like Enum, but also logs the enum's numeric value under the
+// key with a "Code" suffix:
+//
+//	zapfield.EnumCode("status", StatusNotFound)
+//	// {"status": "not found", "statusCode": 404}
+func EnumCode[K ~string, V interface {
+	~int | ~int64 | ~uint64
+	String() string
+}](k K, v V) zap.Field {
+	return zap.Inline(enumObject[V]{key: string(k), name: v.String(), code: v})
+}
+
+type enumObject[V ~int | ~int64 | ~uint64] struct {
+	key  string
+	name string
+	code V
+}
+
+func (o enumObject[V]) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString(o.key, o.name)
+	if numKindOf[V]() == uintKind {
+		enc.AddUint64(o.key+"Code", uint64(o.code))
+	} else {
+		enc.AddInt64(o.key+"Code", int64(o.code))
+	}
+	return nil
+}
+
+// Map constructs a field that carries a map with string-like keys, encoded as
+// an object with its keys in sorted order. encodeValue adds each value to the
+// object under its key:
+//
+//	zapfield.Map("limits", limits, func(enc zapcore.ObjectEncoder, k string, v Limit) {
+//	  enc.AddInt(k, v.Max)
+//	})
+//
+// A nil map is encoded as an empty object. Use UnsortedMap if the order of
+// the keys doesn't matter, to avoid sorting them.
+func Map[K ~string, V any](key string, m map[K]V, encodeValue func(zapcore.ObjectEncoder, string, V)) zap.Field {
+	return zap.Object(key, mapObject[K, V]{m: m, encode: encodeValue, sorted: true})
+}
+
+// UnsortedMap is like Map, but encodes the entries of the map in an
+// unspecified order, which differs between calls.
+func UnsortedMap[K ~string, V any](key string, m map[K]V, encodeValue func(zapcore.ObjectEncoder, string, V)) zap.Field {
+	return zap.Object(key, mapObject[K, V]{m: m, encode: encodeValue})
+}
+
+type mapObject[K ~string, V any] struct {
+	m      map[K]V
+	encode func(zapcore.ObjectEncoder, string, V)
+	sorted bool
+}
+
+func (o mapObject[K, V]) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if !o.sorted {
+		for k, v := range o.m {
+			o.encode(enc, string(k), v)
+		}
+		return nil
+	}
+
+	keys := make([]string, 0, len(o.m))
+	for k := range o.m {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		o.encode(enc, k, o.m[K(k)])
+	}
+	return nil
+}
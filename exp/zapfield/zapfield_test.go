@@ -21,6 +21,7 @@
 package zapfield
 
 import (
+	"io"
 	"sync"
 	"testing"
 
@@ -33,8 +34,38 @@ type (
 	MyKey    string
 	MyValue  string
 	MyValues []MyValue
+	MyInt    int
+	MyUint   uint64
+	MyFloat  float64
 )
 
+type Status int
+
+const (
+	StatusOK       Status = 200
+	StatusNotFound Status = 404
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusNotFound:
+		return "not found"
+	default:
+		return "unknown"
+	}
+}
+
+type Color uint64
+
+func (c Color) String() string {
+	if c == 1 {
+		return "red"
+	}
+	return "unknown"
+}
+
 func TestFieldConstructors(t *testing.T) {
 	var (
 		key    = MyKey("test key")
@@ -52,6 +83,12 @@ func TestFieldConstructors(t *testing.T) {
 	}{
 		{"Str", zap.Field{Type: zapcore.StringType, Key: "test key", String: "test value"}, Str(key, value)},
 		{"Strs", zap.Array("test key", stringArray[MyValue]{"test value 1", "test value 2"}), Strs(key, values)},
+		{"Num:int", zap.Int64("test key", -42), Num(key, MyInt(-42))},
+		{"Num:uint64", zap.Uint64("test key", 1<<63), Num(key, MyUint(1<<63))},
+		{"Num:float64", zap.Float64("test key", 1.5), Num(key, MyFloat(1.5))},
+		{"Num:unnamed", zap.Int64("test key", 7), Num(key, 7)},
+		{"Nums", zap.Array("test key", numArray[MyInt]{1, -2}), Nums(key, []MyInt{1, -2})},
+		{"Enum", zap.String("test key", "not found"), Enum(key, StatusNotFound)},
 	}
 
 	for _, tt := range tests {
@@ -62,6 +99,146 @@ func TestFieldConstructors(t *testing.T) {
 	}
 }
 
+func TestNums(t *testing.T) {
+	tests := []struct {
+		desc  string
+		field zap.Field
+		want  interface{}
+	}{
+		{"int", Nums("k", []MyInt{1, -2}), []interface{}{int64(1), int64(-2)}},
+		{"uint64", Nums("k", []MyUint{1 << 63}), []interface{}{uint64(1 << 63)}},
+		{"float64", Nums("k", []MyFloat{0.5, 2}), []interface{}{0.5, float64(2)}},
+		{"empty", Nums("k", []MyInt(nil)), []interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := zapcore.NewMapObjectEncoder()
+			tt.field.AddTo(enc)
+			assert.Equal(t, tt.want, enc.Fields["k"])
+		})
+	}
+}
+
+func TestEnumCode(t *testing.T) {
+	tests := []struct {
+		desc  string
+		field zap.Field
+		want  map[string]interface{}
+	}{
+		{
+			desc:  "signed",
+			field: EnumCode("status", StatusNotFound),
+			want:  map[string]interface{}{"status": "not found", "statusCode": int64(404)},
+		},
+		{
+			desc:  "unsigned",
+			field: EnumCode(MyKey("color"), Color(1)),
+			want:  map[string]interface{}{"color": "red", "colorCode": uint64(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := zapcore.NewMapObjectEncoder()
+			tt.field.AddTo(enc)
+			assert.Equal(t, tt.want, enc.Fields)
+			assertCanBeReused(t, tt.field)
+		})
+	}
+}
+
+func TestNumEnumDoNotAllocate(t *testing.T) {
+	var f zap.Field
+	allocs := testing.AllocsPerRun(100, func() {
+		f = Num("k", MyInt(42))
+		f = Num("k", MyFloat(4.2))
+		f = Enum("k", StatusOK)
+	})
+	assert.Zero(t, allocs, "Expected Num and Enum not to allocate.")
+	_ = f
+}
+
+func BenchmarkNumEnum(b *testing.B) {
+	logger := zap.New(zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(io.Discard),
+		zapcore.DebugLevel,
+	))
+
+	benchmarks := []struct {
+		name  string
+		field func() zap.Field
+	}{
+		{"Num", func() zap.Field { return Num("k", MyInt(42)) }},
+		{"Any/Num", func() zap.Field { return zap.Any("k", MyInt(42)) }},
+		{"Nums", func() zap.Field { return Nums("k", []MyFloat{1, 2, 3}) }},
+		{"Any/Nums", func() zap.Field { return zap.Any("k", []MyFloat{1, 2, 3}) }},
+		{"Enum", func() zap.Field { return Enum("k", StatusOK) }},
+		{"EnumCode", func() zap.Field { return EnumCode("k", StatusOK) }},
+		{"Any/Enum", func() zap.Field { return zap.Any("k", StatusOK) }},
+	}
+
+	for _, bb := range benchmarks {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				logger.Info("message", bb.field())
+			}
+		})
+	}
+}
+
+type limit struct{ max int }
+
+func encodeLimit(enc zapcore.ObjectEncoder, k string, v limit) {
+	enc.AddInt(k, v.max)
+}
+
+func TestMap(t *testing.T) {
+	limits := map[MyKey]limit{"c": {3}, "a": {1}, "b": {2}}
+	want := map[string]interface{}{"a": 1, "b": 2, "c": 3}
+
+	t.Run("sorted", func(t *testing.T) {
+		enc := zapcore.NewOrderedMapObjectEncoder()
+		field := Map("limits", limits, encodeLimit)
+		field.AddTo(enc)
+		assert.Equal(t, want, enc.Fields["limits"])
+		assert.Equal(t, []string{"a", "b", "c"}, enc.Keys("limits"), "Expected keys in sorted order.")
+		assertCanBeReused(t, field)
+	})
+
+	t.Run("unsorted", func(t *testing.T) {
+		enc := zapcore.NewMapObjectEncoder()
+		field := UnsortedMap("limits", limits, encodeLimit)
+		field.AddTo(enc)
+		assert.Equal(t, want, enc.Fields["limits"])
+		assertCanBeReused(t, field)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		for _, field := range []zap.Field{
+			Map[MyKey]("limits", nil, encodeLimit),
+			UnsortedMap[MyKey]("limits", nil, encodeLimit),
+		} {
+			enc := zapcore.NewMapObjectEncoder()
+			field.AddTo(enc)
+			assert.Equal(t, map[string]interface{}{}, enc.Fields["limits"], "Expected an empty object.")
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{})
+		buf, err := enc.EncodeEntry(zapcore.Entry{}, []zap.Field{
+			Map("limits", limits, encodeLimit),
+			Map[MyKey, limit]("empty", nil, encodeLimit),
+		})
+		if assert.NoError(t, err) {
+			assert.Equal(t, `{"limits":{"a":1,"b":2,"c":3},"empty":{}}`+"\n", buf.String())
+		}
+	})
+}
+
 func assertCanBeReused(t testing.TB, field zap.Field) {
 	var wg sync.WaitGroup
 
@@ -0,0 +1,20 @@
+module github.com/toujourser/zap/exp/zapkafka
+
+go 1.20
+
+require (
+	github.com/segmentio/kafka-go v0.4.47
+	github.com/stretchr/testify v1.8.1
+	github.com/toujourser/zap v1.26.0
+	go.uber.org/multierr v1.10.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/toujourser/zap => ../..
@@ -0,0 +1,60 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapkafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// writerTransport produces messages with a kafka-go Writer.
+type writerTransport struct {
+	w *kafka.Writer
+}
+
+func newWriterTransport(cfg Config) *writerTransport {
+	return &writerTransport{w: &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.LeastBytes{},
+		RequiredAcks: kafka.RequiredAcks(cfg.Acks),
+		BatchBytes:   int64(cfg.BatchBytes),
+		// The sink already batches entries, so have the Writer send each
+		// batch right away instead of waiting for more messages. Messages
+		// aren't empty, so no batch has more messages than bytes.
+		BatchSize:    cfg.BatchBytes + 1,
+		BatchTimeout: time.Millisecond,
+	}}
+}
+
+func (t *writerTransport) Produce(ctx context.Context, msgs [][]byte) error {
+	kmsgs := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		kmsgs[i].Value = msg
+	}
+	return t.w.WriteMessages(ctx, kmsgs...)
+}
+
+func (t *writerTransport) Close() error {
+	return t.w.Close()
+}
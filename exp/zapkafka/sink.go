@@ -0,0 +1,350 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package zapkafka provides a zap sink that produces log entries to a Kafka
+// topic, so that logs can be shipped without a sidecar.
+//
+// Importing the package registers the sink for "kafka" URLs, which name the
+// brokers and the topic:
+//
+//	import _ "github.com/toujourser/zap/exp/zapkafka"
+//
+//	cfg := zap.NewProductionConfig()
+//	cfg.OutputPaths = []string{"kafka://broker1:9092,broker2:9092/logs?acks=1&lingerMs=50"}
+//
+// See ParseURL for the supported query parameters. Each entry becomes one
+// Kafka message. Entries are batched in memory and produced asynchronously;
+// Sync waits for them to be delivered.
+//
+// The package is a separate module so that zap itself doesn't depend on a
+// Kafka client.
+package zapkafka // import "github.com/toujourser/zap/exp/zapkafka"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/toujourser/zap"
+	"github.com/toujourser/zap/zapcore"
+	"go.uber.org/multierr"
+)
+
+const (
+	_defaultBatchBytes      = 1 << 20 // 1 MiB
+	_defaultLinger          = 10 * time.Millisecond
+	_defaultQueueBytes      = 16 << 20 // 16 MiB
+	_defaultDeliveryTimeout = 30 * time.Second
+)
+
+var errClosed = errors.New("zapkafka: sink is closed")
+
+func init() {
+	if err := zap.RegisterSink("kafka", newSinkFromURL); err != nil {
+		panic(err)
+	}
+}
+
+// A Transport produces batches of messages to a Kafka topic. The default
+// Transport uses a kafka-go Writer; tests and other clients may provide
+// their own.
+type Transport interface {
+	// Produce sends the messages to the topic, in order, returning once
+	// they're acknowledged or ctx is done.
+	Produce(ctx context.Context, msgs [][]byte) error
+
+	// Close releases the Transport's connections. Produce isn't called
+	// after Close.
+	Close() error
+}
+
+// FullPolicy decides what a sink does with entries written while its queue
+// is full, typically because the brokers are unreachable.
+type FullPolicy int
+
+const (
+	// BlockWhenFull blocks writes until the queue has room, so that no
+	// entries are lost but logging slows down with the brokers.
+	BlockWhenFull FullPolicy = iota
+	// DropWhenFull drops entries and reports how many were dropped to the
+	// error output, so that logging never blocks.
+	DropWhenFull
+)
+
+// Config configures a Kafka sink.
+type Config struct {
+	// Brokers lists the addresses of the bootstrap brokers.
+	Brokers []string
+	// Topic is the topic entries are produced to.
+	Topic string
+	// Acks is the number of acknowledgements required for each batch: 0
+	// for none, 1 for the partition leader, or -1 for all in-sync
+	// replicas.
+	Acks int
+	// BatchBytes is the size at which a batch is produced without waiting
+	// for Linger. Defaults to 1 MiB.
+	BatchBytes int
+	// Linger is how long entries wait for a batch to fill up before it's
+	// produced. Defaults to 10ms.
+	Linger time.Duration
+	// QueueBytes bounds the size of the entries waiting to be delivered,
+	// including those being produced. Once it's reached, OnFull applies.
+	// Defaults to 16 MiB.
+	QueueBytes int
+	// OnFull decides what happens to entries written while the queue is
+	// full. Defaults to BlockWhenFull.
+	OnFull FullPolicy
+	// DeliveryTimeout bounds how long each batch may take to be produced.
+	// Defaults to 30s.
+	DeliveryTimeout time.Duration
+	// ErrorOutput receives reports of failed deliveries and dropped
+	// entries. Defaults to standard error.
+	ErrorOutput zapcore.WriteSyncer
+	// Transport produces the batches. If nil, a kafka-go Writer for
+	// Brokers and Topic is used.
+	Transport Transport
+}
+
+// NewSink builds a sink that produces the entries written to it to a Kafka
+// topic, batching them as configured.
+//
+// Write doesn't wait for delivery. Failed deliveries are reported to the
+// ErrorOutput and returned by the next call to Sync, which produces the
+// current batch and waits for all pending batches. Close syncs and then
+// closes the Transport.
+func NewSink(cfg Config) (zap.Sink, error) {
+	if cfg.Transport == nil {
+		if len(cfg.Brokers) == 0 {
+			return nil, errors.New("zapkafka: no brokers")
+		}
+		if cfg.Topic == "" {
+			return nil, errors.New("zapkafka: no topic")
+		}
+	}
+	if cfg.BatchBytes <= 0 {
+		cfg.BatchBytes = _defaultBatchBytes
+	}
+	if cfg.Linger <= 0 {
+		cfg.Linger = _defaultLinger
+	}
+	if cfg.QueueBytes <= 0 {
+		cfg.QueueBytes = _defaultQueueBytes
+	}
+	if cfg.DeliveryTimeout <= 0 {
+		cfg.DeliveryTimeout = _defaultDeliveryTimeout
+	}
+	if cfg.ErrorOutput == nil {
+		cfg.ErrorOutput = zapcore.Lock(os.Stderr)
+	}
+	if cfg.Transport == nil {
+		cfg.Transport = newWriterTransport(cfg)
+	}
+
+	s := &sink{
+		cfg:  cfg,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	go s.run()
+	return s, nil
+}
+
+func newSinkFromURL(u *url.URL) (zap.Sink, error) {
+	cfg, err := ParseURL(u)
+	if err != nil {
+		return nil, err
+	}
+	return NewSink(cfg)
+}
+
+type sink struct {
+	cfg  Config
+	wake chan struct{} // signaled when a batch is queued; closed by Close
+	done chan struct{} // closed when run returns
+
+	mu         sync.Mutex
+	cond       *sync.Cond // broadcast when queued bytes are delivered
+	batch      [][]byte   // the batch being filled
+	batchBytes int
+	linger     *time.Timer // produces batch once Linger passes
+	queue      [][][]byte  // full batches waiting to be produced
+	queued     int         // bytes in batch, queue, and being produced
+	dropped    int
+	dropBytes  int
+	err        error // first delivery error since the last Sync
+	closed     bool
+}
+
+func (s *sink) Write(p []byte) (int, error) {
+	n := len(p)
+	if n > 0 && p[n-1] == '\n' {
+		p = p[:n-1]
+	}
+	msg := make([]byte, len(p))
+	copy(msg, p)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for !s.closed && s.queued > 0 && s.queued+len(msg) > s.cfg.QueueBytes {
+		if s.cfg.OnFull == DropWhenFull {
+			s.dropped++
+			s.dropBytes += n
+			return n, nil
+		}
+		s.cond.Wait()
+	}
+	if s.closed {
+		return 0, errClosed
+	}
+
+	s.batch = append(s.batch, msg)
+	s.batchBytes += len(msg)
+	s.queued += len(msg)
+	switch {
+	case s.batchBytes >= s.cfg.BatchBytes:
+		s.queueBatch()
+	case len(s.batch) == 1:
+		s.linger = time.AfterFunc(s.cfg.Linger, s.lingerExpired)
+	}
+	return n, nil
+}
+
+// Sync produces the current batch and waits until all pending batches are
+// delivered or have failed. It returns the first delivery error since the
+// last call to Sync.
+func (s *sink) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queueBatch()
+	for s.queued > 0 {
+		s.cond.Wait()
+	}
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// Close syncs the sink, stops producing, and closes the Transport. Entries
+// written after Close are rejected.
+func (s *sink) Close() error {
+	err := s.Sync()
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return err
+	}
+	s.closed = true
+	close(s.wake)
+	s.cond.Broadcast() // fail blocked writes
+	s.mu.Unlock()
+
+	<-s.done
+	return multierr.Append(err, s.cfg.Transport.Close())
+}
+
+func (s *sink) lingerExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queueBatch()
+}
+
+// queueBatch hands the current batch to the producing goroutine. It must be
+// called with s.mu held.
+func (s *sink) queueBatch() {
+	if s.linger != nil {
+		s.linger.Stop()
+		s.linger = nil
+	}
+	if len(s.batch) == 0 || s.closed {
+		return
+	}
+	s.queue = append(s.queue, s.batch)
+	s.batch, s.batchBytes = nil, 0
+	select {
+	case s.wake <- struct{}{}:
+	default: // already signaled
+	}
+}
+
+// run produces queued batches until the sink is closed.
+func (s *sink) run() {
+	defer close(s.done)
+	for range s.wake {
+		for {
+			batch, ok := s.next()
+			if !ok {
+				break
+			}
+			s.produce(batch)
+		}
+	}
+}
+
+func (s *sink) next() (batch [][]byte, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return nil, false
+	}
+	batch = s.queue[0]
+	s.queue[0] = nil
+	s.queue = s.queue[1:]
+	return batch, true
+}
+
+func (s *sink) produce(batch [][]byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.DeliveryTimeout)
+	err := s.cfg.Transport.Produce(ctx, batch)
+	cancel()
+
+	var size int
+	for _, msg := range batch {
+		size += len(msg)
+	}
+
+	s.mu.Lock()
+	s.queued -= size
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	dropped, dropBytes := s.dropped, s.dropBytes
+	s.dropped, s.dropBytes = 0, 0
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	out := s.cfg.ErrorOutput
+	if err != nil {
+		fmt.Fprintf(out, "%v zapkafka: failed to deliver %d log entries to topic %q: %v\n", time.Now().UTC(), len(batch), s.cfg.Topic, err)
+	}
+	if dropped > 0 {
+		fmt.Fprintf(out, "%v zapkafka: dropped %d log entries (%d bytes) while the queue was full\n", time.Now().UTC(), dropped, dropBytes)
+	}
+	if err != nil || dropped > 0 {
+		_ = out.Sync()
+	}
+}
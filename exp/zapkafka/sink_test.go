@@ -0,0 +1,313 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapkafka
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap"
+	"github.com/toujourser/zap/zapcore"
+)
+
+// mockTransport records the batches produced, optionally failing or
+// blocking until released.
+type mockTransport struct {
+	mu      sync.Mutex
+	batches [][]string
+	err     error
+	closed  bool
+
+	produced chan struct{} // receives after each batch, if non-nil
+	release  chan struct{} // if non-nil, Produce waits for it
+}
+
+func (t *mockTransport) Produce(ctx context.Context, msgs [][]byte) error {
+	if t.release != nil {
+		select {
+		case <-t.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	batch := make([]string, len(msgs))
+	for i, msg := range msgs {
+		batch[i] = string(msg)
+	}
+	t.mu.Lock()
+	t.batches = append(t.batches, batch)
+	err := t.err
+	t.mu.Unlock()
+
+	if t.produced != nil {
+		t.produced <- struct{}{}
+	}
+	return err
+}
+
+func (t *mockTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	return nil
+}
+
+func (t *mockTransport) Batches() [][]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([][]string(nil), t.batches...)
+}
+
+// syncBuffer is a WriteSyncer that's safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+func (b *syncBuffer) Sync() error { return nil }
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
+
+func newTestSink(t *testing.T, cfg Config) zap.Sink {
+	s, err := NewSink(cfg)
+	require.NoError(t, err, "Failed to create sink.")
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func write(t *testing.T, s zap.Sink, msgs ...string) {
+	for _, msg := range msgs {
+		n, err := s.Write([]byte(msg + "\n"))
+		require.NoError(t, err, "Unexpected error writing.")
+		require.Equal(t, len(msg)+1, n, "Unexpected number of bytes written.")
+	}
+}
+
+func TestSinkBatches(t *testing.T) {
+	transport := &mockTransport{}
+	s := newTestSink(t, Config{
+		Transport:  transport,
+		BatchBytes: 6,
+		Linger:     time.Hour,
+	})
+
+	write(t, s, "aaa", "bbb", "ccc", "d")
+	require.NoError(t, s.Sync(), "Unexpected error syncing.")
+	assert.Equal(t, [][]string{{"aaa", "bbb"}, {"ccc", "d"}}, transport.Batches(),
+		"Expected entries to be batched by size, without line endings.")
+}
+
+func TestSinkLinger(t *testing.T) {
+	transport := &mockTransport{produced: make(chan struct{}, 1)}
+	s := newTestSink(t, Config{
+		Transport: transport,
+		Linger:    time.Millisecond,
+	})
+
+	write(t, s, "a", "b")
+	select {
+	case <-transport.produced:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the batch to be produced once Linger passed.")
+	}
+	assert.Equal(t, [][]string{{"a", "b"}}, transport.Batches(), "Unexpected batches.")
+}
+
+func TestSinkDeliveryErrors(t *testing.T) {
+	errOut := &syncBuffer{}
+	transport := &mockTransport{err: errors.New("broker unavailable")}
+	s := newTestSink(t, Config{
+		Topic:       "logs",
+		Transport:   transport,
+		Linger:      time.Hour,
+		ErrorOutput: errOut,
+	})
+
+	write(t, s, "a", "b")
+	assert.EqualError(t, s.Sync(), "broker unavailable", "Expected Sync to return the delivery error.")
+	assert.Contains(t, errOut.String(), `zapkafka: failed to deliver 2 log entries to topic "logs": broker unavailable`,
+		"Expected the delivery error to be reported.")
+	assert.NoError(t, s.Sync(), "Expected delivery errors to be returned once.")
+}
+
+func TestSinkDropWhenFull(t *testing.T) {
+	errOut := &syncBuffer{}
+	transport := &mockTransport{release: make(chan struct{})}
+	s := newTestSink(t, Config{
+		Transport:   transport,
+		BatchBytes:  1,
+		QueueBytes:  4,
+		OnFull:      DropWhenFull,
+		ErrorOutput: errOut,
+	})
+
+	// The first entry is being produced, and the second is queued.
+	write(t, s, "aa", "bb", "cc", "dd")
+	close(transport.release)
+	require.NoError(t, s.Sync(), "Unexpected error syncing.")
+
+	assert.Equal(t, [][]string{{"aa"}, {"bb"}}, transport.Batches(), "Expected entries beyond the queue size to be dropped.")
+	assert.Contains(t, errOut.String(), "zapkafka: dropped 2 log entries (6 bytes) while the queue was full",
+		"Expected dropped entries to be reported.")
+}
+
+func TestSinkBlockWhenFull(t *testing.T) {
+	transport := &mockTransport{release: make(chan struct{})}
+	s := newTestSink(t, Config{
+		Transport:  transport,
+		BatchBytes: 1,
+		QueueBytes: 4,
+	})
+
+	write(t, s, "aa", "bb")
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		_, _ = s.Write([]byte("cc\n"))
+	}()
+
+	select {
+	case <-written:
+		t.Fatal("Expected the write to block while the queue is full.")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(transport.release)
+	<-written
+	require.NoError(t, s.Sync(), "Unexpected error syncing.")
+	assert.Equal(t, [][]string{{"aa"}, {"bb"}, {"cc"}}, transport.Batches(), "Expected no entries to be dropped.")
+}
+
+func TestSinkDeliveryTimeout(t *testing.T) {
+	transport := &mockTransport{release: make(chan struct{})}
+	s := newTestSink(t, Config{
+		Transport:       transport,
+		DeliveryTimeout: time.Millisecond,
+		ErrorOutput:     &syncBuffer{},
+	})
+
+	write(t, s, "a")
+	assert.ErrorIs(t, s.Sync(), context.DeadlineExceeded, "Expected the batch to time out.")
+}
+
+func TestSinkClose(t *testing.T) {
+	transport := &mockTransport{}
+	s := newTestSink(t, Config{Transport: transport, Linger: time.Hour})
+
+	write(t, s, "a")
+	require.NoError(t, s.Close(), "Unexpected error closing.")
+	assert.Equal(t, [][]string{{"a"}}, transport.Batches(), "Expected Close to flush pending entries.")
+	assert.True(t, transport.closed, "Expected Close to close the transport.")
+
+	_, err := s.Write([]byte("b\n"))
+	assert.ErrorIs(t, err, errClosed, "Expected writes after Close to fail.")
+	assert.NoError(t, s.Sync(), "Expected Sync after Close to succeed.")
+	assert.NoError(t, s.Close(), "Expected Close to be idempotent.")
+}
+
+func TestSinkWithLogger(t *testing.T) {
+	transport := &mockTransport{}
+	s := newTestSink(t, Config{Transport: transport, Linger: time.Hour})
+
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+	logger := zap.New(zapcore.NewCore(enc, s, zapcore.InfoLevel))
+	logger.Info("hello", zap.Int("n", 1))
+	require.NoError(t, logger.Sync(), "Unexpected error syncing.")
+
+	assert.Equal(t, [][]string{{`{"msg":"hello","n":1}`}}, transport.Batches(), "Unexpected messages.")
+}
+
+func TestNewSinkErrors(t *testing.T) {
+	_, err := NewSink(Config{Topic: "logs"})
+	assert.ErrorContains(t, err, "no brokers")
+
+	_, err = NewSink(Config{Brokers: []string{"localhost:9092"}})
+	assert.ErrorContains(t, err, "no topic")
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		give    string
+		want    Config
+		wantErr string
+	}{
+		{
+			give: "kafka://localhost:9092/logs",
+			want: Config{Brokers: []string{"localhost:9092"}, Topic: "logs", Acks: 1},
+		},
+		{
+			give: "kafka://b1:9092,b2:9092/logs?acks=all&batchBytes=1048576&lingerMs=50&queueBytes=4096&onFull=drop&timeoutMs=2000",
+			want: Config{
+				Brokers:         []string{"b1:9092", "b2:9092"},
+				Topic:           "logs",
+				Acks:            -1,
+				BatchBytes:      1048576,
+				Linger:          50 * time.Millisecond,
+				QueueBytes:      4096,
+				OnFull:          DropWhenFull,
+				DeliveryTimeout: 2 * time.Second,
+			},
+		},
+		{give: "kafka:///logs", wantErr: "must list brokers"},
+		{give: "kafka://b1", wantErr: "must have a topic"},
+		{give: "kafka://b1/a/b", wantErr: "must have a topic"},
+		{give: "kafka://user:pass@b1/logs", wantErr: "user and password not allowed"},
+		{give: "kafka://b1/logs#frag", wantErr: "fragments not allowed"},
+		{give: "kafka://b1/logs?acks=2", wantErr: `invalid "acks"`},
+		{give: "kafka://b1/logs?lingerMs=-1", wantErr: `invalid "lingerMs"`},
+		{give: "kafka://b1/logs?batchBytes=x", wantErr: `invalid "batchBytes"`},
+		{give: "kafka://b1/logs?onFull=wait", wantErr: `invalid "onFull"`},
+		{give: "kafka://b1/logs?compression=gzip", wantErr: `invalid "compression"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.give, func(t *testing.T) {
+			u, err := url.Parse(tt.give)
+			require.NoError(t, err, "Failed to parse URL.")
+
+			got, err := ParseURL(u)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err, "Unexpected error parsing URL.")
+			assert.Equal(t, tt.want, got, "Unexpected config.")
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapkafka
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseURL builds a Config from a "kafka" URL, whose host lists the
+// comma-separated broker addresses and whose path names the topic:
+//
+//	kafka://broker1:9092,broker2:9092/logs?acks=all&batchBytes=1048576&lingerMs=50
+//
+// The supported query parameters are
+//
+//   - acks: 0, 1, or -1 (also "all"), as Config.Acks; defaults to 1
+//   - batchBytes: Config.BatchBytes
+//   - lingerMs: Config.Linger, in milliseconds
+//   - queueBytes: Config.QueueBytes
+//   - onFull: "block" or "drop", as Config.OnFull
+//   - timeoutMs: Config.DeliveryTimeout, in milliseconds
+func ParseURL(u *url.URL) (Config, error) {
+	if u.User != nil {
+		return Config{}, fmt.Errorf("user and password not allowed with kafka URLs: got %v", u)
+	}
+	if u.Fragment != "" {
+		return Config{}, fmt.Errorf("fragments not allowed with kafka URLs: got %v", u)
+	}
+	if u.Host == "" {
+		return Config{}, fmt.Errorf("kafka URLs must list brokers: got %v", u)
+	}
+	topic := strings.TrimPrefix(u.Path, "/")
+	if topic == "" || strings.Contains(topic, "/") {
+		return Config{}, fmt.Errorf("kafka URLs must have a topic as their path: got %v", u)
+	}
+
+	cfg := Config{
+		Brokers: strings.Split(u.Host, ","),
+		Topic:   topic,
+		Acks:    1,
+	}
+	for key, values := range u.Query() {
+		value := values[len(values)-1]
+		var err error
+		switch key {
+		case "acks":
+			cfg.Acks, err = parseAcks(value)
+		case "batchBytes":
+			cfg.BatchBytes, err = parsePositive(value)
+		case "lingerMs":
+			cfg.Linger, err = parseMillis(value)
+		case "queueBytes":
+			cfg.QueueBytes, err = parsePositive(value)
+		case "onFull":
+			cfg.OnFull, err = parseFullPolicy(value)
+		case "timeoutMs":
+			cfg.DeliveryTimeout, err = parseMillis(value)
+		default:
+			err = fmt.Errorf("unknown parameter")
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid %q in kafka URL %v: %v", key, u, err)
+		}
+	}
+	return cfg, nil
+}
+
+func parseAcks(s string) (int, error) {
+	switch s {
+	case "0":
+		return 0, nil
+	case "1":
+		return 1, nil
+	case "-1", "all":
+		return -1, nil
+	}
+	return 0, fmt.Errorf("must be 0, 1, -1, or all")
+}
+
+func parsePositive(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return n, nil
+}
+
+func parseMillis(s string) (time.Duration, error) {
+	n, err := parsePositive(s)
+	return time.Duration(n) * time.Millisecond, err
+}
+
+func parseFullPolicy(s string) (FullPolicy, error) {
+	switch s {
+	case "block":
+		return BlockWhenFull, nil
+	case "drop":
+		return DropWhenFull, nil
+	}
+	return 0, fmt.Errorf("must be block or drop")
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package zapmetrics counts log entries by level and logger name, for
+// example to alert on the rate of errors logged by each subsystem.
+//
+// The package doesn't depend on a metrics library. Instead, it works with
+// any counter that has an Inc method and any histogram that has an Observe
+// method, so Prometheus metrics can be used directly:
+//
+//	entries := prometheus.NewCounterVec(prometheus.CounterOpts{
+//	  Name: "log_entries_total",
+//	  Help: "Number of log entries written, by level and logger.",
+//	}, []string{"level", "logger"})
+//	registerer.MustRegister(entries)
+//
+//	core = zapmetrics.NewCore(core, func(lvl zapcore.Level, name string) zapmetrics.Counter {
+//	  return entries.WithLabelValues(lvl.String(), name)
+//	})
+//
+// Counters are looked up once per level and logger name and cached, so
+// counting an entry doesn't allocate.
+package zapmetrics // import "github.com/toujourser/zap/exp/zapmetrics"
+
+import (
+	"sync"
+	"time"
+
+	"github.com/toujourser/zap/zapcore"
+)
+
+// Counter counts events. prometheus.Counter implements it.
+type Counter interface {
+	Inc()
+}
+
+// Observer records observations, such as latencies. prometheus.Observer,
+// and so prometheus.Histogram and prometheus.Summary, implement it.
+type Observer interface {
+	Observe(float64)
+}
+
+// CounterFunc returns the Counter for entries at the given level written by
+// the named logger. It's called at most once for each level and logger name.
+type CounterFunc func(lvl zapcore.Level, logger string) Counter
+
+// ObserverFunc returns the Observer for entries at the given level written
+// by the named logger. It's called at most once for each level and logger
+// name.
+type ObserverFunc func(lvl zapcore.Level, logger string) Observer
+
+// An Option configures a metrics Core.
+type Option interface {
+	apply(*metrics)
+}
+
+// optionFunc wraps a func so it satisfies the Option interface.
+type optionFunc func(*metrics)
+
+func (f optionFunc) apply(m *metrics) {
+	f(m)
+}
+
+// WithLatency records the time taken by the wrapped Core to encode and write
+// each entry, in seconds.
+func WithLatency(observer ObserverFunc) Option {
+	return optionFunc(func(m *metrics) {
+		if observer != nil {
+			m.latency = newCache(observer)
+		}
+	})
+}
+
+// withClock sets the function used to measure latency. It's used by tests.
+func withClock(now func() time.Time) Option {
+	return optionFunc(func(m *metrics) {
+		m.now = now
+	})
+}
+
+// NewCore wraps a Core so that each entry it writes increments the Counter
+// for the entry's level and logger name.
+//
+// The metrics Core writes to the wrapped Core directly, once the wrapped Core
+// reports that the entry's level is enabled, so it should wrap the Core that
+// encodes and writes entries, such as one built with zapcore.NewCore. Wrap
+// samplers and other Cores that filter entries around it instead, so that
+// only entries that are written are counted. Since the metrics Core doesn't
+// change what's written, it may be removed from a Tee, or replaced by the
+// Core it wraps, without affecting the output.
+func NewCore(core zapcore.Core, counter CounterFunc, opts ...Option) zapcore.Core {
+	m := &metrics{
+		entries: newCache(counter),
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt.apply(m)
+	}
+	return &metricsCore{Core: core, metrics: m}
+}
+
+// Hook returns a function that increments the Counter for each entry's
+// level and logger name. Use it with zap.Hooks or zapcore.RegisterHooks to
+// count entries without wrapping a Core:
+//
+//	logger = logger.WithOptions(zap.Hooks(zapmetrics.Hook(counter)))
+func Hook(counter CounterFunc) func(zapcore.Entry) error {
+	entries := newCache(counter)
+	return func(ent zapcore.Entry) error {
+		entries.get(ent.Level, ent.LoggerName).Inc()
+		return nil
+	}
+}
+
+// metrics holds the state shared by a metrics Core and the Cores derived from
+// it with With.
+type metrics struct {
+	entries *cache[Counter]
+	latency *cache[Observer] // nil if latency isn't recorded
+	now     func() time.Time
+}
+
+type metricsCore struct {
+	zapcore.Core
+
+	metrics *metrics
+}
+
+var (
+	_ zapcore.Core           = (*metricsCore)(nil)
+	_ zapcore.LeveledEnabler = (*metricsCore)(nil)
+)
+
+func (c *metricsCore) Level() zapcore.Level {
+	return zapcore.LevelOf(c.Core)
+}
+
+func (c *metricsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &metricsCore{
+		Core:    c.Core.With(fields),
+		metrics: c.metrics,
+	}
+}
+
+func (c *metricsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *metricsCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	m := c.metrics
+	m.entries.get(ent.Level, ent.LoggerName).Inc()
+	if m.latency == nil {
+		return c.Core.Write(ent, fields)
+	}
+
+	start := m.now()
+	err := c.Core.Write(ent, fields)
+	m.latency.get(ent.Level, ent.LoggerName).Observe(m.now().Sub(start).Seconds())
+	return err
+}
+
+const (
+	_minLevel  = zapcore.TraceLevel
+	_maxLevel  = zapcore.FatalLevel
+	_numLevels = _maxLevel - _minLevel + 1
+)
+
+// cache holds the metrics for each level and logger name. Metrics for all
+// known levels are created together the first time a logger name is seen.
+type cache[T any] struct {
+	create func(zapcore.Level, string) T
+	byName sync.Map // logger name => *[_numLevels]T
+
+	// mu guards the creation of metrics. Entries at custom levels are
+	// rare, so their metrics are kept in other, also under mu.
+	mu    sync.Mutex
+	other map[otherKey]T
+}
+
+type otherKey struct {
+	lvl  zapcore.Level
+	name string
+}
+
+func newCache[T any](create func(zapcore.Level, string) T) *cache[T] {
+	return &cache[T]{create: create}
+}
+
+func (c *cache[T]) get(lvl zapcore.Level, name string) T {
+	if lvl < _minLevel || lvl > _maxLevel {
+		return c.getOther(lvl, name)
+	}
+
+	if levels, ok := c.byName.Load(name); ok {
+		return levels.(*[_numLevels]T)[lvl-_minLevel]
+	}
+
+	// Create the metrics under the lock so that each is created once.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if levels, ok := c.byName.Load(name); ok {
+		return levels.(*[_numLevels]T)[lvl-_minLevel]
+	}
+	var levels [_numLevels]T
+	for i := range levels {
+		levels[i] = c.create(_minLevel+zapcore.Level(i), name)
+	}
+	c.byName.Store(name, &levels)
+	return levels[lvl-_minLevel]
+}
+
+func (c *cache[T]) getOther(lvl zapcore.Level, name string) T {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := otherKey{lvl, name}
+	if m, ok := c.other[key]; ok {
+		return m
+	}
+	if c.other == nil {
+		c.other = make(map[otherKey]T)
+	}
+	m := c.create(lvl, name)
+	c.other[key] = m
+	return m
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapmetrics
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap"
+	"github.com/toujourser/zap/zapcore"
+)
+
+type counter struct{ n atomic.Int64 }
+
+func (c *counter) Inc() { c.n.Add(1) }
+
+type observer struct {
+	mu  sync.Mutex
+	obs []float64
+}
+
+func (o *observer) Observe(v float64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.obs = append(o.obs, v)
+}
+
+type labels struct {
+	lvl  zapcore.Level
+	name string
+}
+
+// registry creates metrics on demand, recording how often each is created.
+type registry struct {
+	mu        sync.Mutex
+	counters  map[labels]*counter
+	observers map[labels]*observer
+	created   map[labels]int
+}
+
+func newRegistry() *registry {
+	return &registry{
+		counters:  make(map[labels]*counter),
+		observers: make(map[labels]*observer),
+		created:   make(map[labels]int),
+	}
+}
+
+func (r *registry) counter(lvl zapcore.Level, name string) Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := new(counter)
+	r.counters[labels{lvl, name}] = c
+	r.created[labels{lvl, name}]++
+	return c
+}
+
+func (r *registry) observer(lvl zapcore.Level, name string) Observer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o := new(observer)
+	r.observers[labels{lvl, name}] = o
+	return o
+}
+
+func (r *registry) count(lvl zapcore.Level, name string) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.counters[labels{lvl, name}]; ok {
+		return c.n.Load()
+	}
+	return 0
+}
+
+func newTestLogger(buf *bytes.Buffer, lvl zapcore.Level) zapcore.Core {
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		MessageKey:  "msg",
+		LevelKey:    "level",
+		NameKey:     "logger",
+		EncodeLevel: zapcore.LowercaseLevelEncoder,
+	})
+	return zapcore.NewCore(enc, zapcore.AddSync(buf), lvl)
+}
+
+func TestCoreCounts(t *testing.T) {
+	reg := newRegistry()
+	var buf bytes.Buffer
+	logger := zap.New(NewCore(newTestLogger(&buf, zapcore.InfoLevel), reg.counter))
+
+	logger.Debug("disabled")
+	logger.Info("info")
+	logger.Error("error")
+	db := logger.Named("db").With(zap.String("table", "users"))
+	db.Error("error")
+	db.Error("error")
+	db.Warn("warn")
+
+	assert.Equal(t, int64(0), reg.count(zapcore.DebugLevel, ""), "Expected disabled entries to be ignored.")
+	assert.Equal(t, int64(1), reg.count(zapcore.InfoLevel, ""))
+	assert.Equal(t, int64(1), reg.count(zapcore.ErrorLevel, ""))
+	assert.Equal(t, int64(2), reg.count(zapcore.ErrorLevel, "db"))
+	assert.Equal(t, int64(1), reg.count(zapcore.WarnLevel, "db"))
+	assert.Len(t, reg.counters, 16, "Expected counters for all levels of each logger name.")
+	assert.Equal(t, zapcore.InfoLevel, logger.Level())
+}
+
+func TestCoreOutputUnchanged(t *testing.T) {
+	write := func(core zapcore.Core) {
+		logger := zap.New(core).Named("svc").With(zap.Int("n", 1))
+		logger.Info("hello", zap.String("k", "v"))
+		logger.Warn("bye")
+		require.NoError(t, logger.Sync())
+	}
+
+	var plain, counted bytes.Buffer
+	write(newTestLogger(&plain, zapcore.DebugLevel))
+	write(NewCore(newTestLogger(&counted, zapcore.DebugLevel), newRegistry().counter,
+		WithLatency(newRegistry().observer)))
+	assert.Equal(t, plain.String(), counted.String(), "Expected the output to be unaffected.")
+
+	var teeA, teeB bytes.Buffer
+	write(zapcore.NewTee(
+		NewCore(newTestLogger(&teeA, zapcore.DebugLevel), newRegistry().counter),
+		newTestLogger(&teeB, zapcore.DebugLevel),
+	))
+	assert.Equal(t, plain.String(), teeA.String(), "Unexpected output from the counted Core in a Tee.")
+	assert.Equal(t, plain.String(), teeB.String(), "Unexpected output from the other Core in a Tee.")
+}
+
+func TestCoreLatency(t *testing.T) {
+	reg := newRegistry()
+	var (
+		now   = time.Unix(0, 0)
+		calls int
+	)
+	clock := func() time.Time {
+		calls++
+		now = now.Add(250 * time.Millisecond)
+		return now
+	}
+
+	core := NewCore(newTestLogger(new(bytes.Buffer), zapcore.DebugLevel), reg.counter,
+		WithLatency(reg.observer), withClock(clock))
+	logger := zap.New(core)
+	logger.Info("one")
+	logger.Named("db").Error("two")
+
+	assert.Equal(t, 4, calls, "Expected the clock to be read around each write.")
+	assert.Equal(t, []float64{0.25}, reg.observers[labels{zapcore.InfoLevel, ""}].obs)
+	assert.Equal(t, []float64{0.25}, reg.observers[labels{zapcore.ErrorLevel, "db"}].obs)
+}
+
+type errCore struct {
+	zapcore.LevelEnabler
+}
+
+func (c errCore) With([]zapcore.Field) zapcore.Core { return c }
+
+func (c errCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (errCore) Write(zapcore.Entry, []zapcore.Field) error { return errors.New("fail") }
+
+func (errCore) Sync() error { return errors.New("sync fail") }
+
+func TestCoreErrors(t *testing.T) {
+	reg := newRegistry()
+	core := NewCore(errCore{zapcore.DebugLevel}, reg.counter, WithLatency(reg.observer))
+	assert.EqualError(t, core.Write(zapcore.Entry{Level: zapcore.InfoLevel}, nil), "fail")
+	assert.EqualError(t, core.Sync(), "sync fail")
+	assert.Equal(t, int64(1), reg.count(zapcore.InfoLevel, ""), "Expected failed writes to be counted.")
+}
+
+func TestCoreCustomLevel(t *testing.T) {
+	reg := newRegistry()
+	core := NewCore(errCore{zapcore.DebugLevel}, reg.counter)
+	custom := zapcore.Level(42)
+	for i := 0; i < 3; i++ {
+		_ = core.Write(zapcore.Entry{Level: custom, LoggerName: "x"}, nil)
+	}
+	assert.Equal(t, int64(3), reg.count(custom, "x"))
+	assert.Equal(t, 1, reg.created[labels{custom, "x"}])
+}
+
+func TestHook(t *testing.T) {
+	reg := newRegistry()
+	var buf bytes.Buffer
+	logger := zap.New(newTestLogger(&buf, zapcore.InfoLevel), zap.Hooks(Hook(reg.counter)))
+
+	logger.Debug("disabled")
+	logger.Named("http").Warn("slow")
+	logger.Named("http").Warn("slow")
+
+	assert.Equal(t, int64(2), reg.count(zapcore.WarnLevel, "http"))
+	assert.Equal(t, int64(0), reg.count(zapcore.DebugLevel, "http"))
+}
+
+func TestCoreCreatesCountersOnce(t *testing.T) {
+	reg := newRegistry()
+	core := NewCore(errCore{zapcore.DebugLevel}, reg.counter)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = core.Write(zapcore.Entry{Level: zapcore.ErrorLevel, LoggerName: "db"}, nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(800), reg.count(zapcore.ErrorLevel, "db"))
+	for l, n := range reg.created {
+		assert.Equal(t, 1, n, "Expected counter %v to be created once.", l)
+	}
+}
+
+func TestCoreWriteDoesNotAllocate(t *testing.T) {
+	reg := newRegistry()
+	core := NewCore(nopWriteCore{zapcore.DebugLevel}, reg.counter, WithLatency(reg.observer))
+	ent := zapcore.Entry{Level: zapcore.ErrorLevel, LoggerName: "db"}
+	require.NoError(t, core.Write(ent, nil))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = core.Write(ent, nil)
+	})
+	assert.Zero(t, allocs, "Expected counting to not allocate.")
+}
+
+type nopWriteCore struct {
+	zapcore.LevelEnabler
+}
+
+func (c nopWriteCore) With([]zapcore.Field) zapcore.Core { return c }
+
+func (c nopWriteCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (nopWriteCore) Write(zapcore.Entry, []zapcore.Field) error { return nil }
+
+func (nopWriteCore) Sync() error { return nil }
@@ -0,0 +1,18 @@
+module github.com/toujourser/zap/exp/zapr
+
+go 1.20
+
+require (
+	github.com/go-logr/logr v1.4.2
+	github.com/stretchr/testify v1.8.1
+	github.com/toujourser/zap v1.26.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/toujourser/zap => ../..
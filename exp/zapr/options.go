@@ -0,0 +1,50 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapr
+
+import "github.com/toujourser/zap/zapcore"
+
+// An Option configures a LogSink.
+type Option interface {
+	apply(*logSink)
+}
+
+// optionFunc wraps a func so it satisfies the Option interface.
+type optionFunc func(*logSink)
+
+func (f optionFunc) apply(s *logSink) {
+	f(s)
+}
+
+// WithLevelMapper configures how the LogSink translates logr verbosity
+// levels into zap levels, for both Enabled and Info.
+//
+// By default, verbosity level 0 maps to InfoLevel, 1 to DebugLevel, and
+// anything higher to TraceLevel. Levels below TraceLevel are logged at
+// TraceLevel. A nil function restores the default mapping.
+func WithLevelMapper(f func(v int) zapcore.Level) Option {
+	return optionFunc(func(s *logSink) {
+		if f == nil {
+			f = defaultLevel
+		}
+		s.levelOf = f
+	})
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package zapr provides a logr.LogSink backed by a zap Logger, so that
+// projects built on logr, like Kubernetes controllers, can log through zap.
+//
+// The package is a separate module to isolate the logr dependency.
+package zapr // import "github.com/toujourser/zap/exp/zapr"
+
+import (
+	"github.com/go-logr/logr"
+	"github.com/toujourser/zap"
+	"github.com/toujourser/zap/zapcore"
+)
+
+const (
+	_oddNumberErrMsg    = "Ignored key without a value."
+	_nonStringKeyErrMsg = "Ignored key-value pairs with non-string keys."
+)
+
+// logSink implements logr.LogSink by writing to a zap Logger.
+type logSink struct {
+	l       *zap.Logger
+	levelOf func(v int) zapcore.Level
+}
+
+var (
+	_ logr.LogSink          = (*logSink)(nil)
+	_ logr.CallDepthLogSink = (*logSink)(nil)
+)
+
+// NewLogSink returns a logr.LogSink that writes to l. Use it with
+// logr.New:
+//
+//	log := logr.New(zapr.NewLogSink(zapLogger))
+//
+// Verbosity levels are mapped to zap levels as described by
+// WithLevelMapper. Messages logged with Error are always logged at
+// ErrorLevel. Callers are reported correctly as long as l doesn't already
+// skip frames for a wrapper of its own.
+func NewLogSink(l *zap.Logger, opts ...Option) logr.LogSink {
+	s := &logSink{
+		l:       l,
+		levelOf: defaultLevel,
+	}
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+	return s
+}
+
+// defaultLevel maps verbosity level 0 to InfoLevel, 1 to DebugLevel, and
+// anything higher to TraceLevel.
+func defaultLevel(v int) zapcore.Level {
+	switch v {
+	case 0:
+		return zapcore.InfoLevel
+	case 1:
+		return zapcore.DebugLevel
+	default:
+		return zapcore.TraceLevel
+	}
+}
+
+// level returns the zap level for verbosity level v, clamped to the levels
+// that zap logs.
+func (s *logSink) level(v int) zapcore.Level {
+	lvl := s.levelOf(v)
+	if lvl < zapcore.TraceLevel {
+		return zapcore.TraceLevel
+	}
+	return lvl
+}
+
+// Init implements logr.LogSink. It configures the Logger to skip logr's own
+// frames when annotating entries with the caller.
+func (s *logSink) Init(info logr.RuntimeInfo) {
+	// Skip the logSink method, too.
+	s.l = s.l.WithOptions(zap.AddCallerSkip(info.CallDepth + 1))
+}
+
+// Enabled implements logr.LogSink.
+func (s *logSink) Enabled(v int) bool {
+	return s.l.Core().Enabled(s.level(v))
+}
+
+// Info implements logr.LogSink.
+func (s *logSink) Info(v int, msg string, keysAndValues ...interface{}) {
+	if ce := s.l.Check(s.level(v), msg); ce != nil {
+		ce.Write(s.fields(keysAndValues)...)
+	}
+}
+
+// Error implements logr.LogSink. The error is logged under the "error" key,
+// and the message is logged at ErrorLevel regardless of verbosity.
+func (s *logSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if ce := s.l.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(append([]zap.Field{zap.Error(err)}, s.fields(keysAndValues)...)...)
+	}
+}
+
+// WithValues implements logr.LogSink.
+func (s *logSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	clone := *s
+	clone.l = s.l.With(s.fields(keysAndValues)...)
+	return &clone
+}
+
+// WithName implements logr.LogSink. Names are joined with periods, as with
+// zap.Logger.Named.
+func (s *logSink) WithName(name string) logr.LogSink {
+	clone := *s
+	clone.l = s.l.Named(name)
+	return &clone
+}
+
+// WithCallDepth implements logr.CallDepthLogSink.
+func (s *logSink) WithCallDepth(depth int) logr.LogSink {
+	clone := *s
+	clone.l = s.l.WithOptions(zap.AddCallerSkip(depth))
+	return &clone
+}
+
+// fields converts logr's key-value pairs to zap fields. zap.Fields are used
+// as-is. Like a SugaredLogger in strict mode, misused pairs are dropped and
+// reported at DPanicLevel, which panics in development.
+func (s *logSink) fields(keysAndValues []interface{}) []zap.Field {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+
+	fields := make([]zap.Field, 0, len(keysAndValues)/2)
+	var invalid invalidPairs
+	for i := 0; i < len(keysAndValues); {
+		if f, ok := keysAndValues[i].(zap.Field); ok {
+			fields = append(fields, f)
+			i++
+			continue
+		}
+
+		if i == len(keysAndValues)-1 {
+			s.invalidArgs(_oddNumberErrMsg, keysAndValues, zap.Any("ignored", keysAndValues[i]))
+			break
+		}
+
+		key, val := keysAndValues[i], keysAndValues[i+1]
+		if keyStr, ok := key.(string); ok {
+			fields = append(fields, zap.Any(keyStr, val))
+		} else {
+			invalid = append(invalid, invalidPair{i, key, val})
+		}
+		i += 2
+	}
+
+	if len(invalid) > 0 {
+		s.invalidArgs(_nonStringKeyErrMsg, keysAndValues, zap.Array("invalid", invalid))
+	}
+	return fields
+}
+
+// invalidArgs reports misused key-value pairs at DPanicLevel, along with all
+// the pairs that were passed.
+func (s *logSink) invalidArgs(msg string, keysAndValues []interface{}, field zap.Field) {
+	// Skip invalidArgs and fields, which sit between the logSink method and
+	// Check.
+	l := s.l.WithOptions(zap.AddCallerSkip(2))
+	if ce := l.Check(zapcore.DPanicLevel, msg); ce != nil {
+		ce.Write(field, zap.Any("keysAndValues", keysAndValues))
+	}
+}
+
+type invalidPair struct {
+	position   int
+	key, value interface{}
+}
+
+func (p invalidPair) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt64("position", int64(p.position))
+	zap.Any("key", p.key).AddTo(enc)
+	zap.Any("value", p.value).AddTo(enc)
+	return nil
+}
+
+type invalidPairs []invalidPair
+
+func (ps invalidPairs) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for i := range ps {
+		if err := enc.AppendObject(ps[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
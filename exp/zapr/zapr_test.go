@@ -0,0 +1,170 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap"
+	"github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zaptest/observer"
+)
+
+func newTestLogger(enab zapcore.LevelEnabler, opts ...Option) (logr.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(enab)
+	return logr.New(NewLogSink(zap.New(core, zap.AddCaller()), opts...)), logs
+}
+
+func funcName(fn string) string {
+	return fn[strings.LastIndex(fn, ".")+1:]
+}
+
+func TestLogSinkLevels(t *testing.T) {
+	log, logs := newTestLogger(zapcore.DebugLevel)
+
+	assert.True(t, log.Enabled(), "Expected V(0) to be enabled.")
+	assert.True(t, log.V(1).Enabled(), "Expected V(1) to be enabled.")
+	assert.False(t, log.V(2).Enabled(), "Expected V(2) to be disabled.")
+
+	log.Info("info")
+	log.V(1).Info("debug")
+	log.V(2).Info("trace")
+	log.V(1).Error(errors.New("fail"), "error")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 3, "Unexpected number of entries.")
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level, "Unexpected level for V(0).")
+	assert.Equal(t, zapcore.DebugLevel, entries[1].Level, "Unexpected level for V(1).")
+	assert.Equal(t, zapcore.ErrorLevel, entries[2].Level, "Expected Error to log at ErrorLevel regardless of verbosity.")
+	assert.Equal(t, map[string]interface{}{"error": "fail"}, entries[2].ContextMap(), "Expected the error as a field.")
+}
+
+func TestLogSinkLevelMapper(t *testing.T) {
+	log, logs := newTestLogger(zapcore.TraceLevel, WithLevelMapper(func(v int) zapcore.Level {
+		return zapcore.WarnLevel - zapcore.Level(v)
+	}))
+
+	log.Info("warn")
+	log.V(1).Info("info")
+	log.V(10).Info("clamped")
+
+	var levels []zapcore.Level
+	for _, e := range logs.AllUntimed() {
+		levels = append(levels, e.Level)
+	}
+	assert.Equal(t, []zapcore.Level{zapcore.WarnLevel, zapcore.InfoLevel, zapcore.TraceLevel}, levels,
+		"Unexpected levels.")
+
+	t.Run("nil restores default", func(t *testing.T) {
+		log, logs := newTestLogger(zapcore.DebugLevel, WithLevelMapper(nil))
+		log.V(1).Info("debug")
+		require.Equal(t, 1, logs.Len(), "Expected an entry.")
+		assert.Equal(t, zapcore.DebugLevel, logs.All()[0].Level, "Unexpected level.")
+	})
+}
+
+func TestLogSinkValuesAndNames(t *testing.T) {
+	log, logs := newTestLogger(zapcore.DebugLevel)
+
+	log = log.WithName("controller").WithName("pod").WithValues("namespace", "default")
+	log.Info("reconciled", "name", "web", "attempt", 2, zap.Bool("cached", true))
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1, "Expected a single entry.")
+	assert.Equal(t, "controller.pod", entries[0].LoggerName, "Unexpected logger name.")
+	assert.Equal(t, map[string]interface{}{
+		"namespace": "default",
+		"name":      "web",
+		"attempt":   int64(2),
+		"cached":    true,
+	}, entries[0].ContextMap(), "Unexpected fields.")
+}
+
+func TestLogSinkInvalidPairs(t *testing.T) {
+	tests := []struct {
+		desc    string
+		kv      []interface{}
+		wantMsg string
+		want    map[string]interface{}
+	}{
+		{
+			desc:    "dangling key",
+			kv:      []interface{}{"k", "v", "dangling"},
+			wantMsg: _oddNumberErrMsg,
+			want:    map[string]interface{}{"k": "v"},
+		},
+		{
+			desc:    "non-string key",
+			kv:      []interface{}{42, "v", "k", "v"},
+			wantMsg: _nonStringKeyErrMsg,
+			want:    map[string]interface{}{"k": "v"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			log, logs := newTestLogger(zapcore.DebugLevel)
+			log.Info("msg", tt.kv...)
+
+			entries := logs.AllUntimed()
+			require.Len(t, entries, 2, "Expected an error and the entry.")
+			assert.Equal(t, zapcore.DPanicLevel, entries[0].Level, "Expected misuse at DPanicLevel.")
+			assert.Equal(t, tt.wantMsg, entries[0].Message, "Unexpected error message.")
+			assert.Equal(t, tt.kv, entries[0].ContextMap()["keysAndValues"], "Expected all key-value pairs.")
+			assert.Contains(t, entries[0].Caller.Function, "TestLogSinkInvalidPairs",
+				"Expected the error to report the log site.")
+			assert.Equal(t, tt.want, entries[1].ContextMap(), "Expected valid pairs to be logged.")
+		})
+	}
+
+	t.Run("development", func(t *testing.T) {
+		core, _ := observer.New(zapcore.DebugLevel)
+		log := logr.New(NewLogSink(zap.New(core, zap.Development())))
+		assert.Panics(t, func() { log.Info("msg", 1, 2) }, "Expected misuse to panic in development.")
+	})
+}
+
+func TestLogSinkCaller(t *testing.T) {
+	log, logs := newTestLogger(zapcore.DebugLevel)
+
+	log.Info("info")
+	log.V(1).Info("debug")
+	log.Error(errors.New("fail"), "error")
+	log.WithValues("k", "v").WithName("named").Info("derived")
+	logHelper(log)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 5, "Unexpected number of entries.")
+	for _, e := range entries {
+		require.True(t, e.Caller.Defined, "Expected caller for %q.", e.Message)
+		assert.Equal(t, "TestLogSinkCaller", funcName(e.Caller.Function),
+			"Unexpected caller %v for %q.", e.Caller, e.Message)
+	}
+}
+
+func logHelper(log logr.Logger) {
+	log.WithCallDepth(1).Info("helper")
+}
@@ -0,0 +1,20 @@
+module github.com/toujourser/zap/exp/zapsentry
+
+go 1.20
+
+require (
+	github.com/getsentry/sentry-go v0.25.0
+	github.com/stretchr/testify v1.8.2
+	github.com/toujourser/zap v1.26.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/text v0.8.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/toujourser/zap => ../..
@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapsentry
+
+import (
+	"time"
+
+	"github.com/toujourser/zap/zapcore"
+)
+
+// An Option configures a Sentry Core.
+type Option interface {
+	apply(*config)
+}
+
+// optionFunc wraps a func so it satisfies the Option interface.
+type optionFunc func(*config)
+
+func (f optionFunc) apply(cfg *config) {
+	f(cfg)
+}
+
+// Tags sends the fields with the given keys as Sentry tags rather than
+// extras. Tags are indexed and searchable in Sentry, so they're best kept to
+// a few low-cardinality values, such as a region or a customer tier. Tag
+// values are formatted with fmt.Sprint.
+func Tags(keys ...string) Option {
+	return optionFunc(func(cfg *config) {
+		if cfg.tags == nil {
+			cfg.tags = make(map[string]struct{}, len(keys))
+		}
+		for _, k := range keys {
+			cfg.tags[k] = struct{}{}
+		}
+	})
+}
+
+// Extras limits the fields sent as Sentry extras to those with the given
+// keys. By default, every field that isn't a tag is sent.
+func Extras(keys ...string) Option {
+	return optionFunc(func(cfg *config) {
+		if cfg.extras == nil {
+			cfg.extras = make(map[string]struct{}, len(keys))
+		}
+		for _, k := range keys {
+			cfg.extras[k] = struct{}{}
+		}
+	})
+}
+
+// FlushTimeout sets how long Sync waits for buffered events to be sent to
+// Sentry. It defaults to five seconds.
+func FlushTimeout(d time.Duration) Option {
+	return optionFunc(func(cfg *config) {
+		cfg.flushTimeout = d
+	})
+}
+
+// Breadcrumbs records entries at or above the given level, but below the
+// Core's minimum level, as Sentry breadcrumbs. They're attached to the
+// events sent for later entries, showing what led up to an error.
+//
+// The Core keeps as many breadcrumbs as the client's MaxBreadcrumbs option
+// allows, dropping the oldest first.
+func Breadcrumbs(lvl zapcore.Level) Option {
+	return optionFunc(func(cfg *config) {
+		cfg.breadcrumbs = true
+		cfg.breadcrumbLevel = lvl
+	})
+}
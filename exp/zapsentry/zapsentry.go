@@ -0,0 +1,301 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package zapsentry sends log entries to Sentry, so that errors show up in
+// Sentry without instrumenting each call site.
+//
+// NewCore returns a Core that turns each entry at or above a minimum level
+// into a Sentry event. Tee it with the Core that writes the logs:
+//
+//	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: dsn})
+//	if err != nil {
+//	  return err
+//	}
+//	core := zapcore.NewTee(
+//	  logger.Core(),
+//	  zapsentry.NewCore(client, zapcore.ErrorLevel, zapsentry.Tags("region")),
+//	)
+//	logger = zap.New(core, zap.AddStacktrace(zapcore.ErrorLevel))
+//
+// The package is a separate module so that zap itself doesn't depend on the
+// Sentry SDK.
+package zapsentry // import "github.com/toujourser/zap/exp/zapsentry"
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/toujourser/zap/zapcore"
+)
+
+const (
+	_defaultFlushTimeout   = 5 * time.Second
+	_defaultMaxBreadcrumbs = 30 // matches the Sentry SDK
+)
+
+var errFlushTimeout = errors.New("zapsentry: timed out flushing events")
+
+// config holds the state shared by a Sentry Core and the Cores derived from
+// it with With.
+type config struct {
+	minLevel        zapcore.Level
+	breadcrumbs     bool
+	breadcrumbLevel zapcore.Level
+	maxBreadcrumbs  int
+	tags            map[string]struct{}
+	extras          map[string]struct{} // nil if all fields are sent
+	flushTimeout    time.Duration
+
+	// scope holds the breadcrumbs applied to each event.
+	scope *sentry.Scope
+}
+
+// NewCore returns a Core that sends entries at or above minLevel to Sentry
+// using the given client.
+//
+// Each entry becomes a Sentry event with the entry's message, level, and
+// logger name. Fields, including those added with With, become the event's
+// extras or, if configured with the Tags option, its tags. The first error
+// field becomes the event's exception. If the entry has a stack trace, as
+// added by zap.AddStacktrace, it's attached to the exception or, without an
+// error, to the event's thread.
+//
+// Entries at DPanicLevel and above are flushed to Sentry before Write
+// returns, since the process may be about to exit. Other events are sent
+// asynchronously by the client; call Sync to wait for them.
+//
+// If client is nil, NewCore returns a no-op Core.
+func NewCore(client *sentry.Client, minLevel zapcore.Level, opts ...Option) zapcore.Core {
+	if client == nil {
+		return zapcore.NewNopCore()
+	}
+
+	cfg := &config{
+		minLevel:     minLevel,
+		flushTimeout: _defaultFlushTimeout,
+		scope:        sentry.NewScope(),
+	}
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+
+	cfg.maxBreadcrumbs = client.Options().MaxBreadcrumbs
+	switch {
+	case cfg.maxBreadcrumbs < 0:
+		// Breadcrumbs are disabled for the client.
+		cfg.breadcrumbs = false
+	case cfg.maxBreadcrumbs == 0:
+		cfg.maxBreadcrumbs = _defaultMaxBreadcrumbs
+	}
+	if cfg.breadcrumbs && cfg.breadcrumbLevel >= cfg.minLevel {
+		cfg.breadcrumbs = false
+	}
+
+	return &core{client: client, cfg: cfg}
+}
+
+type core struct {
+	client *sentry.Client
+	cfg    *config
+	fields []zapcore.Field
+}
+
+var (
+	_ zapcore.Core           = (*core)(nil)
+	_ zapcore.LeveledEnabler = (*core)(nil)
+)
+
+func (c *core) Level() zapcore.Level {
+	if c.cfg.breadcrumbs {
+		return c.cfg.breadcrumbLevel
+	}
+	return c.cfg.minLevel
+}
+
+func (c *core) Enabled(lvl zapcore.Level) bool {
+	return lvl >= c.Level()
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	return &core{client: c.client, cfg: c.cfg, fields: all}
+}
+
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	var err error
+	for _, fs := range [][]zapcore.Field{c.fields, fields} {
+		for _, f := range fs {
+			f.AddTo(enc)
+			if e, ok := f.Interface.(error); ok && f.Type == zapcore.ErrorType && err == nil {
+				err = e
+			}
+		}
+	}
+
+	if ent.Level < c.cfg.minLevel {
+		c.cfg.scope.AddBreadcrumb(&sentry.Breadcrumb{
+			Type:      "default",
+			Category:  ent.LoggerName,
+			Message:   ent.Message,
+			Data:      enc.Fields,
+			Level:     sentryLevel(ent.Level),
+			Timestamp: ent.Time,
+		}, c.cfg.maxBreadcrumbs)
+		return nil
+	}
+
+	event := sentry.NewEvent()
+	event.Level = sentryLevel(ent.Level)
+	event.Message = ent.Message
+	event.Timestamp = ent.Time
+	event.Logger = ent.LoggerName
+	for k, v := range enc.Fields {
+		if _, ok := c.cfg.tags[k]; ok {
+			event.Tags[k] = fmt.Sprint(v)
+			continue
+		}
+		if _, ok := c.cfg.extras[k]; ok || c.cfg.extras == nil {
+			event.Extra[k] = v
+		}
+	}
+
+	stack := parseStack(ent.Stack)
+	var hint *sentry.EventHint
+	if err != nil {
+		event.Exception = []sentry.Exception{{
+			Type:       reflect.TypeOf(err).String(),
+			Value:      err.Error(),
+			Stacktrace: stack,
+		}}
+		hint = &sentry.EventHint{OriginalException: err}
+	} else if stack != nil {
+		event.Threads = []sentry.Thread{{
+			Stacktrace: stack,
+			Current:    true,
+		}}
+	}
+
+	c.client.CaptureEvent(event, hint, c.cfg.scope)
+
+	if ent.Level > zapcore.ErrorLevel {
+		// Since we may be crashing the program, flush the event before
+		// returning.
+		return c.Sync()
+	}
+	return nil
+}
+
+// Sync waits up to the configured timeout for buffered events to be sent.
+func (c *core) Sync() error {
+	if !c.client.Flush(c.cfg.flushTimeout) {
+		return errFlushTimeout
+	}
+	return nil
+}
+
+func sentryLevel(lvl zapcore.Level) sentry.Level {
+	switch {
+	case lvl <= zapcore.DebugLevel:
+		return sentry.LevelDebug
+	case lvl == zapcore.InfoLevel:
+		return sentry.LevelInfo
+	case lvl == zapcore.WarnLevel:
+		return sentry.LevelWarning
+	case lvl == zapcore.ErrorLevel:
+		return sentry.LevelError
+	default:
+		return sentry.LevelFatal
+	}
+}
+
+// parseStack converts a stack trace formatted by zap, with a line holding
+// the function name and a tab-indented line holding the file and line
+// number for each frame, into a Sentry stack trace. It returns nil for an
+// empty stack.
+func parseStack(stack string) *sentry.Stacktrace {
+	lines := strings.Split(strings.TrimSuffix(stack, "\n"), "\n")
+	if len(lines) < 2 {
+		return nil
+	}
+
+	// Sentry expects the outermost frame first, so fill the frames in
+	// reverse.
+	frames := make([]sentry.Frame, len(lines)/2)
+	for i := range frames {
+		module, function := splitFunction(lines[2*i])
+		file, line := splitLocation(strings.TrimPrefix(lines[2*i+1], "\t"))
+		frames[len(frames)-1-i] = sentry.Frame{
+			Function: function,
+			Module:   module,
+			Filename: filepath.Base(file),
+			AbsPath:  file,
+			Lineno:   line,
+			InApp:    !isStandardLibrary(module),
+		}
+	}
+	return &sentry.Stacktrace{Frames: frames}
+}
+
+// splitFunction splits a fully qualified function name, such as
+// "github.com/toujourser/zap.(*Logger).Error", into its package path and
+// the name within the package.
+func splitFunction(name string) (pkg, function string) {
+	slash := strings.LastIndexByte(name, '/') + 1
+	if dot := strings.IndexByte(name[slash:], '.'); dot >= 0 {
+		return name[:slash+dot], name[slash+dot+1:]
+	}
+	return "", name
+}
+
+// splitLocation splits a "file:line" location.
+func splitLocation(loc string) (file string, line int) {
+	if i := strings.LastIndexByte(loc, ':'); i >= 0 {
+		if n, err := strconv.Atoi(loc[i+1:]); err == nil {
+			return loc[:i], n
+		}
+	}
+	return loc, 0
+}
+
+// isStandardLibrary reports whether the package path belongs to the
+// standard library, whose first path element never contains a dot.
+func isStandardLibrary(pkg string) bool {
+	if pkg == "" || pkg == "main" {
+		return false
+	}
+	first, _, _ := strings.Cut(pkg, "/")
+	return !strings.Contains(first, ".")
+}
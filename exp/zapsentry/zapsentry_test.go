@@ -0,0 +1,229 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapsentry
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap"
+	"github.com/toujourser/zap/zapcore"
+)
+
+// transport records the events sent by a Sentry client.
+type transport struct {
+	mu      sync.Mutex
+	events  []*sentry.Event
+	flushes int
+	flushOK bool
+}
+
+func (t *transport) Configure(sentry.ClientOptions) {}
+
+func (t *transport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func (t *transport) Flush(time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.flushes++
+	return t.flushOK
+}
+
+func (t *transport) Events() []*sentry.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*sentry.Event(nil), t.events...)
+}
+
+func newClient(t *testing.T, opts sentry.ClientOptions) (*sentry.Client, *transport) {
+	tr := &transport{flushOK: true}
+	opts.Dsn = "https://public@sentry.example.com/1"
+	opts.Transport = tr
+	client, err := sentry.NewClient(opts)
+	require.NoError(t, err, "Failed to create Sentry client.")
+	return client, tr
+}
+
+func TestCoreSendsEvents(t *testing.T) {
+	client, tr := newClient(t, sentry.ClientOptions{})
+	logger := zap.New(
+		NewCore(client, zapcore.ErrorLevel, Tags("region"), FlushTimeout(time.Second)),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+	).Named("payments").With(zap.String("region", "us-east"))
+
+	logger.Info("not sent")
+	err := errors.New("card declined")
+	logger.Error("charge failed", zap.Int("amount", 42), zap.Error(err))
+
+	events := tr.Events()
+	require.Len(t, events, 1, "Expected only entries at ErrorLevel and above to be sent.")
+	event := events[0]
+	assert.Equal(t, sentry.LevelError, event.Level, "Unexpected level.")
+	assert.Equal(t, "charge failed", event.Message, "Unexpected message.")
+	assert.Equal(t, "payments", event.Logger, "Unexpected logger name.")
+	assert.Equal(t, "us-east", event.Tags["region"], "Expected context field to be sent as a tag.")
+	assert.NotContains(t, event.Extra, "region", "Tags shouldn't also be sent as extras.")
+	assert.Equal(t, int64(42), event.Extra["amount"], "Expected field to be sent as an extra.")
+
+	require.Len(t, event.Exception, 1, "Expected the error to be sent as an exception.")
+	exception := event.Exception[0]
+	assert.Equal(t, "*errors.errorString", exception.Type, "Unexpected exception type.")
+	assert.Equal(t, "card declined", exception.Value, "Unexpected exception value.")
+	require.NotNil(t, exception.Stacktrace, "Expected the stack trace to be attached.")
+	frames := exception.Stacktrace.Frames
+	require.NotEmpty(t, frames, "Expected stack frames.")
+	innermost := frames[len(frames)-1]
+	assert.Equal(t, "TestCoreSendsEvents", innermost.Function, "Expected the innermost frame last.")
+	assert.Equal(t, "github.com/toujourser/zap/exp/zapsentry", innermost.Module, "Unexpected module.")
+	assert.Equal(t, "zapsentry_test.go", innermost.Filename, "Unexpected file name.")
+	assert.True(t, innermost.InApp, "Expected frames outside the standard library to be in-app.")
+
+	require.NoError(t, logger.Sync(), "Unexpected error syncing.")
+	assert.Equal(t, 1, tr.flushes, "Expected Sync to flush the client.")
+}
+
+func TestCoreStackWithoutError(t *testing.T) {
+	client, tr := newClient(t, sentry.ClientOptions{})
+	logger := zap.New(NewCore(client, zapcore.WarnLevel), zap.AddStacktrace(zapcore.WarnLevel))
+
+	logger.Warn("slow request")
+
+	events := tr.Events()
+	require.Len(t, events, 1, "Expected an event.")
+	assert.Equal(t, sentry.LevelWarning, events[0].Level, "Unexpected level.")
+	assert.Empty(t, events[0].Exception, "Expected no exception without an error field.")
+	require.Len(t, events[0].Threads, 1, "Expected the stack trace to be attached to the thread.")
+	assert.NotEmpty(t, events[0].Threads[0].Stacktrace.Frames, "Expected stack frames.")
+}
+
+func TestCoreExtras(t *testing.T) {
+	client, tr := newClient(t, sentry.ClientOptions{})
+	logger := zap.New(NewCore(client, zapcore.ErrorLevel, Extras("user", "request")))
+
+	logger.Error("failed",
+		zap.String("user", "alice"),
+		zap.String("password", "hunter2"),
+		zap.Dict("request", zap.String("path", "/pay")),
+	)
+
+	events := tr.Events()
+	require.Len(t, events, 1, "Expected an event.")
+	assert.Equal(t, map[string]interface{}{
+		"user":    "alice",
+		"request": map[string]interface{}{"path": "/pay"},
+	}, events[0].Extra, "Expected only allowed fields to be sent as extras.")
+}
+
+func TestCoreBreadcrumbs(t *testing.T) {
+	client, tr := newClient(t, sentry.ClientOptions{MaxBreadcrumbs: 2})
+	logger := zap.New(NewCore(client, zapcore.ErrorLevel, Breadcrumbs(zapcore.InfoLevel)))
+
+	logger.Debug("ignored")
+	logger.Info("first")
+	logger.Info("second", zap.Int("attempt", 2))
+	logger.Warn("third")
+	logger.Error("failed")
+
+	events := tr.Events()
+	require.Len(t, events, 1, "Expected breadcrumbs not to be sent as events.")
+	var messages []string
+	for _, b := range events[0].Breadcrumbs {
+		messages = append(messages, b.Message)
+	}
+	assert.Equal(t, []string{"second", "third"}, messages, "Expected the most recent breadcrumbs.")
+	assert.Equal(t, map[string]interface{}{"attempt": int64(2)}, events[0].Breadcrumbs[0].Data, "Unexpected breadcrumb data.")
+	assert.Equal(t, sentry.LevelWarning, events[0].Breadcrumbs[1].Level, "Unexpected breadcrumb level.")
+}
+
+func TestCoreLevels(t *testing.T) {
+	client, _ := newClient(t, sentry.ClientOptions{})
+
+	core := NewCore(client, zapcore.ErrorLevel)
+	assert.Equal(t, zapcore.ErrorLevel, zapcore.LevelOf(core), "Unexpected level.")
+
+	core = NewCore(client, zapcore.ErrorLevel, Breadcrumbs(zapcore.InfoLevel))
+	assert.Equal(t, zapcore.InfoLevel, zapcore.LevelOf(core), "Expected breadcrumbs to lower the level.")
+
+	core = NewCore(client, zapcore.InfoLevel, Breadcrumbs(zapcore.ErrorLevel))
+	assert.Equal(t, zapcore.InfoLevel, zapcore.LevelOf(core), "Expected breadcrumbs above the minimum level to be ignored.")
+
+	client, _ = newClient(t, sentry.ClientOptions{MaxBreadcrumbs: -1})
+	core = NewCore(client, zapcore.ErrorLevel, Breadcrumbs(zapcore.InfoLevel))
+	assert.Equal(t, zapcore.ErrorLevel, zapcore.LevelOf(core), "Expected breadcrumbs to be disabled by the client.")
+
+	tests := []struct {
+		lvl  zapcore.Level
+		want sentry.Level
+	}{
+		{zapcore.DebugLevel - 1, sentry.LevelDebug},
+		{zapcore.DebugLevel, sentry.LevelDebug},
+		{zapcore.InfoLevel, sentry.LevelInfo},
+		{zapcore.WarnLevel, sentry.LevelWarning},
+		{zapcore.ErrorLevel, sentry.LevelError},
+		{zapcore.DPanicLevel, sentry.LevelFatal},
+		{zapcore.PanicLevel, sentry.LevelFatal},
+		{zapcore.FatalLevel, sentry.LevelFatal},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, sentryLevel(tt.lvl), "Unexpected Sentry level for %v.", tt.lvl)
+	}
+}
+
+func TestCoreFlushes(t *testing.T) {
+	client, tr := newClient(t, sentry.ClientOptions{})
+	logger := zap.New(NewCore(client, zapcore.ErrorLevel))
+
+	logger.DPanic("crashing")
+	assert.Equal(t, 1, tr.flushes, "Expected entries above ErrorLevel to be flushed.")
+
+	tr.flushOK = false
+	assert.ErrorIs(t, logger.Core().Sync(), errFlushTimeout, "Expected an error if flushing times out.")
+}
+
+func TestNilClient(t *testing.T) {
+	core := NewCore(nil, zapcore.ErrorLevel)
+	assert.False(t, core.Enabled(zapcore.FatalLevel), "Expected a no-op Core without a client.")
+}
+
+func TestParseStack(t *testing.T) {
+	stack := "github.com/toujourser/zap.(*Logger).Error\n" +
+		"\t/src/zap/logger.go:250\n" +
+		"main.main\n" +
+		"\t/src/app/main.go:12\n" +
+		"runtime.main\n" +
+		"\t/go/src/runtime/proc.go:267"
+
+	assert.Nil(t, parseStack(""), "Expected no stack trace for an empty stack.")
+	assert.Equal(t, &sentry.Stacktrace{Frames: []sentry.Frame{
+		{Function: "main", Module: "runtime", Filename: "proc.go", AbsPath: "/go/src/runtime/proc.go", Lineno: 267},
+		{Function: "main", Module: "main", Filename: "main.go", AbsPath: "/src/app/main.go", Lineno: 12, InApp: true},
+		{Function: "(*Logger).Error", Module: "github.com/toujourser/zap", Filename: "logger.go", AbsPath: "/src/zap/logger.go", Lineno: 250, InApp: true},
+	}}, parseStack(stack), "Unexpected stack trace.")
+}
@@ -39,6 +39,7 @@ type Handler struct {
 	addCaller  bool
 	addStackAt slog.Level
 	callerSkip int
+	levelOf    func(slog.Level) zapcore.Level
 
 	// List of unapplied groups.
 	//
@@ -54,6 +55,7 @@ func NewHandler(core zapcore.Core, opts ...HandlerOption) *Handler {
 	h := &Handler{
 		core:       core,
 		addStackAt: slog.LevelError,
+		levelOf:    convertSlogLevel,
 	}
 	for _, v := range opts {
 		v.apply(h)
@@ -115,6 +117,9 @@ func convertAttrToField(attr slog.Attr) zapcore.Field {
 
 // convertSlogLevel maps slog Levels to zap Levels.
 // Note that there is some room between slog levels while zap levels are continuous, so we can't 1:1 map them.
+// Custom levels are rounded down to the nearest standard slog level,
+// so slog.LevelInfo+2 is treated as zap's InfoLevel.
+// Use [WithLevelMapper] to change this behavior.
 // See also https://go.googlesource.com/proposal/+/master/design/56345-structured-logging.md?pli=1#levels
 func convertSlogLevel(l slog.Level) zapcore.Level {
 	switch {
@@ -131,13 +136,13 @@ func convertSlogLevel(l slog.Level) zapcore.Level {
 
 // Enabled reports whether the handler handles records at the given level.
 func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
-	return h.core.Enabled(convertSlogLevel(level))
+	return h.core.Enabled(h.levelOf(level))
 }
 
 // Handle handles the Record.
 func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
 	ent := zapcore.Entry{
-		Level:      convertSlogLevel(record.Level),
+		Level:      h.levelOf(record.Level),
 		Time:       record.Time,
 		Message:    record.Message,
 		LoggerName: h.name,
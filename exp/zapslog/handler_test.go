@@ -24,6 +24,7 @@ package zapslog
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log/slog"
 	"sync"
@@ -155,6 +156,70 @@ func TestWithName(t *testing.T) {
 	})
 }
 
+func TestLevelMapping(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give slog.Level
+		want zapcore.Level
+	}{
+		{slog.LevelDebug - 4, zapcore.DebugLevel},
+		{slog.LevelDebug, zapcore.DebugLevel},
+		{slog.LevelInfo, zapcore.InfoLevel},
+		{slog.LevelInfo + 2, zapcore.InfoLevel},
+		{slog.LevelWarn, zapcore.WarnLevel},
+		{slog.LevelError, zapcore.ErrorLevel},
+		{slog.LevelError + 8, zapcore.ErrorLevel},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.give.String(), func(t *testing.T) {
+			t.Parallel()
+
+			fac, logs := observer.New(zapcore.DebugLevel)
+			sl := slog.New(NewHandler(fac))
+			sl.Log(context.Background(), tt.give, "msg")
+
+			require.Len(t, logs.AllUntimed(), 1, "Expected exactly one entry to be logged")
+			assert.Equal(t, tt.want, logs.AllUntimed()[0].Level, "Unexpected level")
+		})
+	}
+}
+
+func TestWithLevelMapper(t *testing.T) {
+	t.Parallel()
+
+	const levelTrace = slog.LevelDebug - 4
+	fac, logs := observer.New(zapcore.InfoLevel)
+	sl := slog.New(NewHandler(fac, WithLevelMapper(func(l slog.Level) zapcore.Level {
+		if l <= levelTrace {
+			return zapcore.DebugLevel
+		}
+		return zapcore.WarnLevel
+	})))
+
+	assert.False(t, sl.Enabled(context.Background(), levelTrace), "Expected trace to be disabled")
+	assert.True(t, sl.Enabled(context.Background(), slog.LevelDebug), "Expected debug to be enabled")
+
+	sl.Log(context.Background(), levelTrace, "trace")
+	sl.Debug("debug")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1, "Expected exactly one entry to be logged")
+	assert.Equal(t, "debug", entries[0].Message, "Unexpected message")
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level, "Unexpected level")
+
+	t.Run("nil restores default", func(t *testing.T) {
+		fac, logs := observer.New(zapcore.DebugLevel)
+		sl := slog.New(NewHandler(fac, WithLevelMapper(nil)))
+		sl.Warn("msg")
+
+		require.Len(t, logs.AllUntimed(), 1, "Expected exactly one entry to be logged")
+		assert.Equal(t, zapcore.WarnLevel, logs.AllUntimed()[0].Level, "Unexpected level")
+	})
+}
+
 func TestInlineGroup(t *testing.T) {
 	fac, observedLogs := observer.New(zapcore.DebugLevel)
 
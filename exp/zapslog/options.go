@@ -22,7 +22,11 @@
 
 package zapslog
 
-import "log/slog"
+import (
+	"log/slog"
+
+	"github.com/toujourser/zap/zapcore"
+)
 
 // A HandlerOption configures a slog Handler.
 type HandlerOption interface {
@@ -70,3 +74,18 @@ func AddStacktraceAt(lvl slog.Level) HandlerOption {
 		log.addStackAt = lvl
 	})
 }
+
+// WithLevelMapper configures how the Handler translates slog levels,
+// including custom numeric levels, into zap levels.
+//
+// By default, levels are rounded down to the nearest standard slog level
+// and mapped to the matching zap level.
+// A nil function restores the default mapping.
+func WithLevelMapper(f func(slog.Level) zapcore.Level) HandlerOption {
+	return handlerOptionFunc(func(handler *Handler) {
+		if f == nil {
+			f = convertSlogLevel
+		}
+		handler.levelOf = f
+	})
+}
@@ -21,8 +21,13 @@
 package zap
 
 import (
+	"encoding"
+	"encoding/json"
 	"fmt"
 	"math"
+	"net/netip"
+	"net/url"
+	"sort"
 	"time"
 
 	"github.com/toujourser/zap/internal/stacktrace"
@@ -336,12 +341,110 @@ func Namespace(key string) Field {
 	return Field{Key: key, Type: zapcore.NamespaceType}
 }
 
+// Secret constructs a field that carries a sensitive string, such as a
+// password or an API token. Encoders log zapcore.RedactedPlaceholder in place
+// of the value unless configured with EncoderConfig.RevealSecrets.
+func Secret(key string, val string) Field {
+	return Field{Key: key, Type: zapcore.RedactedType, String: val}
+}
+
+// Redact masks the value of an existing field in the same way as Secret. The
+// field's key is preserved. Namespace and no-op fields are returned as-is.
+func Redact(field Field) Field {
+	switch field.Type {
+	case zapcore.NamespaceType, zapcore.SkipType, zapcore.RedactedType:
+		return field
+	}
+	return Field{Key: field.Key, Type: zapcore.RedactedType, Interface: field}
+}
+
+// OmitEmpty marks a field to be omitted if its value is the zero value of its
+// type: an empty string, zero, false, a nil error, a zero time.Time, or an
+// empty byte slice. Emptiness is checked when the field is encoded, so lazily
+// evaluated fields such as Stringer are omitted if their String method returns
+// an empty string.
+//
+// The values of Object, Array, Inline, Reflect, and other marshaled fields
+// aren't inspected: they're omitted only if they're nil. Namespace and no-op
+// fields are returned as-is.
+func OmitEmpty(field Field) Field {
+	switch field.Type {
+	case zapcore.NamespaceType, zapcore.SkipType, zapcore.OmitEmptyType:
+		return field
+	}
+	return Field{Key: field.Key, Type: zapcore.OmitEmptyType, Interface: field}
+}
+
 // Stringer constructs a field with the given key and the output of the value's
 // String method. The Stringer's String method is called lazily.
 func Stringer(key string, val fmt.Stringer) Field {
 	return Field{Key: key, Type: zapcore.StringerType, Interface: val}
 }
 
+// TextMarshaler constructs a field with the given key and the text form of
+// the value, as returned by its MarshalText method. The method is called
+// lazily; if it fails, the error is logged under the key with an "Error"
+// suffix instead.
+func TextMarshaler(key string, val encoding.TextMarshaler) Field {
+	return Field{Key: key, Type: zapcore.TextMarshalerType, Interface: val}
+}
+
+// Addr constructs a field with the given key and the text form of a
+// netip.Addr. The address is formatted without allocating an intermediate
+// string; the zero Addr is encoded as an empty string.
+func Addr(key string, val netip.Addr) Field {
+	return Field{Key: key, Type: zapcore.TextAppenderType, Interface: val}
+}
+
+// AddrPort constructs a field with the given key and the text form of a
+// netip.AddrPort, like "1.2.3.4:80" or "[::1]:80".
+func AddrPort(key string, val netip.AddrPort) Field {
+	return Field{Key: key, Type: zapcore.TextAppenderType, Interface: val}
+}
+
+// Prefix constructs a field with the given key and the text form of a
+// netip.Prefix in CIDR notation.
+func Prefix(key string, val netip.Prefix) Field {
+	return Field{Key: key, Type: zapcore.TextAppenderType, Interface: val}
+}
+
+// URL constructs a field with the given key and the text form of a URL. Any
+// password in the URL's userinfo is redacted. If the URL is nil, the field
+// is encoded as null.
+func URL(key string, val *url.URL) Field {
+	if val == nil {
+		return nilField(key)
+	}
+	return Field{Key: key, Type: zapcore.TextAppenderType, Interface: redactedURL{val}}
+}
+
+// UUID constructs a field with the given key and a 16-byte UUID, formatted
+// in the canonical lowercase 8-4-4-4-12 hexadecimal form. It accepts any
+// [16]byte-based UUID type through a conversion, like zap.UUID("id", [16]byte(id)).
+func UUID(key string, val [16]byte) Field {
+	return Field{Key: key, Type: zapcore.TextAppenderType, Interface: uuid(val)}
+}
+
+type redactedURL struct{ u *url.URL }
+
+func (r redactedURL) AppendTo(b []byte) []byte {
+	return append(b, r.u.Redacted()...)
+}
+
+type uuid [16]byte
+
+func (u uuid) AppendTo(b []byte) []byte {
+	const hex = "0123456789abcdef"
+	for i, c := range u {
+		switch i {
+		case 4, 6, 8, 10:
+			b = append(b, '-')
+		}
+		b = append(b, hex[c>>4], hex[c&0x0f])
+	}
+	return b
+}
+
 // Time constructs a Field with the given key and value. The encoder
 // controls how the time is serialized.
 func Time(key string, val time.Time) Field {
@@ -406,8 +509,12 @@ func Object(key string, val zapcore.ObjectMarshaler) Field {
 
 // Inline constructs a Field that is similar to Object, but it
 // will add the elements of the provided ObjectMarshaler to the
-// current namespace.
+// current namespace: at the top level of the entry, or inside the
+// most recently opened Namespace. A nil ObjectMarshaler adds nothing.
 func Inline(val zapcore.ObjectMarshaler) Field {
+	if val == nil {
+		return Skip()
+	}
 	return zapcore.Field{
 		Type:      zapcore.InlineMarshalerType,
 		Interface: val,
@@ -441,6 +548,103 @@ func DictObject(val ...Field) zapcore.ObjectMarshaler {
 	return dictObject(val)
 }
 
+// StringMap constructs a field containing the provided map, encoded as an
+// object with its keys in sorted order. A nil map is encoded as an empty
+// object.
+func StringMap(key string, val map[string]string) Field {
+	return Object(key, stringMap(val))
+}
+
+// Int64Map constructs a field containing the provided map, encoded as an
+// object with its keys in sorted order. A nil map is encoded as an empty
+// object.
+func Int64Map(key string, val map[string]int64) Field {
+	return Object(key, int64Map(val))
+}
+
+// BoolMap constructs a field containing the provided map, encoded as an
+// object with its keys in sorted order. A nil map is encoded as an empty
+// object.
+func BoolMap(key string, val map[string]bool) Field {
+	return Object(key, boolMap(val))
+}
+
+type stringMap map[string]string
+
+func (m stringMap) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	marshalSortedMap(enc, m, zapcore.ObjectEncoder.AddString)
+	return nil
+}
+
+type int64Map map[string]int64
+
+func (m int64Map) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	marshalSortedMap(enc, m, zapcore.ObjectEncoder.AddInt64)
+	return nil
+}
+
+type boolMap map[string]bool
+
+func (m boolMap) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	marshalSortedMap(enc, m, zapcore.ObjectEncoder.AddBool)
+	return nil
+}
+
+// _sortedMapStackKeys is the largest map whose keys marshalSortedMap sorts
+// without allocating.
+const _sortedMapStackKeys = 16
+
+// marshalSortedMap adds the entries of m to enc in sorted key order.
+func marshalSortedMap[V any](enc zapcore.ObjectEncoder, m map[string]V, add func(zapcore.ObjectEncoder, string, V)) {
+	if len(m) > _sortedMapStackKeys {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			add(enc, k, m[k])
+		}
+		return
+	}
+
+	// Insertion sort keeps small key sets on the stack.
+	var arr [_sortedMapStackKeys]string
+	keys := arr[:0]
+	for k := range m {
+		i := len(keys)
+		keys = append(keys, k)
+		for ; i > 0 && keys[i-1] > k; i-- {
+			keys[i] = keys[i-1]
+		}
+		keys[i] = k
+	}
+	for _, k := range keys {
+		add(enc, k, m[k])
+	}
+}
+
+// RawJSON constructs a field that embeds the given JSON document in the
+// output as-is. Encoders that don't support raw JSON, and documents that
+// aren't valid, compact JSON, fall back to encoding the value with
+// reflection; the console encoder's key=value mode writes it as a string.
+//
+// The JSON encoder checks each document before embedding it unless
+// EncoderConfig.TrustRawJSON is set.
+func RawJSON(key string, val json.RawMessage) Field {
+	return Field{Key: key, Type: zapcore.RawJSONType, Interface: val}
+}
+
+// anyRawJSON builds a RawJSON field for zap.Any. Unlike anyFieldC, it stores
+// the value as-is rather than unboxing and re-boxing it, which would
+// allocate.
+type anyRawJSON struct{}
+
+func (anyRawJSON) Any(key string, val any) Field {
+	// val is guaranteed to be a json.RawMessage.
+	return Field{Key: key, Type: zapcore.RawJSONType, Interface: val}
+}
+
 // We discovered an issue where zap.Any can cause a performance degradation
 // when used in new goroutines.
 //
@@ -615,6 +819,22 @@ func Any(key string, value interface{}) Field {
 		c = anyFieldC[error](NamedError)
 	case []error:
 		c = anyFieldC[[]error](Errors)
+	case map[string]string:
+		c = anyFieldC[map[string]string](StringMap)
+	case map[string]int64:
+		c = anyFieldC[map[string]int64](Int64Map)
+	case map[string]bool:
+		c = anyFieldC[map[string]bool](BoolMap)
+	case json.RawMessage:
+		c = anyRawJSON{}
+	case netip.Addr:
+		c = anyFieldC[netip.Addr](Addr)
+	case netip.AddrPort:
+		c = anyFieldC[netip.AddrPort](AddrPort)
+	case netip.Prefix:
+		c = anyFieldC[netip.Prefix](Prefix)
+	case *url.URL:
+		c = anyFieldC[*url.URL](URL)
 	case fmt.Stringer:
 		c = anyFieldC[fmt.Stringer](Stringer)
 	default:
@@ -0,0 +1,279 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+const (
+	schemeGELFUDP = "gelf+udp"
+
+	_defaultGELFPort      = "12201"
+	_defaultGELFChunkSize = 1420
+	_gelfChunkHeaderLen   = 12
+	_gelfMaxChunks        = 128
+	_maxUDPPayload        = 65507
+)
+
+// _gelfChunkMagic starts every chunk of a chunked GELF message.
+var _gelfChunkMagic = [2]byte{0x1e, 0x0f}
+
+// gelfOptions configures a gelfSink. See newGELFSinkFromURL for the URL
+// parameters that map onto these options.
+type gelfOptions struct {
+	// Address is the address of the Graylog server.
+	Address string
+	// Compress is the compression applied to each message: "none", "gzip",
+	// or "zlib".
+	Compress string
+	// ChunkSize is the maximum size of each datagram. Larger messages are
+	// split into chunks.
+	ChunkSize int
+}
+
+// gelfSink is a Sink that sends each written line to a Graylog server as a
+// GELF message over UDP, compressing it if configured to, and splitting it
+// into GELF chunks if it doesn't fit in a single datagram.
+//
+// The connection is dialed on the first write and redialed after a failed
+// write.
+type gelfSink struct {
+	opts gelfOptions
+	dial func(network, address string) (net.Conn, error)
+
+	mu     sync.Mutex
+	conn   net.Conn
+	nextID uint64 // ID of the next chunked message
+	zbuf   bytes.Buffer
+	closed bool
+}
+
+var _ Sink = (*gelfSink)(nil)
+
+func newGELFSink(opts gelfOptions, dial func(network, address string) (net.Conn, error)) *gelfSink {
+	return &gelfSink{
+		opts: opts,
+		dial: dial,
+		// Start at a random ID so that messages from different processes
+		// sending to the same server don't collide.
+		nextID: rand.Uint64(),
+	}
+}
+
+// newGELFSinkFromURL builds a sink from a URL such as
+// gelf+udp://graylog:12201?compress=gzip. See Open for the supported query
+// parameters.
+func newGELFSinkFromURL(u *url.URL) (Sink, error) {
+	if u.User != nil {
+		return nil, fmt.Errorf("user and password not allowed with %s URLs: got %v", u.Scheme, u)
+	}
+	if u.Fragment != "" {
+		return nil, fmt.Errorf("fragments not allowed with %s URLs: got %v", u.Scheme, u)
+	}
+	if u.Path != "" && u.Path != "/" {
+		return nil, fmt.Errorf("paths not allowed with %s URLs: got %v", u.Scheme, u)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("%s URLs must include a host: got %v", u.Scheme, u)
+	}
+
+	opts, err := parseGELFOptions(u.Query())
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s URL %v: %w", u.Scheme, u, err)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = _defaultGELFPort
+	}
+	opts.Address = net.JoinHostPort(u.Hostname(), port)
+	return newGELFSink(opts, net.Dial), nil
+}
+
+func parseGELFOptions(q url.Values) (gelfOptions, error) {
+	opts := gelfOptions{
+		Compress:  "none",
+		ChunkSize: _defaultGELFChunkSize,
+	}
+
+	for key, vals := range q {
+		if len(vals) != 1 {
+			return opts, fmt.Errorf("parameter %q must be specified exactly once", key)
+		}
+		val := vals[0]
+
+		switch key {
+		case "compress":
+			switch val {
+			case "none", "gzip", "zlib":
+				opts.Compress = val
+			default:
+				return opts, fmt.Errorf("unknown compression %q", val)
+			}
+		case "chunkSize":
+			size, err := strconv.Atoi(val)
+			if err != nil {
+				return opts, fmt.Errorf("parameter %q: %w", key, err)
+			}
+			if size <= _gelfChunkHeaderLen || size > _maxUDPPayload {
+				return opts, fmt.Errorf("parameter %q must be between %d and %d: got %d",
+					key, _gelfChunkHeaderLen+1, _maxUDPPayload, size)
+			}
+			opts.ChunkSize = size
+		default:
+			return opts, fmt.Errorf("unknown parameter %q", key)
+		}
+	}
+	return opts, nil
+}
+
+// Write sends each line in p as a GELF message.
+func (s *gelfSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, errors.New("write to closed GELF sink")
+	}
+
+	var err error
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		if line = bytes.TrimRight(line, "\r"); len(line) > 0 {
+			err = multierr.Append(err, s.send(line))
+		}
+	}
+	return len(p), err
+}
+
+// Sync is a no-op: messages are sent as they're written.
+func (s *gelfSink) Sync() error {
+	return nil
+}
+
+// Close closes the connection.
+func (s *gelfSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *gelfSink) send(msg []byte) error {
+	msg, err := s.compress(msg)
+	if err != nil {
+		return fmt.Errorf("compress GELF message: %w", err)
+	}
+
+	if s.conn == nil {
+		conn, err := s.dial("udp", s.opts.Address)
+		if err != nil {
+			return fmt.Errorf("connect to GELF server: %w", err)
+		}
+		s.conn = conn
+	}
+
+	if len(msg) <= s.opts.ChunkSize {
+		return s.write(msg)
+	}
+
+	dataLen := s.opts.ChunkSize - _gelfChunkHeaderLen
+	count := (len(msg) + dataLen - 1) / dataLen
+	if count > _gelfMaxChunks {
+		return fmt.Errorf("GELF message of %d bytes needs %d chunks, more than the maximum of %d",
+			len(msg), count, _gelfMaxChunks)
+	}
+
+	id := s.nextID
+	s.nextID++
+
+	chunk := make([]byte, 0, s.opts.ChunkSize)
+	for seq := 0; seq < count; seq++ {
+		data := msg[seq*dataLen:]
+		if len(data) > dataLen {
+			data = data[:dataLen]
+		}
+
+		chunk = append(chunk[:0], _gelfChunkMagic[:]...)
+		chunk = binary.BigEndian.AppendUint64(chunk, id)
+		chunk = append(chunk, byte(seq), byte(count))
+		chunk = append(chunk, data...)
+		if err := s.write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *gelfSink) write(datagram []byte) error {
+	if _, err := s.conn.Write(datagram); err != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+		return fmt.Errorf("write to GELF server: %w", err)
+	}
+	return nil
+}
+
+// compress returns msg compressed as configured. The result is only valid
+// until the next call.
+func (s *gelfSink) compress(msg []byte) ([]byte, error) {
+	var w io.WriteCloser
+	s.zbuf.Reset()
+	switch s.opts.Compress {
+	case "gzip":
+		w = gzip.NewWriter(&s.zbuf)
+	case "zlib":
+		w = zlib.NewWriter(&s.zbuf)
+	default:
+		return msg, nil
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return s.zbuf.Bytes(), nil
+}
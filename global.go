@@ -140,6 +140,8 @@ func redirectStdLogAt(l *Logger, level zapcore.Level) (func(), error) {
 
 func levelToFunc(logger *Logger, lvl zapcore.Level) (func(string, ...Field), error) {
 	switch lvl {
+	case TraceLevel:
+		return logger.Trace, nil
 	case DebugLevel:
 		return logger.Debug, nil
 	case InfoLevel:
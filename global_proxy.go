@@ -0,0 +1,404 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/toujourser/zap/zapcore"
+)
+
+// LNamed returns a GlobalLogger with the given name: a proxy that delegates
+// each call to the current global Logger (see L) named name. Unlike
+// L().Named(name), it follows later calls to ReplaceGlobals, so libraries
+// can hold one in a package-level variable before the application
+// configures logging:
+//
+//	var log = zap.LNamed("kafka")
+//
+//	func init() {
+//	  log.Info("ready") // uses whatever global is current
+//	}
+//
+// Caller annotations report the caller of the GlobalLogger's methods.
+func LNamed(name string) *GlobalLogger {
+	return &GlobalLogger{name: name}
+}
+
+// SNamed is like LNamed, but returns a proxy for the global SugaredLogger.
+func SNamed(name string) *GlobalSugaredLogger {
+	return &GlobalSugaredLogger{name: name}
+}
+
+// globalResolved caches the logger that a proxy derived from a global.
+type globalResolved[T any] struct {
+	global *Logger
+	logger T
+}
+
+// A GlobalLogger is a proxy for a named child of the global Logger. Its
+// methods look up the current global on each call, so that ReplaceGlobals
+// retargets it. Construct one with LNamed.
+type GlobalLogger struct {
+	name   string
+	fields []Field
+
+	cache atomic.Pointer[globalResolved[*Logger]]
+}
+
+// resolve returns the current global Logger with the proxy's name and
+// fields applied. It skips one more caller to account for the proxy's
+// methods.
+func (g *GlobalLogger) resolve() *Logger {
+	global := L()
+	if c := g.cache.Load(); c != nil && c.global == global {
+		return c.logger
+	}
+	l := global.Named(g.name).With(g.fields...).WithCallerSkip(1)
+	g.cache.Store(&globalResolved[*Logger]{global: global, logger: l})
+	return l
+}
+
+// Logger returns the Logger that the proxy currently delegates to. Unlike
+// the proxy, it's not retargeted by later calls to ReplaceGlobals.
+func (g *GlobalLogger) Logger() *Logger {
+	return g.resolve().WithCallerSkip(-1)
+}
+
+// Named returns a proxy with a new path segment added to its name, as
+// Logger.Named does.
+func (g *GlobalLogger) Named(name string) *GlobalLogger {
+	return &GlobalLogger{name: joinLoggerNames(g.name, name), fields: g.fields}
+}
+
+// With returns a proxy that adds the given fields to every entry. Fields
+// that require evaluation, such as Objects, are evaluated each time the
+// proxy is retargeted.
+func (g *GlobalLogger) With(fields ...Field) *GlobalLogger {
+	if len(fields) == 0 {
+		return g
+	}
+	return &GlobalLogger{
+		name:   g.name,
+		fields: append(g.fields[:len(g.fields):len(g.fields)], fields...),
+	}
+}
+
+// Sugar returns a proxy for the sugared form of this proxy's Logger.
+func (g *GlobalLogger) Sugar() *GlobalSugaredLogger {
+	return &GlobalSugaredLogger{name: g.name, fields: g.fields}
+}
+
+// Check is like Logger.Check, using the current global Logger.
+func (g *GlobalLogger) Check(lvl zapcore.Level, msg string) *zapcore.CheckedEntry {
+	return g.resolve().Check(lvl, msg)
+}
+
+// Log is like Logger.Log, using the current global Logger.
+func (g *GlobalLogger) Log(lvl zapcore.Level, msg string, fields ...Field) {
+	g.resolve().Log(lvl, msg, fields...)
+}
+
+// Trace is like Logger.Trace, using the current global Logger.
+func (g *GlobalLogger) Trace(msg string, fields ...Field) {
+	g.resolve().Trace(msg, fields...)
+}
+
+// Debug is like Logger.Debug, using the current global Logger.
+func (g *GlobalLogger) Debug(msg string, fields ...Field) {
+	g.resolve().Debug(msg, fields...)
+}
+
+// Info is like Logger.Info, using the current global Logger.
+func (g *GlobalLogger) Info(msg string, fields ...Field) {
+	g.resolve().Info(msg, fields...)
+}
+
+// Warn is like Logger.Warn, using the current global Logger.
+func (g *GlobalLogger) Warn(msg string, fields ...Field) {
+	g.resolve().Warn(msg, fields...)
+}
+
+// Error is like Logger.Error, using the current global Logger.
+func (g *GlobalLogger) Error(msg string, fields ...Field) {
+	g.resolve().Error(msg, fields...)
+}
+
+// DPanic is like Logger.DPanic, using the current global Logger.
+func (g *GlobalLogger) DPanic(msg string, fields ...Field) {
+	g.resolve().DPanic(msg, fields...)
+}
+
+// Panic is like Logger.Panic, using the current global Logger.
+func (g *GlobalLogger) Panic(msg string, fields ...Field) {
+	g.resolve().Panic(msg, fields...)
+}
+
+// Fatal is like Logger.Fatal, using the current global Logger.
+func (g *GlobalLogger) Fatal(msg string, fields ...Field) {
+	g.resolve().Fatal(msg, fields...)
+}
+
+// Sync flushes the current global Logger.
+func (g *GlobalLogger) Sync() error {
+	return L().Sync()
+}
+
+// A GlobalSugaredLogger is a proxy for a named child of the global
+// SugaredLogger. Its methods look up the current global on each call, so
+// that ReplaceGlobals retargets it. Construct one with SNamed.
+type GlobalSugaredLogger struct {
+	name   string
+	fields []Field       // from GlobalLogger.With
+	args   []interface{} // from With
+
+	cache atomic.Pointer[globalResolved[*SugaredLogger]]
+}
+
+// resolve returns the current global SugaredLogger with the proxy's name and
+// context applied. It skips one more caller to account for the proxy's
+// methods.
+func (g *GlobalSugaredLogger) resolve() *SugaredLogger {
+	global := L()
+	if c := g.cache.Load(); c != nil && c.global == global {
+		return c.logger
+	}
+	s := global.Named(g.name).With(g.fields...).Sugar().With(g.args...).WithCallerSkip(1)
+	g.cache.Store(&globalResolved[*SugaredLogger]{global: global, logger: s})
+	return s
+}
+
+// Named returns a proxy with a new path segment added to its name, as
+// SugaredLogger.Named does.
+func (g *GlobalSugaredLogger) Named(name string) *GlobalSugaredLogger {
+	return &GlobalSugaredLogger{name: joinLoggerNames(g.name, name), fields: g.fields, args: g.args}
+}
+
+// With returns a proxy that adds the given loosely-typed key-value pairs to
+// every entry, as SugaredLogger.With does. The pairs are converted to
+// fields each time the proxy is retargeted.
+func (g *GlobalSugaredLogger) With(args ...interface{}) *GlobalSugaredLogger {
+	if len(args) == 0 {
+		return g
+	}
+	return &GlobalSugaredLogger{
+		name:   g.name,
+		fields: g.fields,
+		args:   append(g.args[:len(g.args):len(g.args)], args...),
+	}
+}
+
+// Log is like SugaredLogger.Log, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Log(lvl zapcore.Level, args ...interface{}) {
+	g.resolve().Log(lvl, args...)
+}
+
+// Logf is like SugaredLogger.Logf, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Logf(lvl zapcore.Level, template string, args ...interface{}) {
+	g.resolve().Logf(lvl, template, args...)
+}
+
+// Logw is like SugaredLogger.Logw, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Logw(lvl zapcore.Level, msg string, keysAndValues ...interface{}) {
+	g.resolve().Logw(lvl, msg, keysAndValues...)
+}
+
+// Logln is like SugaredLogger.Logln, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Logln(lvl zapcore.Level, args ...interface{}) {
+	g.resolve().Logln(lvl, args...)
+}
+
+// Trace is like SugaredLogger.Trace, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Trace(args ...interface{}) {
+	g.resolve().Trace(args...)
+}
+
+// Debug is like SugaredLogger.Debug, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Debug(args ...interface{}) {
+	g.resolve().Debug(args...)
+}
+
+// Info is like SugaredLogger.Info, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Info(args ...interface{}) {
+	g.resolve().Info(args...)
+}
+
+// Warn is like SugaredLogger.Warn, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Warn(args ...interface{}) {
+	g.resolve().Warn(args...)
+}
+
+// Error is like SugaredLogger.Error, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Error(args ...interface{}) {
+	g.resolve().Error(args...)
+}
+
+// DPanic is like SugaredLogger.DPanic, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) DPanic(args ...interface{}) {
+	g.resolve().DPanic(args...)
+}
+
+// Panic is like SugaredLogger.Panic, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Panic(args ...interface{}) {
+	g.resolve().Panic(args...)
+}
+
+// Fatal is like SugaredLogger.Fatal, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Fatal(args ...interface{}) {
+	g.resolve().Fatal(args...)
+}
+
+// Tracef is like SugaredLogger.Tracef, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Tracef(template string, args ...interface{}) {
+	g.resolve().Tracef(template, args...)
+}
+
+// Debugf is like SugaredLogger.Debugf, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Debugf(template string, args ...interface{}) {
+	g.resolve().Debugf(template, args...)
+}
+
+// Infof is like SugaredLogger.Infof, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Infof(template string, args ...interface{}) {
+	g.resolve().Infof(template, args...)
+}
+
+// Warnf is like SugaredLogger.Warnf, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Warnf(template string, args ...interface{}) {
+	g.resolve().Warnf(template, args...)
+}
+
+// Errorf is like SugaredLogger.Errorf, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Errorf(template string, args ...interface{}) {
+	g.resolve().Errorf(template, args...)
+}
+
+// DPanicf is like SugaredLogger.DPanicf, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) DPanicf(template string, args ...interface{}) {
+	g.resolve().DPanicf(template, args...)
+}
+
+// Panicf is like SugaredLogger.Panicf, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Panicf(template string, args ...interface{}) {
+	g.resolve().Panicf(template, args...)
+}
+
+// Fatalf is like SugaredLogger.Fatalf, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Fatalf(template string, args ...interface{}) {
+	g.resolve().Fatalf(template, args...)
+}
+
+// Tracew is like SugaredLogger.Tracew, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Tracew(msg string, keysAndValues ...interface{}) {
+	g.resolve().Tracew(msg, keysAndValues...)
+}
+
+// Debugw is like SugaredLogger.Debugw, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	g.resolve().Debugw(msg, keysAndValues...)
+}
+
+// Infow is like SugaredLogger.Infow, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Infow(msg string, keysAndValues ...interface{}) {
+	g.resolve().Infow(msg, keysAndValues...)
+}
+
+// Warnw is like SugaredLogger.Warnw, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	g.resolve().Warnw(msg, keysAndValues...)
+}
+
+// Errorw is like SugaredLogger.Errorw, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	g.resolve().Errorw(msg, keysAndValues...)
+}
+
+// DPanicw is like SugaredLogger.DPanicw, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) DPanicw(msg string, keysAndValues ...interface{}) {
+	g.resolve().DPanicw(msg, keysAndValues...)
+}
+
+// Panicw is like SugaredLogger.Panicw, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Panicw(msg string, keysAndValues ...interface{}) {
+	g.resolve().Panicw(msg, keysAndValues...)
+}
+
+// Fatalw is like SugaredLogger.Fatalw, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	g.resolve().Fatalw(msg, keysAndValues...)
+}
+
+// Traceln is like SugaredLogger.Traceln, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Traceln(args ...interface{}) {
+	g.resolve().Traceln(args...)
+}
+
+// Debugln is like SugaredLogger.Debugln, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Debugln(args ...interface{}) {
+	g.resolve().Debugln(args...)
+}
+
+// Infoln is like SugaredLogger.Infoln, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Infoln(args ...interface{}) {
+	g.resolve().Infoln(args...)
+}
+
+// Warnln is like SugaredLogger.Warnln, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Warnln(args ...interface{}) {
+	g.resolve().Warnln(args...)
+}
+
+// Errorln is like SugaredLogger.Errorln, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Errorln(args ...interface{}) {
+	g.resolve().Errorln(args...)
+}
+
+// DPanicln is like SugaredLogger.DPanicln, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) DPanicln(args ...interface{}) {
+	g.resolve().DPanicln(args...)
+}
+
+// Panicln is like SugaredLogger.Panicln, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Panicln(args ...interface{}) {
+	g.resolve().Panicln(args...)
+}
+
+// Fatalln is like SugaredLogger.Fatalln, using the current global SugaredLogger.
+func (g *GlobalSugaredLogger) Fatalln(args ...interface{}) {
+	g.resolve().Fatalln(args...)
+}
+
+// Sync flushes the current global Logger.
+func (g *GlobalSugaredLogger) Sync() error {
+	return L().Sync()
+}
+
+func joinLoggerNames(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	default:
+		return strings.Join([]string{a, b}, ".")
+	}
+}
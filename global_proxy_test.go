@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"testing"
+
+	"github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalLoggerRetargeted(t *testing.T) {
+	log := LNamed("kafka").With(String("k", "v"))
+	sugar := SNamed("kafka").With("k", "v")
+
+	// Logs before the global is replaced go to the no-op global.
+	log.Info("dropped")
+	sugar.Info("dropped")
+
+	for i := 0; i < 2; i++ {
+		withLogger(t, DebugLevel, opts(AddCaller()), func(l *Logger, logs *observer.ObservedLogs) {
+			defer ReplaceGlobals(l.Named("app"))()
+
+			log.Info("typed")
+			log.Named("consumer").Warn("typed")
+			sugar.Infow("sugared", "n", 1)
+			sugar.Named("consumer").Errorf("sugared %d", 2)
+			log.Sugar().Infoln("from", "typed")
+
+			entries := logs.AllUntimed()
+			require.Len(t, entries, 5, "Unexpected number of entries.")
+			for _, ent := range entries {
+				assert.Contains(t, ent.Caller.File, "global_proxy_test.go", "Unexpected caller for %q.", ent.Message)
+				assert.Equal(t, "v", ent.ContextMap()["k"], "Expected the proxy's fields.")
+			}
+			assert.Equal(t, "app.kafka", entries[0].LoggerName, "Unexpected logger name.")
+			assert.Equal(t, "app.kafka.consumer", entries[1].LoggerName, "Unexpected logger name.")
+			assert.Equal(t, "app.kafka", entries[2].LoggerName, "Unexpected logger name.")
+			assert.Equal(t, int64(1), entries[2].ContextMap()["n"], "Expected the sugared fields.")
+			assert.Equal(t, "sugared 2", entries[3].Message, "Unexpected message.")
+			assert.Equal(t, "app.kafka.consumer", entries[3].LoggerName, "Unexpected logger name.")
+			assert.Equal(t, "from typed", entries[4].Message, "Unexpected message.")
+		})
+	}
+}
+
+func TestGlobalLoggerLevels(t *testing.T) {
+	withLogger(t, DebugLevel, opts(AddCaller()), func(l *Logger, logs *observer.ObservedLogs) {
+		defer ReplaceGlobals(l)()
+
+		log := LNamed("")
+		log.Debug("")
+		log.Info("")
+		log.Warn("")
+		log.Error("")
+		log.Log(InfoLevel, "")
+		if ce := log.Check(WarnLevel, ""); ce != nil {
+			ce.Write()
+		}
+		assert.Panics(t, func() { log.Panic("") })
+
+		want := []zapcore.Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, InfoLevel, WarnLevel, PanicLevel}
+		var got []zapcore.Level
+		for _, ent := range logs.TakeAll() {
+			got = append(got, ent.Level)
+			assert.Contains(t, ent.Caller.File, "global_proxy_test.go", "Unexpected caller at %v.", ent.Level)
+			assert.Empty(t, ent.LoggerName, "Expected an unnamed logger.")
+		}
+		assert.Equal(t, want, got, "Unexpected levels.")
+
+		snapshot := log.Logger()
+		snapshot.Info("snapshot")
+		assert.Contains(t, logs.TakeAll()[0].Caller.File, "global_proxy_test.go", "Unexpected caller for the snapshot.")
+		assert.NoError(t, log.Sync(), "Unexpected error syncing.")
+	})
+}
+
+func TestGlobalSugaredLoggerLevels(t *testing.T) {
+	withLogger(t, DebugLevel, opts(AddCaller()), func(l *Logger, logs *observer.ObservedLogs) {
+		defer ReplaceGlobals(l)()
+
+		sugar := SNamed("svc")
+		sugar.Debug("a")
+		sugar.Infof("%s", "b")
+		sugar.Warnw("c")
+		sugar.Errorln("d")
+		sugar.Logw(WarnLevel, "e")
+		assert.Panics(t, func() { sugar.Panicf("f") })
+
+		var msgs []string
+		for _, ent := range logs.TakeAll() {
+			msgs = append(msgs, ent.Message)
+			assert.Contains(t, ent.Caller.File, "global_proxy_test.go", "Unexpected caller for %q.", ent.Message)
+		}
+		assert.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, msgs, "Unexpected messages.")
+		assert.NoError(t, sugar.Sync(), "Unexpected error syncing.")
+	})
+}
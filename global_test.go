@@ -182,9 +182,9 @@ func TestRedirectStdLogAt(t *testing.T) {
 	initialPrefix := log.Prefix()
 
 	// include DPanicLevel here, but do not include Development in options
-	levels := []zapcore.Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel}
+	levels := []zapcore.Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel}
 	for _, level := range levels {
-		withLogger(t, DebugLevel, nil, func(l *Logger, logs *observer.ObservedLogs) {
+		withLogger(t, TraceLevel, nil, func(l *Logger, logs *observer.ObservedLogs) {
 			restore, err := RedirectStdLogAt(l, level)
 			require.NoError(t, err, "Unexpected error.")
 			defer restore()
@@ -0,0 +1,65 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"runtime"
+
+	"github.com/toujourser/zap/internal/pool"
+)
+
+const (
+	_defaultGoroutineDumpLimit = 1 << 20 // 1MiB
+	_goroutineDumpTruncated    = "\n...truncated"
+)
+
+// _goroutineDumpPool holds the buffers that goroutine dumps are captured
+// into. Buffers grow to the largest limit in use.
+var _goroutineDumpPool = pool.New(func() *[]byte {
+	b := make([]byte, 64<<10)
+	return &b
+})
+
+// goroutineDump returns the stacks of all goroutines, truncated to at most
+// limit bytes. A limit of zero selects the default.
+func goroutineDump(limit int) string {
+	if limit <= 0 {
+		limit = _defaultGoroutineDumpLimit
+	}
+
+	bp := _goroutineDumpPool.Get()
+	defer _goroutineDumpPool.Put(bp)
+	if cap(*bp) < limit {
+		*bp = make([]byte, limit)
+	}
+	buf := (*bp)[:limit]
+
+	n := runtime.Stack(buf, true /* all */)
+	if n < limit {
+		return string(buf[:n])
+	}
+	// The dump filled the buffer, so it's probably truncated. Make room
+	// for a marker, but don't exceed the limit.
+	if keep := limit - len(_goroutineDumpTruncated); keep > 0 {
+		return string(buf[:keep]) + _goroutineDumpTruncated
+	}
+	return string(buf[:limit])
+}
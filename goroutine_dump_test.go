@@ -0,0 +1,102 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/toujourser/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parkedGoroutine blocks until stop is closed, so that it shows up in
+// goroutine dumps.
+func parkedGoroutine(started chan<- struct{}, stop <-chan struct{}) {
+	close(started)
+	<-stop
+}
+
+func TestDPanicGoroutineDump(t *testing.T) {
+	started, stop := make(chan struct{}), make(chan struct{})
+	go parkedGoroutine(started, stop)
+	defer close(stop)
+	<-started
+
+	t.Run("development", func(t *testing.T) {
+		opts := []Option{Development(), DPanicGoroutineDump()}
+		withLogger(t, DebugLevel, opts, func(logger *Logger, logs *observer.ObservedLogs) {
+			assert.Panics(t, func() { logger.DPanic("race", String("k", "v")) }, "Expected DPanic to panic.")
+
+			entries := logs.AllUntimed()
+			require.Len(t, entries, 1, "Expected a single entry.")
+			ctx := entries[0].ContextMap()
+			assert.Equal(t, "v", ctx["k"], "Expected fields from the log site.")
+			dump, ok := ctx["goroutines"].(string)
+			require.True(t, ok, "Expected a goroutine dump.")
+			assert.Contains(t, dump, "parkedGoroutine", "Expected the dump to include other goroutines.")
+			assert.Contains(t, dump, "TestDPanicGoroutineDump", "Expected the dump to include the current goroutine.")
+		})
+	})
+
+	t.Run("other levels", func(t *testing.T) {
+		opts := []Option{Development(), DPanicGoroutineDump()}
+		withLogger(t, DebugLevel, opts, func(logger *Logger, logs *observer.ObservedLogs) {
+			logger.Error("not a DPanic")
+			assert.NotContains(t, logs.AllUntimed()[0].ContextMap(), "goroutines", "Unexpected goroutine dump.")
+		})
+	})
+
+	t.Run("production", func(t *testing.T) {
+		withLogger(t, DebugLevel, []Option{DPanicGoroutineDump()}, func(logger *Logger, logs *observer.ObservedLogs) {
+			assert.NotPanics(t, func() { logger.DPanic("race") }, "Unexpected panic in production.")
+			assert.NotContains(t, logs.AllUntimed()[0].ContextMap(), "goroutines", "Unexpected goroutine dump.")
+		})
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		withLogger(t, DebugLevel, []Option{Development()}, func(logger *Logger, logs *observer.ObservedLogs) {
+			assert.Panics(t, func() { logger.DPanic("race") }, "Expected DPanic to panic.")
+			assert.NotContains(t, logs.AllUntimed()[0].ContextMap(), "goroutines", "Unexpected goroutine dump.")
+		})
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		opts := []Option{Development(), DPanicGoroutineDump(), GoroutineDumpLimit(200)}
+		withLogger(t, DebugLevel, opts, func(logger *Logger, logs *observer.ObservedLogs) {
+			assert.Panics(t, func() { logger.DPanic("race") }, "Expected DPanic to panic.")
+			dump := logs.AllUntimed()[0].ContextMap()["goroutines"].(string)
+			assert.Len(t, dump, 200, "Expected the dump to be truncated to the limit.")
+			assert.True(t, strings.HasSuffix(dump, _goroutineDumpTruncated), "Expected a truncation marker.")
+		})
+	})
+}
+
+func TestGoroutineDumpLimit(t *testing.T) {
+	assert.Len(t, goroutineDump(5), 5, "Expected tiny dumps to be truncated without a marker.")
+	assert.LessOrEqual(t, len(goroutineDump(0)), _defaultGoroutineDumpLimit, "Expected the default limit.")
+
+	// Buffers larger than the pooled default are grown and reused.
+	dump := goroutineDump(128 << 10)
+	assert.True(t, strings.HasPrefix(dump, "goroutine "), "Unexpected dump %q.", dump)
+}
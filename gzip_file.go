@@ -0,0 +1,231 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+const (
+	schemeGzipFile = "gzipfile"
+
+	_defaultGzipFlushEvery = time.Second
+)
+
+type gzipFileOptions struct {
+	Level      int           // as accepted by gzip.NewWriterLevel
+	FlushEvery time.Duration // longest an entry stays in the compressor
+}
+
+// gzipFile is a Sink that compresses entries into a file with gzip.
+//
+// Every flush ends the current gzip member, so the file is a sequence of
+// complete members followed by, at most, one member in progress. Standard
+// tools decode such files as a whole, and a file cut short by a crash is
+// still readable up to the last flush. Entries are flushed by Sync, by
+// Close, and by a timer started with each member, so tools following the
+// file see every entry within the flush interval of it being written.
+// The sink runs no goroutines between flushes.
+//
+// Opening an existing file appends new members to it.
+type gzipFile struct {
+	opts gzipFileOptions
+	file *os.File
+
+	mu         sync.Mutex
+	gz         *gzip.Writer
+	open       bool        // whether a member is in progress
+	timer      *time.Timer // flushes the member in progress
+	unreported error       // error from a timed flush, returned by the next Write
+	closed     bool
+}
+
+var _ Sink = (*gzipFile)(nil)
+
+func newGzipFile(file *os.File, opts gzipFileOptions) (*gzipFile, error) {
+	gz, err := gzip.NewWriterLevel(file, opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	if opts.FlushEvery <= 0 {
+		return nil, fmt.Errorf("flush interval must be positive: got %v", opts.FlushEvery)
+	}
+
+	return &gzipFile{
+		opts: opts,
+		file: file,
+		gz:   gz,
+	}, nil
+}
+
+// newGzipFileSinkFromURL builds a gzipFile from a URL like
+//
+//	gzipfile:///var/log/app.log.gz?level=6&flushEvery=1s
+//
+// The supported query parameters are level, the compression level from -2
+// (Huffman coding only) to 9 (best compression), and flushEvery, the
+// longest an entry waits before it's written to the file, as accepted by
+// time.ParseDuration (default 1s).
+func (sr *sinkRegistry) newGzipFileSinkFromURL(u *url.URL) (Sink, error) {
+	if u.User != nil {
+		return nil, fmt.Errorf("user and password not allowed with gzipfile URLs: got %v", u)
+	}
+	if u.Fragment != "" {
+		return nil, fmt.Errorf("fragments not allowed with gzipfile URLs: got %v", u)
+	}
+	if u.Port() != "" {
+		return nil, fmt.Errorf("ports not allowed with gzipfile URLs: got %v", u)
+	}
+	if hn := u.Hostname(); hn != "" && hn != "localhost" {
+		return nil, fmt.Errorf("gzipfile URLs must leave host empty or use localhost: got %v", u)
+	}
+	if u.Path == "" {
+		return nil, fmt.Errorf("gzipfile URLs must include a file name: got %v", u)
+	}
+
+	opts, err := parseGzipFileOptions(u.Query())
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzipfile URL %v: %w", u, err)
+	}
+
+	f, err := sr.openFile(u.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o666)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newGzipFile(f, opts)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func parseGzipFileOptions(q url.Values) (gzipFileOptions, error) {
+	opts := gzipFileOptions{
+		Level:      gzip.DefaultCompression,
+		FlushEvery: _defaultGzipFlushEvery,
+	}
+	for key, vals := range q {
+		if len(vals) != 1 {
+			return opts, fmt.Errorf("parameter %q must be specified exactly once", key)
+		}
+		val := vals[0]
+
+		var err error
+		switch key {
+		case "level":
+			opts.Level, err = strconv.Atoi(val)
+			if err == nil && (opts.Level < gzip.HuffmanOnly || opts.Level > gzip.BestCompression) {
+				err = fmt.Errorf("must be between %d and %d", gzip.HuffmanOnly, gzip.BestCompression)
+			}
+		case "flushEvery":
+			opts.FlushEvery, err = parsePositiveDuration(val)
+		default:
+			return opts, fmt.Errorf("unknown parameter %q", key)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("can't parse %q parameter: %w", key, err)
+		}
+	}
+	return opts, nil
+}
+
+// Write compresses p into the current member, starting one if necessary.
+func (s *gzipFile) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, errors.New("write to closed gzip file")
+	}
+
+	if !s.open {
+		s.gz.Reset(s.file)
+		s.open = true
+		if s.timer == nil {
+			s.timer = time.AfterFunc(s.opts.FlushEvery, s.flushOnTimer)
+		} else {
+			s.timer.Reset(s.opts.FlushEvery)
+		}
+	}
+	n, err := s.gz.Write(p)
+
+	unreported := s.unreported
+	s.unreported = nil
+	return n, multierr.Append(err, unreported)
+}
+
+// Sync ends the current member and flushes the file to stable storage.
+func (s *gzipFile) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	return multierr.Append(s.flush(), s.file.Sync())
+}
+
+// Close ends the current member and closes the file.
+func (s *gzipFile) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return multierr.Append(s.flush(), s.file.Close())
+}
+
+// flush ends the member in progress, if any, writing its trailer to the
+// file. The caller must hold the lock.
+func (s *gzipFile) flush() error {
+	if !s.open {
+		return nil
+	}
+	s.open = false
+	s.timer.Stop()
+	return s.gz.Close()
+}
+
+// flushOnTimer ends the member in progress when the flush interval passes.
+// Since it can't return an error, the next Write does.
+func (s *gzipFile) flushOnTimer() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	if err := s.flush(); err != nil && s.unreported == nil {
+		s.unreported = err
+	}
+}
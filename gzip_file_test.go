@@ -0,0 +1,270 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzipFileURLParsing(t *testing.T) {
+	dir := filepath.ToSlash(t.TempDir())
+
+	tests := []struct {
+		desc    string
+		rawURL  string
+		want    gzipFileOptions
+		wantErr string
+	}{
+		{
+			desc:   "defaults",
+			rawURL: "gzipfile://" + dir + "/app.log.gz",
+			want:   gzipFileOptions{Level: gzip.DefaultCompression, FlushEvery: time.Second},
+		},
+		{
+			desc:   "all parameters",
+			rawURL: "gzipfile://" + dir + "/app.log.gz?level=9&flushEvery=250ms",
+			want:   gzipFileOptions{Level: gzip.BestCompression, FlushEvery: 250 * time.Millisecond},
+		},
+		{
+			desc:   "huffman only",
+			rawURL: "gzipfile://" + dir + "/app.log.gz?level=-2",
+			want:   gzipFileOptions{Level: gzip.HuffmanOnly, FlushEvery: time.Second},
+		},
+		{
+			desc:    "level out of range",
+			rawURL:  "gzipfile://" + dir + "/app.log.gz?level=10",
+			wantErr: `can't parse "level" parameter: must be between -2 and 9`,
+		},
+		{
+			desc:    "zero interval",
+			rawURL:  "gzipfile://" + dir + "/app.log.gz?flushEvery=0s",
+			wantErr: `can't parse "flushEvery" parameter: must be positive`,
+		},
+		{
+			desc:    "repeated parameter",
+			rawURL:  "gzipfile://" + dir + "/app.log.gz?level=1&level=2",
+			wantErr: `parameter "level" must be specified exactly once`,
+		},
+		{
+			desc:    "unknown parameter",
+			rawURL:  "gzipfile://" + dir + "/app.log.gz?flushInterval=1s",
+			wantErr: `unknown parameter "flushInterval"`,
+		},
+		{
+			desc:    "host",
+			rawURL:  "gzipfile://example.com" + dir + "/app.log.gz",
+			wantErr: "must leave host empty",
+		},
+		{
+			desc:    "no path",
+			rawURL:  "gzipfile://",
+			wantErr: "must include a file name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			sink, err := newSinkRegistry().newSink(tt.rawURL)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer func() { assert.NoError(t, sink.Close()) }()
+			assert.Equal(t, tt.want, sink.(*gzipFile).opts, "Unexpected options.")
+		})
+	}
+}
+
+func TestGzipFileOpenFromOutputPaths(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+
+	cfg := NewProductionConfig()
+	cfg.OutputPaths = []string{"gzipfile://" + filepath.ToSlash(path)}
+	logger, err := cfg.Build()
+	require.NoError(t, err, "Unexpected error building logger with a gzipfile URL.")
+
+	logger.Info("hello")
+	require.NoError(t, logger.Sync())
+
+	contents, err := readGzipFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, contents, `"msg":"hello"`)
+}
+
+func TestGzipFileSync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	s := newTestGzipFile(t, path, time.Hour)
+	defer func() { assert.NoError(t, s.Close()) }()
+
+	_, err := s.Write([]byte("a\n"))
+	require.NoError(t, err)
+	require.NoError(t, s.Sync())
+	require.NoError(t, s.Sync(), "Expected Sync without new entries to succeed.")
+
+	_, err = s.Write([]byte("b\n"))
+	require.NoError(t, err)
+	require.NoError(t, s.Sync())
+
+	contents, err := readGzipFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb\n", contents, "Unexpected file contents.")
+	assert.Equal(t, 2, countGzipMembers(t, path), "Expected one member per flush.")
+}
+
+func TestGzipFileFlushesOnTimer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	s := newTestGzipFile(t, path, 10*time.Millisecond)
+	defer func() { assert.NoError(t, s.Close()) }()
+
+	_, err := s.Write([]byte("a\n"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		contents, err := readGzipFile(path)
+		return err == nil && contents == "a\n"
+	}, time.Second, 5*time.Millisecond, "Expected the entry to be flushed without calling Sync.")
+}
+
+func TestGzipFileClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	s := newTestGzipFile(t, path, time.Hour)
+
+	var want strings.Builder
+	for i := 0; i < 1000; i++ {
+		line := fmt.Sprintf(`{"msg":"entry","i":%d}`+"\n", i)
+		want.WriteString(line)
+		_, err := s.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.Close(), "Unexpected error closing the sink without syncing it.")
+	assert.NoError(t, s.Close(), "Expected closing twice to succeed.")
+	assert.NoError(t, s.Sync(), "Expected syncing a closed sink to succeed.")
+
+	_, err := s.Write([]byte("late\n"))
+	assert.Error(t, err, "Expected an error writing to a closed sink.")
+
+	contents, err := readGzipFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, want.String(), contents, "Unexpected file contents.")
+	for _, line := range strings.Split(strings.TrimSuffix(contents, "\n"), "\n") {
+		assert.True(t, json.Valid([]byte(line)), "Expected a whole JSON entry, got %q.", line)
+	}
+}
+
+func TestGzipFileTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	s := newTestGzipFile(t, path, time.Hour)
+	defer func() { assert.NoError(t, s.Close()) }()
+
+	_, err := s.Write([]byte("a\nb\n"))
+	require.NoError(t, err)
+	require.NoError(t, s.Sync())
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	flushed := info.Size()
+
+	_, err = s.Write([]byte(strings.Repeat("c\n", 100)))
+	require.NoError(t, err)
+	require.NoError(t, s.Sync())
+
+	// Simulate a crash in the middle of writing the second member.
+	require.NoError(t, os.Truncate(path, flushed+5))
+	contents, err := readGzipFile(path)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "Expected the second member to be cut short.")
+	assert.Equal(t, "a\nb\n", contents, "Expected entries up to the last complete flush.")
+}
+
+func TestGzipFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	for _, line := range []string{"a\n", "b\n"} {
+		s := newTestGzipFile(t, path, time.Hour)
+		_, err := s.Write([]byte(line))
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+	}
+
+	contents, err := readGzipFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb\n", contents, "Expected reopening the file to append to it.")
+}
+
+func newTestGzipFile(t *testing.T, path string, flushEvery time.Duration) *gzipFile {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o666)
+	require.NoError(t, err)
+	s, err := newGzipFile(f, gzipFileOptions{Level: gzip.DefaultCompression, FlushEvery: flushEvery})
+	require.NoError(t, err)
+	return s
+}
+
+// readGzipFile decompresses every member of the file, returning whatever
+// it could decode along with the first error.
+func readGzipFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	_, err = io.Copy(&out, r)
+	return out.String(), err
+}
+
+func countGzipMembers(t *testing.T, path string) int {
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// bytes.Reader is an io.ByteReader, so the gzip.Reader doesn't read past
+	// the end of each member.
+	br := bytes.NewReader(contents)
+	r, err := gzip.NewReader(br)
+	require.NoError(t, err)
+
+	var n int
+	for {
+		r.Multistream(false)
+		_, err := io.Copy(io.Discard, r)
+		require.NoError(t, err)
+		n++
+		err = r.Reset(br)
+		if err == io.EOF {
+			return n
+		}
+		require.NoError(t, err)
+	}
+}
@@ -21,28 +21,51 @@
 package zap
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 
 	"github.com/toujourser/zap/zapcore"
 )
 
+// _maxLevelRequestBody bounds the size of the PUT request bodies read by the
+// level handlers.
+const _maxLevelRequestBody = 64 << 10
+
+// _levelNames lists the names of the levels accepted by the level handlers,
+// from lowest to highest.
+var _levelNames = func() []string {
+	var names []string
+	for l := zapcore.TraceLevel; l <= zapcore.FatalLevel; l++ {
+		names = append(names, l.String())
+	}
+	return names
+}()
+
 // ServeHTTP is a simple JSON endpoint that can report on or change the current
 // logging level.
 //
 // # GET
 //
-// The GET request returns a JSON description of the current logging level like:
+// The GET request returns a JSON description of the current logging level,
+// along with the names of all the levels it can be set to, like:
 //
-//	{"level":"info"}
+//	{"level":"info","levels":["trace","debug","info","warn","error","dpanic","panic","fatal"]}
 //
 // # PUT
 //
 // The PUT request changes the logging level. It is perfectly safe to change the
-// logging level while a program is running. Two content types are supported:
+// logging level while a program is running. The response describes the new
+// level and the level it replaced, like:
+//
+//	{"level":"debug","previous":"info"}
+//
+// Unknown levels are rejected with a 400 Bad Request response naming the
+// input. Three content types are supported:
 //
 //	Content-Type: application/x-www-form-urlencoded
 //
@@ -52,20 +75,31 @@ import (
 //	level=debug
 //
 // The request body takes precedence over the query parameter, if both are
-// specified.
+// specified. A body that holds just the name of a level is accepted too.
 //
-// This content type is the default for a curl PUT request. Following are two
-// example curl requests that both set the logging level to debug.
+// This content type is the default for a curl PUT request. Following are three
+// example curl requests that all set the logging level to debug.
 //
 //	curl -X PUT localhost:8080/log/level?level=debug
 //	curl -X PUT localhost:8080/log/level -d level=debug
+//	curl -X PUT localhost:8080/log/level -d debug
+//
+//	Content-Type: text/plain
+//
+// With this content type, the body holds the name of the level, optionally
+// surrounded by whitespace, like:
+//
+//	debug
+//
+// If the body is empty, the level is read from the query parameter.
 //
 // For any other content type, the payload is expected to be JSON encoded and
 // look like:
 //
 //	{"level":"info"}
 //
-// An example curl request could look like this:
+// If the body is empty, the level is read from the query parameter. An
+// example curl request could look like this:
 //
 //	curl -X PUT localhost:8080/log/level -H "Content-Type: application/json" -d '{"level":"debug"}'
 func (lvl AtomicLevel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -79,15 +113,20 @@ func (lvl AtomicLevel) serveHTTP(w http.ResponseWriter, r *http.Request) error {
 	type errorResponse struct {
 		Error string `json:"error"`
 	}
-	type payload struct {
-		Level zapcore.Level `json:"level"`
+	type getPayload struct {
+		Level  zapcore.Level `json:"level"`
+		Levels []string      `json:"levels"`
+	}
+	type putPayload struct {
+		Level    zapcore.Level `json:"level"`
+		Previous zapcore.Level `json:"previous"`
 	}
 
 	enc := json.NewEncoder(w)
 
 	switch r.Method {
 	case http.MethodGet:
-		return enc.Encode(payload{Level: lvl.Level()})
+		return enc.Encode(getPayload{Level: lvl.Level(), Levels: _levelNames})
 
 	case http.MethodPut:
 		requestedLvl, err := decodePutRequest(r.Header.Get("Content-Type"), r)
@@ -95,8 +134,8 @@ func (lvl AtomicLevel) serveHTTP(w http.ResponseWriter, r *http.Request) error {
 			w.WriteHeader(http.StatusBadRequest)
 			return enc.Encode(errorResponse{Error: err.Error()})
 		}
-		lvl.SetLevel(requestedLvl)
-		return enc.Encode(payload{Level: lvl.Level()})
+		previous := zapcore.Level(int8(lvl.l.Swap(int32(requestedLvl))))
+		return enc.Encode(putPayload{Level: requestedLvl, Previous: previous})
 
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -108,22 +147,53 @@ func (lvl AtomicLevel) serveHTTP(w http.ResponseWriter, r *http.Request) error {
 
 // Decodes incoming PUT requests and returns the requested logging level.
 func decodePutRequest(contentType string, r *http.Request) (zapcore.Level, error) {
-	if contentType == "application/x-www-form-urlencoded" {
-		return decodePutURL(r)
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, _maxLevelRequestBody))
+	if err != nil {
+		return 0, fmt.Errorf("read request body: %v", err)
+	}
+
+	switch contentType {
+	case "application/x-www-form-urlencoded":
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		lvl, err := decodePutURL(r)
+		if errors.Is(err, errNoLevel) && isLevelText(body) {
+			// curl -d sends bare level names as forms.
+			return decodeLevelText(bytes.TrimSpace(body))
+		}
+		return lvl, err
+	case "text/plain":
+		if text := bytes.TrimSpace(body); len(text) > 0 {
+			return decodeLevelText(text)
+		}
+		return decodePutQuery(r)
+	default:
+		if len(bytes.TrimSpace(body)) == 0 {
+			return decodePutQuery(r)
+		}
+		return decodePutJSON(bytes.NewReader(body))
 	}
-	return decodePutJSON(r.Body)
 }
 
+var errNoLevel = errors.New("must specify logging level")
+
 func decodePutURL(r *http.Request) (zapcore.Level, error) {
 	lvl := r.FormValue("level")
 	if lvl == "" {
-		return 0, errors.New("must specify logging level")
+		return 0, errNoLevel
 	}
-	var l zapcore.Level
-	if err := l.UnmarshalText([]byte(lvl)); err != nil {
-		return 0, err
+	return decodeLevelText([]byte(lvl))
+}
+
+func decodePutQuery(r *http.Request) (zapcore.Level, error) {
+	lvl := r.URL.Query().Get("level")
+	if lvl == "" {
+		return 0, errNoLevel
 	}
-	return l, nil
+	return decodeLevelText([]byte(lvl))
 }
 
 func decodePutJSON(body io.Reader) (zapcore.Level, error) {
@@ -134,7 +204,27 @@ func decodePutJSON(body io.Reader) (zapcore.Level, error) {
 		return 0, fmt.Errorf("malformed request body: %v", err)
 	}
 	if pld.Level == nil {
-		return 0, errors.New("must specify logging level")
+		return 0, errNoLevel
 	}
 	return *pld.Level, nil
 }
+
+// decodeLevelText parses the name of a level. Unlike Level.UnmarshalText, it
+// doesn't accept an empty name.
+func decodeLevelText(text []byte) (zapcore.Level, error) {
+	if len(text) == 0 {
+		return 0, errNoLevel
+	}
+	var l zapcore.Level
+	if err := l.UnmarshalText(text); err != nil {
+		return 0, err
+	}
+	return l, nil
+}
+
+// isLevelText reports whether body holds a single word rather than an
+// URL-encoded form.
+func isLevelText(body []byte) bool {
+	text := bytes.TrimSpace(body)
+	return len(text) > 0 && bytes.IndexAny(text, "=& \t\r\n") < 0
+}
@@ -45,6 +45,7 @@ func TestAtomicLevelServeHTTP(t *testing.T) {
 		body          string
 		expectedCode  int
 		expectedLevel zapcore.Level
+		expectedError string // optional
 	}{
 		{
 			desc:          "GET",
@@ -92,6 +93,97 @@ func TestAtomicLevelServeHTTP(t *testing.T) {
 			expectedLevel: zap.WarnLevel,
 			body:          `{"level":"warn"}`,
 		},
+		{
+			desc:          "PUT query parameters without content type",
+			method:        http.MethodPut,
+			query:         "?level=warn",
+			expectedCode:  http.StatusOK,
+			expectedLevel: zap.WarnLevel,
+		},
+		{
+			desc:          "PUT JSON content type with query parameters",
+			method:        http.MethodPut,
+			query:         "?level=warn",
+			expectedCode:  http.StatusOK,
+			expectedLevel: zap.WarnLevel,
+			contentType:   "application/json",
+		},
+		{
+			desc:          "PUT plain text",
+			method:        http.MethodPut,
+			expectedCode:  http.StatusOK,
+			expectedLevel: zap.DebugLevel,
+			contentType:   "text/plain",
+			body:          "debug\n",
+		},
+		{
+			desc:          "PUT plain text with charset",
+			method:        http.MethodPut,
+			expectedCode:  http.StatusOK,
+			expectedLevel: zap.ErrorLevel,
+			contentType:   "text/plain; charset=utf-8",
+			body:          " ERROR ",
+		},
+		{
+			desc:          "PUT plain text takes precedence over query",
+			method:        http.MethodPut,
+			query:         "?level=info",
+			expectedCode:  http.StatusOK,
+			expectedLevel: zap.DebugLevel,
+			contentType:   "text/plain",
+			body:          "debug",
+		},
+		{
+			desc:          "PUT plain text query parameters",
+			method:        http.MethodPut,
+			query:         "?level=warn",
+			expectedCode:  http.StatusOK,
+			expectedLevel: zap.WarnLevel,
+			contentType:   "text/plain",
+		},
+		{
+			desc:          "PUT URL encoded bare level",
+			method:        http.MethodPut,
+			expectedCode:  http.StatusOK,
+			expectedLevel: zap.DebugLevel,
+			contentType:   "application/x-www-form-urlencoded",
+			body:          "debug\n",
+		},
+		{
+			desc:          "PUT plain text bad level",
+			method:        http.MethodPut,
+			expectedCode:  http.StatusBadRequest,
+			contentType:   "text/plain",
+			body:          "verbose",
+			expectedError: `unrecognized level: "verbose"`,
+		},
+		{
+			desc:          "PUT URL encoded bare bad level",
+			method:        http.MethodPut,
+			expectedCode:  http.StatusBadRequest,
+			contentType:   "application/x-www-form-urlencoded",
+			body:          "verbose",
+			expectedError: `unrecognized level: "verbose"`,
+		},
+		{
+			desc:          "PUT query parameters bad level",
+			method:        http.MethodPut,
+			query:         "?level=verbose",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: `unrecognized level: "verbose"`,
+		},
+		{
+			desc:         "PUT plain text unspecified",
+			method:       http.MethodPut,
+			expectedCode: http.StatusBadRequest,
+			contentType:  "text/plain",
+			body:         "\n",
+		},
+		{
+			desc:         "PUT JSON empty",
+			method:       http.MethodPut,
+			expectedCode: http.StatusBadRequest,
+		},
 		{
 			desc:         "PUT JSON unrecognized",
 			method:       http.MethodPut,
@@ -181,14 +273,31 @@ func TestAtomicLevelServeHTTP(t *testing.T) {
 				}
 				require.NoError(t, json.NewDecoder(res.Body).Decode(&pld), "Decoding response body")
 				assert.NotEmpty(t, pld.Error, "Expected an error message")
+				if tt.expectedError != "" {
+					assert.Contains(t, pld.Error, tt.expectedError, "Unexpected error message")
+				}
+				assert.Equal(t, zapcore.InfoLevel, lvl.Level(), "Level changed by a failed request")
 				return
 			}
 
 			var pld struct {
-				Level zapcore.Level `json:"level"`
+				Level    zapcore.Level  `json:"level"`
+				Previous *zapcore.Level `json:"previous"`
+				Levels   []string       `json:"levels"`
 			}
 			require.NoError(t, json.NewDecoder(res.Body).Decode(&pld), "Decoding response body")
 			assert.Equal(t, tt.expectedLevel, pld.Level, "Unexpected logging level returned")
+			assert.Equal(t, tt.expectedLevel, lvl.Level(), "Unexpected logging level set")
+			if tt.method == http.MethodGet {
+				assert.Nil(t, pld.Previous, "Unexpected previous level in GET response")
+				assert.Equal(t,
+					[]string{"trace", "debug", "info", "warn", "error", "dpanic", "panic", "fatal"},
+					pld.Levels, "Unexpected list of levels")
+			} else {
+				require.NotNil(t, pld.Previous, "Expected the previous level in PUT response")
+				assert.Equal(t, zapcore.InfoLevel, *pld.Previous, "Unexpected previous level")
+				assert.Nil(t, pld.Levels, "Unexpected list of levels in PUT response")
+			}
 		})
 	}
 }
@@ -0,0 +1,523 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+const (
+	_schemeHTTPPostPrefix = "httppost+"
+
+	_defaultHTTPBatchSize     = 100
+	_defaultHTTPFlushInterval = time.Second
+	_defaultHTTPMaxBuffer     = 4 << 20 // 4 MiB
+	_defaultHTTPMaxRetries    = 3
+	_defaultHTTPMinBackoff    = 100 * time.Millisecond
+	_defaultHTTPMaxBackoff    = 5 * time.Second
+	_defaultHTTPTimeout       = 10 * time.Second
+)
+
+// _httpPostSchemes are the URL schemes handled by the HTTP sink. Removing
+// the "httppost+" prefix gives the scheme of the collector's URL.
+var _httpPostSchemes = []string{_schemeHTTPPostPrefix + "http", _schemeHTTPPostPrefix + "https"}
+
+var _httpCredentials = struct {
+	sync.Mutex
+	funcs map[string]func(*http.Request) error
+}{funcs: make(map[string]func(*http.Request) error)}
+
+// RegisterHTTPCredentials registers a function that authenticates the
+// requests of HTTP sinks whose URL names it with the credentials parameter,
+// usually by setting a header:
+//
+//	zap.RegisterHTTPCredentials("collector", func(req *http.Request) error {
+//	  req.Header.Set("Authorization", "Bearer "+token.Load())
+//	  return nil
+//	})
+//
+// The function is called for every request, including retries, so it can
+// return refreshed credentials. If it returns an error, the batch isn't sent.
+// Names must be unique.
+func RegisterHTTPCredentials(name string, fn func(*http.Request) error) error {
+	if name == "" {
+		return errors.New("can't register HTTP credentials for empty string")
+	}
+	if fn == nil {
+		return fmt.Errorf("can't register nil HTTP credentials for %q", name)
+	}
+
+	_httpCredentials.Lock()
+	defer _httpCredentials.Unlock()
+	if _, ok := _httpCredentials.funcs[name]; ok {
+		return fmt.Errorf("HTTP credentials already registered for %q", name)
+	}
+	_httpCredentials.funcs[name] = fn
+	return nil
+}
+
+func lookupHTTPCredentials(name string) (func(*http.Request) error, bool) {
+	_httpCredentials.Lock()
+	defer _httpCredentials.Unlock()
+	fn, ok := _httpCredentials.funcs[name]
+	return fn, ok
+}
+
+// httpSinkOptions configures an httpSink. See newHTTPSinkFromURL for the URL
+// parameters that map onto these options.
+type httpSinkOptions struct {
+	// Endpoint is the collector's URL.
+	Endpoint string
+	// BatchSize is the number of entries sent in each request.
+	BatchSize int
+	// FlushInterval is the longest an entry waits for its batch to fill.
+	FlushInterval time.Duration
+	// Gzip compresses request bodies.
+	Gzip bool
+	// MaxBuffer is the maximum number of bytes held in memory while
+	// requests are failing.
+	MaxBuffer int
+	// MaxRetries is the number of times a failed request is retried before
+	// its batch is dropped.
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the delay between retries.
+	MinBackoff, MaxBackoff time.Duration
+	// Timeout bounds each request.
+	Timeout time.Duration
+	// Credentials names the function registered with
+	// RegisterHTTPCredentials that authenticates requests, if any.
+	Credentials string
+}
+
+// httpSink is a Sink that POSTs entries to an HTTP collector in batches of
+// newline-delimited JSON.
+//
+// Each Write is one entry. Entries are collected into batches, which are
+// sent in order by a background goroutine when they're full or have waited
+// for the flush interval. Requests that fail with a network error, a 5xx
+// status, or 429 Too Many Requests are retried with exponential backoff and
+// jitter; other failures drop the batch immediately.
+//
+// While requests are failing, batches are held in memory up to a limit. If
+// the limit is reached, the oldest batches are dropped. Write reports failed
+// requests and dropped entries once per outage, so that they reach the
+// logger's error output without flooding it.
+type httpSink struct {
+	opts   httpSinkOptions
+	client *http.Client
+	auth   func(*http.Request) error
+
+	// sendMu serializes deliveries, so that batches are sent in order.
+	sendMu sync.Mutex
+
+	mu            sync.Mutex
+	batch         bytes.Buffer // entries waiting for their batch to fill
+	batchLen      int
+	queue         [][]byte // full batches waiting to be sent, oldest first
+	queueBytes    int
+	dropped       int   // entries dropped during the current outage
+	lastErr       error // most recent delivery error
+	unreported    error // error to return from the next Write
+	reported      bool  // whether a failed request was reported during the current outage
+	reportedDrops bool  // whether dropped entries were reported during the current outage
+	started       bool
+	closed        bool
+
+	wake chan struct{} // wakes the delivery loop; buffered
+	done chan struct{} // closed by Close
+	wg   sync.WaitGroup
+}
+
+var _ Sink = (*httpSink)(nil)
+
+func newHTTPSink(opts httpSinkOptions, client *http.Client, auth func(*http.Request) error) *httpSink {
+	return &httpSink{
+		opts:   opts,
+		client: client,
+		auth:   auth,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+// newHTTPSinkFromURL builds a sink from a URL such as
+// httppost+https://collector/logs?batchSize=500. The collector's URL is the
+// sink's URL without the "httppost+" prefix and the following query
+// parameters, which configure the sink:
+//
+//   - batchSize: entries sent in each request (default 100)
+//   - flushInterval: longest an entry waits for its batch to fill, as
+//     accepted by time.ParseDuration (default 1s)
+//   - gzip: whether to compress requests, as accepted by strconv.ParseBool
+//     (default false)
+//   - maxBuffer: bytes held in memory while requests fail (default 4MiB)
+//   - maxRetries: retries of each failed request (default 3)
+//   - minBackoff, maxBackoff: delay between retries (default 100ms and 5s)
+//   - timeout: timeout for each request (default 10s)
+//   - credentials: name of the function registered with
+//     RegisterHTTPCredentials that authenticates requests
+//
+// Other query parameters are sent to the collector.
+func newHTTPSinkFromURL(u *url.URL) (Sink, error) {
+	if u.Fragment != "" {
+		return nil, fmt.Errorf("fragments not allowed with %s URLs: got %v", u.Scheme, u)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("%s URLs must include a host: got %v", u.Scheme, u)
+	}
+
+	query := u.Query()
+	opts, err := parseHTTPSinkOptions(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s URL %v: %w", u.Scheme, u, err)
+	}
+
+	var auth func(*http.Request) error
+	if opts.Credentials != "" {
+		var ok bool
+		if auth, ok = lookupHTTPCredentials(opts.Credentials); !ok {
+			return nil, fmt.Errorf("no HTTP credentials registered for %q", opts.Credentials)
+		}
+	}
+
+	endpoint := *u
+	endpoint.Scheme = strings.TrimPrefix(u.Scheme, _schemeHTTPPostPrefix)
+	endpoint.RawQuery = query.Encode()
+	opts.Endpoint = endpoint.String()
+
+	return newHTTPSink(opts, &http.Client{Timeout: opts.Timeout}, auth), nil
+}
+
+// parseHTTPSinkOptions parses the sink's parameters, removing them from q.
+func parseHTTPSinkOptions(q url.Values) (httpSinkOptions, error) {
+	opts := httpSinkOptions{
+		BatchSize:     _defaultHTTPBatchSize,
+		FlushInterval: _defaultHTTPFlushInterval,
+		MaxBuffer:     _defaultHTTPMaxBuffer,
+		MaxRetries:    _defaultHTTPMaxRetries,
+		MinBackoff:    _defaultHTTPMinBackoff,
+		MaxBackoff:    _defaultHTTPMaxBackoff,
+		Timeout:       _defaultHTTPTimeout,
+	}
+	for key, vals := range q {
+		var err error
+		switch key {
+		case "batchSize", "flushInterval", "gzip", "maxBuffer", "maxRetries",
+			"minBackoff", "maxBackoff", "timeout", "credentials":
+			if len(vals) != 1 {
+				return opts, fmt.Errorf("parameter %q must be specified exactly once", key)
+			}
+			q.Del(key)
+		default:
+			continue // for the collector
+		}
+		val := vals[0]
+
+		switch key {
+		case "batchSize":
+			opts.BatchSize, err = strconv.Atoi(val)
+			if err == nil && opts.BatchSize < 1 {
+				err = errors.New("must be positive")
+			}
+		case "flushInterval":
+			opts.FlushInterval, err = parsePositiveDuration(val)
+		case "gzip":
+			opts.Gzip, err = strconv.ParseBool(val)
+		case "maxBuffer":
+			opts.MaxBuffer, err = strconv.Atoi(val)
+			if err == nil && opts.MaxBuffer < 0 {
+				err = errors.New("must not be negative")
+			}
+		case "maxRetries":
+			opts.MaxRetries, err = strconv.Atoi(val)
+			if err == nil && opts.MaxRetries < 0 {
+				err = errors.New("must not be negative")
+			}
+		case "minBackoff":
+			opts.MinBackoff, err = parsePositiveDuration(val)
+		case "maxBackoff":
+			opts.MaxBackoff, err = parsePositiveDuration(val)
+		case "timeout":
+			opts.Timeout, err = parsePositiveDuration(val)
+		case "credentials":
+			opts.Credentials = val
+		}
+		if err != nil {
+			return opts, fmt.Errorf("can't parse %q parameter: %w", key, err)
+		}
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		return opts, fmt.Errorf("maxBackoff %v is less than minBackoff %v", opts.MaxBackoff, opts.MinBackoff)
+	}
+	return opts, nil
+}
+
+// Write adds an entry to the current batch, terminating it with a newline if
+// necessary.
+func (s *httpSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, errors.New("write to closed HTTP sink")
+	}
+
+	s.batch.Write(p)
+	if len(p) == 0 || p[len(p)-1] != '\n' {
+		s.batch.WriteByte('\n')
+	}
+	s.batchLen++
+	if s.batchLen >= s.opts.BatchSize {
+		s.seal()
+		s.notify()
+	}
+	s.start()
+
+	err := s.unreported
+	s.unreported = nil
+	return len(p), err
+}
+
+// Sync sends all buffered entries, retrying failed requests, and reports the
+// requests that failed.
+func (s *httpSink) Sync() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.seal()
+	s.mu.Unlock()
+
+	return s.deliver()
+}
+
+// Close stops the delivery loop, then makes a last attempt to send buffered
+// entries without retrying failed requests.
+func (s *httpSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.seal()
+	s.mu.Unlock()
+
+	close(s.done)
+	s.wg.Wait()
+	return s.deliver()
+}
+
+// seal queues the current batch for delivery, dropping the oldest batches
+// to stay within the buffer limit. The caller must hold the lock.
+func (s *httpSink) seal() {
+	if s.batchLen == 0 {
+		return
+	}
+	b := append([]byte(nil), s.batch.Bytes()...)
+	s.batch.Reset()
+	s.batchLen = 0
+
+	s.queue = append(s.queue, b)
+	s.queueBytes += len(b)
+	for s.queueBytes > s.opts.MaxBuffer && len(s.queue) > 1 {
+		s.dropped += bytes.Count(s.queue[0], []byte{'\n'})
+		s.queueBytes -= len(s.queue[0])
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+	}
+
+	if s.dropped > 0 && !s.reportedDrops {
+		s.reportedDrops = true
+		s.unreported = s.outageErr("dropping entries: buffer of " + strconv.Itoa(s.opts.MaxBuffer) + " bytes is full")
+	}
+}
+
+// notify wakes the delivery loop. The caller must hold the lock.
+func (s *httpSink) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// start starts the delivery loop if necessary. The caller must hold the
+// lock.
+func (s *httpSink) start() {
+	if !s.started {
+		s.started = true
+		s.wg.Add(1)
+		go s.run()
+	}
+}
+
+// run delivers batches when they're full or the flush interval passes,
+// until the sink is closed.
+func (s *httpSink) run() {
+	defer s.wg.Done()
+
+	tick := time.NewTicker(s.opts.FlushInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-tick.C:
+			s.mu.Lock()
+			s.seal()
+			s.mu.Unlock()
+		case <-s.wake:
+		}
+
+		if err := s.deliver(); err != nil {
+			s.mu.Lock()
+			if !s.reported {
+				s.reported = true
+				s.unreported = err
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// deliver sends queued batches, oldest first, until the queue is empty. It
+// returns the errors of the batches it dropped.
+func (s *httpSink) deliver() error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	var errs error
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return errs
+		}
+		b := s.queue[0]
+		s.mu.Unlock()
+
+		err := s.sendWithRetries(b)
+
+		s.mu.Lock()
+		// The batch may have been dropped to make room while it was being
+		// sent.
+		if len(s.queue) > 0 && &s.queue[0][0] == &b[0] {
+			s.queueBytes -= len(b)
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+		}
+		if err == nil {
+			// The outage, if any, is over.
+			s.lastErr = nil
+			s.dropped = 0
+			s.reported = false
+			s.reportedDrops = false
+		} else {
+			n := bytes.Count(b, []byte{'\n'})
+			err = fmt.Errorf("dropped %d entries: %w", n, err)
+			errs = multierr.Append(errs, err)
+			s.lastErr = err
+		}
+		s.mu.Unlock()
+	}
+}
+
+// sendWithRetries POSTs a batch, retrying with backoff if the error is
+// temporary. It gives up early if the sink is closed.
+func (s *httpSink) sendWithRetries(b []byte) error {
+	backoff := sinkBackoff{min: s.opts.MinBackoff, max: s.opts.MaxBackoff}
+	for attempt := 0; ; attempt++ {
+		err, retry := s.send(b)
+		if err == nil || !retry || attempt >= s.opts.MaxRetries {
+			return err
+		}
+		if !backoff.wait(s.done) {
+			return err
+		}
+	}
+}
+
+// send POSTs a batch once, reporting whether a failed request may be
+// retried.
+func (s *httpSink) send(b []byte) (err error, retry bool) {
+	body := b
+	if s.opts.Gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write(b) // writes to a bytes.Buffer don't fail
+		_ = zw.Close()
+		body = buf.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("HTTP sink %v: %w", s.redactedEndpoint(), err), false
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.opts.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if s.auth != nil {
+		if err := s.auth(req); err != nil {
+			return fmt.Errorf("HTTP sink %v: can't authenticate request: %w", s.redactedEndpoint(), err), true
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP sink %v: %w", s.redactedEndpoint(), err), true
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // allow connection reuse
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil, false
+	}
+	err = fmt.Errorf("HTTP sink %v: unexpected status %s", s.redactedEndpoint(), resp.Status)
+	return err, resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// redactedEndpoint returns the collector's URL without its password.
+func (s *httpSink) redactedEndpoint() string {
+	u, err := url.Parse(s.opts.Endpoint)
+	if err != nil {
+		return s.opts.Endpoint
+	}
+	return u.Redacted()
+}
+
+// outageErr describes the current outage. The caller must hold the lock.
+func (s *httpSink) outageErr(msg string) error {
+	return outageError("HTTP sink "+s.redactedEndpoint(), msg, s.lastErr)
+}
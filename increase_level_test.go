@@ -92,3 +92,79 @@ func TestIncreaseLevel(t *testing.T) {
 		assert.Empty(t, errorOut.String(), "expect no error output")
 	})
 }
+
+func TestIncreaseLevelFastPath(t *testing.T) {
+	errorOut := &bytes.Buffer{}
+	opts := []Option{
+		ErrorOutput(zapcore.AddSync(errorOut)),
+	}
+	withLogger(t, DebugLevel, opts, func(logger *Logger, logs *observer.ObservedLogs) {
+		warn := logger.WithOptions(IncreaseLevel(WarnLevel))
+		assert.NotSame(t, logger, warn, "Expected a new logger for a new level.")
+
+		assert.Same(t, warn, warn.WithOptions(IncreaseLevel(WarnLevel)),
+			"Expected the logger to be reused when its level doesn't change.")
+		opt := IncreaseLevel(WarnLevel)
+		assert.Zero(t, testing.AllocsPerRun(10, func() {
+			warn.WithOptions(opt)
+		}), "Expected no allocations when the level doesn't change.")
+
+		assert.Same(t, warn, warn.WithOptions(IncreaseLevel(InfoLevel)),
+			"Expected the logger to be reused when the level can't be increased.")
+		assert.Contains(t, errorOut.String(), "failed to IncreaseLevel", "Expected the failure to be reported.")
+
+		warn.WithOptions(IncreaseLevel(ErrorLevel)).Warn("ignored warn log")
+		warn.Warn("warn log")
+		assert.Equal(t, []observer.LoggedEntry{
+			newLoggedEntry(WarnLevel, "warn log"),
+		}, logs.AllUntimed(), "unexpected logs")
+	})
+}
+
+func TestOverrideLevel(t *testing.T) {
+	errorOut := &bytes.Buffer{}
+	opts := []Option{
+		ErrorOutput(zapcore.AddSync(errorOut)),
+	}
+	withLogger(t, DebugLevel, opts, func(logger *Logger, logs *observer.ObservedLogs) {
+		parent := logger.WithOptions(IncreaseLevel(WarnLevel))
+		parent.Info("ignored info log")
+
+		child := parent.WithOptions(OverrideLevel(DebugLevel))
+		child.Debug("override level debug log")
+
+		quiet := child.WithOptions(OverrideLevel(ErrorLevel))
+		quiet.Warn("ignored warn log")
+		quiet.Error("override level error log")
+
+		assert.Equal(t, []observer.LoggedEntry{
+			newLoggedEntry(DebugLevel, "override level debug log"),
+			newLoggedEntry(ErrorLevel, "override level error log"),
+		}, logs.AllUntimed(), "unexpected logs")
+		assert.Empty(t, errorOut.String(), "expect no error output")
+	})
+}
+
+func TestOverrideLevelBelowCore(t *testing.T) {
+	errorOut := &bytes.Buffer{}
+	opts := []Option{
+		ErrorOutput(zapcore.AddSync(errorOut)),
+	}
+	lvl := NewAtomicLevelAt(InfoLevel)
+	withLogger(t, lvl, opts, func(logger *Logger, logs *observer.ObservedLogs) {
+		debugLogger := logger.WithOptions(OverrideLevel(DebugLevel))
+		assert.Equal(t,
+			"OverrideLevel: debug entries and above are enabled, but the core logs only info and above\n",
+			errorOut.String(),
+			"unexpected error output",
+		)
+
+		debugLogger.Debug("ignored debug log")
+		lvl.SetLevel(DebugLevel)
+		debugLogger.Debug("override level debug log")
+
+		assert.Equal(t, []observer.LoggedEntry{
+			newLoggedEntry(DebugLevel, "override level debug log"),
+		}, logs.AllUntimed(), "unexpected logs")
+	})
+}
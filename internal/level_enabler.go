@@ -27,11 +27,6 @@ import "github.com/toujourser/zap/zapcore"
 // LeveledEnabler is an interface satisfied by LevelEnablers that are able to
 // report their own level.
 //
-// This interface is defined to use more conveniently in tests and non-zapcore
-// packages.
-// This cannot be imported from zapcore because of the cyclic dependency.
-type LeveledEnabler interface {
-	zapcore.LevelEnabler
-
-	Level() zapcore.Level
-}
+// It's kept as an alias of zapcore.LeveledEnabler for packages that already
+// refer to it.
+type LeveledEnabler = zapcore.LeveledEnabler
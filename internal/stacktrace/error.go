@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stacktrace
+
+import (
+	"errors"
+	"reflect"
+)
+
+// pcStackTracer is implemented by errors that record the program counters
+// of the stack on which they were created.
+type pcStackTracer interface {
+	StackTrace() []uintptr
+}
+
+// FromError returns the program counters recorded by err or by the errors
+// it wraps, or nil if none of them recorded a stack trace. If several errors
+// in the chain have stack traces, the innermost one is used, since it's the
+// closest to where the failure occurred.
+//
+// Besides errors implementing StackTrace() []uintptr, FromError supports
+// errors whose StackTrace method returns a slice of uintptr-based frames, as
+// produced by github.com/pkg/errors. Panics raised by StackTrace methods are
+// recovered and treated as the absence of a stack trace.
+func FromError(err error) []uintptr {
+	var pcs []uintptr
+	for err != nil {
+		if found := errorStack(err); len(found) > 0 {
+			pcs = found
+		}
+		err = errors.Unwrap(err)
+	}
+	return pcs
+}
+
+func errorStack(err error) (pcs []uintptr) {
+	defer func() {
+		if recover() != nil {
+			pcs = nil
+		}
+	}()
+
+	if st, ok := err.(pcStackTracer); ok {
+		return st.StackTrace()
+	}
+
+	// Types like github.com/pkg/errors.StackTrace are named slices of
+	// uintptr-based frames that we can't name without importing the package.
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() {
+		return nil
+	}
+	mt := m.Type()
+	if mt.NumIn() != 0 || mt.NumOut() != 1 {
+		return nil
+	}
+	if out := mt.Out(0); out.Kind() != reflect.Slice || out.Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+
+	frames := m.Call(nil)[0]
+	if frames.Len() == 0 {
+		return nil
+	}
+	pcs = make([]uintptr, frames.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(frames.Index(i).Uint())
+	}
+	return pcs
+}
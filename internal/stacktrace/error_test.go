@@ -0,0 +1,144 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stacktrace
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/toujourser/zap/internal/bufferpool"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pcsError struct{ pcs []uintptr }
+
+func (e *pcsError) Error() string         { return "pcs" }
+func (e *pcsError) StackTrace() []uintptr { return e.pcs }
+
+// frame and frames mirror the types from github.com/pkg/errors.
+type (
+	frame  uintptr
+	frames []frame
+)
+
+type framesError struct{ frames frames }
+
+func (e framesError) Error() string      { return "frames" }
+func (e framesError) StackTrace() frames { return e.frames }
+
+type wrongStackError struct{}
+
+func (wrongStackError) Error() string        { return "wrong" }
+func (wrongStackError) StackTrace() []string { return []string{"foo"} }
+
+type panicStackError struct{}
+
+func (panicStackError) Error() string         { return "panic" }
+func (panicStackError) StackTrace() []uintptr { panic("great sadness") }
+
+func TestFromError(t *testing.T) {
+	inner := []uintptr{1, 2, 3}
+	outer := []uintptr{4, 5}
+
+	tests := []struct {
+		desc string
+		err  error
+		want []uintptr
+	}{
+		{desc: "nil", err: nil},
+		{desc: "no stack", err: errors.New("foo")},
+		{desc: "pcs", err: &pcsError{inner}, want: inner},
+		{desc: "frames", err: framesError{frames{1, 2, 3}}, want: inner},
+		{desc: "empty frames", err: framesError{}},
+		{desc: "wrong return type", err: wrongStackError{}},
+		{desc: "panic", err: panicStackError{}},
+		{desc: "nil pointer", err: (*pcsError)(nil)},
+		{
+			desc: "wrapped",
+			err:  fmt.Errorf("foo: %w", &pcsError{inner}),
+			want: inner,
+		},
+		{
+			desc: "innermost",
+			err:  &wrappingPCsError{pcs: outer, err: fmt.Errorf("foo: %w", &pcsError{inner})},
+			want: inner,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.want, FromError(tt.err))
+		})
+	}
+}
+
+type wrappingPCsError struct {
+	pcs []uintptr
+	err error
+}
+
+func (e *wrappingPCsError) Error() string         { return e.err.Error() }
+func (e *wrappingPCsError) Unwrap() error         { return e.err }
+func (e *wrappingPCsError) StackTrace() []uintptr { return e.pcs }
+
+func TestCallersAndFormatPCs(t *testing.T) {
+	pcs := Callers(0)
+	require.NotEmpty(t, pcs, "Expected at least one frame.")
+
+	buf := bufferpool.Get()
+	defer buf.Free()
+	stackfmt := NewFormatter(buf)
+	stackfmt.FormatPCs(pcs)
+
+	// Callers and FormatPCs should agree with Take.
+	want := Take(0)
+	got := buf.String()
+	gotLines := strings.Split(got, "\n")
+	wantLines := strings.Split(want, "\n")
+	require.Len(t, gotLines, len(wantLines), "Unexpected number of lines.")
+	assert.Contains(t, gotLines[0], "stacktrace.TestCallersAndFormatPCs", "Expected stack to start with the test.")
+	assert.Equal(t, wantLines[2:], gotLines[2:], "Expected callers to match.")
+	assert.NotContains(t, got, "runtime.goexit", "Unexpected runtime frame.")
+}
+
+func TestFormatPCsEmpty(t *testing.T) {
+	buf := bufferpool.Get()
+	defer buf.Free()
+	stackfmt := NewFormatter(buf)
+	stackfmt.FormatPCs(nil)
+	assert.Empty(t, buf.String())
+}
+
+func TestFormatPCsTruncated(t *testing.T) {
+	pcs := make([]uintptr, 1)
+	require.Equal(t, 1, runtime.Callers(1, pcs))
+
+	buf := bufferpool.Get()
+	defer buf.Free()
+	stackfmt := NewFormatter(buf)
+	stackfmt.FormatPCs(pcs)
+	assert.Contains(t, buf.String(), "stacktrace.TestFormatPCsTruncated", "Expected the only frame to be kept.")
+}
@@ -41,6 +41,11 @@ type Stack struct {
 	pcs    []uintptr // program counters; always a subslice of storage
 	frames *runtime.Frames
 
+	// truncated is set if the stack was deeper than the requested number
+	// of frames, in which case the last frame is a regular frame rather
+	// than runtime.main or runtime.goexit.
+	truncated bool
+
 	// The size of pcs varies depending on requirements:
 	// it will be one if the only the first frame was requested,
 	// and otherwise it will reflect the depth of the call stack.
@@ -108,11 +113,44 @@ func Capture(skip int, depth Depth) *Stack {
 	return stack
 }
 
+// CaptureFrames captures at most n frames of the stack trace, skipping the
+// provided number of frames. skip=0 identifies the caller of CaptureFrames.
+// Stacks deeper than n frames are truncated.
+//
+// The caller must call Free on the returned stacktrace after using it.
+func CaptureFrames(skip, n int) *Stack {
+	if n < 1 {
+		n = 1
+	}
+
+	stack := _stackPool.Get()
+
+	// Capture one extra frame to tell whether the stack was truncated.
+	// Grow the pooled storage if it can't hold that many frames; unlike
+	// with Full, the storage is returned to the pool at that size because
+	// the limit is the same for every capture.
+	if len(stack.storage) < n+1 {
+		stack.storage = make([]uintptr, n+1)
+	}
+
+	// +2 to skip CaptureFrames and runtime.Callers.
+	numFrames := runtime.Callers(skip+2, stack.storage[:n+1])
+	if numFrames > n {
+		numFrames = n
+		stack.truncated = true
+	}
+
+	stack.pcs = stack.storage[:numFrames]
+	stack.frames = runtime.CallersFrames(stack.pcs)
+	return stack
+}
+
 // Free releases resources associated with this stacktrace
 // and returns it back to the pool.
 func (st *Stack) Free() {
 	st.frames = nil
 	st.pcs = nil
+	st.truncated = false
 	_stackPool.Put(st)
 }
 
@@ -144,6 +182,20 @@ func Take(skip int) string {
 	return buffer.String()
 }
 
+// Callers returns the program counters of the full call stack, skipping the
+// provided number of frames. skip=0 identifies the caller of Callers.
+//
+// Unlike Capture, the returned slice is not pooled, so it may be retained
+// indefinitely, e.g. by an error that records where it was created.
+func Callers(skip int) []uintptr {
+	stack := Capture(skip+1, Full)
+	defer stack.Free()
+
+	pcs := make([]uintptr, len(stack.pcs))
+	copy(pcs, stack.pcs)
+	return pcs
+}
+
 // Formatter formats a stack trace into a readable string representation.
 type Formatter struct {
 	b        *buffer.Buffer
@@ -161,11 +213,47 @@ func (sf *Formatter) FormatStack(stack *Stack) {
 	// Note: On the last iteration, frames.Next() returns false, with a valid
 	// frame, but we ignore this frame. The last frame is a runtime frame which
 	// adds noise, since it's only either runtime.main or runtime.goexit.
-	for frame, more := stack.Next(); more; frame, more = stack.Next() {
+	//
+	// If the stack was truncated, the last frame is a regular frame, so we
+	// keep it.
+	for {
+		frame, more := stack.Next()
+		if !more && !stack.truncated {
+			return
+		}
 		sf.FormatFrame(frame)
+		if !more {
+			return
+		}
 	}
 }
 
+// FormatPCs formats the frames for the given program counters, as returned
+// by runtime.Callers -- minus a final runtime.main/runtime.goexit frame.
+func (sf *Formatter) FormatPCs(pcs []uintptr) {
+	if len(pcs) == 0 {
+		return
+	}
+
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if !more && isRuntimeEntry(frame) {
+			return
+		}
+		sf.FormatFrame(frame)
+		if !more {
+			return
+		}
+	}
+}
+
+// isRuntimeEntry reports whether frame is the runtime function at the bottom
+// of every goroutine's stack.
+func isRuntimeEntry(frame runtime.Frame) bool {
+	return frame.Function == "runtime.main" || frame.Function == "runtime.goexit"
+}
+
 // FormatFrame formats the given frame.
 func (sf *Formatter) FormatFrame(frame runtime.Frame) {
 	if sf.nonEmpty {
@@ -22,9 +22,12 @@ package stacktrace
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 	"testing"
 
+	"github.com/toujourser/zap/internal/bufferpool"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -86,6 +89,64 @@ func TestTakeDeepStack(t *testing.T) {
 	})
 }
 
+func TestCaptureFrames(t *testing.T) {
+	tests := []struct {
+		desc      string
+		n         int
+		wantCount int
+	}{
+		{desc: "truncated", n: 5, wantCount: 5},
+		{desc: "non-positive", n: 0, wantCount: 1},
+		{desc: "larger than pooled storage", n: 200, wantCount: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			withStackDepth(500, func() {
+				stack := CaptureFrames(0, tt.n)
+				defer stack.Free()
+
+				assert.Equal(t, tt.wantCount, stack.Count(), "Unexpected number of frames.")
+
+				buf := bufferpool.Get()
+				defer buf.Free()
+				stackfmt := NewFormatter(buf)
+				stackfmt.FormatStack(stack)
+
+				lines := strings.Split(buf.String(), "\n")
+				require.Len(t, lines, 2*tt.wantCount, "Expected every captured frame to be formatted.")
+				assert.Contains(t, lines[0], "TestCaptureFrames", "Expected stacktrace to start with the test.")
+				assert.NotContains(t, lines[len(lines)-2], "runtime.", "Unexpected runtime frame.")
+			})
+		})
+	}
+}
+
+func TestCaptureFramesShallowStack(t *testing.T) {
+	full := Take(0)
+
+	stack := CaptureFrames(0, 1000)
+	defer stack.Free()
+
+	buf := bufferpool.Get()
+	defer buf.Free()
+	stackfmt := NewFormatter(buf)
+	stackfmt.FormatStack(stack)
+
+	// Compare only function names since Take is called from another line.
+	functions := func(s string) []string {
+		var fns []string
+		for _, line := range strings.Split(s, "\n") {
+			if !strings.HasPrefix(line, "\t") {
+				fns = append(fns, line)
+			}
+		}
+		return fns
+	}
+	assert.Equal(t, functions(full), functions(buf.String()),
+		"Expected a limit deeper than the stack to capture the full stack.")
+}
+
 func BenchmarkTake(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		Take(0)
@@ -104,3 +165,32 @@ func withStackDepth(depth int, f func()) {
 	}
 	recurse(rune(depth))
 }
+
+func BenchmarkCaptureDeepStack(b *testing.B) {
+	capture := func(b *testing.B, f func() *Stack) {
+		withStackDepth(200, func() {
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				benchmarkFormat(f())
+			}
+		})
+	}
+
+	b.Run("Full", func(b *testing.B) {
+		capture(b, func() *Stack { return Capture(0, Full) })
+	})
+	for _, n := range []int{10, 32} {
+		n := n
+		b.Run(fmt.Sprintf("Frames=%d", n), func(b *testing.B) {
+			capture(b, func() *Stack { return CaptureFrames(0, n) })
+		})
+	}
+}
+
+func benchmarkFormat(stack *Stack) {
+	buf := bufferpool.Get()
+	stackfmt := NewFormatter(buf)
+	stackfmt.FormatStack(stack)
+	buf.Free()
+	stack.Free()
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build linux
+
+package zap
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// journaldSink is a Sink that sends each written entry to the systemd
+// journal as a datagram on its native socket. Entries must be encoded with
+// the "journald" encoding.
+//
+// Entries too large for a datagram are written to a temporary file in
+// /dev/shm, whose descriptor is passed to the journal instead, as the
+// native protocol requires.
+type journaldSink struct {
+	mu   sync.Mutex
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+var _ Sink = (*journaldSink)(nil)
+
+func newJournaldSink(path string) (Sink, error) {
+	// Use an unconnected socket, so that writes don't fail with ECONNREFUSED
+	// if the journal is restarted.
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	addr := &net.UnixAddr{Name: path, Net: "unixgram"}
+	if _, err := os.Stat(path); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &journaldSink{conn: conn, addr: addr}, nil
+}
+
+func (s *journaldSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, _, err := s.conn.WriteMsgUnix(p, nil, s.addr)
+	if err == nil {
+		return len(p), nil
+	}
+	if !isMessageTooLarge(err) {
+		return 0, err
+	}
+	if err := s.writeViaFile(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeViaFile passes p to the journal in an unlinked temporary file.
+func (s *journaldSink) writeViaFile(p []byte) error {
+	f, err := os.CreateTemp("/dev/shm", "zap-journald-")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+	if _, err := f.Write(p); err != nil {
+		return err
+	}
+	rights := syscall.UnixRights(int(f.Fd()))
+	_, _, err = s.conn.WriteMsgUnix(nil, rights, s.addr)
+	return err
+}
+
+func isMessageTooLarge(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+func (s *journaldSink) Sync() error {
+	return nil
+}
+
+func (s *journaldSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.Close()
+}
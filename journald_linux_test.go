@@ -0,0 +1,134 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build linux
+
+package zap
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// journalServer is a fake journal listening on a unixgram socket.
+type journalServer struct {
+	t    testing.TB
+	path string
+	conn *net.UnixConn
+}
+
+func newJournalServer(t testing.TB) *journalServer {
+	// Socket paths are limited to about 100 bytes, which t.TempDir can
+	// exceed.
+	dir, err := os.MkdirTemp("", "zap-journald")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err, "Failed to listen.")
+	t.Cleanup(func() { _ = conn.Close() })
+	return &journalServer{t: t, path: path, conn: conn}
+}
+
+// Receive returns the next entry sent to the server, reading it from the
+// passed file descriptor if there is one.
+func (s *journalServer) Receive() string {
+	buf := make([]byte, 1<<16)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := s.conn.ReadMsgUnix(buf, oob)
+	require.NoError(s.t, err, "Failed to receive.")
+	if oobn == 0 {
+		return string(buf[:n])
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	require.NoError(s.t, err)
+	require.Len(s.t, msgs, 1, "Expected one control message.")
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	require.NoError(s.t, err)
+	require.Len(s.t, fds, 1, "Expected one file descriptor.")
+
+	f := os.NewFile(uintptr(fds[0]), "journal-entry")
+	defer f.Close()
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(s.t, err)
+	data, err := io.ReadAll(f)
+	require.NoError(s.t, err)
+	return string(data)
+}
+
+func TestJournaldSink(t *testing.T) {
+	srv := newJournalServer(t)
+
+	sink, err := _sinkRegistry.newSink("journald://" + srv.path)
+	require.NoError(t, err, "Failed to open sink.")
+	defer func() { assert.NoError(t, sink.Close()) }()
+
+	_, err = sink.Write([]byte("MESSAGE=hello\nPRIORITY=6\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "MESSAGE=hello\nPRIORITY=6\n", srv.Receive())
+	assert.NoError(t, sink.Sync())
+
+	t.Run("too large for a datagram", func(t *testing.T) {
+		big := "MESSAGE=" + strings.Repeat("x", 4<<20) + "\n"
+		n, err := sink.Write([]byte(big))
+		require.NoError(t, err)
+		assert.Equal(t, len(big), n, "Unexpected number of bytes written.")
+		assert.Equal(t, big, srv.Receive(), "Expected the entry to be passed in a file.")
+	})
+}
+
+func TestJournaldSinkMissingSocket(t *testing.T) {
+	_, err := _sinkRegistry.newSink("journald:///does/not/exist")
+	assert.Error(t, err, "Expected an error for a missing socket.")
+}
+
+func TestJournaldLogger(t *testing.T) {
+	srv := newJournalServer(t)
+
+	cfg := NewProductionConfig()
+	cfg.Encoding = "journald"
+	cfg.Sampling = nil
+	cfg.OutputPaths = []string{"journald://" + srv.path}
+	logger, err := cfg.Build()
+	require.NoError(t, err, "Failed to build logger.")
+
+	logger.Named("api").Warn("slow request", String("http.method", "GET"), String("body", "a\nb"))
+
+	got := srv.Receive()
+	assert.True(t, strings.HasPrefix(got, "MESSAGE=slow request\nPRIORITY=4\nCODE_FILE="), "Unexpected entry %q.", got)
+	assert.Contains(t, got, "\nCODE_FUNC=github.com/toujourser/zap.TestJournaldLogger\n")
+	assert.Contains(t, got, "\nLOGGER=api\n")
+	assert.Contains(t, got, "\nHTTP_METHOD=GET\n")
+
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], 3)
+	assert.True(t, strings.HasSuffix(got, "\nBODY\n"+string(size[:])+"a\nb\n"), "Expected a binary field, got %q.", got)
+}
@@ -28,6 +28,10 @@ import (
 )
 
 const (
+	// TraceLevel logs are finer-grained than Debug logs, for extremely
+	// chatty internals. They're disabled unless a logger's level is
+	// explicitly set to TraceLevel.
+	TraceLevel = zapcore.TraceLevel
 	// DebugLevel logs are typically voluminous, and are usually disabled in
 	// production.
 	DebugLevel = zapcore.DebugLevel
@@ -59,6 +63,11 @@ type LevelEnablerFunc func(zapcore.Level) bool
 // Enabled calls the wrapped function.
 func (f LevelEnablerFunc) Enabled(lvl zapcore.Level) bool { return f(lvl) }
 
+// isValidLevel reports whether lvl is one of zap's levels.
+func isValidLevel(lvl zapcore.Level) bool {
+	return lvl >= TraceLevel && lvl <= FatalLevel
+}
+
 // An AtomicLevel is an atomically changeable, dynamic logging level. It lets
 // you safely change the log level of a tree of loggers (the root logger and
 // any children created by adding context) at runtime.
@@ -129,8 +138,8 @@ func (lvl AtomicLevel) String() string {
 }
 
 // UnmarshalText unmarshals the text to an AtomicLevel. It uses the same text
-// representations as the static zapcore.Levels ("debug", "info", "warn",
-// "error", "dpanic", "panic", and "fatal").
+// representations as the static zapcore.Levels ("trace", "debug", "info",
+// "warn", "error", "dpanic", "panic", and "fatal").
 func (lvl *AtomicLevel) UnmarshalText(text []byte) error {
 	if lvl.l == nil {
 		lvl.l = &atomic.Int32{}
@@ -146,8 +155,8 @@ func (lvl *AtomicLevel) UnmarshalText(text []byte) error {
 }
 
 // MarshalText marshals the AtomicLevel to a byte slice. It uses the same
-// text representation as the static zapcore.Levels ("debug", "info", "warn",
-// "error", "dpanic", "panic", and "fatal").
+// text representation as the static zapcore.Levels ("trace", "debug", "info",
+// "warn", "error", "dpanic", "panic", and "fatal").
 func (lvl AtomicLevel) MarshalText() (text []byte, err error) {
 	return lvl.Level().MarshalText()
 }
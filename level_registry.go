@@ -0,0 +1,228 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/toujourser/zap/zapcore"
+)
+
+// A LevelRegistry holds logging levels for named loggers, so that the level
+// of a single subsystem can be changed at runtime. Loggers built with the
+// WithLevelRegistry option consult it on every log call.
+//
+// Levels are matched against logger names by the longest dot-separated
+// prefix: a level set for "db" applies to loggers named "db" and "db.pool",
+// but not "dbx". Loggers whose names match no level use the root level.
+//
+// Since a logger's core filters entries before the registry is consulted,
+// cores should use the registry as their LevelEnabler:
+//
+//	levels := zap.NewLevelRegistry(zap.NewAtomicLevelAt(zap.InfoLevel))
+//	core := zapcore.NewCore(encoder, sink, levels)
+//	logger := zap.New(core, zap.WithLevelRegistry(levels))
+//	levels.SetLevel("db", zap.DebugLevel)
+type LevelRegistry struct {
+	root AtomicLevel
+
+	mu     sync.RWMutex
+	levels map[string]zapcore.Level
+
+	// lowest is the lowest level in levels, or InvalidLevel if there are
+	// none. It's kept separately so that Enabled doesn't need to take the
+	// lock.
+	lowest atomic.Int32
+}
+
+var _ zapcore.LeveledEnabler = (*LevelRegistry)(nil)
+
+// NewLevelRegistry builds a LevelRegistry that falls back to the given root
+// level for loggers that have no level of their own.
+func NewLevelRegistry(root AtomicLevel) *LevelRegistry {
+	r := &LevelRegistry{
+		root:   root,
+		levels: make(map[string]zapcore.Level),
+	}
+	r.lowest.Store(int32(zapcore.InvalidLevel))
+	return r
+}
+
+// Root returns the level used by loggers that have no level of their own.
+func (r *LevelRegistry) Root() AtomicLevel {
+	return r.root
+}
+
+// SetLevel sets the level of the named logger and its descendants. An empty
+// name sets the root level.
+func (r *LevelRegistry) SetLevel(name string, lvl zapcore.Level) {
+	if name == "" {
+		r.root.SetLevel(lvl)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.levels[name] = lvl
+	r.updateLowest()
+}
+
+// Unset removes the level of the named logger, which then inherits the level
+// of its closest ancestor.
+func (r *LevelRegistry) Unset(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.levels, name)
+	r.updateLowest()
+}
+
+// updateLowest must be called with r.mu held.
+func (r *LevelRegistry) updateLowest() {
+	lowest := zapcore.InvalidLevel
+	for _, lvl := range r.levels {
+		if lvl < lowest {
+			lowest = lvl
+		}
+	}
+	r.lowest.Store(int32(lowest))
+}
+
+// LevelFor returns the level of the named logger.
+func (r *LevelRegistry) LevelFor(name string) zapcore.Level {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for name != "" {
+		if lvl, ok := r.levels[name]; ok {
+			return lvl
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return r.root.Level()
+}
+
+// Levels returns the levels set for named loggers, not including the root
+// level.
+func (r *LevelRegistry) Levels() map[string]zapcore.Level {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	levels := make(map[string]zapcore.Level, len(r.levels))
+	for name, lvl := range r.levels {
+		levels[name] = lvl
+	}
+	return levels
+}
+
+// Enabled reports whether the given level is enabled for any logger, which
+// makes the registry suitable as the LevelEnabler of a core.
+func (r *LevelRegistry) Enabled(lvl zapcore.Level) bool {
+	return lvl >= r.Level()
+}
+
+// Level returns the lowest level enabled for any logger.
+func (r *LevelRegistry) Level() zapcore.Level {
+	lowest := zapcore.Level(r.lowest.Load())
+	if root := r.root.Level(); root < lowest {
+		return root
+	}
+	return lowest
+}
+
+// ServeHTTP is a JSON endpoint that can report on or change the levels in the
+// registry. It expects to be mounted with a trailing slash, with the prefix
+// stripped:
+//
+//	mux.Handle("/log/levels/", http.StripPrefix("/log/levels", levels))
+//
+// # GET
+//
+// A GET request for the root path lists the root level and the levels set
+// for named loggers:
+//
+//	{"root":"info","levels":{"db":"debug"}}
+//
+// A GET request for /{name} reports the level used by the named logger:
+//
+//	{"name":"db.pool","level":"debug"}
+//
+// # PUT
+//
+// A PUT request for /{name} sets the level of the named logger, and a PUT
+// request for the root path sets the root level. The level is provided in the
+// same formats accepted by AtomicLevel.ServeHTTP, for example:
+//
+//	curl -X PUT localhost:8080/log/levels/db -H "Content-Type: application/json" -d '{"level":"debug"}'
+func (r *LevelRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if err := r.serveHTTP(w, req); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "internal error: %v", err)
+	}
+}
+
+func (r *LevelRegistry) serveHTTP(w http.ResponseWriter, req *http.Request) error {
+	type errorResponse struct {
+		Error string `json:"error"`
+	}
+	type levelsPayload struct {
+		Root   zapcore.Level            `json:"root"`
+		Levels map[string]zapcore.Level `json:"levels"`
+	}
+	type namedPayload struct {
+		Name  string        `json:"name"`
+		Level zapcore.Level `json:"level"`
+	}
+
+	enc := json.NewEncoder(w)
+	name := strings.Trim(req.URL.Path, "/")
+
+	switch req.Method {
+	case http.MethodGet:
+		if name == "" {
+			return enc.Encode(levelsPayload{Root: r.root.Level(), Levels: r.Levels()})
+		}
+		return enc.Encode(namedPayload{Name: name, Level: r.LevelFor(name)})
+
+	case http.MethodPut:
+		requestedLvl, err := decodePutRequest(req.Header.Get("Content-Type"), req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return enc.Encode(errorResponse{Error: err.Error()})
+		}
+		r.SetLevel(name, requestedLvl)
+		return enc.Encode(namedPayload{Name: name, Level: r.LevelFor(name)})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return enc.Encode(errorResponse{
+			Error: "Only GET and PUT are supported.",
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zaptest/observer"
+)
+
+func TestLevelRegistryLevelFor(t *testing.T) {
+	levels := NewLevelRegistry(NewAtomicLevelAt(InfoLevel))
+	levels.SetLevel("db", DebugLevel)
+	levels.SetLevel("db.pool", ErrorLevel)
+	levels.SetLevel("http.client", WarnLevel)
+
+	tests := []struct {
+		name string
+		want zapcore.Level
+	}{
+		{"", InfoLevel},
+		{"db", DebugLevel},
+		{"db.conn", DebugLevel},
+		{"db.pool", ErrorLevel},
+		{"db.pool.idle", ErrorLevel},
+		{"dbx", InfoLevel},
+		{"http", InfoLevel},
+		{"http.client", WarnLevel},
+		{"http.client.retry", WarnLevel},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, levels.LevelFor(tt.name), "Unexpected level for %q.", tt.name)
+	}
+
+	levels.Unset("db.pool")
+	assert.Equal(t, DebugLevel, levels.LevelFor("db.pool"), "Expected unset name to inherit its parent's level.")
+	assert.Equal(t, map[string]zapcore.Level{"db": DebugLevel, "http.client": WarnLevel}, levels.Levels(),
+		"Unexpected levels.")
+
+	levels.SetLevel("", ErrorLevel)
+	assert.Equal(t, ErrorLevel, levels.Root().Level(), "Expected empty name to set the root level.")
+	assert.Equal(t, ErrorLevel, levels.LevelFor("http"), "Expected unknown names to use the new root level.")
+}
+
+func TestLevelRegistryEnabled(t *testing.T) {
+	root := NewAtomicLevelAt(WarnLevel)
+	levels := NewLevelRegistry(root)
+	assert.Equal(t, WarnLevel, levels.Level(), "Expected root level without named levels.")
+
+	levels.SetLevel("db", DebugLevel)
+	levels.SetLevel("http", ErrorLevel)
+	assert.Equal(t, DebugLevel, zapcore.LevelOf(levels), "Expected lowest named level.")
+	assert.True(t, levels.Enabled(DebugLevel), "Expected debug to be enabled.")
+
+	levels.Unset("db")
+	assert.Equal(t, WarnLevel, levels.Level(), "Expected root level after unsetting the lowest level.")
+
+	root.SetLevel(InfoLevel)
+	assert.Equal(t, InfoLevel, levels.Level(), "Expected changes to the root level to be observed.")
+	assert.False(t, levels.Enabled(DebugLevel), "Expected debug to be disabled.")
+}
+
+func TestLevelRegistryLogger(t *testing.T) {
+	levels := NewLevelRegistry(NewAtomicLevelAt(InfoLevel))
+	core, logs := observer.New(levels)
+	logger := New(core, WithLevelRegistry(levels))
+
+	db := logger.Named("db")
+	pool := db.Named("pool")
+	http := logger.Named("http")
+
+	logAll := func() {
+		for _, l := range []*Logger{logger, db, pool, http} {
+			l.Debug("debug")
+			l.Info("info")
+		}
+	}
+
+	logAll()
+	levels.SetLevel("db", DebugLevel)
+	levels.SetLevel("http", WarnLevel)
+	logAll()
+
+	var got []string
+	for _, e := range logs.AllUntimed() {
+		got = append(got, e.LoggerName+":"+e.Message)
+	}
+	assert.Equal(t, []string{
+		":info", "db:info", "db.pool:info", "http:info",
+		":info", "db:debug", "db:info", "db.pool:debug", "db.pool:info",
+	}, got, "Unexpected entries.")
+
+	assert.Equal(t, InfoLevel, logger.Level(), "Unexpected root logger level.")
+	assert.Equal(t, DebugLevel, pool.Level(), "Unexpected db.pool logger level.")
+	assert.Equal(t, WarnLevel, http.Level(), "Unexpected http logger level.")
+	assert.Nil(t, http.Check(InfoLevel, "info"), "Expected Check to consult the registry.")
+	assert.NotNil(t, pool.Check(DebugLevel, "debug"), "Expected Check to consult the registry.")
+}
+
+func TestLevelRegistryServeHTTP(t *testing.T) {
+	levels := NewLevelRegistry(NewAtomicLevelAt(InfoLevel))
+	mux := http.NewServeMux()
+	mux.Handle("/levels/", http.StripPrefix("/levels", levels))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	do := func(method, path, contentType, body string) (int, string) {
+		req, err := http.NewRequest(method, srv.URL+"/levels"+path, strings.NewReader(body))
+		require.NoError(t, err, "Error constructing %s request.", method)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, "Error making %s request.", method)
+		defer res.Body.Close()
+		out, err := io.ReadAll(res.Body)
+		require.NoError(t, err, "Error reading response body.")
+		return res.StatusCode, strings.TrimSpace(string(out))
+	}
+
+	tests := []struct {
+		desc        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantCode    int
+		wantBody    string
+	}{
+		{
+			desc:     "list empty",
+			method:   http.MethodGet,
+			path:     "/",
+			wantCode: http.StatusOK,
+			wantBody: `{"root":"info","levels":{}}`,
+		},
+		{
+			desc:        "set JSON",
+			method:      http.MethodPut,
+			path:        "/db",
+			contentType: "application/json",
+			body:        `{"level":"debug"}`,
+			wantCode:    http.StatusOK,
+			wantBody:    `{"name":"db","level":"debug"}`,
+		},
+		{
+			desc:        "set form",
+			method:      http.MethodPut,
+			path:        "/http.client",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "level=warn",
+			wantCode:    http.StatusOK,
+			wantBody:    `{"name":"http.client","level":"warn"}`,
+		},
+		{
+			desc:     "get inherited",
+			method:   http.MethodGet,
+			path:     "/db.pool",
+			wantCode: http.StatusOK,
+			wantBody: `{"name":"db.pool","level":"debug"}`,
+		},
+		{
+			desc:     "get unknown",
+			method:   http.MethodGet,
+			path:     "/grpc",
+			wantCode: http.StatusOK,
+			wantBody: `{"name":"grpc","level":"info"}`,
+		},
+		{
+			desc:        "set root",
+			method:      http.MethodPut,
+			path:        "/",
+			contentType: "application/json",
+			body:        `{"level":"error"}`,
+			wantCode:    http.StatusOK,
+			wantBody:    `{"name":"","level":"error"}`,
+		},
+		{
+			desc:     "list",
+			method:   http.MethodGet,
+			path:     "/",
+			wantCode: http.StatusOK,
+			wantBody: `{"root":"error","levels":{"db":"debug","http.client":"warn"}}`,
+		},
+		{
+			desc:        "invalid level",
+			method:      http.MethodPut,
+			path:        "/db",
+			contentType: "application/json",
+			body:        `{"level":"nope"}`,
+			wantCode:    http.StatusBadRequest,
+			wantBody:    `{"error":"malformed request body: unrecognized level: \"nope\""}`,
+		},
+		{
+			desc:        "missing level",
+			method:      http.MethodPut,
+			path:        "/db",
+			contentType: "application/json",
+			body:        `{}`,
+			wantCode:    http.StatusBadRequest,
+			wantBody:    `{"error":"must specify logging level"}`,
+		},
+		{
+			desc:     "unsupported method",
+			method:   http.MethodPost,
+			path:     "/db",
+			wantCode: http.StatusMethodNotAllowed,
+			wantBody: `{"error":"Only GET and PUT are supported."}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			code, body := do(tt.method, tt.path, tt.contentType, tt.body)
+			assert.Equal(t, tt.wantCode, code, "Unexpected status code.")
+			assert.Equal(t, tt.wantBody, body, "Unexpected response body.")
+		})
+	}
+
+	assert.Equal(t, DebugLevel, levels.LevelFor("db"), "Expected invalid requests to keep the previous level.")
+}
@@ -21,10 +21,12 @@
 package zap
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"sync/atomic"
 
 	"github.com/toujourser/zap/internal/bufferpool"
 	"github.com/toujourser/zap/internal/stacktrace"
@@ -41,19 +43,38 @@ import (
 type Logger struct {
 	core zapcore.Core
 
-	development bool
-	addCaller   bool
-	onPanic     zapcore.CheckWriteHook // default is WriteThenPanic
-	onFatal     zapcore.CheckWriteHook // default is WriteThenFatal
+	development        bool
+	dumpGoroutines     bool
+	goroutineDumpLimit int // zero for the default
+	strictSugar        bool
+	addCaller          bool
+	onPanic            zapcore.CheckWriteHook // default is WriteThenPanic
+	onFatal            zapcore.CheckWriteHook // default is WriteThenFatal
 
-	name        string
-	errorOutput zapcore.WriteSyncer
+	name         string
+	errorOutput  zapcore.WriteSyncer
+	errorHandler func(error, zapcore.Entry)
 
-	addStack zapcore.LevelEnabler
+	addStack   zapcore.LevelEnabler
+	stackDepth int // zero if unlimited
+	stackSkip  int
 
 	callerSkip int
 
 	clock zapcore.Clock
+
+	ctxFields []func(context.Context) []Field
+
+	levels *LevelRegistry
+
+	sequence *sequence // shared with derived loggers
+}
+
+// sequence numbers the entries written by a logger and the loggers derived
+// from it.
+type sequence struct {
+	key string
+	n   atomic.Uint64
 }
 
 // New constructs a new Logger from the provided zapcore.Core and Options. If
@@ -145,7 +166,7 @@ func NewExample(options ...Option) *Logger {
 // between them on the boundaries of performance-sensitive code.
 func (log *Logger) Sugar() *SugaredLogger {
 	core := log.clone()
-	core.callerSkip += 2
+	core.callerSkip += _sugarCallerSkip
 	return &SugaredLogger{core}
 }
 
@@ -164,9 +185,28 @@ func (log *Logger) Named(s string) *Logger {
 	return l
 }
 
+// WithCallerSkip returns a copy of the Logger that skips delta more callers
+// when annotating entries with their caller and stack trace. It's equivalent
+// to WithOptions(AddCallerSkip(delta)), but cheaper, so wrappers may call it
+// whenever they hand out a Logger. Negative deltas skip fewer callers.
+func (log *Logger) WithCallerSkip(delta int) *Logger {
+	if delta == 0 {
+		return log
+	}
+	l := log.clone()
+	l.callerSkip += delta
+	return l
+}
+
 // WithOptions clones the current Logger, applies the supplied Options, and
 // returns the resulting Logger. It's safe to use concurrently.
 func (log *Logger) WithOptions(opts ...Option) *Logger {
+	if len(opts) == 1 {
+		if o, ok := opts[0].(increaseLevelOption); ok {
+			return log.withIncreasedLevel(o.lvl)
+		}
+	}
+
 	c := log.clone()
 	for _, opt := range opts {
 		opt.apply(c)
@@ -208,11 +248,35 @@ func (log *Logger) WithLazy(fields ...Field) *Logger {
 	}))
 }
 
+// Ctx returns a child logger with the fields extracted from ctx by the
+// functions registered with the ContextFields option. If ctx is nil or
+// carries no fields, the logger is returned unchanged.
+func (log *Logger) Ctx(ctx context.Context) *Logger {
+	if ctx == nil || len(log.ctxFields) == 0 {
+		return log
+	}
+
+	var fields []Field
+	for _, extract := range log.ctxFields {
+		fields = append(fields, extract(ctx)...)
+	}
+	if len(fields) == 0 {
+		return log
+	}
+	return log.With(fields...)
+}
+
 // Level reports the minimum enabled level for this logger.
 //
 // For NopLoggers, this is [zapcore.InvalidLevel].
 func (log *Logger) Level() zapcore.Level {
-	return zapcore.LevelOf(log.core)
+	lvl := zapcore.LevelOf(log.core)
+	if log.levels != nil {
+		if named := log.levels.LevelFor(log.name); named > lvl {
+			lvl = named
+		}
+	}
+	return lvl
 }
 
 // Check returns a CheckedEntry if logging a message at the specified level
@@ -222,16 +286,50 @@ func (log *Logger) Check(lvl zapcore.Level, msg string) *zapcore.CheckedEntry {
 	return log.check(lvl, msg)
 }
 
+// CheckSkip is like Check, but skips another skip callers when annotating the
+// entry with its caller and stack trace, on top of those skipped by the
+// Logger (see AddCallerSkip and WithCallerSkip). It lets a helper shared by
+// call sites at different depths report the right caller for each call:
+//
+//	func logFailure(log *zap.Logger, depth int, err error) {
+//	  if ce := log.CheckSkip(zap.ErrorLevel, "failed", depth+1); ce != nil {
+//	    ce.Write(zap.Error(err))
+//	  }
+//	}
+func (log *Logger) CheckSkip(lvl zapcore.Level, msg string, skip int) *zapcore.CheckedEntry {
+	if skip == 0 {
+		return log.check(lvl, msg)
+	}
+	// The copy doesn't escape, so adjusting the skip for one call doesn't
+	// allocate.
+	l := *log
+	l.callerSkip += skip
+	return l.check(lvl, msg)
+}
+
 // Log logs a message at the specified level. The message includes any fields
 // passed at the log site, as well as any fields accumulated on the logger.
 // Any Fields that require  evaluation (such as Objects) are evaluated upon
 // invocation of Log.
+//
+// Log behaves exactly like the method for lvl, such as Warn or Fatal. If lvl
+// isn't one of zap's levels, the message is logged at DPanicLevel in
+// development, which panics; otherwise, it's dropped and the error is
+// reported to the logger's ErrorOutput.
 func (log *Logger) Log(lvl zapcore.Level, msg string, fields ...Field) {
 	if ce := log.check(lvl, msg); ce != nil {
 		ce.Write(fields...)
 	}
 }
 
+// Trace logs a message at TraceLevel. The message includes any fields passed
+// at the log site, as well as any fields accumulated on the logger.
+func (log *Logger) Trace(msg string, fields ...Field) {
+	if ce := log.check(TraceLevel, msg); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
 // Debug logs a message at DebugLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (log *Logger) Debug(msg string, fields ...Field) {
@@ -299,8 +397,37 @@ func (log *Logger) Fatal(msg string, fields ...Field) {
 
 // Sync calls the underlying Core's Sync method, flushing any buffered log
 // entries. Applications should take care to call Sync before exiting.
+//
+// If the Logger has an ErrorHandler, it also receives the error, with an
+// entry that carries only the Logger's name and the time of the failure.
+// Errors from sinks that merely can't be synced, such as terminals and
+// pipes, aren't passed to it (see zapcore.SyncError.Ignorable).
 func (log *Logger) Sync() error {
-	return log.core.Sync()
+	err := log.core.Sync()
+	if err != nil && log.errorHandler != nil && !isIgnorableSyncError(err) {
+		log.reportSyncError(err)
+	}
+	return err
+}
+
+// reportSyncError passes a Sync error to the ErrorHandler, falling back to a
+// plain-text line on the ErrorOutput as CheckedEntry.Write does.
+func (log *Logger) reportSyncError(err error) {
+	ent := zapcore.Entry{LoggerName: log.name, Time: log.clock.Now()}
+	if err = zapcore.HandleError(log.errorHandler, syncError{err}, ent); err != nil {
+		_, _ = fmt.Fprintf(log.errorOutput, "%v sync error: %v\n", ent.Time.UTC(), err)
+		_ = log.errorOutput.Sync()
+	}
+}
+
+// reportCheckError passes an error found while checking an entry, such as
+// an invalid level, to the ErrorHandler, falling back to a plain-text line on
+// the ErrorOutput.
+func (log *Logger) reportCheckError(err error, ent zapcore.Entry) {
+	if err = zapcore.HandleError(log.errorHandler, checkError{err}, ent); err != nil {
+		_, _ = fmt.Fprintf(log.errorOutput, "%v Logger.check error: %v\n", ent.Time.UTC(), err)
+		_ = log.errorOutput.Sync()
+	}
 }
 
 // Core returns the Logger's underlying zapcore.Core.
@@ -319,6 +446,35 @@ func (log *Logger) clone() *Logger {
 	return &clone
 }
 
+// withIncreasedLevel is the fast path of WithOptions for a lone
+// IncreaseLevel. Since only the core changes, the Logger is cloned only if
+// the core does: if the level can't be increased, or is already in effect,
+// the Logger is returned as-is.
+func (log *Logger) withIncreasedLevel(lvl zapcore.LevelEnabler) *Logger {
+	core, ok := log.increasedCore(lvl)
+	if !ok || core == log.core {
+		return log
+	}
+	c := log.clone()
+	c.core = core
+	return c
+}
+
+// increasedCore narrows the Logger's core to lvl, reporting failures to the
+// error output.
+func (log *Logger) increasedCore(lvl zapcore.LevelEnabler) (zapcore.Core, bool) {
+	core, err := zapcore.NewIncreaseLevelCore(log.core, lvl)
+	if err != nil {
+		_, _ = fmt.Fprintf(
+			log.errorOutput,
+			"failed to IncreaseLevel: %v\n",
+			err,
+		)
+		return nil, false
+	}
+	return core, true
+}
+
 func (log *Logger) check(lvl zapcore.Level, msg string) *zapcore.CheckedEntry {
 	// Logger.check must always be called directly by a method in the
 	// Logger interface (e.g., Check, Info, Fatal).
@@ -326,11 +482,25 @@ func (log *Logger) check(lvl zapcore.Level, msg string) *zapcore.CheckedEntry {
 	// called it.
 	const callerSkipOffset = 2
 
+	if !isValidLevel(lvl) {
+		if !log.development {
+			ent := zapcore.Entry{LoggerName: log.name, Time: log.clock.Now(), Level: lvl, Message: msg}
+			log.reportCheckError(fmt.Errorf("invalid level %v for message %q", lvl, msg), ent)
+			return nil
+		}
+		msg = fmt.Sprintf("invalid level %v: %s", lvl, msg)
+		lvl = zapcore.DPanicLevel
+	}
+
 	// Check the level first to reduce the cost of disabled log calls.
 	// Since Panic and higher may exit, we skip the optimization for those levels.
 	if lvl < zapcore.DPanicLevel && !log.core.Enabled(lvl) {
 		return nil
 	}
+	nameEnabled := log.nameEnabled(lvl)
+	if lvl < zapcore.DPanicLevel && !nameEnabled {
+		return nil
+	}
 
 	// Create basic checked entry thru the core; this will be non-nil if the
 	// log message will actually be written somewhere.
@@ -340,7 +510,10 @@ func (log *Logger) check(lvl zapcore.Level, msg string) *zapcore.CheckedEntry {
 		Level:      lvl,
 		Message:    msg,
 	}
-	ce := log.core.Check(ent, nil)
+	var ce *zapcore.CheckedEntry
+	if nameEnabled {
+		ce = log.core.Check(ent, nil)
+	}
 	willWrite := ce != nil
 
 	// Set up any required terminal behavior.
@@ -364,6 +537,15 @@ func (log *Logger) check(lvl zapcore.Level, msg string) *zapcore.CheckedEntry {
 
 	// Thread the error output through to the CheckedEntry.
 	ce.ErrorOutput = log.errorOutput
+	ce.ErrorHandler = log.errorHandler
+
+	if seq := log.sequence; seq != nil {
+		ce = ce.AddFields(Uint64(seq.key, seq.n.Add(1)))
+	}
+
+	if ent.Level == zapcore.DPanicLevel && log.development && log.dumpGoroutines {
+		ce = ce.AddFields(String("goroutines", goroutineDump(log.goroutineDumpLimit)))
+	}
 
 	addStack := log.addStack.Enabled(ce.Level)
 	if !log.addCaller && !addStack {
@@ -372,11 +554,17 @@ func (log *Logger) check(lvl zapcore.Level, msg string) *zapcore.CheckedEntry {
 
 	// Adding the caller or stack trace requires capturing the callers of
 	// this function. We'll share information between these two.
-	stackDepth := stacktrace.First
-	if addStack {
-		stackDepth = stacktrace.Full
+	var stack *stacktrace.Stack
+	switch {
+	case !addStack:
+		stack = stacktrace.Capture(log.callerSkip+callerSkipOffset, stacktrace.First)
+	case log.stackDepth > 0:
+		// The caller is the first frame, so it's always captured, even if
+		// the stack trace skips it.
+		stack = stacktrace.CaptureFrames(log.callerSkip+callerSkipOffset, log.stackSkip+log.stackDepth)
+	default:
+		stack = stacktrace.Capture(log.callerSkip+callerSkipOffset, stacktrace.Full)
 	}
-	stack := stacktrace.Capture(log.callerSkip+callerSkipOffset, stackDepth)
 	defer stack.Free()
 
 	if stack.Count() == 0 {
@@ -410,8 +598,14 @@ func (log *Logger) check(lvl zapcore.Level, msg string) *zapcore.CheckedEntry {
 		stackfmt := stacktrace.NewFormatter(buffer)
 
 		// We've already extracted the first frame, so format that
-		// separately and defer to stackfmt for the rest.
-		stackfmt.FormatFrame(frame)
+		// separately, unless it's skipped, and defer to stackfmt for the
+		// rest.
+		if log.stackSkip == 0 {
+			stackfmt.FormatFrame(frame)
+		}
+		for i := 1; i < log.stackSkip && more; i++ {
+			_, more = stack.Next()
+		}
 		if more {
 			stackfmt.FormatStack(stack)
 		}
@@ -421,6 +615,12 @@ func (log *Logger) check(lvl zapcore.Level, msg string) *zapcore.CheckedEntry {
 	return ce
 }
 
+// nameEnabled reports whether the level registry, if any, enables lvl for
+// this logger's name.
+func (log *Logger) nameEnabled(lvl zapcore.Level) bool {
+	return log.levels == nil || lvl >= log.levels.LevelFor(log.name)
+}
+
 func terminalHookOverride(defaultHook, override zapcore.CheckWriteHook) zapcore.CheckWriteHook {
 	// A nil or WriteThenNoop hook will lead to continued execution after
 	// a Panic or Fatal log entry, which is unexpected. For example,
@@ -21,7 +21,9 @@
 package zap
 
 import (
+	"encoding/json"
 	"errors"
+	"net/netip"
 	"runtime"
 	"strconv"
 	"sync"
@@ -122,6 +124,38 @@ func BenchmarkTimeField(b *testing.B) {
 	})
 }
 
+// The network field benchmarks build their fields up front: values wider than
+// a machine word are boxed once when the field is constructed, but encoding
+// them doesn't allocate.
+
+func BenchmarkAddrField(b *testing.B) {
+	f := Addr("foo", netip.MustParseAddr("2001:db8::1"))
+	withBenchedLogger(b, func(log *Logger) {
+		log.Info("Addr.", f)
+	})
+}
+
+func BenchmarkAddrPortField(b *testing.B) {
+	f := AddrPort("foo", netip.MustParseAddrPort("10.0.0.1:443"))
+	withBenchedLogger(b, func(log *Logger) {
+		log.Info("AddrPort.", f)
+	})
+}
+
+func BenchmarkPrefixField(b *testing.B) {
+	f := Prefix("foo", netip.MustParsePrefix("10.0.0.0/8"))
+	withBenchedLogger(b, func(log *Logger) {
+		log.Info("Prefix.", f)
+	})
+}
+
+func BenchmarkUUIDField(b *testing.B) {
+	f := UUID("foo", [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3})
+	withBenchedLogger(b, func(log *Logger) {
+		log.Info("UUID.", f)
+	})
+}
+
 func BenchmarkDurationField(b *testing.B) {
 	withBenchedLogger(b, func(log *Logger) {
 		log.Info("Duration", Duration("foo", time.Second))
@@ -220,6 +254,46 @@ func Benchmark5WithLazysNotUsed(b *testing.B) {
 	benchmarkWithUsed(b, (*Logger).WithLazy, 5, false)
 }
 
+// BenchmarkNestedWith builds three levels of child loggers with five fields
+// each, as a request handler might for service, request, and operation
+// context.
+func BenchmarkNestedWith(b *testing.B) {
+	const depth, width = 3, 5
+	fields := make([][]Field, depth)
+	for i := range fields {
+		for j := 0; j < width; j++ {
+			fields[i] = append(fields[i], String("k"+strconv.Itoa(i*width+j), "v"))
+		}
+	}
+
+	for _, use := range []bool{false, true} {
+		name := "NotUsed"
+		if use {
+			name = "Used"
+		}
+		b.Run(name, func(b *testing.B) {
+			withBenchedLogger(b, func(log *Logger) {
+				for _, fs := range fields {
+					log = log.With(fs...)
+				}
+				if use {
+					log.Info("used")
+					return
+				}
+				runtime.KeepAlive(log)
+			})
+		})
+	}
+}
+
+// BenchmarkWithOptionsIncreaseLevel measures deriving a less verbose child
+// logger, as is common per request or per subsystem.
+func BenchmarkWithOptionsIncreaseLevel(b *testing.B) {
+	withBenchedLogger(b, func(log *Logger) {
+		runtime.KeepAlive(log.WithOptions(IncreaseLevel(WarnLevel)))
+	})
+}
+
 func benchmarkWithUsed(b *testing.B, withMethod func(*Logger, ...zapcore.Field) *Logger, N int, use bool) {
 	keys := make([]string, N)
 	values := make([]string, N)
@@ -302,6 +376,16 @@ func BenchmarkAny(b *testing.B) {
 			typed:  func() Field { return Stringer(key, InfoLevel) },
 			anyArg: InfoLevel,
 		},
+		{
+			name:   "string map",
+			typed:  func() Field { return StringMap(key, map[string]string{"a": "b", "c": "d"}) },
+			anyArg: map[string]string{"a": "b", "c": "d"},
+		},
+		{
+			name:   "raw JSON",
+			typed:  func() Field { return RawJSON(key, json.RawMessage(`{"a":"b"}`)) },
+			anyArg: json.RawMessage(`{"a":"b"}`),
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,108 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zap
+
+import "github.com/toujourser/zap/zapcore"
+
+// LoggerSettings describes how a Logger names, annotates, and terminates
+// entries, apart from its Core. Adapters that wrap a Logger, and tests that
+// need an equivalent Logger writing to a different Core, use it to copy a
+// Logger's behavior:
+//
+//	replica := zap.New(testCore, zap.WithSettings(logger.Settings()))
+//
+// Options that work by wrapping the Core, such as Hooks, Fields, and
+// WrapCore, are part of the Core rather than the settings, as are fields
+// added with With. Context extractors, level registries, and sequence
+// numbering aren't included either.
+type LoggerSettings struct {
+	Name        string
+	Development bool
+	StrictSugar bool
+
+	// AddCaller and CallerSkip control caller annotation, as set by
+	// WithCaller and AddCallerSkip.
+	AddCaller  bool
+	CallerSkip int
+
+	// AddStack, StackDepth, and StackSkip control stack traces, as set by
+	// AddStacktrace, StacktraceDepth, and StacktraceSkip.
+	AddStack   zapcore.LevelEnabler
+	StackDepth int
+	StackSkip  int
+
+	// OnPanic and OnFatal run after writing Panic- and Fatal-level entries,
+	// as set by WithPanicHook and WithFatalHook. Nil hooks select the
+	// default behavior.
+	OnPanic zapcore.CheckWriteHook
+	OnFatal zapcore.CheckWriteHook
+
+	ErrorOutput  zapcore.WriteSyncer
+	ErrorHandler func(error, zapcore.Entry)
+	Clock        zapcore.Clock
+}
+
+// Settings returns the Logger's settings. See LoggerSettings for details.
+func (log *Logger) Settings() LoggerSettings {
+	return LoggerSettings{
+		Name:         log.name,
+		Development:  log.development,
+		StrictSugar:  log.strictSugar,
+		AddCaller:    log.addCaller,
+		CallerSkip:   log.callerSkip,
+		AddStack:     log.addStack,
+		StackDepth:   log.stackDepth,
+		StackSkip:    log.stackSkip,
+		OnPanic:      log.onPanic,
+		OnFatal:      log.onFatal,
+		ErrorOutput:  log.errorOutput,
+		ErrorHandler: log.errorHandler,
+		Clock:        log.clock,
+	}
+}
+
+// WithSettings replaces the Logger's settings with those returned by another
+// Logger's Settings method. Unlike AddCallerSkip, it sets the number of
+// callers skipped rather than adding to it. Nil AddStack, ErrorOutput, and
+// Clock values leave the Logger's own unchanged; the Clock is passed to the
+// Core as WithClock does.
+func WithSettings(s LoggerSettings) Option {
+	return optionFunc(func(log *Logger) {
+		log.name = s.Name
+		log.development = s.Development
+		log.strictSugar = s.StrictSugar
+		log.addCaller = s.AddCaller
+		log.callerSkip = s.CallerSkip
+		if s.AddStack != nil {
+			log.addStack = s.AddStack
+		}
+		log.stackDepth = s.StackDepth
+		log.stackSkip = s.StackSkip
+		log.onPanic = s.OnPanic
+		log.onFatal = s.OnFatal
+		if s.ErrorOutput != nil {
+			log.errorOutput = s.ErrorOutput
+		}
+		log.errorHandler = s.ErrorHandler
+		if s.Clock != nil {
+			WithClock(s.Clock).apply(log)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/toujourser/zap/internal/ztest"
+	"github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zapcore/clock"
+	"github.com/toujourser/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerSettingsRoundTrip(t *testing.T) {
+	errOut := &ztest.Buffer{}
+	clk := clock.NewMockAt(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	origCore, origLogs := observer.New(DebugLevel)
+	original := New(origCore,
+		Development(),
+		WithStrictSugar(),
+		AddCaller(),
+		AddCallerSkip(1),
+		AddStacktrace(WarnLevel),
+		StacktraceDepth(3),
+		StacktraceSkip(1),
+		WithPanicHook(zapcore.WriteThenGoexit),
+		WithFatalHook(zapcore.WriteThenGoexit),
+		ErrorOutput(errOut),
+		WithClock(clk),
+	).Named("svc")
+
+	replicaCore, replicaLogs := observer.New(DebugLevel)
+	replica := New(replicaCore, WithSettings(original.Settings()))
+	assert.Equal(t, original.Settings(), replica.Settings(), "Expected the replica to have the same settings.")
+	assert.Equal(t, original.Settings(), original.Sugar().Desugar().Settings(), "Expected sugaring not to change the settings.")
+
+	for _, logger := range []*Logger{original, replica} {
+		logFromHelper(logger.WithCallerSkip(-1), 0)
+		logger.WithCallerSkip(-1).Warn("stack")
+	}
+
+	orig, rep := origLogs.AllUntimed(), replicaLogs.AllUntimed()
+	require.Len(t, orig, 2)
+	require.Len(t, rep, 2)
+	for i := range orig {
+		assert.Equal(t, orig[i].Entry, rep[i].Entry, "Expected identical entries, including the caller and stack.")
+	}
+	assert.Equal(t, "svc", rep[0].LoggerName)
+	assert.Equal(t, "github.com/toujourser/zap.logFromHelper", rep[0].Caller.Function)
+	assert.NotEmpty(t, rep[1].Stack, "Expected a stack trace at WarnLevel.")
+	assert.Equal(t, clk.Now(), replicaLogs.All()[0].Time, "Expected the replica to use the clock.")
+}
+
+func TestWithSettingsDefaults(t *testing.T) {
+	logger := New(zapcore.NewNopCore(), AddCallerSkip(5), WithSettings(LoggerSettings{CallerSkip: 1}))
+	s := logger.Settings()
+	assert.Equal(t, 1, s.CallerSkip, "Expected WithSettings to replace the caller skip.")
+	assert.Equal(t, zapcore.FatalLevel+1, s.AddStack, "Expected a nil AddStack to be ignored.")
+	assert.NotNil(t, s.ErrorOutput, "Expected a nil ErrorOutput to be ignored.")
+	assert.Equal(t, zapcore.DefaultClock, s.Clock, "Expected a nil Clock to be ignored.")
+}
@@ -23,6 +23,7 @@ package zap
 import (
 	"errors"
 	"fmt"
+	"runtime"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -372,6 +373,57 @@ func TestLoggerWithCaptures(t *testing.T) {
 	}
 }
 
+type countingObjectMarshaler struct {
+	calls atomic.Int64
+}
+
+func (m *countingObjectMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	m.calls.Add(1)
+	enc.AddString("expensive", "value")
+	return nil
+}
+
+func TestLoggerWithLazyEvaluatesOnce(t *testing.T) {
+	t.Run("never logged", func(t *testing.T) {
+		withLogger(t, InfoLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+			var m countingObjectMarshaler
+			child := logger.WithLazy(Object("req", &m))
+			child.Debug("disabled")
+			if ce := child.Check(DebugLevel, "disabled"); ce != nil {
+				t.Fatal("Expected disabled level to return a nil CheckedEntry.")
+			}
+			assert.Zero(t, m.calls.Load(), "Marshaler must not run if the logger never logs.")
+			assert.Zero(t, logs.Len(), "Expected no logs.")
+		})
+	})
+
+	t.Run("concurrent logging", func(t *testing.T) {
+		const goroutines = 10
+
+		var m countingObjectMarshaler
+		enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "m"})
+		var bs ztest.Buffer
+		child := New(zapcore.NewCore(enc, zapcore.Lock(&bs), InfoLevel)).WithLazy(Object("req", &m))
+
+		var wg sync.WaitGroup
+		for i := 0; i < goroutines; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				child.Debug("disabled")
+				child.Info("enabled")
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int64(1), m.calls.Load(), "Marshaler must run exactly once.")
+		assert.Len(t, bs.Lines(), goroutines, "Expected a log line per goroutine.")
+		for _, line := range bs.Lines() {
+			assert.JSONEq(t, `{"m":"enabled","req":{"expensive":"value"}}`, line)
+		}
+	})
+}
+
 func TestLoggerLogPanic(t *testing.T) {
 	for _, tt := range []struct {
 		do       func(*Logger)
@@ -430,11 +482,12 @@ func TestLoggerLogFatal(t *testing.T) {
 }
 
 func TestLoggerLeveledMethods(t *testing.T) {
-	withLogger(t, DebugLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+	withLogger(t, TraceLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
 		tests := []struct {
 			method        func(string, ...Field)
 			expectedLevel zapcore.Level
 		}{
+			{logger.Trace, TraceLevel},
 			{logger.Debug, DebugLevel},
 			{logger.Info, InfoLevel},
 			{logger.Warn, WarnLevel},
@@ -478,6 +531,38 @@ func TestLoggerLogLevels(t *testing.T) {
 	})
 }
 
+func TestLoggerLogInvalidLevel(t *testing.T) {
+	invalid := []zapcore.Level{zapcore.InvalidLevel, zapcore.Level(-5)}
+
+	t.Run("production", func(t *testing.T) {
+		for _, lvl := range invalid {
+			errOut := &ztest.Buffer{}
+			withLogger(t, DebugLevel, opts(ErrorOutput(errOut)), func(logger *Logger, logs *observer.ObservedLogs) {
+				assert.NotPanics(t, func() { logger.Log(lvl, "foo") }, "Unexpected panic.")
+				assert.Nil(t, logger.Check(lvl, "foo"), "Expected no CheckedEntry for an invalid level.")
+				assert.Zero(t, logs.Len(), "Expected nothing to be logged.")
+				assert.Contains(t, errOut.String(), fmt.Sprintf(`Logger.check error: invalid level %v for message "foo"`, lvl),
+					"Expected the invalid level to be reported.")
+			})
+		}
+	})
+
+	t.Run("development", func(t *testing.T) {
+		for _, lvl := range invalid {
+			withLogger(t, DebugLevel, opts(Development(), AddCaller()), func(logger *Logger, logs *observer.ObservedLogs) {
+				assert.Panics(t, func() { logger.Log(lvl, "foo", String("k", "v")) }, "Expected a DPanic.")
+
+				require.Equal(t, 1, logs.Len(), "Expected the message to be logged.")
+				entry := logs.All()[0]
+				assert.Equal(t, DPanicLevel, entry.Level, "Expected the message to be logged at DPanicLevel.")
+				assert.Equal(t, fmt.Sprintf("invalid level %v: foo", lvl), entry.Message, "Unexpected message.")
+				assert.Equal(t, []Field{String("k", "v")}, entry.Context, "Expected the fields to be logged.")
+				assert.Regexp(t, `logger_test.go:\d+$`, entry.Caller.String(), "Expected the caller of Log.")
+			})
+		}
+	})
+}
+
 func TestLoggerAlwaysPanics(t *testing.T) {
 	// Users can disable writing out panic-level logs, but calls to logger.Panic()
 	// should still call panic().
@@ -656,6 +741,52 @@ func TestLoggerAddCaller(t *testing.T) {
 	}
 }
 
+// logFromHelper logs through CheckSkip, as a shared logging helper would.
+func logFromHelper(logger *Logger, skip int) {
+	if ce := logger.CheckSkip(InfoLevel, "helper", skip); ce != nil {
+		ce.Write()
+	}
+}
+
+func TestLoggerCallerSkipComposes(t *testing.T) {
+	const (
+		helper = "github.com/toujourser/zap.logFromHelper"
+		test   = "github.com/toujourser/zap.TestLoggerCallerSkipComposes.func1.1"
+	)
+	tests := []struct {
+		desc    string
+		options []Option
+		with    int
+		call    int
+		want    string
+	}{
+		{desc: "no skip", want: helper},
+		{desc: "per call", call: 1, want: test},
+		{desc: "with", with: 1, want: test},
+		{desc: "option and with", options: opts(AddCallerSkip(2)), with: -1, want: test},
+		{desc: "all three", options: opts(AddCallerSkip(1)), with: 1, call: -2, want: helper},
+		{desc: "sugar round trip", options: opts(AddCallerSkip(1)), with: -1, call: 1, want: test},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			withLogger(t, DebugLevel, append(tt.options, AddCaller()), func(logger *Logger, logs *observer.ObservedLogs) {
+				logger = logger.WithCallerSkip(tt.with).Sugar().Desugar()
+				logFromHelper(logger, tt.call)
+				output := logs.AllUntimed()
+				require.Len(t, output, 1, "Unexpected number of logs written out.")
+				assert.Equal(t, tt.want, output[0].Caller.Function, "Unexpected caller.")
+			})
+		})
+	}
+}
+
+func TestLoggerWithCallerSkipZero(t *testing.T) {
+	logger := NewNop()
+	assert.Same(t, logger, logger.WithCallerSkip(0), "Expected a zero delta to return the logger.")
+	sugar := logger.Sugar()
+	assert.Same(t, sugar, sugar.WithCallerSkip(0), "Expected a zero delta to return the logger.")
+}
+
 func TestLoggerAddCallerFunction(t *testing.T) {
 	tests := []struct {
 		options         []Option
@@ -795,6 +926,34 @@ func TestLoggerHooks(t *testing.T) {
 	assert.Equal(t, int64(2), seen.Load(), "Hook saw an unexpected number of logs.")
 }
 
+func TestLoggerHooksWithFields(t *testing.T) {
+	var seen [][]Field
+	hook := func(ent zapcore.Entry, fields []Field) error {
+		seen = append(seen, append([]Field(nil), fields...))
+		return nil
+	}
+	var count int
+	countHook := func(zapcore.Entry, []Field) error {
+		count++
+		return nil
+	}
+
+	withLogger(t, InfoLevel, opts(HooksWithFields(hook), HooksWithFields(countHook)), func(logger *Logger, logs *observer.ObservedLogs) {
+		parent := logger.With(String("svc", "api"))
+		child := parent.With(Int("attempt", 2))
+		child.Info("", String("user", "alice"))
+		parent.Info("")
+		child.Debug("", String("dropped", "x"))
+
+		assert.Equal(t, [][]Field{
+			{String("svc", "api"), Int("attempt", 2), String("user", "alice")},
+			{String("svc", "api")},
+		}, seen, "Expected hooks to see the context from parent With calls.")
+		assert.Equal(t, 2, count, "Expected repeated HooksWithFields to be additive.")
+		assert.Equal(t, 2, logs.Len(), "Expected the entries to be written.")
+	})
+}
+
 func TestLoggerConcurrent(t *testing.T) {
 	withLogger(t, DebugLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
 		child := logger.With(String("foo", "bar"))
@@ -1021,6 +1180,215 @@ func TestLoggerWithFatalHook(t *testing.T) {
 	})
 }
 
+func TestLoggerLimitFieldsAndTruncateStrings(t *testing.T) {
+	opts := opts(LimitFields(3), TruncateStrings(4, "~"))
+	withLogger(t, InfoLevel, opts, func(logger *Logger, logs *observer.ObservedLogs) {
+		logger.With(String("user", "alice"), Int("id", 1)).Info("",
+			Error(errors.New("great sadness")),
+			String("dropped", "x"),
+		)
+		logger.Info("", Object("obj", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("k", "nested value")
+			return nil
+		})))
+
+		entries := logs.AllUntimed()
+		require.Len(t, entries, 2, "Expected two entries.")
+		assert.Equal(t, map[string]interface{}{
+			"user":                   "alic~(truncated 1B)",
+			"id":                     int64(1),
+			"error":                  "grea~(truncated 9B)",
+			zapcore.DroppedFieldsKey: int64(1),
+		}, entries[0].ContextMap(), "Unexpected fields.")
+		assert.Equal(t, map[string]interface{}{
+			"obj": map[string]interface{}{"k": "nest~(truncated 8B)"},
+		}, entries[1].ContextMap(), "Unexpected fields.")
+	})
+}
+
+func TestLoggerDeduplicateFields(t *testing.T) {
+	withLogger(t, InfoLevel, opts(DeduplicateFields()), func(logger *Logger, logs *observer.ObservedLogs) {
+		logger.With(String("user", "alice"), Int("attempt", 1)).
+			With(Int("attempt", 2)).
+			Info("", String("user", "bob"))
+
+		entries := logs.AllUntimed()
+		require.Len(t, entries, 1, "Expected one entry.")
+		assert.Equal(t, []Field{Int("attempt", 2), String("user", "bob")}, entries[0].Context, "Unexpected fields.")
+	})
+}
+
+func TestLoggerWithSequence(t *testing.T) {
+	seqs := func(logs *observer.ObservedLogs) []interface{} {
+		var got []interface{}
+		for _, ent := range logs.TakeAll() {
+			got = append(got, ent.ContextMap()["seq"])
+		}
+		return got
+	}
+
+	withLogger(t, InfoLevel, opts(WithSequence("seq")), func(logger *Logger, logs *observer.ObservedLogs) {
+		logger.Info("one")
+		logger.With(String("k", "v")).Info("two", Int("n", 2))
+		logger.Debug("disabled")
+		logger.Named("child").Sugar().Warn("three")
+		logger.WithOptions(AddCaller()).Error("four")
+		assert.Equal(t, []interface{}{uint64(1), uint64(2), uint64(3), uint64(4)}, seqs(logs),
+			"Expected derived loggers to share the counter and disabled entries to be skipped.")
+
+		logger.WithOptions(WithSequence("seq")).Info("restarted")
+		logger.Info("five")
+		assert.Equal(t, []interface{}{uint64(1), uint64(5)}, seqs(logs), "Expected WithSequence to start a new counter.")
+
+		logger.WithOptions(WithSequence("")).Info("unnumbered")
+		assert.Equal(t, []interface{}{nil}, seqs(logs), "Expected an empty name to disable numbering.")
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		withLogger(t, InfoLevel, opts(WithSequence("seq")), func(logger *Logger, logs *observer.ObservedLogs) {
+			var wg sync.WaitGroup
+			runConcurrently(10, 100, &wg, func() { logger.Info("") })
+			wg.Wait()
+
+			seen := make(map[uint64]bool)
+			for _, n := range seqs(logs) {
+				seen[n.(uint64)] = true
+			}
+			assert.Len(t, seen, 1000, "Expected unique sequence numbers.")
+			assert.True(t, seen[1] && seen[1000], "Expected numbers from 1 to 1000.")
+		})
+	})
+}
+
+func TestLoggerFatalHook(t *testing.T) {
+	t.Run("exit code", func(t *testing.T) {
+		var (
+			gotEntry  zapcore.Entry
+			gotFields []Field
+		)
+		hook := func(ent zapcore.Entry, fields []Field) int {
+			gotEntry, gotFields = ent, fields
+			return 3
+		}
+		withLogger(t, InfoLevel, opts(FatalHook(hook)), func(logger *Logger, logs *observer.ObservedLogs) {
+			stub := exit.WithStub(func() {
+				logger.With(String("foo", "bar")).Fatal("great sadness", Int("attempt", 2))
+			})
+			assert.True(t, stub.Exited, "Expected Fatal logger call to terminate process.")
+			assert.Equal(t, 3, stub.Code, "Expected exit code from the hook.")
+			assert.Equal(t, "great sadness", gotEntry.Message, "Unexpected entry passed to hook.")
+			assert.Equal(t, FatalLevel, gotEntry.Level, "Unexpected entry passed to hook.")
+			assert.Equal(t, []Field{Int("attempt", 2)}, gotFields, "Unexpected fields passed to hook.")
+			assert.Equal(t, 1, logs.FilterLevelExact(FatalLevel).Len(), "Expected the entry to be written before the hook.")
+		})
+	})
+
+	t.Run("panicking hook", func(t *testing.T) {
+		hook := func(zapcore.Entry, []Field) int { panic("flush failed") }
+		withLogger(t, InfoLevel, opts(FatalHook(hook)), func(logger *Logger, logs *observer.ObservedLogs) {
+			stub := exit.WithStub(func() {
+				assert.Panics(t, func() { logger.Fatal("great sadness") })
+			})
+			assert.True(t, stub.Exited, "Expected Fatal logger call to terminate process.")
+			assert.Equal(t, 1, stub.Code, "Expected default exit code when the hook panics.")
+		})
+	})
+
+	t.Run("nil hook", func(t *testing.T) {
+		withLogger(t, InfoLevel, opts(FatalHook(nil)), func(logger *Logger, logs *observer.ObservedLogs) {
+			stub := exit.WithStub(func() { logger.Fatal("great sadness") })
+			assert.True(t, stub.Exited, "Expected Fatal logger call to terminate process.")
+			assert.Equal(t, 1, stub.Code, "Expected default exit code.")
+		})
+	})
+
+	t.Run("WriteThenExitCode", func(t *testing.T) {
+		withLogger(t, InfoLevel, opts(WithFatalHook(zapcore.WriteThenExitCode(4))), func(logger *Logger, logs *observer.ObservedLogs) {
+			stub := exit.WithStub(func() { logger.Fatal("great sadness") })
+			assert.True(t, stub.Exited, "Expected Fatal logger call to terminate process.")
+			assert.Equal(t, 4, stub.Code, "Unexpected exit code.")
+		})
+	})
+}
+
+func TestLoggerPanicHook(t *testing.T) {
+	t.Run("panics after hook", func(t *testing.T) {
+		tests := []struct {
+			lvl  zapcore.Level
+			opts []Option
+		}{
+			{PanicLevel, nil},
+			{DPanicLevel, []Option{Development()}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.lvl.String(), func(t *testing.T) {
+				var (
+					gotEntry  zapcore.Entry
+					gotFields []Field
+				)
+				hook := func(ent zapcore.Entry, fields []Field) {
+					gotEntry, gotFields = ent, fields
+				}
+				withLogger(t, InfoLevel, opts(append(tt.opts, PanicHook(hook))...), func(logger *Logger, logs *observer.ObservedLogs) {
+					assert.PanicsWithValue(t, "great sadness", func() {
+						logger.With(String("foo", "bar")).Log(tt.lvl, "great sadness", Int("attempt", 2))
+					}, "Expected the logger to panic after the hook.")
+					assert.Equal(t, "great sadness", gotEntry.Message, "Unexpected entry passed to hook.")
+					assert.Equal(t, tt.lvl, gotEntry.Level, "Unexpected entry passed to hook.")
+					assert.Equal(t, []Field{Int("attempt", 2)}, gotFields, "Unexpected fields passed to hook.")
+					assert.Equal(t, 1, logs.FilterLevelExact(tt.lvl).Len(), "Expected the entry to be written before the hook.")
+				})
+			})
+		}
+	})
+
+	t.Run("hook opts out with Goexit", func(t *testing.T) {
+		hook := func(zapcore.Entry, []Field) { runtime.Goexit() }
+		withLogger(t, InfoLevel, opts(PanicHook(hook)), func(logger *Logger, logs *observer.ObservedLogs) {
+			var finished bool
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				logger.Panic("great sadness")
+				finished = true
+			}()
+			<-done
+			assert.False(t, finished, "Expected the hook to stop the goroutine.")
+			assert.Equal(t, 1, logs.FilterLevelExact(PanicLevel).Len(), "Expected the entry to be written.")
+		})
+	})
+
+	t.Run("hook panics with its own value", func(t *testing.T) {
+		hook := func(zapcore.Entry, []Field) { panic("flush failed") }
+		withLogger(t, InfoLevel, opts(PanicHook(hook)), func(logger *Logger, logs *observer.ObservedLogs) {
+			assert.PanicsWithValue(t, "flush failed", func() { logger.Panic("great sadness") })
+		})
+	})
+
+	t.Run("DPanic in production", func(t *testing.T) {
+		var called bool
+		hook := func(zapcore.Entry, []Field) { called = true }
+		withLogger(t, InfoLevel, opts(PanicHook(hook)), func(logger *Logger, logs *observer.ObservedLogs) {
+			assert.NotPanics(t, func() { logger.DPanic("great sadness") })
+			assert.False(t, called, "Expected the hook to run only for terminal entries.")
+		})
+	})
+
+	t.Run("nil hook", func(t *testing.T) {
+		withLogger(t, InfoLevel, opts(WithPanicHook(zapcore.WriteThenGoexit), PanicHook(nil)), func(logger *Logger, logs *observer.ObservedLogs) {
+			var finished bool
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				logger.Panic("great sadness")
+				finished = true
+			}()
+			<-done
+			assert.False(t, finished, "Expected the existing hook to be kept.")
+		})
+	})
+}
+
 func TestNopLogger(t *testing.T) {
 	logger := NewNop()
 
@@ -0,0 +1,69 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/toujourser/zap/zapcore"
+)
+
+// NameFilter builds an enabler for the entries of loggers whose names match
+// a glob pattern, at or above the given level. Patterns use the syntax of
+// path.Match, and since logger names are dot-separated, a "*" can match
+// dots: "rpc.*" matches loggers named "rpc.client" and "rpc.client.pool",
+// but not "rpc" itself. Entries from all other loggers are disabled.
+//
+// Use the filter as the LevelEnabler of a core:
+//
+//	rpc, err := zap.NameFilter("rpc.*", zap.DebugLevel)
+//	core := zapcore.NewCore(encoder, sink, rpc)
+//
+// To log debug entries from the RPC loggers alongside other loggers' info
+// entries, tee that core with one for the other loggers.
+func NameFilter(pattern string, level zapcore.Level) (zapcore.EntryEnabler, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid logger name pattern %q: %v", pattern, err)
+	}
+	return nameFilter{pattern: pattern, level: level}, nil
+}
+
+type nameFilter struct {
+	pattern string
+	level   zapcore.Level
+}
+
+var _ zapcore.LeveledEnabler = nameFilter{}
+
+func (f nameFilter) Enabled(lvl zapcore.Level) bool {
+	return f.level.Enabled(lvl)
+}
+
+func (f nameFilter) Level() zapcore.Level {
+	return f.level
+}
+
+func (f nameFilter) EnabledFor(ent zapcore.Entry) bool {
+	// The pattern was validated by NameFilter, so Match can't fail.
+	ok, _ := path.Match(f.pattern, ent.LoggerName)
+	return ok && f.level.Enabled(ent.Level)
+}
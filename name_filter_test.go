@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"testing"
+
+	"github.com/toujourser/zap/internal/ztest"
+	"github.com/toujourser/zap/zapcore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameFilter(t *testing.T) {
+	rpc, err := NameFilter("rpc.*", DebugLevel)
+	require.NoError(t, err)
+	assert.Equal(t, DebugLevel, zapcore.LevelOf(rpc))
+
+	tests := []struct {
+		name string
+		lvl  zapcore.Level
+		want bool
+	}{
+		{"rpc.client", DebugLevel, true},
+		{"rpc.client.pool", DebugLevel, true},
+		{"rpc.server", ErrorLevel, true},
+		{"rpc", DebugLevel, false},
+		{"db", InfoLevel, false},
+		{"", InfoLevel, false},
+		{"rpc.client", TraceLevel, false},
+	}
+	for _, tt := range tests {
+		ent := zapcore.Entry{LoggerName: tt.name, Level: tt.lvl}
+		assert.Equal(t, tt.want, rpc.Enabled(tt.lvl) && rpc.EnabledFor(ent),
+			"Unexpected result for %v entry from logger %q.", tt.lvl, tt.name)
+	}
+}
+
+func TestNameFilterInvalidPattern(t *testing.T) {
+	_, err := NameFilter("rpc.[", DebugLevel)
+	assert.ErrorContains(t, err, `invalid logger name pattern "rpc.["`)
+}
+
+func TestNameFilterCore(t *testing.T) {
+	rpc, err := NameFilter("rpc.*", DebugLevel)
+	require.NoError(t, err)
+
+	buf := &ztest.Buffer{}
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg", NameKey: "logger"})
+	logger := New(zapcore.NewCore(enc, buf, rpc))
+	logger.Named("rpc").Named("client").Debug("rpc debug")
+	logger.Named("db").Info("db info")
+	logger.Debug("root debug")
+
+	assert.Equal(t, []string{`{"logger":"rpc.client","msg":"rpc debug"}`}, buf.Lines())
+}
@@ -0,0 +1,409 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultNetMaxBuffer    = 1 << 20 // 1 MiB
+	_defaultNetMinBackoff   = 100 * time.Millisecond
+	_defaultNetMaxBackoff   = 30 * time.Second
+	_defaultNetDialTimeout  = 5 * time.Second
+	_defaultNetWriteTimeout = 5 * time.Second
+)
+
+// _netSchemes are the URL schemes handled by the network sink. Each scheme
+// is also the network passed to net.Dial.
+var _netSchemes = []string{"tcp", "udp", "unix", "unixgram"}
+
+// netSinkOptions configures a netSink. See newNetSinkFromURL for the URL
+// parameters that map onto these options.
+type netSinkOptions struct {
+	// Network is the network passed to net.Dial.
+	Network string
+	// Address is the address passed to net.Dial.
+	Address string
+	// MaxBuffer is the maximum number of bytes held in memory while the
+	// sink is disconnected.
+	MaxBuffer int
+	// MinBackoff and MaxBackoff bound the delay between connection
+	// attempts.
+	MinBackoff, MaxBackoff time.Duration
+	// DialTimeout bounds each connection attempt.
+	DialTimeout time.Duration
+	// WriteTimeout bounds each write to the connection. Zero disables it.
+	WriteTimeout time.Duration
+}
+
+// netSink is a Sink that writes to a network connection.
+//
+// The connection is dialed in the background on the first write, and
+// redialed whenever a write fails, with exponential backoff and jitter
+// between attempts. Writes never wait for a connection: while the sink is
+// disconnected, they're held in memory, up to a limit, and sent once it
+// reconnects. If the limit is reached, the oldest writes are dropped.
+//
+// Write reports connection failures and dropped writes once per outage, so
+// that they reach the logger's error output without flooding it.
+type netSink struct {
+	opts netSinkOptions
+	dial func(network, address string, timeout time.Duration) (net.Conn, error)
+
+	mu           sync.Mutex
+	conn         net.Conn
+	pending      [][]byte // writes waiting to be sent, oldest first
+	pendingBytes int
+	dropped      int   // bytes dropped during the current outage
+	lastErr      error // most recent connection error
+	unreported   error // error to return from the next Write
+	reported     bool  // whether dropped writes have been reported
+	started      bool
+	closed       bool
+
+	wake chan struct{} // wakes the reconnect loop; buffered
+	done chan struct{} // closed by Close
+	wg   sync.WaitGroup
+}
+
+var _ Sink = (*netSink)(nil)
+
+func newNetSink(
+	opts netSinkOptions,
+	dial func(network, address string, timeout time.Duration) (net.Conn, error),
+) *netSink {
+	return &netSink{
+		opts: opts,
+		dial: dial,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+}
+
+// newNetSinkFromURL builds a sink from a URL such as tcp://host:port,
+// udp://host:port, or unixgram:///path/to/socket. The following query
+// parameters are supported:
+//
+//   - maxBuffer: bytes held in memory while disconnected (default 1MiB)
+//   - minBackoff, maxBackoff: delay between connection attempts, as
+//     accepted by time.ParseDuration (default 100ms and 30s)
+//   - dialTimeout: timeout for each connection attempt (default 5s)
+//   - writeTimeout: timeout for each write; zero disables it (default 5s)
+func newNetSinkFromURL(u *url.URL) (Sink, error) {
+	if u.User != nil {
+		return nil, fmt.Errorf("user and password not allowed with %s URLs: got %v", u.Scheme, u)
+	}
+	if u.Fragment != "" {
+		return nil, fmt.Errorf("fragments not allowed with %s URLs: got %v", u.Scheme, u)
+	}
+
+	opts, err := parseNetSinkOptions(u.Query())
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s URL %v: %w", u.Scheme, u, err)
+	}
+
+	opts.Network = u.Scheme
+	switch opts.Network {
+	case "tcp", "udp":
+		if u.Hostname() == "" || u.Port() == "" {
+			return nil, fmt.Errorf("%s URLs must include a host and port: got %v", u.Scheme, u)
+		}
+		if u.Path != "" && u.Path != "/" {
+			return nil, fmt.Errorf("paths not allowed with %s URLs: got %v", u.Scheme, u)
+		}
+		opts.Address = u.Host
+	default:
+		if u.Host != "" {
+			return nil, fmt.Errorf("%s URLs must leave host empty: got %v", u.Scheme, u)
+		}
+		if u.Path == "" {
+			return nil, fmt.Errorf("%s URLs must include a socket path: got %v", u.Scheme, u)
+		}
+		opts.Address = u.Path
+	}
+
+	return newNetSink(opts, net.DialTimeout), nil
+}
+
+func parseNetSinkOptions(q url.Values) (netSinkOptions, error) {
+	opts := netSinkOptions{
+		MaxBuffer:    _defaultNetMaxBuffer,
+		MinBackoff:   _defaultNetMinBackoff,
+		MaxBackoff:   _defaultNetMaxBackoff,
+		DialTimeout:  _defaultNetDialTimeout,
+		WriteTimeout: _defaultNetWriteTimeout,
+	}
+	for key, vals := range q {
+		if len(vals) != 1 {
+			return opts, fmt.Errorf("parameter %q must be specified exactly once", key)
+		}
+		val := vals[0]
+
+		var err error
+		switch key {
+		case "maxBuffer":
+			opts.MaxBuffer, err = strconv.Atoi(val)
+			if err == nil && opts.MaxBuffer < 0 {
+				err = errors.New("must not be negative")
+			}
+		case "minBackoff":
+			opts.MinBackoff, err = parsePositiveDuration(val)
+		case "maxBackoff":
+			opts.MaxBackoff, err = parsePositiveDuration(val)
+		case "dialTimeout":
+			opts.DialTimeout, err = parsePositiveDuration(val)
+		case "writeTimeout":
+			opts.WriteTimeout, err = time.ParseDuration(val)
+		default:
+			return opts, fmt.Errorf("unknown parameter %q", key)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("can't parse %q parameter: %w", key, err)
+		}
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		return opts, fmt.Errorf("maxBackoff %v is less than minBackoff %v", opts.MaxBackoff, opts.MinBackoff)
+	}
+	return opts, nil
+}
+
+func parsePositiveDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err == nil && d <= 0 {
+		err = errors.New("must be positive")
+	}
+	return d, err
+}
+
+// Write sends p over the connection. If the sink is disconnected, or the
+// write fails, p is held until the sink reconnects.
+func (s *netSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, fmt.Errorf("write to closed %s sink", s.opts.Network)
+	}
+
+	if s.conn != nil {
+		err := s.send(p)
+		if err == nil {
+			return len(p), nil
+		}
+		s.disconnect(err)
+	}
+
+	s.enqueue(p)
+	s.reconnect()
+
+	err := s.unreported
+	s.unreported = nil
+	return len(p), err
+}
+
+// Sync reports an error if writes are waiting for the sink to reconnect.
+func (s *netSink) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.pendingBytes == 0 {
+		return nil
+	}
+	return s.outageErr(fmt.Sprintf("%d bytes waiting to be sent", s.pendingBytes))
+}
+
+// Close stops reconnecting and closes the connection. Writes that haven't
+// been sent are discarded.
+func (s *netSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+
+	var err error
+	if s.pendingBytes > 0 {
+		err = s.outageErr(fmt.Sprintf("discarded %d unsent bytes", s.pendingBytes))
+	}
+	s.pending = nil
+	s.pendingBytes = 0
+	if s.conn != nil {
+		if cerr := s.conn.Close(); err == nil {
+			err = cerr
+		}
+		s.conn = nil
+	}
+	s.mu.Unlock()
+
+	close(s.done)
+	s.wg.Wait()
+	return err
+}
+
+// send writes p to the connection. The caller must hold the lock.
+func (s *netSink) send(p []byte) error {
+	if s.opts.WriteTimeout > 0 {
+		if err := s.conn.SetWriteDeadline(time.Now().Add(s.opts.WriteTimeout)); err != nil {
+			return err
+		}
+	}
+	_, err := s.conn.Write(p)
+	return err
+}
+
+// disconnect closes a connection that failed with err and arranges for the
+// failure to be reported. The caller must hold the lock.
+func (s *netSink) disconnect(err error) {
+	_ = s.conn.Close()
+	s.conn = nil
+	s.fail(fmt.Errorf("write to %s sink %v: %w", s.opts.Network, s.opts.Address, err))
+}
+
+// fail records a connection error. Only the first error of an outage is
+// reported. The caller must hold the lock.
+func (s *netSink) fail(err error) {
+	if s.lastErr == nil {
+		s.unreported = err
+	}
+	s.lastErr = err
+}
+
+// enqueue holds a copy of p until the sink reconnects, dropping the oldest
+// writes to stay within the buffer limit. The caller must hold the lock.
+func (s *netSink) enqueue(p []byte) {
+	s.pending = append(s.pending, append([]byte(nil), p...))
+	s.pendingBytes += len(p)
+
+	for s.pendingBytes > s.opts.MaxBuffer && len(s.pending) > 0 {
+		s.dropped += len(s.pending[0])
+		s.pendingBytes -= len(s.pending[0])
+		s.pending[0] = nil
+		s.pending = s.pending[1:]
+	}
+
+	if s.dropped > 0 && !s.reported {
+		s.reported = true
+		s.unreported = s.outageErr("dropping writes: buffer of " + strconv.Itoa(s.opts.MaxBuffer) + " bytes is full")
+	}
+}
+
+// outageErr describes the current outage. The caller must hold the lock.
+func (s *netSink) outageErr(msg string) error {
+	return outageError(fmt.Sprintf("%s sink %v disconnected", s.opts.Network, s.opts.Address), msg, s.lastErr)
+}
+
+// flush sends held writes over a new connection. The caller must hold the
+// lock.
+func (s *netSink) flush() error {
+	for len(s.pending) > 0 {
+		if err := s.send(s.pending[0]); err != nil {
+			return err
+		}
+		s.pendingBytes -= len(s.pending[0])
+		s.pending[0] = nil
+		s.pending = s.pending[1:]
+	}
+	s.pending = nil
+	return nil
+}
+
+// reconnect wakes the reconnect loop, starting it if necessary. The caller
+// must hold the lock.
+func (s *netSink) reconnect() {
+	if !s.started {
+		s.started = true
+		s.wg.Add(1)
+		go s.run()
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run dials the connection whenever the sink is woken up while disconnected,
+// until the sink is closed.
+func (s *netSink) run() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+		}
+
+		backoff := sinkBackoff{min: s.opts.MinBackoff, max: s.opts.MaxBackoff}
+		for !s.connect() {
+			if !backoff.wait(s.done) {
+				return
+			}
+		}
+	}
+}
+
+// connect dials the connection and sends held writes over it. It reports
+// whether the sink no longer needs to reconnect.
+func (s *netSink) connect() bool {
+	s.mu.Lock()
+	connected := s.conn != nil || s.closed
+	s.mu.Unlock()
+	if connected {
+		return true
+	}
+
+	conn, err := s.dial(s.opts.Network, s.opts.Address, s.opts.DialTimeout)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		if conn != nil {
+			_ = conn.Close()
+		}
+		return true
+	}
+	if err != nil {
+		s.fail(fmt.Errorf("connect to %s sink %v: %w", s.opts.Network, s.opts.Address, err))
+		return false
+	}
+
+	s.conn = conn
+	if err := s.flush(); err != nil {
+		s.disconnect(err)
+		return false
+	}
+
+	// The outage is over.
+	s.lastErr = nil
+	s.unreported = nil
+	s.reported = false
+	s.dropped = 0
+	return true
+}
@@ -21,8 +21,10 @@
 package zap
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/toujourser/zap/internal/exit"
 	"github.com/toujourser/zap/zapcore"
 )
 
@@ -49,15 +51,28 @@ func WrapCore(f func(zapcore.Core) zapcore.Core) Option {
 // out an Entry. Repeated use of Hooks is additive.
 //
 // Hooks are useful for simple side effects, like capturing metrics for the
-// number of emitted logs. More complex side effects, including anything that
-// requires access to the Entry's structured fields, should be implemented as
-// a zapcore.Core instead. See zapcore.RegisterHooks for details.
+// number of emitted logs. Use HooksWithFields for side effects that require
+// access to the Entry's structured fields, and implement a zapcore.Core for
+// anything more complex. See zapcore.RegisterHooks for details.
 func Hooks(hooks ...func(zapcore.Entry) error) Option {
 	return optionFunc(func(log *Logger) {
 		log.core = zapcore.RegisterHooks(log.core, hooks...)
 	})
 }
 
+// HooksWithFields registers functions which will be called each time the
+// Logger writes out an Entry, with the Entry's fields: those added with With
+// to the Logger and its parents, followed by those passed at the log site.
+// The slice of fields is read-only and must not be retained after the hook
+// returns. Repeated use of HooksWithFields is additive.
+//
+// See zapcore.RegisterFieldHooks for details.
+func HooksWithFields(hooks ...func(zapcore.Entry, []zapcore.Field) error) Option {
+	return optionFunc(func(log *Logger) {
+		log.core = zapcore.RegisterFieldHooks(log.core, hooks...)
+	})
+}
+
 // Fields adds fields to the Logger.
 func Fields(fs ...Field) Option {
 	return optionFunc(func(log *Logger) {
@@ -78,6 +93,27 @@ func ErrorOutput(w zapcore.WriteSyncer) Option {
 	})
 }
 
+// ErrorHandler sets a function to receive errors encountered while writing
+// log entries, such as encoding failures and failed writes of the underlying
+// sinks, together with the entry that couldn't be written. Errors returned
+// by Logger.Sync are passed to it too, with an entry that only carries the
+// Logger's name and the time, unless they're ignorable (see
+// zapcore.SyncError); the syncs that cores built by zapcore.NewCore perform
+// after writing entries above ErrorLevel ignore errors, as before. Entries
+// that are rejected when they're checked, such as those with an invalid
+// level in production, are passed to it too.
+//
+// By default, write errors are written to the ErrorOutput as plain text;
+// Loggers built from a Config instead write them as structured lines using
+// the Config's encoder. Errors from logging performed inside the handler are
+// not passed back to it: they're written to the ErrorOutput as plain text.
+// Passing nil restores the plain-text default.
+func ErrorHandler(f func(err error, ent zapcore.Entry)) Option {
+	return optionFunc(func(log *Logger) {
+		log.errorHandler = f
+	})
+}
+
 // Development puts the logger in development mode, which makes DPanic-level
 // logs panic instead of simply logging an error.
 func Development() Option {
@@ -86,6 +122,30 @@ func Development() Option {
 	})
 }
 
+// DPanicGoroutineDump configures a Logger in development mode to add the
+// stacks of all goroutines to DPanic-level entries, under the "goroutines"
+// key, before panicking. Cross-goroutine bugs are often easier to diagnose
+// with the full dump. Dumps are truncated to GoroutineDumpLimit bytes.
+//
+// It has no effect unless the logger is in development mode.
+func DPanicGoroutineDump() Option {
+	return optionFunc(func(log *Logger) {
+		log.dumpGoroutines = true
+	})
+}
+
+// GoroutineDumpLimit limits the goroutine dumps recorded by the
+// DPanicGoroutineDump option to n bytes. Values less than one restore the
+// default of 1MiB.
+func GoroutineDumpLimit(n int) Option {
+	return optionFunc(func(log *Logger) {
+		if n < 0 {
+			n = 0
+		}
+		log.goroutineDumpLimit = n
+	})
+}
+
 // AddCaller configures the Logger to annotate each message with the filename,
 // line number, and function name of zap's caller. See also WithCaller.
 func AddCaller() Option {
@@ -119,20 +179,105 @@ func AddStacktrace(lvl zapcore.LevelEnabler) Option {
 	})
 }
 
+// StacktraceDepth limits stack traces recorded by the AddStacktrace option
+// to at most n frames. Deep stacks are truncated, keeping the frames closest
+// to the log site. Values less than one remove the limit, which is the
+// default.
+func StacktraceDepth(n int) Option {
+	return optionFunc(func(log *Logger) {
+		if n < 0 {
+			n = 0
+		}
+		log.stackDepth = n
+	})
+}
+
+// StacktraceSkip omits the given number of frames from the start of stack
+// traces recorded by the AddStacktrace option. Use it to hide helpers and
+// wrappers that log errors on behalf of their callers. Unlike AddCallerSkip,
+// it doesn't change the caller annotation. Frames skipped this way don't
+// count toward the StacktraceDepth limit.
+func StacktraceSkip(frames int) Option {
+	return optionFunc(func(log *Logger) {
+		if frames < 0 {
+			frames = 0
+		}
+		log.stackSkip = frames
+	})
+}
+
 // IncreaseLevel increase the level of the logger. It has no effect if
 // the passed in level tries to decrease the level of the logger.
 func IncreaseLevel(lvl zapcore.LevelEnabler) Option {
+	return increaseLevelOption{lvl}
+}
+
+// increaseLevelOption is the Option returned by IncreaseLevel. It's a
+// distinct type so that WithOptions can recognize level-only changes.
+type increaseLevelOption struct {
+	lvl zapcore.LevelEnabler
+}
+
+func (o increaseLevelOption) apply(log *Logger) {
+	if core, ok := log.increasedCore(o.lvl); ok {
+		log.core = core
+	}
+}
+
+// OverrideLevel sets the level of the logger, whether it's higher or lower
+// than the current one. Unlike IncreaseLevel, it replaces the level set by
+// earlier calls to IncreaseLevel and OverrideLevel instead of narrowing it,
+// so a child logger may be more verbose than its parent.
+//
+// The logger's core still checks each entry, so entries below the core's
+// own level are dropped: the override takes effect only as far as the
+// core's enabler, such as an AtomicLevel, permits. If the override is below
+// the core's current level, a warning is written to the logger's error
+// output.
+func OverrideLevel(lvl zapcore.LevelEnabler) Option {
 	return optionFunc(func(log *Logger) {
-		core, err := zapcore.NewIncreaseLevelCore(log.core, lvl)
-		if err != nil {
+		core, floor := zapcore.NewOverrideLevelCore(log.core, lvl)
+		if want := zapcore.LevelOf(lvl); want < floor {
 			_, _ = fmt.Fprintf(
 				log.errorOutput,
-				"failed to IncreaseLevel: %v\n",
-				err,
+				"OverrideLevel: %v entries and above are enabled, but the core logs only %v and above\n",
+				want, floor,
 			)
-		} else {
-			log.core = core
 		}
+		log.core = core
+	})
+}
+
+// LimitFields caps the number of fields on each log entry at max, counting
+// the fields added with With after this option and the fields passed to
+// each log call. Entries that exceed the limit keep their first max fields
+// and get a zapcore.DroppedFieldsKey field with the number of fields
+// dropped. See zapcore.NewFieldLimitCore.
+func LimitFields(max int) Option {
+	return optionFunc(func(log *Logger) {
+		log.core = zapcore.NewFieldLimitCore(log.core, max)
+	})
+}
+
+// TruncateStrings truncates String, ByteString, and Error field values
+// longer than maxLen bytes, as well as strings inside objects and arrays
+// logged with zap.Object, zap.Inline, and zap.Array, appending marker and
+// the amount removed, for example "…(truncated 39MB)". If marker is empty,
+// zapcore.DefaultTruncationMarker is used. See zapcore.NewTruncatingCore.
+func TruncateStrings(maxLen int, marker string) Option {
+	return optionFunc(func(log *Logger) {
+		log.core = zapcore.NewTruncatingCore(log.core, maxLen, marker)
+	})
+}
+
+// DeduplicateFields drops fields whose key is repeated, so that each key
+// appears once in every namespace of each entry. It considers the fields
+// added with With after this option and the fields passed to each log call,
+// and keeps the last field with each key unless zapcore.KeepFirstField is
+// given. See zapcore.NewFieldDedupCore.
+func DeduplicateFields(opts ...zapcore.FieldDedupOption) Option {
+	return optionFunc(func(log *Logger) {
+		log.core = zapcore.NewFieldDedupCore(log.core, opts...)
 	})
 }
 
@@ -145,12 +290,51 @@ func IncreaseLevel(lvl zapcore.LevelEnabler) Option {
 //	zap.New(core, zap.WithPanicHook(zapcore.WriteThenGoexit))
 //
 // This is useful for testing Panic/DPanic log output.
+//
+// Unlike the default, a custom CheckWriteHook that returns normally lets
+// execution continue past the Panic or DPanic call, which callers rarely
+// expect. Hooks that only need to do some work before the panic, such as
+// flushing sinks or notifying a crash reporter, should use PanicHook
+// instead, which still panics once the hook returns.
 func WithPanicHook(hook zapcore.CheckWriteHook) Option {
 	return optionFunc(func(log *Logger) {
 		log.onPanic = hook
 	})
 }
 
+// PanicHook runs hook after writing a log statement with a Panic level (or a
+// DPanic level in development), then panics with the entry's message like
+// the default behavior. The hook receives the entry and the fields passed to
+// the logging call, so it can flush sinks or report the crash before the
+// panic unwinds the stack:
+//
+//	zap.New(core, zap.PanicHook(func(ent zapcore.Entry, _ []zapcore.Field) {
+//	  crashreporter.Notify(ent.Message)
+//	  crashreporter.Flush()
+//	}))
+//
+// The fields don't include those added to the logger with With. A hook that
+// doesn't want the default panic must opt out explicitly, either by
+// panicking with its own value or by calling runtime.Goexit. PanicHook
+// replaces any hook set with WithPanicHook, and a nil hook leaves the
+// logger's panic hook unchanged.
+func PanicHook(hook func(zapcore.Entry, []zapcore.Field)) Option {
+	return optionFunc(func(log *Logger) {
+		if hook != nil {
+			log.onPanic = panicHook(hook)
+		}
+	})
+}
+
+// panicHook adapts the function passed to PanicHook into a
+// zapcore.CheckWriteHook.
+type panicHook func(zapcore.Entry, []zapcore.Field)
+
+func (f panicHook) OnWrite(ce *zapcore.CheckedEntry, fields []zapcore.Field) {
+	f(ce.Entry, fields)
+	panic(ce.Message)
+}
+
 // OnFatal sets the action to take on fatal logs.
 //
 // Deprecated: Use [WithFatalHook] instead.
@@ -177,10 +361,104 @@ func WithFatalHook(hook zapcore.CheckWriteHook) Option {
 	})
 }
 
+// FatalHook runs hook after writing a log statement with a Fatal level, then
+// exits the process with the code that hook returns. The hook receives the
+// entry and the fields passed to the logging call, so it can flush other
+// subsystems or pick an exit code based on the failure:
+//
+//	zap.New(core, zap.FatalHook(func(ent zapcore.Entry, _ []zapcore.Field) int {
+//	  metrics.Flush()
+//	  return 3
+//	}))
+//
+// The fields don't include those added to the logger with With. If hook
+// panics, the process exits with code 1. FatalHook replaces any hook set
+// with WithFatalHook, and a nil hook leaves the logger's fatal hook
+// unchanged.
+func FatalHook(hook func(zapcore.Entry, []zapcore.Field) int) Option {
+	return optionFunc(func(log *Logger) {
+		if hook != nil {
+			log.onFatal = fatalExitHook(hook)
+		}
+	})
+}
+
+// fatalExitHook adapts the function passed to FatalHook into a
+// zapcore.CheckWriteHook.
+type fatalExitHook func(zapcore.Entry, []zapcore.Field) int
+
+func (f fatalExitHook) OnWrite(ce *zapcore.CheckedEntry, fields []zapcore.Field) {
+	code := 1
+	defer func() { exit.With(code) }()
+	code = f(ce.Entry, fields)
+}
+
+// WithStrictSugar makes the SugaredLogger report misused loosely-typed
+// key-value pairs, such as a key without a value or a key that isn't a
+// string, at DPanicLevel instead of ErrorLevel, along with all the key-value
+// pairs that were passed. Combined with Development, this makes such
+// mistakes panic, so that tests catch them.
+func WithStrictSugar() Option {
+	return optionFunc(func(log *Logger) {
+		log.strictSugar = true
+	})
+}
+
 // WithClock specifies the clock used by the logger to determine the current
 // time for logged entries. Defaults to the system clock with time.Now.
+//
+// The clock is also passed to the cores built by zapcore.NewSamplerWithOptions,
+// zapcore.NewRateLimitCore, and the like, and to user-defined cores that
+// implement zapcore.ClockCopier, by replacing the logger's core with a copy
+// from zapcore.WithClock. The original core is left unchanged, so
+// applying this option with WithOptions doesn't affect the parent logger.
+// Cores wrapped with WrapCore after this option don't receive the clock.
 func WithClock(clock zapcore.Clock) Option {
 	return optionFunc(func(log *Logger) {
 		log.clock = clock
+		log.core = zapcore.WithClock(log.core, clock)
+	})
+}
+
+// WithLevelRegistry makes the logger, and any loggers derived from it, look up
+// their level by name in the given registry on every log call. Entries below
+// that level are dropped even if the logger's core would accept them.
+func WithLevelRegistry(levels *LevelRegistry) Option {
+	return optionFunc(func(log *Logger) {
+		log.levels = levels
+	})
+}
+
+// WithSequence numbers the entries the logger writes, adding the number to
+// each entry as a field with the given name. Numbers start at one and are
+// assigned when entries are checked, in order, from a counter shared with the
+// loggers derived from this one with With, Named, and WithOptions; applying
+// WithSequence again starts a new counter. Entries that the logger's core
+// rejects when checking them, such as those dropped by a sampler, aren't
+// numbered, so gaps downstream mean entries were lost after that point.
+//
+// If fieldName is empty, entries aren't numbered.
+func WithSequence(fieldName string) Option {
+	return optionFunc(func(log *Logger) {
+		if fieldName == "" {
+			log.sequence = nil
+			return
+		}
+		log.sequence = &sequence{key: fieldName}
+	})
+}
+
+// ContextFields registers functions that extract fields, such as trace or
+// request IDs, from a context.Context. The fields are added to the loggers
+// returned by Logger.Ctx and SugaredLogger.Ctx. Repeated use of ContextFields
+// is additive, and the extracted fields are added in registration order.
+//
+// Extractors should return nil if the context carries nothing of interest.
+func ContextFields(extractors ...func(ctx context.Context) []Field) Option {
+	return optionFunc(func(log *Logger) {
+		// Don't share the backing array with the parent logger.
+		fs := make([]func(context.Context) []Field, 0, len(log.ctxFields)+len(extractors))
+		fs = append(fs, log.ctxFields...)
+		log.ctxFields = append(fs, extractors...)
 	})
 }
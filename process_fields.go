@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/toujourser/zap/zapcore"
+)
+
+const _unknownHostname = "unknown"
+
+var _defaultProcessKeys = zapcore.ProcessKeys{
+	HostnameKey: "hostname",
+	PIDKey:      "pid",
+	VersionKey:  "version",
+	RevisionKey: "revision",
+}
+
+// Indirections for tests.
+var (
+	_osHostname    = os.Hostname
+	_readBuildInfo = debug.ReadBuildInfo
+)
+
+// buildProcessFields returns the fields enabled by IncludeHostname,
+// IncludePID, and IncludeBuildInfo. Failures to determine the hostname are
+// reported to errSink.
+func (cfg Config) buildProcessFields(errSink zapcore.WriteSyncer) []Field {
+	keys := mergeProcessKeys(_defaultProcessKeys, cfg.EncoderConfig.Process)
+
+	var fs []Field
+	if cfg.IncludeHostname {
+		host, err := _osHostname()
+		if err != nil || host == "" {
+			if err == nil {
+				err = errors.New("empty hostname")
+			}
+			fmt.Fprintf(errSink, "%v can't determine hostname: %v\n", time.Now().UTC(), err)
+			_ = errSink.Sync()
+			host = _unknownHostname
+		}
+		fs = append(fs, String(keys.HostnameKey, host))
+	}
+	if cfg.IncludePID {
+		fs = append(fs, Int(keys.PIDKey, os.Getpid()))
+	}
+	if cfg.IncludeBuildInfo {
+		if info, ok := _readBuildInfo(); ok {
+			if v := info.Main.Version; v != "" {
+				fs = append(fs, String(keys.VersionKey, v))
+			}
+			for _, s := range info.Settings {
+				if s.Key == "vcs.revision" && s.Value != "" {
+					fs = append(fs, String(keys.RevisionKey, s.Value))
+				}
+			}
+		}
+	}
+	return fs
+}
+
+// mergeProcessKeys fills in the empty keys in override from base.
+func mergeProcessKeys(base, override zapcore.ProcessKeys) zapcore.ProcessKeys {
+	if override.HostnameKey != "" {
+		base.HostnameKey = override.HostnameKey
+	}
+	if override.PIDKey != "" {
+		base.PIDKey = override.PIDKey
+	}
+	if override.VersionKey != "" {
+		base.VersionKey = override.VersionKey
+	}
+	if override.RevisionKey != "" {
+		base.RevisionKey = override.RevisionKey
+	}
+	return base
+}
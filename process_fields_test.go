@@ -0,0 +1,149 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"errors"
+	"os"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toujourser/zap/internal/ztest"
+	"github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zaptest/observer"
+)
+
+func stubProcessInfo(t *testing.T, host string, hostErr error, info *debug.BuildInfo) {
+	origHostname, origBuildInfo := _osHostname, _readBuildInfo
+	t.Cleanup(func() {
+		_osHostname, _readBuildInfo = origHostname, origBuildInfo
+	})
+	_osHostname = func() (string, error) { return host, hostErr }
+	_readBuildInfo = func() (*debug.BuildInfo, bool) { return info, info != nil }
+}
+
+func TestConfigProcessFields(t *testing.T) {
+	info := &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app", Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "abc123"},
+		},
+	}
+
+	tests := []struct {
+		desc    string
+		modify  func(*Config)
+		host    string
+		hostErr error
+		info    *debug.BuildInfo
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			desc: "disabled",
+			host: "box",
+			info: info,
+			want: map[string]interface{}{},
+		},
+		{
+			desc: "all",
+			modify: func(cfg *Config) {
+				cfg.IncludeHostname = true
+				cfg.IncludePID = true
+				cfg.IncludeBuildInfo = true
+			},
+			host: "box",
+			info: info,
+			want: map[string]interface{}{
+				"hostname": "box",
+				"pid":      int64(os.Getpid()),
+				"version":  "v1.2.3",
+				"revision": "abc123",
+			},
+		},
+		{
+			desc: "custom keys",
+			modify: func(cfg *Config) {
+				cfg.IncludeHostname = true
+				cfg.IncludeBuildInfo = true
+				cfg.EncoderConfig.Process = zapcore.ProcessKeys{HostnameKey: "host", RevisionKey: "commit"}
+			},
+			host: "box",
+			info: info,
+			want: map[string]interface{}{"host": "box", "version": "v1.2.3", "commit": "abc123"},
+		},
+		{
+			desc:   "no build info",
+			modify: func(cfg *Config) { cfg.IncludeBuildInfo = true },
+			want:   map[string]interface{}{},
+		},
+		{
+			desc:    "hostname error",
+			modify:  func(cfg *Config) { cfg.IncludeHostname = true },
+			hostErr: errors.New("no uname"),
+			want:    map[string]interface{}{"hostname": "unknown"},
+			wantErr: "can't determine hostname: no uname",
+		},
+		{
+			desc: "InitialFields take precedence",
+			modify: func(cfg *Config) {
+				cfg.IncludeHostname = true
+				cfg.IncludePID = true
+				cfg.InitialFields = map[string]interface{}{"hostname": "override", "app": "demo"}
+			},
+			host: "box",
+			want: map[string]interface{}{
+				"hostname": "override",
+				"pid":      int64(os.Getpid()),
+				"app":      "demo",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			stubProcessInfo(t, tt.host, tt.hostErr, tt.info)
+
+			cfg := NewProductionConfig()
+			if tt.modify != nil {
+				tt.modify(&cfg)
+			}
+			errSink := &ztest.Buffer{}
+			fs := cfg.buildInitialFields(errSink)
+
+			core, logs := observer.New(DebugLevel)
+			New(core, Fields(fs...)).Info("hello")
+			require.Equal(t, 1, logs.Len())
+			assert.Equal(t, tt.want, logs.All()[0].ContextMap(), "Unexpected fields.")
+			assert.Len(t, fs, len(tt.want), "Expected no duplicate keys.")
+
+			if tt.wantErr == "" {
+				assert.Empty(t, errSink.String(), "Unexpected internal errors.")
+			} else {
+				assert.Contains(t, errSink.String(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/toujourser/zap/zapcore"
+)
+
+// _panicKey is the key of the field holding a recovered panic value.
+const _panicKey = "panic"
+
+// RecoverAndLog recovers from a panic and logs the panic value with a stack
+// trace. It must be deferred directly:
+//
+//	defer zap.RecoverAndLog(logger, "worker crashed", false)
+//
+// The entry is logged at ErrorLevel, or at DPanicLevel if the logger is in
+// development mode. Its caller and stack trace start at the function that
+// panicked, skipping RecoverAndLog and the runtime's panic handling. The
+// panic value is logged under the "panic" key: errors as Error fields,
+// strings as String fields, and other values with Any.
+//
+// If repanic is true, RecoverAndLog panics again with the original value
+// after logging it. Otherwise, execution continues after the deferred call,
+// except in development mode, where the DPanic entry panics as usual.
+func RecoverAndLog(logger *Logger, msg string, repanic bool) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	logger.logPanic(msg, r, repanic)
+	if repanic {
+		panic(r)
+	}
+}
+
+// RecoverHandler wraps an http.Handler so that panics while serving a
+// request are logged as they are by RecoverAndLog, with the request's
+// method and URL. Unless repanic is true, the client then gets an
+// Internal Server Error response.
+//
+// Panics with http.ErrAbortHandler, which abort the response on purpose,
+// aren't logged and always propagate to the server.
+func RecoverHandler(logger *Logger, msg string, repanic bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			logger.With(String("method", r.Method), String("url", r.URL.String())).logPanic(msg, v, repanic)
+			if repanic {
+				panic(v)
+			}
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logPanic logs a recovered panic value. It must be called directly by the
+// deferred function that recovered it.
+func (log *Logger) logPanic(msg string, r interface{}, repanic bool) {
+	lvl := ErrorLevel
+	if log.development {
+		lvl = DPanicLevel
+	}
+
+	// Logger.check skips logPanic; also skip the deferred function and the
+	// panic handling above it.
+	log = log.WithOptions(AddCallerSkip(1+panicFrames()), AddStacktrace(lvl))
+	ce := log.check(lvl, msg)
+	if ce == nil {
+		return
+	}
+	if repanic {
+		// Our caller panics with the original value instead.
+		ce = ce.After(ce.Entry, zapcore.WriteThenNoop)
+	}
+	ce.Write(panicField(r))
+}
+
+// panicFrames returns the number of frames between the deferred function
+// that called logPanic and the function that panicked. These are the
+// runtime's panic handling and, for deferred calls with arguments, the
+// wrapper the compiler generates.
+func panicFrames() int {
+	var pcs [32]uintptr
+	// Skip runtime.Callers, panicFrames, logPanic, and the deferred
+	// function.
+	n := runtime.Callers(4, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var count int
+	var inRuntime bool
+	for {
+		frame, more := frames.Next()
+		isRuntime := strings.HasPrefix(frame.Function, "runtime.")
+		if inRuntime && !isRuntime {
+			return count
+		}
+		inRuntime = inRuntime || isRuntime
+		count++
+		if !more {
+			// Not called during a panic, so start at the caller.
+			return 0
+		}
+	}
+}
+
+// panicField builds the field for a recovered panic value.
+func panicField(r interface{}) Field {
+	switch v := r.(type) {
+	case error:
+		return NamedError(_panicKey, v)
+	case string:
+		return String(_panicKey, v)
+	default:
+		return Any(_panicKey, v)
+	}
+}
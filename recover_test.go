@@ -0,0 +1,214 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toujourser/zap/zaptest/observer"
+)
+
+type panicStringer struct{}
+
+func (panicStringer) String() string { return "stringer" }
+
+//go:noinline
+func panicWith(v interface{}) {
+	panic(v)
+}
+
+//go:noinline
+func panicNilPointer() {
+	var p *int
+	_ = *p
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	err := errors.New("great sadness")
+	tests := []struct {
+		desc      string
+		give      func()
+		wantField Field
+		wantFunc  string
+	}{
+		{
+			desc:      "string",
+			give:      func() { panicWith("oh no") },
+			wantField: String("panic", "oh no"),
+			wantFunc:  "panicWith",
+		},
+		{
+			desc:      "error",
+			give:      func() { panicWith(err) },
+			wantField: NamedError("panic", err),
+			wantFunc:  "panicWith",
+		},
+		{
+			desc:      "other",
+			give:      func() { panicWith(panicStringer{}) },
+			wantField: Any("panic", panicStringer{}),
+			wantFunc:  "panicWith",
+		},
+		{
+			desc:     "runtime error",
+			give:     panicNilPointer,
+			wantFunc: "panicNilPointer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			withLogger(t, DebugLevel, opts(AddCaller()), func(logger *Logger, logs *observer.ObservedLogs) {
+				assert.NotPanics(t, func() {
+					defer RecoverAndLog(logger, "recovered", false)
+					tt.give()
+				}, "Expected the panic to be recovered.")
+
+				entries := logs.AllUntimed()
+				require.Len(t, entries, 1, "Expected the panic to be logged.")
+				ent := entries[0]
+				assert.Equal(t, ErrorLevel, ent.Level, "Unexpected level.")
+				assert.Equal(t, "recovered", ent.Message, "Unexpected message.")
+				require.Len(t, ent.Context, 1, "Expected a field for the panic value.")
+				if tt.wantField.Key != "" {
+					assert.Equal(t, tt.wantField, ent.Context[0], "Unexpected panic field.")
+				} else {
+					_, ok := ent.Context[0].Interface.(error)
+					assert.True(t, ok, "Expected the runtime error to be logged as an error.")
+				}
+
+				assert.True(t, strings.HasSuffix(ent.Caller.Function, "."+tt.wantFunc),
+					"Expected the caller to be the function that panicked, got %q.", ent.Caller.Function)
+				assert.True(t, strings.HasPrefix(ent.Stack, "github.com/toujourser/zap."+tt.wantFunc+"\n"),
+					"Expected the stack trace to start at the function that panicked, got:\n%s", ent.Stack)
+				assert.NotContains(t, ent.Stack, "zap.RecoverAndLog\n", "Expected the stack trace to skip the recovery frames.")
+			})
+		})
+	}
+}
+
+func TestRecoverAndLogRepanic(t *testing.T) {
+	withLogger(t, DebugLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+		err := errors.New("great sadness")
+		assert.PanicsWithError(t, "great sadness", func() {
+			defer RecoverAndLog(logger, "recovered", true)
+			panicWith(err)
+		}, "Expected the original value to be re-panicked.")
+		assert.Equal(t, 1, logs.Len(), "Expected the panic to be logged.")
+	})
+}
+
+func TestRecoverAndLogDevelopment(t *testing.T) {
+	withLogger(t, DebugLevel, opts(Development()), func(logger *Logger, logs *observer.ObservedLogs) {
+		assert.PanicsWithValue(t, "recovered", func() {
+			defer RecoverAndLog(logger, "recovered", false)
+			panicWith("oh no")
+		}, "Expected DPanic to panic in development.")
+
+		assert.PanicsWithValue(t, "oh no", func() {
+			defer RecoverAndLog(logger, "recovered", true)
+			panicWith("oh no")
+		}, "Expected the original value to be re-panicked.")
+
+		entries := logs.AllUntimed()
+		require.Len(t, entries, 2, "Expected both panics to be logged.")
+		for _, ent := range entries {
+			assert.Equal(t, DPanicLevel, ent.Level, "Expected DPanic in development.")
+		}
+	})
+}
+
+func TestRecoverAndLogWithoutPanic(t *testing.T) {
+	withLogger(t, DebugLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+		func() {
+			defer RecoverAndLog(logger, "recovered", true)
+		}()
+		assert.Zero(t, logs.Len(), "Expected nothing to be logged without a panic.")
+	})
+}
+
+func TestRecoverHandler(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/abort":
+			panic(http.ErrAbortHandler)
+		case "/panic":
+			panicWith("oh no")
+		}
+	})
+
+	t.Run("recover", func(t *testing.T) {
+		withLogger(t, DebugLevel, opts(AddCaller()), func(logger *Logger, logs *observer.ObservedLogs) {
+			rec := httptest.NewRecorder()
+			RecoverHandler(logger, "handler panicked", false, handler).
+				ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic?q=1", nil))
+
+			assert.Equal(t, http.StatusInternalServerError, rec.Code, "Unexpected status code.")
+			entries := logs.AllUntimed()
+			require.Len(t, entries, 1, "Expected the panic to be logged.")
+			assert.Equal(t, "handler panicked", entries[0].Message, "Unexpected message.")
+			assert.Equal(t, []Field{
+				String("method", "GET"),
+				String("url", "/panic?q=1"),
+				String("panic", "oh no"),
+			}, entries[0].Context, "Unexpected fields.")
+			assert.True(t, strings.HasSuffix(entries[0].Caller.Function, ".panicWith"),
+				"Expected the caller to be the function that panicked, got %q.", entries[0].Caller.Function)
+		})
+	})
+
+	t.Run("repanic", func(t *testing.T) {
+		withLogger(t, DebugLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+			h := RecoverHandler(logger, "handler panicked", true, handler)
+			assert.PanicsWithValue(t, "oh no", func() {
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+			}, "Expected the original value to be re-panicked.")
+			assert.Equal(t, 1, logs.Len(), "Expected the panic to be logged.")
+		})
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		withLogger(t, DebugLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+			h := RecoverHandler(logger, "handler panicked", false, handler)
+			assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+			}, "Expected ErrAbortHandler to propagate.")
+			assert.Zero(t, logs.Len(), "Expected ErrAbortHandler not to be logged.")
+		})
+	})
+
+	t.Run("no panic", func(t *testing.T) {
+		withLogger(t, DebugLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+			rec := httptest.NewRecorder()
+			RecoverHandler(logger, "handler panicked", false, handler).
+				ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, http.StatusOK, rec.Code, "Unexpected status code.")
+			assert.Zero(t, logs.Len(), "Expected nothing to be logged.")
+		})
+	})
+}
@@ -0,0 +1,475 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toujourser/zap/zapcore"
+	"go.uber.org/multierr"
+)
+
+const (
+	schemeRotate = "rotate"
+
+	_defaultRotateMaxSizeMB = 100
+	_rotateBackupTimeFormat = "2006-01-02T15-04-05.000000000"
+	_compressSuffix         = ".gz"
+	_megabyte               = 1024 * 1024
+)
+
+// rotateOptions configures a rotatingFile. See newRotatingFileSinkFromURL for
+// the URL parameters that map onto these options.
+type rotateOptions struct {
+	// MaxSize is the maximum size in bytes of the log file before it gets
+	// rotated.
+	MaxSize int64
+	// MaxBackups is the maximum number of rotated files to retain. Zero
+	// retains all of them.
+	MaxBackups int
+	// MaxAge is the maximum age of rotated files, based on the timestamp in
+	// their names. Zero disables age-based cleanup.
+	MaxAge time.Duration
+	// Compress gzips rotated files in the background.
+	Compress bool
+}
+
+// rotatingFile is a Sink that writes to a file and rotates it once it
+// reaches a configured size. Rotated files are renamed to include a timestamp
+// (app-2006-01-02T15-04-05.000000000.log for app.log), and are cleaned up
+// and optionally compressed by a background goroutine.
+//
+// A rotatingFile never splits a single Write across two files, so as long
+// as callers write whole log entries (as zapcore.Core implementations do),
+// no entry is ever split by a rotation.
+type rotatingFile struct {
+	filename string
+	opts     rotateOptions
+	openFile func(string, int, os.FileMode) (*os.File, error) // type matches os.OpenFile
+	clock    zapcore.Clock
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	closed bool
+
+	mill millRunner
+}
+
+var _ Sink = (*rotatingFile)(nil)
+
+func newRotatingFile(
+	filename string,
+	opts rotateOptions,
+	openFile func(string, int, os.FileMode) (*os.File, error),
+	clock zapcore.Clock,
+) (*rotatingFile, error) {
+	if opts.MaxSize <= 0 {
+		return nil, fmt.Errorf("rotation size must be positive: got %d", opts.MaxSize)
+	}
+	if opts.MaxBackups < 0 {
+		return nil, fmt.Errorf("maximum number of backups must not be negative: got %d", opts.MaxBackups)
+	}
+	if opts.MaxAge < 0 {
+		return nil, fmt.Errorf("maximum backup age must not be negative: got %v", opts.MaxAge)
+	}
+
+	rf := &rotatingFile{
+		filename: filename,
+		opts:     opts,
+		openFile: openFile,
+		clock:    clock,
+	}
+	if err := rf.openExisting(); err != nil {
+		return nil, err
+	}
+	// Clean up anything left behind by a previous process.
+	rf.triggerMill()
+	return rf, nil
+}
+
+func (sr *sinkRegistry) newRotatingFileSinkFromURL(u *url.URL) (Sink, error) {
+	if u.User != nil {
+		return nil, fmt.Errorf("user and password not allowed with rotate URLs: got %v", u)
+	}
+	if u.Fragment != "" {
+		return nil, fmt.Errorf("fragments not allowed with rotate URLs: got %v", u)
+	}
+	if u.Port() != "" {
+		return nil, fmt.Errorf("ports not allowed with rotate URLs: got %v", u)
+	}
+	if hn := u.Hostname(); hn != "" && hn != "localhost" {
+		return nil, fmt.Errorf("rotate URLs must leave host empty or use localhost: got %v", u)
+	}
+	if u.Path == "" {
+		return nil, fmt.Errorf("rotate URLs must include a file path: got %v", u)
+	}
+
+	opts, err := parseRotateOptions(u.Query())
+	if err != nil {
+		return nil, fmt.Errorf("invalid rotate URL %v: %w", u, err)
+	}
+	return newRotatingFile(u.Path, opts, sr.openFile, zapcore.DefaultClock)
+}
+
+func parseRotateOptions(q url.Values) (rotateOptions, error) {
+	opts := rotateOptions{MaxSize: _defaultRotateMaxSizeMB * _megabyte}
+	for key, vals := range q {
+		if len(vals) != 1 {
+			return opts, fmt.Errorf("parameter %q must be specified exactly once", key)
+		}
+		val := vals[0]
+
+		var err error
+		switch key {
+		case "maxSizeMB":
+			var mb int64
+			mb, err = strconv.ParseInt(val, 10, 64)
+			opts.MaxSize = mb * _megabyte
+		case "maxBackups":
+			opts.MaxBackups, err = strconv.Atoi(val)
+		case "maxAgeDays":
+			var days int
+			days, err = strconv.Atoi(val)
+			opts.MaxAge = time.Duration(days) * 24 * time.Hour
+		case "compress":
+			opts.Compress, err = strconv.ParseBool(val)
+		default:
+			return opts, fmt.Errorf("unknown parameter %q", key)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("can't parse %q parameter: %w", key, err)
+		}
+	}
+	return opts, nil
+}
+
+// Write writes p to the current file, first rotating it if p would push the
+// file past its maximum size. A single write is never split across files,
+// so writes larger than the maximum size are written whole to a fresh file.
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed {
+		return 0, errors.New("write to closed rotating file")
+	}
+	if rf.file == nil {
+		// An earlier rotation couldn't reopen the file; try again.
+		if err := rf.openExisting(); err != nil {
+			return 0, fmt.Errorf("can't reopen %q: %w", rf.filename, err)
+		}
+	}
+	if rf.size > 0 && rf.size+int64(len(p)) > rf.opts.MaxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Sync flushes the current file to stable storage.
+func (rf *rotatingFile) Sync() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed || rf.file == nil {
+		return nil
+	}
+	return rf.file.Sync()
+}
+
+// Close closes the current file and stops the background cleanup goroutine,
+// waiting for any in-progress cleanup to finish.
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	if rf.closed {
+		rf.mu.Unlock()
+		return nil
+	}
+	rf.closed = true
+	var err error
+	if rf.file != nil {
+		err = rf.file.Close()
+	}
+	rf.mu.Unlock()
+
+	rf.mill.Wait()
+	return err
+}
+
+// openExisting opens the log file for appending, picking up the size of any
+// existing contents.
+func (rf *rotatingFile) openExisting() error {
+	if err := os.MkdirAll(filepath.Dir(rf.filename), 0o755); err != nil {
+		return fmt.Errorf("can't create directory for %q: %w", rf.filename, err)
+	}
+	f, err := rf.openFile(rf.filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o666)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	rf.file = f
+	rf.size = info.Size()
+	return nil
+}
+
+// rotate closes the current file, moves it aside, and opens a new one. It
+// must be called with rf.mu held.
+//
+// The rename is atomic, so readers never observe a partially rotated file.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return fmt.Errorf("can't close %q for rotation: %w", rf.filename, err)
+	}
+	if err := os.Rename(rf.filename, rf.backupName(rf.clock.Now())); err != nil {
+		err = fmt.Errorf("can't rotate %q: %w", rf.filename, err)
+		// Keep writing to the current file; the next write that
+		// overflows it tries to rotate again.
+		if rerr := rf.openExisting(); rerr != nil {
+			rf.file = nil
+			err = multierr.Append(err, fmt.Errorf("can't reopen %q: %w", rf.filename, rerr))
+		}
+		return err
+	}
+
+	f, err := rf.openFile(rf.filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0o666)
+	if err != nil {
+		// The old file is closed and moved aside, so there's nothing left to
+		// write to. The next Write tries to open the file again.
+		rf.file = nil
+		rf.triggerMill()
+		return fmt.Errorf("can't reopen %q after rotation: %w", rf.filename, err)
+	}
+	rf.file = f
+	rf.size = 0
+	rf.triggerMill()
+	return nil
+}
+
+func (rf *rotatingFile) prefixAndExt() (prefix, ext string) {
+	base := filepath.Base(rf.filename)
+	ext = filepath.Ext(base)
+	return base[:len(base)-len(ext)] + "-", ext
+}
+
+func (rf *rotatingFile) backupName(t time.Time) string {
+	prefix, ext := rf.prefixAndExt()
+	return filepath.Join(
+		filepath.Dir(rf.filename),
+		prefix+t.UTC().Format(_rotateBackupTimeFormat)+ext,
+	)
+}
+
+// triggerMill asks the background goroutine to clean up backups.
+func (rf *rotatingFile) triggerMill() {
+	rf.mill.Trigger(rf.millBackups)
+}
+
+// millRunner runs cleanup work on a background goroutine. The goroutine
+// only runs while there's work to do, so that sinks which are never closed
+// don't leak it.
+type millRunner struct {
+	mu      sync.Mutex
+	running bool
+	pending bool
+	wg      sync.WaitGroup
+}
+
+// Trigger runs fn in the background, starting the goroutine if necessary.
+// Requests coalesce, so this never blocks: if fn is already running, it
+// runs once more after it finishes.
+func (m *millRunner) Trigger(fn func() error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running {
+		m.pending = true
+		return
+	}
+	m.running = true
+	m.wg.Add(1)
+	go m.run(fn)
+}
+
+func (m *millRunner) run(fn func() error) {
+	defer m.wg.Done()
+	for {
+		// Errors can't be reported anywhere useful from here, and they'll be
+		// retried on the next trigger.
+		_ = fn()
+
+		m.mu.Lock()
+		if !m.pending {
+			m.running = false
+			m.mu.Unlock()
+			return
+		}
+		m.pending = false
+		m.mu.Unlock()
+	}
+}
+
+// Wait blocks until any in-progress work finishes.
+func (m *millRunner) Wait() {
+	m.wg.Wait()
+}
+
+type rotatedFile struct {
+	name       string
+	timestamp  time.Time
+	compressed bool
+}
+
+// millBackups removes backups beyond MaxBackups or older than MaxAge, and
+// compresses the remaining backups if requested.
+func (rf *rotatingFile) millBackups() error {
+	backups, err := rf.listBackups()
+	if err != nil {
+		return err
+	}
+
+	var remove, keep []rotatedFile
+	cutoff := rf.clock.Now().Add(-rf.opts.MaxAge)
+	for i, b := range backups {
+		switch {
+		case rf.opts.MaxBackups > 0 && i >= rf.opts.MaxBackups:
+			remove = append(remove, b)
+		case rf.opts.MaxAge > 0 && b.timestamp.Before(cutoff):
+			remove = append(remove, b)
+		default:
+			keep = append(keep, b)
+		}
+	}
+
+	var errs []error
+	dir := filepath.Dir(rf.filename)
+	for _, b := range remove {
+		if err := os.Remove(filepath.Join(dir, b.name)); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	if rf.opts.Compress {
+		for _, b := range keep {
+			if b.compressed {
+				continue
+			}
+			if err := compressFile(filepath.Join(dir, b.name)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return multierr.Combine(errs...)
+}
+
+// listBackups returns the rotated files for this sink, newest first.
+func (rf *rotatingFile) listBackups() ([]rotatedFile, error) {
+	entries, err := os.ReadDir(filepath.Dir(rf.filename))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, ext := rf.prefixAndExt()
+	var backups []rotatedFile
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		ts := strings.TrimPrefix(name, prefix)
+		if ts == name {
+			continue
+		}
+		compressed := strings.HasSuffix(ts, ext+_compressSuffix)
+		if compressed {
+			ts = strings.TrimSuffix(ts, ext+_compressSuffix)
+		} else if ts = strings.TrimSuffix(ts, ext); ts+ext != strings.TrimPrefix(name, prefix) {
+			continue
+		}
+		t, err := time.Parse(_rotateBackupTimeFormat, ts)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, rotatedFile{name: name, timestamp: t, compressed: compressed})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].timestamp.After(backups[j].timestamp)
+	})
+	return backups, nil
+}
+
+// compressFile gzips src into src.gz and removes src. The compressed file is
+// written under a temporary name and renamed into place so that a crash
+// never leaves a truncated archive behind.
+func compressFile(src string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	dst := src + _compressSuffix
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = out.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
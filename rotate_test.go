@@ -0,0 +1,371 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toujourser/zap/zapcore"
+)
+
+func TestRotateURLParsing(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.ToSlash(filepath.Join(dir, "app.log"))
+
+	tests := []struct {
+		desc    string
+		rawURL  string
+		want    rotateOptions
+		wantErr string
+	}{
+		{
+			desc:   "defaults",
+			rawURL: "rotate://" + path,
+			want:   rotateOptions{MaxSize: 100 * _megabyte},
+		},
+		{
+			desc:   "all parameters",
+			rawURL: "rotate://" + path + "?maxSizeMB=5&maxBackups=3&maxAgeDays=7&compress=true",
+			want: rotateOptions{
+				MaxSize:    5 * _megabyte,
+				MaxBackups: 3,
+				MaxAge:     7 * 24 * time.Hour,
+				Compress:   true,
+			},
+		},
+		{
+			desc:    "unknown parameter",
+			rawURL:  "rotate://" + path + "?maxSize=5",
+			wantErr: `unknown parameter "maxSize"`,
+		},
+		{
+			desc:    "malformed number",
+			rawURL:  "rotate://" + path + "?maxBackups=many",
+			wantErr: `can't parse "maxBackups" parameter`,
+		},
+		{
+			desc:    "non-positive size",
+			rawURL:  "rotate://" + path + "?maxSizeMB=0",
+			wantErr: "rotation size must be positive",
+		},
+		{
+			desc:    "host",
+			rawURL:  "rotate://example.com" + path,
+			wantErr: "must leave host empty",
+		},
+		{
+			desc:    "user",
+			rawURL:  "rotate://user@localhost" + path,
+			wantErr: "user and password not allowed",
+		},
+		{
+			desc:    "no path",
+			rawURL:  "rotate://",
+			wantErr: "must include a file path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			u, err := url.Parse(tt.rawURL)
+			require.NoError(t, err)
+
+			sink, err := newSinkRegistry().newRotatingFileSinkFromURL(u)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer func() { assert.NoError(t, sink.Close()) }()
+			assert.Equal(t, tt.want, sink.(*rotatingFile).opts, "Unexpected options.")
+		})
+	}
+}
+
+func TestRotateOpenFromOutputPaths(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	cfg := NewProductionConfig()
+	cfg.OutputPaths = []string{"rotate://" + filepath.ToSlash(path) + "?maxSizeMB=1"}
+	logger, err := cfg.Build()
+	require.NoError(t, err, "Unexpected error building logger with a rotate URL.")
+
+	logger.Info("hello")
+	require.NoError(t, logger.Sync())
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), `"msg":"hello"`)
+}
+
+// steppingClock advances by one second every time it's read so that
+// backups get distinct, ordered names.
+type steppingClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *steppingClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+func (c *steppingClock) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(d)
+}
+
+func newTestRotatingFile(t *testing.T, dir string, opts rotateOptions) *rotatingFile {
+	clock := &steppingClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	rf, err := newRotatingFile(filepath.Join(dir, "app.log"), opts, os.OpenFile, clock)
+	require.NoError(t, err)
+	return rf
+}
+
+func TestRotateOnSize(t *testing.T) {
+	dir := t.TempDir()
+	rf := newTestRotatingFile(t, dir, rotateOptions{MaxSize: 10})
+
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "this line is too long\n", "dddd\n"} {
+		n, err := rf.Write([]byte(line))
+		require.NoError(t, err)
+		assert.Equal(t, len(line), n)
+	}
+	require.NoError(t, rf.Sync())
+	require.NoError(t, rf.Close())
+
+	assert.Equal(t, []string{
+		"aaaa\nbbbb\n",
+		"cccc\n",
+		"this line is too long\n",
+		"dddd\n",
+	}, readRotatedFiles(t, dir), "Unexpected file contents after rotation.")
+}
+
+func TestRotateRenameFailure(t *testing.T) {
+	dir := t.TempDir()
+	rf := newTestRotatingFile(t, dir, rotateOptions{MaxSize: 10})
+
+	_, err := rf.Write([]byte("aaaa\n"))
+	require.NoError(t, err)
+
+	// The first rotation reads the clock once, one second after its start.
+	// A non-empty directory with the backup's name makes the rename fail.
+	blocker := rf.backupName(time.Date(2023, 1, 1, 0, 0, 1, 0, time.UTC))
+	require.NoError(t, os.MkdirAll(filepath.Join(blocker, "x"), 0o755))
+
+	_, err = rf.Write([]byte("bbbbbbbb\n"))
+	require.ErrorContains(t, err, "can't rotate", "Expected the rename to fail.")
+
+	_, err = rf.Write([]byte("cc\n"))
+	require.NoError(t, err, "Expected the current file to be reopened.")
+
+	require.NoError(t, os.RemoveAll(blocker))
+	_, err = rf.Write([]byte("dddddddd\n"))
+	require.NoError(t, err, "Expected the next rotation to succeed.")
+	require.NoError(t, rf.Close())
+
+	assert.Equal(t, []string{"aaaa\ncc\n", "dddddddd\n"}, readRotatedFiles(t, dir),
+		"Unexpected file contents after a failed rotation.")
+}
+
+func TestRotateReopenFailure(t *testing.T) {
+	dir := t.TempDir()
+	var fail bool
+	openFile := func(name string, flag int, perm os.FileMode) (*os.File, error) {
+		if fail {
+			return nil, errors.New("can't open")
+		}
+		return os.OpenFile(name, flag, perm)
+	}
+	clock := &steppingClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	rf, err := newRotatingFile(filepath.Join(dir, "app.log"), rotateOptions{MaxSize: 10}, openFile, clock)
+	require.NoError(t, err)
+
+	_, err = rf.Write([]byte("aaaa\n"))
+	require.NoError(t, err)
+
+	// The rename succeeds, but the new file can't be opened.
+	fail = true
+	_, err = rf.Write([]byte("bbbbbbbb\n"))
+	require.ErrorContains(t, err, "can't reopen", "Expected the new file to fail to open.")
+	assert.NoError(t, rf.Sync(), "Expected Sync to have nothing to do without a file.")
+
+	_, err = rf.Write([]byte("cc\n"))
+	require.ErrorContains(t, err, "can't reopen", "Expected writes to fail while the file can't be opened.")
+
+	fail = false
+	_, err = rf.Write([]byte("cc\n"))
+	require.NoError(t, err, "Expected the file to be reopened by the next write.")
+	require.NoError(t, rf.Close())
+
+	assert.Equal(t, []string{"aaaa\n", "cc\n"}, readRotatedFiles(t, dir),
+		"Unexpected file contents after a failed reopen.")
+}
+
+func TestRotateCleanup(t *testing.T) {
+	t.Run("max backups", func(t *testing.T) {
+		dir := t.TempDir()
+		rf := newTestRotatingFile(t, dir, rotateOptions{MaxSize: 5, MaxBackups: 2})
+		for i := 0; i < 6; i++ {
+			_, err := fmt.Fprintf(rf, "%04d\n", i)
+			require.NoError(t, err)
+		}
+		require.NoError(t, rf.Close())
+
+		assert.Equal(t, []string{"0003\n", "0004\n", "0005\n"}, readRotatedFiles(t, dir),
+			"Expected only the newest backups to be retained.")
+	})
+
+	t.Run("max age", func(t *testing.T) {
+		dir := t.TempDir()
+		stale := filepath.Join(dir, "app-"+time.Now().Add(-48*time.Hour).UTC().Format(_rotateBackupTimeFormat)+".log")
+		require.NoError(t, os.WriteFile(stale, []byte("old\n"), 0o666))
+		unrelated := filepath.Join(dir, "app-notatimestamp.log")
+		require.NoError(t, os.WriteFile(unrelated, []byte("keep\n"), 0o666))
+
+		rf, err := newRotatingFile(filepath.Join(dir, "app.log"), rotateOptions{MaxSize: 100, MaxAge: 24 * time.Hour}, os.OpenFile, zapcore.DefaultClock)
+		require.NoError(t, err)
+		require.NoError(t, rf.Close())
+
+		assert.NoFileExists(t, stale, "Expected stale backup to be removed.")
+		assert.FileExists(t, unrelated, "Files that don't look like backups must be left alone.")
+	})
+
+	t.Run("compress", func(t *testing.T) {
+		dir := t.TempDir()
+		rf := newTestRotatingFile(t, dir, rotateOptions{MaxSize: 5, Compress: true})
+		for i := 0; i < 3; i++ {
+			_, err := fmt.Fprintf(rf, "%04d\n", i)
+			require.NoError(t, err)
+		}
+		require.NoError(t, rf.Close())
+
+		matches, err := filepath.Glob(filepath.Join(dir, "app-*.log"+_compressSuffix))
+		require.NoError(t, err)
+		assert.Len(t, matches, 2, "Expected both backups to be compressed.")
+		assert.Equal(t, []string{"0000\n", "0001\n", "0002\n"}, readRotatedFiles(t, dir))
+	})
+}
+
+func TestRotateConcurrentWritesDontSplitLines(t *testing.T) {
+	const (
+		goroutines = 8
+		perRoutine = 500
+	)
+
+	dir := t.TempDir()
+	rf := newTestRotatingFile(t, dir, rotateOptions{MaxSize: 4096})
+
+	cores := make([]zapcore.Core, 2)
+	for i := range cores {
+		// Multiple cores sharing one sink, as with a tee.
+		cores[i] = zapcore.NewCore(zapcore.NewJSONEncoder(NewProductionEncoderConfig()), zapcore.Lock(rf), DebugLevel)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			logger := New(cores[g%len(cores)])
+			for i := 0; i < perRoutine; i++ {
+				logger.Info("message", Int("goroutine", g), Int("i", i), String("pad", strings.Repeat("x", i%50)))
+			}
+		}(g)
+	}
+	wg.Wait()
+	require.NoError(t, rf.Close())
+
+	backups, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, backups, "Expected at least one rotation.")
+
+	seen := make(map[[2]int]bool)
+	for _, contents := range readRotatedFiles(t, dir) {
+		scanner := bufio.NewScanner(strings.NewReader(contents))
+		for scanner.Scan() {
+			var entry struct {
+				Goroutine int `json:"goroutine"`
+				I         int `json:"i"`
+			}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry), "Found a split or corrupt line: %q", scanner.Text())
+			key := [2]int{entry.Goroutine, entry.I}
+			assert.False(t, seen[key], "Duplicate entry %v", key)
+			seen[key] = true
+		}
+	}
+	assert.Len(t, seen, goroutines*perRoutine, "Some entries were lost during rotation.")
+}
+
+// readRotatedFiles returns the contents of the backups in dir, oldest first,
+// followed by the contents of the current file.
+func readRotatedFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "app-") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names) // timestamps sort lexicographically
+	names = append(names, "app.log")
+
+	contents := make([]string, 0, len(names))
+	for _, name := range names {
+		f, err := os.Open(filepath.Join(dir, name))
+		require.NoError(t, err)
+
+		var r io.Reader = f
+		if strings.HasSuffix(name, _compressSuffix) {
+			gz, err := gzip.NewReader(f)
+			require.NoError(t, err)
+			r = gz
+		}
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		if len(b) > 0 {
+			contents = append(contents, string(b))
+		}
+	}
+	return contents
+}
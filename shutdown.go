@@ -0,0 +1,201 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// _shutdownRegistry holds the loggers synced by SyncAll and HandleSignals.
+var _shutdownRegistry shutdownRegistry
+
+type shutdownRegistry struct {
+	mu      sync.Mutex
+	loggers []*Logger // in registration order
+}
+
+func (r *shutdownRegistry) register(log *Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, l := range r.loggers {
+		if l == log {
+			return
+		}
+	}
+	r.loggers = append(r.loggers, log)
+}
+
+func (r *shutdownRegistry) unregister(log *Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, l := range r.loggers {
+		if l == log {
+			copy(r.loggers[i:], r.loggers[i+1:])
+			r.loggers[len(r.loggers)-1] = nil // don't hold on to the logger
+			r.loggers = r.loggers[:len(r.loggers)-1]
+			return
+		}
+	}
+}
+
+// snapshot returns the registered loggers, so that they can be synced
+// without holding the lock.
+func (r *shutdownRegistry) snapshot() []*Logger {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*Logger(nil), r.loggers...)
+}
+
+// RegisterOnShutdown registers a Logger to be synced by SyncAll and
+// HandleSignals, and returns a function that unregisters it. Registering a
+// Logger twice has no effect.
+//
+// The registry holds on to the Logger, and through it, to its outputs, until
+// it's unregistered. Unregister loggers that are discarded before the
+// program exits.
+func RegisterOnShutdown(logger *Logger) func() {
+	if logger == nil {
+		return func() {}
+	}
+	_shutdownRegistry.register(logger)
+	return func() { UnregisterOnShutdown(logger) }
+}
+
+// UnregisterOnShutdown removes a Logger registered with RegisterOnShutdown or
+// RegisterForSync. Unregistering a Logger that isn't registered has no
+// effect.
+func UnregisterOnShutdown(logger *Logger) {
+	_shutdownRegistry.unregister(logger)
+}
+
+// RegisterForSync registers the Logger with RegisterOnShutdown, so that
+// libraries that build their own loggers can have them synced when the
+// program shuts down.
+//
+// Only the Logger that the option is applied to is registered. Loggers
+// derived from it with With or Named share its outputs, so syncing it syncs
+// them too.
+func RegisterForSync() Option {
+	return optionFunc(func(log *Logger) {
+		RegisterOnShutdown(log)
+	})
+}
+
+// SyncAll syncs every Logger registered with RegisterOnShutdown or
+// RegisterForSync, in the order they were registered. It syncs them all even
+// if some fail, and the error combines the errors of every Logger that
+// failed.
+func SyncAll() error {
+	var errs error
+	for _, log := range _shutdownRegistry.snapshot() {
+		if err := log.Sync(); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("can't sync logger %q: %w", log.Name(), err))
+		}
+	}
+	return errs
+}
+
+// HandleSignals syncs the loggers registered with RegisterOnShutdown or
+// RegisterForSync when the process receives one of the given signals, or
+// when ctx is canceled, whichever comes first. It returns a function that
+// stops watching for them without syncing.
+//
+// Since catching a signal stops it from terminating the program, the signal
+// is sent again once the loggers are synced, with HandleSignals no longer
+// listening, so that the program exits as it would have. If the signal
+// can't be sent again, as with os.Interrupt on Windows, the program exits
+// with status 1. Don't pass signals that the program handles itself, since
+// its handlers would see them twice; have HandleSignals watch the context
+// that those handlers cancel instead, or call SyncAll from them.
+//
+// Failures to sync are reported once per Logger, as Logger.Sync reports them
+// to an ErrorHandler, or as plain text on the Logger's ErrorOutput if it has
+// no ErrorHandler. Failures of sinks that can't be synced, such as terminals
+// and pipes, aren't reported.
+func HandleSignals(ctx context.Context, signals ...os.Signal) (stop func()) {
+	var sigs chan os.Signal
+	if len(signals) > 0 {
+		sigs = make(chan os.Signal, 1)
+		signal.Notify(sigs, signals...)
+	}
+	return handleSignals(ctx, sigs, _raiseSignal)
+}
+
+// handleSignals implements HandleSignals, calling raise with the signal it
+// received, if any, after syncing the loggers.
+func handleSignals(ctx context.Context, sigs chan os.Signal, raise func(os.Signal)) (stop func()) {
+	stopc := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		select {
+		case <-stopc:
+		case <-ctx.Done():
+			syncOnShutdown()
+		case sig := <-sigs:
+			signal.Stop(sigs)
+			syncOnShutdown()
+			raise(sig)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if sigs != nil {
+				signal.Stop(sigs)
+			}
+			close(stopc)
+			<-done
+		})
+	}
+}
+
+// syncOnShutdown syncs the registered loggers, reporting failures through
+// their ErrorHandlers or ErrorOutputs.
+func syncOnShutdown() {
+	for _, log := range _shutdownRegistry.snapshot() {
+		if err := log.core.Sync(); err != nil && !isIgnorableSyncError(err) {
+			log.reportSyncError(err)
+		}
+	}
+}
+
+// _raiseSignal sends sig to the current process. Tests replace it.
+var _raiseSignal = func(sig os.Signal) {
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(sig)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
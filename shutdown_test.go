@@ -0,0 +1,166 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toujourser/zap/internal/ztest"
+	"github.com/toujourser/zap/zapcore"
+)
+
+// newSyncTestLogger builds a Logger whose output records calls to Sync and
+// fails them with err, if it's non-nil.
+func newSyncTestLogger(name string, err error, opts ...Option) (*Logger, *ztest.Discarder) {
+	ws := &ztest.Discarder{}
+	ws.SetError(err)
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(NewProductionEncoderConfig()), ws, DebugLevel)
+	return New(core, opts...).Named(name), ws
+}
+
+func TestSyncAll(t *testing.T) {
+	errFailed := errors.New("failed")
+	a, aws := newSyncTestLogger("a", nil)
+	b, bws := newSyncTestLogger("b", errFailed)
+	c, cws := newSyncTestLogger("c", nil)
+
+	defer RegisterOnShutdown(a)()
+	defer RegisterOnShutdown(b)()
+	unregisterC := RegisterOnShutdown(c)
+	RegisterOnShutdown(c) // no effect
+	unregisterC()
+	UnregisterOnShutdown(c) // no effect
+
+	err := SyncAll()
+	assert.ErrorIs(t, err, errFailed, "Expected the failed logger's error.")
+	assert.EqualError(t, err, `can't sync logger "b": failed`, "Expected the error to name the logger.")
+	assert.True(t, aws.Called(), "Expected the registered logger to be synced.")
+	assert.True(t, bws.Called(), "Expected the failing logger to be synced.")
+	assert.False(t, cws.Called(), "Expected the unregistered logger not to be synced.")
+}
+
+func TestSyncAllCombinesErrors(t *testing.T) {
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	a, _ := newSyncTestLogger("a", errA)
+	b, _ := newSyncTestLogger("b", errB)
+	defer RegisterOnShutdown(a)()
+	defer RegisterOnShutdown(b)()
+
+	err := SyncAll()
+	assert.ErrorIs(t, err, errA, "Expected the first logger's error.")
+	assert.ErrorIs(t, err, errB, "Expected the second logger's error.")
+}
+
+func TestRegisterForSync(t *testing.T) {
+	ws := &ztest.Discarder{}
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(NewProductionEncoderConfig()), ws, DebugLevel)
+	log := New(core, RegisterForSync())
+	defer UnregisterOnShutdown(log)
+
+	assert.Contains(t, _shutdownRegistry.snapshot(), log, "Expected the logger to be registered.")
+	require.NoError(t, SyncAll())
+	assert.True(t, ws.Called(), "Expected the logger to be synced.")
+
+	UnregisterOnShutdown(log)
+	assert.NotContains(t, _shutdownRegistry.snapshot(), log, "Expected the logger to be unregistered.")
+}
+
+func TestRegisterOnShutdownNil(t *testing.T) {
+	unregister := RegisterOnShutdown(nil)
+	assert.Empty(t, _shutdownRegistry.snapshot(), "Expected nil loggers to be ignored.")
+	unregister()
+}
+
+func TestHandleSignals(t *testing.T) {
+	errFailed := errors.New("failed")
+	errOut := &ztest.Buffer{}
+	log, ws := newSyncTestLogger("app", errFailed, ErrorOutput(errOut))
+	defer RegisterOnShutdown(log)()
+
+	t.Run("signal", func(t *testing.T) {
+		sigs := make(chan os.Signal, 1)
+		raised := make(chan os.Signal, 1)
+		stop := handleSignals(context.Background(), sigs, func(sig os.Signal) { raised <- sig })
+		defer stop()
+
+		sigs <- syscall.SIGTERM
+		select {
+		case sig := <-raised:
+			assert.Equal(t, syscall.SIGTERM, sig, "Expected the signal to be raised again.")
+		case <-time.After(time.Second):
+			t.Fatal("Expected the signal to be raised again.")
+		}
+		assert.True(t, ws.Called(), "Expected the logger to be synced before the signal was raised.")
+		assert.Equal(t, 1, strings.Count(errOut.String(), "sync error: failed"), "Expected the failure to be reported once.")
+	})
+
+	t.Run("context", func(t *testing.T) {
+		ws.SetError(nil)
+		errOut.Reset()
+		ctx, cancel := context.WithCancel(context.Background())
+		raise := func(os.Signal) { t.Error("Unexpected signal.") }
+		stop := handleSignals(ctx, nil, raise)
+
+		cancel()
+		stop() // waits for the handler to finish
+		assert.True(t, ws.Called(), "Expected the logger to be synced.")
+		assert.Empty(t, errOut.String(), "Unexpected errors.")
+	})
+}
+
+func TestSyncOnShutdownReportsOnce(t *testing.T) {
+	errFailed := errors.New("failed")
+	errOut := &ztest.Buffer{}
+	var handled []error
+	log, _ := newSyncTestLogger("app", errFailed, ErrorOutput(errOut), ErrorHandler(func(err error, _ zapcore.Entry) {
+		handled = append(handled, err)
+	}))
+	defer RegisterOnShutdown(log)()
+
+	syncOnShutdown()
+	require.Len(t, handled, 1, "Expected the handler to be called once.")
+	assert.ErrorIs(t, handled[0], errFailed)
+	assert.Empty(t, errOut.String(), "Expected nothing on the error output.")
+}
+
+func TestHandleSignalsStop(t *testing.T) {
+	log, ws := newSyncTestLogger("app", nil)
+	defer RegisterOnShutdown(log)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := HandleSignals(ctx, os.Interrupt)
+	stop()
+	stop() // no effect
+	cancel()
+
+	assert.False(t, ws.Called(), "Expected stopping not to sync.")
+}
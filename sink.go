@@ -55,6 +55,26 @@ type nopCloserSink struct{ zapcore.WriteSyncer }
 
 func (nopCloserSink) Close() error { return nil }
 
+// IsTerminal reports whether the sink writes to a terminal, so that console
+// encoders using zapcore.ColorAuto can see through the wrapper around
+// os.Stdout and os.Stderr.
+func (s nopCloserSink) IsTerminal() bool {
+	f, ok := s.WriteSyncer.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// File returns the underlying *os.File, if any, so that zapcore can tell
+// ignorable Sync errors from standard output and standard error apart from
+// real failures.
+func (s nopCloserSink) File() *os.File {
+	f, _ := s.WriteSyncer.(*os.File)
+	return f
+}
+
 type sinkRegistry struct {
 	mu        sync.Mutex
 	factories map[string]func(*url.URL) (Sink, error)          // keyed by scheme
@@ -66,8 +86,20 @@ func newSinkRegistry() *sinkRegistry {
 		factories: make(map[string]func(*url.URL) (Sink, error)),
 		openFile:  os.OpenFile,
 	}
-	// Infallible operation: the registry is empty, so we can't have a conflict.
+	// Infallible operations: the registry is empty, so we can't have a conflict.
 	_ = sr.RegisterSink(schemeFile, sr.newFileSinkFromURL)
+	_ = sr.RegisterSink(schemeRotate, sr.newRotatingFileSinkFromURL)
+	_ = sr.RegisterSink(schemeTimedFile, sr.newTimedFileSinkFromURL)
+	_ = sr.RegisterSink(schemeGzipFile, sr.newGzipFileSinkFromURL)
+	_ = sr.RegisterSink(schemeSyslog, newSyslogSinkFromURL)
+	_ = sr.RegisterSink(schemeGELFUDP, newGELFSinkFromURL)
+	_ = sr.RegisterSink(schemeJournald, newJournaldSinkFromURL)
+	for _, scheme := range _netSchemes {
+		_ = sr.RegisterSink(scheme, newNetSinkFromURL)
+	}
+	for _, scheme := range _httpPostSchemes {
+		_ = sr.RegisterSink(scheme, newHTTPSinkFromURL)
+	}
 	return sr
 }
 
@@ -98,10 +130,34 @@ func (sr *sinkRegistry) newSink(rawURL string) (Sink, error) {
 	if filepath.IsAbs(rawURL) {
 		return sr.newFileSinkFromPath(rawURL)
 	}
+	u, factory, err := sr.lookupSink(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return factory(u)
+}
+
+// checkSink reports the error that newSink would return for rawURL before
+// calling a factory: an unparsable URL or an unregistered scheme. It never
+// opens the sink.
+func (sr *sinkRegistry) checkSink(rawURL string) error {
+	if filepath.IsAbs(rawURL) {
+		return nil
+	}
+	_, _, err := sr.lookupSink(rawURL)
+	return err
+}
+
+// lookupSink parses rawURL and returns it along with the factory registered
+// for its scheme.
+func (sr *sinkRegistry) lookupSink(rawURL string) (*url.URL, func(*url.URL) (Sink, error), error) {
+	if strings.HasPrefix(strings.ToLower(rawURL), schemeTimedFile+":") {
+		rawURL = escapeTimedFileURL(rawURL)
+	}
 
 	u, err := url.Parse(rawURL)
 	if err != nil {
-		return nil, fmt.Errorf("can't parse %q as a URL: %v", rawURL, err)
+		return nil, nil, fmt.Errorf("can't parse %q as a URL: %v", rawURL, err)
 	}
 	if u.Scheme == "" {
 		u.Scheme = schemeFile
@@ -111,9 +167,9 @@ func (sr *sinkRegistry) newSink(rawURL string) (Sink, error) {
 	factory, ok := sr.factories[u.Scheme]
 	sr.mu.Unlock()
 	if !ok {
-		return nil, &errSinkNotFound{u.Scheme}
+		return nil, nil, &errSinkNotFound{u.Scheme}
 	}
-	return factory(u)
+	return u, factory, nil
 }
 
 // RegisterSink registers a user-supplied factory for all sinks with a
@@ -121,8 +177,10 @@ func (sr *sinkRegistry) newSink(rawURL string) (Sink, error) {
 //
 // All schemes must be ASCII, valid under section 0.1 of RFC 3986
 // (https://tools.ietf.org/html/rfc3983#section-3.1), and must not already
-// have a factory registered. Zap automatically registers a factory for the
-// "file" scheme.
+// have a factory registered. Zap automatically registers factories for the
+// "file", "rotate", "timedfile", "gzipfile", "syslog", "gelf+udp",
+// "journald", "tcp", "udp", "unix", "unixgram", "httppost+http", and
+// "httppost+https" schemes.
 func RegisterSink(scheme string, factory func(*url.URL) (Sink, error)) error {
 	return _sinkRegistry.RegisterSink(scheme, factory)
 }
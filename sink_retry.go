@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// sinkBackoff spaces out the attempts of the network sinks to reach an
+// unavailable destination. The delay starts at min and doubles after each
+// attempt, up to max.
+type sinkBackoff struct {
+	min, max time.Duration
+	delay    time.Duration // zero before the first wait
+}
+
+// wait sleeps for the next delay, with jitter, returning false if done is
+// closed first.
+func (b *sinkBackoff) wait(done <-chan struct{}) bool {
+	if b.delay == 0 {
+		b.delay = b.min
+	} else if b.delay *= 2; b.delay > b.max {
+		b.delay = b.max
+	}
+
+	t := time.NewTimer(jitter(b.delay))
+	defer t.Stop()
+
+	select {
+	case <-done:
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// jitter returns a random duration between d/2 and d, so that many sinks
+// that lose their destination at once don't retry in lockstep.
+func jitter(d time.Duration) time.Duration {
+	half := int64(d / 2)
+	if half <= 0 {
+		return d
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// outageError describes an outage of the sink described by sink, wrapping
+// lastErr, the most recent error reaching its destination, if any.
+func outageError(sink, msg string, lastErr error) error {
+	if lastErr == nil {
+		return fmt.Errorf("%s: %s", sink, msg)
+	}
+	return fmt.Errorf("%s: %s: %w", sink, msg, lastErr)
+}
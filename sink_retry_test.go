@@ -0,0 +1,55 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSinkBackoff(t *testing.T) {
+	b := sinkBackoff{min: time.Microsecond, max: 4 * time.Microsecond}
+	var delays []time.Duration
+	for i := 0; i < 5; i++ {
+		assert.True(t, b.wait(nil), "Expected wait to finish.")
+		delays = append(delays, b.delay)
+	}
+	assert.Equal(t, []time.Duration{
+		time.Microsecond, 2 * time.Microsecond, 4 * time.Microsecond, 4 * time.Microsecond, 4 * time.Microsecond,
+	}, delays, "Expected delays to double up to the maximum.")
+
+	done := make(chan struct{})
+	close(done)
+	b = sinkBackoff{min: time.Hour, max: time.Hour}
+	assert.False(t, b.wait(done), "Expected wait to stop when done is closed.")
+}
+
+func TestOutageError(t *testing.T) {
+	assert.EqualError(t, outageError("tcp sink", "dropping writes", nil), "tcp sink: dropping writes")
+
+	lastErr := errors.New("connection refused")
+	err := outageError("tcp sink", "dropping writes", lastErr)
+	assert.EqualError(t, err, "tcp sink: dropping writes: connection refused")
+	assert.ErrorIs(t, err, lastErr, "Expected the last error to be wrapped.")
+}
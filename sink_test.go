@@ -22,8 +22,10 @@ package zap
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"net/url"
+	"os"
 	"strings"
 	"testing"
 
@@ -105,3 +107,24 @@ func TestRegisterSinkErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestStandardSinkSyncErrorsIgnorable(t *testing.T) {
+	for _, path := range []string{"stdout", "stderr"} {
+		t.Run(path, func(t *testing.T) {
+			sink, closer, err := Open(path)
+			require.NoError(t, err, "Failed to open %s.", path)
+			defer closer()
+
+			// Depending on how the test binary's output is redirected,
+			// syncing may succeed, but failures must be ignorable.
+			if err := sink.Sync(); err != nil {
+				var serr *zapcore.SyncError
+				require.True(t, errors.As(err, &serr), "Expected a *zapcore.SyncError, got %T.", err)
+				assert.True(t, serr.Ignorable(), "Expected Sync errors from %s to be ignorable.", path)
+			}
+		})
+	}
+
+	assert.Equal(t, os.Stdout, nopCloserSink{os.Stdout}.File(), "Expected to expose the wrapped file.")
+	assert.Nil(t, nopCloserSink{zapcore.AddSync(&bytes.Buffer{})}.File(), "Expected no file for other sinks.")
+}
@@ -32,6 +32,7 @@ import (
 
 	"github.com/toujourser/zap"
 	"github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zaptest/observer"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -143,6 +144,105 @@ func TestStacktraceWithCallerSkip(t *testing.T) {
 	})
 }
 
+func TestStacktraceDepth(t *testing.T) {
+	tests := []struct {
+		desc      string
+		opts      []zap.Option
+		wantFirst string
+		wantCount int // zero to only check that the stack is deeper than 50
+	}{
+		{
+			desc:      "unlimited",
+			wantFirst: "TestStacktraceDepth.func",
+		},
+		{
+			desc:      "depth",
+			opts:      []zap.Option{zap.StacktraceDepth(3)},
+			wantFirst: "TestStacktraceDepth.func",
+			wantCount: 3,
+		},
+		{
+			desc:      "depth of one",
+			opts:      []zap.Option{zap.StacktraceDepth(1)},
+			wantFirst: "TestStacktraceDepth.func",
+			wantCount: 1,
+		},
+		{
+			desc:      "skip",
+			opts:      []zap.Option{zap.StacktraceSkip(2)},
+			wantFirst: "zap_test.recurseStack",
+		},
+		{
+			desc:      "skip and depth",
+			opts:      []zap.Option{zap.StacktraceSkip(2), zap.StacktraceDepth(4)},
+			wantFirst: "zap_test.recurseStack",
+			wantCount: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			opts := append([]zap.Option{zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel)}, tt.opts...)
+			logger := zap.New(core, opts...)
+
+			recurseStack(50, func() {
+				logger.Error("test log")
+			})
+
+			entries := logs.TakeAll()
+			require.Len(t, entries, 1, "Expected exactly one entry.")
+			assert.Contains(t, entries[0].Caller.Function, "TestStacktraceDepth.func",
+				"Stack trace options must not change the caller.")
+
+			frames := stackFrames(entries[0].Stack)
+			require.NotEmpty(t, frames, "Expected a stack trace.")
+			assert.Contains(t, frames[0], tt.wantFirst, "Unexpected first frame.")
+			if tt.wantCount > 0 {
+				assert.Len(t, frames, tt.wantCount, "Unexpected number of frames.")
+			} else {
+				assert.Greater(t, len(frames), 50, "Expected the full stack.")
+			}
+			assert.NotContains(t, frames[len(frames)-1], "runtime.goexit", "Unexpected runtime frame.")
+		})
+	}
+}
+
+func TestStacktraceDepthShallowStack(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	full := zap.New(core, zap.AddStacktrace(zap.ErrorLevel))
+	limited := full.WithOptions(zap.StacktraceDepth(1000))
+
+	full.Error("full")
+	limited.Error("limited")
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2, "Expected two entries.")
+	assert.Equal(t,
+		stackFrames(entries[0].Stack), stackFrames(entries[1].Stack),
+		"A depth limit larger than the stack must not change the stack trace.")
+}
+
+// recurseStack calls f at the bottom of n nested calls.
+func recurseStack(n int, f func()) {
+	if n == 0 {
+		f()
+		return
+	}
+	recurseStack(n-1, f)
+}
+
+// stackFrames returns the function names in a formatted stack trace.
+func stackFrames(stack string) []string {
+	var frames []string
+	for _, line := range strings.Split(stack, "\n") {
+		if line != "" && !strings.HasPrefix(line, "\t") {
+			frames = append(frames, line)
+		}
+	}
+	return frames
+}
+
 // withLogger sets up a logger with a real encoder set up, so that any marshal functions are called.
 // The inbuilt observer does not call Marshal for objects/arrays, which we need for some tests.
 func withLogger(t *testing.T, fn func(logger *zap.Logger, out *bytes.Buffer)) {
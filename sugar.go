@@ -21,7 +21,9 @@
 package zap
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/toujourser/zap/zapcore"
 
@@ -52,17 +54,28 @@ const (
 //	Infow(...any)          Structured logging (read as "info with")
 //	Infof(string, ...any)  Printf-style logging
 //	Infoln(...any)         Println-style logging
+//
+// Like fmt.Errorf, the methods ending in "f" accept the %w verb: the message
+// is formatted as fmt.Errorf would format it, and the wrapped errors are
+// added to the entry as an "error" field, or as an array if there are several.
 type SugaredLogger struct {
 	base *Logger
 }
 
+// _sugarCallerSkip is the number of frames the SugaredLogger adds between the
+// caller and the Logger's Check: the exported method, such as Infow, and the
+// log or logln helper it calls. Sugar adds it to the Logger's caller skip and
+// Desugar removes it, so callers compose with AddCallerSkip and
+// WithCallerSkip as they do on the Logger.
+const _sugarCallerSkip = 2
+
 // Desugar unwraps a SugaredLogger, exposing the original Logger. Desugaring
 // is quite inexpensive, so it's reasonable for a single application to use
 // both Loggers and SugaredLoggers, converting between them on the boundaries
 // of performance-sensitive code.
 func (s *SugaredLogger) Desugar() *Logger {
 	base := s.base.clone()
-	base.callerSkip -= 2
+	base.callerSkip -= _sugarCallerSkip
 	return base
 }
 
@@ -71,6 +84,15 @@ func (s *SugaredLogger) Named(name string) *SugaredLogger {
 	return &SugaredLogger{base: s.base.Named(name)}
 }
 
+// WithCallerSkip returns a copy of the SugaredLogger that skips delta more
+// callers when annotating entries. See Logger.WithCallerSkip for details.
+func (s *SugaredLogger) WithCallerSkip(delta int) *SugaredLogger {
+	if delta == 0 {
+		return s
+	}
+	return &SugaredLogger{base: s.base.WithCallerSkip(delta)}
+}
+
 // WithOptions clones the current SugaredLogger, applies the supplied Options,
 // and returns the result. It's safe to use concurrently.
 func (s *SugaredLogger) WithOptions(opts ...Option) *SugaredLogger {
@@ -112,7 +134,7 @@ func (s *SugaredLogger) WithOptions(opts ...Option) *SugaredLogger {
 // and execution continues. Passing an orphaned key triggers similar behavior:
 // panics in development and errors in production.
 func (s *SugaredLogger) With(args ...interface{}) *SugaredLogger {
-	return &SugaredLogger{base: s.base.With(s.sweetenFields(args)...)}
+	return &SugaredLogger{base: s.base.With(s.sweetenFields(args, 0)...)}
 }
 
 // WithLazy adds a variadic number of fields to the logging context lazily.
@@ -127,7 +149,18 @@ func (s *SugaredLogger) With(args ...interface{}) *SugaredLogger {
 // passing a non-string key panics, while in production it logs an error and skips the pair.
 // Passing an orphaned key has the same behavior.
 func (s *SugaredLogger) WithLazy(args ...interface{}) *SugaredLogger {
-	return &SugaredLogger{base: s.base.WithLazy(s.sweetenFields(args)...)}
+	return &SugaredLogger{base: s.base.WithLazy(s.sweetenFields(args, 0)...)}
+}
+
+// Ctx returns a child logger with the fields extracted from ctx by the
+// functions registered with the ContextFields option. See Logger.Ctx for
+// details.
+func (s *SugaredLogger) Ctx(ctx context.Context) *SugaredLogger {
+	base := s.base.Ctx(ctx)
+	if base == s.base {
+		return s
+	}
+	return &SugaredLogger{base: base}
 }
 
 // Level reports the minimum enabled level for this logger.
@@ -139,10 +172,17 @@ func (s *SugaredLogger) Level() zapcore.Level {
 
 // Log logs the provided arguments at provided level.
 // Spaces are added between arguments when neither is a string.
+// See [Logger.Log] for the handling of invalid levels.
 func (s *SugaredLogger) Log(lvl zapcore.Level, args ...interface{}) {
 	s.log(lvl, "", args, nil)
 }
 
+// Trace logs the provided arguments at [TraceLevel].
+// Spaces are added between arguments when neither is a string.
+func (s *SugaredLogger) Trace(args ...interface{}) {
+	s.log(TraceLevel, "", args, nil)
+}
+
 // Debug logs the provided arguments at [DebugLevel].
 // Spaces are added between arguments when neither is a string.
 func (s *SugaredLogger) Debug(args ...interface{}) {
@@ -192,6 +232,12 @@ func (s *SugaredLogger) Logf(lvl zapcore.Level, template string, args ...interfa
 	s.log(lvl, template, args, nil)
 }
 
+// Tracef formats the message according to the format specifier
+// and logs it at [TraceLevel].
+func (s *SugaredLogger) Tracef(template string, args ...interface{}) {
+	s.log(TraceLevel, template, args, nil)
+}
+
 // Debugf formats the message according to the format specifier
 // and logs it at [DebugLevel].
 func (s *SugaredLogger) Debugf(template string, args ...interface{}) {
@@ -241,6 +287,12 @@ func (s *SugaredLogger) Logw(lvl zapcore.Level, msg string, keysAndValues ...int
 	s.log(lvl, msg, nil, keysAndValues)
 }
 
+// Tracew logs a message with some additional context. The variadic key-value
+// pairs are treated as they are in With.
+func (s *SugaredLogger) Tracew(msg string, keysAndValues ...interface{}) {
+	s.log(TraceLevel, msg, nil, keysAndValues)
+}
+
 // Debugw logs a message with some additional context. The variadic key-value
 // pairs are treated as they are in With.
 //
@@ -294,6 +346,12 @@ func (s *SugaredLogger) Logln(lvl zapcore.Level, args ...interface{}) {
 	s.logln(lvl, args, nil)
 }
 
+// Traceln logs a message at [TraceLevel].
+// Spaces are always added between arguments.
+func (s *SugaredLogger) Traceln(args ...interface{}) {
+	s.logln(TraceLevel, args, nil)
+}
+
 // Debugln logs a message at [DebugLevel].
 // Spaces are always added between arguments.
 func (s *SugaredLogger) Debugln(args ...interface{}) {
@@ -344,30 +402,51 @@ func (s *SugaredLogger) Sync() error {
 
 // log message with Sprint, Sprintf, or neither.
 func (s *SugaredLogger) log(lvl zapcore.Level, template string, fmtArgs []interface{}, context []interface{}) {
-	// If logging at this level is completely disabled, skip the overhead of
-	// string formatting.
-	if lvl < DPanicLevel && !s.base.Core().Enabled(lvl) {
+	if s.disabled(lvl) {
 		return
 	}
 
+	var wrapped []error
+	if len(fmtArgs) > 0 {
+		template, wrapped = rewriteWrapVerbs(template, fmtArgs)
+	}
 	msg := getMessage(template, fmtArgs)
 	if ce := s.base.Check(lvl, msg); ce != nil {
-		ce.Write(s.sweetenFields(context)...)
+		fields := s.sweetenFields(context, 1)
+		switch len(wrapped) {
+		case 0:
+		case 1:
+			fields = append(fields, Error(wrapped[0]))
+		default:
+			fields = append(fields, Errors("error", wrapped))
+		}
+		ce.Write(fields...)
 	}
 }
 
 // logln message with Sprintln
 func (s *SugaredLogger) logln(lvl zapcore.Level, fmtArgs []interface{}, context []interface{}) {
-	if lvl < DPanicLevel && !s.base.Core().Enabled(lvl) {
+	if s.disabled(lvl) {
 		return
 	}
 
 	msg := getMessageln(fmtArgs)
 	if ce := s.base.Check(lvl, msg); ce != nil {
-		ce.Write(s.sweetenFields(context)...)
+		ce.Write(s.sweetenFields(context, 1)...)
 	}
 }
 
+// disabled reports whether logging at lvl is completely disabled, so that
+// log and logln can skip the overhead of formatting the message and
+// sweetening the fields. Check can't be consulted first because the entry it
+// takes includes the message, which samplers and other filters inspect.
+//
+// Invalid levels are reported by Check, and entries at DPanicLevel and above
+// must reach Check even when disabled so that they still panic or exit.
+func (s *SugaredLogger) disabled(lvl zapcore.Level) bool {
+	return lvl < DPanicLevel && isValidLevel(lvl) && !s.base.Core().Enabled(lvl)
+}
+
 // getMessage format with Sprint, Sprintf, or neither.
 func getMessage(template string, fmtArgs []interface{}) string {
 	if len(fmtArgs) == 0 {
@@ -386,13 +465,104 @@ func getMessage(template string, fmtArgs []interface{}) string {
 	return fmt.Sprint(fmtArgs...)
 }
 
+// rewriteWrapVerbs replaces the %w verbs in template whose operands are
+// non-nil errors with %v, as fmt.Errorf does, returning the new template and
+// the wrapped errors. Other %w verbs are left for Sprintf to report as bad
+// verbs, which is also what fmt.Errorf does.
+func rewriteWrapVerbs(template string, args []interface{}) (string, []error) {
+	if strings.IndexByte(template, 'w') < 0 {
+		return template, nil
+	}
+
+	var (
+		rewritten []byte
+		errs      []error
+		argNum    int
+	)
+	for i := 0; i < len(template); i++ {
+		if template[i] != '%' {
+			continue
+		}
+		i++
+		for i < len(template) && strings.IndexByte("+-# 0", template[i]) >= 0 {
+			i++
+		}
+		i, argNum = skipArgIndex(template, i, argNum)
+		i, argNum = skipWidth(template, i, argNum)
+		if i < len(template) && template[i] == '.' {
+			i, argNum = skipArgIndex(template, i+1, argNum)
+			i, argNum = skipWidth(template, i, argNum)
+		}
+		i, argNum = skipArgIndex(template, i, argNum)
+		if i >= len(template) {
+			break
+		}
+		if template[i] == '%' {
+			continue
+		}
+		if template[i] == 'w' && argNum < len(args) {
+			if err, ok := args[argNum].(error); ok && err != nil {
+				if rewritten == nil {
+					rewritten = []byte(template)
+				}
+				rewritten[i] = 'v'
+				errs = append(errs, err)
+			}
+		}
+		argNum++
+	}
+	if rewritten == nil {
+		return template, nil
+	}
+	return string(rewritten), errs
+}
+
+// skipArgIndex skips an explicit argument index such as [2] at template[i],
+// returning the position after it and the operand it selects.
+func skipArgIndex(template string, i, argNum int) (int, int) {
+	if i >= len(template) || template[i] != '[' {
+		return i, argNum
+	}
+	end := strings.IndexByte(template[i:], ']')
+	if end < 0 {
+		return i, argNum
+	}
+	n := 0
+	for _, c := range template[i+1 : i+end] {
+		if c < '0' || c > '9' {
+			return i, argNum
+		}
+		n = n*10 + int(c-'0')
+	}
+	if n < 1 {
+		return i, argNum
+	}
+	return i + end + 1, n - 1
+}
+
+// skipWidth skips a width or precision at template[i], returning the
+// position after it and the next operand. A * consumes an operand.
+func skipWidth(template string, i, argNum int) (int, int) {
+	if i < len(template) && template[i] == '*' {
+		return i + 1, argNum + 1
+	}
+	for i < len(template) && '0' <= template[i] && template[i] <= '9' {
+		i++
+	}
+	return i, argNum
+}
+
 // getMessageln format with Sprintln.
 func getMessageln(fmtArgs []interface{}) string {
 	msg := fmt.Sprintln(fmtArgs...)
 	return msg[:len(msg)-1]
 }
 
-func (s *SugaredLogger) sweetenFields(args []interface{}) []Field {
+// sweetenFields converts loosely-typed key-value pairs to Fields, logging
+// errors for any that are invalid. depth is the number of frames between
+// sweetenFields and the exported method that called it, so that the errors
+// report the user's call site.
+func (s *SugaredLogger) sweetenFields(args []interface{}, depth int) []Field {
 	if len(args) == 0 {
 		return nil
 	}
@@ -419,7 +589,7 @@ func (s *SugaredLogger) sweetenFields(args []interface{}) []Field {
 				seenError = true
 				fields = append(fields, Error(err))
 			} else {
-				s.base.Error(_multipleErrMsg, Error(err))
+				s.logError(ErrorLevel, _multipleErrMsg, depth, Error(err))
 			}
 			i++
 			continue
@@ -427,7 +597,7 @@ func (s *SugaredLogger) sweetenFields(args []interface{}) []Field {
 
 		// Make sure this element isn't a dangling key.
 		if i == len(args)-1 {
-			s.base.Error(_oddNumberErrMsg, Any("ignored", args[i]))
+			s.invalidArgs(_oddNumberErrMsg, args, depth, Any("ignored", args[i]))
 			break
 		}
 
@@ -448,11 +618,33 @@ func (s *SugaredLogger) sweetenFields(args []interface{}) []Field {
 
 	// If we encountered any invalid key-value pairs, log an error.
 	if len(invalid) > 0 {
-		s.base.Error(_nonStringKeyErrMsg, Array("invalid", invalid))
+		s.invalidArgs(_nonStringKeyErrMsg, args, depth, Array("invalid", invalid))
 	}
 	return fields
 }
 
+// invalidArgs reports misused key-value pairs. In strict mode, they're
+// reported at DPanicLevel along with all the arguments.
+func (s *SugaredLogger) invalidArgs(msg string, args []interface{}, depth int, fields ...Field) {
+	// Skip invalidArgs itself, too.
+	if !s.base.strictSugar {
+		s.logError(ErrorLevel, msg, depth+1, fields...)
+		return
+	}
+	s.logError(DPanicLevel, msg, depth+1, append(fields, Any("keysAndValues", args))...)
+}
+
+// logError logs an error about the arguments passed to the SugaredLogger.
+// depth is the number of frames between sweetenFields and the exported
+// method the user called, plus any between logError and sweetenFields.
+func (s *SugaredLogger) logError(lvl zapcore.Level, msg string, depth int, fields ...Field) {
+	// The Logger's _sugarCallerSkip covers logError and the exported
+	// method; skip sweetenFields and the frames in between, too.
+	if ce := s.base.CheckSkip(lvl, msg, depth+1); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
 type invalidPair struct {
 	position   int
 	key, value interface{}
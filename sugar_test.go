@@ -23,6 +23,7 @@ package zap
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"testing"
 
@@ -287,6 +288,67 @@ func TestSugarFieldsInvalidPairs(t *testing.T) {
 	})
 }
 
+func TestSugarStrictInvalidPairs(t *testing.T) {
+	tests := []struct {
+		desc       string
+		args       []interface{}
+		wantMsg    string
+		wantFields map[string]interface{}
+	}{
+		{
+			desc:    "odd number of arguments",
+			args:    []interface{}{"foo", 1, "dangling"},
+			wantMsg: _oddNumberErrMsg,
+			wantFields: map[string]interface{}{
+				"ignored":       "dangling",
+				"keysAndValues": []interface{}{"foo", 1, "dangling"},
+			},
+		},
+		{
+			desc:    "non-string key",
+			args:    []interface{}{42, "foo", "bar", 1},
+			wantMsg: _nonStringKeyErrMsg,
+			wantFields: map[string]interface{}{
+				"invalid": []interface{}{
+					map[string]interface{}{"position": int64(0), "key": int64(42), "value": "foo"},
+				},
+				"keysAndValues": []interface{}{42, "foo", "bar", 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			withSugar(t, DebugLevel, []Option{WithStrictSugar()}, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+				logger.Infow("msg", tt.args...)
+
+				output := logs.AllUntimed()
+				require.Len(t, output, 2, "Unexpected number of entries logged.")
+				assert.Equal(t, DPanicLevel, output[0].Level, "Expected misuse to be reported at DPanicLevel.")
+				assert.Equal(t, tt.wantMsg, output[0].Message, "Unexpected error message.")
+				assert.Equal(t, tt.wantFields, output[0].ContextMap(), "Unexpected error fields.")
+				assert.Equal(t, "msg", output[1].Message, "Expected the original message to be logged.")
+			})
+		})
+	}
+}
+
+func TestSugarStrictDevelopmentPanics(t *testing.T) {
+	opts := []Option{WithStrictSugar(), Development()}
+	withSugar(t, DebugLevel, opts, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+		assert.Panics(t, func() { logger.Logw(InfoLevel, "msg", "dangling") }, "Expected odd arguments to panic.")
+		assert.Panics(t, func() { logger.With(42, "foo") }, "Expected non-string keys to panic.")
+		assert.NotPanics(t, func() { logger.Infow("msg", "foo", "bar") }, "Expected valid pairs not to panic.")
+	})
+
+	// Without strict mode, misuse is only logged as an error.
+	withSugar(t, DebugLevel, []Option{Development()}, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+		assert.NotPanics(t, func() { logger.Logw(InfoLevel, "msg", "dangling") }, "Unexpected panic.")
+		require.Equal(t, 2, logs.Len(), "Unexpected number of entries logged.")
+		assert.Equal(t, ErrorLevel, logs.All()[0].Level, "Expected misuse to be logged at ErrorLevel.")
+	})
+}
+
 func TestSugarStructuredLogging(t *testing.T) {
 	tests := []struct {
 		msg       string
@@ -305,7 +367,8 @@ func TestSugarStructuredLogging(t *testing.T) {
 	)
 
 	for _, tt := range tests {
-		withSugar(t, DebugLevel, nil, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+		withSugar(t, TraceLevel, nil, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+			logger.With(context...).Tracew(tt.msg, extra...)
 			logger.With(context...).Debugw(tt.msg, extra...)
 			logger.With(context...).Infow(tt.msg, extra...)
 			logger.With(context...).Warnw(tt.msg, extra...)
@@ -313,8 +376,8 @@ func TestSugarStructuredLogging(t *testing.T) {
 			logger.With(context...).DPanicw(tt.msg, extra...)
 			logger.With(context...).Logw(WarnLevel, tt.msg, extra...)
 
-			expected := make([]observer.LoggedEntry, 6)
-			for i, lvl := range []zapcore.Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, WarnLevel} {
+			expected := make([]observer.LoggedEntry, 7)
+			for i, lvl := range []zapcore.Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, WarnLevel} {
 				expected[i] = observer.LoggedEntry{
 					Entry:   zapcore.Entry{Message: tt.expectMsg, Level: lvl},
 					Context: expectedFields,
@@ -338,7 +401,8 @@ func TestSugarConcatenatingLogging(t *testing.T) {
 	expectedFields := []Field{String("foo", "bar")}
 
 	for _, tt := range tests {
-		withSugar(t, DebugLevel, nil, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+		withSugar(t, TraceLevel, nil, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+			logger.With(context...).Trace(tt.args...)
 			logger.With(context...).Debug(tt.args...)
 			logger.With(context...).Info(tt.args...)
 			logger.With(context...).Warn(tt.args...)
@@ -346,8 +410,8 @@ func TestSugarConcatenatingLogging(t *testing.T) {
 			logger.With(context...).DPanic(tt.args...)
 			logger.With(context...).Log(InfoLevel, tt.args...)
 
-			expected := make([]observer.LoggedEntry, 6)
-			for i, lvl := range []zapcore.Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, InfoLevel} {
+			expected := make([]observer.LoggedEntry, 7)
+			for i, lvl := range []zapcore.Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, InfoLevel} {
 				expected[i] = observer.LoggedEntry{
 					Entry:   zapcore.Entry{Message: tt.expect, Level: lvl},
 					Context: expectedFields,
@@ -375,7 +439,8 @@ func TestSugarTemplatedLogging(t *testing.T) {
 	expectedFields := []Field{String("foo", "bar")}
 
 	for _, tt := range tests {
-		withSugar(t, DebugLevel, nil, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+		withSugar(t, TraceLevel, nil, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+			logger.With(context...).Tracef(tt.format, tt.args...)
 			logger.With(context...).Debugf(tt.format, tt.args...)
 			logger.With(context...).Infof(tt.format, tt.args...)
 			logger.With(context...).Warnf(tt.format, tt.args...)
@@ -383,8 +448,8 @@ func TestSugarTemplatedLogging(t *testing.T) {
 			logger.With(context...).DPanicf(tt.format, tt.args...)
 			logger.With(context...).Logf(ErrorLevel, tt.format, tt.args...)
 
-			expected := make([]observer.LoggedEntry, 6)
-			for i, lvl := range []zapcore.Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, ErrorLevel} {
+			expected := make([]observer.LoggedEntry, 7)
+			for i, lvl := range []zapcore.Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, ErrorLevel} {
 				expected[i] = observer.LoggedEntry{
 					Entry:   zapcore.Entry{Message: tt.expect, Level: lvl},
 					Context: expectedFields,
@@ -412,7 +477,8 @@ func TestSugarLnLogging(t *testing.T) {
 	expectedFields := []Field{String("foo", "bar")}
 
 	for _, tt := range tests {
-		withSugar(t, DebugLevel, nil, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+		withSugar(t, TraceLevel, nil, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+			logger.With(context...).Traceln(tt.args...)
 			logger.With(context...).Debugln(tt.args...)
 			logger.With(context...).Infoln(tt.args...)
 			logger.With(context...).Warnln(tt.args...)
@@ -420,8 +486,8 @@ func TestSugarLnLogging(t *testing.T) {
 			logger.With(context...).DPanicln(tt.args...)
 			logger.With(context...).Logln(InfoLevel, tt.args...)
 
-			expected := make([]observer.LoggedEntry, 6)
-			for i, lvl := range []zapcore.Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, InfoLevel} {
+			expected := make([]observer.LoggedEntry, 7)
+			for i, lvl := range []zapcore.Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, InfoLevel} {
 				expected[i] = observer.LoggedEntry{
 					Entry:   zapcore.Entry{Message: tt.expect, Level: lvl},
 					Context: expectedFields,
@@ -535,6 +601,72 @@ func TestSugarAddCaller(t *testing.T) {
 	}
 }
 
+func TestSugarLogInvalidLevel(t *testing.T) {
+	t.Run("production", func(t *testing.T) {
+		errOut := &ztest.Buffer{}
+		withSugar(t, DebugLevel, opts(ErrorOutput(errOut)), func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+			logger.Log(zapcore.Level(-5), "foo")
+			logger.Logf(zapcore.InvalidLevel, "%s", "bar")
+			logger.Logw(zapcore.InvalidLevel, "baz", "k", "v")
+			logger.Logln(zapcore.InvalidLevel, "qux")
+			assert.Zero(t, logs.Len(), "Expected nothing to be logged.")
+			assert.Len(t, errOut.Lines(), 4, "Expected every invalid level to be reported.")
+		})
+	})
+
+	t.Run("development", func(t *testing.T) {
+		withSugar(t, DebugLevel, opts(Development(), AddCaller()), func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+			assert.Panics(t, func() { logger.Log(zapcore.Level(-5), "foo") }, "Expected a DPanic.")
+			output := logs.AllUntimed()
+			require.Len(t, output, 1, "Expected the message to be logged.")
+			assert.Equal(t, DPanicLevel, output[0].Level, "Unexpected level.")
+			assert.Equal(t, "invalid level Level(-5): foo", output[0].Message, "Unexpected message.")
+			assert.Regexp(t, `.+/sugar_test.go:[\d]+$`, output[0].Caller, "Expected the caller of Log.")
+		})
+	})
+}
+
+// infowWrapper wraps a SugaredLogger the way adapters do.
+func infowWrapper(logger *SugaredLogger, msg string, keysAndValues ...interface{}) {
+	logger.WithCallerSkip(1).Infow(msg, keysAndValues...)
+}
+
+func TestSugarInvalidArgsCaller(t *testing.T) {
+	// The table's closures are the call sites.
+	const test = "github.com/toujourser/zap.TestSugarInvalidArgsCaller.func"
+	tests := []struct {
+		desc string
+		log  func(*SugaredLogger)
+	}{
+		{"Infow", func(logger *SugaredLogger) { logger.Infow("msg", "dangling") }},
+		{"Infow non-string key", func(logger *SugaredLogger) { logger.Infow("msg", 42, true) }},
+		{"Infow multiple errors", func(logger *SugaredLogger) {
+			logger.Infow("msg", errors.New("a"), errors.New("b"))
+		}},
+		{"With", func(logger *SugaredLogger) { logger.With("dangling") }},
+		{"WithLazy", func(logger *SugaredLogger) { logger.WithLazy("dangling") }},
+		{"wrapper", func(logger *SugaredLogger) { infowWrapper(logger, "msg", "dangling") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			for _, strict := range []bool{false, true} {
+				options := opts(AddCaller())
+				if strict {
+					options = append(options, WithStrictSugar())
+				}
+				withSugar(t, DebugLevel, options, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+					tt.log(logger)
+					errs := logs.FilterLevelExact(ErrorLevel).AllUntimed()
+					errs = append(errs, logs.FilterLevelExact(DPanicLevel).AllUntimed()...)
+					require.Len(t, errs, 1, "Expected an error about the arguments.")
+					assert.Regexp(t, `^`+regexp.QuoteMeta(test)+`\d+$`, errs[0].Caller.Function,
+						"Expected the error to report the call site (strict=%v).", strict)
+				})
+			}
+		})
+	}
+}
+
 func TestSugarAddCallerFail(t *testing.T) {
 	errBuf := &ztest.Buffer{}
 	withSugar(t, DebugLevel, opts(AddCaller(), AddCallerSkip(1e3), ErrorOutput(errBuf)), func(log *SugaredLogger, logs *observer.ObservedLogs) {
@@ -585,6 +717,129 @@ func TestSugarLnWithOptionsIncreaseLevel(t *testing.T) {
 	})
 }
 
+// countingStringer counts how many times it's formatted.
+type countingStringer struct{ n *int }
+
+func (c countingStringer) String() string {
+	*c.n++
+	return "counted"
+}
+
+func TestSugarDisabledLevelSkipsFormatting(t *testing.T) {
+	withSugar(t, InfoLevel, nil, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+		var n int
+		arg := countingStringer{&n}
+
+		logger.Debug(arg, arg)
+		logger.Debugf("%v %s", arg, arg)
+		logger.Debugln(arg)
+		logger.Debugw("msg", "k", arg)
+		logger.Logf(DebugLevel, "%v", arg)
+		assert.Zero(t, n, "Expected no formatting at a disabled level.")
+
+		logger.Infof("%v", arg)
+		assert.Equal(t, 1, n, "Expected formatting at an enabled level.")
+		assert.Equal(t, 1, logs.Len())
+	})
+}
+
+func TestSugarDisabledLevelAllocs(t *testing.T) {
+	args := tenFormatArgs()
+	withSugar(t, InfoLevel, nil, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+		for _, tt := range []struct {
+			name string
+			log  func()
+		}{
+			{"Debug", func() { logger.Debug(args...) }},
+			{"Debugf", func() { logger.Debugf(_tenArgTemplate, args...) }},
+			{"Debugln", func() { logger.Debugln(args...) }},
+			{"Debugw", func() { logger.Debugw("msg", args...) }},
+		} {
+			assert.Zero(t, testing.AllocsPerRun(100, tt.log), "Expected %s at a disabled level not to allocate.", tt.name)
+		}
+	})
+}
+
+func TestSugarFormattedMessages(t *testing.T) {
+	args := tenFormatArgs()
+	withSugar(t, DebugLevel, nil, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+		logger.Debug(args...)
+		logger.Debugf(_tenArgTemplate, args...)
+		logger.Debugln(args...)
+		logger.Debugf("%d%%", 42)
+		logger.Debug("single")
+
+		ln := fmt.Sprintln(args...)
+		var got []string
+		for _, ent := range logs.AllUntimed() {
+			got = append(got, ent.Message)
+		}
+		assert.Equal(t, []string{
+			fmt.Sprint(args...),
+			fmt.Sprintf(_tenArgTemplate, args...),
+			ln[:len(ln)-1],
+			"42%",
+			"single",
+		}, got, "Expected messages to match the fmt package's output.")
+	})
+}
+
+func TestSugarWrapVerbs(t *testing.T) {
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	tests := []struct {
+		desc     string
+		template string
+		args     []interface{}
+		fields   []Field
+	}{
+		{"no verbs", "failed to open %s", []interface{}{"foo"}, []Field{}},
+		{"one error", "failed to open %s: %w", []interface{}{"foo", errA}, []Field{Error(errA)}},
+		{"flags", "failed: %+w", []interface{}{errA}, []Field{Error(errA)}},
+		{"several errors", "%w, then %w", []interface{}{errA, errB}, []Field{Errors("error", []error{errA, errB})}},
+		{"arg index", "%[2]w after %[1]s", []interface{}{"foo", errA}, []Field{Error(errA)}},
+		{"star width", "%*d %w", []interface{}{3, 7, errA}, []Field{Error(errA)}},
+		{"escaped", "100%%w %w", []interface{}{errA}, []Field{Error(errA)}},
+		{"not an error", "got %w", []interface{}{42}, []Field{}},
+		{"nil error", "got %w", []interface{}{nil}, []Field{}},
+		{"missing operand", "%s: %w", []interface{}{"foo"}, []Field{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			withSugar(t, DebugLevel, nil, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+				logger.Errorf(tt.template, tt.args...)
+				logger.Infof(tt.template, tt.args...)
+
+				want := fmt.Errorf(tt.template, tt.args...).Error()
+				entries := logs.AllUntimed()
+				require.Len(t, entries, 2)
+				for _, ent := range entries {
+					assert.Equal(t, want, ent.Message, "Expected the message fmt.Errorf would produce.")
+					assert.Equal(t, tt.fields, ent.Context, "Unexpected wrapped error fields.")
+				}
+			})
+		})
+	}
+}
+
+const _tenArgTemplate = "%v %v %d %s %q %x %v %t %v %+v"
+
+func tenFormatArgs() []interface{} {
+	return []interface{}{"a", 1.5, 3, "s", "q", 255, errors.New("e"), true, []int{1}, struct{ A int }{1}}
+}
+
+func BenchmarkSugarDisabledDebugf(b *testing.B) {
+	args := tenFormatArgs()
+	withSugar(b, InfoLevel, nil /* opts* */, func(log *SugaredLogger, logs *observer.ObservedLogs) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			log.Debugf(_tenArgTemplate, args...)
+		}
+	})
+}
+
 func BenchmarkSugarSingleStrArg(b *testing.B) {
 	withSugar(b, InfoLevel, nil /* opts* */, func(log *SugaredLogger, logs *observer.ObservedLogs) {
 		for i := 0; i < b.N; i++ {
@@ -0,0 +1,484 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toujourser/zap/zapcore"
+)
+
+const (
+	schemeSyslog = "syslog"
+
+	_defaultSyslogPort     = "514"
+	_defaultSyslogSDID     = "zap@32473"
+	_syslogTimeFormat      = "2006-01-02T15:04:05.000000Z07:00"
+	_syslogMinBackoff      = 100 * time.Millisecond
+	_syslogMaxBackoff      = 30 * time.Second
+	_syslogMaxPending      = 1000
+	_syslogMaxSDParamName  = 32
+	_syslogMaxHostnameLen  = 255
+	_syslogMaxAppNameLen   = 48
+	_syslogMaxProcIDLen    = 128
+	_syslogDefaultSeverity = 6 // informational
+)
+
+// _syslogFacilities maps facility names to their RFC 5424 codes.
+var _syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// syslogOptions configures a syslogSink. See newSyslogSinkFromURL for the URL
+// parameters that map onto these options.
+type syslogOptions struct {
+	// Network is the network used to reach the syslog server: "udp", "tcp",
+	// "unix", or "unixgram".
+	Network string
+	// Address is the address of the syslog server.
+	Address string
+	// Facility is the RFC 5424 facility code.
+	Facility int
+	// Tag is the APP-NAME of each message.
+	Tag string
+	// Hostname is the HOSTNAME of each message.
+	Hostname string
+	// LevelKey is the key holding the level in JSON-encoded entries.
+	LevelKey string
+	// SDID is the SD-ID of the structured data element.
+	SDID string
+	// SDFields are the top-level keys of JSON-encoded entries that are
+	// copied into the structured data element.
+	SDFields []string
+}
+
+// syslogSink is a Sink that sends each written log entry to a syslog server
+// as an RFC 5424 message.
+//
+// The severity of each message, and its structured data, are read from the
+// entry if it's JSON-encoded; other encodings are sent with the
+// informational severity and no structured data.
+//
+// Messages that can't be sent are kept in memory, up to a limit, and retried
+// with exponential backoff on later writes. Sync retries immediately.
+type syslogSink struct {
+	opts  syslogOptions
+	dial  func(network, address string) (net.Conn, error)
+	clock zapcore.Clock
+	pid   string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	pending [][]byte // frames waiting to be sent, oldest first
+	dropped int      // frames dropped since the last error was reported
+	backoff time.Duration
+	retryAt time.Time
+	closed  bool
+}
+
+var _ Sink = (*syslogSink)(nil)
+
+func newSyslogSink(
+	opts syslogOptions,
+	dial func(network, address string) (net.Conn, error),
+	clock zapcore.Clock,
+) *syslogSink {
+	return &syslogSink{
+		opts:  opts,
+		dial:  dial,
+		clock: clock,
+		pid:   strconv.Itoa(os.Getpid()),
+	}
+}
+
+func newSyslogSinkFromURL(u *url.URL) (Sink, error) {
+	if u.User != nil {
+		return nil, fmt.Errorf("user and password not allowed with syslog URLs: got %v", u)
+	}
+	if u.Fragment != "" {
+		return nil, fmt.Errorf("fragments not allowed with syslog URLs: got %v", u)
+	}
+
+	opts, err := parseSyslogOptions(u.Query())
+	if err != nil {
+		return nil, fmt.Errorf("invalid syslog URL %v: %w", u, err)
+	}
+
+	switch opts.Network {
+	case "udp", "tcp":
+		if u.Host == "" {
+			return nil, fmt.Errorf("syslog URLs must include a host for network %q: got %v", opts.Network, u)
+		}
+		host, port := u.Hostname(), u.Port()
+		if port == "" {
+			port = _defaultSyslogPort
+		}
+		opts.Address = net.JoinHostPort(host, port)
+	case "unix", "unixgram":
+		if u.Path == "" {
+			return nil, fmt.Errorf("syslog URLs must include a socket path for network %q: got %v", opts.Network, u)
+		}
+		opts.Address = u.Path
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %q: got %v", opts.Network, u)
+	}
+
+	return newSyslogSink(opts, net.Dial, zapcore.DefaultClock), nil
+}
+
+func parseSyslogOptions(q url.Values) (syslogOptions, error) {
+	opts := syslogOptions{
+		Network:  "udp",
+		Facility: _syslogFacilities["user"],
+		Tag:      filepath.Base(os.Args[0]),
+		LevelKey: "level",
+		SDID:     _defaultSyslogSDID,
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		opts.Hostname = hostname
+	}
+
+	for key, vals := range q {
+		if len(vals) != 1 {
+			return opts, fmt.Errorf("parameter %q must be specified exactly once", key)
+		}
+		val := vals[0]
+
+		switch key {
+		case "network":
+			opts.Network = val
+		case "facility":
+			facility, ok := _syslogFacilities[strings.ToLower(val)]
+			if !ok {
+				return opts, fmt.Errorf("unknown facility %q", val)
+			}
+			opts.Facility = facility
+		case "tag":
+			opts.Tag = val
+		case "hostname":
+			opts.Hostname = val
+		case "levelKey":
+			opts.LevelKey = val
+		case "sdID":
+			if val == "" {
+				return opts, errors.New(`parameter "sdID" must not be empty`)
+			}
+			opts.SDID = val
+		case "sdFields":
+			opts.SDFields = strings.Split(val, ",")
+		default:
+			return opts, fmt.Errorf("unknown parameter %q", key)
+		}
+	}
+	return opts, nil
+}
+
+// Write sends each line in p as a syslog message. Lines that can't be sent
+// are retained and retried on later calls.
+func (s *syslogSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, errors.New("write to closed syslog sink")
+	}
+
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		if line = bytes.TrimRight(line, "\r"); len(line) > 0 {
+			s.enqueue(s.frame(line))
+		}
+	}
+	return len(p), s.flush(false /* force */)
+}
+
+// Sync sends any retained messages, reconnecting immediately if necessary.
+func (s *syslogSink) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	return s.flush(true /* force */)
+}
+
+// Close sends any retained messages and closes the connection.
+func (s *syslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	err := s.flush(true /* force */)
+	if s.conn != nil {
+		if cerr := s.conn.Close(); err == nil {
+			err = cerr
+		}
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *syslogSink) enqueue(frame []byte) {
+	if len(s.pending) >= _syslogMaxPending {
+		s.pending[0] = nil
+		s.pending = s.pending[1:]
+		s.dropped++
+	}
+	s.pending = append(s.pending, frame)
+}
+
+// flush sends pending frames. Unless force is set, it doesn't try to
+// reconnect before the backoff period has elapsed.
+func (s *syslogSink) flush(force bool) error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	if s.conn == nil {
+		if !force && s.clock.Now().Before(s.retryAt) {
+			return s.droppedErr()
+		}
+		conn, err := s.dial(s.opts.Network, s.opts.Address)
+		if err != nil {
+			s.scheduleRetry()
+			return s.withDropped(fmt.Errorf("connect to syslog: %w", err))
+		}
+		s.conn = conn
+	}
+
+	for len(s.pending) > 0 {
+		if err := s.send(s.pending[0]); err != nil {
+			_ = s.conn.Close()
+			s.conn = nil
+			s.scheduleRetry()
+			return s.withDropped(fmt.Errorf("write to syslog: %w", err))
+		}
+		s.pending[0] = nil
+		s.pending = s.pending[1:]
+	}
+	s.pending = nil
+	s.backoff = 0
+	return s.droppedErr()
+}
+
+func (s *syslogSink) send(frame []byte) error {
+	if s.opts.Network == "udp" || s.opts.Network == "unixgram" {
+		_, err := s.conn.Write(frame)
+		return err
+	}
+
+	// Stream transports use octet counting (RFC 6587, section 3.4.1).
+	buf := make([]byte, 0, len(frame)+8)
+	buf = strconv.AppendInt(buf, int64(len(frame)), 10)
+	buf = append(buf, ' ')
+	buf = append(buf, frame...)
+	_, err := s.conn.Write(buf)
+	return err
+}
+
+func (s *syslogSink) scheduleRetry() {
+	switch {
+	case s.backoff == 0:
+		s.backoff = _syslogMinBackoff
+	case s.backoff < _syslogMaxBackoff:
+		s.backoff *= 2
+		if s.backoff > _syslogMaxBackoff {
+			s.backoff = _syslogMaxBackoff
+		}
+	}
+	s.retryAt = s.clock.Now().Add(s.backoff)
+}
+
+func (s *syslogSink) droppedErr() error {
+	return s.withDropped(nil)
+}
+
+// withDropped adds the number of frames dropped since the last report to
+// err, if any were dropped.
+func (s *syslogSink) withDropped(err error) error {
+	if s.dropped == 0 {
+		return err
+	}
+	dropped := fmt.Errorf("dropped %d syslog messages", s.dropped)
+	s.dropped = 0
+	if err == nil {
+		return dropped
+	}
+	return fmt.Errorf("%w (%v)", err, dropped)
+}
+
+// frame formats a log entry as an RFC 5424 message.
+func (s *syslogSink) frame(line []byte) []byte {
+	severity := _syslogDefaultSeverity
+	var sd []byte
+	if line[0] == '{' {
+		severity, sd = s.parseEntry(line)
+	}
+
+	buf := make([]byte, 0, len(line)+128)
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(s.opts.Facility*8+severity), 10)
+	buf = append(buf, ">1 "...)
+	buf = s.clock.Now().AppendFormat(buf, _syslogTimeFormat)
+	buf = append(buf, ' ')
+	buf = appendSyslogHeader(buf, s.opts.Hostname, _syslogMaxHostnameLen)
+	buf = append(buf, ' ')
+	buf = appendSyslogHeader(buf, s.opts.Tag, _syslogMaxAppNameLen)
+	buf = append(buf, ' ')
+	buf = appendSyslogHeader(buf, s.pid, _syslogMaxProcIDLen)
+	buf = append(buf, " - "...) // MSGID
+	if len(sd) == 0 {
+		buf = append(buf, '-')
+	} else {
+		buf = append(buf, sd...)
+	}
+	buf = append(buf, ' ')
+	return append(buf, line...)
+}
+
+// parseEntry reads the severity and structured data from a JSON-encoded
+// entry.
+func (s *syslogSink) parseEntry(line []byte) (severity int, sd []byte) {
+	severity = _syslogDefaultSeverity
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return severity, nil
+	}
+
+	if raw, ok := fields[s.opts.LevelKey]; ok {
+		var text string
+		var lvl zapcore.Level
+		if json.Unmarshal(raw, &text) == nil && lvl.UnmarshalText([]byte(text)) == nil {
+			severity = lvl.SyslogSeverity()
+		}
+	}
+
+	for _, key := range s.opts.SDFields {
+		raw, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if len(sd) == 0 {
+			sd = append(sd, '[')
+			sd = appendSyslogSDName(sd, s.opts.SDID)
+		}
+		sd = append(sd, ' ')
+		sd = appendSyslogSDName(sd, key)
+		sd = append(sd, `="`...)
+		var str string
+		if json.Unmarshal(raw, &str) == nil {
+			sd = appendSyslogSDValue(sd, str)
+		} else {
+			sd = appendSyslogSDValue(sd, string(raw))
+		}
+		sd = append(sd, '"')
+	}
+	if len(sd) > 0 {
+		sd = append(sd, ']')
+	}
+	return severity, sd
+}
+
+// appendSyslogHeader appends a header field, replacing characters that aren't
+// printable ASCII with underscores and truncating it to max bytes. Empty
+// values are replaced with the NILVALUE.
+func appendSyslogHeader(buf []byte, s string, max int) []byte {
+	if s == "" {
+		return append(buf, '-')
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c > ' ' && c < 0x7f {
+			buf = append(buf, c)
+		} else {
+			buf = append(buf, '_')
+		}
+	}
+	return buf
+}
+
+// appendSyslogSDName appends a structured data ID or parameter name, which
+// may not contain '=', ' ', ']', or '"'.
+func appendSyslogSDName(buf []byte, s string) []byte {
+	if len(s) > _syslogMaxSDParamName {
+		s = s[:_syslogMaxSDParamName]
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c <= ' ', c >= 0x7f, c == '=', c == ']', c == '"':
+			buf = append(buf, '_')
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+// appendSyslogSDValue appends a structured data parameter value, escaping
+// '"', '\', and ']'.
+func appendSyslogSDValue(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\', ']':
+			buf = append(buf, '\\', c)
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
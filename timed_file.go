@@ -0,0 +1,402 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toujourser/zap/zapcore"
+	"go.uber.org/multierr"
+)
+
+const schemeTimedFile = "timedfile"
+
+// timedFileOptions configures a timedFile. See newTimedFileSinkFromURL for
+// the URL parameters that map onto these options.
+type timedFileOptions struct {
+	// UTC formats file names using UTC rather than local time.
+	UTC bool
+	// MaxAge is the maximum age of old files, based on their modification
+	// time. Zero disables age-based cleanup.
+	MaxAge time.Duration
+	// Symlink, if set, is the path of a symbolic link that's kept pointing
+	// at the current file.
+	Symlink string
+}
+
+// timePatternPart is a single strftime-style directive in a file name
+// pattern, or zero for a run of literal text.
+type timePatternPart struct {
+	verb    byte
+	literal string
+}
+
+// timePattern is a parsed file name pattern. It supports the %Y, %m, %d,
+// %H, %M, %S, and %j directives of strftime, and %% for a literal percent
+// sign.
+type timePattern []timePatternPart
+
+func parseTimePattern(s string) (timePattern, error) {
+	var (
+		pat     timePattern
+		literal strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			literal.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return nil, errors.New("pattern ends with a lone %")
+		}
+		switch verb := s[i]; verb {
+		case '%':
+			literal.WriteByte('%')
+		case 'Y', 'm', 'd', 'H', 'M', 'S', 'j':
+			if literal.Len() > 0 {
+				pat = append(pat, timePatternPart{literal: literal.String()})
+				literal.Reset()
+			}
+			pat = append(pat, timePatternPart{verb: verb})
+		default:
+			return nil, fmt.Errorf("unknown directive %%%c", verb)
+		}
+	}
+	if literal.Len() > 0 {
+		pat = append(pat, timePatternPart{literal: literal.String()})
+	}
+	return pat, nil
+}
+
+// AppendFormat appends the file name for t to buf.
+func (p timePattern) AppendFormat(buf []byte, t time.Time) []byte {
+	for _, part := range p {
+		switch part.verb {
+		case 0:
+			buf = append(buf, part.literal...)
+		case 'Y':
+			buf = appendPadded(buf, t.Year(), 4)
+		case 'm':
+			buf = appendPadded(buf, int(t.Month()), 2)
+		case 'd':
+			buf = appendPadded(buf, t.Day(), 2)
+		case 'H':
+			buf = appendPadded(buf, t.Hour(), 2)
+		case 'M':
+			buf = appendPadded(buf, t.Minute(), 2)
+		case 'S':
+			buf = appendPadded(buf, t.Second(), 2)
+		case 'j':
+			buf = appendPadded(buf, t.YearDay(), 3)
+		}
+	}
+	return buf
+}
+
+// Glob returns a filepath.Match pattern matching every file name that the
+// pattern can produce.
+func (p timePattern) Glob() string {
+	var sb strings.Builder
+	for _, part := range p {
+		if part.verb == 0 {
+			sb.WriteString(part.literal)
+		} else {
+			sb.WriteByte('*')
+		}
+	}
+	return sb.String()
+}
+
+func appendPadded(buf []byte, v, width int) []byte {
+	for n := len(strconv.Itoa(v)); n < width; n++ {
+		buf = append(buf, '0')
+	}
+	return strconv.AppendInt(buf, int64(v), 10)
+}
+
+// timedFile is a Sink that writes to a file named by formatting a pattern
+// with the current time, switching to a new file whenever the formatted
+// name changes. With a pattern like /var/log/app-%Y%m%d%H.log, that's one
+// file per hour. Missing parent directories are created as needed, and
+// files older than the configured maximum age are removed by a background
+// goroutine.
+//
+// Writes and switches are serialized, so every write lands whole in exactly
+// one file, and nothing written around a switch is lost.
+type timedFile struct {
+	pattern  timePattern
+	opts     timedFileOptions
+	openFile func(string, int, os.FileMode) (*os.File, error) // type matches os.OpenFile
+	clock    zapcore.Clock
+
+	mu     sync.Mutex
+	file   *os.File
+	name   string
+	buf    []byte // scratch space for formatting names
+	closed bool
+
+	mill millRunner
+}
+
+var _ Sink = (*timedFile)(nil)
+
+func newTimedFile(
+	pattern string,
+	opts timedFileOptions,
+	openFile func(string, int, os.FileMode) (*os.File, error),
+	clock zapcore.Clock,
+) (*timedFile, error) {
+	pat, err := parseTimePattern(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file name pattern %q: %w", pattern, err)
+	}
+	if opts.MaxAge < 0 {
+		return nil, fmt.Errorf("maximum file age must not be negative: got %v", opts.MaxAge)
+	}
+
+	tf := &timedFile{
+		pattern:  pat,
+		opts:     opts,
+		openFile: openFile,
+		clock:    clock,
+	}
+	if err := tf.switchTo(string(pat.AppendFormat(nil, tf.now()))); err != nil {
+		if tf.file != nil {
+			_ = tf.file.Close()
+		}
+		return nil, err
+	}
+	return tf, nil
+}
+
+// newTimedFileSinkFromURL builds a timedFile from a URL like
+//
+//	timedfile:///var/log/app-%Y%m%d%H.log?utc=true&maxAge=72h
+//
+// The URL's path is the file name pattern; percent signs in it are taken
+// literally rather than as URL escapes. The supported query parameters are
+// utc (a boolean), maxAge (a duration, like "72h"), and symlink (a path).
+func (sr *sinkRegistry) newTimedFileSinkFromURL(u *url.URL) (Sink, error) {
+	if u.User != nil {
+		return nil, fmt.Errorf("user and password not allowed with timedfile URLs: got %v", u)
+	}
+	if u.Fragment != "" {
+		return nil, fmt.Errorf("fragments not allowed with timedfile URLs: got %v", u)
+	}
+	if u.Port() != "" {
+		return nil, fmt.Errorf("ports not allowed with timedfile URLs: got %v", u)
+	}
+	if hn := u.Hostname(); hn != "" && hn != "localhost" {
+		return nil, fmt.Errorf("timedfile URLs must leave host empty or use localhost: got %v", u)
+	}
+	if u.Path == "" {
+		return nil, fmt.Errorf("timedfile URLs must include a file name pattern: got %v", u)
+	}
+
+	opts, err := parseTimedFileOptions(u.Query())
+	if err != nil {
+		return nil, fmt.Errorf("invalid timedfile URL %v: %w", u, err)
+	}
+	return newTimedFile(u.Path, opts, sr.openFile, zapcore.DefaultClock)
+}
+
+func parseTimedFileOptions(q url.Values) (timedFileOptions, error) {
+	var opts timedFileOptions
+	for key, vals := range q {
+		if len(vals) != 1 {
+			return opts, fmt.Errorf("parameter %q must be specified exactly once", key)
+		}
+		val := vals[0]
+
+		var err error
+		switch key {
+		case "utc":
+			opts.UTC, err = strconv.ParseBool(val)
+		case "maxAge":
+			opts.MaxAge, err = time.ParseDuration(val)
+		case "symlink":
+			opts.Symlink = val
+		default:
+			return opts, fmt.Errorf("unknown parameter %q", key)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("can't parse %q parameter: %w", key, err)
+		}
+	}
+	return opts, nil
+}
+
+// escapeTimedFileURL escapes the percent signs in the path of a timedfile
+// URL so that strftime-style directives survive url.Parse. The query is left
+// alone, since it may contain genuine URL escapes.
+func escapeTimedFileURL(rawURL string) string {
+	path, query := rawURL, ""
+	if i := strings.IndexByte(rawURL, '?'); i >= 0 {
+		path, query = rawURL[:i], rawURL[i:]
+	}
+	return strings.ReplaceAll(path, "%", "%25") + query
+}
+
+// Write writes p to the file for the current time, first switching files if
+// the time has moved into a new slice. If the new file can't be opened, p is
+// written to the previous file instead and the error is returned.
+func (tf *timedFile) Write(p []byte) (int, error) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	if tf.closed {
+		return 0, errors.New("write to closed timed file")
+	}
+
+	var switchErr error
+	tf.buf = tf.pattern.AppendFormat(tf.buf[:0], tf.now())
+	if string(tf.buf) != tf.name {
+		switchErr = tf.switchTo(string(tf.buf))
+	}
+
+	n, err := tf.file.Write(p)
+	return n, multierr.Append(err, switchErr)
+}
+
+// Sync flushes the current file to stable storage.
+func (tf *timedFile) Sync() error {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	if tf.closed {
+		return nil
+	}
+	return tf.file.Sync()
+}
+
+// Close closes the current file and stops the background cleanup goroutine,
+// waiting for any in-progress cleanup to finish.
+func (tf *timedFile) Close() error {
+	tf.mu.Lock()
+	if tf.closed {
+		tf.mu.Unlock()
+		return nil
+	}
+	tf.closed = true
+	err := tf.file.Close()
+	tf.mu.Unlock()
+
+	tf.mill.Wait()
+	return err
+}
+
+func (tf *timedFile) now() time.Time {
+	if tf.opts.UTC {
+		return tf.clock.Now().UTC()
+	}
+	return tf.clock.Now().Local()
+}
+
+// switchTo opens the named file and makes it current, closing the previous
+// file. It must be called with tf.mu held. If the new file can't be opened,
+// the previous file stays current.
+//
+// Failing to update the symlink doesn't prevent the switch, but the error
+// is still returned.
+func (tf *timedFile) switchTo(name string) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("can't create directory for %q: %w", name, err)
+	}
+	f, err := tf.openFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o666)
+	if err != nil {
+		return fmt.Errorf("can't open %q: %w", name, err)
+	}
+
+	var errs []error
+	if tf.file != nil {
+		if err := tf.file.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("can't close %q: %w", tf.name, err))
+		}
+	}
+	tf.file, tf.name = f, name
+
+	if tf.opts.Symlink != "" {
+		if err := tf.link(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if tf.opts.MaxAge > 0 {
+		tf.mill.Trigger(tf.removeOld)
+	}
+	return multierr.Combine(errs...)
+}
+
+// link points the symlink at the current file. The link is created under a
+// temporary name and renamed into place, so it never disappears.
+func (tf *timedFile) link() error {
+	target, err := filepath.Abs(tf.name)
+	if err != nil {
+		return err
+	}
+	tmp := tf.opts.Symlink + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return fmt.Errorf("can't link %q to %q: %w", tf.opts.Symlink, tf.name, err)
+	}
+	if err := os.Rename(tmp, tf.opts.Symlink); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("can't link %q to %q: %w", tf.opts.Symlink, tf.name, err)
+	}
+	return nil
+}
+
+// removeOld removes files matching the pattern that haven't been modified
+// within MaxAge. The current file is never removed.
+func (tf *timedFile) removeOld() error {
+	matches, err := filepath.Glob(tf.pattern.Glob())
+	if err != nil {
+		return err
+	}
+
+	tf.mu.Lock()
+	current := tf.name
+	tf.mu.Unlock()
+
+	var errs []error
+	cutoff := tf.clock.Now().Add(-tf.opts.MaxAge)
+	for _, name := range matches {
+		if name == current {
+			continue
+		}
+		info, err := os.Lstat(name)
+		if err != nil || !info.Mode().IsRegular() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	return multierr.Combine(errs...)
+}
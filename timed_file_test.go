@@ -0,0 +1,274 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zapcore/clock"
+)
+
+func TestTimePattern(t *testing.T) {
+	ts := time.Date(2023, 2, 3, 4, 5, 6, 0, time.UTC)
+
+	tests := []struct {
+		pattern string
+		want    string
+		glob    string
+		wantErr string
+	}{
+		{pattern: "app.log", want: "app.log", glob: "app.log"},
+		{pattern: "app-%Y%m%d%H.log", want: "app-2023020304.log", glob: "app-****.log"},
+		{pattern: "%Y/%j/app-%H%M%S.log", want: "2023/034/app-040506.log", glob: "*/*/app-***.log"},
+		{pattern: "100%%-%d.log", want: "100%-03.log", glob: "100%-*.log"},
+		{pattern: "app-%Q.log", wantErr: "unknown directive %Q"},
+		{pattern: "app-%", wantErr: "lone %"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			pat, err := parseTimePattern(tt.pattern)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(pat.AppendFormat(nil, ts)), "Unexpected file name.")
+			assert.Equal(t, tt.glob, pat.Glob(), "Unexpected glob.")
+		})
+	}
+}
+
+func TestTimedFileURLParsing(t *testing.T) {
+	dir := filepath.ToSlash(t.TempDir())
+
+	tests := []struct {
+		desc    string
+		rawURL  string
+		want    timedFileOptions
+		wantErr string
+	}{
+		{
+			desc:   "defaults",
+			rawURL: "timedfile://" + dir + "/app-%Y%m%d%H.log",
+		},
+		{
+			desc:   "all parameters",
+			rawURL: "timedfile://" + dir + "/app-%Y%m%d%H.log?utc=true&maxAge=72h&symlink=" + url.QueryEscape(dir+"/current"),
+			want:   timedFileOptions{UTC: true, MaxAge: 72 * time.Hour, Symlink: dir + "/current"},
+		},
+		{
+			desc:    "unknown parameter",
+			rawURL:  "timedfile://" + dir + "/app-%Y.log?maxAgeDays=3",
+			wantErr: `unknown parameter "maxAgeDays"`,
+		},
+		{
+			desc:    "malformed duration",
+			rawURL:  "timedfile://" + dir + "/app-%Y.log?maxAge=3d",
+			wantErr: `can't parse "maxAge" parameter`,
+		},
+		{
+			desc:    "negative age",
+			rawURL:  "timedfile://" + dir + "/app-%Y.log?maxAge=-1h",
+			wantErr: "must not be negative",
+		},
+		{
+			desc:    "bad directive",
+			rawURL:  "timedfile://" + dir + "/app-%q.log",
+			wantErr: "unknown directive",
+		},
+		{
+			desc:    "host",
+			rawURL:  "timedfile://example.com" + dir + "/app-%Y.log",
+			wantErr: "must leave host empty",
+		},
+		{
+			desc:    "no path",
+			rawURL:  "timedfile://",
+			wantErr: "must include a file name pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			sink, err := newSinkRegistry().newSink(tt.rawURL)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer func() { assert.NoError(t, sink.Close()) }()
+			assert.Equal(t, tt.want, sink.(*timedFile).opts, "Unexpected options.")
+		})
+	}
+}
+
+func TestTimedFileOpenFromOutputPaths(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg := NewProductionConfig()
+	cfg.OutputPaths = []string{"timedfile://" + filepath.ToSlash(dir) + "/app-%Y%m%d.log?utc=true"}
+	logger, err := cfg.Build()
+	require.NoError(t, err, "Unexpected error building logger with a timedfile URL.")
+
+	logger.Info("hello")
+	require.NoError(t, logger.Sync())
+
+	contents, err := os.ReadFile(filepath.Join(dir, "app-"+time.Now().UTC().Format("20060102")+".log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), `"msg":"hello"`)
+}
+
+func TestTimedFileSwitching(t *testing.T) {
+	dir := t.TempDir()
+	clk := clock.NewMockAt(time.Date(2023, 1, 1, 23, 30, 0, 0, time.UTC))
+
+	var opts timedFileOptions
+	opts.UTC = true
+	if runtime.GOOS != "windows" {
+		opts.Symlink = filepath.Join(dir, "current")
+	}
+	tf, err := newTimedFile(filepath.Join(dir, "%Y%m%d", "app-%H.log"), opts, os.OpenFile, clk)
+	require.NoError(t, err)
+
+	for _, line := range []string{"a\n", "b\n"} {
+		_, err := tf.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	clk.Add(time.Hour)
+	_, err = tf.Write([]byte("c\n"))
+	require.NoError(t, err)
+	require.NoError(t, tf.Close())
+
+	assert.Equal(t, map[string]string{
+		"20230101/app-23.log": "a\nb\n",
+		"20230102/app-00.log": "c\n",
+	}, readTimedFiles(t, filepath.Join(dir, "*", "app-*.log")), "Unexpected file contents.")
+
+	if opts.Symlink != "" {
+		target, err := os.Readlink(opts.Symlink)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "20230102", "app-00.log"), target, "Expected symlink to point at the current file.")
+	}
+
+	_, err = tf.Write([]byte("d\n"))
+	assert.Error(t, err, "Expected an error writing to a closed file.")
+}
+
+func TestTimedFileMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+
+	stale := filepath.Join(dir, "app-2023010100.log")
+	require.NoError(t, os.WriteFile(stale, []byte("old\n"), 0o666))
+	require.NoError(t, os.Chtimes(stale, old, old))
+	fresh := filepath.Join(dir, "app-2023010200.log")
+	require.NoError(t, os.WriteFile(fresh, []byte("new\n"), 0o666))
+	unrelated := filepath.Join(dir, "other.log")
+	require.NoError(t, os.WriteFile(unrelated, []byte("keep\n"), 0o666))
+	require.NoError(t, os.Chtimes(unrelated, old, old))
+
+	tf, err := newTimedFile(filepath.Join(dir, "app-%Y%m%d%H.log"), timedFileOptions{MaxAge: 24 * time.Hour}, os.OpenFile, zapcore.DefaultClock)
+	require.NoError(t, err)
+	require.NoError(t, tf.Close())
+
+	assert.NoFileExists(t, stale, "Expected stale file to be removed.")
+	assert.FileExists(t, fresh, "Expected recent file to be retained.")
+	assert.FileExists(t, unrelated, "Files that don't match the pattern must be left alone.")
+}
+
+func TestTimedFileConcurrentSwitching(t *testing.T) {
+	const (
+		goroutines = 8
+		perRoutine = 500
+	)
+
+	dir := t.TempDir()
+	// Every read of the clock advances it by a second, so the per-minute
+	// pattern switches files every sixty writes.
+	clk := &steppingClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tf, err := newTimedFile(filepath.Join(dir, "app-%H%M.log"), timedFileOptions{UTC: true}, os.OpenFile, clk)
+	require.NoError(t, err)
+
+	logger := New(zapcore.NewCore(zapcore.NewJSONEncoder(NewProductionEncoderConfig()), tf, DebugLevel))
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perRoutine; i++ {
+				logger.Info("message", Int("goroutine", g), Int("i", i))
+			}
+		}(g)
+	}
+	wg.Wait()
+	require.NoError(t, tf.Close())
+
+	files := readTimedFiles(t, filepath.Join(dir, "app-*.log"))
+	assert.Greater(t, len(files), 1, "Expected files to switch.")
+
+	seen := make(map[[2]int]bool)
+	for _, contents := range files {
+		scanner := bufio.NewScanner(strings.NewReader(contents))
+		for scanner.Scan() {
+			var entry struct {
+				Goroutine int `json:"goroutine"`
+				I         int `json:"i"`
+			}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry), "Found a split or corrupt line: %q", scanner.Text())
+			key := [2]int{entry.Goroutine, entry.I}
+			assert.False(t, seen[key], "Duplicate entry %v", key)
+			seen[key] = true
+		}
+	}
+	assert.Len(t, seen, goroutines*perRoutine, "Some entries were lost while switching files.")
+}
+
+// readTimedFiles returns the contents of the files matching glob, keyed by
+// their paths relative to the glob's first directory.
+func readTimedFiles(t *testing.T, glob string) map[string]string {
+	matches, err := filepath.Glob(glob)
+	require.NoError(t, err)
+
+	root := glob[:strings.IndexAny(glob, "*")]
+	contents := make(map[string]string, len(matches))
+	for _, name := range matches {
+		b, err := os.ReadFile(name)
+		require.NoError(t, err)
+		rel, err := filepath.Rel(root, name)
+		require.NoError(t, err)
+		contents[filepath.ToSlash(rel)] = string(b)
+	}
+	return contents
+}
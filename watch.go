@@ -0,0 +1,375 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/toujourser/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+const _defaultWatchInterval = time.Second
+
+// A WatchOption configures how WatchConfig watches its configuration file.
+type WatchOption interface {
+	apply(*watchOptions)
+}
+
+type watchOptionFunc func(*watchOptions)
+
+func (f watchOptionFunc) apply(opts *watchOptions) {
+	f(opts)
+}
+
+type watchOptions struct {
+	interval   time.Duration
+	signals    []os.Signal
+	trigger    <-chan struct{}
+	onReload   func(error)
+	loggerOpts []Option
+	clock      zapcore.Clock
+}
+
+// WatchInterval sets how often WatchConfig polls the configuration file for
+// changes. The file is reloaded only if its contents changed since the last
+// attempt. A non-positive interval disables polling. Defaults to one second.
+func WatchInterval(d time.Duration) WatchOption {
+	return watchOptionFunc(func(opts *watchOptions) {
+		opts.interval = d
+	})
+}
+
+// WatchClock sets the clock whose ticks drive polling. Defaults to the
+// system clock.
+func WatchClock(clock zapcore.Clock) WatchOption {
+	return watchOptionFunc(func(opts *watchOptions) {
+		opts.clock = clock
+	})
+}
+
+// WatchSignals reloads the configuration file whenever the process receives
+// one of the given signals, typically syscall.SIGHUP.
+func WatchSignals(sigs ...os.Signal) WatchOption {
+	return watchOptionFunc(func(opts *watchOptions) {
+		opts.signals = append(opts.signals, sigs...)
+	})
+}
+
+// WatchTrigger reloads the configuration file whenever a value is received on
+// the channel. Use it to drive reloads from a file system notification
+// library such as fsnotify.
+func WatchTrigger(ch <-chan struct{}) WatchOption {
+	return watchOptionFunc(func(opts *watchOptions) {
+		opts.trigger = ch
+	})
+}
+
+// WatchOnReload registers a function that is called after every attempt to
+// reload the configuration file with nil on success, or with the reason the
+// new configuration was rejected. It's called from the watching goroutine.
+func WatchOnReload(f func(error)) WatchOption {
+	return watchOptionFunc(func(opts *watchOptions) {
+		opts.onReload = f
+	})
+}
+
+// WatchLoggerOptions applies the given options to the Logger built by
+// WatchConfig.
+func WatchLoggerOptions(opts ...Option) WatchOption {
+	return watchOptionFunc(func(wopts *watchOptions) {
+		wopts.loggerOpts = append(wopts.loggerOpts, opts...)
+	})
+}
+
+// WatchConfig builds a Logger from the Config stored in the JSON or YAML file
+// at path, and keeps it up to date as the file changes. Files with a ".json"
+// extension are decoded as JSON, and all others as YAML.
+//
+// When the file changes, the logger's level, sampling parameters, and
+// initial fields are replaced in place, so the returned Logger and any
+// loggers derived from it observe the new configuration. Changes to other
+// settings, such as the outputs or the encoding, take effect only when the
+// logger is rebuilt. If the new configuration is invalid, it's rejected and
+// the previous one is kept.
+//
+// The returned function stops watching the file and syncs the logger.
+func WatchConfig(path string, opts ...WatchOption) (*Logger, func() error, error) {
+	wopts := watchOptions{
+		interval: _defaultWatchInterval,
+		clock:    zapcore.DefaultClock,
+	}
+	for _, opt := range opts {
+		opt.apply(&wopts)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, err := decodeWatchedConfig(path, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base, errSink, err := cfg.buildCore()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w := &configWatcher{
+		path:     path,
+		last:     data,
+		level:    cfg.Level,
+		base:     base,
+		errSink:  errSink,
+		core:     newReloadableCore(watchedCore(cfg, base, errSink)),
+		onReload: wopts.onReload,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	log := New(w.core, append(cfg.buildLoggerOptions(errSink), wopts.loggerOpts...)...)
+
+	var ticker *time.Ticker
+	if wopts.interval > 0 {
+		ticker = wopts.clock.NewTicker(wopts.interval)
+	}
+
+	var sigs chan os.Signal
+	if len(wopts.signals) > 0 {
+		sigs = make(chan os.Signal, 1)
+		signal.Notify(sigs, wopts.signals...)
+	}
+
+	go w.run(ticker, sigs, wopts.trigger)
+
+	var stopOnce sync.Once
+	stop := func() error {
+		stopOnce.Do(func() {
+			if sigs != nil {
+				signal.Stop(sigs)
+			}
+			close(w.stop)
+			<-w.done
+		})
+		return log.Sync()
+	}
+	return log, stop, nil
+}
+
+// decodeWatchedConfig decodes the contents of the configuration file at path.
+func decodeWatchedConfig(path string, data []byte) (Config, error) {
+	var (
+		cfg Config
+		err error
+	)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &cfg)
+	} else {
+		err = yaml.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("decode %v: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// watchedCore applies the reloadable parts of cfg to the base core.
+func watchedCore(cfg Config, base zapcore.Core, errSink zapcore.WriteSyncer) zapcore.Core {
+	core := cfg.buildSampler(base)
+	if fs := cfg.buildInitialFields(errSink); len(fs) > 0 {
+		core = core.With(fs)
+	}
+	return core
+}
+
+// configWatcher reloads a configuration file and applies it to a logger
+// built by WatchConfig.
+type configWatcher struct {
+	path     string
+	last     []byte // contents of the file at the last reload attempt
+	level    AtomicLevel
+	base     zapcore.Core
+	errSink  zapcore.WriteSyncer
+	core     *reloadableCore
+	onReload func(error)
+
+	stop chan struct{} // closed to stop the watcher
+	done chan struct{} // closed when the watcher has stopped
+}
+
+func (w *configWatcher) run(ticker *time.Ticker, sigs <-chan os.Signal, trigger <-chan struct{}) {
+	defer close(w.done)
+
+	var ticks <-chan time.Time
+	if ticker != nil {
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticks:
+			w.reload(false /* force */)
+		case <-sigs:
+			w.reload(true /* force */)
+		case _, ok := <-trigger:
+			if !ok {
+				trigger = nil
+				continue
+			}
+			w.reload(true /* force */)
+		}
+	}
+}
+
+// reload reads the configuration file and applies it. Unless force is set,
+// the file is applied only if it changed since the last attempt.
+func (w *configWatcher) reload(force bool) {
+	data, err := os.ReadFile(w.path)
+	if err == nil {
+		if !force && bytes.Equal(data, w.last) {
+			return
+		}
+		w.last = data
+		err = w.apply(data)
+	}
+
+	if w.onReload != nil {
+		w.onReload(err)
+	}
+}
+
+func (w *configWatcher) apply(data []byte) error {
+	cfg, err := decodeWatchedConfig(w.path, data)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Level == (AtomicLevel{}) {
+		return errors.New("missing Level")
+	}
+	routes, err := cfg.outputRoutes()
+	if err != nil {
+		return err
+	}
+	if _, err := cfg.buildEncoders(routes); err != nil {
+		return err
+	}
+
+	w.core.swap(watchedCore(cfg, w.base, w.errSink))
+	w.level.SetLevel(cfg.Level.Level())
+	return nil
+}
+
+// reloadableCore is a zapcore.Core that delegates to a core which may be
+// replaced at any time. Cores derived from it with With observe
+// replacements as well.
+type reloadableCore struct {
+	current *atomic.Pointer[reloadGen] // shared with derived cores
+	fields  []zapcore.Field
+
+	// cache holds the result of applying fields to the current core.
+	cache atomic.Pointer[reloadCache]
+}
+
+// reloadGen is a single generation of the core behind a reloadableCore.
+type reloadGen struct {
+	core zapcore.Core
+}
+
+// reloadCache is a core derived from a reloadGen by applying fields.
+type reloadCache struct {
+	gen  *reloadGen
+	core zapcore.Core
+}
+
+var (
+	_ zapcore.Core           = (*reloadableCore)(nil)
+	_ zapcore.LeveledEnabler = (*reloadableCore)(nil)
+)
+
+func newReloadableCore(core zapcore.Core) *reloadableCore {
+	c := &reloadableCore{current: new(atomic.Pointer[reloadGen])}
+	c.swap(core)
+	return c
+}
+
+// swap replaces the core behind c and all cores derived from it.
+func (c *reloadableCore) swap(core zapcore.Core) {
+	c.current.Store(&reloadGen{core: core})
+}
+
+// load returns the current core with c's fields applied.
+func (c *reloadableCore) load() zapcore.Core {
+	gen := c.current.Load()
+	if len(c.fields) == 0 {
+		return gen.core
+	}
+	if cached := c.cache.Load(); cached != nil && cached.gen == gen {
+		return cached.core
+	}
+	core := gen.core.With(c.fields)
+	c.cache.Store(&reloadCache{gen: gen, core: core})
+	return core
+}
+
+func (c *reloadableCore) Enabled(lvl zapcore.Level) bool {
+	return c.load().Enabled(lvl)
+}
+
+func (c *reloadableCore) Level() zapcore.Level {
+	return zapcore.LevelOf(c.load())
+}
+
+func (c *reloadableCore) With(fields []zapcore.Field) zapcore.Core {
+	fs := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	fs = append(fs, c.fields...)
+	fs = append(fs, fields...)
+	return &reloadableCore{
+		current: c.current,
+		fields:  fs,
+	}
+}
+
+func (c *reloadableCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.load().Check(ent, ce)
+}
+
+func (c *reloadableCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.load().Write(ent, fields)
+}
+
+func (c *reloadableCore) Sync() error {
+	return c.load().Sync()
+}
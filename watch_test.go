@@ -0,0 +1,221 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap/internal/ztest"
+	"github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zaptest/observer"
+)
+
+// watchTestConfig returns a YAML config that writes JSON logs to out.
+func watchTestConfig(out, level, extra string) string {
+	return strings.Join([]string{
+		"level: " + level,
+		"encoding: json",
+		"disableCaller: true",
+		"outputPaths: [" + out + "]",
+		"errorOutputPaths: [stderr]",
+		"encoderConfig: {messageKey: msg, levelKey: level, levelEncoder: lowercase}",
+		extra,
+	}, "\n")
+}
+
+func writeWatchedFile(t *testing.T, path, contents string) {
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600), "Failed to write config file.")
+}
+
+func readLines(t *testing.T, path string) []string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err, "Failed to read log output.")
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func watchReloads(t *testing.T) (WatchOption, <-chan error) {
+	errs := make(chan error, 10)
+	return WatchOnReload(func(err error) { errs <- err }), errs
+}
+
+func awaitReload(t *testing.T, errs <-chan error) error {
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Timed out waiting for config reload.")
+		return nil
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.log")
+	path := filepath.Join(dir, "config.yaml")
+	writeWatchedFile(t, path, watchTestConfig(out, "info", "initialFields: {gen: 1}"))
+
+	trigger := make(chan struct{})
+	onReload, errs := watchReloads(t)
+	logger, stop, err := WatchConfig(path, WatchTrigger(trigger), WatchInterval(0), onReload)
+	require.NoError(t, err, "Unexpected error building watched logger.")
+	defer func() { assert.NoError(t, stop(), "Unexpected error stopping watcher.") }()
+
+	child := logger.With(String("child", "yes"))
+	logger.Debug("dropped")
+	child.Info("before")
+
+	writeWatchedFile(t, path, watchTestConfig(out, "debug", "initialFields: {gen: 2}"))
+	trigger <- struct{}{}
+	require.NoError(t, awaitReload(t, errs), "Unexpected error reloading config.")
+
+	assert.Equal(t, DebugLevel, logger.Level(), "Expected level to be reloaded.")
+	logger.Debug("after")
+	child.Debug("after")
+
+	assert.Equal(t, []string{
+		`{"level":"info","msg":"before","gen":1,"child":"yes"}`,
+		`{"level":"debug","msg":"after","gen":2}`,
+		`{"level":"debug","msg":"after","gen":2,"child":"yes"}`,
+	}, readLines(t, out), "Unexpected log output.")
+}
+
+func TestWatchConfigRejectsInvalid(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.log")
+	path := filepath.Join(dir, "config.yaml")
+	writeWatchedFile(t, path, watchTestConfig(out, "warn", ""))
+
+	trigger := make(chan struct{})
+	onReload, errs := watchReloads(t)
+	logger, stop, err := WatchConfig(path, WatchTrigger(trigger), WatchInterval(0), onReload)
+	require.NoError(t, err, "Unexpected error building watched logger.")
+	defer func() { assert.NoError(t, stop(), "Unexpected error stopping watcher.") }()
+
+	tests := []struct {
+		desc     string
+		contents string
+		wantErr  string
+	}{
+		{"unknown level", watchTestConfig(out, "nope", ""), "unrecognized level"},
+		{"missing level", "encoding: json", "missing Level"},
+		{"unknown encoding", strings.Replace(watchTestConfig(out, "debug", ""), "encoding: json", "encoding: nope", 1), "no encoder registered"},
+		{"invalid level outputs", watchTestConfig(out, "debug", "levelOutputs: {nope: [stderr]}"), "invalid LevelOutputs"},
+		{"malformed", "level: [", "decode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			writeWatchedFile(t, path, tt.contents)
+			trigger <- struct{}{}
+			err := awaitReload(t, errs)
+			require.Error(t, err, "Expected invalid config to be rejected.")
+			assert.Contains(t, err.Error(), tt.wantErr, "Unexpected error message.")
+			assert.Equal(t, WarnLevel, logger.Level(), "Expected previous level to be kept.")
+		})
+	}
+
+	require.NoError(t, os.Remove(path), "Failed to remove config file.")
+	trigger <- struct{}{}
+	assert.True(t, errors.Is(awaitReload(t, errs), os.ErrNotExist), "Expected error reading missing file.")
+
+	logger.Info("dropped")
+	logger.Warn("kept")
+	assert.Equal(t, []string{`{"level":"warn","msg":"kept"}`}, readLines(t, out), "Unexpected log output.")
+}
+
+func TestWatchConfigPolling(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.log")
+	path := filepath.Join(dir, "config.json")
+	writeWatchedFile(t, path, `{"level": "info", "encoding": "json", "encoderConfig": {"messageKey": "msg"}, "outputPaths": ["`+filepath.ToSlash(out)+`"]}`)
+
+	clock := ztest.NewMockClock()
+	trigger := make(chan struct{})
+	onReload, errs := watchReloads(t)
+	logger, stop, err := WatchConfig(path,
+		WatchInterval(time.Minute),
+		WatchTrigger(trigger),
+		onReload,
+		WatchClock(clock),
+	)
+	require.NoError(t, err, "Unexpected error building watched logger.")
+	defer func() { assert.NoError(t, stop(), "Unexpected error stopping watcher.") }()
+
+	// Unchanged files aren't reloaded when polling.
+	clock.Add(time.Minute)
+	trigger <- struct{}{}
+	require.NoError(t, awaitReload(t, errs), "Expected only the triggered reload.")
+	assert.Empty(t, errs, "Expected no reload for an unchanged file.")
+
+	writeWatchedFile(t, path, `{"level": "error", "encoding": "json", "encoderConfig": {"messageKey": "msg"}, "sampling": {"initial": 1, "thereafter": 0}}`)
+	clock.Add(time.Minute)
+	require.NoError(t, awaitReload(t, errs), "Unexpected error reloading config.")
+	assert.Equal(t, ErrorLevel, logger.Level(), "Expected level to be reloaded.")
+
+	for i := 0; i < 3; i++ {
+		logger.Error("sampled")
+	}
+	lines := readLines(t, out)
+	require.Len(t, lines, 1, "Expected sampling to be reloaded.")
+	assert.Equal(t, `{"msg":"sampled"}`, lines[0], "Unexpected log output.")
+}
+
+func TestWatchConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, _, err := WatchConfig(filepath.Join(dir, "missing.yaml"))
+	assert.True(t, errors.Is(err, os.ErrNotExist), "Expected error for missing file.")
+
+	path := filepath.Join(dir, "config.yaml")
+	writeWatchedFile(t, path, "encoding: json")
+	_, _, err = WatchConfig(path)
+	assert.EqualError(t, err, "missing Level", "Expected error for invalid config.")
+}
+
+func TestReloadableCore(t *testing.T) {
+	first, firstLogs := observer.New(InfoLevel)
+	second, secondLogs := observer.New(DebugLevel)
+
+	core := newReloadableCore(first)
+	child := core.With([]zapcore.Field{String("k", "v")})
+	assert.Equal(t, InfoLevel, zapcore.LevelOf(core), "Unexpected initial level.")
+
+	logger := New(child)
+	logger.Debug("dropped")
+	logger.Info("first")
+
+	core.swap(second)
+	assert.Equal(t, DebugLevel, zapcore.LevelOf(child), "Expected derived cores to observe swaps.")
+	logger.Debug("second")
+	require.NoError(t, logger.Sync(), "Unexpected error syncing.")
+
+	require.Equal(t, 1, firstLogs.Len(), "Unexpected entries in first core.")
+	assert.Equal(t, "first", firstLogs.All()[0].Message)
+	require.Equal(t, 1, secondLogs.Len(), "Unexpected entries in second core.")
+	assert.Equal(t, []Field{String("k", "v")}, secondLogs.All()[0].Context, "Expected fields on the swapped core.")
+}
@@ -42,6 +42,57 @@ import (
 // filesystem. No user, password, port, fragments, or query parameters are
 // allowed, and the hostname must be empty or "localhost".
 //
+// URLs with the "rotate" scheme also refer to absolute paths on the local
+// filesystem, but the file is rotated once it grows past a maximum size. The
+// following query parameters are supported:
+//
+//   - maxSizeMB: the size in megabytes at which the file is rotated
+//     (default 100).
+//   - maxBackups: the number of rotated files to keep (default 0, keep all).
+//   - maxAgeDays: the number of days to keep rotated files (default 0, keep
+//     forever).
+//   - compress: whether to gzip rotated files (default false).
+//
+// For example, "rotate:///var/log/app.log?maxSizeMB=100&maxBackups=5".
+//
+// URLs with the "syslog" scheme send each entry to a syslog server as an
+// RFC 5424 message. For the "udp" (default) and "tcp" networks, the host and
+// port identify the server, and the port defaults to 514; for the "unix" and
+// "unixgram" networks, the path identifies the socket. The severity of each
+// message is mapped from the entry's level, which is read from JSON-encoded
+// entries. The following query parameters are supported:
+//
+//   - network: "udp", "tcp", "unix", or "unixgram" (default "udp").
+//   - facility: the facility name, e.g. "daemon" or "local0" (default
+//     "user").
+//   - tag: the APP-NAME of each message (default the program name).
+//   - hostname: the HOSTNAME of each message (default os.Hostname).
+//   - levelKey: the key holding the level in JSON-encoded entries (default
+//     "level").
+//   - sdFields: a comma-separated list of top-level keys of JSON-encoded
+//     entries to copy into the message's structured data.
+//   - sdID: the SD-ID of the structured data (default "zap@32473").
+//
+// Messages that can't be delivered are retained in memory and retried with
+// exponential backoff; Sync retries them immediately. For example,
+// "syslog://localhost:514?facility=local0&tag=myapp".
+//
+// URLs with the "gelf+udp" scheme send each entry to a Graylog server as a
+// GELF message over UDP, and are meant for use with the "gelf" encoding. The
+// port defaults to 12201. Messages larger than a datagram are split into
+// GELF chunks. The following query parameters are supported:
+//
+//   - compress: "none", "gzip", or "zlib" (default "none").
+//   - chunkSize: the maximum size of each datagram in bytes (default 1420).
+//
+// For example, "gelf+udp://graylog:12201?compress=gzip".
+//
+// URLs with the "journald" scheme send each entry to the systemd journal
+// over its native protocol, and require the "journald" encoding. The path,
+// if any, identifies the journal's socket, which defaults to
+// /run/systemd/journal/socket. The journald scheme is only supported on
+// Linux; on other platforms, opening it fails.
+//
 // Since it's common to write logs to the local filesystem, URLs without a
 // scheme (e.g., "/var/log/foo.log") are treated as local file paths. Without
 // a scheme, the special paths "stdout" and "stderr" are interpreted as
@@ -84,6 +135,18 @@ func open(paths []string) ([]zapcore.WriteSyncer, func(), error) {
 	return writers, closeAll, nil
 }
 
+// checkSinks reports the paths that open would fail to find a sink for,
+// without opening any of them.
+func checkSinks(paths []string) error {
+	var err error
+	for _, path := range paths {
+		if sinkErr := _sinkRegistry.checkSink(path); sinkErr != nil {
+			err = multierr.Append(err, fmt.Errorf("invalid sink %q: %w", path, sinkErr))
+		}
+	}
+	return err
+}
+
 // CombineWriteSyncers is a utility that combines multiple WriteSyncers into a
 // single, locked WriteSyncer. If no inputs are supplied, it returns a no-op
 // WriteSyncer.
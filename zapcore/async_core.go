@@ -0,0 +1,428 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// _defaultAsyncQueueSize specifies the default number of entries buffered by
+// an AsyncCore.
+const _defaultAsyncQueueSize = 1024
+
+// AsyncDropPolicy controls what an AsyncCore does with new entries when its
+// queue is full.
+type AsyncDropPolicy int8
+
+const (
+	// AsyncBlock blocks the logging goroutine until there's room in the
+	// queue. No entries are dropped.
+	AsyncBlock AsyncDropPolicy = iota
+	// AsyncDropNewest discards the entry being logged.
+	AsyncDropNewest
+	// AsyncDropOldest discards the oldest queued entry to make room for the
+	// entry being logged.
+	AsyncDropOldest
+)
+
+// An AsyncOption configures an AsyncCore.
+type AsyncOption interface {
+	apply(*asyncQueue)
+}
+
+// asyncOptionFunc wraps a func so it satisfies the AsyncOption interface.
+type asyncOptionFunc func(*asyncQueue)
+
+func (f asyncOptionFunc) apply(q *asyncQueue) {
+	f(q)
+}
+
+// AsyncQueueSize sets the maximum number of entries the AsyncCore buffers
+// before applying its drop policy. Values less than one are ignored.
+//
+// Defaults to 1024.
+func AsyncQueueSize(size int) AsyncOption {
+	return asyncOptionFunc(func(q *asyncQueue) {
+		if size > 0 {
+			q.size = size
+		}
+	})
+}
+
+// AsyncPolicy sets what the AsyncCore does when its queue is full.
+//
+// Defaults to AsyncBlock.
+func AsyncPolicy(policy AsyncDropPolicy) AsyncOption {
+	return asyncOptionFunc(func(q *asyncQueue) {
+		q.policy = policy
+	})
+}
+
+// AsyncOnDrop registers a function that's called with every entry the
+// AsyncCore drops because its queue is full. The function is called on the
+// logging goroutine, so it must be safe for concurrent use and should return
+// quickly.
+func AsyncOnDrop(f func(Entry)) AsyncOption {
+	return asyncOptionFunc(func(q *asyncQueue) {
+		q.onDrop = f
+	})
+}
+
+// AsyncSyncTimeout limits how long Sync waits for queued entries to be
+// written. Use Stop to drain the queue with a caller-supplied deadline.
+//
+// By default, Sync waits until the queue is drained.
+func AsyncSyncTimeout(timeout time.Duration) AsyncOption {
+	return asyncOptionFunc(func(q *asyncQueue) {
+		q.syncTimeout = timeout
+	})
+}
+
+// AsyncCore is a Core that queues entries in memory and writes them to a
+// wrapped Core from a dedicated goroutine, so that slow outputs don't stall
+// the goroutines doing the logging.
+//
+// Fields are copied before they're queued, so callers may reuse them as soon
+// as the log call returns. The contents of zap.Binary and zap.ByteString
+// fields are copied, ObjectMarshalers, ArrayMarshalers, and Stringers are
+// run on the logging goroutine and their output recorded, and reflected
+// values are encoded as JSON. Errors and TextMarshalers are still encoded
+// on the writer goroutine, so they must be safe to read concurrently.
+//
+// Entries at DPanicLevel and above are written synchronously after the
+// queue is drained, since the logger may exit or panic immediately after
+// writing them; DPanicLevel entries panic in development. Entries logged
+// after Stop are also written synchronously.
+//
+// The wrapped Core's Check method is not consulted; wrap samplers and other
+// Cores that filter entries around the AsyncCore instead.
+//
+// Call Stop when the AsyncCore is no longer needed to drain the queue and
+// release the writer goroutine.
+type AsyncCore struct {
+	core Core
+	q    *asyncQueue
+}
+
+var (
+	_ Core           = (*AsyncCore)(nil)
+	_ LeveledEnabler = (*AsyncCore)(nil)
+)
+
+// NewAsyncCore builds an AsyncCore that writes to the given Core and starts
+// its writer goroutine.
+func NewAsyncCore(core Core, opts ...AsyncOption) *AsyncCore {
+	q := &asyncQueue{
+		size:     _defaultAsyncQueueSize,
+		stopping: make(chan struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt.apply(q)
+	}
+	q.entries = make(chan asyncEntry, q.size)
+
+	go q.run()
+	return &AsyncCore{core: core, q: q}
+}
+
+// Enabled reports whether the wrapped Core is enabled at the given level.
+func (c *AsyncCore) Enabled(lvl Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+// Level reports the minimum enabled level of the wrapped Core.
+func (c *AsyncCore) Level() Level {
+	return LevelOf(c.core)
+}
+
+// With adds structured context to the wrapped Core. The returned Core shares
+// the queue and writer goroutine of its parent.
+func (c *AsyncCore) With(fields []Field) Core {
+	return &AsyncCore{core: c.core.With(fields), q: c.q}
+}
+
+// Check adds the AsyncCore to the CheckedEntry if the wrapped Core is
+// enabled at the entry's level.
+func (c *AsyncCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write queues the entry to be written by the writer goroutine.
+//
+// Errors from the wrapped Core are reported by the next call to Sync or Stop.
+func (c *AsyncCore) Write(ent Entry, fields []Field) error {
+	if ent.Level >= DPanicLevel {
+		// The logger may panic or exit right after writing these, so write
+		// them synchronously after everything logged before them.
+		ctx, cancel := c.q.syncContext()
+		defer cancel()
+		_ = c.q.flush(ctx)
+		return c.core.Write(ent, fields)
+	}
+
+	e := asyncEntry{core: c.core, ent: ent, fields: copyAsyncFields(fields)}
+	if !c.q.enqueue(e) {
+		return c.core.Write(ent, fields)
+	}
+	return nil
+}
+
+// Sync waits for entries queued so far to be written, then flushes the
+// wrapped Core.
+func (c *AsyncCore) Sync() error {
+	ctx, cancel := c.q.syncContext()
+	defer cancel()
+	return multierr.Combine(
+		c.q.flush(ctx),
+		c.q.takeErr(),
+		c.core.Sync(),
+	)
+}
+
+// Stop stops accepting new entries, waits for queued entries to be written,
+// and then flushes the wrapped Core.
+//
+// If the context expires before the queue is drained, Stop returns the
+// context's error and remaining entries continue to be written in the
+// background. Stop is safe to call multiple times.
+func (c *AsyncCore) Stop(ctx context.Context) error {
+	q := c.q
+	q.stopOnce.Do(func() {
+		// Unblock writers waiting for room in the queue before waiting
+		// for them to finish.
+		close(q.stopping)
+		q.mu.Lock()
+		q.stopped = true
+		q.mu.Unlock()
+		close(q.stop)
+	})
+
+	select {
+	case <-q.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return multierr.Append(q.takeErr(), c.core.Sync())
+}
+
+// Dropped reports the number of entries dropped because the queue was full.
+func (c *AsyncCore) Dropped() uint64 {
+	return c.q.dropped.Load()
+}
+
+// asyncEntry is a queued entry, or a flush marker if flushed is non-nil.
+type asyncEntry struct {
+	core    Core
+	ent     Entry
+	fields  []Field
+	flushed chan struct{} // closed once entries queued before it are written
+}
+
+// asyncQueue is the state shared by an AsyncCore and the Cores derived from
+// it with With.
+type asyncQueue struct {
+	size        int
+	policy      AsyncDropPolicy
+	onDrop      func(Entry)
+	syncTimeout time.Duration
+
+	entries chan asyncEntry
+	dropped atomic.Uint64
+
+	// writeMu is held by the writer goroutine while it writes an entry.
+	writeMu sync.Mutex
+
+	// mu guards stopped. Writers hold it for reading while queueing entries
+	// so that no entries are queued after the writer goroutine drains the
+	// queue.
+	mu       sync.RWMutex
+	stopped  bool
+	stopOnce sync.Once
+	stopping chan struct{} // closed when Stop is first called
+	stop     chan struct{} // closed when no more entries will be queued
+	done     chan struct{} // closed when the writer goroutine exits
+
+	errMu sync.Mutex
+	err   error // write errors since the last Sync or Stop
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.done)
+
+	for {
+		select {
+		case e := <-q.entries:
+			q.handle(e)
+		case <-q.stop:
+			for {
+				select {
+				case e := <-q.entries:
+					q.handle(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (q *asyncQueue) handle(e asyncEntry) {
+	if e.flushed != nil {
+		close(e.flushed)
+		return
+	}
+
+	q.writeMu.Lock()
+	err := e.core.Write(e.ent, e.fields)
+	q.writeMu.Unlock()
+
+	if err != nil {
+		q.errMu.Lock()
+		q.err = multierr.Append(q.err, err)
+		q.errMu.Unlock()
+	}
+}
+
+// enqueue queues an entry according to the drop policy. It returns false if
+// the entry wasn't queued or dropped because the AsyncCore was stopped.
+func (q *asyncQueue) enqueue(e asyncEntry) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.stopped {
+		return false
+	}
+
+	switch q.policy {
+	case AsyncDropNewest:
+		select {
+		case q.entries <- e:
+		default:
+			q.drop(e)
+		}
+		return true
+	case AsyncDropOldest:
+		for {
+			select {
+			case q.entries <- e:
+				return true
+			default:
+			}
+
+			select {
+			case old := <-q.entries:
+				q.drop(old)
+			default:
+			}
+		}
+	default:
+		select {
+		case q.entries <- e:
+			return true
+		case <-q.stopping:
+			return false
+		}
+	}
+}
+
+func (q *asyncQueue) drop(e asyncEntry) {
+	if e.flushed != nil {
+		// Everything queued before the marker has been handed to the writer
+		// goroutine; wait for the entry being written, if any, to finish.
+		q.writeMu.Lock()
+		close(e.flushed)
+		q.writeMu.Unlock()
+		return
+	}
+
+	q.dropped.Add(1)
+	if q.onDrop != nil {
+		q.onDrop(e.ent)
+	}
+}
+
+// flush waits for all entries queued before it was called to be written.
+func (q *asyncQueue) flush(ctx context.Context) error {
+	marker := asyncEntry{flushed: make(chan struct{})}
+	wait := marker.flushed
+
+	q.mu.RLock()
+	if q.stopped {
+		wait = q.done
+	} else {
+		select {
+		case q.entries <- marker:
+		case <-q.stopping:
+			wait = q.done
+		case <-ctx.Done():
+			q.mu.RUnlock()
+			return ctx.Err()
+		}
+	}
+	q.mu.RUnlock()
+
+	select {
+	case <-wait:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *asyncQueue) syncContext() (context.Context, context.CancelFunc) {
+	if q.syncTimeout > 0 {
+		return context.WithTimeout(context.Background(), q.syncTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+func (q *asyncQueue) takeErr() error {
+	q.errMu.Lock()
+	defer q.errMu.Unlock()
+
+	err := q.err
+	q.err = nil
+	return err
+}
+
+// copyAsyncFields copies fields with snapshotField, so that callers may
+// reuse them, and anything they refer to, once Write returns.
+func copyAsyncFields(fields []Field) []Field {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	cp := make([]Field, len(fields))
+	for i := range fields {
+		cp[i] = snapshotField(fields[i])
+	}
+	return cp
+}
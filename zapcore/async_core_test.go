@@ -0,0 +1,326 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedCore is a Core whose writes block until its gate is opened.
+type gatedCore struct {
+	Core
+
+	started chan struct{} // receives a value when a write begins
+	gate    chan struct{} // writes proceed once closed
+}
+
+func newGatedCore(core Core) *gatedCore {
+	return &gatedCore{
+		Core:    core,
+		started: make(chan struct{}, 100),
+		gate:    make(chan struct{}),
+	}
+}
+
+func (c *gatedCore) With(fields []Field) Core {
+	return &gatedCore{Core: c.Core.With(fields), started: c.started, gate: c.gate}
+}
+
+func (c *gatedCore) Write(ent Entry, fields []Field) error {
+	c.started <- struct{}{}
+	<-c.gate
+	return c.Core.Write(ent, fields)
+}
+
+func stopAsyncCore(t *testing.T, core *AsyncCore) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, core.Stop(ctx), "Unexpected error stopping AsyncCore.")
+}
+
+func asyncMessages(logs *observer.ObservedLogs) []string {
+	var msgs []string
+	for _, e := range logs.AllUntimed() {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+func TestAsyncCoreWrites(t *testing.T) {
+	obs, logs := observer.New(InfoLevel)
+	core := NewAsyncCore(obs)
+	defer stopAsyncCore(t, core)
+
+	assert.Equal(t, InfoLevel, LevelOf(core), "Unexpected level.")
+	assert.Nil(t, core.Check(Entry{Level: DebugLevel}, nil), "Expected debug to be disabled.")
+
+	child := core.With([]Field{makeInt64Field("k", 1)})
+	for i := 0; i < 3; i++ {
+		ce := child.Check(Entry{Level: InfoLevel, Message: fmt.Sprint(i)}, nil)
+		require.NotNil(t, ce, "Expected info to be enabled.")
+		ce.Write(makeInt64Field("i", i))
+	}
+
+	require.NoError(t, core.Sync(), "Unexpected error syncing.")
+	assert.Equal(t, []string{"0", "1", "2"}, asyncMessages(logs), "Unexpected messages.")
+	for i, e := range logs.AllUntimed() {
+		assert.Equal(t, []Field{makeInt64Field("k", 1), makeInt64Field("i", i)}, e.Context, "Unexpected context.")
+	}
+}
+
+func TestAsyncCoreCopiesFields(t *testing.T) {
+	obs, logs := observer.New(InfoLevel)
+	gated := newGatedCore(obs)
+	core := NewAsyncCore(gated)
+	defer stopAsyncCore(t, core)
+
+	buf := []byte("foo")
+	fields := []Field{{Key: "bytes", Type: ByteStringType, Interface: buf}}
+	require.NoError(t, core.Write(Entry{Level: InfoLevel}, fields), "Unexpected error writing.")
+
+	// Reuse the buffer and the field slice before the entry is written.
+	copy(buf, "bar")
+	fields[0].Key = "reused"
+
+	close(gated.gate)
+	require.NoError(t, core.Sync(), "Unexpected error syncing.")
+	require.Equal(t, 1, logs.Len(), "Expected exactly one entry.")
+	assert.Equal(t, map[string]interface{}{"bytes": "foo"}, logs.All()[0].ContextMap(), "Unexpected context.")
+}
+
+// mutableStringer is a fmt.Stringer whose output can change after it's
+// logged.
+type mutableStringer struct{ s string }
+
+func (m *mutableStringer) String() string { return m.s }
+
+func TestAsyncCoreSnapshotsMarshalers(t *testing.T) {
+	obs, logs := observer.New(InfoLevel)
+	gated := newGatedCore(obs)
+	core := NewAsyncCore(gated)
+	defer stopAsyncCore(t, core)
+
+	ints := []int{1, 2, 3}
+	attrs := map[string]string{"k": "v"}
+	str := &mutableStringer{"foo"}
+	fields := []Field{
+		{Key: "array", Type: ArrayMarshalerType, Interface: ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+			for _, i := range ints {
+				enc.AppendInt(i)
+			}
+			return nil
+		})},
+		{Key: "object", Type: ObjectMarshalerType, Interface: ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+			for k, v := range attrs {
+				enc.AddString(k, v)
+			}
+			return nil
+		})},
+		{Key: "stringer", Type: StringerType, Interface: str},
+		{Key: "reflect", Type: ReflectType, Interface: ints},
+	}
+	require.NoError(t, core.Write(Entry{Level: InfoLevel}, fields), "Unexpected error writing.")
+
+	// Change the marshaled values before the entry is written.
+	ints[0] = 42
+	attrs["k"] = "changed"
+	str.s = "changed"
+
+	close(gated.gate)
+	require.NoError(t, core.Sync(), "Unexpected error syncing.")
+	require.Equal(t, 1, logs.Len(), "Expected exactly one entry.")
+	assert.Equal(t, map[string]interface{}{
+		"array":    []interface{}{1, 2, 3},
+		"object":   map[string]interface{}{"k": "v"},
+		"stringer": "foo",
+		"reflect":  json.RawMessage(`[1,2,3]`),
+	}, logs.All()[0].ContextMap(), "Expected the values at the time of the log call.")
+}
+
+func TestAsyncCoreDropPolicy(t *testing.T) {
+	tests := []struct {
+		desc        string
+		policy      AsyncDropPolicy
+		wantMsgs    []string
+		wantDropped []string
+	}{
+		{
+			desc:        "drop newest",
+			policy:      AsyncDropNewest,
+			wantMsgs:    []string{"0", "1", "2"},
+			wantDropped: []string{"3", "4"},
+		},
+		{
+			desc:        "drop oldest",
+			policy:      AsyncDropOldest,
+			wantMsgs:    []string{"0", "3", "4"},
+			wantDropped: []string{"1", "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			obs, logs := observer.New(InfoLevel)
+			gated := newGatedCore(obs)
+
+			var dropped []string
+			core := NewAsyncCore(gated,
+				AsyncQueueSize(2),
+				AsyncPolicy(tt.policy),
+				AsyncOnDrop(func(ent Entry) { dropped = append(dropped, ent.Message) }),
+			)
+			defer stopAsyncCore(t, core)
+
+			// Block the writer goroutine on the first entry, then fill
+			// the queue and overflow it.
+			require.NoError(t, core.Write(Entry{Level: InfoLevel, Message: "0"}, nil))
+			<-gated.started
+			for i := 1; i < 5; i++ {
+				require.NoError(t, core.Write(Entry{Level: InfoLevel, Message: fmt.Sprint(i)}, nil))
+			}
+
+			assert.Equal(t, tt.wantDropped, dropped, "Unexpected dropped entries.")
+			assert.Equal(t, uint64(len(tt.wantDropped)), core.Dropped(), "Unexpected drop count.")
+
+			close(gated.gate)
+			require.NoError(t, core.Sync(), "Unexpected error syncing.")
+			assert.Equal(t, tt.wantMsgs, asyncMessages(logs), "Unexpected messages.")
+		})
+	}
+
+	t.Run("block", func(t *testing.T) {
+		obs, logs := observer.New(InfoLevel)
+		gated := newGatedCore(obs)
+		core := NewAsyncCore(gated, AsyncQueueSize(2), AsyncPolicy(AsyncBlock))
+		defer stopAsyncCore(t, core)
+
+		require.NoError(t, core.Write(Entry{Level: InfoLevel, Message: "0"}, nil))
+		<-gated.started
+		for i := 1; i < 3; i++ {
+			require.NoError(t, core.Write(Entry{Level: InfoLevel, Message: fmt.Sprint(i)}, nil))
+		}
+
+		written := make(chan struct{})
+		go func() {
+			defer close(written)
+			assert.NoError(t, core.Write(Entry{Level: InfoLevel, Message: "3"}, nil))
+		}()
+
+		select {
+		case <-written:
+			t.Fatal("Expected Write to block while the queue is full.")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		close(gated.gate)
+		<-written
+		require.NoError(t, core.Sync(), "Unexpected error syncing.")
+		assert.Equal(t, []string{"0", "1", "2", "3"}, asyncMessages(logs), "Unexpected messages.")
+		assert.Zero(t, core.Dropped(), "Expected no entries to be dropped.")
+	})
+}
+
+func TestAsyncCoreStop(t *testing.T) {
+	t.Run("deadline", func(t *testing.T) {
+		obs, logs := observer.New(InfoLevel)
+		gated := newGatedCore(obs)
+		core := NewAsyncCore(gated)
+
+		require.NoError(t, core.Write(Entry{Level: InfoLevel, Message: "0"}, nil))
+		require.NoError(t, core.Write(Entry{Level: InfoLevel, Message: "1"}, nil))
+		<-gated.started
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, core.Stop(ctx), context.Canceled, "Expected Stop to time out.")
+
+		close(gated.gate)
+		stopAsyncCore(t, core)
+		assert.Equal(t, []string{"0", "1"}, asyncMessages(logs), "Expected queued entries to be drained.")
+	})
+
+	t.Run("write after stop", func(t *testing.T) {
+		obs, logs := observer.New(InfoLevel)
+		core := NewAsyncCore(obs)
+		stopAsyncCore(t, core)
+
+		require.NoError(t, core.Write(Entry{Level: InfoLevel, Message: "late"}, nil))
+		assert.Equal(t, []string{"late"}, asyncMessages(logs), "Expected a synchronous write.")
+		assert.NoError(t, core.Sync(), "Unexpected error syncing a stopped core.")
+	})
+}
+
+func TestAsyncCoreSyncTimeout(t *testing.T) {
+	obs, _ := observer.New(InfoLevel)
+	gated := newGatedCore(obs)
+	core := NewAsyncCore(gated, AsyncSyncTimeout(time.Millisecond))
+	defer stopAsyncCore(t, core)
+
+	require.NoError(t, core.Write(Entry{Level: InfoLevel}, nil))
+	<-gated.started
+	assert.ErrorIs(t, core.Sync(), context.DeadlineExceeded, "Expected Sync to time out.")
+	close(gated.gate)
+}
+
+func TestAsyncCoreWritesPanicsSynchronously(t *testing.T) {
+	for _, lvl := range []Level{DPanicLevel, PanicLevel} {
+		t.Run(lvl.String(), func(t *testing.T) {
+			obs, logs := observer.New(InfoLevel)
+			core := NewAsyncCore(obs)
+			defer stopAsyncCore(t, core)
+
+			require.NoError(t, core.Write(Entry{Level: InfoLevel, Message: "before"}, nil))
+			require.NoError(t, core.Write(Entry{Level: lvl, Message: "panic"}, nil))
+			assert.Equal(t, []string{"before", "panic"}, asyncMessages(logs), "Unexpected messages.")
+		})
+	}
+}
+
+func TestAsyncCoreWriteErrors(t *testing.T) {
+	errFail := errors.New("fail")
+	obs, _ := observer.New(InfoLevel)
+	core := NewAsyncCore(&failingCore{Core: obs, err: errFail})
+	defer stopAsyncCore(t, core)
+
+	require.NoError(t, core.Write(Entry{Level: InfoLevel}, nil), "Expected errors to be deferred.")
+	assert.ErrorIs(t, core.Sync(), errFail, "Expected Sync to report write errors.")
+	assert.NoError(t, core.Sync(), "Expected errors to be reported once.")
+}
+
+type failingCore struct {
+	Core
+
+	err error
+}
+
+func (c *failingCore) Write(Entry, []Field) error { return c.err }
@@ -100,13 +100,16 @@ type BufferedWriteSyncer struct {
 	Clock Clock
 
 	// unexported fields for state
-	mu          sync.Mutex
-	initialized bool // whether initialize() has run
-	stopped     bool // whether Stop() has run
-	writer      *bufio.Writer
-	ticker      *time.Ticker
-	stop        chan struct{} // closed when flushLoop should stop
-	done        chan struct{} // closed when flushLoop has stopped
+	mu            sync.Mutex
+	initialized   bool // whether initialize() has run
+	stopped       bool // whether Stop() has run
+	running       bool // whether flushLoop is running
+	writer        *bufio.Writer
+	flushInterval time.Duration
+	flushErr      error // error from the last background flush
+	ticker        *time.Ticker
+	stop          chan struct{} // closed when flushLoop should stop
+	done          chan struct{} // closed when flushLoop has stopped
 }
 
 func (s *BufferedWriteSyncer) initialize() {
@@ -124,16 +127,29 @@ func (s *BufferedWriteSyncer) initialize() {
 		s.Clock = DefaultClock
 	}
 
-	s.ticker = s.Clock.NewTicker(flushInterval)
+	s.flushInterval = flushInterval
 	s.writer = bufio.NewWriterSize(s.WS, size)
+	s.initialized = true
+}
+
+// startFlushLoop starts the background flush goroutine if it isn't already
+// running. It must be called with s.mu held.
+func (s *BufferedWriteSyncer) startFlushLoop() {
+	if s.running || s.stopped {
+		return
+	}
+
+	s.ticker = s.Clock.NewTicker(s.flushInterval)
 	s.stop = make(chan struct{})
 	s.done = make(chan struct{})
-	s.initialized = true
-	go s.flushLoop()
+	s.running = true
+	go s.flushLoop(s.ticker, s.stop, s.done)
 }
 
 // Write writes log data into buffer syncer directly, multiple Write calls will be batched,
 // and log data will be flushed to disk when the buffer is full or periodically.
+//
+// If the last periodic flush failed, Write reports its error.
 func (s *BufferedWriteSyncer) Write(bs []byte) (int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -151,14 +167,26 @@ func (s *BufferedWriteSyncer) Write(bs []byte) (int, error) {
 		}
 	}
 
-	return s.writer.Write(bs)
+	n, err := s.writer.Write(bs)
+	if s.writer.Buffered() > 0 {
+		s.startFlushLoop()
+	}
+	return n, multierr.Append(s.takeFlushErr(), err)
 }
 
 // Sync flushes buffered log data into disk directly.
+//
+// If the last periodic flush failed, Sync reports its error.
 func (s *BufferedWriteSyncer) Sync() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return multierr.Append(s.takeFlushErr(), s.sync())
+}
+
+// sync flushes the buffer and syncs the wrapped WriteSyncer. It must be
+// called with s.mu held.
+func (s *BufferedWriteSyncer) sync() error {
 	var err error
 	if s.initialized {
 		err = s.writer.Flush()
@@ -167,44 +195,76 @@ func (s *BufferedWriteSyncer) Sync() error {
 	return multierr.Append(err, s.WS.Sync())
 }
 
+func (s *BufferedWriteSyncer) takeFlushErr() error {
+	err := s.flushErr
+	s.flushErr = nil
+	return err
+}
+
 // flushLoop flushes the buffer at the configured interval until Stop is
-// called.
-func (s *BufferedWriteSyncer) flushLoop() {
-	defer close(s.done)
+// called, or until a tick finds the buffer empty. Write restarts it once
+// there's data to flush again, so idle writers don't hold on to a goroutine.
+func (s *BufferedWriteSyncer) flushLoop(ticker *time.Ticker, stop, done chan struct{}) {
+	defer close(done)
 
 	for {
 		select {
-		case <-s.ticker.C:
-			// we just simply ignore error here
-			// because the underlying bufio writer stores any errors
-			// and we return any error from Sync() as part of the close
-			_ = s.Sync()
-		case <-s.stop:
+		case <-ticker.C:
+			if s.flushTick(ticker) {
+				return
+			}
+		case <-stop:
 			return
 		}
 	}
 }
 
+// flushTick runs a periodic flush and reports whether flushLoop should exit.
+func (s *BufferedWriteSyncer) flushTick(ticker *time.Ticker) (exit bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return true
+	}
+
+	if s.writer.Buffered() == 0 {
+		ticker.Stop()
+		s.running = false
+		return true
+	}
+
+	// Hold on to the error so that the next caller sees it.
+	if err := s.sync(); err != nil {
+		s.flushErr = err
+	}
+	return false
+}
+
 // Stop closes the buffer, cleans up background goroutines, and flushes
 // remaining unwritten data.
 func (s *BufferedWriteSyncer) Stop() (err error) {
 	// Critical section.
-	stopped := func() bool {
+	stopped, done := func() (bool, chan struct{}) {
 		s.mu.Lock()
 		defer s.mu.Unlock()
 
 		if !s.initialized {
-			return false
+			return false, nil
 		}
 
 		if s.stopped {
-			return false
+			return false, nil
 		}
 		s.stopped = true
 
+		if !s.running {
+			return true, nil
+		}
+		s.running = false
 		s.ticker.Stop()
 		close(s.stop) // tell flushLoop to stop
-		return true
+		return true, s.done
 	}()
 
 	// Not initialized, or already stopped, no need for any cleanup.
@@ -214,7 +274,9 @@ func (s *BufferedWriteSyncer) Stop() (err error) {
 
 	// Wait for flushLoop to end outside of the lock, as it may need the lock to complete.
 	// See https://github.com/uber-go/zap/issues/1428 for details.
-	<-s.done
+	if done != nil {
+		<-done
+	}
 
 	return s.Sync()
 }
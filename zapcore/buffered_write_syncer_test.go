@@ -22,6 +22,7 @@ package zapcore
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 	"time"
 
@@ -135,6 +136,49 @@ func TestBufferWriter(t *testing.T) {
 	})
 }
 
+func TestBufferWriterBackgroundFlush(t *testing.T) {
+	isRunning := func(ws *BufferedWriteSyncer) bool {
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		return ws.running
+	}
+
+	t.Run("idle flush loop exits", func(t *testing.T) {
+		buf := &ztest.Buffer{}
+		ws := &BufferedWriteSyncer{WS: buf, FlushInterval: time.Millisecond}
+
+		requireWriteWorks(t, ws)
+		assert.True(t, isRunning(ws), "Expected flush loop to start after a write.")
+		require.Eventually(t, func() bool { return !isRunning(ws) }, time.Second, time.Millisecond,
+			"Expected flush loop to exit once idle.")
+		assert.Equal(t, "foo", buf.String(), "Expected buffer to be flushed.")
+
+		requireWriteWorks(t, ws)
+		assert.True(t, isRunning(ws), "Expected flush loop to restart after a write.")
+		assert.NoError(t, ws.Stop())
+		assert.False(t, isRunning(ws), "Expected flush loop to stop.")
+		assert.Equal(t, "foofoo", buf.String(), "Unexpected log string")
+	})
+
+	t.Run("flush error reported to next caller", func(t *testing.T) {
+		errSync := errors.New("sync failed")
+		buf := &ztest.Buffer{}
+		buf.SetError(errSync)
+		ws := &BufferedWriteSyncer{WS: buf, FlushInterval: time.Millisecond}
+
+		requireWriteWorks(t, ws)
+		require.Eventually(t, func() bool {
+			ws.mu.Lock()
+			defer ws.mu.Unlock()
+			return ws.flushErr != nil
+		}, time.Second, time.Millisecond, "Expected a background flush to fail.")
+
+		_, err := ws.Write([]byte("bar"))
+		assert.ErrorIs(t, err, errSync, "Expected Write to report the background flush error.")
+		assert.ErrorIs(t, ws.Stop(), errSync, "Expected Stop to fail.")
+	})
+}
+
 func TestBufferWriterWithoutStart(t *testing.T) {
 	t.Run("stop", func(t *testing.T) {
 		ws := &BufferedWriteSyncer{WS: AddSync(new(bytes.Buffer))}
@@ -20,7 +20,10 @@
 
 package zapcore
 
-import "time"
+import (
+	"sync/atomic"
+	"time"
+)
 
 // DefaultClock is the default clock used by Zap in operations that require
 // time. This clock uses the system clock for all operations.
@@ -46,3 +49,52 @@ func (systemClock) Now() time.Time {
 func (systemClock) NewTicker(duration time.Duration) *time.Ticker {
 	return time.NewTicker(duration)
 }
+
+// ClockCopier is implemented by Cores that depend on the passage of time,
+// such as the Sampler, so that WithClock can give them a different Clock.
+// WithClock returns a copy of the Core that uses the given Clock, leaving
+// the Core it's called on unchanged.
+//
+// Cores that wrap other Cores should pass the Clock on to them with the
+// package-level WithClock.
+type ClockCopier interface {
+	WithClock(Clock) Core
+}
+
+// WithClock returns a copy of core that uses the given Clock, if it
+// implements ClockCopier, leaving core and the Cores derived from it
+// unchanged; zap.WithClock uses it. Other Cores are returned as they are.
+func WithClock(core Core, clock Clock) Core {
+	if cc, ok := core.(ClockCopier); ok {
+		return cc.WithClock(clock)
+	}
+	return core
+}
+
+// clockRef holds a Clock that may be replaced while it's in use. A nil
+// clockRef, or one that was never set, holds no Clock.
+type clockRef struct {
+	v atomic.Value // clockBox
+}
+
+// clockBox wraps Clocks so that every value stored in a clockRef has the
+// same concrete type.
+type clockBox struct{ Clock }
+
+func newClockRef(clock Clock) *clockRef {
+	r := new(clockRef)
+	r.Store(clock)
+	return r
+}
+
+func (r *clockRef) Load() Clock {
+	if r == nil {
+		return nil
+	}
+	box, _ := r.v.Load().(clockBox)
+	return box.Clock
+}
+
+func (r *clockRef) Store(clock Clock) {
+	r.v.Store(clockBox{clock})
+}
@@ -18,7 +18,7 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
-package ztest
+package clock
 
 import (
 	"sync/atomic"
@@ -28,9 +28,9 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestMockClock_NewTicker(t *testing.T) {
+func TestMock_NewTicker(t *testing.T) {
 	var n atomic.Int32
-	clock := NewMockClock()
+	clock := NewMock()
 
 	done := make(chan struct{})
 	defer func() { <-done }() // wait for end
@@ -56,8 +56,8 @@ func TestMockClock_NewTicker(t *testing.T) {
 	close(quit)
 }
 
-func TestMockClock_NewTicker_slowConsumer(t *testing.T) {
-	clock := NewMockClock()
+func TestMock_NewTicker_slowConsumer(t *testing.T) {
+	clock := NewMock()
 
 	ticker := clock.NewTicker(time.Microsecond)
 	defer ticker.Stop()
@@ -74,7 +74,16 @@ func TestMockClock_NewTicker_slowConsumer(t *testing.T) {
 	}
 }
 
-func TestMockClock_Add_negative(t *testing.T) {
-	clock := NewMockClock()
+func TestMock_Add_negative(t *testing.T) {
+	clock := NewMock()
 	assert.Panics(t, func() { clock.Add(-1) })
 }
+
+func TestMock_NewMockAt(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewMockAt(start)
+	assert.Equal(t, start, clock.Now())
+
+	clock.Add(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), clock.Now())
+}
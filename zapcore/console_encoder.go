@@ -17,17 +17,33 @@
 // LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
-
 package zapcore
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/toujourser/zap/buffer"
 	"github.com/toujourser/zap/internal/bufferpool"
+	"github.com/toujourser/zap/internal/color"
 	"github.com/toujourser/zap/internal/pool"
 )
 
+// _consoleKeyColor is the color of field keys when ConsoleConfig.ColorKeys
+// is set.
+const _consoleKeyColor = color.Cyan
+
+// _consoleStackIndent is prepended to each line of an indented stacktrace,
+// the keys of multi-line blocks, and wrapped fields.
+const _consoleStackIndent = "    "
+
+// _consoleBlockIndent is prepended to each line of a multi-line block.
+const _consoleBlockIndent = _consoleStackIndent + _consoleStackIndent
+
 var _sliceEncoderPool = pool.New(func() *sliceArrayEncoder {
 	return &sliceArrayEncoder{
 		elems: make([]interface{}, 0, 2),
@@ -35,22 +51,111 @@ var _sliceEncoderPool = pool.New(func() *sliceArrayEncoder {
 })
 
 func getSliceEncoder() *sliceArrayEncoder {
-	return _sliceEncoderPool.Get()
+	e := _sliceEncoderPool.Get()
+	e.columns = true
+	return e
 }
 
 func putSliceEncoder(e *sliceArrayEncoder) {
 	e.elems = e.elems[:0]
+	e.times = e.times[:0]
 	_sliceEncoderPool.Put(e)
 }
 
+// ColorMode controls whether the console encoder writes ANSI colors.
+type ColorMode string
+
+// The zero ColorMode disables the console encoder's colors, but leaves
+// those added by level encoders such as CapitalColorLevelEncoder.
+const (
+	// ColorNever disables colors, including those added by
+	// CapitalColorLevelEncoder and LowercaseColorLevelEncoder, which are
+	// replaced by their uncolored counterparts.
+	ColorNever ColorMode = "never"
+	// ColorAuto enables colors only if every output of the core the encoder
+	// is used with is a terminal. The NO_COLOR and FORCE_COLOR environment
+	// variables override the check: if NO_COLOR is set, colors are disabled,
+	// and otherwise, if FORCE_COLOR is set to anything but "0" or "false",
+	// they're enabled. When colors are disabled, level encoders are treated
+	// as they are with ColorNever. Encoders that aren't passed to NewCore
+	// never add colors of their own.
+	//
+	// WriteSyncers other than *os.File and the wrappers in this package can
+	// report whether they write to a terminal by implementing
+	//
+	//	IsTerminal() bool
+	ColorAuto ColorMode = "auto"
+	// ColorAlways enables colors regardless of the output.
+	ColorAlways ColorMode = "always"
+)
+
+// ConsoleConfig configures the layout of the console encoder. The zero value
+// keeps the console encoder's default output.
+type ConsoleConfig struct {
+	// Color controls ANSI colors. When enabled, the level is colored by
+	// severity. Level encoders that add their own colors, such as
+	// CapitalColorLevelEncoder, are left as-is unless ColorNever or
+	// ColorAuto disables colors.
+	Color ColorMode `json:"color" yaml:"color"`
+	// ColorMessage colors the message to match its level, and ColorKeys
+	// colors field keys. Both require Color to be enabled.
+	ColorMessage bool `json:"colorMessage" yaml:"colorMessage"`
+	ColorKeys    bool `json:"colorKeys" yaml:"colorKeys"`
+	// LevelWidth and NameWidth pad the level and logger name columns with
+	// spaces to at least the given number of characters, so that the
+	// following columns line up.
+	LevelWidth int `json:"levelWidth" yaml:"levelWidth"`
+	NameWidth  int `json:"nameWidth" yaml:"nameWidth"`
+	// KeyValueFields writes fields as space-separated key=value pairs, as
+	// the logfmt encoder does, instead of as a JSON object.
+	KeyValueFields bool `json:"keyValueFields" yaml:"keyValueFields"`
+	// IndentStacktrace indents each line of the stacktrace so that it
+	// stands apart from the entries around it.
+	IndentStacktrace bool `json:"indentStacktrace" yaml:"indentStacktrace"`
+	// MultilineBlocks writes string and error fields whose values span
+	// several lines, such as SQL queries or errors with verbose messages,
+	// as indented blocks beneath the entry rather than escaped on its line.
+	// Only fields passed to the logging call are affected; fields added
+	// with With are encoded when they're added.
+	MultilineBlocks bool `json:"multilineBlocks" yaml:"multilineBlocks"`
+	// WrapWidth, if positive, wraps the fields onto indented continuation
+	// lines so that no line is wider than WrapWidth characters. Fields are
+	// never split, so a line holding a single wide field may still exceed
+	// it, and tabs count as a single character.
+	WrapWidth int `json:"wrapWidth" yaml:"wrapWidth"`
+}
+
+// consoleContext accumulates the structured context of a console encoder.
+type consoleContext interface {
+	ObjectEncoder
+
+	// cloneContext copies the context, coloring keys added from now on with
+	// keyColor if it's non-zero.
+	cloneContext(keyColor color.Color) consoleContext
+	// writeContext writes the context and the given fields to line, with
+	// sep in front if anything was written. If blocks is non-nil, the
+	// fields are added through it.
+	writeContext(line *buffer.Buffer, sep string, fields []Field, blocks *blockEncoder)
+	// fieldSeparator returns the byte that separates fields in the written
+	// context, and the nesting depth of the separators between top-level
+	// fields.
+	fieldSeparator() (sep byte, depth int)
+}
+
 type consoleEncoder struct {
-	*jsonEncoder
+	consoleContext
+	*EncoderConfig
+
+	// color reports whether colors are enabled. It's resolved against the
+	// output for ColorAuto by NewCore.
+	color bool
 }
 
 // NewConsoleEncoder creates an encoder whose output is designed for human -
 // rather than machine - consumption. It serializes the core log entry data
 // (message, level, timestamp, etc.) in a plain-text format and leaves the
-// structured context as JSON.
+// structured context as JSON, or as key=value pairs if
+// ConsoleConfig.KeyValueFields is set.
 //
 // Note that although the console encoder doesn't use the keys specified in the
 // encoder configuration, it will omit any element whose key is set to the empty
@@ -60,11 +165,94 @@ func NewConsoleEncoder(cfg EncoderConfig) Encoder {
 		// Use a default delimiter of '\t' for backwards compatibility
 		cfg.ConsoleSeparator = "\t"
 	}
-	return consoleEncoder{newJSONEncoder(cfg, true)}
+
+	c := consoleEncoder{color: cfg.Console.Color == ColorAlways}
+	if plain, ok := uncoloredLevelEncoder(cfg.EncodeLevel); ok && cfg.Console.Color == ColorNever {
+		cfg.EncodeLevel = plain
+	}
+	// Share the context encoder's config, which has its defaults filled in.
+	if cfg.Console.KeyValueFields {
+		enc := newLogfmtEncoder(cfg)
+		c.EncoderConfig = enc.EncoderConfig
+		enc.keyColor = c.keyColor()
+		c.consoleContext = logfmtConsoleContext{enc}
+	} else {
+		enc := newJSONEncoder(cfg, true)
+		c.EncoderConfig = enc.EncoderConfig
+		enc.keyColor = c.keyColor()
+		c.consoleContext = jsonConsoleContext{enc}
+	}
+	return c
 }
 
 func (c consoleEncoder) Clone() Encoder {
-	return consoleEncoder{c.jsonEncoder.Clone().(*jsonEncoder)}
+	c.consoleContext = c.consoleContext.cloneContext(c.keyColor())
+	return c
+}
+
+// colorResolver is implemented by encoders whose colors depend on the output
+// they write to. NewCore uses it to resolve ColorAuto.
+type colorResolver interface {
+	resolveColor(WriteSyncer) Encoder
+}
+
+// resolveColor resolves ColorAuto against the output the encoder writes to.
+func (c consoleEncoder) resolveColor(ws WriteSyncer) Encoder {
+	if c.Console.Color != ColorAuto {
+		return c
+	}
+	c.color = colorFromEnv(isTerminal(ws))
+	if !c.color {
+		if plain, ok := uncoloredLevelEncoder(c.EncodeLevel); ok {
+			cfg := *c.EncoderConfig
+			cfg.EncodeLevel = plain
+			c.EncoderConfig = &cfg
+		}
+	}
+	return c.Clone()
+}
+
+// colorFromEnv decides whether ColorAuto enables colors, given whether the
+// output is a terminal.
+func colorFromEnv(terminal bool) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	switch os.Getenv("FORCE_COLOR") {
+	case "", "0", "false":
+		return terminal
+	default:
+		return true
+	}
+}
+
+var (
+	_capitalColorLevelEncoder   = reflect.ValueOf(CapitalColorLevelEncoder).Pointer()
+	_lowercaseColorLevelEncoder = reflect.ValueOf(LowercaseColorLevelEncoder).Pointer()
+)
+
+// uncoloredLevelEncoder returns the uncolored counterpart of the level
+// encoders in this package that add colors. It returns false for any other
+// encoder.
+func uncoloredLevelEncoder(e LevelEncoder) (LevelEncoder, bool) {
+	if e == nil {
+		return nil, false
+	}
+	switch reflect.ValueOf(e).Pointer() {
+	case _capitalColorLevelEncoder:
+		return CapitalLevelEncoder, true
+	case _lowercaseColorLevelEncoder:
+		return LowercaseLevelEncoder, true
+	default:
+		return nil, false
+	}
+}
+
+func (c consoleEncoder) keyColor() color.Color {
+	if c.color && c.Console.ColorKeys {
+		return _consoleKeyColor
+	}
+	return 0
 }
 
 func (c consoleEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer, error) {
@@ -80,9 +268,12 @@ func (c consoleEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer,
 	if c.TimeKey != "" && c.EncodeTime != nil && !ent.Time.IsZero() {
 		c.EncodeTime(ent.Time, arr)
 	}
+	levelStart, levelEnd := len(arr.elems), len(arr.elems)
 	if c.LevelKey != "" && c.EncodeLevel != nil {
 		c.EncodeLevel(ent.Level, arr)
+		levelEnd = len(arr.elems)
 	}
+	nameStart, nameEnd := len(arr.elems), len(arr.elems)
 	if ent.LoggerName != "" && c.NameKey != "" {
 		nameEncoder := c.EncodeName
 
@@ -92,6 +283,7 @@ func (c consoleEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer,
 		}
 
 		nameEncoder(ent.LoggerName, arr)
+		nameEnd = len(arr.elems)
 	}
 	if ent.Caller.Defined {
 		if c.CallerKey != "" && c.EncodeCaller != nil {
@@ -105,32 +297,231 @@ func (c consoleEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer,
 		if i > 0 {
 			line.AppendString(c.ConsoleSeparator)
 		}
-		_, _ = fmt.Fprint(line, arr.elems[i])
+		if lt, ok := arr.elems[i].(*layoutTime); ok {
+			line.AppendTime(lt.t, lt.layout)
+			continue
+		}
+		switch {
+		case i >= levelStart && i < levelEnd && (c.color || c.Console.LevelWidth > 0):
+			c.appendLevelColumn(line, fmt.Sprint(arr.elems[i]), ent.Level)
+		case i >= nameStart && i < nameEnd && c.Console.NameWidth > 0:
+			appendPadded(line, fmt.Sprint(arr.elems[i]), c.Console.NameWidth)
+		default:
+			_, _ = fmt.Fprint(line, arr.elems[i])
+		}
 	}
 	putSliceEncoder(arr)
 
 	// Add the message itself.
 	if c.MessageKey != "" {
 		c.addSeparatorIfNecessary(line)
-		line.AppendString(ent.Message)
+		if c.color && c.Console.ColorMessage {
+			appendColorStart(line, levelColor(ent.Level))
+			line.AppendString(ent.Message)
+			appendColorEnd(line)
+		} else {
+			line.AppendString(ent.Message)
+		}
 	}
 
 	// Add any structured context.
-	c.writeContext(line, fields)
+	sep := ""
+	if line.Len() > 0 {
+		sep = c.ConsoleSeparator
+	}
+	var blocks *blockEncoder
+	if c.Console.MultilineBlocks {
+		blocks = &blockEncoder{}
+	}
+	contextStart := line.Len()
+	c.writeContext(line, sep, fields, blocks)
+	if c.Console.WrapWidth > 0 && line.Len() > contextStart {
+		c.wrapContext(line, contextStart+len(sep))
+	}
+	if blocks != nil {
+		c.appendBlocks(line, blocks.blocks)
+	}
 
 	// If there's no stacktrace key, honor that; this allows users to force
 	// single-line output.
 	if ent.Stack != "" && c.StacktraceKey != "" {
 		line.AppendByte('\n')
-		line.AppendString(ent.Stack)
+		if c.Console.IndentStacktrace {
+			appendIndented(line, ent.Stack, _consoleStackIndent)
+		} else {
+			line.AppendString(ent.Stack)
+		}
 	}
 
 	line.AppendString(c.LineEnding)
 	return line, nil
 }
 
-func (c consoleEncoder) writeContext(line *buffer.Buffer, extra []Field) {
-	context := c.jsonEncoder.Clone().(*jsonEncoder)
+// appendLevelColumn writes an encoded level, padded to LevelWidth and
+// colored by severity if colors are enabled.
+func (c consoleEncoder) appendLevelColumn(line *buffer.Buffer, s string, lvl Level) {
+	// Don't color levels that the level encoder has already colored.
+	colored := c.color && !strings.Contains(s, "\x1b[")
+	if colored {
+		appendColorStart(line, levelColor(lvl))
+	}
+	appendPadded(line, s, c.Console.LevelWidth)
+	if colored {
+		appendColorEnd(line)
+	}
+}
+
+// wrapContext rewraps the context written to line from start on, breaking
+// lines between fields to keep them within WrapWidth.
+func (c consoleEncoder) wrapContext(line *buffer.Buffer, start int) {
+	context := bufferpool.Get()
+	defer context.Free()
+	context.Write(line.Bytes()[start:])
+	line.Truncate(start)
+
+	col := visibleWidth(string(line.Bytes()[bytes.LastIndexByte(line.Bytes(), '\n')+1:]))
+	sep, depth := c.fieldSeparator()
+	for i, field := range splitFields(context.String(), sep, depth) {
+		if i > 0 {
+			if sep != ' ' {
+				line.AppendByte(sep)
+				col++
+			}
+			trimmed := strings.TrimLeft(field, " ")
+			if width := visibleWidth(trimmed); col+width+1 > c.Console.WrapWidth {
+				line.AppendByte('\n')
+				line.AppendString(_consoleStackIndent)
+				col = len(_consoleStackIndent)
+				field = trimmed
+			} else if sep == ' ' {
+				line.AppendByte(' ')
+				col++
+			}
+		}
+		line.AppendString(field)
+		col += visibleWidth(field)
+	}
+}
+
+// splitFields splits an encoded context into its top-level fields at each
+// occurrence of sep at the given nesting depth, skipping quoted strings and
+// ANSI color sequences. The separators are dropped.
+func splitFields(s string, sep byte, depth int) []string {
+	var (
+		fields   []string
+		start    int
+		level    int
+		inString bool
+	)
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; {
+		case b == '\x1b':
+			if end := strings.IndexByte(s[i:], 'm'); end > 0 {
+				i += end
+			}
+		case inString:
+			if b == '\\' {
+				i++
+			} else if b == '"' {
+				inString = false
+			}
+		case b == '"':
+			inString = true
+		case b == '{' || b == '[':
+			level++
+		case b == '}' || b == ']':
+			level--
+		case b == sep && level == depth:
+			fields = append(fields, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(fields, s[start:])
+}
+
+// appendBlocks writes the multi-line fields diverted by MultilineBlocks
+// beneath the entry.
+func (c consoleEncoder) appendBlocks(line *buffer.Buffer, blocks []consoleBlock) {
+	keyColor := c.keyColor()
+	for _, b := range blocks {
+		line.AppendByte('\n')
+		line.AppendString(_consoleStackIndent)
+		if keyColor != 0 {
+			appendColorStart(line, keyColor)
+			line.AppendString(b.key)
+			appendColorEnd(line)
+		} else {
+			line.AppendString(b.key)
+		}
+		line.AppendString(":\n")
+		appendIndented(line, strings.TrimSuffix(b.value, "\n"), _consoleBlockIndent)
+	}
+}
+
+// consoleBlock is a multi-line field written beneath an entry.
+type consoleBlock struct {
+	key, value string
+}
+
+// blockEncoder wraps the ObjectEncoder that fields are added to, diverting
+// multi-line strings into blocks.
+type blockEncoder struct {
+	ObjectEncoder
+
+	prefix string // namespaces opened so far, joined by periods
+	blocks []consoleBlock
+}
+
+func (b *blockEncoder) AddString(key, value string) {
+	if strings.IndexByte(value, '\n') >= 0 {
+		b.blocks = append(b.blocks, consoleBlock{b.prefix + key, value})
+		return
+	}
+	b.ObjectEncoder.AddString(key, value)
+}
+
+func (b *blockEncoder) AddByteString(key string, value []byte) {
+	if bytes.IndexByte(value, '\n') >= 0 {
+		b.blocks = append(b.blocks, consoleBlock{b.prefix + key, string(value)})
+		return
+	}
+	b.ObjectEncoder.AddByteString(key, value)
+}
+
+func (b *blockEncoder) OpenNamespace(key string) {
+	b.prefix += key + "."
+	b.ObjectEncoder.OpenNamespace(key)
+}
+
+func (b *blockEncoder) revealSecrets() bool {
+	return shouldRevealSecrets(b.ObjectEncoder)
+}
+
+func (b *blockEncoder) reportPanics() bool {
+	return shouldReportPanics(b.ObjectEncoder)
+}
+
+func (c consoleEncoder) addSeparatorIfNecessary(line *buffer.Buffer) {
+	if line.Len() > 0 {
+		line.AppendString(c.ConsoleSeparator)
+	}
+}
+
+// jsonConsoleContext writes a console encoder's context as a JSON object.
+type jsonConsoleContext struct {
+	*jsonEncoder
+}
+
+func (j jsonConsoleContext) cloneContext(keyColor color.Color) consoleContext {
+	clone := j.jsonEncoder.Clone().(*jsonEncoder)
+	clone.keyColor = keyColor
+	return jsonConsoleContext{clone}
+}
+
+func (jsonConsoleContext) fieldSeparator() (byte, int) { return ',', 1 }
+
+func (j jsonConsoleContext) writeContext(line *buffer.Buffer, sep string, extra []Field, blocks *blockEncoder) {
+	context := j.jsonEncoder.Clone().(*jsonEncoder)
 	defer func() {
 		// putJSONEncoder assumes the buffer is still used, but we write out the buffer so
 		// we can free it.
@@ -138,20 +529,140 @@ func (c consoleEncoder) writeContext(line *buffer.Buffer, extra []Field) {
 		putJSONEncoder(context)
 	}()
 
-	addFields(context, extra)
+	addContextFields(context, extra, blocks)
 	context.closeOpenNamespaces()
 	if context.buf.Len() == 0 {
 		return
 	}
 
-	c.addSeparatorIfNecessary(line)
+	line.AppendString(sep)
 	line.AppendByte('{')
 	line.Write(context.buf.Bytes())
 	line.AppendByte('}')
 }
 
-func (c consoleEncoder) addSeparatorIfNecessary(line *buffer.Buffer) {
-	if line.Len() > 0 {
-		line.AppendString(c.ConsoleSeparator)
+// logfmtConsoleContext writes a console encoder's context as key=value
+// pairs.
+type logfmtConsoleContext struct {
+	*logfmtEncoder
+}
+
+func (l logfmtConsoleContext) cloneContext(keyColor color.Color) consoleContext {
+	clone := l.logfmtEncoder.Clone().(*logfmtEncoder)
+	clone.keyColor = keyColor
+	return logfmtConsoleContext{clone}
+}
+
+func (logfmtConsoleContext) fieldSeparator() (byte, int) { return ' ', 0 }
+
+func (l logfmtConsoleContext) writeContext(line *buffer.Buffer, sep string, extra []Field, blocks *blockEncoder) {
+	context := l.logfmtEncoder.Clone().(*logfmtEncoder)
+	defer func() {
+		context.buf.Free()
+		putLogfmtEncoder(context)
+	}()
+
+	addContextFields(context, extra, blocks)
+	if context.buf.Len() == 0 {
+		return
+	}
+
+	line.AppendString(sep)
+	line.Write(context.buf.Bytes())
+}
+
+// addContextFields adds fields to a console encoder's context, through
+// blocks if it's non-nil.
+func addContextFields(context ObjectEncoder, fields []Field, blocks *blockEncoder) {
+	if blocks == nil {
+		addFields(context, fields)
+		return
+	}
+	blocks.ObjectEncoder = context
+	addFields(blocks, fields)
+}
+
+// isTerminal reports whether everything written to ws ends up in a
+// terminal.
+func isTerminal(ws WriteSyncer) bool {
+	switch w := ws.(type) {
+	case *os.File:
+		fi, err := w.Stat()
+		return err == nil && fi.Mode()&os.ModeCharDevice != 0
+	case *lockedWriteSyncer:
+		return isTerminal(w.ws)
+	case *BufferedWriteSyncer:
+		return isTerminal(w.WS)
+	case multiWriteSyncer:
+		for _, ws := range w {
+			if !isTerminal(ws) {
+				return false
+			}
+		}
+		return len(w) > 0
+	case interface{ IsTerminal() bool }:
+		return w.IsTerminal()
+	default:
+		return false
+	}
+}
+
+func levelColor(lvl Level) color.Color {
+	if c, ok := _levelToColor[lvl]; ok {
+		return c
+	}
+	return _unknownLevelColor
+}
+
+func appendColorStart(buf *buffer.Buffer, c color.Color) {
+	buf.AppendString("\x1b[")
+	buf.AppendUint(uint64(c))
+	buf.AppendByte('m')
+}
+
+func appendColorEnd(buf *buffer.Buffer) {
+	buf.AppendString("\x1b[0m")
+}
+
+// appendPadded writes s followed by enough spaces for it to take up at least
+// width characters, not counting ANSI escape sequences.
+func appendPadded(buf *buffer.Buffer, s string, width int) {
+	buf.AppendString(s)
+	for n := visibleWidth(s); n < width; n++ {
+		buf.AppendByte(' ')
+	}
+}
+
+// visibleWidth returns the number of characters in s, skipping ANSI color
+// sequences.
+func visibleWidth(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '[' {
+			end := strings.IndexByte(s[i:], 'm')
+			if end < 0 {
+				break
+			}
+			i += end + 1
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n++
+	}
+	return n
+}
+
+// appendIndented writes each line of s prefixed with indent.
+func appendIndented(buf *buffer.Buffer, s, indent string) {
+	for {
+		buf.AppendString(indent)
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			buf.AppendString(s)
+			return
+		}
+		buf.AppendString(s[:i+1])
+		s = s[i+1:]
 	}
 }
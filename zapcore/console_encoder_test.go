@@ -20,10 +20,16 @@
 package zapcore_test
 
 import (
+	"encoding/json"
+	"io"
+	"net"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap/internal/ztest"
 	//revive:disable:dot-imports
 	. "github.com/toujourser/zap/zapcore"
 )
@@ -133,3 +139,313 @@ func encoderTestEncoderConfig(separator string) EncoderConfig {
 	testEncoder.ConsoleSeparator = separator
 	return testEncoder
 }
+
+func TestConsoleEncoderLayout(t *testing.T) {
+	const (
+		blue    = "\x1b[34m"
+		yellow  = "\x1b[33m"
+		cyan    = "\x1b[36m"
+		reset   = "\x1b[0m"
+		noStack = ""
+	)
+
+	entry := func(lvl Level, stack string) Entry {
+		ent := testEntry
+		ent.Level = lvl
+		ent.Stack = stack
+		ent.Caller = EntryCaller{}
+		return ent
+	}
+	fields := []Field{
+		{Key: "user", Type: StringType, String: "alice"},
+		{Key: "n", Type: Int64Type, Integer: 1},
+	}
+
+	tests := []struct {
+		desc    string
+		console ConsoleConfig
+		encode  func(*EncoderConfig)
+		ent     Entry
+		want    string
+	}{
+		{
+			desc: "default",
+			ent:  entry(InfoLevel, noStack),
+			want: "0\tinfo\tmain\thello\t" + `{"user": "alice", "n": 1}` + "\n",
+		},
+		{
+			desc:    "column widths",
+			console: ConsoleConfig{LevelWidth: 5, NameWidth: 6},
+			ent:     entry(InfoLevel, noStack),
+			want:    "0\tinfo \tmain  \thello\t" + `{"user": "alice", "n": 1}` + "\n",
+		},
+		{
+			desc:    "column widths exceeded",
+			console: ConsoleConfig{LevelWidth: 2, NameWidth: 2},
+			ent:     entry(WarnLevel, noStack),
+			want:    "0\twarn\tmain\thello\t" + `{"user": "alice", "n": 1}` + "\n",
+		},
+		{
+			desc:    "key=value fields",
+			console: ConsoleConfig{KeyValueFields: true},
+			ent:     entry(InfoLevel, noStack),
+			want:    "0\tinfo\tmain\thello\tuser=alice n=1\n",
+		},
+		{
+			desc:    "level color",
+			console: ConsoleConfig{Color: ColorAlways, LevelWidth: 5},
+			ent:     entry(InfoLevel, noStack),
+			want:    "0\t" + blue + "info " + reset + "\tmain\thello\t" + `{"user": "alice", "n": 1}` + "\n",
+		},
+		{
+			desc:    "message and key colors",
+			console: ConsoleConfig{Color: ColorAlways, ColorMessage: true, ColorKeys: true},
+			ent:     entry(WarnLevel, noStack),
+			want: "0\t" + yellow + "warn" + reset + "\tmain\t" + yellow + "hello" + reset + "\t" +
+				`{` + cyan + `"user"` + reset + `: "alice", ` + cyan + `"n"` + reset + `: 1}` + "\n",
+		},
+		{
+			desc:    "key=value key colors",
+			console: ConsoleConfig{Color: ColorAlways, ColorKeys: true, KeyValueFields: true},
+			ent:     entry(InfoLevel, noStack),
+			want:    "0\t" + blue + "info" + reset + "\tmain\thello\t" + cyan + "user" + reset + "=alice " + cyan + "n" + reset + "=1\n",
+		},
+		{
+			desc:    "colors require Color",
+			console: ConsoleConfig{Color: ColorNever, ColorMessage: true, ColorKeys: true},
+			ent:     entry(InfoLevel, noStack),
+			want:    "0\tinfo\tmain\thello\t" + `{"user": "alice", "n": 1}` + "\n",
+		},
+		{
+			desc:    "colored level encoder",
+			console: ConsoleConfig{Color: ColorAlways, LevelWidth: 6},
+			encode:  func(cfg *EncoderConfig) { cfg.EncodeLevel = CapitalColorLevelEncoder },
+			ent:     entry(InfoLevel, noStack),
+			want:    "0\t" + blue + "INFO" + reset + "  \tmain\thello\t" + `{"user": "alice", "n": 1}` + "\n",
+		},
+		{
+			desc:    "indented stacktrace",
+			console: ConsoleConfig{IndentStacktrace: true},
+			ent:     entry(ErrorLevel, "main.main()\n\tmain.go:1"),
+			want:    "0\terror\tmain\thello\t" + `{"user": "alice", "n": 1}` + "\n    main.main()\n    \tmain.go:1\n",
+		},
+		{
+			desc: "stacktrace",
+			ent:  entry(ErrorLevel, "main.main()\n\tmain.go:1"),
+			want: "0\terror\tmain\thello\t" + `{"user": "alice", "n": 1}` + "\nmain.main()\n\tmain.go:1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			cfg := testEncoderConfig()
+			cfg.Console = tt.console
+			if tt.encode != nil {
+				tt.encode(&cfg)
+			}
+
+			buf, err := NewConsoleEncoder(cfg).EncodeEntry(tt.ent, fields)
+			require.NoError(t, err, "Unexpected console encoding error.")
+			assert.Equal(t, tt.want, buf.String(), "Unexpected console output.")
+			buf.Free()
+		})
+	}
+}
+
+func TestConsoleEncoderContext(t *testing.T) {
+	tests := []struct {
+		desc    string
+		console ConsoleConfig
+		flatten bool
+		want    string
+	}{
+		{
+			desc: "JSON",
+			want: `hello	{"svc": "api", "req": {"id": 7, "ok": true}}` + "\n",
+		},
+		{
+			desc:    "JSON flattened namespaces",
+			flatten: true,
+			want:    `hello	{"svc": "api", "req.id": 7, "req.ok": true}` + "\n",
+		},
+		{
+			desc:    "key=value",
+			console: ConsoleConfig{KeyValueFields: true},
+			want:    "hello\tsvc=api req.id=7 req.ok=true\n",
+		},
+		{
+			desc:    "key=value colors",
+			console: ConsoleConfig{KeyValueFields: true, Color: ColorAlways, ColorKeys: true},
+			want:    "hello\t\x1b[36msvc\x1b[0m=api \x1b[36mreq.id\x1b[0m=7 \x1b[36mreq.ok\x1b[0m=true\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			cfg := EncoderConfig{MessageKey: "msg", Console: tt.console, FlattenNamespaces: tt.flatten}
+			enc := NewConsoleEncoder(cfg)
+			enc.AddString("svc", "api")
+			enc.OpenNamespace("req")
+			enc.AddInt("id", 7)
+
+			buf, err := enc.Clone().EncodeEntry(Entry{Message: "hello"}, []Field{
+				{Key: "ok", Type: BoolType, Integer: 1},
+			})
+			require.NoError(t, err, "Unexpected console encoding error.")
+			assert.Equal(t, tt.want, buf.String(), "Unexpected console output.")
+			buf.Free()
+		})
+	}
+}
+
+func TestConsoleEncoderRawJSON(t *testing.T) {
+	fields := []Field{
+		{Key: "raw", Type: RawJSONType, Interface: json.RawMessage(`{"a":[1,2]}`)},
+		{Key: "ip", Type: TextMarshalerType, Interface: net.ParseIP("10.0.0.1")},
+	}
+	tests := []struct {
+		desc    string
+		console ConsoleConfig
+		want    string
+	}{
+		{
+			desc: "JSON",
+			want: `hello	{"raw": {"a":[1,2]}, "ip": "10.0.0.1"}` + "\n",
+		},
+		{
+			desc:    "key=value",
+			console: ConsoleConfig{KeyValueFields: true},
+			want:    `hello	raw="{\"a\":[1,2]}" ip=10.0.0.1` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := NewConsoleEncoder(EncoderConfig{MessageKey: "msg", Console: tt.console})
+			buf, err := enc.EncodeEntry(Entry{Message: "hello"}, fields)
+			require.NoError(t, err, "Unexpected console encoding error.")
+			assert.Equal(t, tt.want, buf.String(), "Unexpected console output.")
+			buf.Free()
+		})
+	}
+}
+
+func TestConsoleEncoderAutoColor(t *testing.T) {
+	newConfig := func(mode ColorMode) EncoderConfig {
+		return EncoderConfig{
+			MessageKey:  "msg",
+			LevelKey:    "level",
+			EncodeLevel: LowercaseLevelEncoder,
+			Console:     ConsoleConfig{Color: mode, ColorMessage: true},
+		}
+	}
+	const (
+		plain   = "info\thello\n"
+		colored = "\x1b[34minfo\x1b[0m\t\x1b[34mhello\x1b[0m\n"
+	)
+	// Don't let the environment running the tests leak in.
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+
+	t.Run("buffer", func(t *testing.T) {
+		tests := []struct {
+			mode ColorMode
+			want string
+		}{
+			{ColorNever, plain},
+			{ColorAuto, plain},
+			{ColorAlways, colored},
+		}
+
+		for _, tt := range tests {
+			buf := &ztest.Buffer{}
+			core := NewCore(NewConsoleEncoder(newConfig(tt.mode)), Lock(buf), DebugLevel)
+			require.NoError(t, core.Write(Entry{Level: InfoLevel, Message: "hello"}, nil), "Unexpected write error.")
+			assert.Equal(t, tt.want, buf.String(), "Unexpected output for color mode %q.", tt.mode)
+		}
+	})
+
+	t.Run("pipe", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		require.NoError(t, err, "Failed to create pipe.")
+		defer r.Close()
+
+		core := NewCore(NewConsoleEncoder(newConfig(ColorAuto)), w, DebugLevel)
+		require.NoError(t, core.Write(Entry{Level: InfoLevel, Message: "hello"}, nil), "Unexpected write error.")
+		require.NoError(t, w.Close(), "Failed to close pipe.")
+
+		out, err := io.ReadAll(r)
+		require.NoError(t, err, "Failed to read from pipe.")
+		assert.Equal(t, plain, string(out), "Expected no colors when writing to a pipe.")
+	})
+}
+
+// fakeTerminal is a WriteSyncer that reports whether it's a terminal.
+type fakeTerminal struct {
+	ztest.Buffer
+	tty bool
+}
+
+func (f *fakeTerminal) IsTerminal() bool { return f.tty }
+
+func TestConsoleEncoderAutoColorEnv(t *testing.T) {
+	cfg := EncoderConfig{
+		MessageKey:  "msg",
+		LevelKey:    "level",
+		EncodeLevel: CapitalColorLevelEncoder,
+		Console:     ConsoleConfig{Color: ColorAuto},
+	}
+	const (
+		plain   = "INFO\thello\n"
+		colored = "\x1b[34mINFO\x1b[0m\thello\n"
+	)
+
+	tests := []struct {
+		desc       string
+		tty        bool
+		noColor    string
+		forceColor string
+		want       string
+	}{
+		{desc: "terminal", tty: true, want: colored},
+		{desc: "not a terminal", want: plain},
+		{desc: "NO_COLOR", tty: true, noColor: "1", want: plain},
+		{desc: "FORCE_COLOR", forceColor: "1", want: colored},
+		{desc: "FORCE_COLOR=0", tty: true, forceColor: "0", want: colored},
+		{desc: "FORCE_COLOR=false", forceColor: "false", want: plain},
+		{desc: "NO_COLOR wins", tty: true, noColor: "1", forceColor: "1", want: plain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			t.Setenv("FORCE_COLOR", tt.forceColor)
+
+			out := &fakeTerminal{tty: tt.tty}
+			core := NewCore(NewConsoleEncoder(cfg), Lock(out), DebugLevel)
+			require.NoError(t, core.Write(Entry{Level: InfoLevel, Message: "hello"}, nil), "Unexpected write error.")
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestConsoleEncoderColorNeverLevelEncoders(t *testing.T) {
+	for _, tt := range []struct {
+		enc  LevelEncoder
+		want string
+	}{
+		{CapitalColorLevelEncoder, "INFO\thello\n"},
+		{LowercaseColorLevelEncoder, "info\thello\n"},
+	} {
+		cfg := EncoderConfig{
+			MessageKey:  "msg",
+			LevelKey:    "level",
+			EncodeLevel: tt.enc,
+			Console:     ConsoleConfig{Color: ColorNever},
+		}
+		buf, err := NewConsoleEncoder(cfg).EncodeEntry(Entry{Level: InfoLevel, Message: "hello"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, buf.String(), "Expected ColorNever to strip level colors.")
+		buf.Free()
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+)
+
+// verboseError is an error whose verbose form spans several lines, like
+// those produced by github.com/pkg/errors.
+type verboseError struct{ msg string }
+
+func (e verboseError) Error() string { return e.msg }
+
+func (e verboseError) Format(s fmt.State, verb rune) {
+	if s.Flag('+') {
+		fmt.Fprintf(s, "%s\nmain.query\n\t/src/main.go:12", e.msg)
+		return
+	}
+	fmt.Fprint(s, e.msg)
+}
+
+// TestConsoleEncoderGolden compares entries encoded with the console
+// encoder's multi-line and wrapping layouts with the files in
+// testdata/console.
+func TestConsoleEncoderGolden(t *testing.T) {
+	ent := Entry{
+		LoggerName: "db",
+		Level:      ErrorLevel,
+		Time:       time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC),
+		Message:    "query failed",
+		Stack:      "main.main()\n\t/src/main.go:20",
+	}
+	context := []Field{
+		{Key: "service", Type: StringType, String: "checkout"},
+	}
+	fields := []Field{
+		{Key: "query", Type: StringType, String: "SELECT *\nFROM orders\nWHERE id = $1\n"},
+		{Key: "args", Type: ArrayMarshalerType, Interface: ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+			enc.AppendInt64(42)
+			enc.AppendString("a, b")
+			return nil
+		})},
+		{Key: "error", Type: ErrorType, Interface: verboseError{"connection reset"}},
+		{Key: "attempt", Type: Int64Type, Integer: 3},
+		{Key: "db", Type: NamespaceType},
+		{Key: "plan", Type: ByteStringType, Interface: []byte("Seq Scan\n  Filter: id = 42")},
+		{Key: "rows", Type: Int64Type, Integer: 0},
+	}
+
+	tests := []struct {
+		golden  string
+		console ConsoleConfig
+	}{
+		{golden: "default.txt"},
+		{golden: "blocks.txt", console: ConsoleConfig{MultilineBlocks: true}},
+		{golden: "wrapped.txt", console: ConsoleConfig{WrapWidth: 80}},
+		{golden: "wrapped_kv.txt", console: ConsoleConfig{WrapWidth: 60, KeyValueFields: true}},
+		{
+			golden:  "blocks_wrapped.txt",
+			console: ConsoleConfig{MultilineBlocks: true, WrapWidth: 70, IndentStacktrace: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join("testdata", "console", tt.golden))
+			require.NoError(t, err, "Couldn't read golden file.")
+
+			cfg := EncoderConfig{
+				TimeKey:       "ts",
+				LevelKey:      "level",
+				NameKey:       "logger",
+				MessageKey:    "msg",
+				StacktraceKey: "stacktrace",
+				EncodeTime:    ISO8601TimeEncoder,
+				EncodeLevel:   CapitalLevelEncoder,
+				Console:       tt.console,
+			}
+			enc := NewConsoleEncoder(cfg)
+			for _, f := range context {
+				f.AddTo(enc)
+			}
+			buf, err := enc.EncodeEntry(ent, fields)
+			require.NoError(t, err, "Unexpected console encoding error.")
+			defer buf.Free()
+			assert.Equal(t, string(want), buf.String(), "Unexpected console output.")
+		})
+	}
+}
+
+func TestConsoleEncoderMultilineBlocks(t *testing.T) {
+	cfg := EncoderConfig{MessageKey: "msg", Console: ConsoleConfig{MultilineBlocks: true}}
+	enc := NewConsoleEncoder(cfg)
+	// Fields added with With are encoded as usual.
+	enc.AddString("with", "a\nb")
+
+	buf, err := enc.EncodeEntry(Entry{Message: "hello"}, []Field{
+		{Key: "one", Type: StringType, String: "single line"},
+		{Key: "err", Type: ErrorType, Interface: errors.New("x\ny")},
+	})
+	require.NoError(t, err, "Unexpected console encoding error.")
+	defer buf.Free()
+	assert.Equal(t, "hello\t"+`{"with": "a\nb", "one": "single line"}`+"\n    err:\n        x\n        y\n", buf.String())
+}
@@ -20,6 +20,8 @@
 
 package zapcore
 
+import "sync"
+
 // Core is a minimal, fast logger interface. It's designed for library authors
 // to wrap in a more user-friendly API.
 type Core interface {
@@ -56,8 +58,13 @@ func (nopCore) Sync() error                                   { return nil }
 
 // NewCore creates a Core that writes logs to a WriteSyncer.
 func NewCore(enc Encoder, ws WriteSyncer, enab LevelEnabler) Core {
+	if r, ok := enc.(colorResolver); ok {
+		enc = r.resolveColor(ws)
+	}
+	entry, _ := enab.(EntryEnabler)
 	return &ioCore{
 		LevelEnabler: enab,
+		entry:        entry,
 		enc:          enc,
 		out:          ws,
 	}
@@ -65,13 +72,24 @@ func NewCore(enc Encoder, ws WriteSyncer, enab LevelEnabler) Core {
 
 type ioCore struct {
 	LevelEnabler
-	enc Encoder
-	out WriteSyncer
+	entry EntryEnabler // the LevelEnabler, if it's also an EntryEnabler
+	enc   Encoder
+	out   WriteSyncer
+
+	// pending holds context fields whose encoding was deferred by With; see
+	// canDeferEncoding. It's shared with children, which only ever append
+	// beyond its length, so it must never be modified in place.
+	pending []Field
+
+	// full is enc with the pending fields added. It's built at most once,
+	// on the first Write.
+	fullOnce sync.Once
+	full     Encoder
 }
 
 var (
 	_ Core           = (*ioCore)(nil)
-	_ leveledEnabler = (*ioCore)(nil)
+	_ LeveledEnabler = (*ioCore)(nil)
 )
 
 func (c *ioCore) Level() Level {
@@ -79,20 +97,33 @@ func (c *ioCore) Level() Level {
 }
 
 func (c *ioCore) With(fields []Field) Core {
+	if canDeferEncoding(fields) {
+		// Share the parent's encoder rather than cloning it. Children of
+		// request-scoped loggers are often never written to, and those
+		// that are pay for encoding once, on their first Write.
+		return &ioCore{
+			LevelEnabler: c.LevelEnabler,
+			entry:        c.entry,
+			enc:          c.enc,
+			out:          c.out,
+			pending:      append(c.pending[:len(c.pending):len(c.pending)], fields...),
+		}
+	}
 	clone := c.clone()
+	addFields(clone.enc, c.pending)
 	addFields(clone.enc, fields)
 	return clone
 }
 
 func (c *ioCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
-	if c.Enabled(ent.Level) {
+	if c.Enabled(ent.Level) && (c.entry == nil || c.entry.EnabledFor(ent)) {
 		return ce.AddCore(ent, c)
 	}
 	return ce
 }
 
 func (c *ioCore) Write(ent Entry, fields []Field) error {
-	buf, err := c.enc.EncodeEntry(ent, fields)
+	buf, err := c.encoder().EncodeEntry(ent, fields)
 	if err != nil {
 		return err
 	}
@@ -109,14 +140,60 @@ func (c *ioCore) Write(ent Entry, fields []Field) error {
 	return nil
 }
 
+// encoder returns the Encoder holding all of this core's context.
+func (c *ioCore) encoder() Encoder {
+	if len(c.pending) == 0 {
+		return c.enc
+	}
+	c.fullOnce.Do(func() {
+		c.full = c.enc.Clone()
+		addFields(c.full, c.pending)
+	})
+	return c.full
+}
+
 func (c *ioCore) Sync() error {
 	return c.out.Sync()
 }
 
+// clone returns a copy of the core with its own Encoder. Pending fields
+// aren't copied: callers must add them to the new Encoder.
 func (c *ioCore) clone() *ioCore {
 	return &ioCore{
 		LevelEnabler: c.LevelEnabler,
+		entry:        c.entry,
 		enc:          c.enc.Clone(),
 		out:          c.out,
 	}
 }
+
+// canDeferEncoding reports whether encoding the fields can be put off until
+// they're written without changing the output. That's true only of fields
+// holding immutable values: anything that references caller-owned memory or
+// calls user code must be encoded when it's added, as With promises.
+func canDeferEncoding(fields []Field) bool {
+	for i := range fields {
+		switch fields[i].Type {
+		case BoolType, StringType, DurationType, TimeType, TimeFullType,
+			Int64Type, Int32Type, Int16Type, Int8Type,
+			Uint64Type, Uint32Type, Uint16Type, Uint8Type, UintptrType,
+			Float64Type, Float32Type, Complex128Type, Complex64Type,
+			NamespaceType, SkipType:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// entryEnablerOf returns the EntryEnabler that a core consults in Check, or
+// nil if it doesn't have one.
+func entryEnablerOf(core Core) EntryEnabler {
+	switch c := core.(type) {
+	case *ioCore:
+		return c.entry
+	case EntryEnabler:
+		return c
+	}
+	return nil
+}
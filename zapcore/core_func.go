@@ -0,0 +1,71 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zapcore
+
+type funcCore struct {
+	LevelEnabler
+
+	write  func(Entry, []Field) error
+	fields []Field // added with With
+}
+
+var (
+	_ Core           = (*funcCore)(nil)
+	_ LeveledEnabler = (*funcCore)(nil)
+)
+
+// CoreFunc creates a Core that passes the entries enab enables to write,
+// for integrations too small to justify implementing the Core interface.
+//
+// The fields passed to write start with those added with With, followed by
+// those passed to Write. write must not retain the slice. Sync is a no-op.
+func CoreFunc(enab LevelEnabler, write func(Entry, []Field) error) Core {
+	return &funcCore{LevelEnabler: enab, write: write}
+}
+
+func (c *funcCore) Level() Level {
+	return LevelOf(c.LevelEnabler)
+}
+
+func (c *funcCore) With(fields []Field) Core {
+	return &funcCore{
+		LevelEnabler: c.LevelEnabler,
+		write:        c.write,
+		fields:       append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *funcCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *funcCore) Write(ent Entry, fields []Field) error {
+	if len(c.fields) > 0 {
+		fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	}
+	return c.write(ent, fields)
+}
+
+func (c *funcCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zapcore_test
+
+import (
+	"errors"
+	"testing"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoreFunc(t *testing.T) {
+	type written struct {
+		ent    Entry
+		fields []Field
+	}
+	var got []written
+	core := CoreFunc(InfoLevel, func(ent Entry, fields []Field) error {
+		got = append(got, written{ent, append([]Field(nil), fields...)})
+		return nil
+	})
+	assert.Equal(t, InfoLevel, LevelOf(core))
+
+	a, b, c := makeInt64Field("a", 1), makeInt64Field("b", 2), makeInt64Field("c", 3)
+	parent := core.With([]Field{a})
+	child := parent.With([]Field{b})
+	sibling := parent.With([]Field{c})
+
+	for _, tt := range []struct {
+		core Core
+		lvl  Level
+	}{
+		{core, InfoLevel},
+		{child, WarnLevel},
+		{sibling, InfoLevel},
+		{child, DebugLevel},
+	} {
+		if ce := tt.core.Check(Entry{Level: tt.lvl, Message: "msg"}, nil); ce != nil {
+			ce.Write(makeInt64Field("n", 0))
+		}
+	}
+
+	n := makeInt64Field("n", 0)
+	assert.Equal(t, []written{
+		{Entry{Level: InfoLevel, Message: "msg"}, []Field{n}},
+		{Entry{Level: WarnLevel, Message: "msg"}, []Field{a, b, n}},
+		{Entry{Level: InfoLevel, Message: "msg"}, []Field{a, c, n}},
+	}, got, "Expected With fields first and disabled entries dropped.")
+	assert.NoError(t, core.Sync())
+}
+
+func TestCoreFuncError(t *testing.T) {
+	core := CoreFunc(DebugLevel, func(Entry, []Field) error { return errors.New("fail") })
+	assert.EqualError(t, core.Write(Entry{}, nil), "fail")
+}
@@ -21,8 +21,10 @@
 package zapcore_test
 
 import (
+	"context"
 	"errors"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -108,6 +110,86 @@ func TestIOCore(t *testing.T) {
 	)
 }
 
+func TestIOCoreWith(t *testing.T) {
+	cfg := testEncoderConfig()
+	cfg.TimeKey = ""
+	cfg.LevelKey = ""
+
+	buf := &ztest.Buffer{}
+	root := NewCore(NewJSONEncoder(cfg), buf, DebugLevel)
+
+	// Fields that reference mutable state must be captured by With, even
+	// when mixed with fields whose encoding is deferred.
+	n := 1
+	obj := ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+		enc.AddInt("n", n)
+		return nil
+	})
+
+	parent := root.With([]Field{makeInt64Field("a", 1), {Key: "s", Type: StringType, String: "x"}})
+	child1 := parent.With([]Field{makeInt64Field("b", 2)})
+	child2 := parent.With([]Field{makeInt64Field("c", 3)})
+	grandchild := child1.
+		With([]Field{{Key: "obj", Type: ObjectMarshalerType, Interface: obj}}).
+		With([]Field{makeInt64Field("d", 4)})
+	nested := child2.With([]Field{{Key: "ns", Type: NamespaceType}, makeInt64Field("e", 5)})
+	n = 2
+
+	write := func(core Core, msg string) {
+		ce := core.Check(Entry{Level: InfoLevel, Message: msg}, nil)
+		require.NotNil(t, ce, "Expected entry to be enabled.")
+		ce.Write(makeInt64Field("z", 0))
+	}
+	write(root, "root")
+	write(parent, "parent")
+	write(child1, "child1")
+	write(child2, "child2")
+	write(grandchild, "grandchild")
+	write(grandchild, "grandchild again")
+	write(nested, "nested")
+
+	assert.Equal(t, []string{
+		`{"msg":"root","z":0}`,
+		`{"msg":"parent","a":1,"s":"x","z":0}`,
+		`{"msg":"child1","a":1,"s":"x","b":2,"z":0}`,
+		`{"msg":"child2","a":1,"s":"x","c":3,"z":0}`,
+		`{"msg":"grandchild","a":1,"s":"x","b":2,"obj":{"n":1},"d":4,"z":0}`,
+		`{"msg":"grandchild again","a":1,"s":"x","b":2,"obj":{"n":1},"d":4,"z":0}`,
+		`{"msg":"nested","a":1,"s":"x","c":3,"ns":{"e":5,"z":0}}`,
+	}, buf.Lines(), "Unexpected context in output.")
+}
+
+// prefixEnabler enables entries at or above a level whose messages have a
+// prefix.
+type prefixEnabler struct {
+	Level
+	prefix string
+}
+
+func (e prefixEnabler) EnabledFor(ent Entry) bool {
+	return strings.HasPrefix(ent.Message, e.prefix)
+}
+
+func TestIOCoreEntryEnabler(t *testing.T) {
+	cfg := testEncoderConfig()
+	cfg.TimeKey = ""
+
+	buf := &ztest.Buffer{}
+	core := NewCore(NewJSONEncoder(cfg), buf, prefixEnabler{InfoLevel, "keep"}).
+		With([]Field{makeInt64Field("k", 1)})
+
+	for _, ent := range []Entry{
+		{Level: DebugLevel, Message: "keep: disabled level"},
+		{Level: InfoLevel, Message: "keep: enabled"},
+		{Level: InfoLevel, Message: "drop: rejected by entry"},
+	} {
+		if ce := core.Check(ent, nil); ce != nil {
+			ce.Write()
+		}
+	}
+	assert.Equal(t, []string{`{"level":"info","msg":"keep: enabled","k":1}`}, buf.Lines())
+}
+
 func TestIOCoreSyncFail(t *testing.T) {
 	sink := &ztest.Discarder{}
 	err := errors.New("failed")
@@ -164,3 +246,64 @@ func TestIOCoreWriteFailure(t *testing.T) {
 	// Should log the error.
 	assert.Error(t, err, "Expected writing Entry to fail.")
 }
+
+func TestBuiltinCoresAreLeveledEnablers(t *testing.T) {
+	base := NewCore(NewJSONEncoder(testEncoderConfig()), AddSync(&ztest.Buffer{}), InfoLevel)
+	increased, err := NewIncreaseLevelCore(base, WarnLevel)
+	require.NoError(t, err, "Unexpected error increasing level.")
+	async := NewAsyncCore(base)
+	defer func() { assert.NoError(t, async.Stop(context.Background()), "Unexpected error stopping core.") }()
+
+	tests := []struct {
+		desc string
+		core Core
+		want Level
+	}{
+		{"ioCore", base, InfoLevel},
+		{"tee", NewTee(base, increased), InfoLevel},
+		{"sampler", NewSamplerWithOptions(base, time.Second, 1, 1), InfoLevel},
+		{"hooked", RegisterHooks(base), InfoLevel},
+		{"increased level", increased, WarnLevel},
+		{"lazy with", NewLazyWith(base, nil), InfoLevel},
+		{"async", async, InfoLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			lvler, ok := tt.core.(LeveledEnabler)
+			require.True(t, ok, "Expected %T to implement LeveledEnabler.", tt.core)
+			assert.Equal(t, tt.want, lvler.Level(), "Unexpected level.")
+		})
+	}
+
+	t.Run("unknown core", func(t *testing.T) {
+		assert.Equal(t, InvalidLevel, LevelOf(NewNopCore()), "Expected InvalidLevel for a disabled core.")
+	})
+}
+
+func BenchmarkIOCoreCheck(b *testing.B) {
+	enc := NewJSONEncoder(testEncoderConfig())
+	tests := []struct {
+		name string
+		enab LevelEnabler
+		ent  Entry
+	}{
+		{"level/disabled", InfoLevel, Entry{Level: DebugLevel}},
+		{"level/enabled", InfoLevel, Entry{Level: InfoLevel}},
+		{"entry/level disabled", prefixEnabler{InfoLevel, "keep"}, Entry{Level: DebugLevel, Message: "keep"}},
+		{"entry/rejected", prefixEnabler{InfoLevel, "keep"}, Entry{Level: InfoLevel, Message: "drop"}},
+		{"entry/enabled", prefixEnabler{InfoLevel, "keep"}, Entry{Level: InfoLevel, Message: "keep"}},
+	}
+
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			core := NewCore(enc, &ztest.Discarder{}, tt.enab)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if ce := core.Check(tt.ent, nil); ce != nil {
+					ce.Write()
+				}
+			}
+		})
+	}
+}
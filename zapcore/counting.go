@@ -0,0 +1,138 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zapcore
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/multierr"
+)
+
+// CountingCore is a Core that counts the entries it passes to a wrapped Core,
+// so that applications can compare what they logged with what arrived at
+// their log pipeline's destination.
+//
+// An entry is emitted if the wrapped Core accepted and wrote it without
+// error, dropped if the wrapped Core's Check rejected it, as a Sampler does
+// for entries over its limits, and failed if writing it returned an error.
+// Entries rejected because the wrapped Core isn't enabled at their level
+// aren't counted.
+type CountingCore struct {
+	core   Core
+	counts *entryCounts
+}
+
+var (
+	_ Core           = (*CountingCore)(nil)
+	_ LeveledEnabler = (*CountingCore)(nil)
+)
+
+// entryCounts holds the counters shared by a CountingCore and the Cores
+// derived from it with With.
+type entryCounts struct {
+	emitted atomic.Uint64
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+	levels  [_maxLevel - _minLevel + 1]atomic.Uint64 // emitted, by level
+}
+
+// NewCountingCore builds a CountingCore that writes to the given Core.
+func NewCountingCore(core Core) *CountingCore {
+	return &CountingCore{core: core, counts: new(entryCounts)}
+}
+
+// EmittedCount returns the number of entries written to the wrapped Core.
+func (c *CountingCore) EmittedCount() uint64 {
+	return c.counts.emitted.Load()
+}
+
+// DroppedCount returns the number of entries the wrapped Core rejected.
+func (c *CountingCore) DroppedCount() uint64 {
+	return c.counts.dropped.Load()
+}
+
+// FailedCount returns the number of entries the wrapped Core failed to write.
+func (c *CountingCore) FailedCount() uint64 {
+	return c.counts.failed.Load()
+}
+
+// LevelCount returns the number of entries at the given level written to the
+// wrapped Core. It returns zero for levels outside TraceLevel to FatalLevel.
+func (c *CountingCore) LevelCount(lvl Level) uint64 {
+	if lvl < _minLevel || lvl > _maxLevel {
+		return 0
+	}
+	return c.counts.levels[lvl-_minLevel].Load()
+}
+
+// Enabled reports whether the wrapped Core is enabled at the given level.
+func (c *CountingCore) Enabled(lvl Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+// Level reports the minimum enabled level of the wrapped Core.
+func (c *CountingCore) Level() Level {
+	return LevelOf(c.core)
+}
+
+// With adds structured context to the wrapped Core. The returned Core shares
+// the counters of its parent.
+func (c *CountingCore) With(fields []Field) Core {
+	return &CountingCore{core: c.core.With(fields), counts: c.counts}
+}
+
+// Check adds the CountingCore to the CheckedEntry if the wrapped Core is
+// enabled at the entry's level. The wrapped Core's Check is consulted in
+// Write, so that the entries it rejects can be counted.
+func (c *CountingCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write writes the entry to the wrapped Core and counts the outcome.
+func (c *CountingCore) Write(ent Entry, fields []Field) error {
+	inner := c.core.Check(ent, nil)
+	if inner == nil {
+		c.counts.dropped.Add(1)
+		return nil
+	}
+	defer putCheckedEntry(inner)
+
+	var err error
+	for _, core := range inner.cores {
+		err = multierr.Append(err, core.Write(ent, fields))
+	}
+	if err != nil {
+		c.counts.failed.Add(1)
+		return err
+	}
+	c.counts.emitted.Add(1)
+	if ent.Level >= _minLevel && ent.Level <= _maxLevel {
+		c.counts.levels[ent.Level-_minLevel].Add(1)
+	}
+	return nil
+}
+
+// Sync flushes the wrapped Core.
+func (c *CountingCore) Sync() error {
+	return c.core.Sync()
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zapcore_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountingCore(t *testing.T) {
+	obs, logs := observer.New(InfoLevel)
+	core := NewCountingCore(obs)
+	child := core.With([]Field{makeInt64Field("k", 1)})
+
+	writeAt(core, InfoLevel, "info", 0)
+	writeAt(core, WarnLevel, "warn", 0)
+	writeAt(child, WarnLevel, "warn", 0)
+	writeAt(core, DebugLevel, "debug", 0)
+
+	assert.Equal(t, 3, logs.Len())
+	assert.Equal(t, uint64(3), core.EmittedCount(), "Expected With children to share counters.")
+	assert.Equal(t, uint64(1), core.LevelCount(InfoLevel))
+	assert.Equal(t, uint64(2), core.LevelCount(WarnLevel))
+	assert.Zero(t, core.LevelCount(DebugLevel), "Expected disabled levels not to be counted.")
+	assert.Zero(t, core.LevelCount(InvalidLevel))
+	assert.Zero(t, core.DroppedCount())
+	assert.Zero(t, core.FailedCount())
+	assert.Equal(t, InfoLevel, LevelOf(core))
+	assert.NoError(t, core.Sync())
+}
+
+func TestCountingCoreDropped(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	sampler := NewSamplerWithOptions(obs, time.Minute, 2, 0)
+	core := NewCountingCore(sampler)
+
+	for i := 0; i < 5; i++ {
+		writeAt(core, InfoLevel, "sampled", 0)
+	}
+	assert.Equal(t, 2, logs.Len())
+	assert.Equal(t, uint64(2), core.EmittedCount())
+	assert.Equal(t, uint64(3), core.DroppedCount(), "Expected entries the sampler rejects to be counted.")
+}
+
+func TestCountingCoreFailed(t *testing.T) {
+	core := NewCountingCore(errWriteCore{Core: NewNopCore(), err: errors.New("fail")})
+	ent := Entry{Level: InfoLevel, Message: "foo", Time: _rateLimitEpoch}
+	assert.ErrorContains(t, core.Write(ent, nil), "fail")
+	assert.Zero(t, core.EmittedCount())
+	assert.Equal(t, uint64(1), core.FailedCount())
+}
+
+func TestCountingCoreConcurrent(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := NewCountingCore(obs)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				writeAt(core, DebugLevel, "foo", 0)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1000, logs.Len())
+	assert.Equal(t, uint64(1000), core.EmittedCount())
+	assert.Equal(t, uint64(1000), core.LevelCount(DebugLevel))
+}
@@ -0,0 +1,616 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/toujourser/zap/buffer"
+	"github.com/toujourser/zap/internal/bufferpool"
+	"github.com/toujourser/zap/internal/pool"
+)
+
+// _defaultCSVExtrasColumn is the name of the last column of each row, which
+// holds the fields that don't have a column of their own.
+const _defaultCSVExtrasColumn = "extras"
+
+var _csvPool = pool.New(func() *csvEncoder {
+	return &csvEncoder{}
+})
+
+func putCSVEncoder(enc *csvEncoder) {
+	enc.EncoderConfig = nil
+	enc.layout = nil
+	enc.values = nil
+	enc.spans = nil
+	enc.extras = nil
+	enc.namespaced = false
+	_csvPool.Put(enc)
+}
+
+// CSVConfig configures the CSV encoder.
+type CSVConfig struct {
+	// Columns lists the columns of each row, in order. Columns named after
+	// one of the EncoderConfig's keys, such as MessageKey or TimeKey, hold
+	// that part of the entry, encoded with the EncoderConfig's encoders.
+	// Any other column holds the value of the top-level field with the same
+	// key, and is left empty for entries without that field.
+	Columns []string `json:"columns" yaml:"columns"`
+	// ExtrasColumn names the final column of each row, which holds the
+	// fields that don't have a column of their own as a JSON object.
+	// Defaults to "extras".
+	ExtrasColumn string `json:"extrasColumn" yaml:"extrasColumn"`
+	// Delimiter separates the columns. Defaults to a comma; use a tab for
+	// TSV output.
+	Delimiter string `json:"delimiter" yaml:"delimiter"`
+	// Header makes loggers built from a Config write a header row, holding
+	// the column names, before the first entry written to each output.
+	// Encoders ignore it; see NewCSVHeaderWriter.
+	Header bool `json:"header" yaml:"header"`
+}
+
+// csvLayout is the column layout of a CSV encoder. It's shared by clones and
+// never modified after construction.
+type csvLayout struct {
+	delim  string
+	header []byte // including the line ending
+
+	// Column indexes of the parts of the entry, or -1 if the entry part has
+	// no column.
+	time, level, name, caller, function, message, stack int
+
+	// fields maps the keys of top-level fields to column indexes.
+	fields map[string]int
+	width  int // number of columns, excluding the extras column
+}
+
+// csvSpan locates a column's value in a csvEncoder's values buffer. The
+// zero value is an empty cell.
+type csvSpan struct{ start, end int }
+
+type csvEncoder struct {
+	*EncoderConfig
+	layout *csvLayout
+
+	// values holds the encoded values of fields with a column, back to
+	// back. Setting a column again appends its new value and moves its span.
+	values *buffer.Buffer
+	spans  []csvSpan
+
+	// extras holds the fields without a column of their own.
+	extras *jsonEncoder
+
+	// namespaced is set once a namespace is opened. After that, all fields
+	// belong to the namespace, so they're written to the extras.
+	namespaced bool
+}
+
+// NewCSVEncoder creates an encoder that writes each entry as a row of
+// comma-separated values, with the columns given by cfg.CSV.Columns,
+// followed by an extras column holding any other fields as a JSON object.
+// For example, with the columns "ts", "level", "msg", and "user", and the
+// production EncoderConfig,
+//
+//	logger.Info("logged in", zap.String("user", "alice"), zap.Int("attempt", 2))
+//
+// is written as
+//
+//	1700000000.5,info,logged in,alice,"{""attempt"":2}"
+//
+// Values are quoted as described in RFC 4180, but rows end with the
+// EncoderConfig's line ending, which defaults to "\n" rather than "\r\n".
+// Nested objects, arrays, and reflected values in a column are written as
+// JSON. Fields added after a namespace is opened are always written to the
+// extras column.
+func NewCSVEncoder(cfg EncoderConfig) Encoder {
+	return newCSVEncoder(cfg)
+}
+
+func newCSVEncoder(cfg EncoderConfig) *csvEncoder {
+	enc := &csvEncoder{
+		EncoderConfig: &cfg,
+		layout:        newCSVLayout(&cfg),
+		values:        bufferpool.Get(),
+		extras:        newJSONEncoder(cfg, false /* spaced */),
+	}
+	enc.spans = make([]csvSpan, enc.layout.width)
+	return enc
+}
+
+func newCSVLayout(cfg *EncoderConfig) *csvLayout {
+	l := &csvLayout{
+		delim:    cfg.CSV.Delimiter,
+		time:     -1,
+		level:    -1,
+		name:     -1,
+		caller:   -1,
+		function: -1,
+		message:  -1,
+		stack:    -1,
+		fields:   make(map[string]int, len(cfg.CSV.Columns)),
+		width:    len(cfg.CSV.Columns),
+	}
+	if l.delim == "" {
+		l.delim = ","
+	}
+
+	entryColumns := []struct {
+		key string
+		idx *int
+	}{
+		{cfg.TimeKey, &l.time},
+		{cfg.LevelKey, &l.level},
+		{cfg.NameKey, &l.name},
+		{cfg.CallerKey, &l.caller},
+		{cfg.FunctionKey, &l.function},
+		{cfg.MessageKey, &l.message},
+		{cfg.StacktraceKey, &l.stack},
+	}
+	for i, col := range cfg.CSV.Columns {
+		isEntry := false
+		for _, ec := range entryColumns {
+			if ec.key != "" && ec.key == col && *ec.idx < 0 {
+				*ec.idx = i
+				isEntry = true
+				break
+			}
+		}
+		if _, dup := l.fields[col]; !isEntry && !dup {
+			l.fields[col] = i
+		}
+	}
+
+	extras := cfg.CSV.ExtrasColumn
+	if extras == "" {
+		extras = _defaultCSVExtrasColumn
+	}
+	buf := bufferpool.Get()
+	for _, col := range cfg.CSV.Columns {
+		l.appendCell(buf, []byte(col))
+		buf.AppendString(l.delim)
+	}
+	l.appendCell(buf, []byte(extras))
+	buf.AppendString(lineEnding(cfg))
+	l.header = append([]byte(nil), buf.Bytes()...)
+	buf.Free()
+	return l
+}
+
+// appendCell appends a value to buf, quoting it if necessary.
+func (l *csvLayout) appendCell(buf *buffer.Buffer, val []byte) {
+	if !l.needsQuotes(val) {
+		buf.AppendBytes(val)
+		return
+	}
+	buf.AppendByte('"')
+	for {
+		i := bytes.IndexByte(val, '"')
+		if i < 0 {
+			break
+		}
+		buf.AppendBytes(val[:i+1])
+		buf.AppendByte('"')
+		val = val[i+1:]
+	}
+	buf.AppendBytes(val)
+	buf.AppendByte('"')
+}
+
+func (l *csvLayout) needsQuotes(val []byte) bool {
+	return bytes.ContainsAny(val, "\"\r\n") || bytes.Contains(val, []byte(l.delim))
+}
+
+func lineEnding(cfg *EncoderConfig) string {
+	if cfg.LineEnding != "" {
+		return cfg.LineEnding
+	}
+	return DefaultLineEnding
+}
+
+// NewCSVHeaderWriter returns a WriteSyncer that writes the header row of
+// the CSV encoder configured by cfg, listing the column names, before the
+// first write to ws. The header is written once per WriteSyncer, so output
+// appended to an existing file gets a second header.
+func NewCSVHeaderWriter(ws WriteSyncer, cfg EncoderConfig) WriteSyncer {
+	return &csvHeaderWriter{
+		WriteSyncer: ws,
+		header:      newCSVLayout(&cfg).header,
+	}
+}
+
+type csvHeaderWriter struct {
+	WriteSyncer
+
+	once   sync.Once
+	header []byte
+	err    error // from writing the header
+}
+
+func (w *csvHeaderWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() {
+		_, w.err = w.WriteSyncer.Write(w.header)
+	})
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.WriteSyncer.Write(p)
+}
+
+// column returns the index of the column for a top-level field, or -1 if
+// the field belongs in the extras.
+func (enc *csvEncoder) column(key string) int {
+	if enc.namespaced {
+		return -1
+	}
+	if i, ok := enc.layout.fields[key]; ok {
+		return i
+	}
+	return -1
+}
+
+// set starts writing a new value for column i and returns the encoder to
+// write it with.
+func (enc *csvEncoder) set(i int) csvValueEncoder {
+	enc.spans[i] = csvSpan{start: enc.values.Len()}
+	return csvValueEncoder{enc}
+}
+
+// done records the end of the value for column i.
+func (enc *csvEncoder) done(i int) {
+	enc.spans[i].end = enc.values.Len()
+}
+
+// setJSON writes column i as JSON, using f to encode the value.
+func (enc *csvEncoder) setJSON(i int, f func(*jsonEncoder) error) error {
+	json := _jsonPool.Get()
+	json.EncoderConfig = enc.EncoderConfig
+	json.buf = bufferpool.Get()
+	err := f(json)
+	enc.set(i)
+	enc.values.AppendBytes(json.buf.Bytes())
+	enc.done(i)
+	json.buf.Free()
+	putJSONEncoder(json)
+	return err
+}
+
+func (enc *csvEncoder) AddArray(key string, arr ArrayMarshaler) error {
+	if i := enc.column(key); i >= 0 {
+		return enc.setJSON(i, func(json *jsonEncoder) error {
+			return json.AppendArray(arr)
+		})
+	}
+	return enc.extras.AddArray(key, arr)
+}
+
+func (enc *csvEncoder) AddObject(key string, obj ObjectMarshaler) error {
+	if i := enc.column(key); i >= 0 {
+		return enc.setJSON(i, func(json *jsonEncoder) error {
+			return json.AppendObject(obj)
+		})
+	}
+	return enc.extras.AddObject(key, obj)
+}
+
+func (enc *csvEncoder) AddReflected(key string, obj interface{}) error {
+	if i := enc.column(key); i >= 0 {
+		return enc.setJSON(i, func(json *jsonEncoder) error {
+			return json.AppendReflected(obj)
+		})
+	}
+	return enc.extras.AddReflected(key, obj)
+}
+
+func (enc *csvEncoder) AddBinary(key string, val []byte) {
+	if i := enc.column(key); i >= 0 {
+		enc.set(i).appendBinary(val)
+		enc.done(i)
+		return
+	}
+	enc.extras.AddBinary(key, val)
+}
+
+func (enc *csvEncoder) AddByteString(key string, val []byte) {
+	if i := enc.column(key); i >= 0 {
+		enc.set(i).AppendByteString(val)
+		enc.done(i)
+		return
+	}
+	enc.extras.AddByteString(key, val)
+}
+
+func (enc *csvEncoder) AddBool(key string, val bool) {
+	if i := enc.column(key); i >= 0 {
+		enc.set(i).AppendBool(val)
+		enc.done(i)
+		return
+	}
+	enc.extras.AddBool(key, val)
+}
+
+func (enc *csvEncoder) AddComplex128(key string, val complex128) {
+	if i := enc.column(key); i >= 0 {
+		enc.set(i).AppendComplex128(val)
+		enc.done(i)
+		return
+	}
+	enc.extras.AddComplex128(key, val)
+}
+
+func (enc *csvEncoder) AddComplex64(key string, val complex64) {
+	if i := enc.column(key); i >= 0 {
+		enc.set(i).AppendComplex64(val)
+		enc.done(i)
+		return
+	}
+	enc.extras.AddComplex64(key, val)
+}
+
+func (enc *csvEncoder) AddDuration(key string, val time.Duration) {
+	if i := enc.column(key); i >= 0 {
+		enc.set(i).appendDuration(val)
+		enc.done(i)
+		return
+	}
+	enc.extras.AddDuration(key, val)
+}
+
+func (enc *csvEncoder) AddFloat64(key string, val float64) {
+	if i := enc.column(key); i >= 0 {
+		enc.set(i).AppendFloat64(val)
+		enc.done(i)
+		return
+	}
+	enc.extras.AddFloat64(key, val)
+}
+
+func (enc *csvEncoder) AddFloat32(key string, val float32) {
+	if i := enc.column(key); i >= 0 {
+		enc.set(i).AppendFloat32(val)
+		enc.done(i)
+		return
+	}
+	enc.extras.AddFloat32(key, val)
+}
+
+func (enc *csvEncoder) AddInt64(key string, val int64) {
+	if i := enc.column(key); i >= 0 {
+		enc.set(i).AppendInt64(val)
+		enc.done(i)
+		return
+	}
+	enc.extras.AddInt64(key, val)
+}
+
+func (enc *csvEncoder) AddString(key, val string) {
+	if i := enc.column(key); i >= 0 {
+		enc.set(i).AppendString(val)
+		enc.done(i)
+		return
+	}
+	enc.extras.AddString(key, val)
+}
+
+func (enc *csvEncoder) AddTime(key string, val time.Time) {
+	if i := enc.column(key); i >= 0 {
+		enc.set(i).appendTime(val)
+		enc.done(i)
+		return
+	}
+	enc.extras.AddTime(key, val)
+}
+
+func (enc *csvEncoder) AddUint64(key string, val uint64) {
+	if i := enc.column(key); i >= 0 {
+		enc.set(i).AppendUint64(val)
+		enc.done(i)
+		return
+	}
+	enc.extras.AddUint64(key, val)
+}
+
+func (enc *csvEncoder) OpenNamespace(key string) {
+	enc.namespaced = true
+	enc.extras.OpenNamespace(key)
+}
+
+func (enc *csvEncoder) AddInt(k string, v int)         { enc.AddInt64(k, int64(v)) }
+func (enc *csvEncoder) AddInt32(k string, v int32)     { enc.AddInt64(k, int64(v)) }
+func (enc *csvEncoder) AddInt16(k string, v int16)     { enc.AddInt64(k, int64(v)) }
+func (enc *csvEncoder) AddInt8(k string, v int8)       { enc.AddInt64(k, int64(v)) }
+func (enc *csvEncoder) AddUint(k string, v uint)       { enc.AddUint64(k, uint64(v)) }
+func (enc *csvEncoder) AddUint32(k string, v uint32)   { enc.AddUint64(k, uint64(v)) }
+func (enc *csvEncoder) AddUint16(k string, v uint16)   { enc.AddUint64(k, uint64(v)) }
+func (enc *csvEncoder) AddUint8(k string, v uint8)     { enc.AddUint64(k, uint64(v)) }
+func (enc *csvEncoder) AddUintptr(k string, v uintptr) { enc.AddUint64(k, uint64(v)) }
+
+func (enc *csvEncoder) Clone() Encoder {
+	clone := enc.clone()
+	clone.extras.buf.AppendBytes(enc.extras.buf.Bytes())
+	return clone
+}
+
+// clone copies the encoder, including its column values. The extras are
+// left empty.
+func (enc *csvEncoder) clone() *csvEncoder {
+	clone := _csvPool.Get()
+	clone.EncoderConfig = enc.EncoderConfig
+	clone.layout = enc.layout
+	clone.namespaced = enc.namespaced
+
+	// Copy only the current value of each column.
+	clone.values = bufferpool.Get()
+	clone.spans = make([]csvSpan, len(enc.spans))
+	vals := enc.values.Bytes()
+	for i, s := range enc.spans {
+		if s.start == s.end {
+			continue
+		}
+		start := clone.values.Len()
+		clone.values.AppendBytes(vals[s.start:s.end])
+		clone.spans[i] = csvSpan{start, clone.values.Len()}
+	}
+
+	clone.extras = enc.extras.clone()
+	return clone
+}
+
+func (enc *csvEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer, error) {
+	final := enc.clone()
+	final.extras.buf.AppendBytes(enc.extras.buf.Bytes())
+	l := final.layout
+
+	if l.time >= 0 && !ent.Time.IsZero() {
+		final.set(l.time).appendTime(ent.Time)
+		final.done(l.time)
+	}
+	if l.level >= 0 && final.EncodeLevel != nil {
+		final.set(l.level)
+		final.EncodeLevel(ent.Level, csvValueEncoder{final})
+		final.done(l.level)
+	}
+	if l.name >= 0 && ent.LoggerName != "" {
+		v := final.set(l.name)
+		nameEncoder := final.EncodeName
+		if nameEncoder == nil {
+			// Fall back to FullNameEncoder for backward compatibility.
+			nameEncoder = FullNameEncoder
+		}
+		nameEncoder(ent.LoggerName, v)
+		final.done(l.name)
+	}
+	if ent.Caller.Defined {
+		if l.caller >= 0 && final.EncodeCaller != nil {
+			final.set(l.caller)
+			final.EncodeCaller(ent.Caller, csvValueEncoder{final})
+			final.done(l.caller)
+		}
+		if l.function >= 0 {
+			final.set(l.function).AppendString(ent.Caller.Function)
+			final.done(l.function)
+		}
+	}
+	if l.message >= 0 {
+		final.set(l.message).AppendString(ent.Message)
+		final.done(l.message)
+	}
+	if l.stack >= 0 && ent.Stack != "" {
+		final.set(l.stack).AppendString(ent.Stack)
+		final.done(l.stack)
+	}
+
+	addFields(final, fields)
+
+	line := bufferpool.Get()
+	vals := final.values.Bytes()
+	for _, s := range final.spans {
+		l.appendCell(line, vals[s.start:s.end])
+		line.AppendString(l.delim)
+	}
+	if final.extras.buf.Len() > 0 || final.extras.openNamespaces > 0 {
+		final.extras.closeOpenNamespaces()
+		json := bufferpool.Get()
+		json.AppendByte('{')
+		json.AppendBytes(final.extras.buf.Bytes())
+		json.AppendByte('}')
+		l.appendCell(line, json.Bytes())
+		json.Free()
+	}
+	if !final.SkipLineEnding {
+		line.AppendString(lineEnding(final.EncoderConfig))
+	}
+
+	final.values.Free()
+	final.extras.buf.Free()
+	putJSONEncoder(final.extras)
+	putCSVEncoder(final)
+	return line, nil
+}
+
+// csvValueEncoder writes the plain-text representation of values to a
+// csvEncoder's values buffer, for use by the EncoderConfig's encoders.
+type csvValueEncoder struct{ enc *csvEncoder }
+
+var _ PrimitiveArrayEncoder = csvValueEncoder{}
+
+func (v csvValueEncoder) AppendBool(val bool)           { v.enc.values.AppendBool(val) }
+func (v csvValueEncoder) AppendByteString(val []byte)   { v.enc.values.AppendBytes(val) }
+func (v csvValueEncoder) AppendString(val string)       { v.enc.values.AppendString(val) }
+func (v csvValueEncoder) AppendInt64(val int64)         { v.enc.values.AppendInt(val) }
+func (v csvValueEncoder) AppendUint64(val uint64)       { v.enc.values.AppendUint(val) }
+func (v csvValueEncoder) AppendFloat64(val float64)     { v.enc.values.AppendFloat(val, 64) }
+func (v csvValueEncoder) AppendFloat32(val float32)     { v.enc.values.AppendFloat(float64(val), 32) }
+func (v csvValueEncoder) AppendComplex128(c complex128) { v.appendComplex(c, 64) }
+func (v csvValueEncoder) AppendComplex64(c complex64)   { v.appendComplex(complex128(c), 32) }
+func (v csvValueEncoder) AppendInt(val int)             { v.AppendInt64(int64(val)) }
+func (v csvValueEncoder) AppendInt32(val int32)         { v.AppendInt64(int64(val)) }
+func (v csvValueEncoder) AppendInt16(val int16)         { v.AppendInt64(int64(val)) }
+func (v csvValueEncoder) AppendInt8(val int8)           { v.AppendInt64(int64(val)) }
+func (v csvValueEncoder) AppendUint(val uint)           { v.AppendUint64(uint64(val)) }
+func (v csvValueEncoder) AppendUint32(val uint32)       { v.AppendUint64(uint64(val)) }
+func (v csvValueEncoder) AppendUint16(val uint16)       { v.AppendUint64(uint64(val)) }
+func (v csvValueEncoder) AppendUint8(val uint8)         { v.AppendUint64(uint64(val)) }
+func (v csvValueEncoder) AppendUintptr(val uintptr)     { v.AppendUint64(uint64(val)) }
+
+func (v csvValueEncoder) appendComplex(val complex128, precision int) {
+	r, i := real(val), imag(val)
+	v.enc.values.AppendString(strconv.FormatFloat(r, 'f', -1, precision))
+	if i >= 0 {
+		v.enc.values.AppendByte('+')
+	}
+	v.enc.values.AppendString(strconv.FormatFloat(i, 'f', -1, precision))
+	v.enc.values.AppendByte('i')
+}
+
+func (v csvValueEncoder) appendTime(val time.Time) {
+	cur := v.enc.values.Len()
+	if e := v.enc.EncodeTime; e != nil {
+		e(val, v)
+	}
+	if cur == v.enc.values.Len() {
+		// Like the JSON encoder, fall back to nanoseconds since the epoch
+		// if EncodeTime is missing or a no-op.
+		v.AppendInt64(val.UnixNano())
+	}
+}
+
+func (v csvValueEncoder) appendBinary(val []byte) {
+	cur := v.enc.values.Len()
+	if e := v.enc.EncodeBinary; e != nil {
+		e(val, v)
+	}
+	if cur == v.enc.values.Len() {
+		Base64BinaryEncoder(val, v)
+	}
+}
+
+func (v csvValueEncoder) appendDuration(val time.Duration) {
+	cur := v.enc.values.Len()
+	if e := v.enc.EncodeDuration; e != nil {
+		e(val, v)
+	}
+	if cur == v.enc.values.Len() {
+		v.AppendInt64(int64(val))
+	}
+}
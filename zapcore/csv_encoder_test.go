@@ -0,0 +1,241 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap/internal/ztest"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+)
+
+func csvTestEncoderConfig(columns ...string) EncoderConfig {
+	cfg := testEncoderConfig()
+	cfg.EncodeTime = ISO8601TimeEncoder
+	cfg.EncodeDuration = StringDurationEncoder
+	cfg.CSV.Columns = columns
+	return cfg
+}
+
+func TestCSVEncodeEntry(t *testing.T) {
+	ent := Entry{
+		Level:      WarnLevel,
+		Time:       time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+		LoggerName: "svc",
+		Message:    "hello",
+		Caller:     EntryCaller{Defined: true, File: "/src/foo/foo.go", Line: 42, Function: "foo.Bar"},
+	}
+
+	tests := []struct {
+		desc    string
+		columns []string
+		ent     Entry
+		fields  []Field
+		want    string
+	}{
+		{
+			desc:    "entry columns",
+			columns: []string{"ts", "level", "name", "caller", "func", "msg"},
+			ent:     ent,
+			want:    "2023-01-02T03:04:05.000Z,warn,svc,foo/foo.go:42,foo.Bar,hello,\n",
+		},
+		{
+			desc:    "field columns",
+			columns: []string{"msg", "user", "attempt", "elapsed", "ok"},
+			ent:     ent,
+			fields: []Field{
+				makeInt64Field("attempt", 2),
+				{Key: "user", Type: StringType, String: "alice"},
+				{Key: "elapsed", Type: DurationType, Integer: int64(1500 * time.Millisecond)},
+				{Key: "ok", Type: BoolType, Integer: 1},
+			},
+			want: "hello,alice,2,1.5s,true,\n",
+		},
+		{
+			desc:    "missing fields",
+			columns: []string{"msg", "user", "missing"},
+			ent:     ent,
+			fields:  []Field{{Key: "user", Type: StringType, String: "alice"}},
+			want:    "hello,alice,,\n",
+		},
+		{
+			desc:    "extras",
+			columns: []string{"msg", "user"},
+			ent:     ent,
+			fields: []Field{
+				{Key: "user", Type: StringType, String: "alice"},
+				makeInt64Field("attempt", 2),
+				{Key: "role", Type: StringType, String: "admin"},
+			},
+			want: `hello,alice,"{""attempt"":2,""role"":""admin""}"` + "\n",
+		},
+		{
+			desc:    "quoting",
+			columns: []string{"msg", "s"},
+			ent:     Entry{Message: "comma, \"quote\"\nnewline"},
+			fields:  []Field{{Key: "s", Type: StringType, String: "a\rb"}},
+			want:    "\"comma, \"\"quote\"\"\nnewline\",\"a\rb\",\n",
+		},
+		{
+			desc:    "objects in columns",
+			columns: []string{"msg", "obj", "arr"},
+			ent:     Entry{Message: "m"},
+			fields: []Field{
+				{Key: "obj", Type: ObjectMarshalerType, Interface: ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+					enc.AddString("k", "v")
+					return nil
+				})},
+				{Key: "arr", Type: ArrayMarshalerType, Interface: ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+					enc.AppendInt(1)
+					enc.AppendInt(2)
+					return nil
+				})},
+			},
+			want: `m,"{""k"":""v""}","[1,2]",` + "\n",
+		},
+		{
+			desc:    "namespaced fields go to extras",
+			columns: []string{"msg", "user"},
+			ent:     Entry{Message: "m"},
+			fields: []Field{
+				{Key: "ns", Type: NamespaceType},
+				{Key: "user", Type: StringType, String: "alice"},
+			},
+			want: `m,,"{""ns"":{""user"":""alice""}}"` + "\n",
+		},
+		{
+			desc:    "last value wins",
+			columns: []string{"msg", "user"},
+			ent:     Entry{Message: "m"},
+			fields: []Field{
+				{Key: "user", Type: StringType, String: "alice"},
+				{Key: "user", Type: StringType, String: "bob"},
+			},
+			want: "m,bob,\n",
+		},
+		{
+			desc:    "fields named after entry keys go to extras",
+			columns: []string{"msg"},
+			ent:     Entry{Message: "m"},
+			fields:  []Field{{Key: "msg", Type: StringType, String: "field"}},
+			want:    `m,"{""msg"":""field""}"` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := NewCSVEncoder(csvTestEncoderConfig(tt.columns...))
+			buf, err := enc.EncodeEntry(tt.ent, tt.fields)
+			require.NoError(t, err)
+			defer buf.Free()
+			assert.Equal(t, tt.want, buf.String())
+
+			// Every row must be valid CSV with a fixed number of columns.
+			records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+			require.NoError(t, err, "Expected valid CSV.")
+			require.Len(t, records, 1)
+			assert.Len(t, records[0], len(tt.columns)+1)
+		})
+	}
+}
+
+func TestCSVEncoderTSV(t *testing.T) {
+	cfg := csvTestEncoderConfig("msg", "user")
+	cfg.CSV.Delimiter = "\t"
+	enc := NewCSVEncoder(cfg)
+
+	buf, err := enc.EncodeEntry(Entry{Message: "a,b"}, []Field{
+		{Key: "user", Type: StringType, String: "tab\there"},
+	})
+	require.NoError(t, err)
+	defer buf.Free()
+	assert.Equal(t, "a,b\t\"tab\there\"\t\n", buf.String())
+}
+
+func TestCSVEncoderClone(t *testing.T) {
+	enc := NewCSVEncoder(csvTestEncoderConfig("msg", "user"))
+	enc.AddString("user", "alice")
+	enc.AddInt("attempt", 1)
+
+	clone := enc.Clone()
+	clone.AddString("user", "bob")
+	clone.AddInt("retry", 2)
+	enc.AddInt("other", 3)
+
+	encode := func(enc Encoder) string {
+		buf, err := enc.EncodeEntry(Entry{Message: "m"}, nil)
+		require.NoError(t, err)
+		defer buf.Free()
+		return buf.String()
+	}
+	assert.Equal(t, `m,alice,"{""attempt"":1,""other"":3}"`+"\n", encode(enc))
+	assert.Equal(t, `m,bob,"{""attempt"":1,""retry"":2}"`+"\n", encode(clone))
+}
+
+func TestCSVEncoderWith(t *testing.T) {
+	buf := &ztest.Buffer{}
+	core := NewCore(NewCSVEncoder(csvTestEncoderConfig("msg", "user")), buf, DebugLevel).
+		With([]Field{{Key: "user", Type: StringType, String: "alice"}})
+
+	require.NoError(t, core.Write(Entry{Message: "m"}, []Field{makeInt64Field("n", 1)}))
+	assert.Equal(t, []string{`m,alice,"{""n"":1}"`}, buf.Lines())
+}
+
+func TestCSVHeaderWriter(t *testing.T) {
+	cfg := csvTestEncoderConfig("ts", "msg", "a,b")
+	cfg.CSV.ExtrasColumn = "rest"
+
+	buf := &ztest.Buffer{}
+	ws := NewCSVHeaderWriter(buf, cfg)
+	for i := 0; i < 2; i++ {
+		_, err := ws.Write([]byte("row\n"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{`ts,msg,"a,b",rest`, "row", "row"}, buf.Lines())
+
+	t.Run("header write fails", func(t *testing.T) {
+		ws := NewCSVHeaderWriter(&ztest.FailWriter{}, cfg)
+		_, err := ws.Write([]byte("row\n"))
+		assert.Error(t, err)
+	})
+}
+
+func TestCSVEncoderMarshalError(t *testing.T) {
+	enc := NewCSVEncoder(csvTestEncoderConfig("msg", "obj"))
+	err := enc.AddObject("obj", ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+		enc.AddString("k", "v")
+		return errors.New("sadness")
+	}))
+	assert.EqualError(t, err, "sadness")
+
+	buf, err := enc.EncodeEntry(Entry{Message: "m"}, nil)
+	require.NoError(t, err)
+	defer buf.Free()
+	assert.Equal(t, `m,"{""k"":""v""}",`+"\n", buf.String())
+}
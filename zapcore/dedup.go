@@ -0,0 +1,478 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/toujourser/zap/internal/bufferpool"
+	"go.uber.org/multierr"
+)
+
+const _defaultDedupMaxKeys = 4096
+
+// _dedupEncoderConfig configures the encoder used to hash fields. Secrets are
+// revealed so that redacted fields with different values aren't merged; the
+// encoded fields are never written anywhere.
+var _dedupEncoderConfig = &EncoderConfig{
+	NewReflectedEncoder: defaultReflectedEncoder,
+	RevealSecrets:       true,
+}
+
+// dedupOptionFunc wraps a func so it satisfies the DedupOption interface.
+type dedupOptionFunc func(*deduper)
+
+func (f dedupOptionFunc) apply(d *deduper) {
+	f(d)
+}
+
+// DedupOption configures a deduplicating Core.
+type DedupOption interface {
+	apply(*deduper)
+}
+
+// DedupHook registers a function which will be called each time the
+// deduplicating Core writes an entry or drops a duplicate. The decision is
+// reported with the same values as the Sampler's: LogSampled for entries
+// that were written and LogDropped for duplicates.
+func DedupHook(hook func(entry Entry, dec SamplingDecision)) DedupOption {
+	return dedupOptionFunc(func(d *deduper) {
+		d.hook = hook
+	})
+}
+
+// DedupMaxKeys sets the maximum number of distinct entries the Core tracks at
+// once. When the limit is reached, entries whose window has expired are
+// forgotten; if none have, new entries are written without deduplication
+// until some do. Values less than one are ignored.
+//
+// Defaults to 4096.
+func DedupMaxKeys(n int) DedupOption {
+	return dedupOptionFunc(func(d *deduper) {
+		if n > 0 {
+			d.maxKeys = n
+		}
+	})
+}
+
+// DedupClock sets the Clock used to measure windows. By default, the Core
+// uses each entry's timestamp, and the system clock when syncing.
+func DedupClock(clock Clock) DedupOption {
+	return dedupOptionFunc(func(d *deduper) {
+		d.clock.Store(clock)
+	})
+}
+
+// NewDedupCore creates a Core that drops entries identical to one it wrote
+// less than window ago, such as the same error logged by every iteration of
+// a retry loop.
+//
+// The first entry with a given key is written as usual and starts a window.
+// Entries with the same key are dropped until the window expires. Once it
+// has, the last dropped duplicate is written with a "repeated" field holding
+// the number dropped. While any duplicates are pending, a goroutine checks
+// for expired windows once per window, using the Core's Clock; the next
+// entry with the key also writes the pending duplicate if it comes first, then
+// is written itself and starts a new window. Syncing the Core writes the
+// pending duplicates for all keys immediately and stops the goroutine.
+// Errors from writes made by the goroutine are returned by the next call to
+// Write or Sync.
+//
+// keyFn computes the key of an entry from the entry and its fields, starting
+// with those added with With. If it's nil, entries are keyed by their level,
+// logger name, message, and the encoded values of all their fields, so
+// entries with different field values are never merged. Custom key functions
+// may ignore some fields, such as request IDs, to merge entries that differ
+// only in those.
+//
+// Entries at PanicLevel and above are never dropped. If window isn't
+// positive, NewDedupCore returns the provided Core unchanged.
+func NewDedupCore(inner Core, window time.Duration, keyFn func(Entry, []Field) uint64, opts ...DedupOption) Core {
+	if window <= 0 {
+		return inner
+	}
+
+	d := &deduper{
+		window:  int64(window),
+		key:     keyFn,
+		hook:    nopSamplingHook,
+		clock:   new(clockRef),
+		maxKeys: _defaultDedupMaxKeys,
+		seen:    make(map[uint64]*dedupState),
+	}
+	for _, opt := range opts {
+		opt.apply(d)
+	}
+	return &dedupCore{
+		core:    inner,
+		deduper: d,
+		clock:   d.clock,
+		ctxHash: _fnv64Offset,
+	}
+}
+
+// deduper holds the state shared by a deduplicating Core and the Cores
+// derived from it with With.
+type deduper struct {
+	window  int64 // nanoseconds
+	key     func(Entry, []Field) uint64
+	hook    func(Entry, SamplingDecision)
+	clock   *clockRef
+	maxKeys int
+
+	mu       sync.Mutex
+	seen     map[uint64]*dedupState
+	stop     chan struct{} // closed to stop flushLoop; nil if it isn't running
+	flushErr error         // from the last write made by flushLoop
+}
+
+// dedupState tracks the current window of one key.
+type dedupState struct {
+	start    int64 // Unix nanoseconds
+	repeated uint64
+
+	// The last duplicate dropped in the window and the Core it was meant
+	// for. Only set if repeated is non-zero.
+	core   Core
+	ent    Entry
+	fields []Field
+}
+
+// take removes the pending duplicate from the state, if any, returning it as
+// a "repeated" entry.
+func (s *dedupState) take() (pending dedupPending, ok bool) {
+	if s.repeated == 0 {
+		return dedupPending{}, false
+	}
+	pending = dedupPending{
+		core: s.core,
+		ent:  s.ent,
+		fields: append(s.fields[:len(s.fields):len(s.fields)], Field{
+			Key:     "repeated",
+			Type:    Uint64Type,
+			Integer: int64(s.repeated),
+		}),
+	}
+	s.repeated = 0
+	s.core, s.ent, s.fields = nil, Entry{}, nil
+	return pending, true
+}
+
+// dedupPending is a "repeated" entry waiting to be written.
+type dedupPending struct {
+	core   Core
+	ent    Entry
+	fields []Field
+}
+
+func (p dedupPending) write() error {
+	return checkAndWrite(p.core, p.ent, p.fields)
+}
+
+// startFlushing starts flushLoop if it isn't running. It must be called with
+// d.mu held.
+func (d *deduper) startFlushing(clock Clock) {
+	if d.stop != nil {
+		return
+	}
+	if clock == nil {
+		clock = DefaultClock
+	}
+	d.stop = make(chan struct{})
+	go d.flushLoop(clock, clock.NewTicker(time.Duration(d.window)), d.stop)
+}
+
+// stopFlushing stops flushLoop if it's running. It must be called with d.mu
+// held.
+func (d *deduper) stopFlushing() {
+	if d.stop != nil {
+		close(d.stop)
+		d.stop = nil
+	}
+}
+
+// flushLoop writes the pending duplicates of expired windows on every tick,
+// until there are none left or stop is closed.
+func (d *deduper) flushLoop(clock Clock, ticker *time.Ticker, stop chan struct{}) {
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+
+		// Unlike Write and Sync, write while holding the lock, so that a
+		// concurrent Write of the same key can't overtake its duplicate.
+		d.mu.Lock()
+		for _, p := range d.evictExpired(clock.Now().UnixNano(), nil) {
+			d.flushErr = multierr.Append(d.flushErr, p.write())
+		}
+		done := !d.hasPending()
+		if done {
+			d.stopFlushing()
+		}
+		d.mu.Unlock()
+
+		if done {
+			return
+		}
+	}
+}
+
+// hasPending reports whether any key has a pending duplicate. It must be
+// called with d.mu held.
+func (d *deduper) hasPending() bool {
+	for _, s := range d.seen {
+		if s.repeated > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// takeFlushErr returns and clears the error from flushLoop's writes. It must
+// be called with d.mu held.
+func (d *deduper) takeFlushErr() error {
+	err := d.flushErr
+	d.flushErr = nil
+	return err
+}
+
+// evictExpired forgets keys whose window ended before now, returning their
+// pending duplicates. It must be called with d.mu held.
+func (d *deduper) evictExpired(now int64, pending []dedupPending) []dedupPending {
+	for key, s := range d.seen {
+		if now-s.start < d.window {
+			continue
+		}
+		if p, ok := s.take(); ok {
+			pending = append(pending, p)
+		}
+		delete(d.seen, key)
+	}
+	return pending
+}
+
+type dedupCore struct {
+	core    Core
+	deduper *deduper
+	clock   *clockRef // shared with the Cores derived with With
+
+	// fields were added with With. They're only kept if there is a custom
+	// key function to pass them to; otherwise only their hash is.
+	fields  []Field
+	ctxHash uint64
+}
+
+var (
+	_ Core           = (*dedupCore)(nil)
+	_ LeveledEnabler = (*dedupCore)(nil)
+	_ ClockCopier    = (*dedupCore)(nil)
+)
+
+func (c *dedupCore) Enabled(lvl Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *dedupCore) Level() Level {
+	return LevelOf(c.core)
+}
+
+func (c *dedupCore) WithClock(clock Clock) Core {
+	return &dedupCore{
+		core:    WithClock(c.core, clock),
+		deduper: c.deduper,
+		clock:   newClockRef(clock),
+		fields:  c.fields,
+		ctxHash: c.ctxHash,
+	}
+}
+
+// now returns the current time according to the Core's Clock, or ent's
+// timestamp if there is none.
+func (c *dedupCore) now(ent Entry) int64 {
+	if clock := c.clock.Load(); clock != nil {
+		return clock.Now().UnixNano()
+	}
+	return ent.Time.UnixNano()
+}
+
+func (c *dedupCore) With(fields []Field) Core {
+	clone := &dedupCore{
+		core:    c.core.With(fields),
+		deduper: c.deduper,
+		clock:   c.clock,
+		ctxHash: hashFields(c.ctxHash, fields),
+	}
+	if c.deduper.key != nil {
+		clone.fields = make([]Field, 0, len(c.fields)+len(fields))
+		clone.fields = append(clone.fields, c.fields...)
+		clone.fields = append(clone.fields, fields...)
+	}
+	return clone
+}
+
+func (c *dedupCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if ent.Level >= PanicLevel {
+		return c.core.Check(ent, ce)
+	}
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	// Keys depend on fields, so duplicates are detected in Write.
+	return ce.AddCore(ent, c)
+}
+
+func (c *dedupCore) Write(ent Entry, fields []Field) error {
+	d := c.deduper
+	key := c.key(ent, fields)
+	now := c.now(ent)
+
+	var (
+		pending []dedupPending
+		copied  []Field
+		isCopy  bool
+	)
+	d.mu.Lock()
+	for !isCopy {
+		if s, ok := d.seen[key]; !ok || now-s.start >= d.window {
+			break
+		}
+		// Duplicates are kept until the summary is written, but the caller
+		// may reuse fields once Write returns. Copying them runs marshalers,
+		// which may log, so the lock is released meanwhile.
+		d.mu.Unlock()
+		copied, isCopy = copyAsyncFields(fields), true
+		d.mu.Lock()
+	}
+	err := d.takeFlushErr()
+	s, ok := d.seen[key]
+	switch {
+	case ok && now-s.start < d.window:
+		s.repeated++
+		s.core, s.ent, s.fields = c.core, ent, copied
+		d.startFlushing(c.clock.Load())
+		d.mu.Unlock()
+		d.hook(ent, LogDropped)
+		return err
+	case ok:
+		if p, ok := s.take(); ok {
+			pending = append(pending, p)
+		}
+		s.start = now
+	default:
+		if len(d.seen) >= d.maxKeys {
+			pending = d.evictExpired(now, pending)
+		}
+		if len(d.seen) < d.maxKeys {
+			d.seen[key] = &dedupState{start: now}
+		}
+	}
+	d.mu.Unlock()
+
+	for _, p := range pending {
+		err = multierr.Append(err, p.write())
+	}
+	d.hook(ent, LogSampled)
+	return multierr.Append(err, checkAndWrite(c.core, ent, fields))
+}
+
+func (c *dedupCore) Sync() error {
+	d := c.deduper
+	clock := c.clock.Load()
+	if clock == nil {
+		clock = DefaultClock
+	}
+	now := clock.Now().UnixNano()
+
+	var pending []dedupPending
+	d.mu.Lock()
+	err := d.takeFlushErr()
+	pending = d.evictExpired(now, pending)
+	for _, s := range d.seen {
+		if p, ok := s.take(); ok {
+			pending = append(pending, p)
+		}
+	}
+	d.stopFlushing()
+	d.mu.Unlock()
+
+	for _, p := range pending {
+		err = multierr.Append(err, p.write())
+	}
+	return multierr.Append(err, c.core.Sync())
+}
+
+// key returns the deduplication key of an entry written to this Core.
+func (c *dedupCore) key(ent Entry, fields []Field) uint64 {
+	if fn := c.deduper.key; fn != nil {
+		if len(c.fields) > 0 {
+			all := make([]Field, 0, len(c.fields)+len(fields))
+			all = append(all, c.fields...)
+			fields = append(all, fields...)
+		}
+		return fn(ent, fields)
+	}
+
+	h := fnv64aByte(c.ctxHash, byte(ent.Level))
+	h = fnv64aString(h, ent.LoggerName)
+	h = fnv64aByte(h, 0)
+	h = fnv64aString(h, ent.Message)
+	h = fnv64aByte(h, 0)
+	return hashFields(h, fields)
+}
+
+// hashFields adds the encoded values of fields to the hash h.
+func hashFields(h uint64, fields []Field) uint64 {
+	if len(fields) == 0 {
+		return h
+	}
+
+	enc := _jsonPool.Get()
+	enc.EncoderConfig = _dedupEncoderConfig
+	enc.buf = bufferpool.Get()
+	addFields(enc, fields)
+	for _, b := range enc.buf.Bytes() {
+		h = fnv64aByte(h, b)
+	}
+	enc.buf.Free()
+	putJSONEncoder(enc)
+	return h
+}
+
+const (
+	_fnv64Offset = 14695981039346656037
+	_fnv64Prime  = 1099511628211
+)
+
+func fnv64aByte(h uint64, b byte) uint64 {
+	return (h ^ uint64(b)) * _fnv64Prime
+}
+
+func fnv64aString(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h = fnv64aByte(h, s[i])
+	}
+	return h
+}
@@ -0,0 +1,350 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zapcore/clock"
+	"github.com/toujourser/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDedupCore builds a deduplicating Core that is synced when the test
+// ends, which stops its background goroutine.
+func newDedupCore(t testing.TB, inner Core, window time.Duration, keyFn func(Entry, []Field) uint64, opts ...DedupOption) Core {
+	core := NewDedupCore(inner, window, keyFn, opts...)
+	t.Cleanup(func() { _ = core.Sync() })
+	return core
+}
+
+func repeatedCounts(entries []observer.LoggedEntry) []int64 {
+	counts := make([]int64, len(entries))
+	for i, e := range entries {
+		if n, ok := e.ContextMap()["repeated"]; ok {
+			counts[i] = int64(n.(uint64))
+		}
+	}
+	return counts
+}
+
+func TestDedupCore(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := newDedupCore(t, obs, time.Second, nil)
+
+	for i := 0; i < 5; i++ {
+		writeAt(core, InfoLevel, "retrying", time.Duration(i)*100*time.Millisecond)
+	}
+	assert.Equal(t, []string{"retrying"}, loggedMessages(logs), "Expected duplicates to be dropped.")
+
+	writeAt(core, InfoLevel, "retrying", 1500*time.Millisecond)
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2, "Expected the pending duplicate and the new entry.")
+	assert.Equal(t, []int64{4, 0}, repeatedCounts(entries), "Unexpected repeated counts.")
+	assert.Equal(t, _rateLimitEpoch.Add(400*time.Millisecond), entries[0].Time,
+		"Expected the last duplicate's timestamp.")
+
+	// A window without duplicates leaves nothing to report.
+	writeAt(core, InfoLevel, "retrying", 3*time.Second)
+	assert.Equal(t, []int64{0}, repeatedCounts(logs.TakeAll()))
+}
+
+func TestDedupCoreDistinctEntries(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := newDedupCore(t, obs, time.Minute, nil)
+
+	writeAt(core, InfoLevel, "msg", 0)
+	writeAt(core, WarnLevel, "msg", 0)
+	writeAt(core, InfoLevel, "other", 0)
+	writeAt(core, InfoLevel, "msg", 0, Field{Key: "attempt", Type: Int64Type, Integer: 1})
+	writeAt(core, InfoLevel, "msg", 0, Field{Key: "attempt", Type: Int64Type, Integer: 2})
+	writeAt(core, InfoLevel, "msg", 0, Field{Key: "pw", Type: RedactedType, String: "a"})
+	writeAt(core, InfoLevel, "msg", 0, Field{Key: "pw", Type: RedactedType, String: "b"})
+	writeAt(core.With([]Field{{Key: "user", Type: StringType, String: "a"}}), InfoLevel, "msg", 0)
+	writeAt(core.With([]Field{{Key: "user", Type: StringType, String: "b"}}), InfoLevel, "msg", 0)
+
+	named := Entry{Level: InfoLevel, Message: "msg", LoggerName: "named", Time: _rateLimitEpoch}
+	require.NotNil(t, core.Check(named, nil))
+	require.NoError(t, core.Write(named, nil))
+
+	assert.Equal(t, 10, logs.Len(), "Expected entries that differ in any way to be written.")
+
+	writeAt(core.With([]Field{{Key: "user", Type: StringType, String: "a"}}), InfoLevel, "msg", 0)
+	writeAt(core, InfoLevel, "msg", 0, Field{Key: "attempt", Type: Int64Type, Integer: 2})
+	assert.Equal(t, 10, logs.Len(), "Expected exact duplicates to be dropped.")
+}
+
+func TestDedupCoreKeyFunc(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	var seen [][]Field
+	core := newDedupCore(t, obs, time.Minute, func(ent Entry, fields []Field) uint64 {
+		seen = append(seen, fields)
+		return uint64(len(ent.Message))
+	})
+
+	ctx := core.With([]Field{{Key: "user", Type: StringType, String: "a"}})
+	writeAt(ctx, InfoLevel, "foo", 0, Field{Key: "request", Type: Int64Type, Integer: 1})
+	writeAt(core, InfoLevel, "bar", 0, Field{Key: "request", Type: Int64Type, Integer: 2})
+	writeAt(core, InfoLevel, "quux", 0)
+
+	assert.Equal(t, []string{"foo", "quux"}, loggedMessages(logs), "Expected entries to be merged by key.")
+	assert.Equal(t, [][]Field{
+		{{Key: "user", Type: StringType, String: "a"}, {Key: "request", Type: Int64Type, Integer: 1}},
+		{{Key: "request", Type: Int64Type, Integer: 2}},
+		nil,
+	}, seen, "Expected the key function to receive context and entry fields.")
+}
+
+func TestDedupCorePanicPassesThrough(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := newDedupCore(t, obs, time.Minute, nil)
+
+	for _, lvl := range []Level{PanicLevel, PanicLevel, FatalLevel, FatalLevel} {
+		ce := core.Check(Entry{Level: lvl, Message: "boom", Time: _rateLimitEpoch}, nil)
+		require.NotNil(t, ce, "Expected %v entries to be accepted.", lvl)
+		ce.Write()
+	}
+	assert.Equal(t, 4, logs.Len(), "Expected Panic and Fatal entries to never be dropped.")
+}
+
+func TestDedupCoreSync(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := newDedupCore(t, obs, time.Hour, nil, DedupClock(clock.NewMockAt(_rateLimitEpoch)))
+	ctx := core.With([]Field{{Key: "user", Type: StringType, String: "a"}})
+
+	for i := 0; i < 3; i++ {
+		writeAt(ctx, InfoLevel, "foo", 0, Field{Key: "n", Type: Int64Type, Integer: 1})
+		writeAt(core, WarnLevel, "bar", 0)
+	}
+	writeAt(core, InfoLevel, "once", 0)
+	logs.TakeAll()
+
+	require.NoError(t, core.Sync())
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2, "Expected pending duplicates to be written on sync.")
+	byMessage := make(map[string]observer.LoggedEntry)
+	for _, e := range entries {
+		byMessage[e.Message] = e
+	}
+	assert.Equal(t, map[string]interface{}{"user": "a", "n": int64(1), "repeated": uint64(2)},
+		byMessage["foo"].ContextMap(), "Expected the duplicate's context and fields.")
+	assert.Equal(t, map[string]interface{}{"repeated": uint64(2)}, byMessage["bar"].ContextMap())
+
+	require.NoError(t, core.Sync())
+	assert.Equal(t, 0, logs.Len(), "Expected nothing left to write.")
+
+	// Windows keep running after a sync.
+	writeAt(core, WarnLevel, "bar", 0)
+	assert.Equal(t, 0, logs.Len(), "Expected duplicates within the window to be dropped.")
+}
+
+func TestDedupCoreCopiesFields(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := newDedupCore(t, obs, time.Hour, nil, DedupClock(clock.NewMockAt(_rateLimitEpoch)))
+
+	buf := []byte("abc")
+	for i := 0; i < 2; i++ {
+		writeAt(core, InfoLevel, "foo", 0, Field{Key: "b", Type: ByteStringType, Interface: buf})
+	}
+	copy(buf, "xyz")
+	logs.TakeAll()
+
+	require.NoError(t, core.Sync())
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1, "Expected the pending duplicate.")
+	assert.Equal(t, "abc", entries[0].ContextMap()["b"], "Expected the duplicate's fields to be copied.")
+}
+
+func TestDedupCoreFlushesExpiredWindows(t *testing.T) {
+	clk := clock.NewMockAt(_rateLimitEpoch)
+	obs, logs := observer.New(DebugLevel)
+	core := newDedupCore(t, obs, time.Minute, nil, DedupClock(clk))
+
+	for i := 0; i < 3; i++ {
+		writeAt(core, InfoLevel, "foo", 0)
+	}
+	assert.Equal(t, []string{"foo"}, loggedMessages(logs))
+
+	clk.Add(time.Minute)
+	require.Eventually(t, func() bool { return logs.Len() > 0 }, time.Second, time.Millisecond,
+		"Expected the pending duplicate to be written when the window expires.")
+	entries := logs.TakeAll()
+	assert.Equal(t, []int64{2}, repeatedCounts(entries))
+	assert.Equal(t, "foo", entries[0].Message)
+
+	// The window is over, so the next entry is written as usual.
+	writeAt(core, InfoLevel, "foo", 0)
+	assert.Equal(t, []int64{0}, repeatedCounts(logs.TakeAll()))
+}
+
+func TestDedupCoreFlushError(t *testing.T) {
+	clk := clock.NewMockAt(_rateLimitEpoch)
+	errCore := &toggleErrCore{Core: NewNopCore()}
+	core := newDedupCore(t, errCore, time.Minute, nil, DedupClock(clk))
+
+	writeAt(core, InfoLevel, "foo", 0)
+	writeAt(core, InfoLevel, "foo", 0)
+	errCore.err.Store(errors.New("fail"))
+
+	clk.Add(time.Minute)
+	require.Eventually(t, func() bool { return errCore.writes.Load() == 2 }, time.Second, time.Millisecond,
+		"Expected the pending duplicate to be written when the window expires.")
+	assert.ErrorContains(t, core.Sync(), "fail", "Expected the background write error on sync.")
+}
+
+// toggleErrCore is a Core whose writes fail once err is set.
+type toggleErrCore struct {
+	Core
+
+	err    atomic.Value // error
+	writes atomic.Int64
+}
+
+func (c *toggleErrCore) Enabled(Level) bool { return true }
+
+func (c *toggleErrCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *toggleErrCore) Write(Entry, []Field) error {
+	c.writes.Add(1)
+	err, _ := c.err.Load().(error)
+	return err
+}
+
+func TestDedupCoreHook(t *testing.T) {
+	var written, dropped atomic.Int64
+	hook := func(_ Entry, dec SamplingDecision) {
+		if dec&LogDropped > 0 {
+			dropped.Add(1)
+		}
+		if dec&LogSampled > 0 {
+			written.Add(1)
+		}
+	}
+	obs, _ := observer.New(DebugLevel)
+	core := newDedupCore(t, obs, time.Minute, nil, DedupHook(hook))
+	for i := 0; i < 10; i++ {
+		writeAt(core, InfoLevel, "foo", 0)
+	}
+	writeAt(core, InfoLevel, "bar", 0)
+
+	assert.Equal(t, int64(2), written.Load(), "Unexpected number of written entries.")
+	assert.Equal(t, int64(9), dropped.Load(), "Unexpected number of dropped entries.")
+}
+
+func TestDedupCoreMaxKeys(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := newDedupCore(t, obs, time.Second, nil, DedupMaxKeys(2))
+
+	writeAt(core, InfoLevel, "a", 0)
+	writeAt(core, InfoLevel, "a", 0)
+	writeAt(core, InfoLevel, "b", 0)
+	writeAt(core, InfoLevel, "c", 0) // table full: not tracked
+	writeAt(core, InfoLevel, "c", 0)
+	assert.Equal(t, []string{"a", "b", "c", "c"}, loggedMessages(logs))
+
+	// Once windows expire, their keys make room, flushing duplicates.
+	writeAt(core, InfoLevel, "c", 2*time.Second)
+	writeAt(core, InfoLevel, "c", 2*time.Second)
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "a", entries[0].Message)
+	assert.Equal(t, []int64{1, 0}, repeatedCounts(entries))
+	assert.Equal(t, "c", entries[1].Message)
+}
+
+func TestDedupCoreClock(t *testing.T) {
+	clk := clock.NewMockAt(_rateLimitEpoch)
+	obs, logs := observer.New(DebugLevel)
+	core := WithClock(newDedupCore(t, obs, time.Minute, nil), clk)
+
+	// Entry timestamps are ignored in favor of the clock.
+	writeAt(core, InfoLevel, "foo", 0)
+	writeAt(core, InfoLevel, "foo", time.Hour)
+	assert.Equal(t, []string{"foo"}, loggedMessages(logs))
+
+	clk.Add(time.Minute)
+	writeAt(core, InfoLevel, "foo", 0)
+	assert.Equal(t, []int64{1, 0}, repeatedCounts(logs.TakeAll()))
+}
+
+func TestDedupCoreDisabled(t *testing.T) {
+	obs, _ := observer.New(InfoLevel)
+	assert.Equal(t, obs, NewDedupCore(obs, 0, nil), "Expected a non-positive window to return the core.")
+
+	core := newDedupCore(t, obs, time.Minute, nil)
+	assert.Equal(t, InfoLevel, LevelOf(core))
+	assert.Nil(t, core.Check(Entry{Level: DebugLevel}, nil), "Expected disabled entries to be dropped.")
+}
+
+func TestDedupCoreWriteError(t *testing.T) {
+	errCore := errWriteCore{Core: NewNopCore(), err: errors.New("fail")}
+	core := newDedupCore(t, errCore, time.Minute, nil)
+	writeAt(core, InfoLevel, "foo", 0)
+	ent := Entry{Level: InfoLevel, Message: "bar", Time: _rateLimitEpoch}
+	assert.ErrorContains(t, core.Write(ent, nil), "fail")
+	assert.NoError(t, core.Write(ent, nil), "Expected dropped duplicates to succeed.")
+}
+
+type errWriteCore struct {
+	Core
+
+	err error
+}
+
+func (c errWriteCore) Enabled(Level) bool { return true }
+
+func (c errWriteCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c errWriteCore) Write(Entry, []Field) error { return c.err }
+
+func TestDedupCoreConcurrent(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := newDedupCore(t, obs, time.Hour, nil, DedupClock(clock.NewMockAt(_rateLimitEpoch)))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				writeAt(core, InfoLevel, "foo", 0)
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, core.Sync())
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2, "Expected the entry and one repeated summary.")
+	assert.ElementsMatch(t, []int64{0, 799}, repeatedCounts(entries))
+}
@@ -0,0 +1,283 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// DroppingOption configures a DroppingWriteSyncer.
+type DroppingOption interface {
+	apply(*DroppingWriteSyncer)
+}
+
+// droppingOptionFunc wraps a func so it satisfies the DroppingOption
+// interface.
+type droppingOptionFunc func(*DroppingWriteSyncer)
+
+func (f droppingOptionFunc) apply(s *DroppingWriteSyncer) {
+	f(s)
+}
+
+// DroppingReportEncoder makes the DroppingWriteSyncer report dropped entries
+// once the wrapped WriteSyncer accepts writes again, as a WarnLevel entry
+// encoded with enc. The entry has "droppedEntries" and "droppedBytes" fields
+// holding the number of entries and bytes dropped since the last report.
+//
+// Use the Encoder of the Core that writes to the DroppingWriteSyncer, so
+// that the report has the same format as the other entries.
+func DroppingReportEncoder(enc Encoder) DroppingOption {
+	return droppingOptionFunc(func(s *DroppingWriteSyncer) {
+		s.report = enc
+	})
+}
+
+// A DroppingWriteSyncer is a WriteSyncer that never blocks the goroutine
+// doing the logging. Writes are queued in memory and written to a wrapped
+// WriteSyncer from a dedicated goroutine; if the wrapped WriteSyncer falls
+// behind (e.g., because the pipe behind a container's standard output is
+// full) and the queue fills up, further writes are dropped.
+//
+// Each call to Write is treated as a single log entry: it's either queued in
+// full or dropped in full, so partial lines are never written. Use Dropped
+// and DroppedBytes to monitor drops, or DroppingReportEncoder to have a
+// single entry reporting the drops since the last report written after the
+// queued entries once the wrapped WriteSyncer accepts writes again.
+//
+// DroppingWriteSyncer is safe for concurrent use. Call Stop when it's no
+// longer needed to write the remaining entries and release its goroutine.
+type DroppingWriteSyncer struct {
+	ws     WriteSyncer
+	size   int
+	report Encoder // encodes drop reports; nil if they're not written
+
+	mu      sync.Mutex
+	idle    *sync.Cond // signaled when the writer goroutine runs out of work
+	queue   []byte     // entries waiting to be written; never above size
+	spare   []byte     // reused for the next queue
+	writing bool       // whether the writer goroutine is writing a batch
+	stopped bool
+	err     error // write errors since the last Sync or Stop
+
+	// Drops not yet reported to ws. Drops whose report failed are carried
+	// over to the next report, but don't make the writer goroutine retry
+	// it on their own.
+	unreportedEntries uint64
+	unreportedBytes   uint64
+	carriedEntries    uint64
+	carriedBytes      uint64
+
+	droppedEntries atomic.Uint64
+	droppedBytes   atomic.Uint64
+
+	wake     chan struct{} // signals the writer goroutine that data is queued
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+var _ WriteSyncer = (*DroppingWriteSyncer)(nil)
+
+// NewDroppingWriteSyncer builds a DroppingWriteSyncer that queues up to
+// queueBytes of log entries for ws, and starts its writer goroutine. Entries
+// larger than queueBytes are always dropped. Non-positive values use a
+// default of 256 kB.
+func NewDroppingWriteSyncer(ws WriteSyncer, queueBytes int, opts ...DroppingOption) *DroppingWriteSyncer {
+	if queueBytes <= 0 {
+		queueBytes = _defaultBufferSize
+	}
+	s := &DroppingWriteSyncer{
+		ws:    ws,
+		size:  queueBytes,
+		queue: make([]byte, 0, queueBytes),
+		spare: make([]byte, 0, queueBytes),
+		wake:  make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+	s.idle = sync.NewCond(&s.mu)
+	go s.run()
+	return s
+}
+
+// Write queues a copy of bs to be written, or drops it if there isn't room
+// in the queue. It never returns an error; errors from the wrapped
+// WriteSyncer are reported by the next call to Sync or Stop.
+//
+// After Stop, Write writes to the wrapped WriteSyncer directly.
+func (s *DroppingWriteSyncer) Write(bs []byte) (int, error) {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return s.ws.Write(bs)
+	}
+	if len(s.queue)+len(bs) > s.size {
+		if s.report != nil {
+			s.unreportedEntries++
+			s.unreportedBytes += uint64(len(bs))
+		}
+		s.mu.Unlock()
+
+		s.droppedEntries.Add(1)
+		s.droppedBytes.Add(uint64(len(bs)))
+		return len(bs), nil
+	}
+	s.queue = append(s.queue, bs...)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return len(bs), nil
+}
+
+// Sync waits for the queued entries to be written, then syncs the wrapped
+// WriteSyncer. Unlike Write, Sync blocks for as long as the wrapped
+// WriteSyncer does.
+func (s *DroppingWriteSyncer) Sync() error {
+	return multierr.Append(s.wait(), s.ws.Sync())
+}
+
+// Stop writes the queued entries, stops the writer goroutine, and syncs the
+// wrapped WriteSyncer. Stop is safe to call multiple times.
+func (s *DroppingWriteSyncer) Stop() error {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+	return s.Sync()
+}
+
+// Dropped reports the number of entries dropped because the queue was full.
+func (s *DroppingWriteSyncer) Dropped() uint64 {
+	return s.droppedEntries.Load()
+}
+
+// DroppedBytes reports the total size of the entries dropped because the
+// queue was full.
+func (s *DroppingWriteSyncer) DroppedBytes() uint64 {
+	return s.droppedBytes.Load()
+}
+
+// wait blocks until the writer goroutine is idle, and returns the write
+// errors since the last call.
+func (s *DroppingWriteSyncer) wait() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.writing || s.pending() {
+		s.idle.Wait()
+	}
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// pending reports whether there's anything left for the writer goroutine to
+// write. s.mu must be held.
+func (s *DroppingWriteSyncer) pending() bool {
+	return len(s.queue) > 0 || s.unreportedEntries > 0
+}
+
+func (s *DroppingWriteSyncer) run() {
+	defer close(s.done)
+
+	for {
+		select {
+		case <-s.wake:
+			s.drain(false)
+		case <-s.stop:
+			s.drain(true)
+			return
+		}
+	}
+}
+
+// drain writes queued entries until the queue is empty. If stop is set,
+// later writes bypass the queue.
+func (s *DroppingWriteSyncer) drain(stop bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.pending() {
+		batch := s.queue
+		s.queue = s.spare
+		s.spare = nil
+
+		entries := s.unreportedEntries + s.carriedEntries
+		bytes := s.unreportedBytes + s.carriedBytes
+		s.unreportedEntries, s.unreportedBytes = 0, 0
+		s.carriedEntries, s.carriedBytes = 0, 0
+
+		s.writing = true
+		s.mu.Unlock()
+		err := s.write(batch, entries, bytes)
+		s.mu.Lock()
+		s.writing = false
+
+		s.spare = batch[:0]
+		if err != nil {
+			// Report the drops with the next batch.
+			s.carriedEntries += entries
+			s.carriedBytes += bytes
+			s.err = multierr.Append(s.err, err)
+		}
+	}
+	s.stopped = stop
+	s.idle.Broadcast()
+}
+
+// write writes a batch of entries and, if it succeeds, a report of the
+// entries dropped before it. If it fails, the caller carries the drops over
+// to the next report.
+func (s *DroppingWriteSyncer) write(batch []byte, entries, bytes uint64) error {
+	if len(batch) > 0 {
+		if _, err := s.ws.Write(batch); err != nil {
+			return err
+		}
+	}
+	if entries == 0 {
+		return nil
+	}
+
+	buf, err := s.report.EncodeEntry(Entry{
+		Level:   WarnLevel,
+		Time:    time.Now(),
+		Message: "dropped log entries while the output was blocked",
+	}, []Field{
+		{Key: "droppedEntries", Type: Uint64Type, Integer: int64(entries)},
+		{Key: "droppedBytes", Type: Uint64Type, Integer: int64(bytes)},
+	})
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	_, err = s.ws.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap/internal/ztest"
+)
+
+// _droppingReportEncoder encodes drop reports without timestamps, so that
+// tests can compare them.
+var _droppingReportEncoder = NewJSONEncoder(EncoderConfig{MessageKey: "msg"})
+
+const _droppingReport = `{"msg":"dropped log entries while the output was blocked","droppedEntries":%d,"droppedBytes":%d}`
+
+func TestDroppingWriteSyncer(t *testing.T) {
+	t.Run("writes through", func(t *testing.T) {
+		buf := &ztest.Buffer{}
+		ws := NewDroppingWriteSyncer(buf, 0)
+		defer func() { assert.NoError(t, ws.Stop()) }()
+
+		for i := 0; i < 3; i++ {
+			n, err := fmt.Fprintf(ws, "entry %d\n", i)
+			require.NoError(t, err)
+			assert.Equal(t, 8, n, "Unexpected number of bytes written.")
+		}
+		require.NoError(t, ws.Sync(), "Unexpected error syncing.")
+		assert.Equal(t, []string{"entry 0", "entry 1", "entry 2"}, buf.Lines())
+		assert.True(t, buf.Called(), "Expected Sync to sync the wrapped WriteSyncer.")
+		assert.Zero(t, ws.Dropped(), "Unexpected drops.")
+	})
+
+	t.Run("drops entries larger than the queue", func(t *testing.T) {
+		buf := &ztest.Buffer{}
+		ws := NewDroppingWriteSyncer(buf, 8, DroppingReportEncoder(_droppingReportEncoder))
+		defer func() { assert.NoError(t, ws.Stop()) }()
+
+		_, err := ws.Write([]byte("much too long\n"))
+		require.NoError(t, err)
+		_, err = ws.Write([]byte("fits\n"))
+		require.NoError(t, err)
+		require.NoError(t, ws.Sync())
+
+		assert.Equal(t, []string{
+			"fits",
+			fmt.Sprintf(_droppingReport, 1, 14),
+		}, buf.Lines())
+		assert.Equal(t, uint64(1), ws.Dropped(), "Unexpected number of dropped entries.")
+		assert.Equal(t, uint64(14), ws.DroppedBytes(), "Unexpected number of dropped bytes.")
+	})
+
+	t.Run("doesn't report drops without an encoder", func(t *testing.T) {
+		buf := &ztest.Buffer{}
+		ws := NewDroppingWriteSyncer(buf, 8)
+		defer func() { assert.NoError(t, ws.Stop()) }()
+
+		_, err := ws.Write([]byte("much too long\n"))
+		require.NoError(t, err)
+		_, err = ws.Write([]byte("fits\n"))
+		require.NoError(t, err)
+		require.NoError(t, ws.Sync())
+
+		assert.Equal(t, []string{"fits"}, buf.Lines())
+		assert.Equal(t, uint64(1), ws.Dropped(), "Unexpected number of dropped entries.")
+	})
+
+	t.Run("keeps drops when the write fails", func(t *testing.T) {
+		buf := &ztest.Buffer{}
+		fw := &flakyWriter{Buffer: buf, fail: true}
+		ws := NewDroppingWriteSyncer(fw, 8, DroppingReportEncoder(_droppingReportEncoder))
+		defer func() { assert.NoError(t, ws.Stop()) }()
+
+		_, err := ws.Write([]byte("much too long\n"))
+		require.NoError(t, err)
+		_, err = ws.Write([]byte("lost\n"))
+		require.NoError(t, err)
+		assert.Error(t, ws.Sync(), "Expected the write error.")
+
+		fw.setFail(false)
+		_, err = ws.Write([]byte("fits\n"))
+		require.NoError(t, err)
+		require.NoError(t, ws.Sync())
+		assert.Equal(t, []string{
+			"fits",
+			fmt.Sprintf(_droppingReport, 1, 14),
+		}, buf.Lines(), "Expected the drops to be reported after the next batch.")
+	})
+
+	t.Run("reports write errors", func(t *testing.T) {
+		ws := NewDroppingWriteSyncer(&ztest.FailWriter{}, 0)
+		_, err := ws.Write([]byte("foo\n"))
+		require.NoError(t, err, "Write should never fail.")
+		assert.Error(t, ws.Sync(), "Expected the write error to be reported by Sync.")
+		assert.NoError(t, ws.Sync(), "Expected the write error to be reported once.")
+		assert.NoError(t, ws.Stop())
+	})
+
+	t.Run("writes directly after stop", func(t *testing.T) {
+		buf := &ztest.Buffer{}
+		ws := NewDroppingWriteSyncer(buf, 0)
+		_, err := ws.Write([]byte("before\n"))
+		require.NoError(t, err)
+		require.NoError(t, ws.Stop())
+		require.NoError(t, ws.Stop(), "Stop should be idempotent.")
+
+		_, err = ws.Write([]byte("after\n"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"before", "after"}, buf.Lines())
+	})
+}
+
+func TestDroppingWriteSyncerBlockedPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	// Hide the pipe's Sync method, which fails for pipes.
+	ws := NewDroppingWriteSyncer(AddSync(struct{ io.Writer }{w}), 1024, DroppingReportEncoder(_droppingReportEncoder))
+
+	// Nothing reads from the pipe yet, so it fills up after its internal
+	// buffer (64 kB on Linux) and blocks the writer goroutine.
+	const numEntries = 5000
+	entry := func(i int) string {
+		return fmt.Sprintf("entry %04d %s\n", i, strings.Repeat("x", 88))
+	}
+	start := time.Now()
+	for i := 0; i < numEntries; i++ {
+		_, err := ws.Write([]byte(entry(i)))
+		require.NoError(t, err)
+	}
+	elapsed := time.Since(start)
+	assert.Less(t, elapsed, time.Second, "Expected writes to a blocked pipe to return quickly.")
+
+	dropped := ws.Dropped()
+	require.NotZero(t, dropped, "Expected entries to be dropped.")
+	assert.Equal(t, dropped*uint64(len(entry(0))), ws.DroppedBytes(), "Unexpected number of dropped bytes.")
+
+	// Unblock the pipe.
+	output := make(chan []byte, 1)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- data
+	}()
+	require.NoError(t, ws.Stop(), "Unexpected error stopping.")
+	require.NoError(t, w.Close())
+	data := <-output
+
+	entryRe := regexp.MustCompile(`^entry (\d{4}) x{88}$`)
+	reportRe := regexp.MustCompile(`^{"msg":"dropped log entries while the output was blocked","droppedEntries":(\d+),"droppedBytes":(\d+)}$`)
+	var written, reported uint64
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		if entryRe.MatchString(line) {
+			written++
+			continue
+		}
+		m := reportRe.FindStringSubmatch(line)
+		require.NotNil(t, m, "Unexpected partial or corrupt line %q.", line)
+		n, err := strconv.ParseUint(m[1], 10, 64)
+		require.NoError(t, err)
+		reported += n
+	}
+	assert.Equal(t, dropped, reported, "Expected every dropped entry to be reported.")
+	assert.Equal(t, uint64(numEntries), written+dropped, "Expected every entry to be written or dropped.")
+	assert.True(t, bytes.HasPrefix(lastLine(data), []byte(`{"msg":"dropped`)), "Expected the drop report after the queued entries.")
+}
+
+func lastLine(data []byte) []byte {
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	return data[bytes.LastIndexByte(data, '\n')+1:]
+}
+
+// flakyWriter is a WriteSyncer whose writes fail while fail is set.
+type flakyWriter struct {
+	*ztest.Buffer
+
+	mu   sync.Mutex
+	fail bool
+}
+
+func (w *flakyWriter) setFail(fail bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.fail = fail
+}
+
+func (w *flakyWriter) Write(bs []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.fail {
+		return 0, errors.New("failed")
+	}
+	return w.Buffer.Write(bs)
+}
@@ -0,0 +1,268 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zapcore
+
+import (
+	"strings"
+	"time"
+
+	"github.com/toujourser/zap/buffer"
+)
+
+const (
+	// _ecsVersion is the version of the Elastic Common Schema that the ECS
+	// encoder follows.
+	_ecsVersion = "1.6.0"
+
+	// _ecsErrorKey is the key of zap.Error fields, which the ECS encoder
+	// writes as the ECS error.message field.
+	_ecsErrorKey = "error"
+
+	_ecsTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// ECSTimeEncoder serializes a time.Time as an ECS @timestamp: an RFC 3339
+// string in UTC with millisecond precision, such as
+// "2023-11-14T22:13:20.500Z".
+func ECSTimeEncoder(t time.Time, enc PrimitiveArrayEncoder) {
+	encodeTimeLayout(t.UTC(), _ecsTimeLayout, enc)
+}
+
+// ecsEncoder writes entries in the Elastic Common Schema (ECS) layout. It
+// writes the JSON itself through a jsonEncoder, and translates the keys of
+// top-level fields.
+type ecsEncoder struct {
+	*EncoderConfig
+	json *jsonEncoder
+
+	// namespaced is set once a namespace is opened. Keys in namespaces
+	// aren't translated.
+	namespaced bool
+}
+
+// NewECSEncoder creates an encoder that serializes entries as JSON in the
+// layout of the Elastic Common Schema (ECS), for ingestion into
+// Elasticsearch, for example
+//
+//	{"@timestamp":"2023-11-14T22:13:20.500Z","log.level":"error","message":"failed","ecs.version":"1.6.0","log.logger":"app","log.origin.file.name":"app/main.go","log.origin.file.line":42,"error.message":"boom"}
+//
+// Use it with the EncoderConfig returned by zap.NewECSEncoderConfig, which
+// sets the keys of the entry's time, level, message, logger name, function,
+// and stacktrace to their ECS names. As ECS requires, the time, level, and
+// message are written first, followed by the ECS version. The caller is
+// written as two fields: the file, as a package/file path, under CallerKey,
+// and the line under "log.origin.file.line". EncodeCaller isn't used.
+// EncodeTime defaults to ECSTimeEncoder and EncodeLevel to
+// LowercaseLevelEncoder.
+//
+// Fields with the key "error", such as those added by zap.Error, are
+// written as the ECS error.message field. Other fields are written at the
+// top level, with ECSFieldPrefix prepended to their keys if it's set, so
+// that they don't collide with ECS fields. Keys inside objects and
+// namespaces are written as they are.
+func NewECSEncoder(cfg EncoderConfig) Encoder {
+	return newECSEncoder(cfg)
+}
+
+func newECSEncoder(cfg EncoderConfig) *ecsEncoder {
+	if cfg.EncodeTime == nil {
+		cfg.EncodeTime = ECSTimeEncoder
+	}
+	if cfg.EncodeLevel == nil {
+		cfg.EncodeLevel = LowercaseLevelEncoder
+	}
+	json := newJSONEncoder(cfg, false /* spaced */)
+	return &ecsEncoder{
+		EncoderConfig: json.EncoderConfig,
+		json:          json,
+	}
+}
+
+// key translates the key of a field into its ECS name.
+func (enc *ecsEncoder) key(key string) string {
+	if enc.namespaced {
+		return key
+	}
+	if key == _ecsErrorKey {
+		return "error.message"
+	}
+	return enc.ECSFieldPrefix + key
+}
+
+func (enc *ecsEncoder) AddArray(key string, arr ArrayMarshaler) error {
+	return enc.json.AddArray(enc.key(key), arr)
+}
+
+func (enc *ecsEncoder) AddObject(key string, obj ObjectMarshaler) error {
+	return enc.json.AddObject(enc.key(key), obj)
+}
+
+func (enc *ecsEncoder) AddBinary(key string, val []byte) {
+	enc.json.AddBinary(enc.key(key), val)
+}
+
+func (enc *ecsEncoder) AddByteString(key string, val []byte) {
+	enc.json.AddByteString(enc.key(key), val)
+}
+
+func (enc *ecsEncoder) AddBool(key string, val bool) {
+	enc.json.AddBool(enc.key(key), val)
+}
+
+func (enc *ecsEncoder) AddComplex128(key string, val complex128) {
+	enc.json.AddComplex128(enc.key(key), val)
+}
+
+func (enc *ecsEncoder) AddComplex64(key string, val complex64) {
+	enc.json.AddComplex64(enc.key(key), val)
+}
+
+func (enc *ecsEncoder) AddDuration(key string, val time.Duration) {
+	enc.json.AddDuration(enc.key(key), val)
+}
+
+func (enc *ecsEncoder) AddFloat64(key string, val float64) {
+	enc.json.AddFloat64(enc.key(key), val)
+}
+
+func (enc *ecsEncoder) AddFloat32(key string, val float32) {
+	enc.json.AddFloat32(enc.key(key), val)
+}
+
+func (enc *ecsEncoder) AddInt64(key string, val int64) {
+	enc.json.AddInt64(enc.key(key), val)
+}
+
+func (enc *ecsEncoder) AddReflected(key string, obj interface{}) error {
+	return enc.json.AddReflected(enc.key(key), obj)
+}
+
+func (enc *ecsEncoder) OpenNamespace(key string) {
+	enc.json.OpenNamespace(enc.key(key))
+	enc.namespaced = true
+}
+
+func (enc *ecsEncoder) AddString(key, val string) {
+	enc.json.AddString(enc.key(key), val)
+}
+
+func (enc *ecsEncoder) AddTime(key string, val time.Time) {
+	enc.json.AddTime(enc.key(key), val)
+}
+
+func (enc *ecsEncoder) AddUint64(key string, val uint64) {
+	enc.json.AddUint64(enc.key(key), val)
+}
+
+func (enc *ecsEncoder) AddInt(k string, v int)         { enc.AddInt64(k, int64(v)) }
+func (enc *ecsEncoder) AddInt32(k string, v int32)     { enc.AddInt64(k, int64(v)) }
+func (enc *ecsEncoder) AddInt16(k string, v int16)     { enc.AddInt64(k, int64(v)) }
+func (enc *ecsEncoder) AddInt8(k string, v int8)       { enc.AddInt64(k, int64(v)) }
+func (enc *ecsEncoder) AddUint(k string, v uint)       { enc.AddUint64(k, uint64(v)) }
+func (enc *ecsEncoder) AddUint32(k string, v uint32)   { enc.AddUint64(k, uint64(v)) }
+func (enc *ecsEncoder) AddUint16(k string, v uint16)   { enc.AddUint64(k, uint64(v)) }
+func (enc *ecsEncoder) AddUint8(k string, v uint8)     { enc.AddUint64(k, uint64(v)) }
+func (enc *ecsEncoder) AddUintptr(k string, v uintptr) { enc.AddUint64(k, uint64(v)) }
+
+func (enc *ecsEncoder) Clone() Encoder {
+	clone := enc.clone()
+	clone.json.buf.Write(enc.json.buf.Bytes())
+	return clone
+}
+
+func (enc *ecsEncoder) clone() *ecsEncoder {
+	return &ecsEncoder{
+		EncoderConfig: enc.EncoderConfig,
+		json:          enc.json.clone(),
+		namespaced:    enc.namespaced,
+	}
+}
+
+func (enc *ecsEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer, error) {
+	final := enc.clone()
+	json := final.json
+	json.buf.AppendByte('{')
+
+	// The entry's own keys are never in a namespace.
+	namespace := json.namespace
+	json.namespace = ""
+
+	if final.TimeKey != "" && !ent.Time.IsZero() {
+		json.AddTime(final.TimeKey, ent.Time)
+	}
+	if final.LevelKey != "" {
+		json.addKey(final.LevelKey)
+		cur := json.buf.Len()
+		final.EncodeLevel(ent.Level, json)
+		if cur == json.buf.Len() {
+			json.AppendString(ent.Level.String())
+		}
+	}
+	if final.MessageKey != "" {
+		json.addKey(final.MessageKey)
+		json.AppendString(ent.Message)
+	}
+	json.addKey("ecs.version")
+	json.AppendString(_ecsVersion)
+
+	if ent.LoggerName != "" && final.NameKey != "" {
+		json.addKey(final.NameKey)
+		cur := json.buf.Len()
+		nameEncoder := final.EncodeName
+		if nameEncoder == nil {
+			nameEncoder = FullNameEncoder
+		}
+		nameEncoder(ent.LoggerName, json)
+		if cur == json.buf.Len() {
+			json.AppendString(ent.LoggerName)
+		}
+	}
+	if ent.Caller.Defined {
+		if final.CallerKey != "" {
+			file := ent.Caller.TrimmedPath()
+			if i := strings.LastIndexByte(file, ':'); i >= 0 {
+				file = file[:i]
+			}
+			json.AddString(final.CallerKey, file)
+			json.AddInt("log.origin.file.line", ent.Caller.Line)
+		}
+		if final.FunctionKey != "" {
+			json.AddString(final.FunctionKey, ent.Caller.Function)
+		}
+	}
+
+	if enc.json.buf.Len() > 0 {
+		json.addElementSeparator()
+		json.buf.Write(enc.json.buf.Bytes())
+	}
+	json.namespace = namespace
+	addFields(final, fields)
+	json.closeOpenNamespaces()
+	json.namespace = ""
+	if ent.Stack != "" && final.StacktraceKey != "" {
+		json.AddString(final.StacktraceKey, ent.Stack)
+	}
+	json.buf.AppendByte('}')
+	json.buf.AppendString(final.LineEnding)
+
+	ret := json.buf
+	putJSONEncoder(json)
+	return ret, nil
+}
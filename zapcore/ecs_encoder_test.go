@@ -0,0 +1,184 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zapcore
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testECSEncoderConfig matches zap.NewECSEncoderConfig, which can't be
+// imported here.
+func testECSEncoderConfig() EncoderConfig {
+	return EncoderConfig{
+		TimeKey:        "@timestamp",
+		LevelKey:       "log.level",
+		NameKey:        "log.logger",
+		CallerKey:      "log.origin.file.name",
+		FunctionKey:    "log.origin.function",
+		MessageKey:     "message",
+		StacktraceKey:  "error.stack_trace",
+		EncodeLevel:    LowercaseLevelEncoder,
+		EncodeTime:     ECSTimeEncoder,
+		EncodeDuration: NanosDurationEncoder,
+		EncodeCaller:   ShortCallerEncoder,
+	}
+}
+
+// TestECSEncoderGolden compares entries with the files in testdata/ecs,
+// which follow the examples in the ECS logging specification
+// (https://github.com/elastic/ecs-logging).
+func TestECSEncoderGolden(t *testing.T) {
+	ts := time.Date(2016, time.May, 23, 10, 5, 34, 853_123_456, time.FixedZone("CEST", 2*60*60))
+
+	tests := []struct {
+		golden  string
+		prefix  string
+		context []Field
+		ent     Entry
+		fields  []Field
+	}{
+		{
+			golden: "minimal.json",
+			ent:    Entry{Level: InfoLevel, Time: ts, Message: "Hello World!"},
+		},
+		{
+			golden: "origin.json",
+			ent: Entry{
+				Level:      WarnLevel,
+				Time:       ts,
+				LoggerName: "app.http",
+				Message:    "slow request",
+				Caller:     EntryCaller{Defined: true, File: "/src/app/http/server.go", Line: 42, Function: "app/http.(*Server).serve"},
+			},
+			fields: []Field{
+				{Key: "event.duration", Type: DurationType, Integer: int64(1500 * time.Millisecond)},
+			},
+		},
+		{
+			golden: "error.json",
+			ent: Entry{
+				Level:   ErrorLevel,
+				Time:    ts,
+				Message: "request failed",
+				Stack:   "main.main\n\t/src/main.go:12",
+			},
+			fields: []Field{
+				{Key: "error", Type: ErrorType, Interface: errors.New("connection refused")},
+				{Key: "http.request.method", Type: StringType, String: "GET"},
+			},
+		},
+		{
+			golden: "fields.json",
+			context: []Field{
+				{Key: "service.name", Type: StringType, String: "checkout"},
+			},
+			ent: Entry{Level: DebugLevel, Time: ts, Message: "fields"},
+			fields: []Field{
+				{Key: "user", Type: ObjectMarshalerType, Interface: ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+					enc.AddString("name", "alice")
+					enc.AddString("error", "not an ECS error")
+					return nil
+				})},
+				{Key: "count", Type: Int64Type, Integer: 3},
+				{Key: "db", Type: NamespaceType},
+				{Key: "rows", Type: Int64Type, Integer: 7},
+				{Key: "error", Type: StringType, String: "in a namespace"},
+			},
+		},
+		{
+			golden: "labels.json",
+			prefix: "labels.",
+			context: []Field{
+				{Key: "tenant", Type: StringType, String: "acme"},
+			},
+			ent: Entry{Level: InfoLevel, Time: ts, Message: "labeled"},
+			fields: []Field{
+				{Key: "retry", Type: BoolType, Integer: 1},
+				{Key: "error", Type: ErrorType, Interface: errors.New("timeout")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join("testdata", "ecs", tt.golden))
+			require.NoError(t, err, "Couldn't read golden file.")
+
+			cfg := testECSEncoderConfig()
+			cfg.ECSFieldPrefix = tt.prefix
+			enc := NewECSEncoder(cfg)
+			addFields(enc, tt.context)
+
+			buf, err := enc.EncodeEntry(tt.ent, tt.fields)
+			require.NoError(t, err, "Unexpected error encoding entry.")
+			defer buf.Free()
+
+			assert.Equal(t, string(want), buf.String(), "Unexpected entry.")
+			assert.True(t, json.Valid(buf.Bytes()), "Expected valid JSON.")
+		})
+	}
+}
+
+func TestECSEncoderDefaults(t *testing.T) {
+	// The time and level fall back to their ECS encodings.
+	enc := NewECSEncoder(EncoderConfig{TimeKey: "@timestamp", LevelKey: "log.level", MessageKey: "message"})
+	buf, err := enc.EncodeEntry(Entry{
+		Level:   InfoLevel,
+		Time:    time.Date(2023, time.November, 14, 22, 13, 20, 500_000_000, time.UTC),
+		Message: "hello",
+	}, nil)
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	defer buf.Free()
+
+	assert.Equal(t,
+		`{"@timestamp":"2023-11-14T22:13:20.500Z","log.level":"info","message":"hello","ecs.version":"1.6.0"}`+"\n",
+		buf.String(), "Unexpected entry.")
+}
+
+func TestECSEncoderClone(t *testing.T) {
+	parent := NewECSEncoder(EncoderConfig{MessageKey: "message", ECSFieldPrefix: "labels."})
+	parent.AddString("a", "1")
+	child := parent.Clone()
+	child.OpenNamespace("ns")
+	child.AddString("b", "2")
+	parent.AddString("c", "3")
+
+	buf, err := child.EncodeEntry(Entry{Message: "child"}, []Field{{Key: "d", Type: StringType, String: "4"}})
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	assert.Equal(t,
+		`{"message":"child","ecs.version":"1.6.0","labels.a":"1","labels.ns":{"b":"2","d":"4"}}`+"\n",
+		buf.String(), "Unexpected child entry.")
+	buf.Free()
+
+	buf, err = parent.EncodeEntry(Entry{Message: "parent"}, nil)
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	assert.Equal(t,
+		`{"message":"parent","ecs.version":"1.6.0","labels.a":"1","labels.c":"3"}`+"\n",
+		buf.String(), "Unexpected parent entry.")
+	buf.Free()
+}
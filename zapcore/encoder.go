@@ -21,11 +21,17 @@
 package zapcore
 
 import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"io"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/toujourser/zap/buffer"
+	"github.com/toujourser/zap/internal/bufferpool"
 )
 
 // DefaultLineEnding defines the default line ending when writing logs.
@@ -159,6 +165,49 @@ func RFC3339NanoTimeEncoder(t time.Time, enc PrimitiveArrayEncoder) {
 	encodeTimeLayout(t, time.RFC3339Nano, enc)
 }
 
+// ProtoTimestampTimeEncoder serializes a time.Time as an object with
+// "seconds" and "nanos" fields, following the semantics of the protocol
+// buffers Timestamp type: seconds since the Unix epoch, and a non-negative
+// count of nanoseconds within that second. For example, the JSON encoder
+// writes
+//
+//	{"ts":{"seconds":1700000000,"nanos":123456789}}
+//
+// The console encoder, which writes metadata as plain-text columns, writes
+// the same value as "1700000000.123456789" instead. Encoders that can't nest
+// objects get nanoseconds since the epoch.
+func ProtoTimestampTimeEncoder(t time.Time, enc PrimitiveArrayEncoder) {
+	switch enc := enc.(type) {
+	case *sliceArrayEncoder:
+		if enc.columns {
+			// The console encoder prints each element with fmt, which
+			// would show a nested object as a Go map.
+			enc.AppendString(formatProtoTimestamp(t))
+			return
+		}
+		_ = enc.AppendObject(protoTimestamp(t))
+	case ArrayEncoder:
+		_ = enc.AppendObject(protoTimestamp(t))
+	default:
+		enc.AppendInt64(t.UnixNano())
+	}
+}
+
+// protoTimestamp marshals a time.Time as a protocol buffers Timestamp.
+type protoTimestamp time.Time
+
+func (ts protoTimestamp) MarshalLogObject(enc ObjectEncoder) error {
+	t := time.Time(ts)
+	enc.AddInt64("seconds", t.Unix())
+	enc.AddInt32("nanos", int32(t.Nanosecond()))
+	return nil
+}
+
+func formatProtoTimestamp(t time.Time) string {
+	nanos := strconv.Itoa(t.Nanosecond())
+	return strconv.FormatInt(t.Unix(), 10) + "." + strings.Repeat("0", 9-len(nanos)) + nanos
+}
+
 // TimeEncoderOfLayout returns TimeEncoder which serializes a time.Time using
 // given layout.
 func TimeEncoderOfLayout(layout string) TimeEncoder {
@@ -173,6 +222,7 @@ func TimeEncoderOfLayout(layout string) TimeEncoder {
 // "iso8601" and "ISO8601" are unmarshaled to ISO8601TimeEncoder.
 // "millis" is unmarshaled to EpochMillisTimeEncoder.
 // "nanos" is unmarshaled to EpochNanosEncoder.
+// "protoTimestamp" is unmarshaled to ProtoTimestampTimeEncoder.
 // Anything else is unmarshaled to EpochTimeEncoder.
 func (e *TimeEncoder) UnmarshalText(text []byte) error {
 	switch string(text) {
@@ -186,6 +236,8 @@ func (e *TimeEncoder) UnmarshalText(text []byte) error {
 		*e = EpochMillisTimeEncoder
 	case "nanos":
 		*e = EpochNanosTimeEncoder
+	case "protoTimestamp":
+		*e = ProtoTimestampTimeEncoder
 	default:
 		*e = EpochTimeEncoder
 	}
@@ -253,13 +305,86 @@ func StringDurationEncoder(d time.Duration, enc PrimitiveArrayEncoder) {
 	enc.AppendString(d.String())
 }
 
+// HumanizedDurationEncoder serializes a time.Duration like its String method,
+// but without trailing zero units: "2h3m" rather than "2h3m0s", alongside
+// "1.5s" and "230ms".
+func HumanizedDurationEncoder(d time.Duration, enc PrimitiveArrayEncoder) {
+	enc.AppendString(humanizeDuration(d))
+}
+
+func humanizeDuration(d time.Duration) string {
+	s := d.String()
+	for _, zero := range []string{"0s", "0m"} {
+		// Only trim whole zero units, as in "2h0m0s", not the end of "10s"
+		// or "20m".
+		n := len(s) - len(zero)
+		if n < 1 || s[n:] != zero || (s[n-1] != 'h' && s[n-1] != 'm') {
+			break
+		}
+		s = s[:n]
+	}
+	return s
+}
+
+// SplitDurationEncoder serializes a time.Duration as an object with an integer
+// "value" and its "unit", which is the largest of "h", "m", "s", "ms", "us",
+// and "ns" that represents the duration exactly. For example, the JSON
+// encoder writes
+//
+//	{"elapsed":{"value":1500,"unit":"ms"}}
+//
+// for 1.5 seconds. Encoders that can't nest objects get nanoseconds instead.
+func SplitDurationEncoder(d time.Duration, enc PrimitiveArrayEncoder) {
+	if arr, ok := enc.(ArrayEncoder); ok {
+		_ = arr.AppendObject(splitDuration(d))
+		return
+	}
+	enc.AppendInt64(int64(d))
+}
+
+// _durationUnits lists the units SplitDurationEncoder chooses from, largest
+// first.
+var _durationUnits = []struct {
+	name string
+	size time.Duration
+}{
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+	{"ms", time.Millisecond},
+	{"us", time.Microsecond},
+}
+
+// splitDuration marshals a time.Duration as a value and a unit.
+type splitDuration time.Duration
+
+func (d splitDuration) MarshalLogObject(enc ObjectEncoder) error {
+	value, unit := int64(d), "ns"
+	if d != 0 {
+		for _, u := range _durationUnits {
+			if time.Duration(d)%u.size == 0 {
+				value, unit = int64(time.Duration(d)/u.size), u.name
+				break
+			}
+		}
+	}
+	enc.AddInt64("value", value)
+	enc.AddString("unit", unit)
+	return nil
+}
+
 // UnmarshalText unmarshals text to a DurationEncoder. "string" is unmarshaled
-// to StringDurationEncoder, and anything else is unmarshaled to
-// NanosDurationEncoder.
+// to StringDurationEncoder, "humanized" to HumanizedDurationEncoder, "split"
+// to SplitDurationEncoder, "nanos" to NanosDurationEncoder, "ms" to
+// MillisDurationEncoder, and anything else to SecondsDurationEncoder.
 func (e *DurationEncoder) UnmarshalText(text []byte) error {
 	switch string(text) {
 	case "string":
 		*e = StringDurationEncoder
+	case "humanized":
+		*e = HumanizedDurationEncoder
+	case "split":
+		*e = SplitDurationEncoder
 	case "nanos":
 		*e = NanosDurationEncoder
 	case "ms":
@@ -270,6 +395,51 @@ func (e *DurationEncoder) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// A BinaryEncoder serializes a []byte, such as the value of a zap.Binary
+// field, to a primitive type.
+type BinaryEncoder func([]byte, PrimitiveArrayEncoder)
+
+// Base64BinaryEncoder serializes a []byte as a standard base64-encoded
+// string. It's the default.
+func Base64BinaryEncoder(b []byte, enc PrimitiveArrayEncoder) {
+	enc.AppendString(base64.StdEncoding.EncodeToString(b))
+}
+
+// HexBinaryEncoder serializes a []byte as a lowercase hexadecimal string,
+// which suits fingerprints and digests.
+func HexBinaryEncoder(b []byte, enc PrimitiveArrayEncoder) {
+	enc.AppendString(hex.EncodeToString(b))
+}
+
+// Base32BinaryEncoder serializes a []byte as a standard base32-encoded
+// string.
+func Base32BinaryEncoder(b []byte, enc PrimitiveArrayEncoder) {
+	enc.AppendString(base32.StdEncoding.EncodeToString(b))
+}
+
+// SizeOnlyBinaryEncoder serializes only the length of a []byte, like
+// "<len=4096>", which keeps large payloads out of the logs.
+func SizeOnlyBinaryEncoder(b []byte, enc PrimitiveArrayEncoder) {
+	enc.AppendString("<len=" + strconv.Itoa(len(b)) + ">")
+}
+
+// UnmarshalText unmarshals text to a BinaryEncoder. "hex" is unmarshaled to
+// HexBinaryEncoder, "base32" to Base32BinaryEncoder, "size" to
+// SizeOnlyBinaryEncoder, and anything else to Base64BinaryEncoder.
+func (e *BinaryEncoder) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "hex":
+		*e = HexBinaryEncoder
+	case "base32":
+		*e = Base32BinaryEncoder
+	case "size":
+		*e = SizeOnlyBinaryEncoder
+	default:
+		*e = Base64BinaryEncoder
+	}
+	return nil
+}
+
 // A CallerEncoder serializes an EntryCaller to a primitive type.
 //
 // This function must make exactly one call
@@ -290,12 +460,62 @@ func ShortCallerEncoder(caller EntryCaller, enc PrimitiveArrayEncoder) {
 	enc.AppendString(caller.TrimmedPath())
 }
 
+// FuncCallerEncoder serializes a caller in package/file:line (function)
+// format, where the function name is qualified by its package name alone
+// (for example, "server/handler.go:42 (server.(*Server).handle)"). If the
+// caller's function is unknown, it behaves like ShortCallerEncoder.
+func FuncCallerEncoder(caller EntryCaller, enc PrimitiveArrayEncoder) {
+	if !caller.Defined || caller.Function == "" {
+		enc.AppendString(caller.TrimmedPath())
+		return
+	}
+	fn := caller.Function
+	if idx := strings.LastIndexByte(fn, '/'); idx >= 0 {
+		fn = fn[idx+1:]
+	}
+	buf := bufferpool.Get()
+	buf.AppendString(caller.TrimmedPath())
+	buf.AppendString(" (")
+	buf.AppendString(fn)
+	buf.AppendByte(')')
+	enc.AppendString(buf.String())
+	buf.Free()
+}
+
+// RelativeCallerEncoder returns a CallerEncoder that serializes a caller in
+// path/to/file:line format, relative to the given root directory (typically
+// the module root). Callers outside root are serialized with their full path.
+func RelativeCallerEncoder(root string) CallerEncoder {
+	if root != "" && !strings.HasSuffix(root, "/") {
+		root += "/"
+	}
+	return func(caller EntryCaller, enc PrimitiveArrayEncoder) {
+		if !caller.Defined || root == "" || !strings.HasPrefix(caller.File, root) {
+			enc.AppendString(caller.FullPath())
+			return
+		}
+		buf := bufferpool.Get()
+		buf.AppendString(caller.File[len(root):])
+		buf.AppendByte(':')
+		buf.AppendInt(int64(caller.Line))
+		enc.AppendString(buf.String())
+		buf.Free()
+	}
+}
+
 // UnmarshalText unmarshals text to a CallerEncoder. "full" is unmarshaled to
-// FullCallerEncoder and anything else is unmarshaled to ShortCallerEncoder.
+// FullCallerEncoder, "func" is unmarshaled to FuncCallerEncoder, and
+// "relative:<root>" is unmarshaled to RelativeCallerEncoder(<root>).
+// Anything else is unmarshaled to ShortCallerEncoder.
 func (e *CallerEncoder) UnmarshalText(text []byte) error {
-	switch string(text) {
-	case "full":
+	s := string(text)
+	switch {
+	case s == "full":
 		*e = FullCallerEncoder
+	case s == "func":
+		*e = FuncCallerEncoder
+	case strings.HasPrefix(s, "relative:"):
+		*e = RelativeCallerEncoder(strings.TrimPrefix(s, "relative:"))
 	default:
 		*e = ShortCallerEncoder
 	}
@@ -350,12 +570,76 @@ type EncoderConfig struct {
 	// Unlike the other primitive type encoders, EncodeName is optional. The
 	// zero value falls back to FullNameEncoder.
 	EncodeName NameEncoder `json:"nameEncoder" yaml:"nameEncoder"`
+	// EncodeBinary is optional, too. The zero value falls back to
+	// Base64BinaryEncoder. It's used by the JSON, console, logfmt, and CSV
+	// encoders.
+	EncodeBinary BinaryEncoder `json:"binaryEncoder" yaml:"binaryEncoder"`
 	// Configure the encoder for interface{} type objects.
 	// If not provided, objects are encoded using json.Encoder
 	NewReflectedEncoder func(io.Writer) ReflectedEncoder `json:"-" yaml:"-"`
 	// Configures the field separator used by the console encoder. Defaults
 	// to tab.
 	ConsoleSeparator string `json:"consoleSeparator" yaml:"consoleSeparator"`
+	// Configures colors and column alignment for the console encoder.
+	Console ConsoleConfig `json:"console" yaml:"console"`
+	// RevealSecrets logs the real values of redacted fields and
+	// RedactedStringers instead of RedactedPlaceholder. It's intended for
+	// development only.
+	RevealSecrets bool `json:"revealSecrets" yaml:"revealSecrets"`
+	// TrustRawJSON embeds raw JSON fields without first checking that
+	// they're valid, compact JSON. Enable it only if the documents come
+	// from a trusted source, since an invalid document corrupts the entry.
+	TrustRawJSON bool `json:"trustRawJSON" yaml:"trustRawJSON"`
+	// FlattenNamespaces makes the JSON and console encoders write the fields
+	// in a namespace at the top level, with the namespace's key and
+	// NamespaceSeparator prepended to their keys, instead of as a nested
+	// object. Objects and arrays keep their structure, and namespaces opened
+	// inside objects are flattened into those objects. NamespaceSeparator
+	// defaults to ".".
+	FlattenNamespaces  bool   `json:"flattenNamespaces" yaml:"flattenNamespaces"`
+	NamespaceSeparator string `json:"namespaceSeparator" yaml:"namespaceSeparator"`
+	// SortKeys makes the JSON encoder write each entry's fields sorted by
+	// key, wherever they were added, so that entries are easy to diff. The
+	// entry's own keys, such as the level, time, and message, still come
+	// first in their usual order, and the stacktrace last. SortNestedKeys
+	// also sorts the keys of the objects in field values, including
+	// namespaces; it has no effect unless SortKeys is set. Sorting buffers
+	// and copies every entry, so both are off by default.
+	SortKeys       bool `json:"sortKeys" yaml:"sortKeys"`
+	SortNestedKeys bool `json:"sortNestedKeys" yaml:"sortNestedKeys"`
+	// By default, encoders recover from panics in the MarshalLogObject and
+	// MarshalLogArray methods of field values: they discard the value's
+	// partial output, log "<panic: ...>" in its place, or "<nil>" if the
+	// value is a nil pointer, and go on encoding the remaining fields.
+	// PropagateMarshalerPanics lets such panics through instead, for
+	// programs that prefer to fail fast.
+	PropagateMarshalerPanics bool `json:"propagateMarshalerPanics" yaml:"propagateMarshalerPanics"`
+	// ReportPanics adds a field named after the key of a value that
+	// panicked, with an "Error" suffix, that holds the panic as
+	// "PANIC=...". Panics are recovered from the String, Error, and
+	// MarshalText methods as well as the marshalers above. Either way, the
+	// field's own key holds the placeholder.
+	ReportPanics bool `json:"reportPanics" yaml:"reportPanics"`
+	// Configures the columns of the CSV encoder.
+	CSV CSVConfig `json:"csv" yaml:"csv"`
+	// ECSFieldPrefix is prepended to the keys of the top-level fields that
+	// the ECS encoder doesn't map to ECS fields, such as "labels.", so that
+	// they can't collide with ECS fields. By default, they're written as
+	// they are.
+	ECSFieldPrefix string `json:"ecsFieldPrefix" yaml:"ecsFieldPrefix"`
+	// Sets the keys of the process fields that zap.Config can add to every
+	// entry. Encoders don't use them.
+	Process ProcessKeys `json:"process" yaml:"process"`
+}
+
+// ProcessKeys sets the keys of the fields added by zap.Config's
+// IncludeHostname, IncludePID, and IncludeBuildInfo. Empty keys fall back to
+// "hostname", "pid", "version", and "revision".
+type ProcessKeys struct {
+	HostnameKey string `json:"hostnameKey" yaml:"hostnameKey"`
+	PIDKey      string `json:"pidKey" yaml:"pidKey"`
+	VersionKey  string `json:"versionKey" yaml:"versionKey"`
+	RevisionKey string `json:"revisionKey" yaml:"revisionKey"`
 }
 
 // ObjectEncoder is a strongly-typed, encoding-agnostic interface for adding a
@@ -601,6 +601,7 @@ func TestTimeEncoders(t *testing.T) {
 		{"timeEncoder: RFC3339", "1970-01-01T00:01:40Z"},
 		{"timeEncoder: rfc3339nano", "1970-01-01T00:01:40.050005Z"},
 		{"timeEncoder: RFC3339Nano", "1970-01-01T00:01:40.050005Z"},
+		{"timeEncoder: protoTimestamp", map[string]interface{}{"seconds": int64(100), "nanos": int32(50005000)}},
 	}
 
 	for _, tt := range tests {
@@ -635,6 +636,7 @@ func TestTimeEncodersParseFromJSON(t *testing.T) {
 	}{
 		{`{"timeEncoder": "iso8601"}`, "1970-01-01T00:01:40.050Z"},
 		{`{"timeEncoder": {"layout": "06/01/02 03:04pm"}}`, "70/01/01 12:01am"},
+		{`{"timeEncoder": "protoTimestamp"}`, map[string]interface{}{"seconds": int64(100), "nanos": int32(50005000)}},
 	}
 
 	for _, tt := range tests {
@@ -650,6 +652,92 @@ func TestTimeEncodersParseFromJSON(t *testing.T) {
 	}
 }
 
+func TestProtoTimestampTimeEncoder(t *testing.T) {
+	var cfg EncoderConfig
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"timeKey": "ts",
+		"messageKey": "msg",
+		"timeEncoder": "protoTimestamp"
+	}`), &cfg), "Unexpected error unmarshaling config.")
+
+	ent := Entry{Time: time.Unix(1700000000, 1234).UTC(), Message: "hello"}
+	fields := []Field{{Key: "k", Type: StringType, String: "v"}}
+
+	tests := []struct {
+		desc string
+		enc  Encoder
+		want string
+	}{
+		{
+			desc: "json",
+			enc:  NewJSONEncoder(cfg),
+			want: `{"ts":{"seconds":1700000000,"nanos":1234},"msg":"hello","k":"v"}` + "\n",
+		},
+		{
+			desc: "json clone",
+			enc:  NewJSONEncoder(cfg).Clone(),
+			want: `{"ts":{"seconds":1700000000,"nanos":1234},"msg":"hello","k":"v"}` + "\n",
+		},
+		{
+			desc: "console",
+			enc:  NewConsoleEncoder(cfg).Clone(),
+			want: "1700000000.000001234\thello\t" + `{"k": "v"}` + "\n",
+		},
+		{
+			desc: "logfmt",
+			enc:  NewLogfmtEncoder(cfg),
+			want: "ts.seconds=1700000000 ts.nanos=1234 msg=hello k=v\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			buf, err := tt.enc.EncodeEntry(ent, fields)
+			require.NoError(t, err, "Unexpected encoding error.")
+			assert.Equal(t, tt.want, buf.String(), "Unexpected output.")
+			buf.Free()
+		})
+	}
+}
+
+func TestProtoTimestampTimeEncoderRoundTrip(t *testing.T) {
+	encode := func(te TimeEncoder, moment time.Time) string {
+		buf, err := NewJSONEncoder(EncoderConfig{TimeKey: "ts", EncodeTime: te}).EncodeEntry(Entry{Time: moment}, nil)
+		require.NoError(t, err, "Unexpected encoding error.")
+		defer buf.Free()
+		return buf.String()
+	}
+
+	moments := []time.Time{
+		time.Unix(0, 1),
+		time.Unix(1700000000, 999999999),
+		time.Unix(-1, 1),                             // before the epoch, nanos stay positive
+		time.Unix(0, 1<<62),                          // beyond float64 precision
+		time.Date(2262, 4, 11, 0, 0, 0, 0, time.UTC), // near the end of UnixNano
+	}
+	for _, moment := range moments {
+		var proto struct {
+			TS struct {
+				Seconds int64 `json:"seconds"`
+				Nanos   int32 `json:"nanos"`
+			} `json:"ts"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(encode(ProtoTimestampTimeEncoder, moment)), &proto),
+			"Unexpected error decoding proto timestamp.")
+
+		var nanos struct {
+			TS int64 `json:"ts"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(encode(EpochNanosTimeEncoder, moment)), &nanos),
+			"Unexpected error decoding epoch nanos.")
+
+		assert.GreaterOrEqual(t, proto.TS.Nanos, int32(0), "Nanos must not be negative.")
+		assert.Less(t, proto.TS.Nanos, int32(time.Second), "Nanos must be within a second.")
+		assert.Equal(t, nanos.TS, time.Unix(proto.TS.Seconds, int64(proto.TS.Nanos)).UnixNano(),
+			"Expected no precision loss for %v.", moment)
+	}
+}
+
 func TestDurationEncoders(t *testing.T) {
 	elapsed := time.Second + 500*time.Nanosecond
 	tests := []struct {
@@ -657,6 +745,8 @@ func TestDurationEncoders(t *testing.T) {
 		expected interface{} // output of serializing elapsed
 	}{
 		{"string", "1.0000005s"},
+		{"humanized", "1.0000005s"},
+		{"split", map[string]interface{}{"value": int64(1000000500), "unit": "ns"}},
 		{"nanos", int64(1000000500)},
 		{"ms", int64(1000)},
 		{"", 1.0000005},
@@ -675,8 +765,196 @@ func TestDurationEncoders(t *testing.T) {
 	}
 }
 
+func TestBinaryEncoders(t *testing.T) {
+	b := []byte{0xde, 0xad, 0xbe, 0xef}
+	tests := []struct {
+		name     string
+		expected interface{} // output of serializing b
+	}{
+		{"hex", "deadbeef"},
+		{"base32", "32W353Y="},
+		{"size", "<len=4>"},
+		{"base64", "3q2+7w=="},
+		{"", "3q2+7w=="},
+		{"something-random", "3q2+7w=="},
+	}
+
+	for _, tt := range tests {
+		var be BinaryEncoder
+		require.NoError(t, be.UnmarshalText([]byte(tt.name)), "Unexpected error unmarshaling %q.", tt.name)
+		assertAppended(
+			t,
+			tt.expected,
+			func(arr ArrayEncoder) { be(b, arr) },
+			"Unexpected output serializing %v with %q.", b, tt.name,
+		)
+	}
+}
+
+func TestEncodeBinary(t *testing.T) {
+	payload := []byte{0xde, 0xad, 0xbe, 0xef}
+	fields := []Field{
+		{Key: "b", Type: BinaryType, Interface: payload},
+		{Key: "objs", Type: ArrayMarshalerType, Interface: ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+			return arr.AppendObject(ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+				enc.AddBinary("nested", payload)
+				return nil
+			}))
+		})},
+	}
+	noop := func([]byte, PrimitiveArrayEncoder) {}
+
+	tests := []struct {
+		desc   string
+		encode BinaryEncoder
+		newEnc func(EncoderConfig) Encoder
+		want   string
+	}{
+		{
+			desc:   "json default",
+			newEnc: NewJSONEncoder,
+			want:   `{"b":"3q2+7w==","objs":[{"nested":"3q2+7w=="}]}`,
+		},
+		{
+			desc:   "json hex",
+			encode: HexBinaryEncoder,
+			newEnc: NewJSONEncoder,
+			want:   `{"b":"deadbeef","objs":[{"nested":"deadbeef"}]}`,
+		},
+		{
+			desc:   "json no-op",
+			encode: noop,
+			newEnc: NewJSONEncoder,
+			want:   `{"b":"3q2+7w==","objs":[{"nested":"3q2+7w=="}]}`,
+		},
+		{
+			desc:   "console size",
+			encode: SizeOnlyBinaryEncoder,
+			newEnc: NewConsoleEncoder,
+			want:   `{"b": "<len=4>", "objs": [{"nested": "<len=4>"}]}`,
+		},
+		{
+			desc:   "logfmt hex",
+			encode: HexBinaryEncoder,
+			newEnc: NewLogfmtEncoder,
+			want:   `b=deadbeef objs.0.nested=deadbeef`,
+		},
+		{
+			desc:   "logfmt no-op",
+			encode: noop,
+			newEnc: NewLogfmtEncoder,
+			want:   `b="3q2+7w==" objs.0.nested="3q2+7w=="`,
+		},
+		{
+			desc:   "csv base32",
+			encode: Base32BinaryEncoder,
+			newEnc: func(cfg EncoderConfig) Encoder {
+				cfg.CSV.Columns = []string{"b"}
+				return NewCSVEncoder(cfg)
+			},
+			want: `32W353Y=,"{""objs"":[{""nested"":""32W353Y=""}]}"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := tt.newEnc(EncoderConfig{EncodeBinary: tt.encode})
+			buf, err := enc.EncodeEntry(Entry{}, fields)
+			require.NoError(t, err, "Unexpected encoding error.")
+			assert.Equal(t, tt.want, strings.TrimSpace(buf.String()), "Unexpected output.")
+			buf.Free()
+		})
+	}
+}
+
+func TestBinaryEncoderParseFromConfig(t *testing.T) {
+	var cfg EncoderConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`binaryEncoder: hex`), &cfg), "Unexpected error unmarshaling YAML.")
+	assertAppended(t, "ff", func(arr ArrayEncoder) { cfg.EncodeBinary([]byte{0xff}, arr) }, "Unexpected YAML-configured encoder.")
+
+	cfg = EncoderConfig{}
+	require.NoError(t, json.Unmarshal([]byte(`{"binaryEncoder": "size"}`), &cfg), "Unexpected error unmarshaling JSON.")
+	assertAppended(t, "<len=1>", func(arr ArrayEncoder) { cfg.EncodeBinary([]byte{0xff}, arr) }, "Unexpected JSON-configured encoder.")
+}
+
+func TestHumanizedDurationEncoder(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{1500 * time.Millisecond, "1.5s"},
+		{230 * time.Millisecond, "230ms"},
+		{2*time.Hour + 3*time.Minute, "2h3m"},
+		{time.Hour, "1h"},
+		{20 * time.Minute, "20m"},
+		{10*time.Minute + 10*time.Second, "10m10s"},
+		{time.Minute + 500*time.Millisecond, "1m0.5s"},
+		{-90 * time.Second, "-1m30s"},
+	}
+
+	for _, tt := range tests {
+		assertAppended(
+			t,
+			tt.expected,
+			func(arr ArrayEncoder) { HumanizedDurationEncoder(tt.d, arr) },
+			"Unexpected output serializing %v.", tt.d,
+		)
+	}
+}
+
+func TestSplitDurationEncoder(t *testing.T) {
+	tests := []struct {
+		d     time.Duration
+		value int64
+		unit  string
+	}{
+		{0, 0, "ns"},
+		{1500 * time.Millisecond, 1500, "ms"},
+		{2 * time.Second, 2, "s"},
+		{90 * time.Minute, 90, "m"},
+		{3 * time.Hour, 3, "h"},
+		{1500 * time.Microsecond, 1500, "us"},
+		{7, 7, "ns"},
+		{-2 * time.Second, -2, "s"},
+	}
+
+	for _, tt := range tests {
+		assertAppended(
+			t,
+			map[string]interface{}{"value": tt.value, "unit": tt.unit},
+			func(arr ArrayEncoder) { SplitDurationEncoder(tt.d, arr) },
+			"Unexpected output serializing %v.", tt.d,
+		)
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		var de DurationEncoder
+		require.NoError(t, yaml.Unmarshal([]byte("split"), &de))
+		enc := NewJSONEncoder(EncoderConfig{EncodeDuration: de, SkipLineEnding: true})
+		enc.AddDuration("elapsed", 1500*time.Millisecond)
+		require.NoError(t, enc.AddArray("ds", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+			arr.AppendDuration(time.Second)
+			arr.AppendDuration(time.Minute + time.Millisecond)
+			return nil
+		})))
+		buf, err := enc.EncodeEntry(Entry{}, nil)
+		require.NoError(t, err)
+		assert.Equal(t,
+			`{"elapsed":{"value":1500,"unit":"ms"},"ds":[{"value":1,"unit":"s"},{"value":60001,"unit":"ms"}]}`,
+			buf.String(),
+		)
+		buf.Free()
+	})
+}
+
 func TestCallerEncoders(t *testing.T) {
-	caller := EntryCaller{Defined: true, File: "/home/jack/src/github.com/foo/foo.go", Line: 42}
+	caller := EntryCaller{
+		Defined:  true,
+		File:     "/home/jack/src/github.com/foo/foo.go",
+		Line:     42,
+		Function: "github.com/foo.(*Server).handle",
+	}
 	tests := []struct {
 		name     string
 		expected interface{} // output of serializing caller
@@ -685,6 +963,11 @@ func TestCallerEncoders(t *testing.T) {
 		{"something-random", "foo/foo.go:42"},
 		{"short", "foo/foo.go:42"},
 		{"full", "/home/jack/src/github.com/foo/foo.go:42"},
+		{"func", "foo/foo.go:42 (foo.(*Server).handle)"},
+		{"relative:/home/jack/src", "github.com/foo/foo.go:42"},
+		{"relative:/home/jack/src/", "github.com/foo/foo.go:42"},
+		{"relative:/elsewhere", "/home/jack/src/github.com/foo/foo.go:42"},
+		{"relative:", "/home/jack/src/github.com/foo/foo.go:42"},
 	}
 
 	for _, tt := range tests {
@@ -699,6 +982,97 @@ func TestCallerEncoders(t *testing.T) {
 	}
 }
 
+func TestCallerEncodersIncompleteCaller(t *testing.T) {
+	tests := []struct {
+		desc     string
+		enc      CallerEncoder
+		caller   EntryCaller
+		expected interface{}
+	}{
+		{
+			desc:     "func/no function",
+			enc:      FuncCallerEncoder,
+			caller:   EntryCaller{Defined: true, File: "/src/foo/foo.go", Line: 42},
+			expected: "foo/foo.go:42",
+		},
+		{
+			desc:     "func/undefined",
+			enc:      FuncCallerEncoder,
+			caller:   EntryCaller{},
+			expected: "undefined",
+		},
+		{
+			desc:     "relative/undefined",
+			enc:      RelativeCallerEncoder("/src"),
+			caller:   EntryCaller{},
+			expected: "undefined",
+		},
+	}
+
+	for _, tt := range tests {
+		assertAppended(
+			t,
+			tt.expected,
+			func(arr ArrayEncoder) { tt.enc(tt.caller, arr) },
+			"Unexpected output serializing caller: %s.", tt.desc,
+		)
+	}
+}
+
+func TestCallerEncodersInEntries(t *testing.T) {
+	ent := Entry{
+		Level:   InfoLevel,
+		Message: "hello",
+		Caller: EntryCaller{
+			Defined:  true,
+			File:     "/src/github.com/foo/server/handler.go",
+			Line:     42,
+			Function: "github.com/foo/server.(*Server).handle",
+		},
+	}
+	tests := []struct {
+		desc        string
+		enc         CallerEncoder
+		wantJSON    string
+		wantConsole string
+	}{
+		{
+			desc:        "func",
+			enc:         FuncCallerEncoder,
+			wantJSON:    `{"level":"info","caller":"server/handler.go:42 (server.(*Server).handle)","msg":"hello"}`,
+			wantConsole: "info\tserver/handler.go:42 (server.(*Server).handle)\thello",
+		},
+		{
+			desc:        "relative",
+			enc:         RelativeCallerEncoder("/src/github.com/foo"),
+			wantJSON:    `{"level":"info","caller":"server/handler.go:42","msg":"hello"}`,
+			wantConsole: "info\tserver/handler.go:42\thello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			cfg := EncoderConfig{
+				LevelKey:     "level",
+				CallerKey:    "caller",
+				MessageKey:   "msg",
+				EncodeLevel:  LowercaseLevelEncoder,
+				EncodeCaller: tt.enc,
+			}
+
+			buf, err := NewJSONEncoder(cfg).EncodeEntry(ent, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantJSON+"\n", buf.String(), "Unexpected JSON output.")
+			buf.Free()
+
+			buf, err = NewConsoleEncoder(cfg).EncodeEntry(ent, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantConsole+"\n", buf.String(), "Unexpected console output.")
+			buf.Free()
+		})
+	}
+}
+
 func TestNameEncoders(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -22,8 +22,10 @@ package zapcore
 
 import (
 	"fmt"
+	"reflect"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/toujourser/zap/internal/bufferpool"
@@ -49,9 +51,23 @@ func putCheckedEntry(ce *CheckedEntry) {
 	if ce == nil {
 		return
 	}
+	// Returning an entry to the pool twice would hand it out to two
+	// goroutines at once, so only the first return counts.
+	if ce.state.Swap(cePooled) == cePooled {
+		return
+	}
 	_cePool.Put(ce)
 }
 
+// States of a CheckedEntry. Transitions are atomic so that concurrent or
+// repeated calls to Write are detected, and so that an entry is returned to
+// the pool at most once.
+const (
+	ceOpen    uint32 = iota // being built by Check; may be written
+	ceWriting               // claimed by a call to Write
+	cePooled                // returned to the pool
+)
+
 // NewEntryCaller makes an EntryCaller from the return signature of
 // runtime.Caller.
 func NewEntryCaller(pc uintptr, file string, line int, ok bool) EntryCaller {
@@ -201,6 +217,18 @@ func (a CheckWriteAction) OnWrite(ce *CheckedEntry, _ []Field) {
 
 var _ CheckWriteHook = CheckWriteAction(0)
 
+// WriteThenExitCode returns a CheckWriteHook that exits the process with the
+// given code after Write. WriteThenExitCode(1) behaves like WriteThenFatal.
+func WriteThenExitCode(code int) CheckWriteHook {
+	return exitCodeHook(code)
+}
+
+type exitCodeHook int
+
+func (code exitCodeHook) OnWrite(*CheckedEntry, []Field) {
+	exit.With(int(code))
+}
+
 // CheckedEntry is an Entry together with a collection of Cores that have
 // already agreed to log it.
 //
@@ -210,21 +238,34 @@ var _ CheckWriteHook = CheckWriteAction(0)
 type CheckedEntry struct {
 	Entry
 	ErrorOutput WriteSyncer
-	dirty       bool // best-effort detection of pool misuse
+	state       atomic.Uint32 // best-effort detection of pool misuse
 	after       CheckWriteHook
 	cores       []Core
+	fields      []Field // added with AddFields
+
+	// ErrorHandler, if non-nil, receives any error returned by the Cores'
+	// Write methods along with the entry that failed. If it's nil, or if
+	// it's invoked again while already handling an error on the same
+	// goroutine, a plain-text description is written to ErrorOutput
+	// instead.
+	ErrorHandler func(error, Entry)
 }
 
 func (ce *CheckedEntry) reset() {
 	ce.Entry = Entry{}
 	ce.ErrorOutput = nil
-	ce.dirty = false
+	ce.ErrorHandler = nil
+	ce.state.Store(ceOpen)
 	ce.after = nil
 	for i := range ce.cores {
 		// don't keep references to cores
 		ce.cores[i] = nil
 	}
 	ce.cores = ce.cores[:0]
+	for i := range ce.fields {
+		ce.fields[i] = Field{}
+	}
+	ce.fields = ce.fields[:0]
 }
 
 // Write writes the entry to the stored Cores, returns any errors, and returns
@@ -235,36 +276,24 @@ func (ce *CheckedEntry) Write(fields ...Field) {
 		return
 	}
 
-	if ce.dirty {
-		if ce.ErrorOutput != nil {
-			// Make a best effort to detect unsafe re-use of this CheckedEntry.
-			// If the entry is dirty, log an internal error; because the
-			// CheckedEntry is being used after it was returned to the pool,
-			// the message may be an amalgamation from multiple call sites.
-			_, _ = fmt.Fprintf(
-				ce.ErrorOutput,
-				"%v Unsafe CheckedEntry re-use near Entry %+v.\n",
-				ce.Time,
-				ce.Entry,
-			)
-			_ = ce.ErrorOutput.Sync() // ignore error
-		}
+	if !ce.state.CompareAndSwap(ceOpen, ceWriting) {
+		// The entry is being written by another call, or was already
+		// returned to the pool. Don't modify it: it may belong to another
+		// goroutine by now.
+		ce.reportReuse()
 		return
 	}
-	ce.dirty = true
+
+	if len(ce.fields) > 0 {
+		fields = append(fields[:len(fields):len(fields)], ce.fields...)
+	}
 
 	var err error
 	for i := range ce.cores {
 		err = multierr.Append(err, ce.cores[i].Write(ce.Entry, fields))
 	}
-	if err != nil && ce.ErrorOutput != nil {
-		_, _ = fmt.Fprintf(
-			ce.ErrorOutput,
-			"%v write error: %v\n",
-			ce.Time,
-			err,
-		)
-		_ = ce.ErrorOutput.Sync() // ignore error
+	if err != nil {
+		ce.reportError(err)
 	}
 
 	hook := ce.after
@@ -274,6 +303,106 @@ func (ce *CheckedEntry) Write(fields ...Field) {
 	putCheckedEntry(ce)
 }
 
+// reportError passes a write error to the ErrorHandler, falling back to
+// a plain-text line on ErrorOutput if there's no handler, if the handler
+// panics, or if the handler's own logging failed and brought us back here.
+func (ce *CheckedEntry) reportError(err error) {
+	if err = HandleError(ce.ErrorHandler, err, ce.Entry); err == nil {
+		return
+	}
+	if ce.ErrorOutput == nil {
+		return
+	}
+	_, _ = fmt.Fprintf(
+		ce.ErrorOutput,
+		"%v write error: %v\n",
+		ce.Time,
+		err,
+	)
+	_ = ce.ErrorOutput.Sync() // ignore error
+}
+
+// HandleError passes err and ent to handle the way CheckedEntry.Write passes
+// write errors to its ErrorHandler: panics in handle are recovered, and
+// handle isn't called if the current goroutine is already running an
+// ErrorHandler, so that handlers that log through a failing Logger don't
+// recurse. It returns nil if handle ran; otherwise, it returns the error to
+// report some other way, including the panic, if any.
+func HandleError(handle func(error, Entry), err error, ent Entry) error {
+	if handle == nil || inErrorHandler() {
+		return err
+	}
+	if herr := callErrorHandler(handle, err, ent); herr != nil {
+		return multierr.Append(err, herr)
+	}
+	return nil
+}
+
+// callErrorHandler runs an ErrorHandler, converting panics into errors.
+// inErrorHandler looks for this function on the stack, so it must remain a
+// distinct, named function.
+func callErrorHandler(handle func(error, Entry), err error, ent Entry) (retErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			retErr = fmt.Errorf("error handler panicked: %v", r)
+		}
+	}()
+	handle(err, ent)
+	return nil
+}
+
+// _callErrorHandlerName is the fully-qualified name of callErrorHandler, as
+// reported by runtime.Frame.Function.
+var _callErrorHandlerName = runtime.FuncForPC(reflect.ValueOf(callErrorHandler).Pointer()).Name()
+
+// inErrorHandler reports whether the current goroutine is already running
+// an ErrorHandler. Handlers that log through a failing Logger would
+// otherwise recurse without bound. Errors are rare, so inspecting the stack
+// here is cheaper than tracking handler state on every Write.
+func inErrorHandler() bool {
+	var pcs [64]uintptr
+	// Skip runtime.Callers, inErrorHandler, and HandleError.
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if frame.Function == _callErrorHandlerName {
+			return true
+		}
+		if !more {
+			return false
+		}
+	}
+}
+
+// reportReuse makes a best effort to report unsafe re-use of this
+// CheckedEntry, identifying the caller of the offending Write. Because the
+// CheckedEntry may have been returned to the pool, the message may be an
+// amalgamation from multiple call sites.
+func (ce *CheckedEntry) reportReuse() {
+	errOut := ce.ErrorOutput
+	if errOut == nil {
+		return
+	}
+
+	caller := "unknown caller"
+	// Skip reportReuse and Write.
+	if pc, file, line, ok := runtime.Caller(2); ok {
+		caller = fmt.Sprintf("%s:%d", file, line)
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			caller = fn.Name() + " (" + caller + ")"
+		}
+	}
+	_, _ = fmt.Fprintf(
+		errOut,
+		"%v Unsafe CheckedEntry re-use near Entry %+v: written again by %s.\n",
+		ce.Time,
+		ce.Entry,
+		caller,
+	)
+	_ = errOut.Sync() // ignore error
+}
+
 // AddCore adds a Core that has agreed to log this CheckedEntry. It's intended to be
 // used by Core.Check implementations, and is safe to call on nil CheckedEntry
 // references.
@@ -286,6 +415,17 @@ func (ce *CheckedEntry) AddCore(ent Entry, core Core) *CheckedEntry {
 	return ce
 }
 
+// AddFields adds fields to the entry, after those passed to Write. It's
+// intended to be used by Loggers that annotate entries when they're checked,
+// and is safe to call on nil CheckedEntry references, which it leaves nil.
+func (ce *CheckedEntry) AddFields(fields ...Field) *CheckedEntry {
+	if ce == nil {
+		return nil
+	}
+	ce.fields = append(ce.fields, fields...)
+	return ce
+}
+
 // Should sets this CheckedEntry's CheckWriteAction, which controls whether a
 // Core will panic or fatal after writing this log entry. Like AddCore, it's
 // safe to call on nil CheckedEntry references.
@@ -306,3 +446,20 @@ func (ce *CheckedEntry) After(ent Entry, hook CheckWriteHook) *CheckedEntry {
 	ce.after = hook
 	return ce
 }
+
+// checkAndWrite writes an entry to the Cores that core's Check selects for
+// it. Cores that defer their decisions to Write use it to write to the Cores
+// they wrap without bypassing those Cores' Check.
+func checkAndWrite(core Core, ent Entry, fields []Field) error {
+	ce := core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+	defer putCheckedEntry(ce)
+
+	var err error
+	for _, c := range ce.cores {
+		err = multierr.Append(err, c.Write(ent, fields))
+	}
+	return err
+}
@@ -22,13 +22,17 @@ package zapcore_test
 
 import (
 	"bytes"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/toujourser/zap"
 	"github.com/toujourser/zap/zapcore"
 	"github.com/toujourser/zap/zaptest"
+	"github.com/toujourser/zap/zaptest/observer"
 )
 
 func TestCheckedEntryIllegalReuse(t *testing.T) {
@@ -53,3 +57,59 @@ func TestCheckedEntryIllegalReuse(t *testing.T) {
 	assert.Contains(t, errOut.String(), "Unsafe CheckedEntry re-use near Entry",
 		"Expected error logged on second write.")
 }
+
+func TestCheckedEntryConcurrentWrite(t *testing.T) {
+	// Not parallel: other tests could pick the entry up from the pool after
+	// it's written, hiding the re-use.
+	const numWriters = 16
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	var errOut bytes.Buffer
+	ce := core.Check(zapcore.Entry{Level: zapcore.InfoLevel, Message: "hello"}, nil)
+	require.NotNil(t, ce, "Expected the entry to be enabled.")
+	ce.ErrorOutput = zapcore.Lock(zapcore.AddSync(&errOut))
+
+	var start, wg sync.WaitGroup
+	start.Add(1)
+	for i := 0; i < numWriters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start.Wait()
+			ce.Write(zap.String("k", "v"))
+		}()
+	}
+	start.Done()
+	wg.Wait()
+
+	assert.Equal(t, 1, logs.Len(), "Expected exactly one write to succeed.")
+	reports := strings.Split(strings.TrimSpace(errOut.String()), "\n")
+	require.Len(t, reports, numWriters-1, "Expected every other write to be reported.")
+	for _, r := range reports {
+		assert.Contains(t, r, "Unsafe CheckedEntry re-use near Entry", "Unexpected error output.")
+		assert.Contains(t, r, "written again by github.com/toujourser/zap/zapcore_test.TestCheckedEntryConcurrentWrite.func1",
+			"Expected the report to identify the caller of the offending Write.")
+	}
+}
+
+// retainingHook writes the CheckedEntry again from OnWrite, as a buggy hook
+// that retains the entry might.
+type retainingHook struct{}
+
+func (retainingHook) OnWrite(ce *zapcore.CheckedEntry, _ []zapcore.Field) {
+	ce.Write(zap.String("again", "yes"))
+}
+
+func TestCheckedEntryWriteFromHook(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	var errOut bytes.Buffer
+
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Message: "hello"}
+	ce := core.Check(ent, nil).After(ent, retainingHook{})
+	ce.ErrorOutput = zapcore.AddSync(&errOut)
+	ce.Write()
+
+	assert.Equal(t, 1, logs.Len(), "Expected the entry to be written once.")
+	assert.Contains(t, errOut.String(), "written again by github.com/toujourser/zap/zapcore_test.retainingHook.OnWrite",
+		"Expected the hook's write to be reported.")
+}
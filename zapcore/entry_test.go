@@ -62,7 +62,7 @@ func TestPutNilEntry(t *testing.T) {
 		for i := 0; i < 1000; i++ {
 			ce := getCheckedEntry()
 			assert.NotNil(t, ce, "Expected only non-nil CheckedEntries in pool.")
-			assert.False(t, ce.dirty, "Unexpected dirty bit set.")
+			assert.Equal(t, ceOpen, ce.state.Load(), "Unexpected state for a fresh CheckedEntry.")
 			assert.Nil(t, ce.ErrorOutput, "Non-nil ErrorOutput.")
 			assert.Nil(t, ce.after, "Unexpected terminal behavior.")
 			assert.Equal(t, 0, len(ce.cores), "Expected empty slice of cores.")
@@ -131,6 +131,18 @@ func TestCheckedEntryWrite(t *testing.T) {
 		assert.Equal(t, 1, stub.Code, "Expected to exit when WriteThenFatal is set.")
 	})
 
+	t.Run("WriteThenExitCode", func(t *testing.T) {
+		for _, code := range []int{0, 1, 3} {
+			var ce *CheckedEntry
+			ce = ce.After(Entry{}, WriteThenExitCode(code))
+			stub := exit.WithStub(func() {
+				ce.Write()
+			})
+			assert.True(t, stub.Exited, "Expected to exit when WriteThenExitCode is set.")
+			assert.Equal(t, code, stub.Code, "Unexpected exit code.")
+		}
+	})
+
 	t.Run("After", func(t *testing.T) {
 		var ce *CheckedEntry
 		hook := &customHook{}
@@ -138,6 +150,43 @@ func TestCheckedEntryWrite(t *testing.T) {
 		ce.Write()
 		assert.True(t, hook.called, "Expected to call custom action after Write.")
 	})
+
+	t.Run("AddFields", func(t *testing.T) {
+		var nilCE *CheckedEntry
+		assert.Nil(t, nilCE.AddFields(Field{Key: "k"}), "Expected AddFields to leave nil entries nil.")
+
+		core := &fieldsCore{Core: NewNopCore()}
+		ce := core.Check(Entry{}, nil)
+		ce = ce.AddFields(Field{Key: "seq", Type: Uint64Type, Integer: 1})
+		passed := []Field{{Key: "k", Type: StringType, String: "v"}}
+		ce.Write(passed...)
+		assert.Equal(t, []Field{
+			{Key: "k", Type: StringType, String: "v"},
+			{Key: "seq", Type: Uint64Type, Integer: 1},
+		}, core.fields, "Expected added fields after those passed to Write.")
+		assert.Len(t, passed, 1, "Expected the caller's fields to be left alone.")
+
+		// Pooled entries don't keep added fields.
+		core.fields = nil
+		core.Check(Entry{}, nil).Write()
+		assert.Empty(t, core.fields, "Expected a reused entry to have no added fields.")
+	})
+}
+
+// fieldsCore records the fields of the last entry written to it.
+type fieldsCore struct {
+	Core
+
+	fields []Field
+}
+
+func (c *fieldsCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *fieldsCore) Write(_ Entry, fields []Field) error {
+	c.fields = fields
+	return nil
 }
 
 type customHook struct {
@@ -22,17 +22,33 @@ package zapcore
 
 import (
 	"fmt"
-	"reflect"
 
+	"github.com/toujourser/zap/internal/bufferpool"
 	"github.com/toujourser/zap/internal/pool"
+	"github.com/toujourser/zap/internal/stacktrace"
 )
 
+// StackTracer is implemented by errors that record the stack trace on which
+// they were created, as program counters returned by runtime.Callers.
+//
+// Errors implementing StackTracer, or wrapping an error that does, are
+// logged with their stack trace under ${key}Stack. Errors from
+// github.com/pkg/errors, whose StackTrace method returns frames rather than
+// a []uintptr, are supported as well.
+type StackTracer interface {
+	StackTrace() []uintptr
+}
+
 // Encodes the given error into fields of an object. A field with the given
 // name is added for the error message.
 //
 // If the error implements fmt.Formatter, a field with the name ${key}Verbose
 // is also added with the full verbose error message.
 //
+// If the error or an error it wraps records a stack trace (see StackTracer),
+// a ${key}Stack field is added with the innermost stack trace, formatted like
+// the stack traces zap captures for log entries.
+//
 // Finally, if the error implements errorGroup (from go.uber.org/multierr) or
 // causer (from github.com/pkg/errors), a ${key}Causes field is added with an
 // array of objects containing the errors this error was comprised of.
@@ -40,29 +56,33 @@ import (
 //	{
 //	  "error": err.Error(),
 //	  "errorVerbose": fmt.Sprintf("%+v", err),
+//	  "errorStack": "main.f\n\t/path/to/main.go:12\n...",
 //	  "errorCauses": [
 //	    ...
 //	  ],
 //	}
 func encodeError(key string, err error, enc ObjectEncoder) (retErr error) {
 	// Try to capture panics (from nil references or otherwise) when calling
-	// the Error() method
+	// the Error() method. A panic from the Format method comes after the
+	// key is written, so it's only reported.
+	var added bool
 	defer func() {
 		if rerr := recover(); rerr != nil {
-			// If it's a nil pointer, just say "<nil>". The likeliest causes are a
-			// error that fails to guard against nil or a nil pointer for a
-			// value receiver, and in either case, "<nil>" is a nice result.
-			if v := reflect.ValueOf(err); v.Kind() == reflect.Ptr && v.IsNil() {
-				enc.AddString(key, "<nil>")
+			if added {
+				retErr = &panicError{rerr}
 				return
 			}
-
-			retErr = fmt.Errorf("PANIC=%v", rerr)
+			retErr = addPanicked(enc, key, err, rerr)
 		}
 	}()
 
 	basic := err.Error()
 	enc.AddString(key, basic)
+	added = true
+
+	if pcs := stacktrace.FromError(err); len(pcs) > 0 {
+		enc.AddString(key+"Stack", formatPCs(pcs))
+	}
 
 	switch e := err.(type) {
 	case errorGroup:
@@ -78,6 +98,15 @@ func encodeError(key string, err error, enc ObjectEncoder) (retErr error) {
 	return nil
 }
 
+func formatPCs(pcs []uintptr) string {
+	buf := bufferpool.Get()
+	defer buf.Free()
+
+	stackfmt := stacktrace.NewFormatter(buf)
+	stackfmt.FormatPCs(pcs)
+	return buf.String()
+}
+
 type errorGroup interface {
 	// Provides read-only access to the underlying list of errors, preferably
 	// without causing any allocs.
@@ -24,9 +24,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"go.uber.org/multierr"
 	//revive:disable:dot-imports
@@ -168,6 +171,38 @@ func TestErrorEncoding(t *testing.T) {
 	}
 }
 
+type stackTracerError struct{ pcs []uintptr }
+
+func (e stackTracerError) Error() string         { return "with stack" }
+func (e stackTracerError) StackTrace() []uintptr { return e.pcs }
+
+func TestErrorStack(t *testing.T) {
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	err := fmt.Errorf("failed: %w", stackTracerError{pcs})
+
+	enc := NewMapObjectEncoder()
+	Field{Key: "k", Type: ErrorType, Interface: err}.AddTo(enc)
+
+	assert.Equal(t, "failed: with stack", enc.Fields["k"], "Unexpected basic error message.")
+	require.Contains(t, enc.Fields, "kStack", "Expected the wrapped error's stack to be logged.")
+	stack := enc.Fields["kStack"].(string)
+	assert.True(t, strings.HasPrefix(stack, "github.com/toujourser/zap/zapcore_test.TestErrorStack\n\t"),
+		"Expected the stack to start at the test, got:\n%s", stack)
+	assert.NotContains(t, stack, "runtime.goexit", "Unexpected runtime frame in stack.")
+}
+
+func TestErrorStackPanics(t *testing.T) {
+	enc := NewMapObjectEncoder()
+	Field{Key: "k", Type: ErrorType, Interface: panicStackError{}}.AddTo(enc)
+	assert.Equal(t, map[string]any{"k": "panics"}, enc.Fields, "Expected a panicking StackTrace to be ignored.")
+}
+
+type panicStackError struct{}
+
+func (panicStackError) Error() string         { return "panics" }
+func (panicStackError) StackTrace() []uintptr { panic("great sadness") }
+
 func TestRichErrorSupport(t *testing.T) {
 	f := Field{
 		Type:      ErrorType,
@@ -0,0 +1,277 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zapcore
+
+import (
+	"sync"
+	"time"
+)
+
+const _everyShards = 16
+
+// everyOptionFunc wraps a func so it satisfies the EveryOption interface.
+type everyOptionFunc func(*everyLimiter)
+
+func (f everyOptionFunc) apply(l *everyLimiter) {
+	f(l)
+}
+
+// EveryOption configures a Core created with NewEveryCore.
+type EveryOption interface {
+	apply(*everyLimiter)
+}
+
+// EveryHook registers a function which will be called each time the Core
+// writes or suppresses an entry. The decision is reported with the same
+// values as the Sampler's: LogSampled for entries that were written and
+// LogDropped for entries that were suppressed.
+func EveryHook(hook func(entry Entry, dec SamplingDecision)) EveryOption {
+	return everyOptionFunc(func(l *everyLimiter) {
+		l.hook = hook
+	})
+}
+
+// EveryClock sets the Clock used to measure intervals. By default, the Core
+// uses each entry's timestamp.
+func EveryClock(clock Clock) EveryOption {
+	return everyOptionFunc(func(l *everyLimiter) {
+		l.clock.Store(clock)
+	})
+}
+
+// NewEveryCore creates a Core that writes each key at most once per interval,
+// such as a "cache miss" logged on every request, no matter how many entries
+// with that key are logged. Unlike the Sampler, which counts entries, it only
+// looks at when the key was last written.
+//
+// The first entry with a given key is written and starts an interval. Entries
+// with the same key are dropped until the interval ends. The next entry with
+// the key after that is written with a "suppressed_count" field holding the
+// number dropped since the last one written, and starts a new interval.
+//
+// keyFn computes the key of an entry from the entry and its fields, starting
+// with those added with With. If it's nil, entries are keyed by their level,
+// logger name, and message, so the values of their fields are ignored.
+//
+// Keys are kept in a sharded map and forgotten once they haven't been written
+// for two intervals, which bounds memory by the number of distinct keys
+// logged in that time. The count of entries suppressed since a forgotten key
+// was last written is discarded with it.
+//
+// Entries at PanicLevel and above are never dropped. If interval isn't
+// positive, NewEveryCore returns the provided Core unchanged.
+func NewEveryCore(inner Core, interval time.Duration, keyFn func(Entry, []Field) string, opts ...EveryOption) Core {
+	if interval <= 0 {
+		return inner
+	}
+
+	l := &everyLimiter{
+		interval: int64(interval),
+		key:      keyFn,
+		hook:     nopSamplingHook,
+		clock:    new(clockRef),
+	}
+	for i := range l.shards {
+		l.shards[i].seen = make(map[everyKey]*everyState)
+	}
+	for _, opt := range opts {
+		opt.apply(l)
+	}
+	return &everyCore{
+		core:    inner,
+		limiter: l,
+		clock:   l.clock,
+	}
+}
+
+// everyLimiter holds the state shared by a Core created with NewEveryCore and
+// the Cores derived from it with With.
+type everyLimiter struct {
+	interval int64 // nanoseconds
+	key      func(Entry, []Field) string
+	hook     func(Entry, SamplingDecision)
+	clock    *clockRef
+
+	shards [_everyShards]everyShard
+}
+
+// everyKey identifies the entries limited together. Custom key functions
+// only set msg.
+type everyKey struct {
+	level  Level
+	logger string
+	msg    string
+}
+
+// everyState tracks the current interval of one key.
+type everyState struct {
+	last       int64 // Unix nanoseconds
+	suppressed uint64
+}
+
+type everyShard struct {
+	mu    sync.Mutex
+	seen  map[everyKey]*everyState
+	swept int64 // Unix nanoseconds
+}
+
+func (l *everyLimiter) shard(key everyKey) *everyShard {
+	h := fnv64aString(_fnv64Offset, key.logger)
+	h = fnv64aByte(h, 0)
+	h = fnv64aString(h, key.msg)
+	return &l.shards[h%_everyShards]
+}
+
+// allow reports whether an entry with the given key may be written at now,
+// along with the number of entries suppressed since the key was last
+// written.
+func (l *everyLimiter) allow(key everyKey, now int64) (ok bool, suppressed uint64) {
+	s := l.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now-s.swept >= l.interval {
+		s.sweep(now, l.interval)
+	}
+	st, found := s.seen[key]
+	if !found {
+		s.seen[key] = &everyState{last: now}
+		return true, 0
+	}
+	if now-st.last < l.interval {
+		st.suppressed++
+		return false, 0
+	}
+	suppressed = st.suppressed
+	st.last, st.suppressed = now, 0
+	return true, suppressed
+}
+
+// sweep forgets keys that haven't been written for two intervals, and keys
+// whose interval ended with nothing suppressed. It must be called with s.mu
+// held.
+func (s *everyShard) sweep(now, interval int64) {
+	for key, st := range s.seen {
+		age := now - st.last
+		if age >= 2*interval || (age >= interval && st.suppressed == 0) {
+			delete(s.seen, key)
+		}
+	}
+	s.swept = now
+}
+
+type everyCore struct {
+	core    Core
+	limiter *everyLimiter
+	clock   *clockRef // shared with the Cores derived with With
+
+	// fields were added with With. They're only kept if there is a custom
+	// key function to pass them to.
+	fields []Field
+}
+
+var (
+	_ Core           = (*everyCore)(nil)
+	_ LeveledEnabler = (*everyCore)(nil)
+	_ ClockCopier    = (*everyCore)(nil)
+)
+
+func (c *everyCore) Enabled(lvl Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *everyCore) Level() Level {
+	return LevelOf(c.core)
+}
+
+func (c *everyCore) WithClock(clock Clock) Core {
+	return &everyCore{
+		core:    WithClock(c.core, clock),
+		limiter: c.limiter,
+		clock:   newClockRef(clock),
+		fields:  c.fields,
+	}
+}
+
+// now returns the current time according to the Core's Clock, or ent's
+// timestamp if there is none.
+func (c *everyCore) now(ent Entry) int64 {
+	if clock := c.clock.Load(); clock != nil {
+		return clock.Now().UnixNano()
+	}
+	return ent.Time.UnixNano()
+}
+
+func (c *everyCore) With(fields []Field) Core {
+	clone := &everyCore{
+		core:    c.core.With(fields),
+		limiter: c.limiter,
+		clock:   c.clock,
+	}
+	if c.limiter.key != nil {
+		clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	}
+	return clone
+}
+
+func (c *everyCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if ent.Level >= PanicLevel {
+		return c.core.Check(ent, ce)
+	}
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	// Custom keys may depend on fields, so entries are limited in Write.
+	return ce.AddCore(ent, c)
+}
+
+func (c *everyCore) Write(ent Entry, fields []Field) error {
+	l := c.limiter
+	ok, suppressed := l.allow(c.key(ent, fields), c.now(ent))
+	if !ok {
+		l.hook(ent, LogDropped)
+		return nil
+	}
+	if suppressed > 0 {
+		fields = append(fields[:len(fields):len(fields)], Field{
+			Key:     "suppressed_count",
+			Type:    Uint64Type,
+			Integer: int64(suppressed),
+		})
+	}
+	l.hook(ent, LogSampled)
+	return checkAndWrite(c.core, ent, fields)
+}
+
+func (c *everyCore) Sync() error {
+	return c.core.Sync()
+}
+
+// key returns the key of an entry written to this Core.
+func (c *everyCore) key(ent Entry, fields []Field) everyKey {
+	fn := c.limiter.key
+	if fn == nil {
+		return everyKey{level: ent.Level, logger: ent.LoggerName, msg: ent.Message}
+	}
+	if len(c.fields) > 0 {
+		fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	}
+	return everyKey{msg: fn(ent, fields)}
+}
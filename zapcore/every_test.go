@@ -0,0 +1,207 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zapcore_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zapcore/clock"
+	"github.com/toujourser/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func suppressedCounts(entries []observer.LoggedEntry) []int64 {
+	counts := make([]int64, len(entries))
+	for i, e := range entries {
+		if n, ok := e.ContextMap()["suppressed_count"]; ok {
+			counts[i] = int64(n.(uint64))
+		}
+	}
+	return counts
+}
+
+func TestEveryCore(t *testing.T) {
+	clk := clock.NewMockAt(_rateLimitEpoch)
+	obs, logs := observer.New(DebugLevel)
+	core := NewEveryCore(obs, 30*time.Second, nil, EveryClock(clk))
+
+	for i := 0; i < 100; i++ {
+		writeAt(core, InfoLevel, "cache miss", 0, Field{Key: "key", Type: Int64Type, Integer: int64(i)})
+		clk.Add(100 * time.Millisecond)
+	}
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1, "Expected one entry per interval.")
+	assert.Equal(t, []int64{0}, suppressedCounts(entries))
+	assert.Equal(t, int64(0), entries[0].ContextMap()["key"], "Expected the first entry to be written.")
+
+	clk.Add(25 * time.Second)
+	writeAt(core, InfoLevel, "cache miss", 0)
+	writeAt(core, InfoLevel, "cache miss", 0)
+	entries = logs.TakeAll()
+	require.Len(t, entries, 1)
+	assert.Equal(t, []int64{99}, suppressedCounts(entries), "Expected the suppressed count on the next entry.")
+
+	// An interval without suppressed entries leaves nothing to report.
+	clk.Add(30 * time.Second)
+	writeAt(core, InfoLevel, "cache miss", 0)
+	clk.Add(30 * time.Second)
+	writeAt(core, InfoLevel, "cache miss", 0)
+	assert.Equal(t, []int64{1, 0}, suppressedCounts(logs.TakeAll()))
+}
+
+func TestEveryCoreDistinctKeys(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := NewEveryCore(obs, time.Minute, nil)
+
+	writeAt(core, InfoLevel, "msg", 0)
+	writeAt(core, WarnLevel, "msg", 0)
+	writeAt(core, InfoLevel, "other", 0)
+	writeAt(core, InfoLevel, "msg", time.Second, Field{Key: "attempt", Type: Int64Type, Integer: 1})
+	writeAt(core, InfoLevel, "other", time.Second)
+
+	ent := Entry{Level: InfoLevel, LoggerName: "named", Message: "msg", Time: _rateLimitEpoch}
+	require.NoError(t, core.Write(ent, nil))
+
+	assert.Equal(t, []string{"msg", "msg", "other", "msg"}, loggedMessages(logs),
+		"Expected keys to depend on level, logger name, and message only.")
+}
+
+func TestEveryCoreKeyFunc(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	var (
+		mu   sync.Mutex
+		seen [][]string
+	)
+	keyFn := func(ent Entry, fields []Field) string {
+		mu.Lock()
+		defer mu.Unlock()
+		keys := make([]string, len(fields))
+		for i, f := range fields {
+			keys[i] = f.Key
+		}
+		seen = append(seen, keys)
+		for _, f := range fields {
+			if f.Key == "key" {
+				return f.String
+			}
+		}
+		return ""
+	}
+	core := NewEveryCore(obs, time.Minute, keyFn).With([]Field{{Key: "svc", Type: StringType, String: "api"}})
+
+	for _, k := range []string{"a", "b", "a", "c", "b"} {
+		writeAt(core, InfoLevel, "cache miss", time.Second, Field{Key: "key", Type: StringType, String: k})
+	}
+	entries := logs.TakeAll()
+	require.Len(t, entries, 3, "Expected one entry per distinct key.")
+	for i, want := range []string{"a", "b", "c"} {
+		assert.Equal(t, want, entries[i].ContextMap()["key"])
+		assert.Equal(t, "api", entries[i].ContextMap()["svc"], "Expected With fields to be written.")
+	}
+	assert.Equal(t, []string{"svc", "key"}, seen[0], "Expected the key func to see With fields first.")
+}
+
+func TestEveryCoreForgetsIdleKeys(t *testing.T) {
+	clk := clock.NewMockAt(_rateLimitEpoch)
+	obs, logs := observer.New(DebugLevel)
+	core := NewEveryCore(obs, time.Minute, nil, EveryClock(clk))
+
+	writeAt(core, InfoLevel, "foo", 0)
+	writeAt(core, InfoLevel, "foo", 0)
+	require.Equal(t, []int64{0}, suppressedCounts(logs.TakeAll()))
+
+	// Two intervals later, the key and its suppressed count are forgotten.
+	clk.Add(2 * time.Minute)
+	writeAt(core, InfoLevel, "bar", 0)
+	writeAt(core, InfoLevel, "foo", 0)
+	assert.Equal(t, []int64{0, 0}, suppressedCounts(logs.TakeAll()))
+
+	// Less than two intervals later, the count is kept.
+	writeAt(core, InfoLevel, "foo", 0)
+	clk.Add(90 * time.Second)
+	writeAt(core, InfoLevel, "bar", 0)
+	writeAt(core, InfoLevel, "foo", 0)
+	assert.Equal(t, []int64{0, 1}, suppressedCounts(logs.TakeAll()))
+}
+
+func TestEveryCoreClock(t *testing.T) {
+	clk := clock.NewMockAt(_rateLimitEpoch)
+	obs, logs := observer.New(DebugLevel)
+	core := WithClock(NewEveryCore(obs, time.Minute, nil), clk)
+
+	// Entry timestamps are ignored in favor of the clock.
+	writeAt(core, InfoLevel, "foo", 0)
+	writeAt(core, InfoLevel, "foo", time.Hour)
+	assert.Equal(t, []string{"foo"}, loggedMessages(logs))
+
+	clk.Add(time.Minute)
+	writeAt(core, InfoLevel, "foo", 0)
+	assert.Equal(t, []int64{1}, suppressedCounts(logs.TakeAll()))
+}
+
+func TestEveryCorePanicLevel(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := NewEveryCore(obs, time.Minute, nil)
+
+	for i := 0; i < 3; i++ {
+		ent := Entry{Level: DPanicLevel, Message: "boom", Time: _rateLimitEpoch}
+		if ce := core.Check(ent, nil); ce != nil {
+			ce.Write()
+		}
+	}
+	assert.Equal(t, 1, logs.Len(), "Expected DPanic entries to be limited.")
+
+	for i := 0; i < 3; i++ {
+		ent := Entry{Level: PanicLevel, Message: "boom", Time: _rateLimitEpoch}
+		if ce := core.Check(ent, nil); ce != nil {
+			ce.Should(ent, WriteThenNoop)
+			ce.Write()
+		}
+	}
+	assert.Equal(t, 4, logs.Len(), "Expected Panic entries to bypass the core.")
+}
+
+func TestEveryCoreHook(t *testing.T) {
+	var decisions []SamplingDecision
+	hook := func(_ Entry, dec SamplingDecision) { decisions = append(decisions, dec) }
+	obs, _ := observer.New(DebugLevel)
+	core := NewEveryCore(obs, time.Minute, nil, EveryHook(hook))
+
+	writeAt(core, InfoLevel, "foo", 0)
+	writeAt(core, InfoLevel, "foo", 0)
+	writeAt(core, InfoLevel, "foo", time.Minute)
+	assert.Equal(t, []SamplingDecision{LogSampled, LogDropped, LogSampled}, decisions)
+}
+
+func TestEveryCoreDisabled(t *testing.T) {
+	obs, _ := observer.New(InfoLevel)
+	assert.Equal(t, obs, NewEveryCore(obs, 0, nil), "Expected a non-positive interval to return the core.")
+
+	core := NewEveryCore(obs, time.Minute, nil)
+	assert.Equal(t, InfoLevel, LevelOf(core))
+	assert.Nil(t, core.Check(Entry{Level: DebugLevel}, nil), "Expected disabled entries to be dropped.")
+	assert.NoError(t, core.Sync())
+}
@@ -22,10 +22,15 @@ package zapcore
 
 import (
 	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
 	"time"
+
+	"github.com/toujourser/zap/internal/bufferpool"
 )
 
 // A FieldType indicates which member of the Field union struct should be used
@@ -96,6 +101,22 @@ const (
 	// InlineMarshalerType indicates that the field carries an ObjectMarshaler
 	// that should be inlined.
 	InlineMarshalerType
+	// RedactedType indicates that the field carries a sensitive value that
+	// should be masked. The value is either a string or, if Interface is
+	// set, the Field that it masks.
+	RedactedType
+	// RawJSONType indicates that the field carries a json.RawMessage that
+	// should be embedded in the output as-is.
+	RawJSONType
+	// TextMarshalerType indicates that the field carries an
+	// encoding.TextMarshaler.
+	TextMarshalerType
+	// OmitEmptyType indicates that the field carries, in Interface, a Field
+	// that should be omitted if its value is empty.
+	OmitEmptyType
+	// TextAppenderType indicates that the field carries a value that appends
+	// its text form to a byte slice, like the address types in net/netip.
+	TextAppenderType
 )
 
 // A Field is a marshaling operation used to add a key-value pair to a logger's
@@ -120,7 +141,7 @@ func (f Field) AddTo(enc ObjectEncoder) {
 	case ObjectMarshalerType:
 		err = enc.AddObject(f.Key, f.Interface.(ObjectMarshaler))
 	case InlineMarshalerType:
-		err = f.Interface.(ObjectMarshaler).MarshalLogObject(enc)
+		err = encodeInline(f.Interface, enc)
 	case BinaryType:
 		enc.AddBinary(f.Key, f.Interface.([]byte))
 	case BoolType:
@@ -174,13 +195,23 @@ func (f Field) AddTo(enc ObjectEncoder) {
 		err = encodeStringer(f.Key, f.Interface, enc)
 	case ErrorType:
 		err = encodeError(f.Key, f.Interface.(error), enc)
+	case RedactedType:
+		err = encodeRedacted(f, enc)
+	case RawJSONType:
+		err = enc.AddReflected(f.Key, f.Interface)
+	case TextMarshalerType:
+		err = encodeTextMarshaler(f.Key, f.Interface, enc)
+	case OmitEmptyType:
+		encodeOmitEmpty(f.Interface.(Field), enc)
+	case TextAppenderType:
+		encodeTextAppender(f.Key, f.Interface.(textAppender), enc)
 	case SkipType:
 		break
 	default:
 		panic(fmt.Sprintf("unknown field type: %v", f))
 	}
 
-	if err != nil {
+	if err != nil && (!isPanicError(err) || shouldReportPanics(enc)) {
 		enc.AddString(fmt.Sprintf("%sError", f.Key), err.Error())
 	}
 }
@@ -198,8 +229,14 @@ func (f Field) Equals(other Field) bool {
 	switch f.Type {
 	case BinaryType, ByteStringType:
 		return bytes.Equal(f.Interface.([]byte), other.Interface.([]byte))
-	case ArrayMarshalerType, ObjectMarshalerType, ErrorType, ReflectType:
+	case ArrayMarshalerType, ObjectMarshalerType, InlineMarshalerType, ErrorType, ReflectType, TextMarshalerType, TextAppenderType:
 		return reflect.DeepEqual(f.Interface, other.Interface)
+	case RedactedType:
+		return f.String == other.String && reflect.DeepEqual(f.Interface, other.Interface)
+	case RawJSONType:
+		return bytes.Equal(f.Interface.(json.RawMessage), other.Interface.(json.RawMessage))
+	case OmitEmptyType:
+		return f.Interface.(Field).Equals(other.Interface.(Field))
 	default:
 		return f == other
 	}
@@ -214,13 +251,90 @@ func addFields(enc ObjectEncoder, fields []Field) {
 func encodeStringer(key string, stringer interface{}, enc ObjectEncoder) (retErr error) {
 	// Try to capture panics (from nil references or otherwise) when calling
 	// the String() method, similar to https://golang.org/src/fmt/print.go#L540
+	defer func() {
+		if r := recover(); r != nil {
+			retErr = addPanicked(enc, key, stringer, r)
+		}
+	}()
+
+	if rs, ok := stringer.(RedactedStringer); ok {
+		enc.AddString(key, redactedString(enc, rs))
+		return nil
+	}
+	enc.AddString(key, stringer.(fmt.Stringer).String())
+	return nil
+}
+
+// marshalerPanic describes a panic recovered from a method of v, such as
+// String or MarshalLogObject. It returns the placeholder that encoders log
+// in place of the value and the error they return for it.
+//
+// If v is a nil pointer, the placeholder is "<nil>" and there's no error.
+// The likeliest causes are a method that fails to guard against nil or a
+// nil pointer for a value receiver, and in either case, "<nil>" is a nice
+// result.
+func marshalerPanic(v, r interface{}) (placeholder string, err error) {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return "<nil>", nil
+	}
+	return fmt.Sprintf("<panic: %v>", r), &panicError{r}
+}
+
+// addPanicked logs the placeholder for a value that panicked under key.
+func addPanicked(enc ObjectEncoder, key string, v, r interface{}) error {
+	placeholder, err := marshalerPanic(v, r)
+	enc.AddString(key, placeholder)
+	return err
+}
+
+// panicError reports a panic recovered while encoding a field's value.
+// Unlike the errors that values return, the panic is only reported in the
+// field's error key if the encoder is configured with
+// EncoderConfig.ReportPanics, since the placeholder logged under the
+// field's own key already says what happened.
+type panicError struct {
+	r interface{}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("PANIC=%v", e.r)
+}
+
+func isPanicError(err error) bool {
+	var pe *panicError
+	return errors.As(err, &pe)
+}
+
+// panicReporter is implemented by encoders that report recovered panics in
+// an error key. Encoders that embed *EncoderConfig implement it
+// automatically.
+type panicReporter interface {
+	reportPanics() bool
+}
+
+func (cfg *EncoderConfig) reportPanics() bool {
+	return cfg.ReportPanics
+}
+
+// shouldReportPanics reports whether the given encoder is configured to add
+// an error key for values that panicked.
+func shouldReportPanics(enc interface{}) bool {
+	r, ok := enc.(panicReporter)
+	return ok && r.reportPanics()
+}
+
+// encodeInline adds the fields of an ObjectMarshaler directly to enc, in
+// the current namespace. A nil marshaler adds nothing.
+func encodeInline(marshaler interface{}, enc ObjectEncoder) (retErr error) {
+	if marshaler == nil {
+		return nil
+	}
+	// Capture panics the same way encodeStringer does. There's no key to
+	// attach a placeholder to, so nil pointers just add nothing, and other
+	// panics are always reported.
 	defer func() {
 		if err := recover(); err != nil {
-			// If it's a nil pointer, just say "<nil>". The likeliest causes are a
-			// Stringer that fails to guard against nil or a nil pointer for a
-			// value receiver, and in either case, "<nil>" is a nice result.
-			if v := reflect.ValueOf(stringer); v.Kind() == reflect.Ptr && v.IsNil() {
-				enc.AddString(key, "<nil>")
+			if v := reflect.ValueOf(marshaler); v.Kind() == reflect.Ptr && v.IsNil() {
 				return
 			}
 
@@ -228,6 +342,47 @@ func encodeStringer(key string, stringer interface{}, enc ObjectEncoder) (retErr
 		}
 	}()
 
-	enc.AddString(key, stringer.(fmt.Stringer).String())
+	return marshaler.(ObjectMarshaler).MarshalLogObject(enc)
+}
+
+func encodeTextMarshaler(key string, marshaler interface{}, enc ObjectEncoder) (retErr error) {
+	// Capture panics from MarshalText the same way encodeStringer does.
+	defer func() {
+		if r := recover(); r != nil {
+			retErr = addPanicked(enc, key, marshaler, r)
+		}
+	}()
+
+	text, err := marshaler.(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return err
+	}
+	enc.AddByteString(key, text)
+	return nil
+}
+
+// A textAppender appends its text form to a byte slice. The types in net/netip
+// implement it.
+type textAppender interface {
+	AppendTo([]byte) []byte
+}
+
+func encodeTextAppender(key string, val textAppender, enc ObjectEncoder) {
+	// Append into a pooled buffer rather than allocating a string.
+	buf := bufferpool.Get()
+	enc.AddByteString(key, val.AppendTo(buf.Bytes()))
+	buf.Free()
+}
+
+func encodeRedacted(f Field, enc ObjectEncoder) error {
+	if !shouldRevealSecrets(enc) {
+		enc.AddString(f.Key, RedactedPlaceholder)
+		return nil
+	}
+	if orig, ok := f.Interface.(Field); ok {
+		orig.AddTo(enc)
+		return nil
+	}
+	enc.AddString(f.Key, f.String)
 	return nil
 }
@@ -0,0 +1,311 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+// FieldDedupOption configures a Core built with NewFieldDedupCore.
+type FieldDedupOption interface {
+	apply(*fieldDeduper)
+}
+
+// fieldDedupOptionFunc wraps a func so it satisfies the FieldDedupOption
+// interface.
+type fieldDedupOptionFunc func(*fieldDeduper)
+
+func (f fieldDedupOptionFunc) apply(d *fieldDeduper) {
+	f(d)
+}
+
+// KeepFirstField keeps the first field with each key and drops the later
+// ones, instead of keeping the last.
+func KeepFirstField() FieldDedupOption {
+	return fieldDedupOptionFunc(func(d *fieldDeduper) {
+		d.keepFirst = true
+	})
+}
+
+// fieldDeduper holds the configuration shared by a field-deduplicating Core
+// and the Cores derived from it with With.
+type fieldDeduper struct {
+	keepFirst bool
+}
+
+type fieldDedupCore struct {
+	base Core // the wrapped Core, without context
+	core Core // base with context
+	d    *fieldDeduper
+
+	context []Field        // context fields added with With, without duplicates
+	keys    []fieldKeySlot // keys of context, as a set
+	scope   int            // namespace of the fields that follow context
+}
+
+var (
+	_ Core           = (*fieldDedupCore)(nil)
+	_ LeveledEnabler = (*fieldDedupCore)(nil)
+)
+
+// NewFieldDedupCore wraps a Core, dropping fields whose key is repeated so
+// that encoders never write duplicate keys. By default, the last field with
+// each key is kept; use KeepFirstField to keep the first instead. Both the
+// fields added with With and those passed to each log call are considered,
+// in that order.
+//
+// Keys are only compared within a namespace: a field added after a
+// Namespace field doesn't replace a field with the same key outside of it.
+// Namespace fields themselves are always kept, as are inline objects, whose
+// keys aren't known until they're encoded.
+//
+// Entries without duplicate keys are checked with a small hash set and
+// written unchanged. When a field passed to a log call replaces a field
+// added with With, the context is encoded again for that entry, which is
+// slower. Fields added to the wrapped Core before it was wrapped aren't
+// considered.
+func NewFieldDedupCore(core Core, opts ...FieldDedupOption) Core {
+	d := &fieldDeduper{}
+	for _, opt := range opts {
+		opt.apply(d)
+	}
+	c := &fieldDedupCore{base: core, core: core, d: d}
+	c.setContext(nil)
+	return c
+}
+
+func (c *fieldDedupCore) Enabled(lvl Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *fieldDedupCore) Level() Level {
+	return LevelOf(c.core)
+}
+
+func (c *fieldDedupCore) With(fields []Field) Core {
+	n := len(c.context)
+	all := append(c.context[:n:n], fields...)
+	drop := c.d.duplicates(all)
+
+	clone := &fieldDedupCore{base: c.base, d: c.d}
+	if dropsAny(drop, 0, n) {
+		// A new field replaced part of the context, which the wrapped
+		// Core has already encoded, so start over.
+		clone.core = c.base.With(keepFields(all, drop, 0, len(all)))
+	} else {
+		clone.core = c.core.With(keepFields(all, drop, n, len(all)))
+	}
+	clone.setContext(keepFields(all, drop, 0, len(all)))
+	return clone
+}
+
+func (c *fieldDedupCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *fieldDedupCore) Write(ent Entry, fields []Field) error {
+	if !c.hasDuplicates(fields) {
+		return checkAndWrite(c.core, ent, fields)
+	}
+
+	n := len(c.context)
+	all := make([]Field, 0, n+len(fields))
+	all = append(all, c.context...)
+	all = append(all, fields...)
+	drop := c.d.duplicates(all)
+
+	core := c.core
+	if dropsAny(drop, 0, n) {
+		core = c.base.With(keepFields(all, drop, 0, n))
+	}
+	return checkAndWrite(core, ent, keepFields(all, drop, n, len(all)))
+}
+
+func (c *fieldDedupCore) Sync() error {
+	return c.core.Sync()
+}
+
+// setContext sets the context fields, which must not have duplicates, and
+// indexes their keys.
+func (c *fieldDedupCore) setContext(fields []Field) {
+	c.context = fields
+	c.keys = newFieldKeySlots(len(fields), nil)
+	for i, f := range fields {
+		if f.Type == NamespaceType {
+			c.scope = i + 1
+			continue
+		}
+		if hasFieldKey(f) {
+			h := fieldKeyHash(c.scope, f.Key)
+			*findFieldKey(c.keys, fields, h, c.scope, f.Key) = fieldKeySlot{hash: h, scope: int32(c.scope), idx: int32(i)}
+		}
+	}
+}
+
+// hasDuplicates reports whether any of the fields passed to a log call
+// repeats a key of the context or of another field. It doesn't allocate for
+// up to 8 fields.
+func (c *fieldDedupCore) hasDuplicates(fields []Field) bool {
+	if len(fields) == 0 {
+		return false
+	}
+
+	var buf [16]fieldKeySlot
+	slots := newFieldKeySlots(len(fields), buf[:])
+	scope := c.scope
+	for i, f := range fields {
+		if f.Type == NamespaceType {
+			// Number namespaces as if the fields followed the context.
+			scope = len(c.context) + i + 1
+			continue
+		}
+		if !hasFieldKey(f) {
+			continue
+		}
+		h := fieldKeyHash(scope, f.Key)
+		if scope == c.scope && findFieldKey(c.keys, c.context, h, scope, f.Key).hash != 0 {
+			return true
+		}
+		s := findFieldKey(slots, fields, h, scope, f.Key)
+		if s.hash != 0 {
+			return true
+		}
+		*s = fieldKeySlot{hash: h, scope: int32(scope), idx: int32(i)}
+	}
+	return false
+}
+
+// duplicates reports which of the fields should be dropped because another
+// field in the same namespace has the same key. It returns nil if no field
+// should be.
+func (d *fieldDeduper) duplicates(fields []Field) []bool {
+	var drop []bool
+	slots := newFieldKeySlots(len(fields), nil)
+	scope := 0
+	for i, f := range fields {
+		if f.Type == NamespaceType {
+			scope = i + 1
+			continue
+		}
+		if !hasFieldKey(f) {
+			continue
+		}
+		h := fieldKeyHash(scope, f.Key)
+		s := findFieldKey(slots, fields, h, scope, f.Key)
+		if s.hash == 0 {
+			*s = fieldKeySlot{hash: h, scope: int32(scope), idx: int32(i)}
+			continue
+		}
+		if drop == nil {
+			drop = make([]bool, len(fields))
+		}
+		if d.keepFirst {
+			drop[i] = true
+		} else {
+			drop[s.idx] = true
+			s.idx = int32(i)
+		}
+	}
+	return drop
+}
+
+// dropsAny reports whether any of the fields in [lo, hi) is dropped.
+func dropsAny(drop []bool, lo, hi int) bool {
+	if drop == nil {
+		return false
+	}
+	for _, d := range drop[lo:hi] {
+		if d {
+			return true
+		}
+	}
+	return false
+}
+
+// keepFields returns the fields in [lo, hi) that aren't dropped.
+func keepFields(fields []Field, drop []bool, lo, hi int) []Field {
+	if !dropsAny(drop, lo, hi) {
+		return fields[lo:hi:hi]
+	}
+	kept := make([]Field, 0, hi-lo)
+	for i := lo; i < hi; i++ {
+		if !drop[i] {
+			kept = append(kept, fields[i])
+		}
+	}
+	return kept
+}
+
+// hasFieldKey reports whether the field is written under its key.
+func hasFieldKey(f Field) bool {
+	switch f.Type {
+	case NamespaceType, SkipType, InlineMarshalerType:
+		return false
+	case OmitEmptyType:
+		return hasFieldKey(f.Interface.(Field))
+	}
+	return true
+}
+
+// fieldKeySlot is a slot of an open-addressed set of field keys. Fields are
+// identified by their index in a slice, and namespaces by the index of
+// their Namespace field plus one, or zero outside of any namespace.
+type fieldKeySlot struct {
+	hash  uint64 // zero if the slot is empty
+	scope int32
+	idx   int32
+}
+
+// newFieldKeySlots returns an empty set with room for n keys, using buf if
+// it's large enough.
+func newFieldKeySlots(n int, buf []fieldKeySlot) []fieldKeySlot {
+	size := 8
+	for size < 2*n {
+		size <<= 1
+	}
+	if size <= len(buf) {
+		return buf[:size]
+	}
+	return make([]fieldKeySlot, size)
+}
+
+// findFieldKey returns the slot holding the key in the namespace, or the
+// empty slot where it belongs. The set must not be full.
+func findFieldKey(slots []fieldKeySlot, fields []Field, h uint64, scope int, key string) *fieldKeySlot {
+	mask := len(slots) - 1
+	for i := int(h) & mask; ; i = (i + 1) & mask {
+		s := &slots[i]
+		if s.hash == 0 || (s.hash == h && int(s.scope) == scope && fields[s.idx].Key == key) {
+			return s
+		}
+	}
+}
+
+func fieldKeyHash(scope int, key string) uint64 {
+	h := uint64(_fnv64Offset)
+	for i := 0; i < 8; i++ {
+		h = fnv64aByte(h, byte(scope>>(8*i)))
+	}
+	h = fnv64aString(h, key)
+	if h == 0 {
+		h = 1 // zero marks empty slots
+	}
+	return h
+}
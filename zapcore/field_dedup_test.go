@@ -0,0 +1,252 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toujourser/zap/internal/ztest"
+	"github.com/toujourser/zap/zaptest/observer"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+)
+
+func TestFieldDedupCore(t *testing.T) {
+	field := func(key string, v int64) Field {
+		return Field{Key: key, Type: Int64Type, Integer: v}
+	}
+	namespace := func(key string) Field {
+		return Field{Key: key, Type: NamespaceType}
+	}
+	skip := Field{Type: SkipType}
+	inline := Field{Type: InlineMarshalerType, Interface: ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+		enc.AddInt64("a", 2)
+		return nil
+	})}
+
+	tests := []struct {
+		desc      string
+		keepFirst bool
+		with      [][]Field
+		fields    []Field
+		want      string
+	}{
+		{
+			desc:   "no duplicates",
+			with:   [][]Field{{field("a", 1)}},
+			fields: []Field{field("b", 2)},
+			want:   `{"a":1,"b":2}`,
+		},
+		{
+			desc:   "duplicate call fields",
+			fields: []Field{field("a", 1), field("b", 2), field("a", 3)},
+			want:   `{"b":2,"a":3}`,
+		},
+		{
+			desc:      "duplicate call fields, keep first",
+			keepFirst: true,
+			fields:    []Field{field("a", 1), field("b", 2), field("a", 3)},
+			want:      `{"a":1,"b":2}`,
+		},
+		{
+			desc: "duplicate context fields",
+			with: [][]Field{{field("a", 1), field("b", 2)}, {field("a", 3)}},
+			want: `{"b":2,"a":3}`,
+		},
+		{
+			desc:      "duplicate context fields, keep first",
+			keepFirst: true,
+			with:      [][]Field{{field("a", 1), field("b", 2)}, {field("a", 3)}},
+			want:      `{"a":1,"b":2}`,
+		},
+		{
+			desc:   "call field replaces context field",
+			with:   [][]Field{{field("a", 1), field("b", 2)}},
+			fields: []Field{field("a", 3)},
+			want:   `{"b":2,"a":3}`,
+		},
+		{
+			desc:      "call field replaces context field, keep first",
+			keepFirst: true,
+			with:      [][]Field{{field("a", 1), field("b", 2)}},
+			fields:    []Field{field("a", 3)},
+			want:      `{"a":1,"b":2}`,
+		},
+		{
+			desc:   "keys in different namespaces",
+			with:   [][]Field{{field("a", 1), namespace("ns")}},
+			fields: []Field{field("a", 2), namespace("ns"), field("a", 3)},
+			want:   `{"a":1,"ns":{"a":2,"ns":{"a":3}}}`,
+		},
+		{
+			desc: "duplicates in nested namespaces",
+			with: [][]Field{
+				{field("a", 1), namespace("outer"), field("a", 2)},
+				{field("b", 3), field("a", 4)},
+			},
+			fields: []Field{field("b", 5), namespace("inner"), field("c", 6), field("c", 7)},
+			want:   `{"a":1,"outer":{"a":4,"b":5,"inner":{"c":7}}}`,
+		},
+		{
+			desc: "duplicates in nested namespaces, keep first",
+			with: [][]Field{
+				{field("a", 1), namespace("outer"), field("a", 2)},
+				{field("b", 3), field("a", 4)},
+			},
+			keepFirst: true,
+			fields:    []Field{field("b", 5), namespace("inner"), field("c", 6), field("c", 7)},
+			want:      `{"a":1,"outer":{"a":2,"b":3,"inner":{"c":6}}}`,
+		},
+		{
+			desc:   "field named like a namespace",
+			with:   [][]Field{{field("ns", 1), namespace("ns")}},
+			fields: []Field{field("ns", 2)},
+			want:   `{"ns":1,"ns":{"ns":2}}`,
+		},
+		{
+			desc:   "skipped and inline fields",
+			fields: []Field{skip, skip, field("a", 1), inline, field("a", 3)},
+			want:   `{"a":2,"a":3}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var opts []FieldDedupOption
+			if tt.keepFirst {
+				opts = append(opts, KeepFirstField())
+			}
+			buf := &ztest.Buffer{}
+			enc := NewJSONEncoder(EncoderConfig{})
+			core := NewFieldDedupCore(NewCore(enc, buf, DebugLevel), opts...)
+			for _, fields := range tt.with {
+				core = core.With(fields)
+			}
+
+			require.NoError(t, core.Write(Entry{}, tt.fields), "Unexpected error writing entry.")
+			assert.Equal(t, tt.want, buf.Stripped(), "Unexpected output.")
+		})
+	}
+}
+
+func TestFieldDedupCoreManyFields(t *testing.T) {
+	// More fields than fit in the stack-allocated set.
+	var fields []Field
+	for i := 0; i < 40; i++ {
+		fields = append(fields, Field{Key: fmt.Sprint("k", i%30), Type: Int64Type, Integer: int64(i)})
+	}
+
+	obs, logs := observer.New(DebugLevel)
+	core := NewFieldDedupCore(obs)
+	require.NoError(t, core.Write(Entry{}, fields), "Unexpected error writing entry.")
+	require.Equal(t, 1, logs.Len(), "Expected an entry.")
+
+	got := logs.AllUntimed()[0].Context
+	assert.Len(t, got, 30, "Expected one field for each key.")
+	assert.Equal(t, fields[10:], got, "Expected the last field with each key.")
+}
+
+func TestFieldDedupCoreLevels(t *testing.T) {
+	obs, logs := observer.New(InfoLevel)
+	core := NewFieldDedupCore(obs).With([]Field{{Key: "a", Type: Int64Type}})
+	assert.Equal(t, InfoLevel, LevelOf(core), "Unexpected level.")
+
+	assert.Nil(t, core.Check(Entry{Level: DebugLevel}, nil), "Expected disabled entries to be dropped.")
+	ce := core.Check(Entry{Level: InfoLevel, Time: time.Now()}, nil)
+	require.NotNil(t, ce, "Expected enabled entries to be written.")
+	ce.Write(Field{Key: "a", Type: Int64Type, Integer: 1})
+	assert.Equal(t, []Field{{Key: "a", Type: Int64Type, Integer: 1}}, logs.AllUntimed()[0].Context, "Unexpected fields.")
+	assert.NoError(t, core.Sync(), "Unexpected error syncing.")
+}
+
+func TestFieldDedupCoreDoesNotAllocate(t *testing.T) {
+	core := NewFieldDedupCore(NewNopCore()).With([]Field{
+		{Key: "a", Type: Int64Type},
+		{Key: "b", Type: StringType},
+	})
+	fields := []Field{
+		{Key: "c", Type: Int64Type},
+		{Key: "d", Type: StringType},
+		{Key: "ns", Type: NamespaceType},
+		{Key: "a", Type: Int64Type},
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = core.Write(Entry{}, fields)
+	})
+	assert.Zero(t, allocs, "Expected entries without duplicates not to allocate.")
+}
+
+func BenchmarkFieldDedupCore(b *testing.B) {
+	context := []Field{
+		{Key: "service", Type: StringType, String: "payments"},
+		{Key: "region", Type: StringType, String: "us-east"},
+		{Key: "attempt", Type: Int64Type, Integer: 1},
+	}
+	unique := []Field{
+		{Key: "user", Type: StringType, String: "alice"},
+		{Key: "amount", Type: Int64Type, Integer: 42},
+		{Key: "currency", Type: StringType, String: "USD"},
+	}
+	duplicate := []Field{
+		{Key: "user", Type: StringType, String: "alice"},
+		{Key: "amount", Type: Int64Type, Integer: 42},
+		{Key: "attempt", Type: Int64Type, Integer: 2},
+	}
+
+	newCore := func(dedup bool, opts ...FieldDedupOption) Core {
+		core := NewCore(NewJSONEncoder(testEncoderConfig()), &ztest.Discarder{}, DebugLevel)
+		if dedup {
+			core = NewFieldDedupCore(core, opts...)
+		}
+		return core.With(context)
+	}
+
+	benchmarks := []struct {
+		name   string
+		core   Core
+		fields []Field
+	}{
+		{"baseline", newCore(false), unique},
+		{"no duplicates", newCore(true), unique},
+		{"duplicates", newCore(true), duplicate},
+		{"duplicates/keep first", newCore(true, KeepFirstField()), duplicate},
+	}
+	for _, bb := range benchmarks {
+		b.Run(bb.name, func(b *testing.B) {
+			ent := Entry{Level: InfoLevel, Message: "charged card", Time: time.Unix(0, 0)}
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := bb.core.Write(ent, bb.fields); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zapcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// encodeOmitEmpty adds f to enc unless its value is empty.
+func encodeOmitEmpty(f Field, enc ObjectEncoder) {
+	if f.Type == StringerType {
+		// Call String once, rather than once to check for an empty string
+		// and again to encode it.
+		if s, ok := stringerValue(f.Interface); ok {
+			if s != "" {
+				enc.AddString(f.Key, s)
+			}
+			return
+		}
+	}
+	if !isEmptyField(f) {
+		f.AddTo(enc)
+	}
+}
+
+// isEmptyField reports whether f holds the zero value of its type. Values
+// that are only known once they're encoded, such as those of marshalers and
+// reflected fields, are empty only if they're nil.
+func isEmptyField(f Field) bool {
+	switch f.Type {
+	case StringType:
+		return f.String == ""
+	case BoolType, DurationType, Int64Type, Int32Type, Int16Type, Int8Type,
+		Uint64Type, Uint32Type, Uint16Type, Uint8Type, UintptrType:
+		return f.Integer == 0
+	case Float64Type:
+		return math.Float64frombits(uint64(f.Integer)) == 0
+	case Float32Type:
+		return math.Float32frombits(uint32(f.Integer)) == 0
+	case Complex128Type:
+		return f.Interface.(complex128) == 0
+	case Complex64Type:
+		return f.Interface.(complex64) == 0
+	case TimeFullType:
+		return f.Interface.(time.Time).IsZero()
+	case BinaryType, ByteStringType:
+		return len(f.Interface.([]byte)) == 0
+	case RawJSONType:
+		return len(f.Interface.(json.RawMessage)) == 0
+	case RedactedType:
+		if orig, ok := f.Interface.(Field); ok {
+			return isEmptyField(orig)
+		}
+		return f.String == ""
+	case OmitEmptyType:
+		return isEmptyField(f.Interface.(Field))
+	case TextAppenderType:
+		v, ok := f.Interface.(interface{ IsValid() bool })
+		return ok && !v.IsValid()
+	case ErrorType, StringerType, ArrayMarshalerType, ObjectMarshalerType,
+		InlineMarshalerType, ReflectType, TextMarshalerType:
+		return isNilValue(f.Interface)
+	}
+	// TimeType holds times that can be represented in nanoseconds since the
+	// epoch, which excludes the zero time.
+	return false
+}
+
+// stringerValue calls the String method of a fmt.Stringer. It returns false
+// if the value is a RedactedStringer, whose output depends on the encoder,
+// or if String panics, leaving the caller to encode the field as usual.
+func stringerValue(v interface{}) (s string, ok bool) {
+	if isNilValue(v) {
+		return "", true
+	}
+	if _, redacted := v.(RedactedStringer); redacted {
+		return "", false
+	}
+	defer func() {
+		if recover() != nil {
+			s, ok = "", false
+		}
+	}()
+	return v.(fmt.Stringer).String(), true
+}
+
+// isNilValue reports whether v is nil or a nil pointer, map, slice, channel,
+// function, or interface.
+func isNilValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
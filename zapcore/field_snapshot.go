@@ -0,0 +1,452 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/toujourser/zap/internal/bufferpool"
+)
+
+// snapshotField returns a copy of f that doesn't refer to memory the caller
+// may change once it's done logging. Byte slices are copied, marshalers and
+// Stringers are run right away and their output recorded, and reflected
+// values are encoded as JSON. Encoding the copy replays what was recorded,
+// including any errors or panics.
+//
+// Nil values, errors, and the other field types are left as they are. Since
+// the encoder that replays a marshaler isn't known yet, secrets that the
+// marshaler adds with a RedactedType Field are always redacted.
+func snapshotField(f Field) Field {
+	switch f.Type {
+	case BinaryType, ByteStringType:
+		if b, ok := f.Interface.([]byte); ok {
+			f.Interface = append([]byte(nil), b...)
+		}
+	case RawJSONType:
+		if b, ok := f.Interface.(json.RawMessage); ok {
+			f.Interface = append(json.RawMessage(nil), b...)
+		}
+	case ArrayMarshalerType:
+		if m, ok := f.Interface.(ArrayMarshaler); ok {
+			f.Interface, _ = snapshotArray(m)
+		}
+	case ObjectMarshalerType, InlineMarshalerType:
+		if m, ok := f.Interface.(ObjectMarshaler); ok {
+			f.Interface, _ = snapshotObject(m)
+		}
+	case StringerType:
+		f.Interface = snapshotStringer(f.Interface)
+	case ReflectType:
+		f.Interface = snapshotReflected(f.Interface)
+	case OmitEmptyType:
+		if inner, ok := f.Interface.(Field); ok {
+			f.Interface = snapshotField(inner)
+		}
+	}
+	return f
+}
+
+// snapshotArray runs m against a recorder and returns an ArrayMarshaler that
+// replays it, along with the error m's encoder would have returned.
+func snapshotArray(m ArrayMarshaler) (snap ArrayMarshaler, err error) {
+	if isNilValue(m) {
+		return m, nil
+	}
+	s := new(arraySnapshot)
+	defer func() {
+		if r := recover(); r != nil {
+			s.panicked, s.r = true, r
+			snap = s
+			_, err = marshalerPanic(m, r)
+		}
+	}()
+	s.err = m.MarshalLogArray(&s.arrayRecorder)
+	return s, s.err
+}
+
+// snapshotObject is the ObjectMarshaler counterpart of snapshotArray.
+func snapshotObject(m ObjectMarshaler) (snap ObjectMarshaler, err error) {
+	if isNilValue(m) {
+		return m, nil
+	}
+	s := new(objectSnapshot)
+	defer func() {
+		if r := recover(); r != nil {
+			s.panicked, s.r = true, r
+			snap = s
+			_, err = marshalerPanic(m, r)
+		}
+	}()
+	s.err = m.MarshalLogObject(&s.objectRecorder)
+	return s, s.err
+}
+
+// snapshotStringer calls the String method of v, and the RedactedString
+// method of RedactedStringers, returning a Stringer that replays them.
+func snapshotStringer(v interface{}) interface{} {
+	if isNilValue(v) {
+		return v
+	}
+	switch v := v.(type) {
+	case RedactedStringer:
+		return redactedSnapshot{
+			stringSnapshot: snapshotString(v.String),
+			redacted:       snapshotString(v.RedactedString),
+		}
+	case fmt.Stringer:
+		return snapshotString(v.String)
+	}
+	return v
+}
+
+// snapshotReflected encodes values that may refer to mutable memory as
+// JSON, returning a json.RawMessage that encoders embed as-is. Values that
+// can't be encoded are returned as they are, so that the error is reported
+// when the field is encoded.
+func snapshotReflected(v interface{}) interface{} {
+	if isNilValue(v) {
+		return v
+	}
+	if _, ok := v.(RedactedStringer); ok {
+		return snapshotStringer(v)
+	}
+	if raw, ok := v.(json.RawMessage); ok {
+		return append(json.RawMessage(nil), raw...)
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+	default:
+		return v
+	}
+
+	buf := bufferpool.Get()
+	defer buf.Free()
+	if err := defaultReflectedEncoder(buf).Encode(v); err != nil {
+		return v
+	}
+	return json.RawMessage(bytes.TrimSuffix(append([]byte(nil), buf.Bytes()...), []byte("\n")))
+}
+
+// stringSnapshot is the recorded result of a String method.
+type stringSnapshot struct {
+	s        string
+	panicked bool
+	r        interface{}
+}
+
+func snapshotString(f func() string) (s stringSnapshot) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = stringSnapshot{panicked: true, r: r}
+		}
+	}()
+	return stringSnapshot{s: f()}
+}
+
+func (s stringSnapshot) String() string {
+	if s.panicked {
+		panic(s.r)
+	}
+	return s.s
+}
+
+// redactedSnapshot is the recorded result of a RedactedStringer.
+type redactedSnapshot struct {
+	stringSnapshot
+
+	redacted stringSnapshot
+}
+
+func (s redactedSnapshot) RedactedString() string {
+	return s.redacted.String()
+}
+
+// arraySnapshot replays what an ArrayMarshaler added to its encoder.
+type arraySnapshot struct {
+	arrayRecorder
+
+	err      error
+	panicked bool
+	r        interface{}
+}
+
+func (s *arraySnapshot) MarshalLogArray(enc ArrayEncoder) error {
+	for _, op := range s.ops {
+		op(enc)
+	}
+	if s.panicked {
+		panic(s.r)
+	}
+	return s.err
+}
+
+// objectSnapshot replays what an ObjectMarshaler added to its encoder.
+type objectSnapshot struct {
+	objectRecorder
+
+	err      error
+	panicked bool
+	r        interface{}
+}
+
+func (s *objectSnapshot) MarshalLogObject(enc ObjectEncoder) error {
+	for _, op := range s.ops {
+		op(enc)
+	}
+	if s.panicked {
+		panic(s.r)
+	}
+	return s.err
+}
+
+// objectRecorder is an ObjectEncoder that records what's added to it.
+type objectRecorder struct {
+	ops []func(ObjectEncoder)
+}
+
+var _ ObjectEncoder = (*objectRecorder)(nil)
+
+func (r *objectRecorder) add(op func(ObjectEncoder)) {
+	r.ops = append(r.ops, op)
+}
+
+func (r *objectRecorder) AddArray(key string, m ArrayMarshaler) error {
+	s, err := snapshotArray(m)
+	r.add(func(enc ObjectEncoder) { _ = enc.AddArray(key, s) })
+	return err
+}
+
+func (r *objectRecorder) AddObject(key string, m ObjectMarshaler) error {
+	s, err := snapshotObject(m)
+	r.add(func(enc ObjectEncoder) { _ = enc.AddObject(key, s) })
+	return err
+}
+
+func (r *objectRecorder) AddBinary(key string, v []byte) {
+	v = append([]byte(nil), v...)
+	r.add(func(enc ObjectEncoder) { enc.AddBinary(key, v) })
+}
+
+func (r *objectRecorder) AddByteString(key string, v []byte) {
+	v = append([]byte(nil), v...)
+	r.add(func(enc ObjectEncoder) { enc.AddByteString(key, v) })
+}
+
+func (r *objectRecorder) AddBool(key string, v bool) {
+	r.add(func(enc ObjectEncoder) { enc.AddBool(key, v) })
+}
+
+func (r *objectRecorder) AddComplex128(key string, v complex128) {
+	r.add(func(enc ObjectEncoder) { enc.AddComplex128(key, v) })
+}
+
+func (r *objectRecorder) AddComplex64(key string, v complex64) {
+	r.add(func(enc ObjectEncoder) { enc.AddComplex64(key, v) })
+}
+
+func (r *objectRecorder) AddDuration(key string, v time.Duration) {
+	r.add(func(enc ObjectEncoder) { enc.AddDuration(key, v) })
+}
+
+func (r *objectRecorder) AddFloat64(key string, v float64) {
+	r.add(func(enc ObjectEncoder) { enc.AddFloat64(key, v) })
+}
+
+func (r *objectRecorder) AddFloat32(key string, v float32) {
+	r.add(func(enc ObjectEncoder) { enc.AddFloat32(key, v) })
+}
+
+func (r *objectRecorder) AddInt(key string, v int) {
+	r.add(func(enc ObjectEncoder) { enc.AddInt(key, v) })
+}
+
+func (r *objectRecorder) AddInt64(key string, v int64) {
+	r.add(func(enc ObjectEncoder) { enc.AddInt64(key, v) })
+}
+
+func (r *objectRecorder) AddInt32(key string, v int32) {
+	r.add(func(enc ObjectEncoder) { enc.AddInt32(key, v) })
+}
+
+func (r *objectRecorder) AddInt16(key string, v int16) {
+	r.add(func(enc ObjectEncoder) { enc.AddInt16(key, v) })
+}
+
+func (r *objectRecorder) AddInt8(key string, v int8) {
+	r.add(func(enc ObjectEncoder) { enc.AddInt8(key, v) })
+}
+
+func (r *objectRecorder) AddString(key, v string) {
+	r.add(func(enc ObjectEncoder) { enc.AddString(key, v) })
+}
+
+func (r *objectRecorder) AddTime(key string, v time.Time) {
+	r.add(func(enc ObjectEncoder) { enc.AddTime(key, v) })
+}
+
+func (r *objectRecorder) AddUint(key string, v uint) {
+	r.add(func(enc ObjectEncoder) { enc.AddUint(key, v) })
+}
+
+func (r *objectRecorder) AddUint64(key string, v uint64) {
+	r.add(func(enc ObjectEncoder) { enc.AddUint64(key, v) })
+}
+
+func (r *objectRecorder) AddUint32(key string, v uint32) {
+	r.add(func(enc ObjectEncoder) { enc.AddUint32(key, v) })
+}
+
+func (r *objectRecorder) AddUint16(key string, v uint16) {
+	r.add(func(enc ObjectEncoder) { enc.AddUint16(key, v) })
+}
+
+func (r *objectRecorder) AddUint8(key string, v uint8) {
+	r.add(func(enc ObjectEncoder) { enc.AddUint8(key, v) })
+}
+
+func (r *objectRecorder) AddUintptr(key string, v uintptr) {
+	r.add(func(enc ObjectEncoder) { enc.AddUintptr(key, v) })
+}
+
+func (r *objectRecorder) AddReflected(key string, v interface{}) error {
+	v = snapshotReflected(v)
+	r.add(func(enc ObjectEncoder) { _ = enc.AddReflected(key, v) })
+	return nil
+}
+
+func (r *objectRecorder) OpenNamespace(key string) {
+	r.add(func(enc ObjectEncoder) { enc.OpenNamespace(key) })
+}
+
+// arrayRecorder is an ArrayEncoder that records what's appended to it.
+type arrayRecorder struct {
+	ops []func(ArrayEncoder)
+}
+
+var _ ArrayEncoder = (*arrayRecorder)(nil)
+
+func (r *arrayRecorder) add(op func(ArrayEncoder)) {
+	r.ops = append(r.ops, op)
+}
+
+func (r *arrayRecorder) AppendArray(m ArrayMarshaler) error {
+	s, err := snapshotArray(m)
+	r.add(func(enc ArrayEncoder) { _ = enc.AppendArray(s) })
+	return err
+}
+
+func (r *arrayRecorder) AppendObject(m ObjectMarshaler) error {
+	s, err := snapshotObject(m)
+	r.add(func(enc ArrayEncoder) { _ = enc.AppendObject(s) })
+	return err
+}
+
+func (r *arrayRecorder) AppendReflected(v interface{}) error {
+	v = snapshotReflected(v)
+	r.add(func(enc ArrayEncoder) { _ = enc.AppendReflected(v) })
+	return nil
+}
+
+func (r *arrayRecorder) AppendBool(v bool) {
+	r.add(func(enc ArrayEncoder) { enc.AppendBool(v) })
+}
+
+func (r *arrayRecorder) AppendByteString(v []byte) {
+	v = append([]byte(nil), v...)
+	r.add(func(enc ArrayEncoder) { enc.AppendByteString(v) })
+}
+
+func (r *arrayRecorder) AppendComplex128(v complex128) {
+	r.add(func(enc ArrayEncoder) { enc.AppendComplex128(v) })
+}
+
+func (r *arrayRecorder) AppendComplex64(v complex64) {
+	r.add(func(enc ArrayEncoder) { enc.AppendComplex64(v) })
+}
+
+func (r *arrayRecorder) AppendDuration(v time.Duration) {
+	r.add(func(enc ArrayEncoder) { enc.AppendDuration(v) })
+}
+
+func (r *arrayRecorder) AppendFloat64(v float64) {
+	r.add(func(enc ArrayEncoder) { enc.AppendFloat64(v) })
+}
+
+func (r *arrayRecorder) AppendFloat32(v float32) {
+	r.add(func(enc ArrayEncoder) { enc.AppendFloat32(v) })
+}
+
+func (r *arrayRecorder) AppendInt(v int) {
+	r.add(func(enc ArrayEncoder) { enc.AppendInt(v) })
+}
+
+func (r *arrayRecorder) AppendInt64(v int64) {
+	r.add(func(enc ArrayEncoder) { enc.AppendInt64(v) })
+}
+
+func (r *arrayRecorder) AppendInt32(v int32) {
+	r.add(func(enc ArrayEncoder) { enc.AppendInt32(v) })
+}
+
+func (r *arrayRecorder) AppendInt16(v int16) {
+	r.add(func(enc ArrayEncoder) { enc.AppendInt16(v) })
+}
+
+func (r *arrayRecorder) AppendInt8(v int8) {
+	r.add(func(enc ArrayEncoder) { enc.AppendInt8(v) })
+}
+
+func (r *arrayRecorder) AppendString(v string) {
+	r.add(func(enc ArrayEncoder) { enc.AppendString(v) })
+}
+
+func (r *arrayRecorder) AppendTime(v time.Time) {
+	r.add(func(enc ArrayEncoder) { enc.AppendTime(v) })
+}
+
+func (r *arrayRecorder) AppendUint(v uint) {
+	r.add(func(enc ArrayEncoder) { enc.AppendUint(v) })
+}
+
+func (r *arrayRecorder) AppendUint64(v uint64) {
+	r.add(func(enc ArrayEncoder) { enc.AppendUint64(v) })
+}
+
+func (r *arrayRecorder) AppendUint32(v uint32) {
+	r.add(func(enc ArrayEncoder) { enc.AppendUint32(v) })
+}
+
+func (r *arrayRecorder) AppendUint16(v uint16) {
+	r.add(func(enc ArrayEncoder) { enc.AppendUint16(v) })
+}
+
+func (r *arrayRecorder) AppendUint8(v uint8) {
+	r.add(func(enc ArrayEncoder) { enc.AppendUint8(v) })
+}
+
+func (r *arrayRecorder) AppendUintptr(v uintptr) {
+	r.add(func(enc ArrayEncoder) { enc.AppendUintptr(v) })
+}
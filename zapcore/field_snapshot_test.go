@@ -0,0 +1,138 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snapshotPanicker writes part of its output, then panics.
+type snapshotPanicker struct{}
+
+func (snapshotPanicker) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("written", "before panic")
+	enc.OpenNamespace("ns")
+	enc.AddTime("t", time.Unix(1, 0))
+	panic("boom")
+}
+
+func (snapshotPanicker) MarshalLogArray(enc ArrayEncoder) error {
+	enc.AppendDuration(time.Second)
+	panic("boom")
+}
+
+func (snapshotPanicker) String() string { panic("boom") }
+
+// snapshotNil dereferences its nil receiver.
+type snapshotNil struct{ name string }
+
+func (n *snapshotNil) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("name", n.name)
+	return nil
+}
+
+func (n *snapshotNil) String() string { return n.name }
+
+// snapshotSecret is a RedactedStringer.
+type snapshotSecret string
+
+func (s snapshotSecret) String() string         { return string(s) }
+func (s snapshotSecret) RedactedString() string { return RedactedPlaceholder }
+
+func TestSnapshotFieldEncodesLikeOriginal(t *testing.T) {
+	fields := []Field{
+		{Key: "bytes", Type: ByteStringType, Interface: []byte("foo")},
+		{Key: "object", Type: ObjectMarshalerType, Interface: turducken{}},
+		{Key: "array", Type: ArrayMarshalerType, Interface: turduckens(2)},
+		{Key: "failed", Type: ObjectMarshalerType, Interface: loggable{false}},
+		{Key: "failedArray", Type: ArrayMarshalerType, Interface: loggable{false}},
+		{Key: "panicked", Type: ObjectMarshalerType, Interface: snapshotPanicker{}},
+		{Key: "panickedArray", Type: ArrayMarshalerType, Interface: snapshotPanicker{}},
+		{Key: "nil", Type: ObjectMarshalerType, Interface: (*snapshotNil)(nil)},
+		{Key: "stringer", Type: StringerType, Interface: &snapshotNil{name: "foo"}},
+		{Key: "nilStringer", Type: StringerType, Interface: (*snapshotNil)(nil)},
+		{Key: "panickedStringer", Type: StringerType, Interface: snapshotPanicker{}},
+		{Key: "secret", Type: StringerType, Interface: snapshotSecret("hunter2")},
+		{Key: "reflect", Type: ReflectType, Interface: map[string][]int{"k": {1, 2}}},
+		{Key: "reflectFailed", Type: ReflectType, Interface: noJSON{}},
+		{Key: "reflectSecret", Type: ReflectType, Interface: snapshotSecret("hunter2")},
+		{Key: "omitEmpty", Type: OmitEmptyType, Interface: Field{Key: "inner", Type: ObjectMarshalerType, Interface: loggable{true}}},
+		{Type: InlineMarshalerType, Interface: maybeNamespace{true}},
+	}
+
+	cfg := EncoderConfig{
+		MessageKey:     "msg",
+		EncodeTime:     EpochTimeEncoder,
+		EncodeDuration: StringDurationEncoder,
+	}
+	for _, reveal := range []bool{false, true} {
+		for _, report := range []bool{false, true} {
+			t.Run(fmt.Sprintf("reveal=%v/report=%v", reveal, report), func(t *testing.T) {
+				cfg := cfg
+				cfg.RevealSecrets, cfg.ReportPanics = reveal, report
+
+				encode := func(fields []Field) string {
+					buf, err := NewJSONEncoder(cfg).EncodeEntry(Entry{Message: "foo"}, fields)
+					require.NoError(t, err, "Unexpected error encoding.")
+					defer buf.Free()
+					return buf.String()
+				}
+
+				snapshots := make([]Field, len(fields))
+				for i, f := range fields {
+					snapshots[i] = snapshotField(f)
+				}
+				assert.Equal(t, encode(fields), encode(snapshots), "Expected snapshots to encode like the original fields.")
+			})
+		}
+	}
+}
+
+func TestSnapshotFieldErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		desc string
+		m    ObjectMarshaler
+		want error
+	}{
+		{"success", loggable{true}, nil},
+		{"error", ObjectMarshalerFunc(func(ObjectEncoder) error { return errBoom }), errBoom},
+		{"nested error", ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+			return enc.AddObject("k", ObjectMarshalerFunc(func(ObjectEncoder) error { return errBoom }))
+		}), errBoom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := snapshotObject(tt.m)
+			assert.Equal(t, tt.want, err, "Unexpected error.")
+		})
+	}
+
+	_, err := snapshotArray(snapshotPanicker{})
+	assert.True(t, isPanicError(err), "Expected a panic error, got %v.", err)
+}
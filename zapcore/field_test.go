@@ -21,9 +21,12 @@
 package zapcore_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"net"
+	"net/netip"
 	"net/url"
 	"testing"
 	"time"
@@ -60,6 +63,21 @@ func (u users) MarshalLogArray(enc ArrayEncoder) error {
 	return nil
 }
 
+// request is an ObjectMarshaler with a pointer receiver that doesn't guard
+// against nil.
+type request struct {
+	method string
+	panics bool
+}
+
+func (r *request) MarshalLogObject(enc ObjectEncoder) error {
+	if r.panics {
+		panic("panic in MarshalLogObject")
+	}
+	enc.AddString("method", r.method)
+	return nil
+}
+
 type obj struct {
 	kind int
 }
@@ -82,6 +100,20 @@ func (o *obj) String() string {
 	return "obj"
 }
 
+type textObj struct {
+	kind int
+}
+
+func (t textObj) MarshalText() ([]byte, error) {
+	switch t.kind {
+	case 1:
+		return nil, errors.New("can't marshal")
+	case 2:
+		panic("panic with string")
+	}
+	return []byte("text"), nil
+}
+
 type errObj struct {
 	kind   int
 	errMsg string
@@ -108,22 +140,28 @@ func TestFieldAddingError(t *testing.T) {
 		t     FieldType
 		iface interface{}
 		want  interface{}
-		err   string
+		err   interface{}
 	}{
 		{t: ArrayMarshalerType, iface: users(-1), want: []interface{}{}, err: "too few users"},
 		{t: ObjectMarshalerType, iface: users(-1), want: map[string]interface{}{}, err: "too few users"},
 		{t: InlineMarshalerType, iface: users(-1), want: nil, err: "too few users"},
-		{t: StringerType, iface: obj{}, want: empty, err: "PANIC=interface conversion: zapcore_test.obj is not fmt.Stringer: missing method String"},
-		{t: StringerType, iface: &obj{1}, want: empty, err: "PANIC=panic with string"},
-		{t: StringerType, iface: &obj{2}, want: empty, err: "PANIC=panic with error"},
-		{t: StringerType, iface: &obj{3}, want: empty, err: "PANIC=<nil>"},
-		{t: ErrorType, iface: &errObj{kind: 1}, want: empty, err: "PANIC=panic in Error() method"},
+		{t: InlineMarshalerType, iface: &request{panics: true}, want: nil, err: "PANIC=panic in MarshalLogObject"},
+		{t: TextMarshalerType, iface: textObj{1}, want: empty, err: "can't marshal"},
+		// Panics leave a placeholder, and they're only reported with
+		// EncoderConfig.ReportPanics, which the map encoder doesn't have.
+		{t: StringerType, iface: obj{}, want: "<panic: interface conversion: zapcore_test.obj is not fmt.Stringer: missing method String>", err: empty},
+		{t: StringerType, iface: &obj{1}, want: "<panic: panic with string>", err: empty},
+		{t: StringerType, iface: &obj{2}, want: "<panic: panic with error>", err: empty},
+		{t: StringerType, iface: &obj{3}, want: "<panic: <nil>>", err: empty},
+		{t: ErrorType, iface: &errObj{kind: 1}, want: "<panic: panic in Error() method>", err: empty},
+		{t: TextMarshalerType, iface: textObj{2}, want: "<panic: panic with string>", err: empty},
+		{t: TextMarshalerType, iface: &textObj{2}, want: "<panic: panic with string>", err: empty},
 	}
 	for _, tt := range tests {
 		f := Field{Key: "k", Interface: tt.iface, Type: tt.t}
 		enc := NewMapObjectEncoder()
 		assert.NotPanics(t, func() { f.AddTo(enc) }, "Unexpected panic when adding fields returns an error.")
-		assert.Equal(t, tt.want, enc.Fields["k"], "On error, expected zero value in field.Key.")
+		assert.Equal(t, tt.want, enc.Fields["k"], "Unexpected value in field.Key.")
 		assert.Equal(t, tt.err, enc.Fields["kError"], "Expected error message in log context.")
 	}
 }
@@ -167,6 +205,13 @@ func TestFields(t *testing.T) {
 		{t: StringerType, iface: (*url.URL)(nil), want: "<nil>"},
 		{t: StringerType, iface: (*users)(nil), want: "<nil>"},
 		{t: ErrorType, iface: (*errObj)(nil), want: "<nil>"},
+		{t: RawJSONType, iface: json.RawMessage(`{"a":1}`), want: json.RawMessage(`{"a":1}`)},
+		{t: TextMarshalerType, iface: textObj{}, want: "text"},
+		{t: TextMarshalerType, iface: &textObj{}, want: "text"},
+		{t: TextMarshalerType, iface: (*textObj)(nil), want: "<nil>"},
+		{t: TextMarshalerType, iface: net.ParseIP("1.2.3.4"), want: "1.2.3.4"},
+		{t: TextAppenderType, iface: netip.MustParseAddr("1.2.3.4"), want: "1.2.3.4"},
+		{t: TextAppenderType, iface: netip.MustParsePrefix("::1/128"), want: "::1/128"},
 	}
 
 	for _, tt := range tests {
@@ -203,6 +248,142 @@ func TestInlineMarshaler(t *testing.T) {
 	}, enc.Fields)
 }
 
+func TestInlineMarshalerNil(t *testing.T) {
+	for _, iface := range []interface{}{nil, (*request)(nil)} {
+		enc := NewMapObjectEncoder()
+		f := Field{Type: InlineMarshalerType, Interface: iface}
+		assert.NotPanics(t, func() { f.AddTo(enc) }, "Unexpected panic inlining %#v.", iface)
+		assert.Empty(t, enc.Fields, "Expected nil marshaler %#v to add nothing.", iface)
+	}
+}
+
+func TestOmitEmpty(t *testing.T) {
+	var (
+		nilUsers *users
+		nilErr   *errObj
+	)
+	tests := []struct {
+		desc  string
+		field Field
+		want  interface{} // nil if the field should be omitted
+	}{
+		{"empty string", zap.String("k", ""), nil},
+		{"string", zap.String("k", "v"), "v"},
+		{"zero int", zap.Int("k", 0), nil},
+		{"int", zap.Int("k", -1), int64(-1)},
+		{"zero uint", zap.Uint8("k", 0), nil},
+		{"false", zap.Bool("k", false), nil},
+		{"true", zap.Bool("k", true), true},
+		{"zero float", zap.Float64("k", 0), nil},
+		{"float", zap.Float32("k", 0.5), float32(0.5)},
+		{"zero complex", zap.Complex128("k", 0), nil},
+		{"zero duration", zap.Duration("k", 0), nil},
+		{"duration", zap.Duration("k", time.Second), time.Second},
+		{"zero time", zap.Time("k", time.Time{}), nil},
+		{"epoch", zap.Time("k", time.Unix(0, 0)), time.Unix(0, 0)},
+		{"empty bytes", zap.ByteString("k", nil), nil},
+		{"empty binary", zap.Binary("k", []byte{}), nil},
+		{"empty raw JSON", zap.RawJSON("k", nil), nil},
+		{"nil error", Field{Key: "k", Type: ErrorType}, nil},
+		{"nil error pointer", Field{Key: "k", Type: ErrorType, Interface: nilErr}, nil},
+		{"error", zap.NamedError("k", errors.New("fail")), "fail"},
+		{"Stringer", zap.Stringer("k", users(0)), "0 users"},
+		{"nil Stringer", zap.Stringer("k", nilUsers), nil},
+		{"Stringer returning empty string", zap.Stringer("k", emptyStringer{}), nil},
+		{"nil object", zap.Object("k", nilUsers), nil},
+		{"empty object", zap.Object("k", users(0)), map[string]interface{}{"users": 0}},
+		{"nil reflected", zap.Reflect("k", nil), nil},
+		{"empty reflected", zap.Reflect("k", []int{}), []int{}},
+		{"empty secret", zap.Secret("k", ""), nil},
+		{"redacted zero", zap.Redact(zap.Int("k", 0)), nil},
+		{"redacted", zap.Redact(zap.Int("k", 1)), RedactedPlaceholder},
+		{"zero Addr", zap.Addr("k", netip.Addr{}), nil},
+		{"Addr", zap.Addr("k", netip.IPv4Unspecified()), "0.0.0.0"},
+		{"zero Prefix", zap.Prefix("k", netip.Prefix{}), nil},
+		{"zero UUID", zap.UUID("k", [16]byte{}), "00000000-0000-0000-0000-000000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := NewMapObjectEncoder()
+			zap.OmitEmpty(tt.field).AddTo(enc)
+			if tt.want == nil {
+				assert.Empty(t, enc.Fields, "Expected the field to be omitted.")
+				return
+			}
+			assert.Equal(t, map[string]interface{}{"k": tt.want}, enc.Fields, "Unexpected fields.")
+		})
+	}
+}
+
+func TestOmitEmptyStringerCalledOnce(t *testing.T) {
+	var calls int
+	s := countingStringer{s: "v", calls: &calls}
+
+	enc := NewMapObjectEncoder()
+	zap.OmitEmpty(zap.Stringer("k", s)).AddTo(enc)
+	assert.Equal(t, map[string]interface{}{"k": "v"}, enc.Fields, "Unexpected fields.")
+	assert.Equal(t, 1, calls, "Expected String to be called once.")
+
+	// Panics are handled as they are without OmitEmpty.
+	enc = NewMapObjectEncoder()
+	zap.OmitEmpty(zap.Stringer("k", &obj{1})).AddTo(enc)
+	assert.Equal(t, map[string]interface{}{"k": "<panic: panic with string>"}, enc.Fields, "Expected a placeholder.")
+}
+
+type emptyStringer struct{}
+
+func (emptyStringer) String() string { return "" }
+
+type countingStringer struct {
+	s     string
+	calls *int
+}
+
+func (s countingStringer) String() string {
+	*s.calls++
+	return s.s
+}
+
+func TestInlineMarshalerNamespace(t *testing.T) {
+	fields := []Field{
+		{Key: "k", Type: StringType, String: "s"},
+		{Type: InlineMarshalerType, Interface: &request{method: "GET"}},
+		{Key: "http", Type: NamespaceType},
+		{Type: InlineMarshalerType, Interface: &request{method: "POST"}},
+	}
+
+	t.Run("map", func(t *testing.T) {
+		enc := NewMapObjectEncoder()
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+		assert.Equal(t, map[string]interface{}{
+			"k":      "s",
+			"method": "GET",
+			"http":   map[string]interface{}{"method": "POST"},
+		}, enc.Fields)
+	})
+
+	ent := Entry{Message: "hello"}
+	cfg := EncoderConfig{MessageKey: "msg", LineEnding: DefaultLineEnding}
+	for _, tt := range []struct {
+		name string
+		enc  Encoder
+		want string
+	}{
+		{"json", NewJSONEncoder(cfg), `{"msg":"hello","k":"s","method":"GET","http":{"method":"POST"}}` + "\n"},
+		{"console", NewConsoleEncoder(cfg), `hello	{"k": "s", "method": "GET", "http": {"method": "POST"}}` + "\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := tt.enc.EncodeEntry(ent, fields)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+			buf.Free()
+		})
+	}
+}
+
 func TestEquals(t *testing.T) {
 	// Values outside the UnixNano range were encoded incorrectly (#737, #803).
 	timeOutOfRangeHigh := time.Unix(0, math.MaxInt64).Add(time.Nanosecond)
@@ -241,6 +422,16 @@ func TestEquals(t *testing.T) {
 			b:    zap.Time("k", time.Unix(1000, 1000)),
 			want: true,
 		},
+		{
+			a:    zap.RawJSON("k", json.RawMessage(`{"a":1}`)),
+			b:    zap.RawJSON("k", json.RawMessage(`{"a":1}`)),
+			want: true,
+		},
+		{
+			a:    zap.RawJSON("k", json.RawMessage(`{"a":1}`)),
+			b:    zap.RawJSON("k", json.RawMessage(`{"a":2}`)),
+			want: false,
+		},
 		{
 			a:    zap.Time("k", time.Unix(1000, 1000).In(time.UTC)),
 			b:    zap.Time("k", time.Unix(1000, 1000).In(time.FixedZone("TEST", -8))),
@@ -341,6 +532,31 @@ func TestEquals(t *testing.T) {
 			b:    zap.Object("k", nil),
 			want: false,
 		},
+		{
+			a:    zap.Secret("k", "a"),
+			b:    zap.Secret("k", "b"),
+			want: false,
+		},
+		{
+			a:    zap.Redact(zap.Binary("k", []byte{1, 2})),
+			b:    zap.Redact(zap.Binary("k", []byte{1, 2})),
+			want: true,
+		},
+		{
+			a:    zap.Redact(zap.Binary("k", []byte{1, 2})),
+			b:    zap.Redact(zap.Binary("k", []byte{1, 3})),
+			want: false,
+		},
+		{
+			a:    zap.OmitEmpty(zap.Binary("k", []byte{1, 2})),
+			b:    zap.OmitEmpty(zap.Binary("k", []byte{1, 2})),
+			want: true,
+		},
+		{
+			a:    zap.OmitEmpty(zap.Binary("k", []byte{1, 2})),
+			b:    zap.OmitEmpty(zap.Binary("k", []byte{1, 3})),
+			want: false,
+		},
 	}
 
 	for _, tt := range tests {
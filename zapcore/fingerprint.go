@@ -0,0 +1,183 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"encoding/hex"
+	"hash/fnv"
+	"reflect"
+	"strings"
+)
+
+const _defaultFingerprintKey = "fingerprint"
+
+// fingerprintOptionFunc wraps a func so it satisfies the FingerprintOption
+// interface.
+type fingerprintOptionFunc func(*fingerprinter)
+
+func (f fingerprintOptionFunc) apply(fp *fingerprinter) {
+	f(fp)
+}
+
+// FingerprintOption configures a fingerprinting Core.
+type FingerprintOption interface {
+	apply(*fingerprinter)
+}
+
+// FingerprintKey sets the key of the field holding the fingerprint.
+//
+// Defaults to "fingerprint".
+func FingerprintKey(key string) FingerprintOption {
+	return fingerprintOptionFunc(func(fp *fingerprinter) {
+		fp.key = key
+	})
+}
+
+// FingerprintLevel sets the minimum level of the entries that are
+// fingerprinted. Entries below it are written unchanged.
+//
+// Defaults to ErrorLevel.
+func FingerprintLevel(lvl Level) FingerprintOption {
+	return fingerprintOptionFunc(func(fp *fingerprinter) {
+		fp.level = lvl
+	})
+}
+
+type fingerprinter struct {
+	key   string
+	level Level
+	fn    func(Entry, []Field) string
+}
+
+// NewFingerprintCore creates a Core that adds a field holding a fingerprint
+// of the entry to every entry at or above ErrorLevel, so that tools like
+// error trackers can group related entries. Use FingerprintKey and
+// FingerprintLevel to change the field's key and the minimum level.
+//
+// fn computes the fingerprint from the entry and its fields, starting with
+// those added with With. If it's nil, DefaultFingerprint is used.
+//
+// The Core is built on NewMutatorCore and so shares its behavior with
+// respect to sampling and Tee.
+func NewFingerprintCore(inner Core, fn func(Entry, []Field) string, opts ...FingerprintOption) Core {
+	fp := &fingerprinter{
+		key:   _defaultFingerprintKey,
+		level: ErrorLevel,
+		fn:    fn,
+	}
+	for _, opt := range opts {
+		opt.apply(fp)
+	}
+	if fp.fn == nil {
+		fp.fn = DefaultFingerprint
+	}
+	return NewMutatorCore(inner, fp.mutate)
+}
+
+func (fp *fingerprinter) mutate(ent Entry, fields []Field) (Entry, []Field) {
+	if ent.Level < fp.level {
+		return ent, fields
+	}
+	n := len(fields)
+	return ent, append(fields[:n:n], Field{
+		Key:    fp.key,
+		Type:   StringType,
+		String: fp.fn(ent, fields),
+	})
+}
+
+// DefaultFingerprint fingerprints an entry by hashing its level, its
+// message, and the types of any errors among its fields. The fingerprint is
+// a 16-character hexadecimal string.
+func DefaultFingerprint(ent Entry, fields []Field) string {
+	return fingerprint(ent.Level, ent.Message, fields)
+}
+
+// NormalizedFingerprint is like DefaultFingerprint, but ignores UUIDs and
+// numbers in the message, so that "user 123 not found" and "user 456 not
+// found" have the same fingerprint.
+func NormalizedFingerprint(ent Entry, fields []Field) string {
+	return fingerprint(ent.Level, normalizeMessage(ent.Message), fields)
+}
+
+func fingerprint(lvl Level, msg string, fields []Field) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(lvl.String()))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(msg))
+	for _, f := range fields {
+		if f.Type != ErrorType || f.Interface == nil {
+			continue
+		}
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(reflect.TypeOf(f.Interface).String()))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizeMessage replaces the UUIDs and runs of digits in msg with '#'.
+func normalizeMessage(msg string) string {
+	var sb strings.Builder
+	for i := 0; i < len(msg); {
+		switch {
+		case isUUID(msg[i:]):
+			sb.WriteByte('#')
+			i += 36
+		case isDigit(msg[i]):
+			sb.WriteByte('#')
+			for i < len(msg) && isDigit(msg[i]) {
+				i++
+			}
+		default:
+			sb.WriteByte(msg[i])
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// isUUID reports whether s starts with a UUID in its canonical textual form.
+func isUUID(s string) bool {
+	if len(s) < 36 {
+		return false
+	}
+	for i := 0; i < 36; i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHexDigit(s[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
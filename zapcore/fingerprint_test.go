@@ -0,0 +1,126 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func errorField(err error) Field {
+	return Field{Key: "error", Type: ErrorType, Interface: err}
+}
+
+func TestDefaultFingerprint(t *testing.T) {
+	ent := Entry{Level: ErrorLevel, Message: "user 123 not found"}
+	fp := DefaultFingerprint(ent, nil)
+	assert.Len(t, fp, 16, "Expected a 64-bit hex fingerprint.")
+
+	same := []Field{makeInt64Field("user", 123), errorField(errors.New("a"))}
+	assert.Equal(t,
+		DefaultFingerprint(ent, []Field{errorField(errors.New("b"))}),
+		DefaultFingerprint(ent, same),
+		"Expected error messages and other fields to be ignored.")
+
+	for _, tt := range []struct {
+		desc   string
+		ent    Entry
+		fields []Field
+	}{
+		{"level", Entry{Level: WarnLevel, Message: ent.Message}, nil},
+		{"message", Entry{Level: ErrorLevel, Message: "user 456 not found"}, nil},
+		{"error type", ent, []Field{errorField(fs.ErrNotExist), errorField(&fs.PathError{})}},
+	} {
+		assert.NotEqual(t, DefaultFingerprint(ent, same), DefaultFingerprint(tt.ent, tt.fields),
+			"Expected %s to change the fingerprint.", tt.desc)
+	}
+}
+
+func TestNormalizedFingerprint(t *testing.T) {
+	fp := func(msg string) string {
+		return NormalizedFingerprint(Entry{Level: ErrorLevel, Message: msg}, nil)
+	}
+
+	assert.Equal(t, fp("user 123 not found"), fp("user 456 not found"))
+	assert.Equal(t, fp("user 123 not found"), fp("user 7 not found"))
+	assert.Equal(t,
+		fp("order 0f8fad5b-d9cb-469f-a165-70867728950e failed"),
+		fp("order 7C9E6679-7425-40DE-944B-E07FC1F90AE7 failed"),
+	)
+	assert.NotEqual(t, fp("user 123 not found"), fp("order 123 not found"))
+	assert.NotEqual(t, fp("user 123 not found"), DefaultFingerprint(Entry{Level: ErrorLevel, Message: "user 123 not found"}, nil),
+		"Expected normalized fingerprints to differ from the default.")
+}
+
+func TestFingerprintCore(t *testing.T) {
+	inner, logs := observer.New(DebugLevel)
+	var seen [][]Field
+	core := NewFingerprintCore(inner, func(_ Entry, fields []Field) string {
+		seen = append(seen, fields)
+		return "fp"
+	}, FingerprintKey("group"), FingerprintLevel(WarnLevel))
+
+	ctx := makeInt64Field("ctx", 1)
+	core = core.With([]Field{ctx})
+	for _, lvl := range []Level{InfoLevel, WarnLevel} {
+		ce := core.Check(Entry{Level: lvl, Message: "hello"}, nil)
+		require.NotNil(t, ce)
+		ce.Write(makeInt64Field("k", 2))
+	}
+
+	assert.Equal(t, [][]Field{{ctx, makeInt64Field("k", 2)}}, seen,
+		"Expected the fingerprinter to see With fields, and only for entries at or above the level.")
+	assert.Equal(t, []observer.LoggedEntry{
+		{
+			Entry:   Entry{Level: InfoLevel, Message: "hello"},
+			Context: []Field{ctx, makeInt64Field("k", 2)},
+		},
+		{
+			Entry:   Entry{Level: WarnLevel, Message: "hello"},
+			Context: []Field{ctx, makeInt64Field("k", 2), {Key: "group", Type: StringType, String: "fp"}},
+		},
+	}, logs.AllUntimed())
+}
+
+func TestFingerprintCoreDefaults(t *testing.T) {
+	inner, logs := observer.New(DebugLevel)
+	core := NewFingerprintCore(inner, nil)
+
+	for _, lvl := range []Level{WarnLevel, ErrorLevel} {
+		if ce := core.Check(Entry{Level: lvl, Message: "boom"}, nil); ce != nil {
+			ce.Write()
+		}
+	}
+
+	require.Equal(t, 2, logs.Len())
+	assert.Empty(t, logs.All()[0].Context, "Expected entries below ErrorLevel to be unchanged.")
+	assert.Equal(t, map[string]interface{}{
+		"fingerprint": DefaultFingerprint(Entry{Level: ErrorLevel, Message: "boom"}, nil),
+	}, logs.All()[1].ContextMap())
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"encoding/base64"
+	"os"
+	"time"
+
+	"github.com/toujourser/zap/buffer"
+)
+
+// _gelfVersion is the version of the GELF format written by the GELF encoder.
+const _gelfVersion = "1.1"
+
+// gelfEncoder writes entries in the Graylog Extended Log Format (GELF). It
+// writes the JSON itself through a jsonEncoder, and translates field keys
+// into GELF additional field names.
+type gelfEncoder struct {
+	*EncoderConfig
+	json *jsonEncoder
+	host string
+
+	// prefix is prepended to every field key. It's extended by
+	// OpenNamespace and, temporarily, by AddObject to flatten nested
+	// objects, since GELF additional fields can't be nested.
+	prefix string
+}
+
+// NewGELFEncoder creates an encoder that serializes entries as GELF 1.1
+// messages, for example
+//
+//	{"version":"1.1","host":"web-1","short_message":"hello","timestamp":1700000000.5,"level":6,"_user":"alice"}
+//
+// The host is the hostname reported by the kernel. The message, time, and
+// level are always written under the names that GELF requires, as the
+// short_message, timestamp (seconds since the Unix epoch), and level
+// (syslog severity) fields, so the MessageKey, TimeKey, LevelKey and their
+// encoders are ignored. Stacktraces are appended to the message in the
+// full_message field.
+//
+// All other fields, including the logger name and caller, are written as
+// GELF additional fields: their keys are prefixed with an underscore, and
+// characters other than ASCII letters, digits, underscores, and dashes,
+// including dots, are replaced with underscores. Since the "_id" field is
+// reserved, a field with the key "id" is written as "_id_". Nested objects
+// and namespaces are flattened, so zap.Namespace("db") followed by
+// zap.Int("rows", 3) produces "_db_rows":3. Values other than strings and
+// numbers, such as booleans and arrays, are written as JSON-encoded strings.
+func NewGELFEncoder(cfg EncoderConfig) Encoder {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	return newGELFEncoder(cfg, host)
+}
+
+func newGELFEncoder(cfg EncoderConfig, host string) *gelfEncoder {
+	json := newJSONEncoder(cfg, false /* spaced */)
+	return &gelfEncoder{
+		EncoderConfig: json.EncoderConfig,
+		json:          json,
+		host:          host,
+	}
+}
+
+func (enc *gelfEncoder) AddArray(key string, arr ArrayMarshaler) error {
+	return enc.addJSONString(key, func(json *jsonEncoder) error {
+		return json.AppendArray(arr)
+	})
+}
+
+func (enc *gelfEncoder) AddObject(key string, obj ObjectMarshaler) (retErr error) {
+	old := enc.prefix
+	if !enc.PropagateMarshalerPanics {
+		start := enc.json.buf.Len()
+		defer func() {
+			if r := recover(); r != nil {
+				enc.json.buf.Truncate(start)
+				enc.prefix = old
+				retErr = addPanicked(enc, key, obj, r)
+			}
+		}()
+	}
+
+	enc.prefix = old + key + "_"
+	err := obj.MarshalLogObject(enc)
+	enc.prefix = old
+	return err
+}
+
+func (enc *gelfEncoder) AddBinary(key string, val []byte) {
+	enc.AddString(key, base64.StdEncoding.EncodeToString(val))
+}
+
+func (enc *gelfEncoder) AddByteString(key string, val []byte) {
+	enc.addKey(key)
+	enc.json.AppendByteString(val)
+}
+
+func (enc *gelfEncoder) AddBool(key string, val bool) {
+	enc.addKey(key)
+	if val {
+		enc.json.AppendString("true")
+	} else {
+		enc.json.AppendString("false")
+	}
+}
+
+func (enc *gelfEncoder) AddComplex128(key string, val complex128) {
+	enc.addKey(key)
+	enc.json.AppendComplex128(val)
+}
+
+func (enc *gelfEncoder) AddComplex64(key string, val complex64) {
+	enc.addKey(key)
+	enc.json.AppendComplex64(val)
+}
+
+func (enc *gelfEncoder) AddDuration(key string, val time.Duration) {
+	enc.addKey(key)
+	enc.json.AppendDuration(val)
+}
+
+func (enc *gelfEncoder) AddFloat64(key string, val float64) {
+	enc.addKey(key)
+	enc.json.AppendFloat64(val)
+}
+
+func (enc *gelfEncoder) AddFloat32(key string, val float32) {
+	enc.addKey(key)
+	enc.json.AppendFloat32(val)
+}
+
+func (enc *gelfEncoder) AddInt64(key string, val int64) {
+	enc.addKey(key)
+	enc.json.AppendInt64(val)
+}
+
+func (enc *gelfEncoder) AddReflected(key string, obj interface{}) error {
+	if rs, ok := obj.(RedactedStringer); ok {
+		enc.AddString(key, redactedString(enc, rs))
+		return nil
+	}
+	valueBytes, err := enc.json.encodeReflected(obj)
+	if err != nil {
+		return err
+	}
+	enc.addKey(key)
+	if len(valueBytes) > 0 && (valueBytes[0] == '"' || valueBytes[0] == '-' ||
+		(valueBytes[0] >= '0' && valueBytes[0] <= '9')) {
+		// Strings and numbers are valid GELF values as they are.
+		_, err = enc.json.buf.Write(valueBytes)
+		return err
+	}
+	enc.json.AppendByteString(valueBytes)
+	return nil
+}
+
+func (enc *gelfEncoder) OpenNamespace(key string) {
+	enc.prefix = enc.prefix + key + "_"
+}
+
+func (enc *gelfEncoder) AddString(key, val string) {
+	enc.addKey(key)
+	enc.json.AppendString(val)
+}
+
+func (enc *gelfEncoder) AddTime(key string, val time.Time) {
+	enc.addKey(key)
+	enc.json.AppendTime(val)
+}
+
+func (enc *gelfEncoder) AddUint64(key string, val uint64) {
+	enc.addKey(key)
+	enc.json.AppendUint64(val)
+}
+
+func (enc *gelfEncoder) AddInt(k string, v int)         { enc.AddInt64(k, int64(v)) }
+func (enc *gelfEncoder) AddInt32(k string, v int32)     { enc.AddInt64(k, int64(v)) }
+func (enc *gelfEncoder) AddInt16(k string, v int16)     { enc.AddInt64(k, int64(v)) }
+func (enc *gelfEncoder) AddInt8(k string, v int8)       { enc.AddInt64(k, int64(v)) }
+func (enc *gelfEncoder) AddUint(k string, v uint)       { enc.AddUint64(k, uint64(v)) }
+func (enc *gelfEncoder) AddUint32(k string, v uint32)   { enc.AddUint64(k, uint64(v)) }
+func (enc *gelfEncoder) AddUint16(k string, v uint16)   { enc.AddUint64(k, uint64(v)) }
+func (enc *gelfEncoder) AddUint8(k string, v uint8)     { enc.AddUint64(k, uint64(v)) }
+func (enc *gelfEncoder) AddUintptr(k string, v uintptr) { enc.AddUint64(k, uint64(v)) }
+
+func (enc *gelfEncoder) Clone() Encoder {
+	clone := enc.clone()
+	clone.json.buf.Write(enc.json.buf.Bytes())
+	return clone
+}
+
+func (enc *gelfEncoder) clone() *gelfEncoder {
+	return &gelfEncoder{
+		EncoderConfig: enc.EncoderConfig,
+		json:          enc.json.clone(),
+		host:          enc.host,
+		prefix:        enc.prefix,
+	}
+}
+
+func (enc *gelfEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer, error) {
+	final := enc.clone()
+	json := final.json
+	json.buf.AppendByte('{')
+
+	json.addKey("version")
+	json.AppendString(_gelfVersion)
+	json.addKey("host")
+	json.AppendString(final.host)
+	json.addKey("short_message")
+	json.AppendString(ent.Message)
+	if ent.Stack != "" && final.StacktraceKey != "" {
+		json.addKey("full_message")
+		json.buf.AppendByte('"')
+		json.safeAddString(ent.Message)
+		json.safeAddString("\n")
+		json.safeAddString(ent.Stack)
+		json.buf.AppendByte('"')
+	}
+	if !ent.Time.IsZero() {
+		json.addKey("timestamp")
+		// Seconds with millisecond precision, as GELF recommends.
+		json.buf.AppendFloat(float64(ent.Time.UnixMilli())/1000, 64)
+	}
+	json.addKey("level")
+	json.AppendInt(ent.Level.SyslogSeverity())
+
+	if ent.LoggerName != "" && final.NameKey != "" {
+		final.addEntryKey(final.NameKey)
+		cur := json.buf.Len()
+		nameEncoder := final.EncodeName
+		if nameEncoder == nil {
+			nameEncoder = FullNameEncoder
+		}
+		nameEncoder(ent.LoggerName, json)
+		if cur == json.buf.Len() {
+			json.AppendString(ent.LoggerName)
+		}
+	}
+	if ent.Caller.Defined {
+		if final.CallerKey != "" && final.EncodeCaller != nil {
+			final.addEntryKey(final.CallerKey)
+			cur := json.buf.Len()
+			final.EncodeCaller(ent.Caller, json)
+			if cur == json.buf.Len() {
+				json.AppendString(ent.Caller.String())
+			}
+		}
+		if final.FunctionKey != "" {
+			final.addEntryKey(final.FunctionKey)
+			json.AppendString(ent.Caller.Function)
+		}
+	}
+	if enc.json.buf.Len() > 0 {
+		json.addElementSeparator()
+		json.buf.Write(enc.json.buf.Bytes())
+	}
+	addFields(final, fields)
+	json.buf.AppendByte('}')
+	json.buf.AppendString(final.LineEnding)
+
+	ret := json.buf
+	putJSONEncoder(json)
+	return ret, nil
+}
+
+// addKey writes the GELF additional field name for key, taking the current
+// prefix into account.
+func (enc *gelfEncoder) addKey(key string) {
+	enc.writeKey(enc.prefix, key)
+}
+
+// addEntryKey writes the GELF additional field name for a key holding
+// entry metadata, such as the logger name, which isn't in any namespace.
+func (enc *gelfEncoder) addEntryKey(key string) {
+	enc.writeKey("", key)
+}
+
+func (enc *gelfEncoder) writeKey(prefix, key string) {
+	buf := enc.json.buf
+	enc.json.addElementSeparator()
+	buf.AppendString(`"_`)
+	appendGELFFieldName(buf, prefix)
+	appendGELFFieldName(buf, key)
+	if prefix == "" && key == "id" {
+		// "_id" is reserved by GELF.
+		buf.AppendByte('_')
+	}
+	buf.AppendString(`":`)
+}
+
+// appendGELFFieldName appends s, replacing characters that aren't allowed
+// in GELF field names with underscores. GELF also allows dots, but they're
+// replaced too, because Graylog stores fields in Elasticsearch or
+// OpenSearch, which treat dots as object paths.
+func appendGELFFieldName(buf *buffer.Buffer, s string) {
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			buf.AppendByte(byte(r))
+		default:
+			buf.AppendByte('_')
+		}
+	}
+}
+
+// addJSONString writes the value that appendValue appends to a JSON
+// encoder as a JSON-encoded string, since GELF values can only be strings
+// or numbers.
+func (enc *gelfEncoder) addJSONString(key string, appendValue func(*jsonEncoder) error) error {
+	scratch := enc.json.clone()
+	err := appendValue(scratch)
+	enc.addKey(key)
+	enc.json.AppendByteString(scratch.buf.Bytes())
+	scratch.buf.Free()
+	putJSONEncoder(scratch)
+	return err
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testGELFEncoderConfig() EncoderConfig {
+	return EncoderConfig{
+		MessageKey:     "msg",
+		LevelKey:       "level",
+		TimeKey:        "ts",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		FunctionKey:    "func",
+		StacktraceKey:  "stacktrace",
+		EncodeLevel:    LowercaseLevelEncoder,
+		EncodeTime:     ISO8601TimeEncoder,
+		EncodeDuration: StringDurationEncoder,
+		EncodeCaller:   ShortCallerEncoder,
+	}
+}
+
+func TestGELFEncodeEntry(t *testing.T) {
+	ts := time.Date(2023, time.November, 14, 22, 13, 20, 500_000_000, time.UTC)
+
+	tests := []struct {
+		desc   string
+		ent    Entry
+		fields []Field
+		want   string
+	}{
+		{
+			desc: "minimal",
+			ent:  Entry{Level: InfoLevel, Message: "hello"},
+			want: `{"version":"1.1","host":"web-1","short_message":"hello","level":6}` + "\n",
+		},
+		{
+			desc: "entry metadata",
+			ent: Entry{
+				Level:      ErrorLevel,
+				Time:       ts,
+				LoggerName: "app.http",
+				Message:    "failed",
+				Caller:     EntryCaller{Defined: true, File: "/src/main.go", Line: 42, Function: "main.run"},
+				Stack:      "main.run\n\tmain.go:42",
+			},
+			want: `{"version":"1.1","host":"web-1","short_message":"failed",` +
+				`"full_message":"failed\nmain.run\n\tmain.go:42",` +
+				`"timestamp":1700000000.5,"level":3,` +
+				`"_logger":"app.http","_caller":"src/main.go:42","_func":"main.run"}` + "\n",
+		},
+		{
+			desc: "fields",
+			ent:  Entry{Level: DebugLevel, Message: "fields"},
+			fields: []Field{
+				{Key: "user", Type: StringType, String: "alice"},
+				{Key: "count", Type: Int64Type, Integer: 3},
+				{Key: "ratio", Type: Float64Type, Integer: 4602678819172646912}, // 0.5
+				{Key: "ok", Type: BoolType, Integer: 1},
+				{Key: "elapsed", Type: DurationType, Integer: int64(time.Second)},
+				{Key: "err", Type: ErrorType, Interface: errors.New("boom")},
+			},
+			want: `{"version":"1.1","host":"web-1","short_message":"fields","level":7,` +
+				`"_user":"alice","_count":3,"_ratio":0.5,"_ok":"true","_elapsed":"1s","_err":"boom"}` + "\n",
+		},
+		{
+			desc: "field names",
+			ent:  Entry{Level: WarnLevel, Message: "names"},
+			fields: []Field{
+				{Key: "id", Type: StringType, String: "x"},
+				{Key: "http.method", Type: StringType, String: "GET"},
+				{Key: "user id", Type: StringType, String: "a"},
+				{Key: "café", Type: StringType, String: "b"},
+				{Key: "trace-id", Type: StringType, String: "c"},
+			},
+			want: `{"version":"1.1","host":"web-1","short_message":"names","level":4,` +
+				`"_id_":"x","_http_method":"GET","_user_id":"a","_caf_":"b","_trace-id":"c"}` + "\n",
+		},
+		{
+			desc: "nested values",
+			ent:  Entry{Level: InfoLevel, Message: "nested"},
+			fields: []Field{
+				{Key: "req", Type: ObjectMarshalerType, Interface: ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+					enc.AddString("method", "GET")
+					enc.AddString("id", "r1")
+					return nil
+				})},
+				{Key: "ids", Type: ArrayMarshalerType, Interface: ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+					enc.AppendInt(1)
+					enc.AppendString("two")
+					return nil
+				})},
+				{Key: "any", Type: ReflectType, Interface: map[string]int{"a": 1}},
+				{Key: "num", Type: ReflectType, Interface: 12},
+				{Key: "db", Type: NamespaceType},
+				{Key: "rows", Type: Int64Type, Integer: 3},
+			},
+			want: `{"version":"1.1","host":"web-1","short_message":"nested","level":6,` +
+				`"_req_method":"GET","_req_id":"r1","_ids":"[1,\"two\"]",` +
+				`"_any":"{\"a\":1}","_num":12,"_db_rows":3}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := newGELFEncoder(testGELFEncoderConfig(), "web-1")
+			buf, err := enc.EncodeEntry(tt.ent, tt.fields)
+			require.NoError(t, err, "Unexpected error encoding entry.")
+			assert.Equal(t, tt.want, buf.String(), "Unexpected GELF output.")
+			buf.Free()
+		})
+	}
+}
+
+func TestGELFEncoderClone(t *testing.T) {
+	enc := newGELFEncoder(testGELFEncoderConfig(), "web-1")
+	enc.OpenNamespace("svc")
+	enc.AddString("name", "api")
+
+	clone := enc.Clone()
+	clone.AddInt("shard", 2)
+
+	buf, err := clone.EncodeEntry(Entry{Message: "cloned"}, []Field{{Key: "k", Type: StringType, String: "v"}})
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	assert.Equal(t,
+		`{"version":"1.1","host":"web-1","short_message":"cloned","level":6,"_svc_name":"api","_svc_shard":2,"_svc_k":"v"}`+"\n",
+		buf.String(), "Unexpected GELF output from clone.")
+	buf.Free()
+
+	buf, err = enc.EncodeEntry(Entry{Message: "original"}, nil)
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	assert.Equal(t,
+		`{"version":"1.1","host":"web-1","short_message":"original","level":6,"_svc_name":"api"}`+"\n",
+		buf.String(), "Fields added to the clone leaked into the original.")
+	buf.Free()
+}
+
+func TestGELFEncoderHost(t *testing.T) {
+	host, err := os.Hostname()
+	require.NoError(t, err, "Failed to get hostname.")
+
+	enc := NewGELFEncoder(testGELFEncoderConfig()).(*gelfEncoder)
+	assert.Equal(t, host, enc.host, "Expected the hostname as the GELF host.")
+}
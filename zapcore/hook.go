@@ -29,7 +29,7 @@ type hooked struct {
 
 var (
 	_ Core           = (*hooked)(nil)
-	_ leveledEnabler = (*hooked)(nil)
+	_ LeveledEnabler = (*hooked)(nil)
 )
 
 // RegisterHooks wraps a Core and runs a collection of user-defined callback
@@ -75,3 +75,71 @@ func (h *hooked) Write(ent Entry, _ []Field) error {
 	}
 	return err
 }
+
+type writeHooked struct {
+	Core
+	funcs  []func(Entry, []Field) error
+	fields []Field // added with With
+}
+
+var (
+	_ Core           = (*writeHooked)(nil)
+	_ LeveledEnabler = (*writeHooked)(nil)
+)
+
+// WriteHookCore wraps a Core and runs a collection of user-defined callback
+// hooks each time the wrapped Core logs a message. Unlike the hooks
+// registered with RegisterHooks, these receive the entry's fields, starting
+// with those added with With. The hooks only observe entries: they can't
+// change or drop them, and they must not retain the slice of fields.
+// Execution of the callbacks is blocking.
+func WriteHookCore(core Core, hooks ...func(Entry, []Field) error) Core {
+	funcs := append([]func(Entry, []Field) error{}, hooks...)
+	return &writeHooked{
+		Core:  core,
+		funcs: funcs,
+	}
+}
+
+// RegisterFieldHooks wraps a Core and runs a collection of user-defined
+// callback hooks each time a message is logged, like RegisterHooks, but also
+// passes the hooks the entry's fields: first those added with With by the
+// Core and its parents, then those passed at the log site. The slice of
+// fields is read-only and must not be retained after the hook returns.
+//
+// RegisterFieldHooks is equivalent to WriteHookCore.
+func RegisterFieldHooks(core Core, hooks ...func(Entry, []Field) error) Core {
+	return WriteHookCore(core, hooks...)
+}
+
+func (h *writeHooked) Level() Level {
+	return LevelOf(h.Core)
+}
+
+func (h *writeHooked) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	// As with RegisterHooks, the wrapped Core registers itself directly
+	// with the CheckedEntry.
+	if downstream := h.Core.Check(ent, ce); downstream != nil {
+		return downstream.AddCore(ent, h)
+	}
+	return ce
+}
+
+func (h *writeHooked) With(fields []Field) Core {
+	return &writeHooked{
+		Core:   h.Core.With(fields),
+		funcs:  h.funcs,
+		fields: append(h.fields[:len(h.fields):len(h.fields)], fields...),
+	}
+}
+
+func (h *writeHooked) Write(ent Entry, fields []Field) error {
+	if len(h.fields) > 0 {
+		fields = append(h.fields[:len(h.fields):len(h.fields)], fields...)
+	}
+	var err error
+	for i := range h.funcs {
+		err = multierr.Append(err, h.funcs[i](ent, fields))
+	}
+	return err
+}
@@ -21,6 +21,7 @@
 package zapcore_test
 
 import (
+	"errors"
 	"testing"
 
 	//revive:disable:dot-imports
@@ -81,3 +82,62 @@ func TestHooks(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteHookCore(t *testing.T) {
+	fac, logs := observer.New(InfoLevel)
+	var (
+		entries []Entry
+		fields  [][]Field
+	)
+	hook := func(ent Entry, fs []Field) error {
+		entries = append(entries, ent)
+		fields = append(fields, append([]Field(nil), fs...))
+		return nil
+	}
+	core := WriteHookCore(fac, hook)
+	assert.Equal(t, InfoLevel, LevelOf(core), "Wrapped core has the wrong level.")
+
+	ctx := makeInt64Field("ctx", 1)
+	field := makeInt64Field("foo", 42)
+	child := core.With([]Field{ctx})
+	for _, lvl := range []Level{DebugLevel, InfoLevel} {
+		if ce := child.Check(Entry{Message: "bar", Level: lvl}, nil); ce != nil {
+			ce.Write(field)
+		}
+	}
+
+	assert.Equal(t, []Entry{{Message: "bar", Level: InfoLevel}}, entries, "Expected the hook to see enabled entries only.")
+	assert.Equal(t, [][]Field{{ctx, field}}, fields, "Expected the hook to see context and entry fields.")
+	assert.Equal(t,
+		[]observer.LoggedEntry{{Entry: Entry{Message: "bar", Level: InfoLevel}, Context: []Field{ctx, field}}},
+		logs.AllUntimed(),
+		"Expected the entry to reach the wrapped core unchanged.",
+	)
+	assert.NoError(t, core.Sync())
+}
+
+func TestWriteHookCoreErrors(t *testing.T) {
+	fac, logs := observer.New(InfoLevel)
+	core := WriteHookCore(fac,
+		func(Entry, []Field) error { return errors.New("first") },
+		func(Entry, []Field) error { return errors.New("second") },
+	)
+	err := core.Write(Entry{Level: InfoLevel}, nil)
+	assert.EqualError(t, err, "first; second", "Expected errors from all hooks.")
+	assert.Zero(t, logs.Len(), "Expected Write to leave writing to the wrapped core.")
+}
+
+func TestRegisterFieldHooks(t *testing.T) {
+	fac, _ := observer.New(InfoLevel)
+	var got []Field
+	core := RegisterFieldHooks(fac, func(_ Entry, fields []Field) error {
+		got = append([]Field(nil), fields...)
+		return nil
+	})
+
+	a, b, c := makeInt64Field("a", 1), makeInt64Field("b", 2), makeInt64Field("c", 3)
+	if ce := core.With([]Field{a}).With([]Field{b}).Check(Entry{Level: InfoLevel}, nil); ce != nil {
+		ce.Write(c)
+	}
+	assert.Equal(t, []Field{a, b, c}, got, "Expected fields from parent With calls first.")
+}
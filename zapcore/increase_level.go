@@ -29,7 +29,7 @@ type levelFilterCore struct {
 
 var (
 	_ Core           = (*levelFilterCore)(nil)
-	_ leveledEnabler = (*levelFilterCore)(nil)
+	_ LeveledEnabler = (*levelFilterCore)(nil)
 )
 
 // NewIncreaseLevelCore creates a core that can be used to increase the level of
@@ -43,6 +43,21 @@ func NewIncreaseLevelCore(core Core, level LevelEnabler) (Core, error) {
 		}
 	}
 
+	// Increasing a fixed level to another fixed level replaces the filter
+	// instead of stacking another one: we've already checked that level is
+	// no more permissive than the old filter, and neither can change. A
+	// dynamic level on either side must be stacked, or lowering it later
+	// would let through entries that the other filter drops.
+	if lf, ok := core.(*levelFilterCore); ok {
+		old, oldStatic := lf.level.(Level)
+		lvl, newStatic := level.(Level)
+		if oldStatic && newStatic {
+			if lvl == old {
+				return core, nil
+			}
+			return &levelFilterCore{lf.core, level}, nil
+		}
+	}
 	return &levelFilterCore{core, level}, nil
 }
 
@@ -73,3 +88,68 @@ func (c *levelFilterCore) Write(ent Entry, fields []Field) error {
 func (c *levelFilterCore) Sync() error {
 	return c.core.Sync()
 }
+
+type levelOverrideCore struct {
+	core  Core
+	level LevelEnabler
+}
+
+var (
+	_ Core           = (*levelOverrideCore)(nil)
+	_ LeveledEnabler = (*levelOverrideCore)(nil)
+)
+
+// NewOverrideLevelCore creates a core whose level is level, whether it's
+// higher or lower than that of core. Unlike NewIncreaseLevelCore, it replaces
+// rather than narrows the level: if core was built by NewIncreaseLevelCore or
+// NewOverrideLevelCore, the override applies to the core they wrap.
+//
+// The override decides which entries are enabled, but core still checks
+// them before they're written, so entries below core's own level are
+// dropped. For example, an override can make a logger more verbose than its
+// parent only up to the level of the underlying core, which may be an
+// AtomicLevel that's lowered later. NewOverrideLevelCore also returns the
+// lowest level that the underlying core currently logs, as reported by
+// LevelOf, so that callers can detect overrides that can't take effect yet.
+func NewOverrideLevelCore(core Core, level LevelEnabler) (Core, Level) {
+	for {
+		switch c := core.(type) {
+		case *levelFilterCore:
+			core = c.core
+			continue
+		case *levelOverrideCore:
+			core = c.core
+			continue
+		}
+		break
+	}
+	return &levelOverrideCore{core, level}, LevelOf(core)
+}
+
+func (c *levelOverrideCore) Enabled(lvl Level) bool {
+	return c.level.Enabled(lvl)
+}
+
+func (c *levelOverrideCore) Level() Level {
+	return LevelOf(c.level)
+}
+
+func (c *levelOverrideCore) With(fields []Field) Core {
+	return &levelOverrideCore{c.core.With(fields), c.level}
+}
+
+func (c *levelOverrideCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+
+	return c.core.Check(ent, ce)
+}
+
+func (c *levelOverrideCore) Write(ent Entry, fields []Field) error {
+	return c.core.Write(ent, fields)
+}
+
+func (c *levelOverrideCore) Sync() error {
+	return c.core.Sync()
+}
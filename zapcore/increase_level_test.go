@@ -128,3 +128,96 @@ func TestIncreaseLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestIncreaseLevelRepeatedly(t *testing.T) {
+	t.Run("static", func(t *testing.T) {
+		core, _ := observer.New(DebugLevel)
+		warn, err := NewIncreaseLevelCore(core, WarnLevel)
+		require.NoError(t, err)
+		errorCore, err := NewIncreaseLevelCore(warn, ErrorLevel)
+		require.NoError(t, err)
+
+		assert.False(t, errorCore.Enabled(WarnLevel), "Expected warn to be disabled.")
+		assert.True(t, errorCore.Enabled(ErrorLevel), "Expected error to be enabled.")
+		assert.Equal(t, ErrorLevel, LevelOf(errorCore))
+
+		_, err = NewIncreaseLevelCore(warn, InfoLevel)
+		assert.ErrorContains(t, err, "invalid increase level", "Expected to be unable to decrease the level.")
+	})
+
+	t.Run("dynamic", func(t *testing.T) {
+		core, _ := observer.New(DebugLevel)
+		lvl := zap.NewAtomicLevelAt(InfoLevel)
+		info, err := NewIncreaseLevelCore(core, lvl)
+		require.NoError(t, err)
+		warn, err := NewIncreaseLevelCore(info, WarnLevel)
+		require.NoError(t, err)
+		assert.NotNil(t, warn.Check(Entry{Level: WarnLevel}, nil), "Expected warn to be logged.")
+
+		// The earlier, dynamic filter must still apply.
+		lvl.SetLevel(ErrorLevel)
+		assert.Nil(t, warn.Check(Entry{Level: WarnLevel}, nil), "Expected warn to be dropped by the dynamic level.")
+	})
+
+	t.Run("dynamic after static", func(t *testing.T) {
+		core, _ := observer.New(DebugLevel)
+		warn, err := NewIncreaseLevelCore(core, WarnLevel)
+		require.NoError(t, err)
+		lvl := zap.NewAtomicLevelAt(ErrorLevel)
+		dynamic, err := NewIncreaseLevelCore(warn, lvl)
+		require.NoError(t, err)
+		assert.Nil(t, dynamic.Check(Entry{Level: WarnLevel}, nil), "Expected warn to be dropped by the dynamic level.")
+
+		// The earlier, static filter must still apply.
+		lvl.SetLevel(DebugLevel)
+		assert.Nil(t, dynamic.Check(Entry{Level: DebugLevel}, nil), "Expected debug to be dropped by the static level.")
+		assert.NotNil(t, dynamic.Check(Entry{Level: WarnLevel}, nil), "Expected warn to be logged.")
+	})
+
+	t.Run("same static level", func(t *testing.T) {
+		core, _ := observer.New(DebugLevel)
+		warn, err := NewIncreaseLevelCore(core, WarnLevel)
+		require.NoError(t, err)
+		again, err := NewIncreaseLevelCore(warn, WarnLevel)
+		require.NoError(t, err)
+		assert.Equal(t, warn, again, "Expected the core to be returned as-is.")
+	})
+}
+
+func TestOverrideLevelCore(t *testing.T) {
+	core, logs := observer.New(DebugLevel)
+	warn, err := NewIncreaseLevelCore(core, WarnLevel)
+	require.NoError(t, err)
+
+	// Lowering the level replaces the filter instead of narrowing it.
+	info, floor := NewOverrideLevelCore(warn, InfoLevel)
+	assert.Equal(t, DebugLevel, floor, "Expected the level of the underlying core.")
+	assert.Equal(t, InfoLevel, LevelOf(info), "Unexpected level.")
+	assert.False(t, info.Enabled(DebugLevel), "Expected debug to be disabled.")
+	info.With([]Field{zap.Int("k", 1)}).Check(Entry{Level: InfoLevel, Message: "info"}, nil).Write()
+	assert.Equal(t, []observer.LoggedEntry{{
+		Entry:   Entry{Level: InfoLevel, Message: "info"},
+		Context: []Field{zap.Int("k", 1)},
+	}}, logs.TakeAll(), "Expected the override to enable info.")
+
+	// So does raising it, including over another override.
+	errorCore, _ := NewOverrideLevelCore(info, ErrorLevel)
+	assert.Nil(t, errorCore.Check(Entry{Level: WarnLevel}, nil), "Expected warn to be disabled.")
+	assert.NotNil(t, errorCore.Check(Entry{Level: ErrorLevel}, nil), "Expected error to be enabled.")
+}
+
+func TestOverrideLevelCoreBelowCore(t *testing.T) {
+	lvl := zap.NewAtomicLevelAt(InfoLevel)
+	core, logs := observer.New(lvl)
+
+	debug, floor := NewOverrideLevelCore(core, DebugLevel)
+	assert.Equal(t, InfoLevel, floor, "Expected the level of the underlying core.")
+	assert.True(t, debug.Enabled(DebugLevel), "Expected the override to decide enablement.")
+
+	// The underlying core still checks entries.
+	assert.Nil(t, debug.Check(Entry{Level: DebugLevel}, nil), "Expected debug to be dropped by the core.")
+
+	lvl.SetLevel(DebugLevel)
+	debug.Check(Entry{Level: DebugLevel, Message: "debug"}, nil).Write()
+	assert.Equal(t, 1, logs.FilterMessage("debug").Len(), "Expected debug once the core allows it.")
+}
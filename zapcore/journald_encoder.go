@@ -0,0 +1,373 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/toujourser/zap/buffer"
+	"github.com/toujourser/zap/internal/bufferpool"
+	"github.com/toujourser/zap/internal/pool"
+)
+
+// _journaldMaxFieldName is the maximum length of a journal field name.
+const _journaldMaxFieldName = 64
+
+var _journaldPool = pool.New(func() *journaldEncoder {
+	return &journaldEncoder{}
+})
+
+func putJournaldEncoder(enc *journaldEncoder) {
+	enc.EncoderConfig = nil
+	enc.buf = nil
+	enc.prefix = ""
+	_journaldPool.Put(enc)
+}
+
+// journaldEncoder writes entries in the systemd journal's native protocol.
+type journaldEncoder struct {
+	*EncoderConfig
+	buf *buffer.Buffer
+
+	// prefix is prepended to every field key. It's extended by
+	// OpenNamespace and, temporarily, by AddObject to flatten nested
+	// objects, since journal fields can't be nested.
+	prefix string
+}
+
+// NewJournaldEncoder creates an encoder that serializes entries in the
+// native protocol of the systemd journal, for use with the "journald" sink.
+// Each entry is a list of journal fields, one per line:
+//
+//	MESSAGE=hello
+//	PRIORITY=6
+//	CODE_FILE=/src/app/main.go
+//	CODE_LINE=12
+//	CODE_FUNC=main.main
+//	USER_ID=42
+//
+// The message and level are always written as the MESSAGE and PRIORITY
+// (syslog severity) fields, and the caller, if any, as the CODE_FILE,
+// CODE_LINE, and CODE_FUNC fields, so the MessageKey, LevelKey, CallerKey,
+// FunctionKey, and their encoders are ignored. The journal records the time
+// it receives each entry, so the entry's time isn't written.
+//
+// Other field names, including those of the logger name and stacktrace, are
+// derived from their keys: letters are upper-cased, characters other than
+// ASCII letters and digits are replaced with underscores, leading
+// underscores are removed since they're reserved for trusted fields, names
+// that would start with a digit are prefixed with "F_", and names are
+// truncated to 64 characters. Nested objects and namespaces are flattened,
+// so zap.Namespace("db") followed by zap.Int("rows", 3) produces DB_ROWS=3.
+// Arrays and reflected values are written as JSON.
+//
+// Values containing newlines, such as stacktraces, and binary values are
+// written with the protocol's length-prefixed encoding.
+func NewJournaldEncoder(cfg EncoderConfig) Encoder {
+	return newJournaldEncoder(cfg)
+}
+
+func newJournaldEncoder(cfg EncoderConfig) *journaldEncoder {
+	if cfg.NewReflectedEncoder == nil {
+		cfg.NewReflectedEncoder = defaultReflectedEncoder
+	}
+	return &journaldEncoder{
+		EncoderConfig: &cfg,
+		buf:           bufferpool.Get(),
+	}
+}
+
+func (enc *journaldEncoder) AddArray(key string, arr ArrayMarshaler) error {
+	return enc.addJSON(key, func(json *jsonEncoder) error {
+		return json.AppendArray(arr)
+	})
+}
+
+func (enc *journaldEncoder) AddObject(key string, obj ObjectMarshaler) (retErr error) {
+	old := enc.prefix
+	if !enc.PropagateMarshalerPanics {
+		start := enc.buf.Len()
+		defer func() {
+			if r := recover(); r != nil {
+				enc.buf.Truncate(start)
+				enc.prefix = old
+				retErr = addPanicked(enc, key, obj, r)
+			}
+		}()
+	}
+
+	enc.prefix = old + key + "_"
+	err := obj.MarshalLogObject(enc)
+	enc.prefix = old
+	return err
+}
+
+func (enc *journaldEncoder) AddBinary(key string, val []byte) {
+	enc.addKey(key)
+	enc.appendBinary(val)
+}
+
+func (enc *journaldEncoder) AddByteString(key string, val []byte) {
+	enc.addKey(key)
+	enc.appendBytes(val)
+}
+
+func (enc *journaldEncoder) AddBool(key string, val bool) {
+	enc.addKey(key)
+	enc.buf.AppendByte('=')
+	enc.buf.AppendBool(val)
+	enc.buf.AppendByte('\n')
+}
+
+func (enc *journaldEncoder) AddComplex128(key string, val complex128) {
+	enc.addKey(key)
+	enc.buf.AppendByte('=')
+	enc.buf.AppendString(strconv.FormatComplex(val, 'g', -1, 128))
+	enc.buf.AppendByte('\n')
+}
+
+func (enc *journaldEncoder) AddComplex64(key string, val complex64) {
+	enc.addKey(key)
+	enc.buf.AppendByte('=')
+	enc.buf.AppendString(strconv.FormatComplex(complex128(val), 'g', -1, 64))
+	enc.buf.AppendByte('\n')
+}
+
+func (enc *journaldEncoder) AddDuration(key string, val time.Duration) {
+	enc.AddString(key, val.String())
+}
+
+func (enc *journaldEncoder) AddFloat64(key string, val float64) {
+	enc.addKey(key)
+	enc.buf.AppendByte('=')
+	enc.buf.AppendFloat(val, 64)
+	enc.buf.AppendByte('\n')
+}
+
+func (enc *journaldEncoder) AddFloat32(key string, val float32) {
+	enc.addKey(key)
+	enc.buf.AppendByte('=')
+	enc.buf.AppendFloat(float64(val), 32)
+	enc.buf.AppendByte('\n')
+}
+
+func (enc *journaldEncoder) AddInt64(key string, val int64) {
+	enc.addKey(key)
+	enc.buf.AppendByte('=')
+	enc.buf.AppendInt(val)
+	enc.buf.AppendByte('\n')
+}
+
+func (enc *journaldEncoder) AddReflected(key string, obj interface{}) error {
+	if rs, ok := obj.(RedactedStringer); ok {
+		enc.AddString(key, redactedString(enc, rs))
+		return nil
+	}
+	return enc.addJSON(key, func(json *jsonEncoder) error {
+		valueBytes, err := json.encodeReflected(obj)
+		if err != nil {
+			return err
+		}
+		_, err = json.buf.Write(valueBytes)
+		return err
+	})
+}
+
+func (enc *journaldEncoder) OpenNamespace(key string) {
+	enc.prefix = enc.prefix + key + "_"
+}
+
+func (enc *journaldEncoder) AddString(key, val string) {
+	enc.addKey(key)
+	enc.appendString(val)
+}
+
+func (enc *journaldEncoder) AddTime(key string, val time.Time) {
+	enc.addKey(key)
+	enc.buf.AppendByte('=')
+	enc.buf.AppendTime(val, time.RFC3339Nano)
+	enc.buf.AppendByte('\n')
+}
+
+func (enc *journaldEncoder) AddUint64(key string, val uint64) {
+	enc.addKey(key)
+	enc.buf.AppendByte('=')
+	enc.buf.AppendUint(val)
+	enc.buf.AppendByte('\n')
+}
+
+func (enc *journaldEncoder) AddInt(k string, v int)         { enc.AddInt64(k, int64(v)) }
+func (enc *journaldEncoder) AddInt32(k string, v int32)     { enc.AddInt64(k, int64(v)) }
+func (enc *journaldEncoder) AddInt16(k string, v int16)     { enc.AddInt64(k, int64(v)) }
+func (enc *journaldEncoder) AddInt8(k string, v int8)       { enc.AddInt64(k, int64(v)) }
+func (enc *journaldEncoder) AddUint(k string, v uint)       { enc.AddUint64(k, uint64(v)) }
+func (enc *journaldEncoder) AddUint32(k string, v uint32)   { enc.AddUint64(k, uint64(v)) }
+func (enc *journaldEncoder) AddUint16(k string, v uint16)   { enc.AddUint64(k, uint64(v)) }
+func (enc *journaldEncoder) AddUint8(k string, v uint8)     { enc.AddUint64(k, uint64(v)) }
+func (enc *journaldEncoder) AddUintptr(k string, v uintptr) { enc.AddUint64(k, uint64(v)) }
+
+func (enc *journaldEncoder) Clone() Encoder {
+	clone := enc.clone()
+	clone.buf.Write(enc.buf.Bytes())
+	return clone
+}
+
+func (enc *journaldEncoder) clone() *journaldEncoder {
+	clone := _journaldPool.Get()
+	clone.EncoderConfig = enc.EncoderConfig
+	clone.prefix = enc.prefix
+	clone.buf = bufferpool.Get()
+	return clone
+}
+
+func (enc *journaldEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer, error) {
+	final := enc.clone()
+	// Entry metadata is never namespaced.
+	final.prefix = ""
+
+	final.buf.AppendString("MESSAGE")
+	final.appendString(ent.Message)
+	final.buf.AppendString("PRIORITY=")
+	final.buf.AppendInt(int64(ent.Level.SyslogSeverity()))
+	final.buf.AppendByte('\n')
+
+	if ent.Caller.Defined {
+		final.buf.AppendString("CODE_FILE")
+		final.appendString(ent.Caller.File)
+		final.buf.AppendString("CODE_LINE=")
+		final.buf.AppendInt(int64(ent.Caller.Line))
+		final.buf.AppendByte('\n')
+		if ent.Caller.Function != "" {
+			final.buf.AppendString("CODE_FUNC")
+			final.appendString(ent.Caller.Function)
+		}
+	}
+	if ent.LoggerName != "" && final.NameKey != "" {
+		final.AddString(final.NameKey, ent.LoggerName)
+	}
+	if ent.Stack != "" && final.StacktraceKey != "" {
+		final.AddString(final.StacktraceKey, ent.Stack)
+	}
+
+	final.prefix = enc.prefix
+	final.buf.Write(enc.buf.Bytes())
+	addFields(final, fields)
+
+	ret := final.buf
+	putJournaldEncoder(final)
+	return ret, nil
+}
+
+// addKey writes the journal field name for key, taking the current prefix
+// into account.
+func (enc *journaldEncoder) addKey(key string) {
+	appendJournaldFieldName(enc.buf, enc.prefix, key)
+}
+
+// appendJournaldFieldName appends the journal field name for prefix+key.
+// Journal field names may only contain upper-case ASCII letters, digits,
+// and underscores, and must not start with an underscore or a digit.
+func appendJournaldFieldName(buf *buffer.Buffer, prefix, key string) {
+	n := 0
+	for _, s := range [2]string{prefix, key} {
+		for i := 0; i < len(s) && n < _journaldMaxFieldName; i++ {
+			c := s[i]
+			switch {
+			case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			case c >= 'a' && c <= 'z':
+				c -= 'a' - 'A'
+			default:
+				c = '_'
+			}
+			if n == 0 {
+				if c == '_' {
+					continue
+				}
+				if c >= '0' && c <= '9' {
+					buf.AppendString("F_")
+					n += 2
+				}
+			}
+			buf.AppendByte(c)
+			n++
+		}
+	}
+	if n == 0 {
+		buf.AppendByte('F')
+	}
+}
+
+// appendString appends a field value, using the binary encoding if it
+// contains a newline.
+func (enc *journaldEncoder) appendString(val string) {
+	if strings.IndexByte(val, '\n') < 0 {
+		enc.buf.AppendByte('=')
+		enc.buf.AppendString(val)
+		enc.buf.AppendByte('\n')
+		return
+	}
+	enc.appendLength(len(val))
+	enc.buf.AppendString(val)
+	enc.buf.AppendByte('\n')
+}
+
+func (enc *journaldEncoder) appendBytes(val []byte) {
+	for _, c := range val {
+		if c == '\n' {
+			enc.appendBinary(val)
+			return
+		}
+	}
+	enc.buf.AppendByte('=')
+	enc.buf.Write(val)
+	enc.buf.AppendByte('\n')
+}
+
+// appendBinary appends a field value with the binary encoding: a newline,
+// the little-endian 64-bit length of the value, and the value itself.
+func (enc *journaldEncoder) appendBinary(val []byte) {
+	enc.appendLength(len(val))
+	enc.buf.Write(val)
+	enc.buf.AppendByte('\n')
+}
+
+func (enc *journaldEncoder) appendLength(n int) {
+	var size [9]byte
+	size[0] = '\n'
+	binary.LittleEndian.PutUint64(size[1:], uint64(n))
+	enc.buf.Write(size[:])
+}
+
+// addJSON writes the value that appendValue appends to a JSON encoder.
+func (enc *journaldEncoder) addJSON(key string, appendValue func(*jsonEncoder) error) error {
+	json := _jsonPool.Get()
+	json.EncoderConfig = enc.EncoderConfig
+	json.buf = bufferpool.Get()
+	err := appendValue(json)
+	enc.addKey(key)
+	enc.appendBytes(json.buf.Bytes())
+	json.buf.Free()
+	putJSONEncoder(json)
+	return err
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap/buffer"
+)
+
+// journaldBinary returns the binary encoding of a journal field.
+func journaldBinary(name, val string) string {
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(val)))
+	return name + "\n" + string(size[:]) + val + "\n"
+}
+
+func TestJournaldEncodeEntry(t *testing.T) {
+	tests := []struct {
+		desc   string
+		ent    Entry
+		fields []Field
+		want   string
+	}{
+		{
+			desc: "minimal",
+			ent:  Entry{Level: InfoLevel, Message: "hello", Time: time.Now()},
+			want: "MESSAGE=hello\nPRIORITY=6\n",
+		},
+		{
+			desc: "entry metadata",
+			ent: Entry{
+				Level:      ErrorLevel,
+				LoggerName: "app.http",
+				Message:    "failed",
+				Caller:     EntryCaller{Defined: true, File: "/src/main.go", Line: 42, Function: "main.run"},
+				Stack:      "main.run\n\tmain.go:42",
+			},
+			want: "MESSAGE=failed\nPRIORITY=3\n" +
+				"CODE_FILE=/src/main.go\nCODE_LINE=42\nCODE_FUNC=main.run\n" +
+				"LOGGER=app.http\n" +
+				journaldBinary("STACKTRACE", "main.run\n\tmain.go:42"),
+		},
+		{
+			desc: "multi-line message",
+			ent:  Entry{Level: DebugLevel, Message: "a\nb"},
+			want: journaldBinary("MESSAGE", "a\nb") + "PRIORITY=7\n",
+		},
+		{
+			desc: "fields",
+			ent:  Entry{Level: WarnLevel, Message: "fields"},
+			fields: []Field{
+				{Key: "user.id", Type: Int64Type, Integer: 42},
+				{Key: "ok", Type: BoolType, Integer: 1},
+				{Key: "ratio", Type: Float64Type, Integer: 4609434218613702656}, // 1.5
+				{Key: "elapsed", Type: DurationType, Integer: int64(time.Second)},
+				{Key: "raw", Type: BinaryType, Interface: []byte("a\x00b")},
+				{Key: "lines", Type: StringType, String: "one\ntwo"},
+				{Key: "tags", Type: ArrayMarshalerType, Interface: ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+					enc.AppendString("a")
+					enc.AppendInt(1)
+					return nil
+				})},
+				{Key: "req", Type: ObjectMarshalerType, Interface: ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+					enc.AddString("method", "GET")
+					return nil
+				})},
+				{Key: "meta", Type: ReflectType, Interface: map[string]int{"n": 1}},
+				{Key: "db", Type: NamespaceType},
+				{Key: "rows", Type: Int64Type, Integer: 3},
+			},
+			want: "MESSAGE=fields\nPRIORITY=4\n" +
+				"USER_ID=42\nOK=true\nRATIO=1.5\nELAPSED=1s\n" +
+				journaldBinary("RAW", "a\x00b") +
+				journaldBinary("LINES", "one\ntwo") +
+				`TAGS=["a",1]` + "\n" +
+				"REQ_METHOD=GET\n" +
+				`META={"n":1}` + "\n" +
+				"DB_ROWS=3\n",
+		},
+	}
+
+	enc := NewJournaldEncoder(testGELFEncoderConfig())
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			buf, err := enc.EncodeEntry(tt.ent, tt.fields)
+			require.NoError(t, err)
+			defer buf.Free()
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestJournaldEncoderWith(t *testing.T) {
+	enc := NewJournaldEncoder(testGELFEncoderConfig())
+	enc.OpenNamespace("http")
+	enc.AddString("method", "GET")
+	clone := enc.Clone()
+	clone.AddInt("status", 200)
+
+	buf, err := clone.EncodeEntry(Entry{Level: InfoLevel, Message: "done"}, []Field{
+		{Key: "bytes", Type: Int64Type, Integer: 512},
+	})
+	require.NoError(t, err)
+	defer buf.Free()
+	assert.Equal(t, "MESSAGE=done\nPRIORITY=6\nHTTP_METHOD=GET\nHTTP_STATUS=200\nHTTP_BYTES=512\n", buf.String())
+}
+
+func TestJournaldEncoderErrors(t *testing.T) {
+	enc := NewJournaldEncoder(testGELFEncoderConfig())
+	buf, err := enc.EncodeEntry(Entry{Message: "oops"}, []Field{
+		{Key: "err", Type: ErrorType, Interface: errors.New("line one\nline two")},
+	})
+	require.NoError(t, err)
+	defer buf.Free()
+	assert.Equal(t, "MESSAGE=oops\nPRIORITY=6\n"+journaldBinary("ERR", "line one\nline two"), buf.String())
+}
+
+func TestJournaldFieldName(t *testing.T) {
+	tests := []struct {
+		prefix, key string
+		want        string
+	}{
+		{"", "user", "USER"},
+		{"", "userID", "USERID"},
+		{"", "user-agent", "USER_AGENT"},
+		{"", "_trusted", "TRUSTED"},
+		{"", "__", "F"},
+		{"", "", "F"},
+		{"", "9lives", "F_9LIVES"},
+		{"", "ключ", "F"},
+		{"db_", "rows", "DB_ROWS"},
+		{"_", "1", "F_1"},
+		{"", "a_very_long_key_that_is_definitely_longer_than_sixty_four_characters", "A_VERY_LONG_KEY_THAT_IS_DEFINITELY_LONGER_THAN_SIXTY_FOUR_CHARAC"},
+	}
+
+	for _, tt := range tests {
+		buf := &buffer.Buffer{}
+		appendJournaldFieldName(buf, tt.prefix, tt.key)
+		assert.Equal(t, tt.want, buf.String(), "Unexpected field name for %q+%q.", tt.prefix, tt.key)
+		assert.LessOrEqual(t, buf.Len(), _journaldMaxFieldName, "Field name too long.")
+	}
+}
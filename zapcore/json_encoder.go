@@ -21,13 +21,15 @@
 package zapcore
 
 import (
-	"encoding/base64"
+	"bytes"
+	"encoding/json"
 	"math"
 	"time"
 	"unicode/utf8"
 
 	"github.com/toujourser/zap/buffer"
 	"github.com/toujourser/zap/internal/bufferpool"
+	"github.com/toujourser/zap/internal/color"
 	"github.com/toujourser/zap/internal/pool"
 )
 
@@ -46,6 +48,8 @@ func putJSONEncoder(enc *jsonEncoder) {
 	enc.buf = nil
 	enc.spaced = false
 	enc.openNamespaces = 0
+	enc.namespace = ""
+	enc.keyColor = 0
 	enc.reflectBuf = nil
 	enc.reflectEnc = nil
 	_jsonPool.Put(enc)
@@ -56,6 +60,11 @@ type jsonEncoder struct {
 	buf            *buffer.Buffer
 	spaced         bool // include spaces after colons and commas
 	openNamespaces int
+	keyColor       color.Color // if non-zero, color keys for the console encoder
+
+	// namespace prefixes keys when FlattenNamespaces is set. It holds the
+	// keys of the open namespaces, each followed by NamespaceSeparator.
+	namespace string
 
 	// for encoding generic values by reflection
 	reflectBuf *buffer.Buffer
@@ -90,6 +99,10 @@ func newJSONEncoder(cfg EncoderConfig, spaced bool) *jsonEncoder {
 		cfg.NewReflectedEncoder = defaultReflectedEncoder
 	}
 
+	if cfg.FlattenNamespaces && cfg.NamespaceSeparator == "" {
+		cfg.NamespaceSeparator = "."
+	}
+
 	return &jsonEncoder{
 		EncoderConfig: &cfg,
 		buf:           bufferpool.Get(),
@@ -108,7 +121,8 @@ func (enc *jsonEncoder) AddObject(key string, obj ObjectMarshaler) error {
 }
 
 func (enc *jsonEncoder) AddBinary(key string, val []byte) {
-	enc.AddString(key, base64.StdEncoding.EncodeToString(val))
+	enc.addKey(key)
+	enc.appendBinary(val)
 }
 
 func (enc *jsonEncoder) AddByteString(key string, val []byte) {
@@ -176,7 +190,30 @@ func (enc *jsonEncoder) encodeReflected(obj interface{}) ([]byte, error) {
 	return enc.reflectBuf.Bytes(), nil
 }
 
+// isCompactJSON reports whether raw is a valid JSON document that can be
+// written as-is without splitting the entry across lines.
+func isCompactJSON(raw json.RawMessage) bool {
+	return json.Valid(raw) && bytes.IndexAny(raw, "\r\n") < 0
+}
+
+// embedRawJSON reports whether raw can be written to the output as-is.
+func (enc *jsonEncoder) embedRawJSON(raw json.RawMessage) bool {
+	if enc.EncoderConfig != nil && enc.TrustRawJSON {
+		return len(raw) > 0
+	}
+	return isCompactJSON(raw)
+}
+
 func (enc *jsonEncoder) AddReflected(key string, obj interface{}) error {
+	if rs, ok := obj.(RedactedStringer); ok {
+		enc.AddString(key, redactedString(enc, rs))
+		return nil
+	}
+	if raw, ok := obj.(json.RawMessage); ok && enc.embedRawJSON(raw) {
+		enc.addKey(key)
+		enc.buf.AppendBytes(raw)
+		return nil
+	}
 	valueBytes, err := enc.encodeReflected(obj)
 	if err != nil {
 		return err
@@ -187,6 +224,10 @@ func (enc *jsonEncoder) AddReflected(key string, obj interface{}) error {
 }
 
 func (enc *jsonEncoder) OpenNamespace(key string) {
+	if enc.FlattenNamespaces {
+		enc.namespace += key + enc.NamespaceSeparator
+		return
+	}
 	enc.addKey(key)
 	enc.buf.AppendByte('{')
 	enc.openNamespaces++
@@ -207,7 +248,16 @@ func (enc *jsonEncoder) AddUint64(key string, val uint64) {
 	enc.AppendUint64(val)
 }
 
-func (enc *jsonEncoder) AppendArray(arr ArrayMarshaler) error {
+func (enc *jsonEncoder) AppendArray(arr ArrayMarshaler) (retErr error) {
+	if !enc.PropagateMarshalerPanics {
+		start := enc.buf.Len()
+		defer func() {
+			if r := recover(); r != nil {
+				retErr = enc.replacePanicked(start, arr, r)
+			}
+		}()
+	}
+
 	enc.addElementSeparator()
 	enc.buf.AppendByte('[')
 	err := arr.MarshalLogArray(enc)
@@ -215,17 +265,37 @@ func (enc *jsonEncoder) AppendArray(arr ArrayMarshaler) error {
 	return err
 }
 
-func (enc *jsonEncoder) AppendObject(obj ObjectMarshaler) error {
+func (enc *jsonEncoder) AppendObject(obj ObjectMarshaler) (retErr error) {
 	// Close ONLY new openNamespaces that are created during
 	// AppendObject().
-	old := enc.openNamespaces
-	enc.openNamespaces = 0
+	old, oldNamespace := enc.openNamespaces, enc.namespace
+	if !enc.PropagateMarshalerPanics {
+		start := enc.buf.Len()
+		defer func() {
+			if r := recover(); r != nil {
+				enc.openNamespaces, enc.namespace = old, oldNamespace
+				retErr = enc.replacePanicked(start, obj, r)
+			}
+		}()
+	}
+
+	enc.openNamespaces, enc.namespace = 0, ""
 	enc.addElementSeparator()
 	enc.buf.AppendByte('{')
 	err := obj.MarshalLogObject(enc)
 	enc.buf.AppendByte('}')
 	enc.closeOpenNamespaces()
-	enc.openNamespaces = old
+	enc.openNamespaces, enc.namespace = old, oldNamespace
+	return err
+}
+
+// replacePanicked discards whatever a marshaler that panicked wrote after
+// the start offset, logging a placeholder in its place so that the entry
+// remains valid JSON.
+func (enc *jsonEncoder) replacePanicked(start int, marshaler, r interface{}) error {
+	enc.buf.Truncate(start)
+	placeholder, err := marshalerPanic(marshaler, r)
+	enc.AppendString(placeholder)
 	return err
 }
 
@@ -274,12 +344,28 @@ func (enc *jsonEncoder) AppendDuration(val time.Duration) {
 	}
 }
 
+func (enc *jsonEncoder) appendBinary(val []byte) {
+	cur := enc.buf.Len()
+	if e := enc.EncodeBinary; e != nil {
+		e(val, enc)
+	}
+	if cur == enc.buf.Len() {
+		// EncodeBinary is missing or a no-op. Fall back to base64 to keep
+		// JSON valid.
+		Base64BinaryEncoder(val, enc)
+	}
+}
+
 func (enc *jsonEncoder) AppendInt64(val int64) {
 	enc.addElementSeparator()
 	enc.buf.AppendInt(val)
 }
 
 func (enc *jsonEncoder) AppendReflected(val interface{}) error {
+	if rs, ok := val.(RedactedStringer); ok {
+		enc.AppendString(redactedString(enc, rs))
+		return nil
+	}
 	valueBytes, err := enc.encodeReflected(val)
 	if err != nil {
 		return err
@@ -354,6 +440,8 @@ func (enc *jsonEncoder) clone() *jsonEncoder {
 	clone.EncoderConfig = enc.EncoderConfig
 	clone.spaced = enc.spaced
 	clone.openNamespaces = enc.openNamespaces
+	clone.namespace = enc.namespace
+	clone.keyColor = enc.keyColor
 	clone.buf = bufferpool.Get()
 	return clone
 }
@@ -362,6 +450,10 @@ func (enc *jsonEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer,
 	final := enc.clone()
 	final.buf.AppendByte('{')
 
+	// The entry's own keys are never in a namespace.
+	namespace := final.namespace
+	final.namespace = ""
+
 	if final.LevelKey != "" && final.EncodeLevel != nil {
 		final.addKey(final.LevelKey)
 		cur := final.buf.Len()
@@ -409,16 +501,21 @@ func (enc *jsonEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer,
 			final.AppendString(ent.Caller.Function)
 		}
 	}
-	if enc.buf.Len() > 0 {
-		final.addElementSeparator()
-		final.buf.Write(enc.buf.Bytes())
-	}
 	if final.MessageKey != "" {
 		final.addKey(enc.MessageKey)
 		final.AppendString(ent.Message)
 	}
+	fieldsStart := final.buf.Len()
+	if enc.buf.Len() > 0 {
+		final.addElementSeparator()
+		final.buf.Write(enc.buf.Bytes())
+	}
+	final.namespace = namespace
 	addFields(final, fields)
 	final.closeOpenNamespaces()
+	if final.SortKeys {
+		final.sortFields(fieldsStart, final.SortNestedKeys)
+	}
 	if ent.Stack != "" && final.StacktraceKey != "" {
 		final.AddString(final.StacktraceKey, ent.Stack)
 	}
@@ -439,13 +536,21 @@ func (enc *jsonEncoder) closeOpenNamespaces() {
 		enc.buf.AppendByte('}')
 	}
 	enc.openNamespaces = 0
+	enc.namespace = ""
 }
 
 func (enc *jsonEncoder) addKey(key string) {
 	enc.addElementSeparator()
+	if enc.keyColor != 0 {
+		appendColorStart(enc.buf, enc.keyColor)
+	}
 	enc.buf.AppendByte('"')
+	enc.safeAddString(enc.namespace)
 	enc.safeAddString(key)
 	enc.buf.AppendByte('"')
+	if enc.keyColor != 0 {
+		appendColorEnd(enc.buf)
+	}
 	enc.buf.AppendByte(':')
 	if enc.spaced {
 		enc.buf.AppendByte(' ')
@@ -72,6 +72,44 @@ func generateStringSlice(n int) StringSlice {
 	return output
 }
 
+func BenchmarkJSONEncodeEntrySortKeys(b *testing.B) {
+	fields := []Field{
+		{Key: "user", Type: StringType, String: "alice"},
+		{Key: "attempt", Type: Int64Type, Integer: 3},
+		{Key: "latency", Type: DurationType, Integer: int64(time.Millisecond)},
+		{Key: "path", Type: StringType, String: "/v1/users"},
+		{Key: "ok", Type: BoolType, Integer: 1},
+	}
+	ent := Entry{Level: InfoLevel, Time: time.Unix(0, 0), Message: "request"}
+
+	for _, bb := range []struct {
+		name   string
+		sort   bool
+		nested bool
+	}{
+		{"Default", false, false},
+		{"SortKeys", true, false},
+		{"SortNestedKeys", true, true},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			cfg := testEncoderConfig()
+			cfg.SortKeys = bb.sort
+			cfg.SortNestedKeys = bb.nested
+			enc := NewJSONEncoder(cfg)
+			enc.AddString("svc", "api")
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buf, err := enc.EncodeEntry(ent, fields)
+				if err != nil {
+					b.Fatal(err)
+				}
+				buf.Free()
+			}
+		})
+	}
+}
+
 func BenchmarkZapJSON(b *testing.B) {
 	additional := generateStringSlice(_sliceSize)
 	b.ResetTimer()
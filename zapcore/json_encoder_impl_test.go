@@ -228,6 +228,34 @@ func TestJSONEncoderObjectFields(t *testing.T) {
 				assert.NoError(t, e.AddReflected("k", map[string]string{"escape": "<&>", "loggable": "yes"}), "Unexpected error JSON-serializing a map.")
 			},
 		},
+		{
+			desc:     "reflect raw JSON",
+			expected: `"k":{"a":[1,2]}`,
+			f: func(e Encoder) {
+				assert.NoError(t, e.AddReflected("k", json.RawMessage(`{"a":[1,2]}`)), "Unexpected error adding raw JSON.")
+			},
+		},
+		{
+			desc:     "reflect raw JSON (multi-line)",
+			expected: `"k":{"a":[1,2]}`,
+			f: func(e Encoder) {
+				assert.NoError(t, e.AddReflected("k", json.RawMessage("{\n  \"a\": [1, 2]\n}")), "Unexpected error adding raw JSON.")
+			},
+		},
+		{
+			desc:     "reflect raw JSON (empty)",
+			expected: `"k":null`,
+			f: func(e Encoder) {
+				assert.NoError(t, e.AddReflected("k", json.RawMessage(nil)), "Unexpected error adding empty raw JSON.")
+			},
+		},
+		{
+			desc:     "reflect raw JSON (invalid)",
+			expected: "",
+			f: func(e Encoder) {
+				assert.Error(t, e.AddReflected("k", json.RawMessage(`{"a":`)), "Expected an error adding invalid raw JSON.")
+			},
+		},
 		{
 			desc:     "reflect (failure)",
 			expected: "",
@@ -288,6 +316,29 @@ func TestJSONEncoderObjectFields(t *testing.T) {
 	}
 }
 
+func TestJSONEncoderTrustRawJSON(t *testing.T) {
+	cfg := _defaultEncoderConfig
+	cfg.TrustRawJSON = true
+
+	tests := []struct {
+		desc     string
+		raw      json.RawMessage
+		expected string
+	}{
+		{desc: "valid", raw: json.RawMessage(`{"a":[1,2]}`), expected: `"k":{"a":[1,2]}`},
+		{desc: "not checked", raw: json.RawMessage(`{"a":`), expected: `"k":{"a":`},
+		{desc: "empty", raw: nil, expected: `"k":null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assertOutput(t, cfg, tt.expected, func(e Encoder) {
+				assert.NoError(t, e.AddReflected("k", tt.raw), "Unexpected error adding raw JSON.")
+			})
+		})
+	}
+}
+
 func TestJSONEncoderTimeFormats(t *testing.T) {
 	date := time.Date(2000, time.January, 2, 3, 4, 5, 6, time.UTC)
 
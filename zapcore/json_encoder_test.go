@@ -226,6 +226,207 @@ func (enc *emptyReflectedEncoder) Encode(obj interface{}) error {
 	return err
 }
 
+func TestJSONEncoderFlattenNamespaces(t *testing.T) {
+	row := zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddInt("id", 1)
+		enc.OpenNamespace("meta")
+		enc.AddString("table", "users")
+		return nil
+	})
+	rows := zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		return enc.AppendObject(row)
+	})
+
+	tests := []struct {
+		desc      string
+		separator string
+		with      []zapcore.Field
+		fields    []zapcore.Field
+		expected  string
+	}{
+		{
+			desc:     "no namespaces",
+			fields:   []zapcore.Field{zap.Int("rows", 3)},
+			expected: `{"level":"info","msg":"x","rows":3}`,
+		},
+		{
+			desc:     "namespace in context",
+			with:     []zapcore.Field{zap.String("svc", "api"), zap.Namespace("db")},
+			fields:   []zapcore.Field{zap.Int("rows", 3)},
+			expected: `{"level":"info","msg":"x","svc":"api","db.rows":3}`,
+		},
+		{
+			desc:     "nested namespaces",
+			with:     []zapcore.Field{zap.Namespace("db"), zap.String("name", "main")},
+			fields:   []zapcore.Field{zap.Namespace("query"), zap.Int("rows", 3)},
+			expected: `{"level":"info","msg":"x","db.name":"main","db.query.rows":3}`,
+		},
+		{
+			desc:      "custom separator",
+			separator: "_",
+			with:      []zapcore.Field{zap.Namespace("db")},
+			fields:    []zapcore.Field{zap.Namespace("query"), zap.Int("rows", 3)},
+			expected:  `{"level":"info","msg":"x","db_query_rows":3}`,
+		},
+		{
+			desc:     "objects and arrays keep their structure",
+			with:     []zapcore.Field{zap.Namespace("db")},
+			fields:   []zapcore.Field{zap.Object("row", row), zap.Array("rows", rows)},
+			expected: `{"level":"info","msg":"x","db.row":{"id":1,"meta.table":"users"},"db.rows":[{"id":1,"meta.table":"users"}]}`,
+		},
+		{
+			desc:     "escaped keys",
+			fields:   []zapcore.Field{zap.Namespace(`"q"`), zap.Int("n", 1)},
+			expected: `{"level":"info","msg":"x","\"q\".n":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+				MessageKey:         "msg",
+				LevelKey:           "level",
+				StacktraceKey:      "stacktrace",
+				EncodeLevel:        zapcore.LowercaseLevelEncoder,
+				FlattenNamespaces:  true,
+				NamespaceSeparator: tt.separator,
+				SkipLineEnding:     true,
+			})
+			for _, f := range tt.with {
+				f.AddTo(enc)
+			}
+
+			buf, err := enc.EncodeEntry(zapcore.Entry{Level: zapcore.InfoLevel, Message: "x"}, tt.fields)
+			if assert.NoError(t, err, "Unexpected JSON encoding error.") {
+				assert.Equal(t, tt.expected, buf.String(), "Incorrect encoded JSON entry.")
+			}
+			buf.Free()
+		})
+	}
+
+	t.Run("stacktrace", func(t *testing.T) {
+		enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+			MessageKey:        "msg",
+			StacktraceKey:     "stacktrace",
+			FlattenNamespaces: true,
+			SkipLineEnding:    true,
+		})
+		buf, err := enc.EncodeEntry(zapcore.Entry{Message: "x", Stack: "s"}, []zapcore.Field{
+			zap.Namespace("db"), zap.Int("rows", 3),
+		})
+		if assert.NoError(t, err, "Unexpected JSON encoding error.") {
+			assert.Equal(t, `{"msg":"x","db.rows":3,"stacktrace":"s"}`, buf.String(), "Expected the stack trace outside of the namespace.")
+		}
+		buf.Free()
+	})
+}
+
+func TestJSONEncoderSortKeys(t *testing.T) {
+	obj := zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddInt("z", 1)
+		enc.AddString("a", "x,y")
+		return enc.AddArray("m", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			return arr.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+				enc.AddBool("y", true)
+				enc.AddString("b", `"{[`)
+				return nil
+			}))
+		}))
+	})
+
+	tests := []struct {
+		desc     string
+		nested   bool
+		flatten  bool
+		with     []zapcore.Field
+		fields   []zapcore.Field
+		expected string
+	}{
+		{
+			desc:     "context and fields",
+			with:     []zapcore.Field{zap.String("svc", "api"), zap.Int("attempt", 1)},
+			fields:   []zapcore.Field{zap.Int("rows", 3), zap.String("b", "c")},
+			expected: `{"level":"info","msg":"x","attempt":1,"b":"c","rows":3,"svc":"api"}`,
+		},
+		{
+			desc:     "duplicate keys keep their order",
+			with:     []zapcore.Field{zap.Int("k", 1)},
+			fields:   []zapcore.Field{zap.String("a", "b"), zap.Int("k", 2)},
+			expected: `{"level":"info","msg":"x","a":"b","k":1,"k":2}`,
+		},
+		{
+			desc:     "nested objects unsorted",
+			fields:   []zapcore.Field{zap.Object("obj", obj), zap.Int("n", 1)},
+			expected: `{"level":"info","msg":"x","n":1,"obj":{"z":1,"a":"x,y","m":[{"y":true,"b":"\"{["}]}}`,
+		},
+		{
+			desc:     "nested objects sorted",
+			nested:   true,
+			fields:   []zapcore.Field{zap.Object("obj", obj), zap.Int("n", 1)},
+			expected: `{"level":"info","msg":"x","n":1,"obj":{"a":"x,y","m":[{"b":"\"{[","y":true}],"z":1}}`,
+		},
+		{
+			desc:     "namespaces",
+			nested:   true,
+			with:     []zapcore.Field{zap.String("svc", "api"), zap.Namespace("db")},
+			fields:   []zapcore.Field{zap.Int("rows", 3), zap.String("name", "main")},
+			expected: `{"level":"info","msg":"x","db":{"name":"main","rows":3},"svc":"api"}`,
+		},
+		{
+			desc:     "flattened namespaces",
+			flatten:  true,
+			with:     []zapcore.Field{zap.Namespace("db")},
+			fields:   []zapcore.Field{zap.Int("rows", 3), zap.String("a", "b")},
+			expected: `{"level":"info","msg":"x","db.a":"b","db.rows":3}`,
+		},
+		{
+			desc:     "no fields",
+			expected: `{"level":"info","msg":"x"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+				MessageKey:        "msg",
+				LevelKey:          "level",
+				EncodeLevel:       zapcore.LowercaseLevelEncoder,
+				FlattenNamespaces: tt.flatten,
+				SortKeys:          true,
+				SortNestedKeys:    tt.nested,
+				SkipLineEnding:    true,
+			})
+			for _, f := range tt.with {
+				f.AddTo(enc)
+			}
+
+			buf, err := enc.EncodeEntry(zapcore.Entry{Level: zapcore.InfoLevel, Message: "x"}, tt.fields)
+			if assert.NoError(t, err, "Unexpected JSON encoding error.") {
+				assert.Equal(t, tt.expected, buf.String(), "Incorrect encoded JSON entry.")
+			}
+			buf.Free()
+		})
+	}
+
+	t.Run("metadata and stacktrace stay put", func(t *testing.T) {
+		enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+			MessageKey:    "msg",
+			LevelKey:      "level",
+			TimeKey:       "ts",
+			StacktraceKey: "stacktrace",
+			EncodeLevel:   zapcore.LowercaseLevelEncoder,
+			EncodeTime:    zapcore.EpochTimeEncoder,
+			SortKeys:      true,
+		})
+		ent := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Unix(1, 0), Message: "x", Stack: "s"}
+		buf, err := enc.EncodeEntry(ent, []zapcore.Field{zap.Int("b", 2), zap.Int("a", 1)})
+		if assert.NoError(t, err, "Unexpected JSON encoding error.") {
+			assert.Equal(t, `{"level":"info","ts":1,"msg":"x","a":1,"b":2,"stacktrace":"s"}`+"\n", buf.String())
+		}
+		buf.Free()
+	})
+}
+
 func TestJSONCustomReflectedEncoder(t *testing.T) {
 	tests := []struct {
 		name     string
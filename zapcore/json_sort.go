@@ -0,0 +1,187 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zapcore
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/toujourser/zap/buffer"
+	"github.com/toujourser/zap/internal/bufferpool"
+)
+
+// sortFields sorts the fields the encoder wrote to its buffer from start by
+// key, replacing the buffer. The fields may be preceded by a comma separating
+// them from the entry's own keys. If nested is true, the keys of objects in
+// field values are sorted too.
+func (enc *jsonEncoder) sortFields(start int, nested bool) {
+	fields := enc.buf.Bytes()[start:]
+	if len(fields) == 0 {
+		return
+	}
+
+	sorted := bufferpool.Get()
+	sorted.Write(enc.buf.Bytes()[:start])
+	sep := enc.elementSeparator()
+	if fields[0] == ',' {
+		sorted.AppendString(sep)
+		fields = bytes.TrimLeft(fields[1:], " ")
+	}
+	writeSortedMembers(sorted, fields, sep, nested)
+	enc.buf.Free()
+	enc.buf = sorted
+}
+
+func (enc *jsonEncoder) elementSeparator() string {
+	if enc.spaced {
+		return ", "
+	}
+	return ","
+}
+
+// writeSortedMembers writes the comma-separated members of a JSON object,
+// without the enclosing braces, sorted by key.
+func writeSortedMembers(buf *buffer.Buffer, members []byte, sep string, nested bool) {
+	elems := splitJSONElements(members)
+	sort.SliceStable(elems, func(i, j int) bool {
+		ki, _ := jsonMemberKey(elems[i])
+		kj, _ := jsonMemberKey(elems[j])
+		return bytes.Compare(ki, kj) < 0
+	})
+	for i, m := range elems {
+		if i > 0 {
+			buf.AppendString(sep)
+		}
+		if !nested {
+			buf.AppendBytes(m)
+			continue
+		}
+		n := jsonValueStart(m)
+		buf.AppendBytes(m[:n])
+		writeSortedValue(buf, m[n:], sep)
+	}
+}
+
+// writeSortedValue writes a JSON value, sorting the keys of any objects in
+// it.
+func writeSortedValue(buf *buffer.Buffer, v []byte, sep string) {
+	if len(v) < 2 {
+		buf.AppendBytes(v)
+		return
+	}
+	switch v[0] {
+	case '{':
+		buf.AppendByte('{')
+		writeSortedMembers(buf, v[1:len(v)-1], sep, true)
+		buf.AppendByte('}')
+	case '[':
+		buf.AppendByte('[')
+		for i, elem := range splitJSONElements(v[1 : len(v)-1]) {
+			if i > 0 {
+				buf.AppendString(sep)
+			}
+			writeSortedValue(buf, elem, sep)
+		}
+		buf.AppendByte(']')
+	default:
+		buf.AppendBytes(v)
+	}
+}
+
+// splitJSONElements splits the comma-separated elements of a JSON object or
+// array, without the enclosing brackets, trimming the spaces around them.
+func splitJSONElements(b []byte) [][]byte {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil
+	}
+
+	var (
+		elems    [][]byte
+		start    int
+		depth    int
+		inString bool
+		escaped  bool
+	)
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '\x1b':
+			// Skip ANSI color sequences around keys and values.
+			for i < len(b) && b[i] != 'm' {
+				i++
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			elems = append(elems, bytes.TrimSpace(b[start:i]))
+			start = i + 1
+		}
+	}
+	return append(elems, bytes.TrimSpace(b[start:]))
+}
+
+// jsonMemberKey returns the escaped key of a JSON object member and the
+// offset of its closing quote.
+func jsonMemberKey(m []byte) (key []byte, end int) {
+	start := bytes.IndexByte(m, '"') + 1
+	if start == 0 {
+		return nil, 0
+	}
+	for i := start; i < len(m); i++ {
+		switch m[i] {
+		case '\\':
+			i++
+		case '"':
+			return m[start:i], i
+		}
+	}
+	return m[start:], len(m)
+}
+
+// jsonValueStart returns the offset of the value of a JSON object member.
+func jsonValueStart(m []byte) int {
+	_, end := jsonMemberKey(m)
+	if end >= len(m) {
+		return len(m)
+	}
+	colon := bytes.IndexByte(m[end:], ':')
+	if colon < 0 {
+		return len(m)
+	}
+	i := end + colon + 1
+	for i < len(m) && m[i] == ' ' {
+		i++
+	}
+	return i
+}
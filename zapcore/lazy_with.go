@@ -20,35 +20,81 @@
 
 package zapcore
 
-import "sync"
+import (
+	"sync"
+	"sync/atomic"
+)
 
 type lazyWithCore struct {
-	Core
+	orig Core // the wrapped Core, used until the fields are needed
 	sync.Once
+	core   Core // orig with fields added; only valid after initOnce
 	fields []Field
+
+	built atomic.Bool // whether core has been built
 }
 
+var (
+	_ Core           = (*lazyWithCore)(nil)
+	_ LeveledEnabler = (*lazyWithCore)(nil)
+)
+
 // NewLazyWith wraps a Core with a "lazy" Core that will only encode fields if
 // the logger is written to (or is further chained in a lon-lazy manner).
+//
+// The fields are added to the wrapped Core at most once, on the first call
+// to With or on the first call to Check for an enabled level. It's safe to
+// use the returned Core from multiple goroutines concurrently.
 func NewLazyWith(core Core, fields []Field) Core {
 	return &lazyWithCore{
-		Core:   core,
+		orig:   core,
 		fields: fields,
 	}
 }
 
 func (d *lazyWithCore) initOnce() {
 	d.Once.Do(func() {
-		d.Core = d.Core.With(d.fields)
+		d.core = d.orig.With(d.fields)
+		d.built.Store(true)
 	})
 }
 
+func (d *lazyWithCore) Enabled(lvl Level) bool {
+	// Adding fields doesn't change the level, so there's no need to
+	// evaluate them just to answer this.
+	return d.orig.Enabled(lvl)
+}
+
+func (d *lazyWithCore) Level() Level {
+	return LevelOf(d.orig)
+}
+
 func (d *lazyWithCore) With(fields []Field) Core {
 	d.initOnce()
-	return d.Core.With(fields)
+	return d.core.With(fields)
 }
 
 func (d *lazyWithCore) Check(e Entry, ce *CheckedEntry) *CheckedEntry {
+	// Entries that won't be logged don't need the fields, so skip
+	// evaluating them.
+	if !d.orig.Enabled(e.Level) {
+		return ce
+	}
+	d.initOnce()
+	return d.core.Check(e, ce)
+}
+
+func (d *lazyWithCore) Write(e Entry, fields []Field) error {
 	d.initOnce()
-	return d.Core.Check(e, ce)
+	return d.core.Write(e, fields)
+}
+
+func (d *lazyWithCore) Sync() error {
+	// Sync the Core that entries were written to, if any. Building it just
+	// to sync it isn't worth evaluating the fields, so orig is synced
+	// otherwise.
+	if d.built.Load() {
+		return d.core.Sync()
+	}
+	return d.orig.Sync()
 }
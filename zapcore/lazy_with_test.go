@@ -152,3 +152,52 @@ func TestLazyCore(t *testing.T) {
 		})
 	}
 }
+
+func TestLazyCoreDisabledLevelsSkipWith(t *testing.T) {
+	withLazyCore(func(lazy zapcore.Core, proxy *proxyCore, logs *observer.ObservedLogs) {
+		assert.Equal(t, zapcore.InfoLevel, zapcore.LevelOf(lazy), "Unexpected level.")
+		assert.False(t, lazy.Enabled(zapcore.DebugLevel), "Debug should be disabled.")
+
+		ce := lazy.Check(zapcore.Entry{Level: zapcore.DebugLevel, Message: "debug"}, nil)
+		assert.Nil(t, ce, "Expected disabled entry to be dropped.")
+		assert.NoError(t, lazy.Sync(), "Unexpected error syncing.")
+		assert.Zero(t, proxy.withCount.Load(), "Expected fields to stay unevaluated for disabled levels.")
+		assert.Zero(t, logs.Len(), "Expected no logs.")
+	}, makeInt64Field("a", 1))
+}
+
+// syncRecordingCore records its Syncs, and the Cores built from it with With.
+type syncRecordingCore struct {
+	zapcore.Core
+
+	syncs    atomic.Int64
+	children []*syncRecordingCore
+}
+
+func (c *syncRecordingCore) With(fields []zapcore.Field) zapcore.Core {
+	child := &syncRecordingCore{Core: c.Core.With(fields)}
+	c.children = append(c.children, child)
+	return child
+}
+
+func (c *syncRecordingCore) Sync() error {
+	c.syncs.Add(1)
+	return c.Core.Sync()
+}
+
+func TestLazyCoreSyncsBuiltCore(t *testing.T) {
+	obs, _ := observer.New(zapcore.InfoLevel)
+	orig := &syncRecordingCore{Core: obs}
+	lazy := zapcore.NewLazyWith(orig, []zapcore.Field{makeInt64Field("a", 1)})
+
+	assert.NoError(t, lazy.Sync(), "Unexpected error syncing.")
+	assert.Equal(t, int64(1), orig.syncs.Load(), "Expected the original Core to be synced before any writes.")
+	assert.Empty(t, orig.children, "Expected Sync not to evaluate the fields.")
+
+	assert.NoError(t, lazy.Write(zapcore.Entry{Level: zapcore.InfoLevel}, nil), "Unexpected error writing.")
+	assert.NoError(t, lazy.Sync(), "Unexpected error syncing.")
+	if assert.Len(t, orig.children, 1, "Expected the fields to be added once.") {
+		assert.Equal(t, int64(1), orig.children[0].syncs.Load(), "Expected the Core written to to be synced.")
+	}
+	assert.Equal(t, int64(1), orig.syncs.Load(), "Expected the original Core not to be synced again.")
+}
@@ -32,9 +32,13 @@ var errUnmarshalNilLevel = errors.New("can't unmarshal a nil *Level")
 type Level int8
 
 const (
+	// TraceLevel logs are finer-grained than Debug logs, for extremely
+	// chatty internals. They're disabled unless a logger's level is
+	// explicitly set to TraceLevel.
+	TraceLevel Level = iota - 2
 	// DebugLevel logs are typically voluminous, and are usually disabled in
 	// production.
-	DebugLevel Level = iota - 1
+	DebugLevel
 	// InfoLevel is the default logging priority.
 	InfoLevel
 	// WarnLevel logs are more important than Info, but don't need individual
@@ -51,7 +55,7 @@ const (
 	// FatalLevel logs a message, then calls os.Exit(1).
 	FatalLevel
 
-	_minLevel = DebugLevel
+	_minLevel = TraceLevel
 	_maxLevel = FatalLevel
 
 	// InvalidLevel is an invalid value for Level.
@@ -72,9 +76,24 @@ func ParseLevel(text string) (Level, error) {
 	return level, err
 }
 
-type leveledEnabler interface {
+// EntryEnabler is a LevelEnabler that can also decide, based on the rest of
+// the entry, whether to log it. Cores built by NewCore honor it, as do
+// samplers wrapping them: they consult EnabledFor only for entries whose
+// level is enabled, so it can assume that Enabled(ent.Level) is true.
+type EntryEnabler interface {
 	LevelEnabler
 
+	// EnabledFor reports whether the entry should be logged.
+	EnabledFor(Entry) bool
+}
+
+// LeveledEnabler is a LevelEnabler that can report its own minimum enabled
+// level. All of Zap's built-in Cores implement it, and Cores that wrap other
+// Cores should implement it to let LevelOf see through them.
+type LeveledEnabler interface {
+	LevelEnabler
+
+	// Level reports the minimum enabled level.
 	Level() Level
 }
 
@@ -82,8 +101,8 @@ type leveledEnabler interface {
 // from Zap's supported log levels, or [InvalidLevel] if none of them are
 // enabled.
 //
-// A LevelEnabler may implement a 'Level() Level' method to override the
-// behavior of this function.
+// A LevelEnabler may implement [LeveledEnabler] to override the behavior of
+// this function.
 //
 //	func (c *core) Level() Level {
 //		return c.currentLevel
@@ -96,7 +115,7 @@ type leveledEnabler interface {
 //		return zapcore.LevelOf(c.wrappedCore)
 //	}
 func LevelOf(enab LevelEnabler) Level {
-	if lvler, ok := enab.(leveledEnabler); ok {
+	if lvler, ok := enab.(LeveledEnabler); ok {
 		return lvler.Level()
 	}
 
@@ -112,6 +131,8 @@ func LevelOf(enab LevelEnabler) Level {
 // String returns a lower-case ASCII representation of the log level.
 func (l Level) String() string {
 	switch l {
+	case TraceLevel:
+		return "trace"
 	case DebugLevel:
 		return "debug"
 	case InfoLevel:
@@ -136,6 +157,8 @@ func (l Level) CapitalString() string {
 	// Printing levels in all-caps is common enough that we should export this
 	// functionality.
 	switch l {
+	case TraceLevel:
+		return "TRACE"
 	case DebugLevel:
 		return "DEBUG"
 	case InfoLevel:
@@ -155,6 +178,29 @@ func (l Level) CapitalString() string {
 	}
 }
 
+// SyslogSeverity returns the RFC 5424 severity of the log level, as used by
+// syslog, GELF, and journald. Unknown levels are informational.
+func (l Level) SyslogSeverity() int {
+	switch l {
+	case TraceLevel, DebugLevel:
+		return 7 // debug
+	case InfoLevel:
+		return 6 // informational
+	case WarnLevel:
+		return 4 // warning
+	case ErrorLevel:
+		return 3 // error
+	case DPanicLevel:
+		return 2 // critical
+	case PanicLevel:
+		return 1 // alert
+	case FatalLevel:
+		return 0 // emergency
+	default:
+		return 6 // informational
+	}
+}
+
 // MarshalText marshals the Level to text. Note that the text representation
 // drops the -Level suffix (see example).
 func (l Level) MarshalText() ([]byte, error) {
@@ -179,6 +225,8 @@ func (l *Level) UnmarshalText(text []byte) error {
 
 func (l *Level) unmarshalText(text []byte) bool {
 	switch string(text) {
+	case "trace":
+		*l = TraceLevel
 	case "debug":
 		*l = DebugLevel
 	case "info", "": // make the zero value useful
@@ -223,7 +271,7 @@ func (l Level) Enabled(lvl Level) bool {
 // Each concrete Level value implements a static LevelEnabler which returns
 // true for itself and all higher logging levels. For example WarnLevel.Enabled()
 // will return true for WarnLevel, ErrorLevel, DPanicLevel, PanicLevel, and
-// FatalLevel, but return false for InfoLevel and DebugLevel.
+// FatalLevel, but return false for InfoLevel, DebugLevel, and TraceLevel.
 type LevelEnabler interface {
 	Enabled(Level) bool
 }
@@ -31,6 +31,7 @@ import (
 
 func TestLevelString(t *testing.T) {
 	tests := map[Level]string{
+		TraceLevel:   "trace",
 		DebugLevel:   "debug",
 		InfoLevel:    "info",
 		WarnLevel:    "warn",
@@ -48,11 +49,30 @@ func TestLevelString(t *testing.T) {
 	}
 }
 
+func TestLevelSyslogSeverity(t *testing.T) {
+	tests := map[Level]int{
+		TraceLevel:  7,
+		DebugLevel:  7,
+		InfoLevel:   6,
+		WarnLevel:   4,
+		ErrorLevel:  3,
+		DPanicLevel: 2,
+		PanicLevel:  1,
+		FatalLevel:  0,
+		Level(-42):  6,
+	}
+
+	for lvl, severity := range tests {
+		assert.Equal(t, severity, lvl.SyslogSeverity(), "Unexpected severity for %v.", lvl)
+	}
+}
+
 func TestLevelText(t *testing.T) {
 	tests := []struct {
 		text  string
 		level Level
 	}{
+		{"trace", TraceLevel},
 		{"debug", DebugLevel},
 		{"info", InfoLevel},
 		{"", InfoLevel}, // make the zero value useful
@@ -114,6 +134,7 @@ func TestCapitalLevelsParse(t *testing.T) {
 		text  string
 		level Level
 	}{
+		{"TRACE", TraceLevel},
 		{"DEBUG", DebugLevel},
 		{"INFO", InfoLevel},
 		{"WARN", WarnLevel},
@@ -192,7 +213,7 @@ func TestLevelAsFlagValue(t *testing.T) {
 	fs.SetOutput(&buf)
 	fs.Var(&lvl, "level", "log level")
 
-	for _, expected := range []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, PanicLevel, FatalLevel} {
+	for _, expected := range []Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, PanicLevel, FatalLevel} {
 		assert.NoError(t, fs.Parse([]string{"-level", expected.String()}))
 		assert.Equal(t, expected, lvl, "Unexpected level after parsing flag.")
 		assert.Equal(t, expected, lvl.Get(), "Unexpected output using flag.Getter API.")
@@ -213,7 +234,7 @@ func TestLevelAsFlagValue(t *testing.T) {
 // method.
 type enablerWithCustomLevel struct{ lvl Level }
 
-var _ leveledEnabler = (*enablerWithCustomLevel)(nil)
+var _ LeveledEnabler = (*enablerWithCustomLevel)(nil)
 
 func (l *enablerWithCustomLevel) Enabled(lvl Level) bool {
 	return l.lvl.Enabled(lvl)
@@ -237,7 +258,7 @@ func TestLevelOf(t *testing.T) {
 		{desc: "panic", give: PanicLevel, want: PanicLevel},
 		{desc: "fatal", give: FatalLevel, want: FatalLevel},
 		{
-			desc: "leveledEnabler",
+			desc: "LeveledEnabler",
 			give: &enablerWithCustomLevel{lvl: InfoLevel},
 			want: InfoLevel,
 		},
@@ -0,0 +1,465 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// DroppedFieldsKey is the key of the field that a Core built with
+// NewFieldLimitCore adds to entries to report how many fields it dropped.
+const DroppedFieldsKey = "droppedFields"
+
+// DefaultTruncationMarker is appended to strings truncated by a Core built
+// with NewTruncatingCore if no marker is given.
+const DefaultTruncationMarker = "…"
+
+type fieldLimitCore struct {
+	core Core
+	max  int
+
+	// count and dropped are the numbers of context fields added with With
+	// that were kept and dropped.
+	count   int
+	dropped int
+}
+
+var (
+	_ Core           = (*fieldLimitCore)(nil)
+	_ LeveledEnabler = (*fieldLimitCore)(nil)
+)
+
+// NewFieldLimitCore wraps a Core, keeping at most max fields on each entry,
+// counting both the fields added with With and the fields passed to each
+// log call, in that order. Fields beyond the limit are dropped, and entries
+// that lost fields get an additional DroppedFieldsKey field holding the
+// number of fields dropped. A negative max is treated as zero.
+//
+// Fields added to the wrapped Core before it was wrapped aren't counted.
+func NewFieldLimitCore(core Core, max int) Core {
+	if max < 0 {
+		max = 0
+	}
+	return &fieldLimitCore{core: core, max: max}
+}
+
+func (c *fieldLimitCore) Enabled(lvl Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *fieldLimitCore) Level() Level {
+	return LevelOf(c.core)
+}
+
+func (c *fieldLimitCore) With(fields []Field) Core {
+	kept, dropped := c.limit(c.count, fields)
+	return &fieldLimitCore{
+		core:    c.core.With(kept),
+		max:     c.max,
+		count:   c.count + len(kept),
+		dropped: c.dropped + dropped,
+	}
+}
+
+func (c *fieldLimitCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *fieldLimitCore) Write(ent Entry, fields []Field) error {
+	kept, dropped := c.limit(c.count, fields)
+	if dropped += c.dropped; dropped > 0 {
+		fields = make([]Field, len(kept), len(kept)+1)
+		copy(fields, kept)
+		fields = append(fields, Field{Key: DroppedFieldsKey, Type: Int64Type, Integer: int64(dropped)})
+	}
+	return checkAndWrite(c.core, ent, fields)
+}
+
+func (c *fieldLimitCore) Sync() error {
+	return c.core.Sync()
+}
+
+// limit returns the fields that fit within the limit after count fields,
+// and the number of fields that don't.
+func (c *fieldLimitCore) limit(count int, fields []Field) (kept []Field, dropped int) {
+	room := c.max - count
+	if room < 0 {
+		room = 0
+	}
+	if len(fields) <= room {
+		return fields, 0
+	}
+	return fields[:room:room], len(fields) - room
+}
+
+type truncatingCore struct {
+	core Core
+	t    *truncator
+}
+
+var (
+	_ Core           = (*truncatingCore)(nil)
+	_ LeveledEnabler = (*truncatingCore)(nil)
+)
+
+// NewTruncatingCore wraps a Core, truncating string values longer than
+// maxLen bytes in the fields added with With and passed to each log call.
+// It truncates String, ByteString, and Error fields, and the strings in the
+// objects and arrays of ObjectMarshaler, InlineMarshaler, and
+// ArrayMarshaler fields.
+//
+// Truncated values keep their first maxLen bytes, shortened as needed so
+// that no UTF-8 sequence is split, followed by the marker and the amount
+// removed, for example
+//
+//	"aaaa…(truncated 39MB)"
+//
+// If marker is empty, DefaultTruncationMarker is used. A negative maxLen is
+// treated as zero.
+func NewTruncatingCore(core Core, maxLen int, marker string) Core {
+	if maxLen < 0 {
+		maxLen = 0
+	}
+	if marker == "" {
+		marker = DefaultTruncationMarker
+	}
+	return &truncatingCore{
+		core: core,
+		t:    &truncator{max: maxLen, marker: marker},
+	}
+}
+
+func (c *truncatingCore) Enabled(lvl Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *truncatingCore) Level() Level {
+	return LevelOf(c.core)
+}
+
+func (c *truncatingCore) With(fields []Field) Core {
+	return &truncatingCore{
+		core: c.core.With(c.t.fields(fields)),
+		t:    c.t,
+	}
+}
+
+func (c *truncatingCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *truncatingCore) Write(ent Entry, fields []Field) error {
+	return checkAndWrite(c.core, ent, c.t.fields(fields))
+}
+
+func (c *truncatingCore) Sync() error {
+	return c.core.Sync()
+}
+
+// truncator truncates strings longer than max bytes.
+type truncator struct {
+	max    int
+	marker string
+}
+
+// fields returns the fields with their values truncated, copying the slice
+// only if a field changes.
+func (t *truncator) fields(fields []Field) []Field {
+	var out []Field
+	for i, f := range fields {
+		tf, changed := t.field(f)
+		if !changed {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]Field, i, len(fields))
+			copy(out, fields[:i])
+		}
+		out = append(out, tf)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+func (t *truncator) field(f Field) (Field, bool) {
+	switch f.Type {
+	case StringType:
+		if len(f.String) <= t.max {
+			return f, false
+		}
+		f.String = t.truncate(f.String)
+	case ByteStringType:
+		bs := f.Interface.([]byte)
+		if len(bs) <= t.max {
+			return f, false
+		}
+		f.Interface = t.truncateBytes(bs)
+	case ErrorType:
+		err, ok := t.error(f.Interface.(error))
+		if !ok {
+			return f, false
+		}
+		f.Interface = err
+	case ObjectMarshalerType, InlineMarshalerType:
+		f.Interface = truncatingObject{obj: f.Interface.(ObjectMarshaler), t: t}
+	case ArrayMarshalerType:
+		f.Interface = truncatingArray{arr: f.Interface.(ArrayMarshaler), t: t}
+	case OmitEmptyType:
+		inner, ok := t.field(f.Interface.(Field))
+		if !ok {
+			return f, false
+		}
+		f.Interface = inner
+	default:
+		return f, false
+	}
+	return f, true
+}
+
+func (t *truncator) truncate(s string) string {
+	if len(s) <= t.max {
+		return s
+	}
+	cut := truncationPoint(s, t.max)
+	return s[:cut] + t.marker + "(truncated " + formatByteCount(len(s)-cut) + ")"
+}
+
+func (t *truncator) truncateBytes(bs []byte) []byte {
+	if len(bs) <= t.max {
+		return bs
+	}
+	cut := truncationPoint(bs, t.max)
+	out := make([]byte, 0, cut+len(t.marker)+24)
+	out = append(out, bs[:cut]...)
+	out = append(out, t.marker...)
+	out = append(out, "(truncated "...)
+	out = append(out, formatByteCount(len(bs)-cut)...)
+	return append(out, ')')
+}
+
+// truncationPoint returns the length to which s is truncated: at most max
+// bytes, without splitting a UTF-8 sequence. s must be longer than max bytes.
+func truncationPoint[S string | []byte](s S, max int) int {
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return cut
+}
+
+// error returns err with its messages truncated, and whether any of them
+// were too long.
+func (t *truncator) error(err error) (error, bool) {
+	basic, verbose, ok := errorStrings(err)
+	if !ok {
+		// Leave errors that panic to encodeError, which reports them.
+		return err, false
+	}
+
+	var (
+		causes    []error
+		truncated = len(basic) > t.max || len(verbose) > t.max
+	)
+	if group, ok := err.(errorGroup); ok {
+		causes = group.Errors()
+		copied := false
+		for i, cause := range causes {
+			if cause == nil {
+				continue
+			}
+			if tc, ok := t.error(cause); ok {
+				if !copied {
+					// Don't modify the slice owned by the error.
+					causes = append([]error(nil), causes...)
+					copied = true
+				}
+				causes[i] = tc
+				truncated = true
+			}
+		}
+	}
+	if !truncated {
+		return err, false
+	}
+
+	te := &truncatedError{
+		err:     err,
+		basic:   t.truncate(basic),
+		verbose: t.truncate(verbose),
+	}
+	if causes != nil {
+		return &truncatedErrorGroup{truncatedError: te, causes: causes}, true
+	}
+	return te, true
+}
+
+// errorStrings returns the message of err and, if it's a fmt.Formatter
+// with a different verbose form, its verbose message. It reports false if
+// producing them panics.
+func errorStrings(err error) (basic, verbose string, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	basic = err.Error()
+	if f, isFormatter := err.(fmt.Formatter); isFormatter {
+		if verbose = fmt.Sprintf("%+v", f); verbose == basic {
+			verbose = ""
+		}
+	}
+	return basic, verbose, true
+}
+
+// truncatedError is an error whose messages were truncated.
+type truncatedError struct {
+	err     error
+	basic   string
+	verbose string
+}
+
+func (e *truncatedError) Error() string { return e.basic }
+func (e *truncatedError) Unwrap() error { return e.err }
+
+func (e *truncatedError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') && e.verbose != "" {
+		_, _ = io.WriteString(s, e.verbose)
+		return
+	}
+	_, _ = io.WriteString(s, e.basic)
+}
+
+// truncatedErrorGroup is a truncatedError for an error that holds a list of
+// errors, such as those built by go.uber.org/multierr.
+type truncatedErrorGroup struct {
+	*truncatedError
+	causes []error
+}
+
+func (e *truncatedErrorGroup) Errors() []error { return e.causes }
+
+// truncatingObject truncates the strings that an ObjectMarshaler adds.
+type truncatingObject struct {
+	obj ObjectMarshaler
+	t   *truncator
+}
+
+func (o truncatingObject) MarshalLogObject(enc ObjectEncoder) error {
+	return o.obj.MarshalLogObject(truncatingObjectEncoder{ObjectEncoder: enc, t: o.t})
+}
+
+// truncatingArray truncates the strings that an ArrayMarshaler appends.
+type truncatingArray struct {
+	arr ArrayMarshaler
+	t   *truncator
+}
+
+func (a truncatingArray) MarshalLogArray(enc ArrayEncoder) error {
+	return a.arr.MarshalLogArray(truncatingArrayEncoder{ArrayEncoder: enc, t: a.t})
+}
+
+type truncatingObjectEncoder struct {
+	ObjectEncoder
+	t *truncator
+}
+
+func (e truncatingObjectEncoder) AddString(key, val string) {
+	e.ObjectEncoder.AddString(key, e.t.truncate(val))
+}
+
+func (e truncatingObjectEncoder) AddByteString(key string, val []byte) {
+	e.ObjectEncoder.AddByteString(key, e.t.truncateBytes(val))
+}
+
+func (e truncatingObjectEncoder) AddObject(key string, obj ObjectMarshaler) error {
+	return e.ObjectEncoder.AddObject(key, truncatingObject{obj: obj, t: e.t})
+}
+
+func (e truncatingObjectEncoder) AddArray(key string, arr ArrayMarshaler) error {
+	return e.ObjectEncoder.AddArray(key, truncatingArray{arr: arr, t: e.t})
+}
+
+func (e truncatingObjectEncoder) revealSecrets() bool {
+	return shouldRevealSecrets(e.ObjectEncoder)
+}
+
+func (e truncatingObjectEncoder) reportPanics() bool {
+	return shouldReportPanics(e.ObjectEncoder)
+}
+
+type truncatingArrayEncoder struct {
+	ArrayEncoder
+	t *truncator
+}
+
+func (e truncatingArrayEncoder) AppendString(val string) {
+	e.ArrayEncoder.AppendString(e.t.truncate(val))
+}
+
+func (e truncatingArrayEncoder) AppendByteString(val []byte) {
+	e.ArrayEncoder.AppendByteString(e.t.truncateBytes(val))
+}
+
+func (e truncatingArrayEncoder) AppendObject(obj ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(truncatingObject{obj: obj, t: e.t})
+}
+
+func (e truncatingArrayEncoder) AppendArray(arr ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(truncatingArray{arr: arr, t: e.t})
+}
+
+func (e truncatingArrayEncoder) revealSecrets() bool {
+	return shouldRevealSecrets(e.ArrayEncoder)
+}
+
+func (e truncatingArrayEncoder) reportPanics() bool {
+	return shouldReportPanics(e.ArrayEncoder)
+}
+
+// formatByteCount formats a number of bytes with a binary unit, rounding
+// down, for example "512B", "3KB", or "39MB".
+func formatByteCount(n int) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.Itoa(n) + "B"
+	}
+	div, exp := unit, 0
+	for n/div >= unit && exp < 2 {
+		div *= unit
+		exp++
+	}
+	return strconv.Itoa(n/div) + string("KMG"[exp]) + "B"
+}
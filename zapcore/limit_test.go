@@ -0,0 +1,301 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+
+	"github.com/toujourser/zap/zaptest/observer"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+)
+
+func TestFieldLimitCore(t *testing.T) {
+	field := func(key string) Field {
+		return Field{Key: key, Type: Int64Type, Integer: 1}
+	}
+	dropped := func(n int64) Field {
+		return Field{Key: DroppedFieldsKey, Type: Int64Type, Integer: n}
+	}
+
+	tests := []struct {
+		desc        string
+		max         int
+		with        [][]Field
+		fields      []Field
+		wantContext []Field
+	}{
+		{
+			desc:        "under the limit",
+			max:         3,
+			with:        [][]Field{{field("a")}},
+			fields:      []Field{field("b"), field("c")},
+			wantContext: []Field{field("a"), field("b"), field("c")},
+		},
+		{
+			desc:        "call fields over the limit",
+			max:         2,
+			fields:      []Field{field("a"), field("b"), field("c"), field("d")},
+			wantContext: []Field{field("a"), field("b"), dropped(2)},
+		},
+		{
+			desc:        "context fields over the limit",
+			max:         2,
+			with:        [][]Field{{field("a")}, {field("b"), field("c")}},
+			fields:      []Field{field("d")},
+			wantContext: []Field{field("a"), field("b"), dropped(2)},
+		},
+		{
+			desc:        "zero",
+			max:         0,
+			with:        [][]Field{{field("a")}},
+			fields:      []Field{field("b")},
+			wantContext: []Field{dropped(2)},
+		},
+		{
+			desc:        "negative",
+			max:         -1,
+			fields:      []Field{field("a")},
+			wantContext: []Field{dropped(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			inner, logs := observer.New(InfoLevel)
+			core := NewFieldLimitCore(inner, tt.max)
+			for _, fields := range tt.with {
+				core = core.With(fields)
+			}
+
+			fields := append([]Field(nil), tt.fields...)
+			if ce := core.Check(Entry{Level: InfoLevel, Message: "msg"}, nil); ce != nil {
+				ce.Write(fields...)
+			}
+			assert.Equal(t, tt.fields, fields, "Fields passed to Write must not be modified.")
+
+			require.Equal(t, 1, logs.Len(), "Expected one entry.")
+			assert.Equal(t, tt.wantContext, logs.All()[0].Context, "Unexpected fields.")
+		})
+	}
+
+	t.Run("disabled levels", func(t *testing.T) {
+		inner, logs := observer.New(InfoLevel)
+		core := NewFieldLimitCore(inner, 1)
+		assert.False(t, core.Enabled(DebugLevel), "Expected the inner core's level.")
+		assert.Nil(t, core.Check(Entry{Level: DebugLevel}, nil), "Expected disabled entries to be dropped.")
+		assert.Equal(t, InfoLevel, LevelOf(core), "Unexpected level.")
+		assert.NoError(t, core.Sync(), "Unexpected error syncing.")
+		assert.Zero(t, logs.Len(), "Expected no entries.")
+	})
+}
+
+type bigObject struct {
+	name string
+	tags []string
+	next *bigObject
+}
+
+func (o *bigObject) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("name", o.name)
+	enc.AddByteString("raw", []byte(o.name))
+	if err := enc.AddArray("tags", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+		for _, tag := range o.tags {
+			arr.AppendString(tag)
+		}
+		arr.AppendByteString([]byte(o.name))
+		return arr.AppendObject(ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+			enc.AddString("in", o.name)
+			return nil
+		}))
+	})); err != nil {
+		return err
+	}
+	if o.next != nil {
+		return enc.AddObject("next", o.next)
+	}
+	return nil
+}
+
+func TestTruncatingCore(t *testing.T) {
+	const long = "0123456789abcdef"
+
+	tests := []struct {
+		desc   string
+		field  Field
+		want   map[string]interface{}
+		marker string
+	}{
+		{
+			desc:  "short string",
+			field: Field{Key: "k", Type: StringType, String: "01234567"},
+			want:  map[string]interface{}{"k": "01234567"},
+		},
+		{
+			desc:  "long string",
+			field: Field{Key: "k", Type: StringType, String: long},
+			want:  map[string]interface{}{"k": "01234567…(truncated 8B)"},
+		},
+		{
+			desc:   "custom marker",
+			marker: " [...]",
+			field:  Field{Key: "k", Type: StringType, String: long},
+			want:   map[string]interface{}{"k": "01234567 [...](truncated 8B)"},
+		},
+		{
+			desc:  "multi-byte runes",
+			field: Field{Key: "k", Type: StringType, String: "日本語のテキスト"},
+			want:  map[string]interface{}{"k": "日本…(truncated 18B)"},
+		},
+		{
+			desc:  "large string",
+			field: Field{Key: "k", Type: StringType, String: strings.Repeat("x", 3<<20)},
+			want:  map[string]interface{}{"k": "xxxxxxxx…(truncated 2MB)"},
+		},
+		{
+			desc:  "byte string",
+			field: Field{Key: "k", Type: ByteStringType, Interface: []byte("日本語のテキスト")},
+			want:  map[string]interface{}{"k": "日本…(truncated 18B)"},
+		},
+		{
+			desc:  "error",
+			field: Field{Key: "error", Type: ErrorType, Interface: errTooFewUsers(12345)},
+			want: map[string]interface{}{
+				"error":        "12345 to…(truncated 11B)",
+				"errorVerbose": "verbose:…(truncated 20B)",
+			},
+		},
+		{
+			desc:  "short error",
+			field: Field{Key: "error", Type: ErrorType, Interface: errors.New("short")},
+			want:  map[string]interface{}{"error": "short"},
+		},
+		{
+			desc: "error group",
+			field: Field{Key: "error", Type: ErrorType, Interface: multierr.Combine(
+				errors.New("short"),
+				errors.New(long),
+			)},
+			want: map[string]interface{}{
+				"error": "short; 0…(truncated 15B)",
+				"errorCauses": []interface{}{
+					map[string]interface{}{"error": "short"},
+					map[string]interface{}{"error": "01234567…(truncated 8B)"},
+				},
+			},
+		},
+		{
+			desc: "nested objects",
+			field: Field{Key: "obj", Type: ObjectMarshalerType, Interface: &bigObject{
+				name: "short",
+				tags: []string{"a", long},
+				next: &bigObject{name: long},
+			}},
+			want: map[string]interface{}{"obj": map[string]interface{}{
+				"name": "short",
+				"raw":  "short",
+				"tags": []interface{}{"a", "01234567…(truncated 8B)", "short", map[string]interface{}{"in": "short"}},
+				"next": map[string]interface{}{
+					"name": "01234567…(truncated 8B)",
+					"raw":  "01234567…(truncated 8B)",
+					"tags": []interface{}{
+						"01234567…(truncated 8B)",
+						map[string]interface{}{"in": "01234567…(truncated 8B)"},
+					},
+				},
+			}},
+		},
+		{
+			desc:  "inline object",
+			field: Field{Type: InlineMarshalerType, Interface: &bigObject{name: long}},
+			want: map[string]interface{}{
+				"name": "01234567…(truncated 8B)",
+				"raw":  "01234567…(truncated 8B)",
+				"tags": []interface{}{
+					"01234567…(truncated 8B)",
+					map[string]interface{}{"in": "01234567…(truncated 8B)"},
+				},
+			},
+		},
+		{
+			desc: "array",
+			field: Field{Key: "arr", Type: ArrayMarshalerType, Interface: ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+				arr.AppendString(long)
+				return arr.AppendArray(ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+					arr.AppendString(long)
+					return nil
+				}))
+			})},
+			want: map[string]interface{}{"arr": []interface{}{
+				"01234567…(truncated 8B)",
+				[]interface{}{"01234567…(truncated 8B)"},
+			}},
+		},
+		{
+			desc:  "other types",
+			field: Field{Key: "k", Type: Int64Type, Integer: 1234567890123},
+			want:  map[string]interface{}{"k": int64(1234567890123)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			for _, with := range []bool{false, true} {
+				inner, logs := observer.New(InfoLevel)
+				core := NewTruncatingCore(inner, 8, tt.marker)
+
+				var fields []Field
+				if with {
+					core = core.With([]Field{tt.field})
+				} else {
+					fields = []Field{tt.field}
+				}
+				if ce := core.Check(Entry{Level: InfoLevel}, nil); ce != nil {
+					ce.Write(fields...)
+				}
+
+				require.Equal(t, 1, logs.Len(), "Expected one entry.")
+				assert.Equal(t, tt.want, logs.All()[0].ContextMap(), "Unexpected fields (with=%v).", with)
+			}
+		})
+	}
+}
+
+func TestTruncatingCoreLeavesFieldsUnchanged(t *testing.T) {
+	inner, logs := observer.New(InfoLevel)
+	core := NewTruncatingCore(inner, 4, "")
+
+	short := Field{Key: "short", Type: StringType, String: "abc"}
+	fields := []Field{short, {Key: "long", Type: StringType, String: "abcdef"}}
+	original := append([]Field(nil), fields...)
+
+	require.NoError(t, core.Write(Entry{Level: InfoLevel}, fields), "Unexpected error writing.")
+	assert.Equal(t, original, fields, "Fields passed to Write must not be modified.")
+	assert.Equal(t, []Field{short, {Key: "long", Type: StringType, String: "abcd…(truncated 2B)"}},
+		logs.All()[0].Context, "Unexpected fields.")
+}
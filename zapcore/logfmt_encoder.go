@@ -0,0 +1,633 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"encoding/base64"
+	"math"
+	"time"
+	"unicode/utf8"
+
+	"github.com/toujourser/zap/buffer"
+	"github.com/toujourser/zap/internal/bufferpool"
+	"github.com/toujourser/zap/internal/color"
+	"github.com/toujourser/zap/internal/pool"
+)
+
+var (
+	_logfmtPool = pool.New(func() *logfmtEncoder {
+		return &logfmtEncoder{}
+	})
+	_logfmtArrayPool = pool.New(func() *logfmtArrayEncoder {
+		return &logfmtArrayEncoder{}
+	})
+)
+
+func putLogfmtEncoder(enc *logfmtEncoder) {
+	if enc.reflectBuf != nil {
+		enc.reflectBuf.Free()
+	}
+	enc.EncoderConfig = nil
+	enc.buf = nil
+	enc.prefix = ""
+	enc.keyColor = 0
+	enc.reflectBuf = nil
+	enc.reflectEnc = nil
+	_logfmtPool.Put(enc)
+}
+
+type logfmtEncoder struct {
+	*EncoderConfig
+	buf *buffer.Buffer
+
+	// prefix is prepended to every key. It's extended by OpenNamespace and,
+	// temporarily, by AddObject and AddArray to flatten nested values.
+	prefix string
+
+	// keyColor, if non-zero, colors keys for the console encoder.
+	keyColor color.Color
+
+	// for encoding generic values by reflection
+	reflectBuf *buffer.Buffer
+	reflectEnc ReflectedEncoder
+}
+
+// NewLogfmtEncoder creates an encoder that serializes entries as logfmt: a
+// single line of space-separated key=value pairs, for example
+//
+//	ts=1.5e+09 level=info msg="hello world" user=alice
+//
+// Keys are taken from the EncoderConfig, and its level, time, duration,
+// caller, and name encoders are honored. Values that contain spaces, equal
+// signs, quotes, or control characters are quoted and escaped.
+//
+// Since logfmt has no notion of nesting, objects, arrays, and namespaces are
+// flattened into dotted keys: zap.Namespace("db") followed by
+// zap.Int("rows", 3) produces db.rows=3, and an array field "ids" produces
+// ids.0=..., ids.1=..., and so on.
+func NewLogfmtEncoder(cfg EncoderConfig) Encoder {
+	return newLogfmtEncoder(cfg)
+}
+
+func newLogfmtEncoder(cfg EncoderConfig) *logfmtEncoder {
+	if cfg.SkipLineEnding {
+		cfg.LineEnding = ""
+	} else if cfg.LineEnding == "" {
+		cfg.LineEnding = DefaultLineEnding
+	}
+
+	// If no EncoderConfig.NewReflectedEncoder is provided by the user, then use default
+	if cfg.NewReflectedEncoder == nil {
+		cfg.NewReflectedEncoder = defaultReflectedEncoder
+	}
+
+	return &logfmtEncoder{
+		EncoderConfig: &cfg,
+		buf:           bufferpool.Get(),
+	}
+}
+
+func (enc *logfmtEncoder) AddArray(key string, arr ArrayMarshaler) (retErr error) {
+	ae := enc.arrayEncoder(key, true /* indexed */)
+	defer putLogfmtArrayEncoder(ae)
+	if !enc.PropagateMarshalerPanics {
+		start := enc.buf.Len()
+		defer func() {
+			if r := recover(); r != nil {
+				enc.buf.Truncate(start)
+				retErr = addPanicked(enc, key, arr, r)
+			}
+		}()
+	}
+
+	return arr.MarshalLogArray(ae)
+}
+
+func (enc *logfmtEncoder) AddObject(key string, obj ObjectMarshaler) (retErr error) {
+	old := enc.prefix
+	if !enc.PropagateMarshalerPanics {
+		start := enc.buf.Len()
+		defer func() {
+			if r := recover(); r != nil {
+				enc.buf.Truncate(start)
+				enc.prefix = old
+				retErr = addPanicked(enc, key, obj, r)
+			}
+		}()
+	}
+
+	enc.prefix = old + key + "."
+	err := obj.MarshalLogObject(enc)
+	enc.prefix = old
+	return err
+}
+
+func (enc *logfmtEncoder) AddBinary(key string, val []byte) {
+	cur := enc.buf.Len()
+	if e := enc.EncodeBinary; e != nil {
+		ae := enc.arrayEncoder(key, false /* indexed */)
+		e(val, ae)
+		putLogfmtArrayEncoder(ae)
+	}
+	if cur == enc.buf.Len() {
+		// User-supplied EncodeBinary is a no-op. Fall back to base64.
+		enc.AddString(key, base64.StdEncoding.EncodeToString(val))
+	}
+}
+
+func (enc *logfmtEncoder) AddByteString(key string, val []byte) {
+	enc.addKey(key)
+	enc.appendByteStringValue(val)
+}
+
+func (enc *logfmtEncoder) AddBool(key string, val bool) {
+	enc.addKey(key)
+	enc.buf.AppendBool(val)
+}
+
+func (enc *logfmtEncoder) AddComplex128(key string, val complex128) {
+	enc.addKey(key)
+	enc.appendComplexValue(val, 64)
+}
+
+func (enc *logfmtEncoder) AddComplex64(key string, val complex64) {
+	enc.addKey(key)
+	enc.appendComplexValue(complex128(val), 32)
+}
+
+func (enc *logfmtEncoder) AddDuration(key string, val time.Duration) {
+	cur := enc.buf.Len()
+	if e := enc.EncodeDuration; e != nil {
+		ae := enc.arrayEncoder(key, false /* indexed */)
+		e(val, ae)
+		putLogfmtArrayEncoder(ae)
+	}
+	if cur == enc.buf.Len() {
+		// User-supplied EncodeDuration is a no-op. Fall back to nanoseconds.
+		enc.AddInt64(key, int64(val))
+	}
+}
+
+func (enc *logfmtEncoder) AddFloat64(key string, val float64) {
+	enc.addKey(key)
+	enc.appendFloatValue(val, 64)
+}
+
+func (enc *logfmtEncoder) AddFloat32(key string, val float32) {
+	enc.addKey(key)
+	enc.appendFloatValue(float64(val), 32)
+}
+
+func (enc *logfmtEncoder) AddInt64(key string, val int64) {
+	enc.addKey(key)
+	enc.buf.AppendInt(val)
+}
+
+func (enc *logfmtEncoder) AddReflected(key string, obj interface{}) error {
+	if rs, ok := obj.(RedactedStringer); ok {
+		enc.AddString(key, redactedString(enc, rs))
+		return nil
+	}
+	valueBytes, err := enc.encodeReflected(obj)
+	if err != nil {
+		return err
+	}
+	enc.addKey(key)
+	enc.appendByteStringValue(valueBytes)
+	return nil
+}
+
+func (enc *logfmtEncoder) OpenNamespace(key string) {
+	enc.prefix = enc.prefix + key + "."
+}
+
+func (enc *logfmtEncoder) AddString(key, val string) {
+	enc.addKey(key)
+	enc.appendStringValue(val)
+}
+
+func (enc *logfmtEncoder) AddTime(key string, val time.Time) {
+	cur := enc.buf.Len()
+	if e := enc.EncodeTime; e != nil {
+		ae := enc.arrayEncoder(key, false /* indexed */)
+		e(val, ae)
+		putLogfmtArrayEncoder(ae)
+	}
+	if cur == enc.buf.Len() {
+		// User-supplied EncodeTime is a no-op. Fall back to nanos since epoch.
+		enc.AddInt64(key, val.UnixNano())
+	}
+}
+
+func (enc *logfmtEncoder) AddUint64(key string, val uint64) {
+	enc.addKey(key)
+	enc.buf.AppendUint(val)
+}
+
+func (enc *logfmtEncoder) AddInt(k string, v int)         { enc.AddInt64(k, int64(v)) }
+func (enc *logfmtEncoder) AddInt32(k string, v int32)     { enc.AddInt64(k, int64(v)) }
+func (enc *logfmtEncoder) AddInt16(k string, v int16)     { enc.AddInt64(k, int64(v)) }
+func (enc *logfmtEncoder) AddInt8(k string, v int8)       { enc.AddInt64(k, int64(v)) }
+func (enc *logfmtEncoder) AddUint(k string, v uint)       { enc.AddUint64(k, uint64(v)) }
+func (enc *logfmtEncoder) AddUint32(k string, v uint32)   { enc.AddUint64(k, uint64(v)) }
+func (enc *logfmtEncoder) AddUint16(k string, v uint16)   { enc.AddUint64(k, uint64(v)) }
+func (enc *logfmtEncoder) AddUint8(k string, v uint8)     { enc.AddUint64(k, uint64(v)) }
+func (enc *logfmtEncoder) AddUintptr(k string, v uintptr) { enc.AddUint64(k, uint64(v)) }
+
+func (enc *logfmtEncoder) Clone() Encoder {
+	clone := enc.clone()
+	clone.buf.Write(enc.buf.Bytes())
+	return clone
+}
+
+func (enc *logfmtEncoder) clone() *logfmtEncoder {
+	clone := _logfmtPool.Get()
+	clone.EncoderConfig = enc.EncoderConfig
+	clone.prefix = enc.prefix
+	clone.keyColor = enc.keyColor
+	clone.buf = bufferpool.Get()
+	return clone
+}
+
+func (enc *logfmtEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer, error) {
+	final := enc.clone()
+	// Entry metadata is never namespaced.
+	final.prefix = ""
+
+	if final.TimeKey != "" && !ent.Time.IsZero() {
+		final.AddTime(final.TimeKey, ent.Time)
+	}
+	if final.LevelKey != "" && final.EncodeLevel != nil {
+		cur := final.buf.Len()
+		ae := final.arrayEncoder(final.LevelKey, false /* indexed */)
+		final.EncodeLevel(ent.Level, ae)
+		putLogfmtArrayEncoder(ae)
+		if cur == final.buf.Len() {
+			// User-supplied EncodeLevel was a no-op. Fall back to strings.
+			final.AddString(final.LevelKey, ent.Level.String())
+		}
+	}
+	if ent.LoggerName != "" && final.NameKey != "" {
+		nameEncoder := final.EncodeName
+
+		// if no name encoder provided, fall back to FullNameEncoder for backwards
+		// compatibility
+		if nameEncoder == nil {
+			nameEncoder = FullNameEncoder
+		}
+
+		cur := final.buf.Len()
+		ae := final.arrayEncoder(final.NameKey, false /* indexed */)
+		nameEncoder(ent.LoggerName, ae)
+		putLogfmtArrayEncoder(ae)
+		if cur == final.buf.Len() {
+			// User-supplied EncodeName was a no-op. Fall back to strings.
+			final.AddString(final.NameKey, ent.LoggerName)
+		}
+	}
+	if ent.Caller.Defined {
+		if final.CallerKey != "" {
+			cur := final.buf.Len()
+			if final.EncodeCaller != nil {
+				ae := final.arrayEncoder(final.CallerKey, false /* indexed */)
+				final.EncodeCaller(ent.Caller, ae)
+				putLogfmtArrayEncoder(ae)
+			}
+			if cur == final.buf.Len() {
+				// User-supplied EncodeCaller was a no-op. Fall back to strings.
+				final.AddString(final.CallerKey, ent.Caller.String())
+			}
+		}
+		if final.FunctionKey != "" {
+			final.AddString(final.FunctionKey, ent.Caller.Function)
+		}
+	}
+	if final.MessageKey != "" {
+		final.AddString(final.MessageKey, ent.Message)
+	}
+	if enc.buf.Len() > 0 {
+		final.addSeparator()
+		final.buf.Write(enc.buf.Bytes())
+	}
+
+	final.prefix = enc.prefix
+	addFields(final, fields)
+	final.prefix = ""
+
+	if ent.Stack != "" && final.StacktraceKey != "" {
+		final.AddString(final.StacktraceKey, ent.Stack)
+	}
+	final.buf.AppendString(final.LineEnding)
+
+	ret := final.buf
+	putLogfmtEncoder(final)
+	return ret, nil
+}
+
+// Only invoke the standard JSON encoder if there is actually something to
+// encode; otherwise write JSON null literal directly.
+func (enc *logfmtEncoder) encodeReflected(obj interface{}) ([]byte, error) {
+	if obj == nil {
+		return nullLiteralBytes, nil
+	}
+	if enc.reflectBuf == nil {
+		enc.reflectBuf = bufferpool.Get()
+		enc.reflectEnc = enc.NewReflectedEncoder(enc.reflectBuf)
+	} else {
+		enc.reflectBuf.Reset()
+	}
+	if err := enc.reflectEnc.Encode(obj); err != nil {
+		return nil, err
+	}
+	enc.reflectBuf.TrimNewline()
+	return enc.reflectBuf.Bytes(), nil
+}
+
+func (enc *logfmtEncoder) arrayEncoder(key string, indexed bool) *logfmtArrayEncoder {
+	ae := _logfmtArrayPool.Get()
+	ae.enc = enc
+	ae.key = key
+	ae.indexed = indexed
+	ae.index = 0
+	return ae
+}
+
+func (enc *logfmtEncoder) addSeparator() {
+	if enc.buf.Len() > 0 {
+		enc.buf.AppendByte(' ')
+	}
+}
+
+func (enc *logfmtEncoder) addKey(key string) {
+	enc.addSeparator()
+	if enc.keyColor != 0 {
+		appendColorStart(enc.buf, enc.keyColor)
+	}
+	enc.appendKey(enc.prefix)
+	enc.appendKey(key)
+	if enc.keyColor != 0 {
+		appendColorEnd(enc.buf)
+	}
+	enc.buf.AppendByte('=')
+}
+
+// addIndexedKey writes a key of the form prefix+key+"."+i.
+func (enc *logfmtEncoder) addIndexedKey(key string, i int) {
+	enc.addSeparator()
+	if enc.keyColor != 0 {
+		appendColorStart(enc.buf, enc.keyColor)
+	}
+	enc.appendKey(enc.prefix)
+	enc.appendKey(key)
+	enc.buf.AppendByte('.')
+	enc.buf.AppendInt(int64(i))
+	if enc.keyColor != 0 {
+		appendColorEnd(enc.buf)
+	}
+	enc.buf.AppendByte('=')
+}
+
+// appendKey writes a key, replacing any bytes that aren't allowed in logfmt
+// keys (spaces, control characters, equal signs, and quotes) with
+// underscores.
+func (enc *logfmtEncoder) appendKey(key string) {
+	last := 0
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; c > ' ' && c != '=' && c != '"' && c != 0x7f {
+			continue
+		}
+		enc.buf.AppendString(key[last:i])
+		enc.buf.AppendByte('_')
+		last = i + 1
+	}
+	enc.buf.AppendString(key[last:])
+}
+
+func (enc *logfmtEncoder) appendStringValue(s string) {
+	if !logfmtNeedsQuoting(s, utf8.DecodeRuneInString) {
+		enc.buf.AppendString(s)
+		return
+	}
+	enc.buf.AppendByte('"')
+	safeAppendStringLike((*buffer.Buffer).AppendString, utf8.DecodeRuneInString, enc.buf, s)
+	enc.buf.AppendByte('"')
+}
+
+func (enc *logfmtEncoder) appendByteStringValue(s []byte) {
+	if !logfmtNeedsQuoting(s, utf8.DecodeRune) {
+		enc.buf.AppendBytes(s)
+		return
+	}
+	enc.buf.AppendByte('"')
+	safeAppendStringLike((*buffer.Buffer).AppendBytes, utf8.DecodeRune, enc.buf, s)
+	enc.buf.AppendByte('"')
+}
+
+func (enc *logfmtEncoder) appendFloatValue(val float64, bitSize int) {
+	switch {
+	case math.IsNaN(val):
+		enc.buf.AppendString("NaN")
+	case math.IsInf(val, 1):
+		enc.buf.AppendString("+Inf")
+	case math.IsInf(val, -1):
+		enc.buf.AppendString("-Inf")
+	default:
+		enc.buf.AppendFloat(val, bitSize)
+	}
+}
+
+func (enc *logfmtEncoder) appendComplexValue(val complex128, precision int) {
+	// Cast to a platform-independent, fixed-size type.
+	r, i := float64(real(val)), float64(imag(val))
+	enc.buf.AppendFloat(r, precision)
+	// If imaginary part is less than 0, minus (-) sign is added by default
+	// by AppendFloat.
+	if i >= 0 {
+		enc.buf.AppendByte('+')
+	}
+	enc.buf.AppendFloat(i, precision)
+	enc.buf.AppendByte('i')
+}
+
+// logfmtNeedsQuoting reports whether a value must be quoted to survive a
+// round-trip through a logfmt parser: empty values, values containing
+// whitespace, control characters, equal signs, quotes, or backslashes, and
+// values that aren't valid UTF-8.
+func logfmtNeedsQuoting[S []byte | string](s S, decodeRune func(S) (rune, int)) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c <= ' ' || c == '=' || c == '"' || c == '\\' || c == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := decodeRune(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+func putLogfmtArrayEncoder(ae *logfmtArrayEncoder) {
+	ae.enc = nil
+	ae.key = ""
+	_logfmtArrayPool.Put(ae)
+}
+
+// logfmtArrayEncoder writes each appended element as its own key=value pair.
+// Indexed encoders, used for arrays, suffix the key with the element's
+// position; non-indexed encoders, used for the level, time, and other
+// EncoderConfig-driven values, write the key as-is.
+type logfmtArrayEncoder struct {
+	enc     *logfmtEncoder
+	key     string
+	indexed bool
+	index   int
+}
+
+func (ae *logfmtArrayEncoder) addKey() {
+	if !ae.indexed {
+		ae.enc.addKey(ae.key)
+		return
+	}
+	ae.enc.addIndexedKey(ae.key, ae.index)
+	ae.index++
+}
+
+// elemKey returns the flattened key for the next element, relative to the
+// encoder's current prefix.
+func (ae *logfmtArrayEncoder) elemKey() string {
+	if !ae.indexed {
+		return ae.key
+	}
+	buf := bufferpool.Get()
+	buf.AppendString(ae.key)
+	buf.AppendByte('.')
+	buf.AppendInt(int64(ae.index))
+	key := buf.String()
+	buf.Free()
+	ae.index++
+	return key
+}
+
+func (ae *logfmtArrayEncoder) AppendArray(arr ArrayMarshaler) error {
+	return ae.enc.AddArray(ae.elemKey(), arr)
+}
+
+func (ae *logfmtArrayEncoder) AppendObject(obj ObjectMarshaler) error {
+	return ae.enc.AddObject(ae.elemKey(), obj)
+}
+
+func (ae *logfmtArrayEncoder) AppendReflected(val interface{}) error {
+	return ae.enc.AddReflected(ae.elemKey(), val)
+}
+
+func (ae *logfmtArrayEncoder) AppendDuration(val time.Duration) {
+	cur := ae.enc.buf.Len()
+	if e := ae.enc.EncodeDuration; e != nil {
+		e(val, ae)
+	}
+	if cur == ae.enc.buf.Len() {
+		ae.AppendInt64(int64(val))
+	}
+}
+
+func (ae *logfmtArrayEncoder) AppendTime(val time.Time) {
+	cur := ae.enc.buf.Len()
+	if e := ae.enc.EncodeTime; e != nil {
+		e(val, ae)
+	}
+	if cur == ae.enc.buf.Len() {
+		ae.AppendInt64(val.UnixNano())
+	}
+}
+
+func (ae *logfmtArrayEncoder) AppendTimeLayout(t time.Time, layout string) {
+	ae.addKey()
+	// Some layouts contain spaces, so format into a scratch buffer first to
+	// decide whether quoting is necessary.
+	buf := bufferpool.Get()
+	buf.AppendTime(t, layout)
+	ae.enc.appendByteStringValue(buf.Bytes())
+	buf.Free()
+}
+
+func (ae *logfmtArrayEncoder) AppendBool(v bool) {
+	ae.addKey()
+	ae.enc.buf.AppendBool(v)
+}
+
+func (ae *logfmtArrayEncoder) AppendByteString(v []byte) {
+	ae.addKey()
+	ae.enc.appendByteStringValue(v)
+}
+
+func (ae *logfmtArrayEncoder) AppendComplex128(v complex128) {
+	ae.addKey()
+	ae.enc.appendComplexValue(v, 64)
+}
+
+func (ae *logfmtArrayEncoder) AppendComplex64(v complex64) {
+	ae.addKey()
+	ae.enc.appendComplexValue(complex128(v), 32)
+}
+
+func (ae *logfmtArrayEncoder) AppendFloat64(v float64) {
+	ae.addKey()
+	ae.enc.appendFloatValue(v, 64)
+}
+
+func (ae *logfmtArrayEncoder) AppendFloat32(v float32) {
+	ae.addKey()
+	ae.enc.appendFloatValue(float64(v), 32)
+}
+
+func (ae *logfmtArrayEncoder) AppendInt64(v int64) {
+	ae.addKey()
+	ae.enc.buf.AppendInt(v)
+}
+
+func (ae *logfmtArrayEncoder) AppendString(v string) {
+	ae.addKey()
+	ae.enc.appendStringValue(v)
+}
+
+func (ae *logfmtArrayEncoder) AppendUint64(v uint64) {
+	ae.addKey()
+	ae.enc.buf.AppendUint(v)
+}
+
+func (ae *logfmtArrayEncoder) AppendInt(v int)         { ae.AppendInt64(int64(v)) }
+func (ae *logfmtArrayEncoder) AppendInt32(v int32)     { ae.AppendInt64(int64(v)) }
+func (ae *logfmtArrayEncoder) AppendInt16(v int16)     { ae.AppendInt64(int64(v)) }
+func (ae *logfmtArrayEncoder) AppendInt8(v int8)       { ae.AppendInt64(int64(v)) }
+func (ae *logfmtArrayEncoder) AppendUint(v uint)       { ae.AppendUint64(uint64(v)) }
+func (ae *logfmtArrayEncoder) AppendUint32(v uint32)   { ae.AppendUint64(uint64(v)) }
+func (ae *logfmtArrayEncoder) AppendUint16(v uint16)   { ae.AppendUint64(uint64(v)) }
+func (ae *logfmtArrayEncoder) AppendUint8(v uint8)     { ae.AppendUint64(uint64(v)) }
+func (ae *logfmtArrayEncoder) AppendUintptr(v uintptr) { ae.AppendUint64(uint64(v)) }
@@ -0,0 +1,214 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+)
+
+func TestLogfmtEncodeEntry(t *testing.T) {
+	type bar struct {
+		Key string  `json:"key"`
+		Val float64 `json:"val"`
+	}
+
+	tests := []struct {
+		desc     string
+		expected string
+		ent      Entry
+		fields   []Field
+	}{
+		{
+			desc:     "info entry with some fields",
+			expected: `ts=100 level=info name=bob msg="lob law" so=passes answer=42 common_pie=3.14 null_value=null array_with_null_elements.0=null array_with_null_elements.1=null array_with_null_elements.2=null array_with_null_elements.3=2 such.key=value such.value=3.14` + "\n",
+			ent: Entry{
+				Level:      InfoLevel,
+				Time:       time.Date(1970, time.January, 1, 0, 1, 40, 0, time.UTC),
+				LoggerName: "bob",
+				Message:    "lob law",
+			},
+			fields: []Field{
+				{Key: "so", Type: StringType, String: "passes"},
+				{Key: "answer", Type: Int64Type, Integer: 42},
+				{Key: "common_pie", Type: Float64Type, Integer: int64(math.Float64bits(3.14))},
+				{Key: "null_value", Type: ReflectType, Interface: nil},
+				{Key: "array_with_null_elements", Type: ArrayMarshalerType, Interface: ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+					enc.AppendReflected(nil)
+					enc.AppendReflected((*int)(nil))
+					enc.AppendReflected([]int(nil))
+					enc.AppendInt(2)
+					return nil
+				})},
+				{Key: "such", Type: ObjectMarshalerType, Interface: ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+					enc.AddString("key", "value")
+					enc.AddFloat64("value", 3.14)
+					return nil
+				})},
+			},
+		},
+		{
+			desc:     "reflected and quoted values",
+			expected: `level=warn msg=hello bar="{\"key\":\"x y\",\"val\":1}" quote="say \"hi\"" eq="a=b" empty="" multi="line1\nline2"` + "\n",
+			ent: Entry{
+				Level:   WarnLevel,
+				Message: "hello",
+			},
+			fields: []Field{
+				{Key: "bar", Type: ReflectType, Interface: bar{Key: "x y", Val: 1}},
+				{Key: "quote", Type: StringType, String: `say "hi"`},
+				{Key: "eq", Type: StringType, String: "a=b"},
+				{Key: "empty", Type: StringType, String: ""},
+				{Key: "multi", Type: StringType, String: "line1\nline2"},
+			},
+		},
+		{
+			desc:     "namespaces and nested arrays",
+			expected: `level=error msg=oops db.rows=3 db.nested.0.0=a db.nested.1.x=true db.error="not found" stacktrace="fake\nstack"` + "\n",
+			ent: Entry{
+				Level:   ErrorLevel,
+				Message: "oops",
+				Stack:   "fake\nstack",
+			},
+			fields: []Field{
+				{Key: "db", Type: NamespaceType},
+				{Key: "rows", Type: Int64Type, Integer: 3},
+				{Key: "nested", Type: ArrayMarshalerType, Interface: ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+					if err := enc.AppendArray(ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+						enc.AppendString("a")
+						return nil
+					})); err != nil {
+						return err
+					}
+					return enc.AppendObject(ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+						enc.AddBool("x", true)
+						return nil
+					}))
+				})},
+				{Key: "error", Type: ErrorType, Interface: errors.New("not found")},
+			},
+		},
+	}
+
+	enc := NewLogfmtEncoder(testEncoderConfig())
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			buf, err := enc.EncodeEntry(tt.ent, tt.fields)
+			require.NoError(t, err, "Unexpected logfmt encoding error.")
+			assert.Equal(t, tt.expected, buf.String(), "Incorrect encoded logfmt entry.")
+			buf.Free()
+		})
+	}
+}
+
+func TestLogfmtEncoderEncoderConfig(t *testing.T) {
+	cfg := testEncoderConfig()
+	cfg.EncodeTime = ISO8601TimeEncoder
+	cfg.EncodeDuration = StringDurationEncoder
+	cfg.EncodeLevel = CapitalLevelEncoder
+	cfg.TimeKey = "time"
+	cfg.MessageKey = "message"
+
+	enc := NewLogfmtEncoder(cfg)
+	enc.AddDuration("elapsed", time.Second+500*time.Millisecond)
+
+	buf, err := enc.EncodeEntry(_testEntry, []Field{
+		{Key: "at", Type: TimeFullType, Interface: time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t,
+		`time=1970-01-01T00:00:00.000Z level=INFO name=main caller=foo.go:42 func=foo.Foo message=hello elapsed=1.5s at=2023-06-01T12:00:00.000Z stacktrace=fake-stack`+"\n",
+		buf.String(),
+	)
+	buf.Free()
+}
+
+func TestLogfmtEncoderClone(t *testing.T) {
+	enc := NewLogfmtEncoder(testEncoderConfig())
+	enc.OpenNamespace("outer")
+	enc.AddString("a", "1")
+
+	clone := enc.Clone()
+	clone.OpenNamespace("inner")
+	clone.AddString("b", "2")
+
+	ent := Entry{Level: InfoLevel, Message: "m"}
+	fields := []Field{{Key: "c", Type: StringType, String: "3"}}
+
+	buf, err := enc.EncodeEntry(ent, fields)
+	require.NoError(t, err)
+	assert.Equal(t, "level=info msg=m outer.a=1 outer.c=3\n", buf.String(), "Parent encoder affected by clone.")
+	buf.Free()
+
+	buf, err = clone.EncodeEntry(ent, fields)
+	require.NoError(t, err)
+	assert.Equal(t, "level=info msg=m outer.a=1 outer.inner.b=2 outer.inner.c=3\n", buf.String(), "Unexpected clone output.")
+	buf.Free()
+}
+
+func TestLogfmtEncoderKeysAndValues(t *testing.T) {
+	tests := []struct {
+		desc     string
+		f        func(Encoder)
+		expected string
+	}{
+		{"invalid key bytes", func(e Encoder) { e.AddString(`a b=c"d`, "x") }, `a_b_c_d=x`},
+		{"binary", func(e Encoder) { e.AddBinary("k", []byte("foo")) }, `k=Zm9v`},
+		{"binary with padding", func(e Encoder) { e.AddBinary("k", []byte("fo")) }, `k="Zm8="`},
+		{"byte string", func(e Encoder) { e.AddByteString("k", []byte("a b")) }, `k="a b"`},
+		{"complex", func(e Encoder) { e.AddComplex128("k", 1-2i) }, `k=1-2i`},
+		{"NaN", func(e Encoder) { e.AddFloat64("k", math.NaN()) }, `k=NaN`},
+		{"+Inf", func(e Encoder) { e.AddFloat32("k", float32(math.Inf(1))) }, `k=+Inf`},
+		{"uint", func(e Encoder) { e.AddUint8("k", 7) }, `k=7`},
+		{"invalid UTF-8", func(e Encoder) { e.AddString("k", "\xff") }, `k="\ufffd"`},
+		{"unicode", func(e Encoder) { e.AddString("k", "héllo") }, `k=héllo`},
+		{"backslash", func(e Encoder) { e.AddString("k", `a\b`) }, `k="a\\b"`},
+		{"durations", func(e Encoder) {
+			assert.NoError(t, e.AddArray("k", ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+				enc.AppendDuration(time.Second)
+				enc.AppendDuration(time.Millisecond)
+				return nil
+			})))
+		}, `k.0=1 k.1=0.001`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := NewLogfmtEncoder(EncoderConfig{
+				EncodeDuration: SecondsDurationEncoder,
+				SkipLineEnding: true,
+			})
+			tt.f(enc)
+			buf, err := enc.EncodeEntry(Entry{}, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, buf.String())
+			buf.Free()
+		})
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zapcore_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap"
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+)
+
+// halfMarshaler writes part of its output, then panics.
+type halfMarshaler struct{}
+
+func (halfMarshaler) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("written", "before panic")
+	enc.OpenNamespace("ns")
+	enc.AddInt("n", 1)
+	panic("boom")
+}
+
+func (halfMarshaler) MarshalLogArray(enc ArrayEncoder) error {
+	enc.AppendString("written")
+	_ = enc.AppendObject(ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+		enc.AddString("k", "v")
+		panic("boom")
+	}))
+	panic("boom")
+}
+
+// nilMarshaler dereferences its nil receiver.
+type nilMarshaler struct{ name string }
+
+func (m *nilMarshaler) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("name", m.name)
+	return nil
+}
+
+func panickingFields() []Field {
+	return []Field{
+		zap.String("first", "a"),
+		zap.Object("obj", halfMarshaler{}),
+		zap.Array("arr", halfMarshaler{}),
+		zap.Object("nested", ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+			enc.AddString("before", "b")
+			_ = enc.AddObject("inner", halfMarshaler{})
+			enc.AddString("after", "c")
+			return nil
+		})),
+		// Objects stops at the first element that fails.
+		zap.Objects("objs", []ObjectMarshaler{halfMarshaler{}, halfMarshaler{}}),
+		zap.Object("nil", (*nilMarshaler)(nil)),
+		zap.String("last", "z"),
+	}
+}
+
+func TestJSONEncoderMarshalerPanics(t *testing.T) {
+	enc := NewJSONEncoder(EncoderConfig{MessageKey: "msg"})
+	enc.AddString("context", "ctx")
+	buf, err := enc.EncodeEntry(Entry{Message: "hello"}, panickingFields())
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	defer buf.Free()
+
+	require.True(t, json.Valid(buf.Bytes()), "Expected valid JSON, got %s.", buf)
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got), "Couldn't decode entry.")
+	assert.Equal(t, map[string]interface{}{
+		"msg":     "hello",
+		"context": "ctx",
+		"first":   "a",
+		"obj":     "<panic: boom>",
+		"arr":     "<panic: boom>",
+		"nested": map[string]interface{}{
+			"before": "b",
+			"inner":  "<panic: boom>",
+			"after":  "c",
+		},
+		"objs": []interface{}{"<panic: boom>"},
+		"nil":  "<nil>",
+		"last": "z",
+	}, got, "Unexpected entry.")
+}
+
+func TestJSONEncoderReportPanics(t *testing.T) {
+	enc := NewJSONEncoder(EncoderConfig{MessageKey: "msg", ReportPanics: true})
+	buf, err := enc.EncodeEntry(Entry{Message: "hello"}, panickingFields())
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	defer buf.Free()
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got), "Couldn't decode entry.")
+	assert.Equal(t, "<panic: boom>", got["obj"], "Expected a placeholder.")
+	assert.Equal(t, "PANIC=boom", got["objError"], "Expected the panic to be reported.")
+	assert.Equal(t, "PANIC=boom", got["arrError"], "Expected the panic to be reported.")
+	assert.Equal(t, "PANIC=boom", got["objsError"], "Expected the panic to be reported.")
+	assert.NotContains(t, got, "nilError", "Nil pointers aren't panics worth reporting.")
+}
+
+func TestConsoleEncoderMarshalerPanics(t *testing.T) {
+	enc := NewConsoleEncoder(EncoderConfig{MessageKey: "msg", ReportPanics: true})
+	buf, err := enc.EncodeEntry(Entry{Message: "hello"}, panickingFields())
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	defer buf.Free()
+
+	line := strings.TrimSuffix(buf.String(), "\n")
+	ctx := line[strings.IndexByte(line, '{'):]
+	require.True(t, json.Valid([]byte(ctx)), "Expected the context to be valid JSON, got %s.", ctx)
+	assert.Contains(t, ctx, `"obj": "<panic: boom>", "objError": "PANIC=boom"`, "Expected a placeholder.")
+	assert.Contains(t, ctx, `"last": "z"`, "Expected the remaining fields to be encoded.")
+}
+
+func TestMapObjectEncoderMarshalerPanics(t *testing.T) {
+	enc := NewMapObjectEncoder()
+	for _, f := range panickingFields() {
+		f.AddTo(enc)
+	}
+
+	assert.Equal(t, "<panic: boom>", enc.Fields["obj"], "Unexpected placeholder.")
+	assert.NotContains(t, enc.Fields, "objError", "Expected panics to be reported only if configured.")
+	assert.Equal(t, "<panic: boom>", enc.Fields["arr"], "Unexpected placeholder.")
+	assert.Equal(t, map[string]interface{}{
+		"before": "b",
+		"inner":  "<panic: boom>",
+		"after":  "c",
+	}, enc.Fields["nested"], "Expected nested panics to be contained.")
+	assert.Equal(t, []interface{}{"<panic: boom>"}, enc.Fields["objs"],
+		"Expected panics in array elements to be contained.")
+	assert.Equal(t, "<nil>", enc.Fields["nil"], "Unexpected placeholder for a nil marshaler.")
+	assert.Equal(t, "z", enc.Fields["last"], "Expected the remaining fields to be encoded.")
+}
+
+func TestPropagateMarshalerPanics(t *testing.T) {
+	cfg := EncoderConfig{MessageKey: "msg", PropagateMarshalerPanics: true}
+	for _, enc := range []Encoder{NewJSONEncoder(cfg), NewConsoleEncoder(cfg)} {
+		assert.PanicsWithValue(t, "boom", func() {
+			_, _ = enc.EncodeEntry(Entry{Message: "hello"}, []Field{zap.Object("obj", halfMarshaler{})})
+		}, "Expected the panic to propagate.")
+		assert.PanicsWithValue(t, "boom", func() {
+			enc.AddObject("obj", halfMarshaler{})
+		}, "Expected the panic to propagate.")
+	}
+}
+
+// nilStringer dereferences its nil receiver.
+type nilStringer struct{ name string }
+
+func (s *nilStringer) String() string { return s.name }
+
+// panicStringer always panics.
+type panicStringer struct{}
+
+func (panicStringer) String() string { panic("boom") }
+
+func nilPointerFields() []Field {
+	return []Field{
+		zap.Stringer("str", (*nilStringer)(nil)),
+		zap.Object("obj", (*nilMarshaler)(nil)),
+		zap.Stringer("boom", panicStringer{}),
+		zap.String("last", "z"),
+	}
+}
+
+func TestNilPointerFields(t *testing.T) {
+	want := map[string]interface{}{
+		"str":  "<nil>",
+		"obj":  "<nil>",
+		"boom": "<panic: boom>",
+		"last": "z",
+	}
+	wantReported := map[string]interface{}{
+		"str":       "<nil>",
+		"obj":       "<nil>",
+		"boom":      "<panic: boom>",
+		"boomError": "PANIC=boom",
+		"last":      "z",
+	}
+
+	t.Run("map", func(t *testing.T) {
+		enc := NewMapObjectEncoder()
+		for _, f := range nilPointerFields() {
+			f.AddTo(enc)
+		}
+		assert.Equal(t, want, enc.Fields, "Unexpected fields.")
+	})
+
+	for _, report := range []bool{false, true} {
+		expected := want
+		if report {
+			expected = wantReported
+		}
+		cfg := EncoderConfig{ReportPanics: report}
+
+		t.Run(fmt.Sprintf("json/report=%v", report), func(t *testing.T) {
+			buf, err := NewJSONEncoder(cfg).EncodeEntry(Entry{}, nilPointerFields())
+			require.NoError(t, err, "Unexpected error encoding entry.")
+			defer buf.Free()
+
+			var got map[string]interface{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &got), "Couldn't decode entry %s.", buf)
+			assert.Equal(t, expected, got, "Unexpected entry.")
+		})
+
+		t.Run(fmt.Sprintf("console/report=%v", report), func(t *testing.T) {
+			buf, err := NewConsoleEncoder(cfg).EncodeEntry(Entry{}, nilPointerFields())
+			require.NoError(t, err, "Unexpected error encoding entry.")
+			defer buf.Free()
+
+			line := strings.TrimSuffix(buf.String(), "\n")
+			var got map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(line[strings.IndexByte(line, '{'):]), &got),
+				"Couldn't decode context of %q.", line)
+			assert.Equal(t, expected, got, "Unexpected context.")
+		})
+	}
+}
+
+func TestNilPointerFieldsDontPanic(t *testing.T) {
+	// These encoders used to let panics from nil ObjectMarshalers through.
+	encoders := map[string]func(EncoderConfig) Encoder{
+		"logfmt":   NewLogfmtEncoder,
+		"gelf":     NewGELFEncoder,
+		"journald": NewJournaldEncoder,
+	}
+	for name, newEncoder := range encoders {
+		t.Run(name, func(t *testing.T) {
+			enc := newEncoder(EncoderConfig{MessageKey: "msg"})
+			fields := append(nilPointerFields(), zap.Object("half", halfMarshaler{}))
+
+			var out string
+			require.NotPanics(t, func() {
+				buf, err := enc.EncodeEntry(Entry{Message: "hello"}, fields)
+				require.NoError(t, err, "Unexpected error encoding entry.")
+				out = buf.String()
+				buf.Free()
+			}, "Expected panics to be recovered.")
+			assert.Contains(t, out, "<nil>", "Expected a placeholder for nil pointers.")
+			assert.Contains(t, out, "<panic: boom>", "Expected a placeholder for panics.")
+			assert.NotContains(t, out, "before panic", "Expected partial output to be discarded.")
+			assert.NotContains(t, strings.ToLower(out), "error", "Expected panics to be reported only if configured.")
+		})
+	}
+}
@@ -20,7 +20,11 @@
 
 package zapcore
 
-import "time"
+import (
+	"sort"
+	"strings"
+	"time"
+)
 
 // MapObjectEncoder is an ObjectEncoder backed by a simple
 // map[string]interface{}. It's not fast enough for production use, but it's
@@ -30,6 +34,12 @@ type MapObjectEncoder struct {
 	Fields map[string]interface{}
 	// cur is a pointer to the namespace we're currently writing to.
 	cur map[string]interface{}
+	// path holds the keys of the namespaces leading to cur.
+	path []string
+	// order records the keys of each map in the order they were added,
+	// keyed by orderKey of the map's path. It's nil unless the encoder is
+	// ordered.
+	order map[string][]string
 }
 
 // NewMapObjectEncoder creates a new map-backed ObjectEncoder.
@@ -41,118 +51,329 @@ func NewMapObjectEncoder() *MapObjectEncoder {
 	}
 }
 
-// AddArray implements ObjectEncoder.
-func (m *MapObjectEncoder) AddArray(key string, v ArrayMarshaler) error {
+// NewOrderedMapObjectEncoder creates a new map-backed ObjectEncoder that
+// also records the order in which keys are added, including the keys of
+// nested namespaces and objects. Use Keys to retrieve them, for example to
+// re-encode fields into an ordered format.
+func NewOrderedMapObjectEncoder() *MapObjectEncoder {
+	m := NewMapObjectEncoder()
+	m.order = make(map[string][]string)
+	return m
+}
+
+// Keys returns the keys of the encoded context in the order they were first
+// added. If a path is provided, Keys returns the keys of the namespace or
+// object found by following those keys from the top-level context, or nil if
+// there isn't one.
+//
+// Keys are only ordered if the encoder was created with
+// NewOrderedMapObjectEncoder. Otherwise, and for keys added to Fields
+// directly, they're sorted.
+func (m *MapObjectEncoder) Keys(path ...string) []string {
+	fields := m.Fields
+	for _, k := range path {
+		nested, ok := fields[k].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields = nested
+	}
+
+	keys := make([]string, 0, len(fields))
+	seen := make(map[string]struct{}, len(fields))
+	for _, k := range m.order[orderKey(path)] {
+		if _, ok := fields[k]; ok {
+			keys = append(keys, k)
+			seen[k] = struct{}{}
+		}
+	}
+
+	unordered := make([]string, 0, len(fields)-len(keys))
+	for k := range fields {
+		if _, ok := seen[k]; !ok {
+			unordered = append(unordered, k)
+		}
+	}
+	sort.Strings(unordered)
+	return append(keys, unordered...)
+}
+
+// Clone returns a deep copy of the encoder. Fields added to the copy, even in
+// a namespace opened before cloning, don't affect the original, and vice
+// versa. Use it to implement Core.With in cores that build on
+// MapObjectEncoder.
+func (m *MapObjectEncoder) Clone() *MapObjectEncoder {
+	clone := &MapObjectEncoder{
+		Fields: copyMap(m.Fields),
+		path:   append([]string(nil), m.path...),
+	}
+
+	clone.cur = clone.Fields
+	for _, k := range clone.path {
+		ns, ok := clone.cur[k].(map[string]interface{})
+		if !ok {
+			// The namespace was overwritten after it was opened, so
+			// it's no longer reachable from Fields.
+			clone.cur = copyMap(m.cur)
+			break
+		}
+		clone.cur = ns
+	}
+
+	if m.order != nil {
+		clone.order = make(map[string][]string, len(m.order))
+		for k, keys := range m.order {
+			clone.order[k] = append([]string(nil), keys...)
+		}
+	}
+	return clone
+}
+
+// set adds a key to the current namespace, recording its order if the
+// encoder is ordered.
+func (m *MapObjectEncoder) set(k string, v interface{}) {
+	if m.order != nil {
+		if _, ok := m.cur[k]; !ok {
+			key := orderKey(m.path)
+			m.order[key] = append(m.order[key], k)
+		}
+	}
+	m.cur[k] = v
+}
+
+// nested returns an encoder that writes to a new map, stored under k in the
+// current namespace, sharing this encoder's key order.
+func (m *MapObjectEncoder) nested(k string) *MapObjectEncoder {
+	fields := make(map[string]interface{})
+	m.set(k, fields)
+
+	path := make([]string, len(m.path)+1)
+	copy(path, m.path)
+	path[len(m.path)] = k
+	return &MapObjectEncoder{
+		Fields: fields,
+		cur:    fields,
+		path:   path,
+		order:  m.order,
+	}
+}
+
+// AddArray implements ObjectEncoder. If the marshaler panics, the panic is
+// logged as a placeholder instead of the array.
+func (m *MapObjectEncoder) AddArray(key string, v ArrayMarshaler) (retErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			retErr = m.setPanicked(key, v, r)
+		}
+	}()
+
 	arr := &sliceArrayEncoder{elems: make([]interface{}, 0)}
 	err := v.MarshalLogArray(arr)
-	m.cur[key] = arr.elems
+	m.set(key, arr.elems)
 	return err
 }
 
-// AddObject implements ObjectEncoder.
-func (m *MapObjectEncoder) AddObject(k string, v ObjectMarshaler) error {
-	newMap := NewMapObjectEncoder()
-	m.cur[k] = newMap.Fields
-	return v.MarshalLogObject(newMap)
+// AddObject implements ObjectEncoder. If the marshaler panics, the panic is
+// logged as a placeholder instead of the object.
+func (m *MapObjectEncoder) AddObject(k string, v ObjectMarshaler) (retErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			retErr = m.setPanicked(k, v, r)
+		}
+	}()
+
+	return v.MarshalLogObject(m.nested(k))
+}
+
+// setPanicked logs the placeholder for a marshaler that panicked under k.
+func (m *MapObjectEncoder) setPanicked(k string, marshaler, r interface{}) error {
+	placeholder, err := marshalerPanic(marshaler, r)
+	m.set(k, placeholder)
+	return err
 }
 
 // AddBinary implements ObjectEncoder.
-func (m *MapObjectEncoder) AddBinary(k string, v []byte) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddBinary(k string, v []byte) { m.set(k, v) }
 
 // AddByteString implements ObjectEncoder.
-func (m *MapObjectEncoder) AddByteString(k string, v []byte) { m.cur[k] = string(v) }
+func (m *MapObjectEncoder) AddByteString(k string, v []byte) { m.set(k, string(v)) }
 
 // AddBool implements ObjectEncoder.
-func (m *MapObjectEncoder) AddBool(k string, v bool) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddBool(k string, v bool) { m.set(k, v) }
 
 // AddDuration implements ObjectEncoder.
-func (m MapObjectEncoder) AddDuration(k string, v time.Duration) { m.cur[k] = v }
+func (m MapObjectEncoder) AddDuration(k string, v time.Duration) { m.set(k, v) }
 
 // AddComplex128 implements ObjectEncoder.
-func (m *MapObjectEncoder) AddComplex128(k string, v complex128) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddComplex128(k string, v complex128) { m.set(k, v) }
 
 // AddComplex64 implements ObjectEncoder.
-func (m *MapObjectEncoder) AddComplex64(k string, v complex64) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddComplex64(k string, v complex64) { m.set(k, v) }
 
 // AddFloat64 implements ObjectEncoder.
-func (m *MapObjectEncoder) AddFloat64(k string, v float64) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddFloat64(k string, v float64) { m.set(k, v) }
 
 // AddFloat32 implements ObjectEncoder.
-func (m *MapObjectEncoder) AddFloat32(k string, v float32) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddFloat32(k string, v float32) { m.set(k, v) }
 
 // AddInt implements ObjectEncoder.
-func (m *MapObjectEncoder) AddInt(k string, v int) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddInt(k string, v int) { m.set(k, v) }
 
 // AddInt64 implements ObjectEncoder.
-func (m *MapObjectEncoder) AddInt64(k string, v int64) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddInt64(k string, v int64) { m.set(k, v) }
 
 // AddInt32 implements ObjectEncoder.
-func (m *MapObjectEncoder) AddInt32(k string, v int32) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddInt32(k string, v int32) { m.set(k, v) }
 
 // AddInt16 implements ObjectEncoder.
-func (m *MapObjectEncoder) AddInt16(k string, v int16) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddInt16(k string, v int16) { m.set(k, v) }
 
 // AddInt8 implements ObjectEncoder.
-func (m *MapObjectEncoder) AddInt8(k string, v int8) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddInt8(k string, v int8) { m.set(k, v) }
 
 // AddString implements ObjectEncoder.
-func (m *MapObjectEncoder) AddString(k string, v string) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddString(k string, v string) { m.set(k, v) }
 
 // AddTime implements ObjectEncoder.
-func (m MapObjectEncoder) AddTime(k string, v time.Time) { m.cur[k] = v }
+func (m MapObjectEncoder) AddTime(k string, v time.Time) { m.set(k, v) }
 
 // AddUint implements ObjectEncoder.
-func (m *MapObjectEncoder) AddUint(k string, v uint) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddUint(k string, v uint) { m.set(k, v) }
 
 // AddUint64 implements ObjectEncoder.
-func (m *MapObjectEncoder) AddUint64(k string, v uint64) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddUint64(k string, v uint64) { m.set(k, v) }
 
 // AddUint32 implements ObjectEncoder.
-func (m *MapObjectEncoder) AddUint32(k string, v uint32) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddUint32(k string, v uint32) { m.set(k, v) }
 
 // AddUint16 implements ObjectEncoder.
-func (m *MapObjectEncoder) AddUint16(k string, v uint16) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddUint16(k string, v uint16) { m.set(k, v) }
 
 // AddUint8 implements ObjectEncoder.
-func (m *MapObjectEncoder) AddUint8(k string, v uint8) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddUint8(k string, v uint8) { m.set(k, v) }
 
 // AddUintptr implements ObjectEncoder.
-func (m *MapObjectEncoder) AddUintptr(k string, v uintptr) { m.cur[k] = v }
+func (m *MapObjectEncoder) AddUintptr(k string, v uintptr) { m.set(k, v) }
 
 // AddReflected implements ObjectEncoder.
 func (m *MapObjectEncoder) AddReflected(k string, v interface{}) error {
-	m.cur[k] = v
+	if rs, ok := v.(RedactedStringer); ok {
+		v = rs.RedactedString()
+	}
+	m.set(k, v)
 	return nil
 }
 
 // OpenNamespace implements ObjectEncoder.
 func (m *MapObjectEncoder) OpenNamespace(k string) {
-	ns := make(map[string]interface{})
-	m.cur[k] = ns
-	m.cur = ns
+	ns := m.nested(k)
+	m.cur = ns.cur
+	m.path = ns.path
+}
+
+// orderKey identifies the map at path in an encoder's key order.
+func orderKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+// copyMap returns a deep copy of an encoded map.
+func copyMap(fields map[string]interface{}) map[string]interface{} {
+	clone := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		clone[k] = copyValue(v)
+	}
+	return clone
+}
+
+// copyValue returns a deep copy of an encoded value. Values other than the
+// maps, slices, and byte slices built by the encoders are shared.
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return copyMap(v)
+	case []interface{}:
+		clone := make([]interface{}, len(v))
+		for i, elem := range v {
+			clone[i] = copyValue(elem)
+		}
+		return clone
+	case []byte:
+		return append([]byte(nil), v...)
+	default:
+		return v
+	}
 }
 
 // sliceArrayEncoder is an ArrayEncoder backed by a simple []interface{}. Like
 // the MapObjectEncoder, it's not designed for production use.
 type sliceArrayEncoder struct {
 	elems []interface{}
+
+	// columns is set if the elements are printed as plain-text columns by
+	// the console encoder.
+	columns bool
+
+	// times backs the *layoutTime elements appended by AppendTimeLayout in
+	// columns mode, so that pooled encoders reuse their storage.
+	times []layoutTime
+}
+
+// layoutTime is a time that the console encoder formats with layout
+// directly into its output.
+type layoutTime struct {
+	t      time.Time
+	layout string
+}
+
+// AppendTimeLayout implements the fast path used by time encoders like
+// ISO8601TimeEncoder. In columns mode, it defers formatting to the console
+// encoder, which appends the time to its buffer without a temporary string.
+func (s *sliceArrayEncoder) AppendTimeLayout(t time.Time, layout string) {
+	if !s.columns {
+		s.elems = append(s.elems, t.Format(layout))
+		return
+	}
+	s.times = append(s.times, layoutTime{t: t, layout: layout})
+	s.elems = append(s.elems, &s.times[len(s.times)-1])
 }
 
-func (s *sliceArrayEncoder) AppendArray(v ArrayMarshaler) error {
+func (s *sliceArrayEncoder) AppendArray(v ArrayMarshaler) (retErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			retErr = s.appendPanicked(v, r)
+		}
+	}()
+
 	enc := &sliceArrayEncoder{}
 	err := v.MarshalLogArray(enc)
 	s.elems = append(s.elems, enc.elems)
 	return err
 }
 
-func (s *sliceArrayEncoder) AppendObject(v ObjectMarshaler) error {
+func (s *sliceArrayEncoder) AppendObject(v ObjectMarshaler) (retErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			retErr = s.appendPanicked(v, r)
+		}
+	}()
+
 	m := NewMapObjectEncoder()
 	err := v.MarshalLogObject(m)
 	s.elems = append(s.elems, m.Fields)
 	return err
 }
 
+// appendPanicked appends the placeholder for a marshaler that panicked.
+func (s *sliceArrayEncoder) appendPanicked(marshaler, r interface{}) error {
+	placeholder, err := marshalerPanic(marshaler, r)
+	s.elems = append(s.elems, placeholder)
+	return err
+}
+
 func (s *sliceArrayEncoder) AppendReflected(v interface{}) error {
+	if rs, ok := v.(RedactedStringer); ok {
+		v = rs.RedactedString()
+	}
 	s.elems = append(s.elems, v)
 	return nil
 }
@@ -368,3 +368,111 @@ func TestMapObjectEncoderReflectionFailures(t *testing.T) {
 		"Expected encoder to use empty values on errors.",
 	)
 }
+
+func TestMapObjectEncoderClone(t *testing.T) {
+	for _, newEncoder := range []func() *MapObjectEncoder{NewMapObjectEncoder, NewOrderedMapObjectEncoder} {
+		enc := newEncoder()
+		enc.AddString("a", "1")
+		enc.OpenNamespace("ns")
+		enc.AddString("b", "2")
+		assert.NoError(t, enc.AddObject("obj", loggable{true}), "Expected AddObject to succeed.")
+		assert.NoError(t, enc.AddArray("arr", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+			arr.AppendString("x")
+			return nil
+		})), "Expected AddArray to succeed.")
+		enc.AddBinary("bin", []byte("foo"))
+
+		snapshot := enc.Clone()
+		want := map[string]interface{}{
+			"a": "1",
+			"ns": map[string]interface{}{
+				"b":   "2",
+				"obj": map[string]interface{}{"loggable": "yes"},
+				"arr": []interface{}{"x"},
+				"bin": []byte("foo"),
+			},
+		}
+		require.Equal(t, want, snapshot.Fields, "Unexpected fields in clone.")
+
+		// Mutating the original after snapshotting must not leak into the
+		// snapshot, even inside the open namespace.
+		enc.AddString("c", "3")
+		enc.OpenNamespace("inner")
+		enc.AddString("d", "4")
+		enc.Fields["ns"].(map[string]interface{})["obj"].(map[string]interface{})["loggable"] = "no"
+		enc.Fields["ns"].(map[string]interface{})["arr"].([]interface{})[0] = "y"
+		enc.Fields["ns"].(map[string]interface{})["bin"].([]byte)[0] = 'g'
+		assert.Equal(t, want, snapshot.Fields, "Mutating the original changed the clone.")
+
+		// The clone keeps writing to its own copy of the open namespace.
+		snapshot.AddString("e", "5")
+		assert.Equal(t, "5", snapshot.Fields["ns"].(map[string]interface{})["e"], "Expected clone to write to its namespace.")
+		assert.NotContains(t, enc.Fields["ns"], "e", "Mutating the clone changed the original.")
+	}
+}
+
+func TestMapObjectEncoderCloneOverwrittenNamespace(t *testing.T) {
+	enc := NewMapObjectEncoder()
+	enc.OpenNamespace("ns")
+	enc.AddString("a", "1")
+	enc.Fields["ns"] = "overwritten"
+
+	clone := enc.Clone()
+	clone.AddString("b", "2")
+	assert.Equal(t, map[string]interface{}{"ns": "overwritten"}, clone.Fields, "Unexpected clone fields.")
+}
+
+func TestMapObjectEncoderKeys(t *testing.T) {
+	encode := func(enc *MapObjectEncoder) {
+		enc.AddString("z", "")
+		enc.AddInt("a", 0)
+		enc.AddBool("m", false)
+		enc.AddString("z", "again") // re-adding keeps the first position
+		assert.NoError(t, enc.AddObject("obj", ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+			enc.AddString("y", "")
+			enc.AddString("b", "")
+			return nil
+		})), "Expected AddObject to succeed.")
+		enc.OpenNamespace("ns")
+		enc.AddString("q", "")
+		enc.AddString("c", "")
+		enc.OpenNamespace("deeper")
+		enc.AddString("x", "")
+		enc.AddString("d", "")
+	}
+
+	tests := []struct {
+		desc string
+		enc  *MapObjectEncoder
+		path []string
+		want []string
+	}{
+		{"ordered", NewOrderedMapObjectEncoder(), nil, []string{"z", "a", "m", "obj", "ns"}},
+		{"ordered object", NewOrderedMapObjectEncoder(), []string{"obj"}, []string{"y", "b"}},
+		{"ordered namespace", NewOrderedMapObjectEncoder(), []string{"ns"}, []string{"q", "c", "deeper"}},
+		{"ordered nested namespace", NewOrderedMapObjectEncoder(), []string{"ns", "deeper"}, []string{"x", "d"}},
+		{"ordered missing path", NewOrderedMapObjectEncoder(), []string{"nope"}, nil},
+		{"ordered non-map path", NewOrderedMapObjectEncoder(), []string{"z"}, nil},
+		{"unordered", NewMapObjectEncoder(), nil, []string{"a", "m", "ns", "obj", "z"}},
+		{"unordered namespace", NewMapObjectEncoder(), []string{"ns"}, []string{"c", "deeper", "q"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			encode(tt.enc)
+			assert.Equal(t, tt.want, tt.enc.Keys(tt.path...), "Unexpected keys.")
+			assert.Equal(t, tt.want, tt.enc.Clone().Keys(tt.path...), "Unexpected keys in clone.")
+		})
+	}
+}
+
+func TestOrderedMapObjectEncoderDirectFields(t *testing.T) {
+	enc := NewOrderedMapObjectEncoder()
+	enc.AddString("z", "")
+	enc.AddString("y", "")
+	enc.Fields["b"] = "added directly"
+	enc.Fields["a"] = "added directly"
+	delete(enc.Fields, "z")
+
+	assert.Equal(t, []string{"y", "a", "b"}, enc.Keys(), "Expected direct fields to be sorted after ordered keys.")
+}
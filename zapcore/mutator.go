@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+// DropEntry is returned by the functions passed to NewMutatorCore to drop
+// an entry instead of writing it. Any entry with InvalidLevel is dropped.
+var DropEntry = Entry{Level: InvalidLevel}
+
+type mutatorCore struct {
+	core   Core
+	mutate func(Entry, []Field) (Entry, []Field)
+
+	// context holds the fields added with With. They're kept here, rather
+	// than added to the wrapped Core, so that mutate can see them.
+	context []Field
+}
+
+var (
+	_ Core           = (*mutatorCore)(nil)
+	_ LeveledEnabler = (*mutatorCore)(nil)
+)
+
+// NewMutatorCore wraps a Core, passing each entry that it writes through fn
+// first. fn receives the entry along with all of its fields, both those
+// added with With and those passed to the log call, in that order, and
+// returns the entry and fields to write. It may change anything about the
+// entry, including its level, or return DropEntry to drop it. fn must not
+// modify the slice of fields it's given or retain it after returning; to
+// change the fields, return a new slice.
+//
+// For example, to add a field to every entry:
+//
+//	core = zapcore.NewMutatorCore(core, func(ent zapcore.Entry, fs []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+//		return ent, append(fs[:len(fs):len(fs)], zap.String("host", host))
+//	})
+//
+// fn runs only for entries whose level is enabled, but before the wrapped
+// Core's Check, which sees the mutated entry. When sampling, this makes the
+// order of wrapping significant. A mutator wrapping a sampler runs for every
+// enabled entry, including those the sampler then drops, and the sampler
+// counts the mutated entries. A mutator wrapped by a sampler runs only for
+// the entries that the sampler keeps, and the sampler counts the original
+// entries. Similarly, a mutator wrapping a Tee applies to all of the Tee's
+// Cores, while a mutator inside a Tee applies only to the Core it wraps.
+func NewMutatorCore(core Core, fn func(Entry, []Field) (Entry, []Field)) Core {
+	return &mutatorCore{
+		core:   core,
+		mutate: fn,
+	}
+}
+
+func (c *mutatorCore) Enabled(lvl Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *mutatorCore) Level() Level {
+	return LevelOf(c.core)
+}
+
+func (c *mutatorCore) With(fields []Field) Core {
+	n := len(c.context)
+	return &mutatorCore{
+		core:    c.core,
+		mutate:  c.mutate,
+		context: append(c.context[:n:n], fields...),
+	}
+}
+
+func (c *mutatorCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *mutatorCore) Write(ent Entry, fields []Field) error {
+	if n := len(c.context); n > 0 {
+		fields = append(c.context[:n:n], fields...)
+	}
+	ent, fields = c.mutate(ent, fields)
+	if ent.Level == InvalidLevel {
+		return nil
+	}
+	return checkAndWrite(c.core, ent, fields)
+}
+
+func (c *mutatorCore) Sync() error {
+	return c.core.Sync()
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"testing"
+	"time"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dropDebug drops debug entries and renames the "user" field to "uid".
+func dropDebug(ent Entry, fields []Field) (Entry, []Field) {
+	if ent.Level == DebugLevel {
+		return DropEntry, nil
+	}
+	out := make([]Field, len(fields))
+	for i, f := range fields {
+		if f.Key == "user" {
+			f.Key = "uid"
+		}
+		out[i] = f
+	}
+	return ent, out
+}
+
+func TestMutatorCore(t *testing.T) {
+	inner, logs := observer.New(DebugLevel)
+	core := NewMutatorCore(inner, dropDebug)
+
+	assert.Equal(t, DebugLevel, LevelOf(core), "Unexpected level.")
+	assert.True(t, core.Enabled(DebugLevel), "Expected the wrapped core's levels to be enabled.")
+
+	parent := makeInt64Field("user", 1)
+	child := core.With([]Field{parent})
+	for _, lvl := range []Level{DebugLevel, InfoLevel} {
+		ent := Entry{Level: lvl, Message: "hello"}
+		if ce := child.Check(ent, nil); assert.NotNil(t, ce, "Expected %v entry to pass Check.", lvl) {
+			ce.Write(makeInt64Field("user", 2))
+		}
+	}
+
+	assert.Equal(t, []observer.LoggedEntry{{
+		Entry:   Entry{Level: InfoLevel, Message: "hello"},
+		Context: []Field{makeInt64Field("uid", 1), makeInt64Field("uid", 2)},
+	}}, logs.AllUntimed(), "Expected debug entry to be dropped and With fields to be rewritten.")
+	assert.Equal(t, "user", parent.Key, "Mutator must not modify the caller's fields.")
+}
+
+func TestMutatorCoreChangesLevel(t *testing.T) {
+	inner, logs := observer.New(WarnLevel)
+	core := NewMutatorCore(inner, func(ent Entry, fields []Field) (Entry, []Field) {
+		ent.Level = InfoLevel
+		return ent, fields
+	})
+
+	ce := core.Check(Entry{Level: ErrorLevel, Message: "demoted"}, nil)
+	require.NotNil(t, ce, "Expected error entry to pass Check.")
+	ce.Write()
+	assert.Zero(t, logs.Len(), "Expected the wrapped core to reject the demoted entry.")
+}
+
+func TestMutatorCoreSampling(t *testing.T) {
+	// Rewrite every message to the same string, so that the order of
+	// wrapping decides which entries the sampler considers identical.
+	var calls int
+	sameMessage := func(ent Entry, fields []Field) (Entry, []Field) {
+		calls++
+		ent.Message = "same"
+		return ent, fields
+	}
+	write := func(core Core) {
+		for _, msg := range []string{"a", "b", "c"} {
+			if ce := core.Check(Entry{Level: InfoLevel, Message: msg}, nil); ce != nil {
+				ce.Write()
+			}
+		}
+	}
+
+	t.Run("outside", func(t *testing.T) {
+		calls = 0
+		inner, logs := observer.New(DebugLevel)
+		write(NewMutatorCore(NewSamplerWithOptions(inner, time.Minute, 1, 0), sameMessage))
+		assert.Equal(t, 3, calls, "Expected the mutator to run for every enabled entry.")
+		assert.Equal(t, 1, logs.Len(), "Expected the sampler to see the mutated messages.")
+	})
+
+	t.Run("inside", func(t *testing.T) {
+		calls = 0
+		inner, logs := observer.New(DebugLevel)
+		sampler := NewSamplerWithOptions(NewMutatorCore(inner, sameMessage), time.Minute, 1, 0)
+		write(sampler)
+		write(sampler)
+		assert.Equal(t, 3, calls, "Expected the mutator to run only for sampled entries.")
+		assert.Equal(t, 3, logs.Len(), "Expected the sampler to see the original messages.")
+	})
+}
+
+func TestMutatorCoreTee(t *testing.T) {
+	tag := func(ent Entry, fields []Field) (Entry, []Field) {
+		return ent, append(fields[:len(fields):len(fields)], makeInt64Field("tagged", 1))
+	}
+	write := func(core Core) {
+		if ce := core.Check(Entry{Level: InfoLevel, Message: "hello"}, nil); ce != nil {
+			ce.Write()
+		}
+	}
+
+	t.Run("outside", func(t *testing.T) {
+		a, aLogs := observer.New(DebugLevel)
+		b, bLogs := observer.New(DebugLevel)
+		write(NewMutatorCore(NewTee(a, b), tag))
+		require.Equal(t, 1, aLogs.Len())
+		require.Equal(t, 1, bLogs.Len())
+		assert.Len(t, aLogs.All()[0].Context, 1, "Expected mutation to apply to all cores.")
+		assert.Len(t, bLogs.All()[0].Context, 1, "Expected mutation to apply to all cores.")
+	})
+
+	t.Run("inside", func(t *testing.T) {
+		a, aLogs := observer.New(DebugLevel)
+		b, bLogs := observer.New(DebugLevel)
+		write(NewTee(NewMutatorCore(a, tag), b))
+		require.Equal(t, 1, aLogs.Len())
+		require.Equal(t, 1, bLogs.Len())
+		assert.Len(t, aLogs.All()[0].Context, 1, "Expected mutation to apply to the wrapped core.")
+		assert.Empty(t, bLogs.All()[0].Context, "Expected other cores to be unaffected.")
+	})
+}
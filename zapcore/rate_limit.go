@@ -0,0 +1,363 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+const (
+	_defaultRateLimitBuckets = 4096
+	_defaultRateLimitWindow  = time.Minute
+)
+
+// rateLimitOptionFunc wraps a func so it satisfies the RateLimitOption
+// interface.
+type rateLimitOptionFunc func(*rateLimiter)
+
+func (f rateLimitOptionFunc) apply(r *rateLimiter) {
+	f(r)
+}
+
+// RateLimitOption configures a rate-limiting Core.
+type RateLimitOption interface {
+	apply(*rateLimiter)
+}
+
+// RateLimitHook registers a function which will be called each time the
+// rate-limiting Core lets an entry through or drops it. The decision is
+// reported with the same values as the Sampler's: LogSampled for entries
+// that were written and LogDropped for entries that were suppressed.
+//
+// Use it to track metrics of suppressed logs:
+//
+//	var dropped atomic.Int64
+//	zapcore.RateLimitHook(func(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+//	  if dec&zapcore.LogDropped > 0 {
+//	    dropped.Add(1)
+//	  }
+//	})
+func RateLimitHook(hook func(entry Entry, dec SamplingDecision)) RateLimitOption {
+	return rateLimitOptionFunc(func(r *rateLimiter) {
+		r.hook = hook
+	})
+}
+
+// RateLimitKeyFunc sets the function used to group entries for rate
+// limiting. Entries with the same level and key share a limit.
+//
+// By default, entries are grouped by message. The function also receives the
+// fields passed at the log site, so entries may be keyed by a field's value:
+//
+//	zapcore.RateLimitKeyFunc(func(ent zapcore.Entry, fields []zapcore.Field) string {
+//	  for _, f := range fields {
+//	    if f.Key == "tenant" {
+//	      return ent.Message + ":" + f.String
+//	    }
+//	  }
+//	  return ent.Message
+//	})
+func RateLimitKeyFunc(key func(Entry, []Field) string) RateLimitOption {
+	return rateLimitOptionFunc(func(r *rateLimiter) {
+		r.key = key
+	})
+}
+
+// RateLimitBuckets sets the number of buckets the Core keeps for each level.
+// Keys that hash to the same bucket share a limit, so increase this if
+// entries have many distinct keys. Values less than one are ignored.
+//
+// Defaults to 4096.
+func RateLimitBuckets(perLevel int) RateLimitOption {
+	return rateLimitOptionFunc(func(r *rateLimiter) {
+		if perLevel > 0 {
+			r.bucketsPerLevel = perLevel
+		}
+	})
+}
+
+// RateLimitWindow sets how long a key may stay suppressed before the Core
+// summarizes the entries it dropped. Non-positive durations are ignored.
+//
+// Defaults to one minute.
+func RateLimitWindow(window time.Duration) RateLimitOption {
+	return rateLimitOptionFunc(func(r *rateLimiter) {
+		if window > 0 {
+			r.window = window
+		}
+	})
+}
+
+// RateLimitClock sets the Clock used to measure the rate of entries. By
+// default, the Core uses each entry's timestamp, and the system clock when
+// syncing.
+func RateLimitClock(clock Clock) RateLimitOption {
+	return rateLimitOptionFunc(func(r *rateLimiter) {
+		r.clock.Store(clock)
+	})
+}
+
+// NewRateLimitCore creates a Core that allows at most limit entries per
+// second, with bursts of up to burst entries, for each level and key. Entries
+// over the limit are dropped.
+//
+// Unlike the Sampler, the Core doesn't drop entries silently. Once a key has
+// been suppressed for the configured window (see RateLimitWindow), the next
+// entry with that key is preceded by a summary entry at the same level, such
+// as
+//
+//	suppressed 1523 similar messages in the last 1m0s
+//
+// with the suppressed count and the original message attached as the
+// "suppressed" and "suppressedMessage" fields. Syncing the Core writes
+// summaries for all suppressed keys immediately.
+//
+// Entries at PanicLevel and above are never rate limited. If limit isn't
+// positive, NewRateLimitCore returns the provided Core unchanged.
+//
+// Like the Sampler, the Core is optimized for speed over precision: keys are
+// tracked in a fixed number of buckets without locks, so keys that hash to
+// the same bucket share a limit, and under heavy concurrency a few entries
+// may be attributed to the wrong suppression window.
+func NewRateLimitCore(core Core, limit float64, burst int, opts ...RateLimitOption) Core {
+	if limit <= 0 {
+		return core
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	interval := float64(time.Second) / limit
+	if max := float64(math.MaxInt64 / int64(burst)); interval > max {
+		interval = max
+	}
+
+	r := &rateLimiter{
+		interval:        int64(interval),
+		burst:           int64(burst),
+		window:          _defaultRateLimitWindow,
+		bucketsPerLevel: _defaultRateLimitBuckets,
+		hook:            nopSamplingHook,
+		clock:           new(clockRef),
+	}
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+	r.buckets = make([]rateBucket, int(_numLevels)*r.bucketsPerLevel)
+
+	return &rateLimitCore{
+		core:    core,
+		limiter: r,
+		clock:   r.clock,
+	}
+}
+
+// rateLimiter holds the state shared by a rate-limiting Core and the Cores
+// derived from it with With.
+type rateLimiter struct {
+	interval        int64 // nanoseconds between entries
+	burst           int64
+	window          time.Duration
+	bucketsPerLevel int
+	buckets         []rateBucket
+	key             func(Entry, []Field) string
+	hook            func(Entry, SamplingDecision)
+	clock           *clockRef
+}
+
+func (r *rateLimiter) bucket(lvl Level, key string) *rateBucket {
+	i := int(lvl - _minLevel)
+	j := int(fnv32a(key) % uint32(r.bucketsPerLevel))
+	return &r.buckets[i*r.bucketsPerLevel+j]
+}
+
+// rateBucket tracks the rate of entries for one level and key hash.
+type rateBucket struct {
+	// tat is the theoretical arrival time, in Unix nanoseconds, of the next
+	// entry if entries arrived exactly at the limit.
+	tat atomic.Int64
+
+	// dropped is the number of entries suppressed since suppressedAt.
+	dropped atomic.Uint64
+
+	// suppressedAt is the time, in Unix nanoseconds, of the first entry
+	// suppressed in the current window, or zero.
+	suppressedAt atomic.Int64
+
+	// first is the first entry suppressed in the current window.
+	first atomic.Pointer[Entry]
+}
+
+// take reports whether an entry arriving at now fits within the limit. It
+// implements the generic cell rate algorithm, which needs no lock.
+func (b *rateBucket) take(now, interval, burst int64) bool {
+	for {
+		tat := b.tat.Load()
+		next := tat
+		if next < now {
+			next = now
+		}
+		next += interval
+		if next-now > burst*interval {
+			return false
+		}
+		if b.tat.CompareAndSwap(tat, next) {
+			return true
+		}
+	}
+}
+
+// drop records an entry suppressed at now.
+func (b *rateBucket) drop(ent Entry, now int64) {
+	b.dropped.Add(1)
+	if b.suppressedAt.CompareAndSwap(0, now) {
+		b.first.Store(&Entry{
+			Level:      ent.Level,
+			LoggerName: ent.LoggerName,
+			Message:    ent.Message,
+		})
+	}
+}
+
+// summarize ends the current suppression window if it started at least
+// window ago, returning a summary of the entries suppressed in it. It returns
+// false if there is nothing to summarize.
+func (b *rateBucket) summarize(now int64, window time.Duration) (Entry, []Field, bool) {
+	since := b.suppressedAt.Load()
+	if since == 0 || now-since < int64(window) {
+		return Entry{}, nil, false
+	}
+	if !b.suppressedAt.CompareAndSwap(since, 0) {
+		// Another goroutine ended this window.
+		return Entry{}, nil, false
+	}
+
+	n := b.dropped.Swap(0)
+	first := b.first.Load()
+	if n == 0 || first == nil {
+		return Entry{}, nil, false
+	}
+
+	elapsed := time.Duration(now - since).Round(time.Millisecond)
+	ent := *first
+	ent.Time = time.Unix(0, now)
+	ent.Message = fmt.Sprintf("suppressed %d similar messages in the last %v", n, elapsed)
+	return ent, []Field{
+		{Key: "suppressed", Type: Uint64Type, Integer: int64(n)},
+		{Key: "suppressedMessage", Type: StringType, String: first.Message},
+	}, true
+}
+
+type rateLimitCore struct {
+	core    Core
+	limiter *rateLimiter
+	clock   *clockRef // shared with the Cores derived with With
+}
+
+var (
+	_ Core           = (*rateLimitCore)(nil)
+	_ LeveledEnabler = (*rateLimitCore)(nil)
+	_ ClockCopier    = (*rateLimitCore)(nil)
+)
+
+func (c *rateLimitCore) Enabled(lvl Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *rateLimitCore) Level() Level {
+	return LevelOf(c.core)
+}
+
+func (c *rateLimitCore) WithClock(clock Clock) Core {
+	return &rateLimitCore{
+		core:    WithClock(c.core, clock),
+		limiter: c.limiter,
+		clock:   newClockRef(clock),
+	}
+}
+
+func (c *rateLimitCore) With(fields []Field) Core {
+	return &rateLimitCore{
+		core:    c.core.With(fields),
+		limiter: c.limiter,
+		clock:   c.clock,
+	}
+}
+
+func (c *rateLimitCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if ent.Level >= PanicLevel || ent.Level < _minLevel || ent.Level > _maxLevel {
+		return c.core.Check(ent, ce)
+	}
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	// Keys may depend on fields, so the limit is applied in Write.
+	return ce.AddCore(ent, c)
+}
+
+func (c *rateLimitCore) Write(ent Entry, fields []Field) error {
+	r := c.limiter
+	key := ent.Message
+	if r.key != nil {
+		key = r.key(ent, fields)
+	}
+
+	now := ent.Time.UnixNano()
+	if clock := c.clock.Load(); clock != nil {
+		now = clock.Now().UnixNano()
+	}
+	b := r.bucket(ent.Level, key)
+
+	var err error
+	if sum, sumFields, ok := b.summarize(now, r.window); ok {
+		err = checkAndWrite(c.core, sum, sumFields)
+	}
+
+	if !b.take(now, r.interval, r.burst) {
+		b.drop(ent, now)
+		r.hook(ent, LogDropped)
+		return err
+	}
+	r.hook(ent, LogSampled)
+	return multierr.Append(err, checkAndWrite(c.core, ent, fields))
+}
+
+func (c *rateLimitCore) Sync() error {
+	r := c.limiter
+	clock := c.clock.Load()
+	if clock == nil {
+		clock = DefaultClock
+	}
+	now := clock.Now().UnixNano()
+
+	var err error
+	for i := range r.buckets {
+		if sum, fields, ok := r.buckets[i].summarize(now, 0); ok {
+			err = multierr.Append(err, checkAndWrite(c.core, sum, fields))
+		}
+	}
+	return multierr.Append(err, c.core.Sync())
+}
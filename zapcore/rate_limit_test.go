@@ -0,0 +1,287 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zapcore/clock"
+	"github.com/toujourser/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _rateLimitEpoch = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func writeAt(core Core, lvl Level, msg string, at time.Duration, fields ...Field) {
+	ent := Entry{Level: lvl, Message: msg, Time: _rateLimitEpoch.Add(at)}
+	if ce := core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
+func loggedMessages(logs *observer.ObservedLogs) []string {
+	var msgs []string
+	for _, entry := range logs.TakeAll() {
+		msgs = append(msgs, entry.Message)
+	}
+	return msgs
+}
+
+func TestRateLimitCore(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := NewRateLimitCore(obs, 1, 3, RateLimitWindow(10*time.Second))
+
+	for i := 0; i < 10; i++ {
+		writeAt(core, InfoLevel, "foo", 0)
+	}
+	writeAt(core, InfoLevel, "bar", 0)
+	writeAt(core, WarnLevel, "foo", 0)
+	assert.Equal(t, []string{"foo", "foo", "foo", "bar", "foo"}, loggedMessages(logs),
+		"Expected only the burst to be logged for each level and message.")
+
+	// One token is refilled each second.
+	writeAt(core, InfoLevel, "foo", time.Second)
+	writeAt(core, InfoLevel, "foo", time.Second)
+	assert.Equal(t, []string{"foo"}, loggedMessages(logs), "Expected one entry after a second.")
+
+	// Once the window ends, the next entry is preceded by a summary.
+	writeAt(core, InfoLevel, "foo", 10*time.Second)
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2, "Expected a summary and an entry.")
+	assert.Equal(t, InfoLevel, entries[0].Level, "Unexpected summary level.")
+	assert.Equal(t, "suppressed 8 similar messages in the last 10s", entries[0].Message, "Unexpected summary.")
+	assert.Equal(t, map[string]interface{}{
+		"suppressed":        uint64(8),
+		"suppressedMessage": "foo",
+	}, entries[0].ContextMap(), "Unexpected summary fields.")
+	assert.Equal(t, "foo", entries[1].Message, "Unexpected entry after summary.")
+
+	// Nothing is left to summarize.
+	writeAt(core, InfoLevel, "foo", time.Minute)
+	assert.Equal(t, []string{"foo"}, loggedMessages(logs), "Unexpected second summary.")
+}
+
+func TestRateLimitCoreSummaryOnSuppressedEntry(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := NewRateLimitCore(obs, 0.001, 1, RateLimitWindow(time.Minute))
+
+	writeAt(core, InfoLevel, "foo", 0)
+	for i := 0; i < 5; i++ {
+		writeAt(core, InfoLevel, "foo", time.Duration(i)*time.Second)
+	}
+	writeAt(core, InfoLevel, "foo", 2*time.Minute)
+	assert.Equal(t, []string{
+		"foo",
+		"suppressed 5 similar messages in the last 2m0s",
+	}, loggedMessages(logs), "Expected a summary even while the key is still limited.")
+}
+
+func TestRateLimitCoreSync(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := NewRateLimitCore(obs, 1, 1)
+
+	writeAt(core, ErrorLevel, "foo", 0)
+	writeAt(core, ErrorLevel, "foo", 0)
+	writeAt(core, ErrorLevel, "foo", 0)
+	writeAt(core, DebugLevel, "bar", 0)
+	writeAt(core, DebugLevel, "bar", 0)
+	assert.Equal(t, []string{"foo", "bar"}, loggedMessages(logs), "Unexpected entries before sync.")
+
+	require.NoError(t, core.Sync(), "Unexpected error syncing.")
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2, "Expected a summary for each suppressed key.")
+	summaries := make(map[Level]interface{})
+	for _, entry := range entries {
+		summaries[entry.Level] = entry.ContextMap()["suppressed"]
+	}
+	assert.Equal(t, map[Level]interface{}{
+		DebugLevel: uint64(1),
+		ErrorLevel: uint64(2),
+	}, summaries, "Unexpected summaries after sync.")
+
+	require.NoError(t, core.Sync(), "Unexpected error syncing.")
+	assert.Zero(t, logs.Len(), "Expected no summaries after a second sync.")
+}
+
+func TestRateLimitCoreNeverSuppressesPanicAndFatal(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := NewRateLimitCore(obs, 1, 1)
+
+	for _, lvl := range []Level{PanicLevel, FatalLevel} {
+		for i := 0; i < 5; i++ {
+			writeAt(core, lvl, "foo", 0)
+		}
+	}
+	assert.Equal(t, 10, logs.Len(), "Expected panic and fatal entries to never be suppressed.")
+}
+
+func TestRateLimitCoreKeyFunc(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := NewRateLimitCore(obs, 1, 1, RateLimitKeyFunc(func(ent Entry, fields []Field) string {
+		for _, f := range fields {
+			if f.Key == "tenant" {
+				return f.String
+			}
+		}
+		return ent.Message
+	}))
+
+	tenant := func(name string) Field {
+		return Field{Key: "tenant", Type: StringType, String: name}
+	}
+	writeAt(core, InfoLevel, "foo", 0, tenant("a"))
+	writeAt(core, InfoLevel, "bar", 0, tenant("a"))
+	writeAt(core, InfoLevel, "foo", 0, tenant("b"))
+	writeAt(core, InfoLevel, "foo", 0)
+	writeAt(core, InfoLevel, "baz", 0)
+	assert.Equal(t, []string{"foo", "foo", "foo", "baz"}, loggedMessages(logs), "Unexpected entries keyed by field.")
+}
+
+func TestRateLimitCoreHook(t *testing.T) {
+	var sampled, dropped atomic.Int64
+	hook := RateLimitHook(func(_ Entry, dec SamplingDecision) {
+		if dec&LogDropped > 0 {
+			dropped.Add(1)
+		} else if dec&LogSampled > 0 {
+			sampled.Add(1)
+		}
+	})
+
+	obs, logs := observer.New(InfoLevel)
+	core := NewRateLimitCore(obs, 1, 2, hook)
+	for i := 0; i < 5; i++ {
+		writeAt(core, InfoLevel, "foo", 0)
+	}
+	writeAt(core, DebugLevel, "foo", 0)
+
+	assert.Equal(t, 2, logs.Len(), "Unexpected number of entries logged.")
+	assert.Equal(t, int64(2), sampled.Load(), "Unexpected number of sampled entries.")
+	assert.Equal(t, int64(3), dropped.Load(), "Unexpected number of dropped entries.")
+}
+
+func TestRateLimitCoreWith(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core := NewRateLimitCore(obs, 1, 1)
+	child := core.With([]Field{{Key: "child", Type: BoolType, Integer: 1}})
+
+	writeAt(child, InfoLevel, "foo", 0)
+	writeAt(core, InfoLevel, "foo", 0)
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1, "Expected derived cores to share limits.")
+	assert.Equal(t, map[string]interface{}{"child": true}, entries[0].ContextMap(), "Unexpected context.")
+}
+
+func TestRateLimitCoreLevel(t *testing.T) {
+	obs, _ := observer.New(WarnLevel)
+	core := NewRateLimitCore(obs, 1, 1)
+	assert.Equal(t, WarnLevel, LevelOf(core), "Unexpected level.")
+	assert.False(t, core.Enabled(InfoLevel), "Expected info to be disabled.")
+	assert.True(t, core.Enabled(ErrorLevel), "Expected error to be enabled.")
+}
+
+func TestRateLimitCoreDisabled(t *testing.T) {
+	obs, _ := observer.New(DebugLevel)
+	assert.Equal(t, obs, NewRateLimitCore(obs, 0, 10), "Expected a non-positive limit to disable rate limiting.")
+}
+
+func TestRateLimitCoreConcurrent(t *testing.T) {
+	const (
+		goroutines = 8
+		perRoutine = 1000
+		burst      = 100
+	)
+
+	var dropped atomic.Int64
+	obs, logs := observer.New(DebugLevel)
+	core := NewRateLimitCore(obs, 0.001, burst, RateLimitHook(func(_ Entry, dec SamplingDecision) {
+		if dec&LogDropped > 0 {
+			dropped.Add(1)
+		}
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perRoutine; j++ {
+				writeAt(core, InfoLevel, "foo", 0)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, burst, logs.Len(), "Expected exactly the burst to be logged.")
+	assert.Equal(t, int64(goroutines*perRoutine-burst), dropped.Load(), "Unexpected number of dropped entries.")
+}
+
+func TestRateLimitCoreClock(t *testing.T) {
+	clk := clock.NewMockAt(_rateLimitEpoch)
+	obs, logs := observer.New(DebugLevel)
+	core := NewRateLimitCore(obs, 1, 1, RateLimitClock(clk), RateLimitWindow(time.Minute))
+
+	write := func() {
+		// Entry timestamps are ignored in favor of the clock.
+		if ce := core.Check(Entry{Level: InfoLevel, Message: "foo"}, nil); ce != nil {
+			ce.Write()
+		}
+	}
+
+	write()
+	write()
+	assert.Equal(t, []string{"foo"}, loggedMessages(logs), "Expected the second entry to be dropped.")
+
+	clk.Add(time.Second)
+	write()
+	assert.Equal(t, []string{"foo"}, loggedMessages(logs), "Expected a token after a second.")
+
+	write()
+	clk.Add(time.Minute)
+	require.NoError(t, core.Sync(), "Unexpected error syncing.")
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1, "Expected a summary.")
+	assert.Equal(t, "suppressed 2 similar messages in the last 1m1s", entries[0].Message, "Unexpected summary.")
+	assert.True(t, clk.Now().Equal(entries[0].Time), "Expected the summary to be stamped by the clock.")
+}
+
+func TestRateLimitCoreWithClock(t *testing.T) {
+	clk := clock.NewMockAt(_rateLimitEpoch)
+	obs, logs := observer.New(DebugLevel)
+	core := WithClock(NewRateLimitCore(NewSamplerWithOptions(obs, time.Second, 1, 0), 100, 100), clk)
+
+	write := func() {
+		if ce := core.Check(Entry{Level: InfoLevel, Message: "foo"}, nil); ce != nil {
+			ce.Write()
+		}
+	}
+	write()
+	write()
+	clk.Add(time.Second)
+	write()
+	assert.Equal(t, 2, logs.Len(), "Expected the wrapped sampler to follow the clock.")
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import "fmt"
+
+// RedactedPlaceholder is logged in place of sensitive values unless the
+// encoder is configured to reveal them.
+const RedactedPlaceholder = "[REDACTED]"
+
+// RedactedStringer is implemented by types that hold sensitive values, such
+// as credentials or tokens.
+//
+// Zap's encoders recognize RedactedStringers passed to AddReflected and
+// AppendReflected, and fields built from them with zap.Stringer or zap.Any,
+// and log the result of RedactedString in their place. The String method,
+// which should return the real value, is used only if the encoder is
+// configured with EncoderConfig.RevealSecrets.
+type RedactedStringer interface {
+	fmt.Stringer
+
+	// RedactedString returns a placeholder that is safe to log.
+	RedactedString() string
+}
+
+// secretRevealer is implemented by encoders that may log sensitive values
+// as-is. Encoders that embed *EncoderConfig implement it automatically.
+type secretRevealer interface {
+	revealSecrets() bool
+}
+
+func (cfg *EncoderConfig) revealSecrets() bool {
+	return cfg.RevealSecrets
+}
+
+// shouldRevealSecrets reports whether the given encoder is configured to log
+// sensitive values as-is.
+func shouldRevealSecrets(enc interface{}) bool {
+	r, ok := enc.(secretRevealer)
+	return ok && r.revealSecrets()
+}
+
+// redactedString returns the string to log for a value that holds a secret.
+func redactedString(enc interface{}, val RedactedStringer) string {
+	if shouldRevealSecrets(enc) {
+		return val.String()
+	}
+	return val.RedactedString()
+}
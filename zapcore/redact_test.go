@@ -0,0 +1,137 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap"
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+)
+
+const rawSecret = "hunter2"
+
+// apiToken is a RedactedStringer holding a secret.
+type apiToken string
+
+func (t apiToken) String() string         { return string(t) }
+func (t apiToken) RedactedString() string { return "token:" + RedactedPlaceholder }
+
+// credentials logs secrets from within an ObjectMarshaler.
+type credentials struct {
+	user  string
+	token apiToken
+}
+
+func (c credentials) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("user", c.user)
+	zap.Secret("password", rawSecret).AddTo(enc)
+	if err := enc.AddReflected("token", c.token); err != nil {
+		return err
+	}
+	return enc.AddArray("tokens", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+		return arr.AppendReflected(c.token)
+	}))
+}
+
+func TestRedactedEncoding(t *testing.T) {
+	fields := []Field{
+		zap.Secret("password", rawSecret),
+		zap.Redact(zap.String("session", rawSecret)),
+		zap.Redact(zap.Int("pin", 1234)),
+		zap.Stringer("token", apiToken(rawSecret)),
+		zap.Any("anyToken", apiToken(rawSecret)),
+		zap.Object("creds", credentials{user: "alice", token: rawSecret}),
+	}
+
+	encoders := []struct {
+		name string
+		new  func(EncoderConfig) Encoder
+	}{
+		{"json", NewJSONEncoder},
+		{"console", NewConsoleEncoder},
+		{"logfmt", NewLogfmtEncoder},
+	}
+
+	for _, tt := range encoders {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Run("masked", func(t *testing.T) {
+				cfg := testEncoderConfig()
+				enc := tt.new(cfg)
+				enc.AddString("other", "visible")
+				for _, f := range fields {
+					f.AddTo(enc)
+				}
+
+				buf, err := enc.EncodeEntry(Entry{Message: "login"}, []Field{zap.Secret("late", rawSecret)})
+				require.NoError(t, err, "Unexpected error encoding entry.")
+				out := buf.String()
+				buf.Free()
+
+				assert.NotContains(t, out, rawSecret, "Secret leaked into encoded output.")
+				assert.NotContains(t, out, "1234", "Redacted field leaked into encoded output.")
+				assert.Contains(t, out, "visible", "Expected non-secret fields to be logged.")
+				assert.Contains(t, out, "alice", "Expected non-secret fields in objects to be logged.")
+				assert.Equal(t, 9, strings.Count(out, RedactedPlaceholder), "Unexpected number of placeholders in %q.", out)
+			})
+
+			t.Run("revealed", func(t *testing.T) {
+				cfg := testEncoderConfig()
+				cfg.RevealSecrets = true
+				enc := tt.new(cfg)
+				for _, f := range fields {
+					f.AddTo(enc)
+				}
+
+				buf, err := enc.EncodeEntry(Entry{Message: "login"}, nil)
+				require.NoError(t, err, "Unexpected error encoding entry.")
+				out := buf.String()
+				buf.Free()
+
+				assert.NotContains(t, out, RedactedPlaceholder, "Unexpected placeholder with RevealSecrets.")
+				assert.Equal(t, 7, strings.Count(out, rawSecret), "Unexpected number of secrets in %q.", out)
+				assert.Contains(t, out, "1234", "Expected redacted field to be revealed.")
+			})
+		})
+	}
+}
+
+func TestRedactedMapObjectEncoder(t *testing.T) {
+	enc := NewMapObjectEncoder()
+	zap.Secret("password", rawSecret).AddTo(enc)
+	zap.Redact(zap.Int("pin", 1234)).AddTo(enc)
+	zap.Object("creds", credentials{user: "alice", token: rawSecret}).AddTo(enc)
+
+	assert.Equal(t, map[string]interface{}{
+		"password": RedactedPlaceholder,
+		"pin":      RedactedPlaceholder,
+		"creds": map[string]interface{}{
+			"user":     "alice",
+			"password": RedactedPlaceholder,
+			"token":    "token:" + RedactedPlaceholder,
+			"tokens":   []interface{}{"token:" + RedactedPlaceholder},
+		},
+	}, enc.Fields, "Unexpected encoded fields.")
+}
@@ -0,0 +1,349 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"container/list"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+const _defaultRouterCacheSize = 64
+
+// routerOptionFunc wraps a func so it satisfies the RouterOption interface.
+type routerOptionFunc func(*router)
+
+func (f routerOptionFunc) apply(r *router) {
+	f(r)
+}
+
+// RouterOption configures a router Core.
+type RouterOption interface {
+	apply(*router)
+}
+
+// RouterLevel sets the levels accepted by the router Core. Entries at these
+// levels are routed to a Core, which may still drop them.
+//
+// Since the Core an entry is routed to isn't known until the entry is
+// written, the router accepts all levels by default.
+func RouterLevel(enab LevelEnabler) RouterOption {
+	return routerOptionFunc(func(r *router) {
+		r.enab = enab
+	})
+}
+
+// RouterCacheSize sets the maximum number of Cores the router keeps. When
+// a Core is built for a new value past this limit, the least recently used
+// Core is evicted. Values less than one are ignored.
+//
+// Defaults to 64.
+func RouterCacheSize(n int) RouterOption {
+	return routerOptionFunc(func(r *router) {
+		if n > 0 {
+			r.size = n
+		}
+	})
+}
+
+// RouterOnEvict registers a function that is called with each Core the
+// router evicts from its cache, after the Core is synced and once no writes
+// to it are in progress. Use it to release resources held by the Core, such
+// as open files.
+func RouterOnEvict(f func(value string, core Core)) RouterOption {
+	return routerOptionFunc(func(r *router) {
+		r.onEvict = f
+	})
+}
+
+// NewRouterCore creates a Core that routes each entry to a Core chosen by the
+// value of the field named key, such as a tenant ID. The field may be passed
+// with the entry or added earlier with With; fields passed with the entry
+// take precedence.
+//
+// The Core for each distinct value is built by factory the first time the
+// value is seen, and kept in a cache of limited size (see RouterCacheSize).
+// Entries without the field, and entries for which factory fails, are written
+// to fallback, which may be nil to drop them. Factory errors are also
+// returned from Write so that they reach the logger's error output.
+//
+// Fields with string, byte string, integer, boolean, and fmt.Stringer values
+// may be used for routing. Entries whose fmt.Stringer panics are written to
+// fallback.
+//
+// The Cores returned by factory receive the fields added with With as part
+// of each Write, rather than through their own With method, so they need not
+// be rebuilt for each derived Core. Syncing the router syncs fallback and all
+// cached Cores.
+func NewRouterCore(key string, factory func(value string) (Core, error), fallback Core, opts ...RouterOption) Core {
+	r := &router{
+		key:      key,
+		factory:  factory,
+		fallback: fallback,
+		enab:     _minLevel,
+		size:     _defaultRouterCacheSize,
+		cores:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+	return &routerCore{router: r}
+}
+
+// router holds the state shared by a router Core and the Cores derived from
+// it with With.
+type router struct {
+	key      string
+	factory  func(string) (Core, error)
+	fallback Core
+	enab     LevelEnabler
+	size     int
+	onEvict  func(string, Core)
+
+	mu    sync.Mutex
+	cores map[string]*list.Element // values are *routedCore
+	lru   *list.List               // most recently used first
+}
+
+// routedCore is a Core built by the router's factory.
+type routedCore struct {
+	value   string
+	core    Core
+	refs    int // writes in progress
+	evicted bool
+}
+
+// acquire returns the Core for value, building it if necessary. The caller
+// must release it once done.
+func (r *router) acquire(value string) (*routedCore, error) {
+	r.mu.Lock()
+	if elem, ok := r.cores[value]; ok {
+		r.lru.MoveToFront(elem)
+		rc := elem.Value.(*routedCore)
+		rc.refs++
+		r.mu.Unlock()
+		return rc, nil
+	}
+	r.mu.Unlock()
+
+	// Build the Core without holding the lock, since factories may be
+	// slow. If another goroutine builds one for the same value in the
+	// meantime, theirs wins.
+	core, err := r.factory(value)
+	if err != nil {
+		return nil, err
+	}
+	if core == nil {
+		return nil, fmt.Errorf("router factory returned a nil Core for %q", value)
+	}
+
+	r.mu.Lock()
+	if elem, ok := r.cores[value]; ok {
+		r.lru.MoveToFront(elem)
+		rc := elem.Value.(*routedCore)
+		rc.refs++
+		r.mu.Unlock()
+		r.discard(&routedCore{value: value, core: core})
+		return rc, nil
+	}
+
+	rc := &routedCore{value: value, core: core, refs: 1}
+	r.cores[value] = r.lru.PushFront(rc)
+
+	var evicted []*routedCore
+	for r.lru.Len() > r.size {
+		oldest := r.lru.Remove(r.lru.Back()).(*routedCore)
+		delete(r.cores, oldest.value)
+		oldest.evicted = true
+		if oldest.refs == 0 {
+			evicted = append(evicted, oldest)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, old := range evicted {
+		r.discard(old)
+	}
+	return rc, nil
+}
+
+// release marks a write to rc as done, discarding rc if it was evicted in
+// the meantime.
+func (r *router) release(rc *routedCore) {
+	r.mu.Lock()
+	rc.refs--
+	done := rc.evicted && rc.refs == 0
+	r.mu.Unlock()
+
+	if done {
+		r.discard(rc)
+	}
+}
+
+// discard syncs a Core that's no longer cached and hands it to the eviction
+// hook.
+func (r *router) discard(rc *routedCore) {
+	_ = rc.core.Sync()
+	if r.onEvict != nil {
+		r.onEvict(rc.value, rc.core)
+	}
+}
+
+type routerCore struct {
+	*router
+
+	fields   []Field // added with With
+	value    string  // value of the routing field in fields, if any
+	hasValue bool
+}
+
+var (
+	_ Core           = (*routerCore)(nil)
+	_ LeveledEnabler = (*routerCore)(nil)
+)
+
+func (c *routerCore) Enabled(lvl Level) bool {
+	return c.enab.Enabled(lvl)
+}
+
+func (c *routerCore) Level() Level {
+	return LevelOf(c.enab)
+}
+
+func (c *routerCore) With(fields []Field) Core {
+	clone := &routerCore{
+		router:   c.router,
+		fields:   make([]Field, 0, len(c.fields)+len(fields)),
+		value:    c.value,
+		hasValue: c.hasValue,
+	}
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	if value, ok := c.routeValue(fields); ok {
+		clone.value, clone.hasValue = value, true
+	}
+	return clone
+}
+
+func (c *routerCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	// Fields passed with the entry may choose the route, so routing
+	// happens in Write.
+	return ce.AddCore(ent, c)
+}
+
+func (c *routerCore) Write(ent Entry, fields []Field) error {
+	value, ok := c.routeValue(fields)
+	if !ok {
+		value, ok = c.value, c.hasValue
+	}
+
+	all := fields
+	if len(c.fields) > 0 {
+		all = make([]Field, 0, len(c.fields)+len(fields))
+		all = append(all, c.fields...)
+		all = append(all, fields...)
+	}
+
+	if !ok {
+		return c.writeFallback(ent, all)
+	}
+
+	rc, err := c.acquire(value)
+	if err != nil {
+		err = fmt.Errorf("route to %s=%q: %w", c.key, value, err)
+		return multierr.Append(err, c.writeFallback(ent, all))
+	}
+	defer c.release(rc)
+	return checkAndWrite(rc.core, ent, all)
+}
+
+func (c *routerCore) writeFallback(ent Entry, fields []Field) error {
+	if c.fallback == nil {
+		return nil
+	}
+	return checkAndWrite(c.fallback, ent, fields)
+}
+
+func (c *routerCore) Sync() error {
+	c.mu.Lock()
+	cores := make([]Core, 0, c.lru.Len()+1)
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		cores = append(cores, elem.Value.(*routedCore).core)
+	}
+	c.mu.Unlock()
+
+	if c.fallback != nil {
+		cores = append(cores, c.fallback)
+	}
+
+	var err error
+	for _, core := range cores {
+		err = multierr.Append(err, core.Sync())
+	}
+	return err
+}
+
+// routeValue returns the value of the last routing field in fields.
+func (c *routerCore) routeValue(fields []Field) (string, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		f := fields[i]
+		if f.Key != c.key {
+			continue
+		}
+
+		switch f.Type {
+		case StringType:
+			return f.String, true
+		case ByteStringType:
+			return string(f.Interface.([]byte)), true
+		case Int64Type, Int32Type, Int16Type, Int8Type:
+			return strconv.FormatInt(f.Integer, 10), true
+		case Uint64Type, Uint32Type, Uint16Type, Uint8Type, UintptrType:
+			return strconv.FormatUint(uint64(f.Integer), 10), true
+		case BoolType:
+			return strconv.FormatBool(f.Integer == 1), true
+		case StringerType:
+			if s, ok := f.Interface.(fmt.Stringer); ok {
+				return stringerRoute(s)
+			}
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// stringerRoute returns the routing value of a fmt.Stringer. Stringers that
+// panic have no routing value, so their entries go to the fallback Core.
+func stringerRoute(s fmt.Stringer) (v string, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			v, ok = "", false
+		}
+	}()
+	return s.String(), true
+}
@@ -0,0 +1,389 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// routerTargets builds observed Cores on demand for a router.
+type routerTargets struct {
+	mu    sync.Mutex
+	cores map[string]Core
+	logs  map[string]*observer.ObservedLogs
+	syncs map[string]*int32
+	built int
+}
+
+func newRouterTargets() *routerTargets {
+	return &routerTargets{
+		cores: make(map[string]Core),
+		logs:  make(map[string]*observer.ObservedLogs),
+		syncs: make(map[string]*int32),
+	}
+}
+
+func (t *routerTargets) factory(value string) (Core, error) {
+	if value == "bad" {
+		return nil, errors.New("no such tenant")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Cores rebuilt after eviction share their logs with earlier ones.
+	core, ok := t.cores[value]
+	if !ok {
+		var logs *observer.ObservedLogs
+		core, logs = observer.New(DebugLevel)
+		t.cores[value] = core
+		t.logs[value] = logs
+		t.syncs[value] = new(int32)
+	}
+	t.built++
+	return &countingSyncCore{Core: core, syncs: t.syncs[value]}, nil
+}
+
+func (t *routerTargets) messages(value string) []string {
+	t.mu.Lock()
+	logs, ok := t.logs[value]
+	t.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return loggedMessages(logs)
+}
+
+func (t *routerTargets) syncCount(value string) int32 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return atomic.LoadInt32(t.syncs[value])
+}
+
+type countingSyncCore struct {
+	Core
+
+	syncs *int32
+}
+
+func (c *countingSyncCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *countingSyncCore) Sync() error {
+	atomic.AddInt32(c.syncs, 1)
+	return c.Core.Sync()
+}
+
+func writeRouted(t testing.TB, core Core, msg string, fields ...Field) error {
+	t.Helper()
+	ent := Entry{Level: InfoLevel, Message: msg}
+	require.NotNil(t, core.Check(ent, nil), "Expected router to accept entry.")
+	return core.Write(ent, fields)
+}
+
+func tenant(v string) Field {
+	return Field{Key: "tenant", Type: StringType, String: v}
+}
+
+func TestRouterCoreRoutesByField(t *testing.T) {
+	targets := newRouterTargets()
+	fallback, fallbackLogs := observer.New(DebugLevel)
+	router := NewRouterCore("tenant", targets.factory, fallback)
+
+	require.NoError(t, writeRouted(t, router, "a1", tenant("a")))
+	require.NoError(t, writeRouted(t, router, "b1", tenant("b")))
+	require.NoError(t, writeRouted(t, router, "a2", tenant("a")))
+	require.NoError(t, writeRouted(t, router, "none"))
+
+	assert.Equal(t, []string{"a1", "a2"}, targets.messages("a"))
+	assert.Equal(t, []string{"b1"}, targets.messages("b"))
+	assert.Equal(t, []string{"none"}, loggedMessages(fallbackLogs))
+	assert.Equal(t, 2, targets.built, "Expected one Core per value.")
+}
+
+func TestRouterCoreFieldTypes(t *testing.T) {
+	tests := []struct {
+		field Field
+		want  string
+	}{
+		{Field{Key: "tenant", Type: StringType, String: "s"}, "s"},
+		{Field{Key: "tenant", Type: ByteStringType, Interface: []byte("bs")}, "bs"},
+		{Field{Key: "tenant", Type: Int64Type, Integer: -42}, "-42"},
+		{Field{Key: "tenant", Type: Uint32Type, Integer: 7}, "7"},
+		{Field{Key: "tenant", Type: BoolType, Integer: 1}, "true"},
+		{Field{Key: "tenant", Type: StringerType, Interface: Level(ErrorLevel)}, "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			var got []string
+			router := NewRouterCore("tenant", func(value string) (Core, error) {
+				got = append(got, value)
+				return NewNopCore(), nil
+			}, nil)
+			require.NoError(t, writeRouted(t, router, "msg", tt.field))
+			assert.Equal(t, []string{tt.want}, got, "Unexpected routing value.")
+		})
+	}
+
+	t.Run("unsupported type", func(t *testing.T) {
+		fallback, logs := observer.New(DebugLevel)
+		router := NewRouterCore("tenant", func(string) (Core, error) {
+			t.Fatal("factory must not be called")
+			return nil, nil
+		}, fallback)
+		require.NoError(t, writeRouted(t, router, "msg", Field{Key: "tenant", Type: Float64Type}))
+		assert.Equal(t, []string{"msg"}, loggedMessages(logs))
+	})
+
+	t.Run("panicking stringer", func(t *testing.T) {
+		fallback, logs := observer.New(DebugLevel)
+		router := NewRouterCore("tenant", func(string) (Core, error) {
+			t.Fatal("factory must not be called")
+			return nil, nil
+		}, fallback)
+		field := Field{Key: "tenant", Type: StringerType, Interface: panicStringer{}}
+		require.NoError(t, writeRouted(t, router, "msg", field))
+		assert.Equal(t, []string{"msg"}, loggedMessages(logs))
+	})
+}
+
+func TestRouterCoreWith(t *testing.T) {
+	targets := newRouterTargets()
+	fallback, fallbackLogs := observer.New(DebugLevel)
+	router := NewRouterCore("tenant", targets.factory, fallback)
+
+	request := router.With([]Field{
+		{Key: "request", Type: StringType, String: "r1"},
+		tenant("a"),
+	})
+	nested := request.With([]Field{{Key: "user", Type: StringType, String: "u1"}})
+	other := request.With([]Field{tenant("b")})
+
+	require.NoError(t, writeRouted(t, nested, "from with"))
+	require.NoError(t, writeRouted(t, nested, "overridden", tenant("c")))
+	require.NoError(t, writeRouted(t, other, "rerouted"))
+	require.NoError(t, writeRouted(t, router, "unrouted"))
+
+	targets.mu.Lock()
+	entries := targets.logs["a"].AllUntimed()
+	targets.mu.Unlock()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "from with", entries[0].Message)
+	assert.Equal(t, []Field{
+		{Key: "request", Type: StringType, String: "r1"},
+		tenant("a"),
+		{Key: "user", Type: StringType, String: "u1"},
+	}, entries[0].Context, "Expected accumulated fields to be written in order.")
+
+	assert.Equal(t, []string{"rerouted"}, targets.messages("b"))
+	assert.Equal(t, []string{"overridden"}, targets.messages("c"))
+	assert.Equal(t, []string{"unrouted"}, loggedMessages(fallbackLogs),
+		"Expected With on a derived Core to leave the parent unaffected.")
+}
+
+func TestRouterCoreFactoryError(t *testing.T) {
+	t.Run("fallback", func(t *testing.T) {
+		targets := newRouterTargets()
+		fallback, logs := observer.New(DebugLevel)
+		router := NewRouterCore("tenant", targets.factory, fallback)
+
+		err := writeRouted(t, router, "msg", tenant("bad"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `tenant="bad"`)
+		assert.Contains(t, err.Error(), "no such tenant")
+		assert.Equal(t, []string{"msg"}, loggedMessages(logs))
+	})
+
+	t.Run("not cached", func(t *testing.T) {
+		var calls int
+		router := NewRouterCore("tenant", func(string) (Core, error) {
+			calls++
+			return nil, errors.New("fail")
+		}, nil)
+		assert.Error(t, writeRouted(t, router, "1", tenant("a")))
+		assert.Error(t, writeRouted(t, router, "2", tenant("a")))
+		assert.Equal(t, 2, calls, "Expected failed routes to be retried.")
+	})
+
+	t.Run("nil core", func(t *testing.T) {
+		router := NewRouterCore("tenant", func(string) (Core, error) {
+			return nil, nil
+		}, nil)
+		assert.ErrorContains(t, writeRouted(t, router, "msg", tenant("a")), "nil Core")
+	})
+}
+
+func TestRouterCoreEviction(t *testing.T) {
+	targets := newRouterTargets()
+	var (
+		mu      sync.Mutex
+		evicted []string
+	)
+	router := NewRouterCore("tenant", targets.factory, nil,
+		RouterCacheSize(2),
+		RouterOnEvict(func(value string, core Core) {
+			mu.Lock()
+			defer mu.Unlock()
+			evicted = append(evicted, value)
+		}),
+	)
+
+	require.NoError(t, writeRouted(t, router, "a1", tenant("a")))
+	require.NoError(t, writeRouted(t, router, "b1", tenant("b")))
+	require.NoError(t, writeRouted(t, router, "a2", tenant("a"))) // a is now most recent
+	require.NoError(t, writeRouted(t, router, "c1", tenant("c"))) // evicts b
+
+	assert.Equal(t, []string{"b"}, evicted)
+	assert.Equal(t, int32(1), targets.syncCount("b"), "Expected evicted Core to be synced.")
+	assert.Equal(t, 3, targets.built)
+
+	require.NoError(t, writeRouted(t, router, "b2", tenant("b"))) // rebuilds b, evicts a
+	assert.Equal(t, []string{"b", "a"}, evicted)
+	assert.Equal(t, 4, targets.built)
+	assert.Equal(t, []string{"b1", "b2"}, targets.messages("b"))
+}
+
+func TestRouterCoreSync(t *testing.T) {
+	targets := newRouterTargets()
+	var fallbackSyncs int32
+	fallbackCore, _ := observer.New(DebugLevel)
+	fallback := &countingSyncCore{Core: fallbackCore, syncs: &fallbackSyncs}
+	router := NewRouterCore("tenant", targets.factory, fallback)
+
+	for _, v := range []string{"a", "b", "c"} {
+		require.NoError(t, writeRouted(t, router, v, tenant(v)))
+	}
+	require.NoError(t, router.With([]Field{tenant("d")}).Sync())
+
+	for _, v := range []string{"a", "b", "c"} {
+		assert.Equal(t, int32(1), targets.syncCount(v), "Expected %q to be synced.", v)
+	}
+	assert.Equal(t, int32(1), fallbackSyncs, "Expected fallback to be synced.")
+}
+
+func TestRouterCoreSyncError(t *testing.T) {
+	router := NewRouterCore("tenant", func(v string) (Core, error) {
+		return errSyncCore{Core: NewNopCore(), err: fmt.Errorf("sync %v", v)}, nil
+	}, nil)
+	require.NoError(t, writeRouted(t, router, "a", tenant("a")))
+	require.NoError(t, writeRouted(t, router, "b", tenant("b")))
+
+	err := router.Sync()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sync a")
+	assert.Contains(t, err.Error(), "sync b")
+}
+
+type errSyncCore struct {
+	Core
+
+	err error
+}
+
+func (c errSyncCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c errSyncCore) Sync() error { return c.err }
+
+func TestRouterCoreLevel(t *testing.T) {
+	router := NewRouterCore("tenant", newRouterTargets().factory, nil)
+	assert.Equal(t, TraceLevel, LevelOf(router), "Expected all levels by default.")
+
+	router = NewRouterCore("tenant", newRouterTargets().factory, nil, RouterLevel(WarnLevel))
+	assert.Equal(t, WarnLevel, LevelOf(router.With([]Field{tenant("a")})))
+	assert.Nil(t, router.Check(Entry{Level: InfoLevel}, nil), "Expected entry below level to be dropped.")
+	assert.NotNil(t, router.Check(Entry{Level: WarnLevel}, nil), "Expected entry at level to be accepted.")
+}
+
+func TestRouterCoreTraceLevel(t *testing.T) {
+	target, targetLogs := observer.New(TraceLevel)
+	factory := func(string) (Core, error) { return target, nil }
+	router := NewRouterCore("tenant", factory, nil)
+
+	ent := Entry{Level: TraceLevel, Message: "trace"}
+	require.NotNil(t, router.Check(ent, nil), "Expected router to accept TraceLevel entries by default.")
+	require.NoError(t, router.Write(ent, []Field{tenant("a")}))
+	assert.Equal(t, []string{"trace"}, loggedMessages(targetLogs))
+}
+
+func TestRouterCoreConcurrent(t *testing.T) {
+	targets := newRouterTargets()
+	var (
+		mu      sync.Mutex
+		evicted = make(map[string]int)
+	)
+	router := NewRouterCore("tenant", targets.factory, nil,
+		RouterCacheSize(3),
+		RouterOnEvict(func(value string, core Core) {
+			mu.Lock()
+			defer mu.Unlock()
+			evicted[value]++
+		}),
+	)
+
+	const goroutines, writes = 8, 200
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				v := fmt.Sprint((g + i) % 6)
+				assert.NoError(t, writeRouted(t, router, v, tenant(v)))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	total := 0
+	for v := 0; v < 6; v++ {
+		value := fmt.Sprint(v)
+		msgs := targets.messages(value)
+		for _, m := range msgs {
+			assert.Equal(t, value, m, "Entry routed to the wrong Core.")
+		}
+		total += len(msgs)
+	}
+	assert.Equal(t, goroutines*writes, total, "Expected every entry to be written once.")
+
+	mu.Lock()
+	defer mu.Unlock()
+	for value, n := range evicted {
+		assert.Equal(t, int32(n), targets.syncCount(value), "Expected each eviction of %q to sync it.", value)
+	}
+}
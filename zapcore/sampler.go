@@ -21,30 +21,94 @@
 package zapcore
 
 import (
+	"math"
+	"math/bits"
 	"sync/atomic"
 	"time"
 )
 
 const (
-	_numLevels        = _maxLevel - _minLevel + 1
-	_countersPerLevel = 4096
+	_numLevels               = _maxLevel - _minLevel + 1
+	_defaultCountersPerLevel = 4096
 )
 
 type counter struct {
 	resetAt atomic.Int64
 	counter atomic.Uint64
+
+	// hot is the number of consecutive ticks before the current one in
+	// which the counter exceeded the sampler's first N entries.
+	hot atomic.Uint32
+
+	// owner tags the key that last claimed the counter. It's only used by
+	// samplers that track collisions, and zero if the counter is unclaimed.
+	owner atomic.Uint32
 }
 
-type counters [_numLevels][_countersPerLevel]counter
+type counters struct {
+	perLevel uint32
+	counters []counter
+}
 
-func newCounters() *counters {
-	return &counters{}
+func newCounters(perLevel int) *counters {
+	return &counters{
+		perLevel: uint32(perLevel),
+		counters: make([]counter, int(_numLevels)*perLevel),
+	}
 }
 
 func (cs *counters) get(lvl Level, key string) *counter {
-	i := lvl - _minLevel
-	j := fnv32a(key) % _countersPerLevel
-	return &cs[i][j]
+	i := uint32(lvl - _minLevel)
+	j := fnv32a(key) % cs.perLevel
+	return &cs.counters[i*cs.perLevel+j]
+}
+
+// claim returns a counter for key that no other key has used during the
+// current tick, if possible. Each key has two candidate counters: the one
+// returned by get, and a second chosen by rehashing the key, which is used
+// if another key already holds the first. If both are held, claim returns
+// the first and reports a collision.
+func (cs *counters) claim(lvl Level, key string, t time.Time) (c *counter, collided bool) {
+	base := uint32(lvl-_minLevel) * cs.perLevel
+	h := fnv32a(key)
+	tag := h | 1 // never zero, which marks unclaimed counters
+	primary := &cs.counters[base+h%cs.perLevel]
+	secondary := &cs.counters[base+bits.RotateLeft32(h*0x9e3779b1, 16)%cs.perLevel]
+
+	// Stay on the counter the key already holds, so that its count isn't
+	// split across both.
+	if primary.owner.Load() == tag {
+		return primary, false
+	}
+	if secondary.owner.Load() == tag {
+		return secondary, false
+	}
+
+	tn := t.UnixNano()
+	if primary.tryClaim(tag, tn) {
+		return primary, false
+	}
+	if secondary.tryClaim(tag, tn) {
+		return secondary, false
+	}
+	return primary, true
+}
+
+// tryClaim makes the counter owned by tag if it's unclaimed or its owner
+// hasn't used it during the current tick.
+func (c *counter) tryClaim(tag uint32, tn int64) bool {
+	owner := c.owner.Load()
+	if owner != 0 && c.resetAt.Load() > tn {
+		return false
+	}
+	if !c.owner.CompareAndSwap(owner, tag) {
+		return c.owner.Load() == tag
+	}
+	if owner != 0 {
+		// The previous owner's hot streak doesn't carry over.
+		c.hot.Store(0)
+	}
+	return true
 }
 
 // fnv32a, adapted from "hash/fnv", but without a []byte(string) alloc
@@ -61,14 +125,17 @@ func fnv32a(s string) uint32 {
 	return hash
 }
 
-func (c *counter) IncCheckReset(t time.Time, tick time.Duration) uint64 {
+// IncCheckReset increments the counter, resetting it first if its tick has
+// elapsed. When resetting, it records whether the previous tick was
+// immediately before this one and saw more than hotAbove entries.
+func (c *counter) IncCheckReset(t time.Time, tick time.Duration, hotAbove uint64) uint64 {
 	tn := t.UnixNano()
 	resetAfter := c.resetAt.Load()
 	if resetAfter > tn {
 		return c.counter.Add(1)
 	}
 
-	c.counter.Store(1)
+	prev := c.counter.Swap(1)
 
 	newResetAfter := tn + tick.Nanoseconds()
 	if !c.resetAt.CompareAndSwap(resetAfter, newResetAfter) {
@@ -77,6 +144,11 @@ func (c *counter) IncCheckReset(t time.Time, tick time.Duration) uint64 {
 		return c.counter.Add(1)
 	}
 
+	if prev > hotAbove && tn < resetAfter+tick.Nanoseconds() {
+		c.hot.Add(1)
+	} else {
+		c.hot.Store(0)
+	}
 	return 1
 }
 
@@ -89,6 +161,11 @@ const (
 	LogDropped SamplingDecision = 1 << iota
 	// LogSampled indicates that the Sampler sampled a log entry.
 	LogSampled
+	// LogCollision is set along with LogDropped or LogSampled if the entry
+	// was counted together with entries of another key during the current
+	// tick, so the decision may have been made on account of that key. It's
+	// only reported by samplers created with SamplerTrackCollisions.
+	LogCollision
 )
 
 // optionFunc wraps a func so it satisfies the SamplerOption interface.
@@ -124,6 +201,105 @@ func SamplerHook(hook func(entry Entry, dec SamplingDecision)) SamplerOption {
 	})
 }
 
+// SamplerKeyFunc sets the function used to group entries for sampling.
+// Entries with the same level and key are counted together.
+//
+// By default, entries are grouped by message. For example, to sample entries
+// by logger name and message:
+//
+//	zapcore.SamplerKeyFunc(func(ent zapcore.Entry) string {
+//	  return ent.LoggerName + ":" + ent.Message
+//	})
+func SamplerKeyFunc(key func(Entry) string) SamplerOption {
+	return optionFunc(func(s *sampler) {
+		s.key = key
+	})
+}
+
+// SamplerClock sets the Clock used to group entries into ticks. By default,
+// the sampler uses each entry's timestamp.
+func SamplerClock(clock Clock) SamplerOption {
+	return optionFunc(func(s *sampler) {
+		s.clock.Store(clock)
+	})
+}
+
+// SamplerCounters sets the number of counters the sampler keeps for each
+// level. Keys that hash to the same counter are sampled together, so
+// increase this if entries have many distinct keys; SamplerTrackCollisions
+// reports when that happens. Values less than one are ignored.
+//
+// Defaults to 4096.
+func SamplerCounters(perLevel int) SamplerOption {
+	return optionFunc(func(s *sampler) {
+		if perLevel > 0 {
+			s.countersPerLevel = perLevel
+		}
+	})
+}
+
+// SamplerDecay makes sampling progressively stricter for keys that stay hot.
+// For each consecutive tick in which a key exceeds the first N entries, the
+// thereafter rate of the following tick is multiplied by factor. The rate
+// returns to normal after a tick in which the key isn't hot.
+//
+// For example, with a thereafter of 5 and a factor of 2, a key that stays
+// hot logs every 5th entry past the first N in the first tick, every 10th in
+// the second, every 20th in the third, and so on.
+//
+// Factors less than 2 disable decay, which is the default.
+func SamplerDecay(factor int) SamplerOption {
+	return optionFunc(func(s *sampler) {
+		if factor > 1 {
+			s.decay = uint64(factor)
+		} else {
+			s.decay = 0
+		}
+	})
+}
+
+// SamplerTrackCollisions makes the sampler keep track of the key that uses
+// each of its counters, so that keys whose counters are in use by other keys
+// fall back to a second counter rather than share one. If both of a key's
+// counters are in use, its entries are counted together with those of
+// another key, and sampling decisions for them include LogCollision:
+//
+//	zapcore.SamplerHook(func(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+//	  if dec&zapcore.LogCollision > 0 {
+//	    collisions.Inc()
+//	  }
+//	})
+//
+// If collisions are frequent, increase the number of counters with
+// SamplerCounters. Tracking collisions makes sampling slightly slower, so
+// it's disabled by default.
+func SamplerTrackCollisions() SamplerOption {
+	return optionFunc(func(s *sampler) {
+		s.trackCollisions = true
+	})
+}
+
+// SamplerLevel overrides the first and thereafter values passed to
+// NewSamplerWithOptions for entries at lvl, so that each level can be
+// sampled at its own rate. For example, to log every Warn entry while
+// sampling others:
+//
+//	zapcore.SamplerLevel(zapcore.WarnLevel, 100, 1)
+//
+// Entries at levels without an override use the sampler's defaults.
+// Negative values and invalid levels are ignored.
+func SamplerLevel(lvl Level, first, thereafter int) SamplerOption {
+	return optionFunc(func(s *sampler) {
+		if lvl < _minLevel || lvl > _maxLevel || first < 0 || thereafter < 0 {
+			return
+		}
+		s.rates[lvl-_minLevel] = samplingRate{
+			first:      uint64(first),
+			thereafter: uint64(thereafter),
+		}
+	})
+}
+
 // NewSamplerWithOptions creates a Core that samples incoming entries, which
 // caps the CPU and I/O load of logging while attempting to preserve a
 // representative subset of your logs.
@@ -144,23 +320,30 @@ func SamplerHook(hook func(entry Entry, dec SamplingDecision)) SamplerOption {
 // in that interval.
 //
 // Sampler can be configured to report sampling decisions with the SamplerHook
-// option.
+// option. Use SamplerLevel to sample some levels at a different rate, the
+// SamplerKeyFunc, SamplerCounters, and SamplerDecay options to
+// change how entries are grouped and how strictly hot keys are sampled, and
+// SamplerClock to control the passage of time.
 //
 // Keep in mind that Zap's sampling implementation is optimized for speed over
 // absolute precision; under load, each tick may be slightly over- or
 // under-sampled.
 func NewSamplerWithOptions(core Core, tick time.Duration, first, thereafter int, opts ...SamplerOption) Core {
 	s := &sampler{
-		Core:       core,
-		tick:       tick,
-		counts:     newCounters(),
-		first:      uint64(first),
-		thereafter: uint64(thereafter),
-		hook:       nopSamplingHook,
+		Core:             core,
+		tick:             tick,
+		hook:             nopSamplingHook,
+		countersPerLevel: _defaultCountersPerLevel,
+		clock:            new(clockRef),
+	}
+	for i := range s.rates {
+		s.rates[i] = samplingRate{first: uint64(first), thereafter: uint64(thereafter)}
 	}
 	for _, opt := range opts {
 		opt.apply(s)
 	}
+	s.counts = newCounters(s.countersPerLevel)
+	s.entry = entryEnablerOf(core)
 
 	return s
 }
@@ -168,15 +351,31 @@ func NewSamplerWithOptions(core Core, tick time.Duration, first, thereafter int,
 type sampler struct {
 	Core
 
-	counts            *counters
-	tick              time.Duration
+	counts           *counters
+	countersPerLevel int
+	tick             time.Duration
+	rates            [_numLevels]samplingRate // indexed by level - _minLevel
+	decay            uint64                   // zero if decay is disabled
+	trackCollisions  bool
+	key              func(Entry) string
+	hook             func(Entry, SamplingDecision)
+	clock            *clockRef // shared with derived samplers
+
+	// entry is the wrapped core's EntryEnabler, if any. Entries it rejects
+	// aren't counted, so they don't use up the sampling budget.
+	entry EntryEnabler
+}
+
+// samplingRate is the number of entries a sampler logs per tick for a level
+// and key before it starts dropping them, and how many of the rest it logs.
+type samplingRate struct {
 	first, thereafter uint64
-	hook              func(Entry, SamplingDecision)
 }
 
 var (
 	_ Core           = (*sampler)(nil)
-	_ leveledEnabler = (*sampler)(nil)
+	_ LeveledEnabler = (*sampler)(nil)
+	_ ClockCopier    = (*sampler)(nil)
 )
 
 // NewSampler creates a Core that samples incoming entries, which
@@ -202,28 +401,80 @@ func (s *sampler) Level() Level {
 
 func (s *sampler) With(fields []Field) Core {
 	return &sampler{
-		Core:       s.Core.With(fields),
-		tick:       s.tick,
-		counts:     s.counts,
-		first:      s.first,
-		thereafter: s.thereafter,
-		hook:       s.hook,
+		Core:             s.Core.With(fields),
+		tick:             s.tick,
+		counts:           s.counts,
+		countersPerLevel: s.countersPerLevel,
+		rates:            s.rates,
+		decay:            s.decay,
+		trackCollisions:  s.trackCollisions,
+		key:              s.key,
+		hook:             s.hook,
+		clock:            s.clock,
+		entry:            s.entry,
 	}
 }
 
+func (s *sampler) WithClock(clock Clock) Core {
+	clone := *s
+	clone.Core = WithClock(s.Core, clock)
+	clone.clock = newClockRef(clock)
+	return &clone
+}
+
 func (s *sampler) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
 	if !s.Enabled(ent.Level) {
 		return ce
 	}
+	if s.entry != nil && !s.entry.EnabledFor(ent) {
+		return ce
+	}
 
 	if ent.Level >= _minLevel && ent.Level <= _maxLevel {
-		counter := s.counts.get(ent.Level, ent.Message)
-		n := counter.IncCheckReset(ent.Time, s.tick)
-		if n > s.first && (s.thereafter == 0 || (n-s.first)%s.thereafter != 0) {
-			s.hook(ent, LogDropped)
+		key := ent.Message
+		if s.key != nil {
+			key = s.key(ent)
+		}
+		now := ent.Time
+		if clock := s.clock.Load(); clock != nil {
+			now = clock.Now()
+		}
+		rate := s.rates[ent.Level-_minLevel]
+		var (
+			counter *counter
+			dec     SamplingDecision
+		)
+		if s.trackCollisions {
+			var collided bool
+			counter, collided = s.counts.claim(ent.Level, key, now)
+			if collided {
+				dec = LogCollision
+			}
+		} else {
+			counter = s.counts.get(ent.Level, key)
+		}
+		n := counter.IncCheckReset(now, s.tick, rate.first)
+		if n > rate.first && (rate.thereafter == 0 || (n-rate.first)%s.thereafterFor(counter, rate.thereafter) != 0) {
+			s.hook(ent, dec|LogDropped)
 			return ce
 		}
-		s.hook(ent, LogSampled)
+		s.hook(ent, dec|LogSampled)
 	}
 	return s.Core.Check(ent, ce)
 }
+
+// thereafterFor returns the thereafter rate for the given counter, tightened
+// according to how long it has been hot if decay is enabled.
+func (s *sampler) thereafterFor(c *counter, thereafter uint64) uint64 {
+	if s.decay == 0 {
+		return thereafter
+	}
+
+	for i := c.hot.Load(); i > 0; i-- {
+		if thereafter > math.MaxUint32/s.decay {
+			return math.MaxUint32
+		}
+		thereafter *= s.decay
+	}
+	return thereafter
+}
@@ -205,6 +205,14 @@ var counterTestCases = [][]string{
 }
 
 func BenchmarkSampler_Check(b *testing.B) {
+	benchmarkSamplerCheck(b)
+}
+
+func BenchmarkSampler_CheckTrackCollisions(b *testing.B) {
+	benchmarkSamplerCheck(b, SamplerTrackCollisions())
+}
+
+func benchmarkSamplerCheck(b *testing.B, opts ...SamplerOption) {
 	for _, keys := range counterTestCases {
 		b.Run(fmt.Sprintf("%v keys", len(keys)), func(b *testing.B) {
 			fac := NewSamplerWithOptions(
@@ -213,7 +221,7 @@ func BenchmarkSampler_Check(b *testing.B) {
 					&ztest.Discarder{},
 					DebugLevel,
 				),
-				time.Millisecond, 1, 1000)
+				time.Millisecond, 1, 1000, opts...)
 			b.ResetTimer()
 			b.RunParallel(func(pb *testing.PB) {
 				i := 0
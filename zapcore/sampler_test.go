@@ -31,6 +31,7 @@ import (
 	"github.com/toujourser/zap/internal/ztest"
 	//revive:disable:dot-imports
 	. "github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zapcore/clock"
 	"github.com/toujourser/zap/zaptest/observer"
 
 	"github.com/stretchr/testify/assert"
@@ -272,7 +273,7 @@ func TestSamplerRaces(t *testing.T) {
 func TestSamplerUnknownLevels(t *testing.T) {
 	// Prove that out-of-bounds levels don't panic.
 	unknownLevels := []Level{
-		DebugLevel - 1,
+		TraceLevel - 1,
 		FatalLevel + 1,
 	}
 
@@ -327,3 +328,235 @@ func TestSamplerWithZeroThereafter(t *testing.T) {
 	assert.Equal(t, 4, int(counter.logs.Load()),
 		"Unexpected number of logs")
 }
+
+// sampledMessages checks each entry against the sampler and returns the
+// messages of the entries that were logged.
+func sampledMessages(core Core, ents ...Entry) []string {
+	var msgs []string
+	for _, ent := range ents {
+		if ce := core.Check(ent, nil); ce != nil {
+			msgs = append(msgs, ent.Message)
+		}
+	}
+	return msgs
+}
+
+func TestSamplerEntryEnabler(t *testing.T) {
+	cfg := testEncoderConfig()
+	cfg.TimeKey = ""
+	cfg.LevelKey = ""
+
+	buf := &ztest.Buffer{}
+	core := NewCore(NewJSONEncoder(cfg), buf, prefixEnabler{DebugLevel, "keep"})
+	// All entries share a counter, and only the first is logged.
+	sampler := NewSamplerWithOptions(core, time.Minute, 1, 0, SamplerKeyFunc(func(Entry) string { return "" }))
+
+	for _, msg := range []string{"drop 1", "drop 2", "keep 1", "keep 2"} {
+		if ce := sampler.With(nil).Check(Entry{Level: InfoLevel, Message: msg}, nil); ce != nil {
+			ce.Write()
+		}
+	}
+	assert.Equal(t, []string{`{"msg":"keep 1"}`}, buf.Lines(),
+		"Entries rejected by the EntryEnabler shouldn't use up the sampling budget.")
+}
+
+func TestSamplerKeyFunc(t *testing.T) {
+	core, _ := observer.New(DebugLevel)
+	sampler := NewSamplerWithOptions(core, time.Minute, 1, 0,
+		SamplerKeyFunc(func(ent Entry) string { return ent.LoggerName }))
+
+	now := time.Now()
+	got := sampledMessages(sampler,
+		Entry{Level: InfoLevel, Time: now, LoggerName: "a", Message: "a1"},
+		Entry{Level: InfoLevel, Time: now, LoggerName: "a", Message: "a2"},
+		Entry{Level: InfoLevel, Time: now, LoggerName: "b", Message: "b1"},
+		Entry{Level: InfoLevel, Time: now, LoggerName: "b", Message: "b2"},
+	)
+	assert.Equal(t, []string{"a1", "b1"}, got, "Expected entries to be sampled by logger name.")
+}
+
+func TestSamplerCounters(t *testing.T) {
+	now := time.Now()
+	ents := []Entry{
+		{Level: InfoLevel, Time: now, Message: "foo"},
+		{Level: InfoLevel, Time: now, Message: "bar"},
+	}
+
+	core, _ := observer.New(DebugLevel)
+	sampler := NewSamplerWithOptions(core, time.Minute, 1, 0)
+	assert.Equal(t, []string{"foo", "bar"}, sampledMessages(sampler, ents...),
+		"Expected distinct messages to be sampled separately.")
+
+	// With a single counter per level, all messages share it.
+	sampler = NewSamplerWithOptions(core, time.Minute, 1, 0, SamplerCounters(1))
+	assert.Equal(t, []string{"foo"}, sampledMessages(sampler, ents...),
+		"Expected messages to share a counter.")
+}
+
+func TestSamplerLevel(t *testing.T) {
+	core, logs := observer.New(DebugLevel)
+	sampler := NewSamplerWithOptions(core, time.Minute, 2, 3,
+		SamplerLevel(WarnLevel, 1, 1),
+		SamplerLevel(ErrorLevel, 1, 0),
+		SamplerLevel(InfoLevel, -1, 2), // ignored
+		SamplerLevel(Level(42), 1, 1),  // ignored
+	)
+
+	tests := []struct {
+		lvl  Level
+		want []int64
+	}{
+		{DebugLevel, []int64{1, 2, 5, 8}},
+		{InfoLevel, []int64{1, 2, 5, 8}},
+		{WarnLevel, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{ErrorLevel, []int64{1}},
+	}
+	for _, tt := range tests {
+		for i := 1; i < 10; i++ {
+			writeSequence(sampler, i, tt.lvl)
+		}
+		assertSequence(t, logs.TakeAll(), tt.lvl, tt.want...)
+	}
+}
+
+func TestSamplerTrackCollisions(t *testing.T) {
+	now := time.Now()
+	// With two counters per level, "qux" hashes to the same first counter as
+	// "foo", but to a different second one, and both of "bar"'s counters are
+	// the one "qux" falls back to.
+	ents := []Entry{
+		{Level: InfoLevel, Time: now, Message: "foo"},
+		{Level: InfoLevel, Time: now, Message: "qux"},
+		{Level: InfoLevel, Time: now, Message: "bar"},
+		{Level: InfoLevel, Time: now, Message: "foo"},
+	}
+
+	var decs []SamplingDecision
+	hook := SamplerHook(func(_ Entry, dec SamplingDecision) {
+		decs = append(decs, dec)
+	})
+
+	core, _ := observer.New(DebugLevel)
+	sampler := NewSamplerWithOptions(core, time.Minute, 1, 0, SamplerCounters(2), hook)
+	assert.Equal(t, []string{"foo", "bar"}, sampledMessages(sampler, ents...),
+		"Expected colliding keys to share counters.")
+	assert.Equal(t, []SamplingDecision{LogSampled, LogDropped, LogSampled, LogDropped}, decs,
+		"Expected no collisions to be reported without tracking.")
+
+	decs = nil
+	sampler = NewSamplerWithOptions(core, time.Minute, 1, 0, SamplerCounters(2), SamplerTrackCollisions(), hook)
+	assert.Equal(t, []string{"foo", "qux"}, sampledMessages(sampler, ents...),
+		"Expected a colliding key to fall back to its second counter.")
+	assert.Equal(t, []SamplingDecision{
+		LogSampled,
+		LogSampled,
+		LogDropped | LogCollision,
+		LogDropped,
+	}, decs, "Unexpected sampling decisions.")
+
+	t.Run("next tick", func(t *testing.T) {
+		later := now.Add(time.Minute)
+		assert.Equal(t, []string{"bar", "foo"}, sampledMessages(sampler,
+			Entry{Level: InfoLevel, Time: later, Message: "bar"},
+			Entry{Level: InfoLevel, Time: later, Message: "foo"},
+		), "Expected counters to be claimable by other keys in a new tick.")
+	})
+}
+
+func TestSamplerDecay(t *testing.T) {
+	var decisions []SamplingDecision
+	core, _ := observer.New(DebugLevel)
+	sampler := NewSamplerWithOptions(core, time.Second, 1, 2,
+		SamplerDecay(2),
+		SamplerHook(func(_ Entry, dec SamplingDecision) { decisions = append(decisions, dec) }),
+	)
+
+	start := time.Now()
+	logTick := func(tick int) []string {
+		ents := make([]Entry, 9)
+		for i := range ents {
+			ents[i] = Entry{
+				Level:   InfoLevel,
+				Time:    start.Add(time.Duration(tick) * time.Second),
+				Message: "hot",
+			}
+		}
+		var logged []string
+		for i, ent := range ents {
+			if ce := sampler.Check(ent, nil); ce != nil {
+				logged = append(logged, fmt.Sprint(i+1))
+			}
+		}
+		return logged
+	}
+
+	assert.Equal(t, []string{"1", "3", "5", "7", "9"}, logTick(0), "Unexpected entries in first hot tick.")
+	assert.Equal(t, []string{"1", "5", "9"}, logTick(1), "Expected thereafter to double.")
+	assert.Equal(t, []string{"1", "9"}, logTick(2), "Expected thereafter to double again.")
+
+	// Skipping a tick cools the key down.
+	assert.Equal(t, []string{"1", "3", "5", "7", "9"}, logTick(4), "Expected thereafter to reset.")
+
+	var sampled, dropped int
+	for _, dec := range decisions {
+		if dec&LogSampled > 0 {
+			sampled++
+		} else if dec&LogDropped > 0 {
+			dropped++
+		}
+	}
+	assert.Equal(t, 15, sampled, "Unexpected number of sampled decisions.")
+	assert.Equal(t, 21, dropped, "Unexpected number of dropped decisions.")
+}
+
+func TestSamplerClock(t *testing.T) {
+	clk := clock.NewMock()
+	obs, logs := observer.New(DebugLevel)
+	sampler := NewSamplerWithOptions(obs, time.Second, 1, 0, SamplerClock(clk))
+
+	// Entry timestamps are ignored in favor of the clock.
+	write := func(core Core, msg string) {
+		ent := Entry{Level: InfoLevel, Message: msg, Time: time.Unix(int64(logs.Len()), 0)}
+		if ce := core.Check(ent, nil); ce != nil {
+			ce.Write()
+		}
+	}
+
+	write(sampler, "foo")
+	write(sampler, "foo")
+	assert.Equal(t, 1, logs.Len(), "Expected the second entry to be dropped within the tick.")
+
+	clk.Add(time.Second)
+	write(sampler.With(nil), "foo")
+	assert.Equal(t, 2, logs.Len(), "Expected derived samplers to follow the clock.")
+}
+
+func TestSamplerWithClock(t *testing.T) {
+	clk := clock.NewMock()
+	obs, logs := observer.New(DebugLevel)
+	inner := NewSamplerWithOptions(obs, time.Second, 1, 0)
+	tee := NewTee(NewSamplerWithOptions(inner, time.Second, 2, 0), NewNopCore())
+
+	copied := WithClock(tee, clk)
+	assert.Equal(t, NewNopCore(), WithClock(NewNopCore(), clk), "Expected nop core to be returned as-is.")
+
+	ent := Entry{Level: InfoLevel, Message: "foo"}
+	write := func(core Core) {
+		if ce := core.Check(ent, nil); ce != nil {
+			ce.Write()
+		}
+	}
+
+	write(copied)
+	write(copied)
+	assert.Equal(t, 1, logs.Len(), "Expected inner sampler to drop entries within the tick.")
+
+	clk.Add(time.Second)
+	write(copied)
+	assert.Equal(t, 2, logs.Len(), "Expected the clock to reach the copied samplers.")
+
+	// The original tee still uses the entries' timestamps, which haven't
+	// moved.
+	write(tee)
+	assert.Equal(t, 2, logs.Len(), "Expected the original samplers to keep their clock.")
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+
+	"go.uber.org/multierr"
+)
+
+// A SinkSyncError records a failure to sync a single sink.
+type SinkSyncError struct {
+	// Name identifies the sink, typically by file name. It's empty for
+	// sinks that don't have a name.
+	Name string
+	// Err is the error returned by the sink's Sync method.
+	Err error
+	// Ignorable reports whether Err only indicates that the sink can't be
+	// synced (for example, because it's a terminal or pipe), rather than
+	// that logs may have been lost.
+	Ignorable bool
+}
+
+func (e SinkSyncError) Error() string {
+	return e.Err.Error()
+}
+
+// SyncError is returned by Sync when at least one of the underlying sinks is
+// a file and syncing fails. It breaks the failure down by sink, so callers
+// can ignore the errors that standard output and standard error routinely
+// report when they're attached to a terminal or pipe:
+//
+//	if err := logger.Sync(); err != nil {
+//		var serr *zapcore.SyncError
+//		if !errors.As(err, &serr) || !serr.Ignorable() {
+//			// handle err
+//		}
+//	}
+type SyncError struct {
+	Sinks []SinkSyncError
+}
+
+func (e *SyncError) Error() string {
+	msgs := make([]string, len(e.Sinks))
+	for i, s := range e.Sinks {
+		msgs[i] = s.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Ignorable reports whether every sink that failed to sync did so only
+// because it doesn't support syncing.
+func (e *SyncError) Ignorable() bool {
+	for _, s := range e.Sinks {
+		if !s.Ignorable {
+			return false
+		}
+	}
+	return len(e.Sinks) > 0
+}
+
+// Unwrap returns the per-sink errors, so that errors.Is and errors.As can
+// inspect them.
+func (e *SyncError) Unwrap() []error {
+	errs := make([]error, len(e.Sinks))
+	for i, s := range e.Sinks {
+		errs[i] = s.Err
+	}
+	return errs
+}
+
+// A fileSyncer exposes the *os.File underlying a WriteSyncer, so that Sync
+// errors can be classified through wrappers like zap's standard output sink.
+type fileSyncer interface {
+	File() *os.File
+}
+
+// syncWriteSyncer syncs ws. If ws is (or wraps) a file, failures are
+// returned as a *SyncError.
+func syncWriteSyncer(ws WriteSyncer) error {
+	err := ws.Sync()
+	if err == nil {
+		return nil
+	}
+	var f *os.File
+	switch ws := ws.(type) {
+	case *os.File:
+		f = ws
+	case fileSyncer:
+		f = ws.File()
+	}
+	if f == nil {
+		return err
+	}
+	if _, ok := err.(*SyncError); ok {
+		return err
+	}
+	return &SyncError{Sinks: []SinkSyncError{{
+		Name:      f.Name(),
+		Err:       err,
+		Ignorable: isIgnorableSyncError(f, err),
+	}}}
+}
+
+// isIgnorableSyncError reports whether err only indicates that f can't be
+// synced. Terminals, pipes, and sockets report EINVAL or ENOTTY, and a
+// detached console reports EBADF (or an invalid handle on Windows) for the
+// standard streams. Errors from regular files are never ignorable.
+func isIgnorableSyncError(f *os.File, err error) bool {
+	std := f == os.Stdout || f == os.Stderr
+	if std {
+		for _, target := range _consoleSyncErrors {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+	}
+	if !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTTY) {
+		return false
+	}
+	if std {
+		return true
+	}
+	fi, statErr := f.Stat()
+	return statErr == nil && !fi.Mode().IsRegular()
+}
+
+// appendSyncError combines two Sync errors. If either is a *SyncError, the
+// result is a *SyncError with a breakdown of every failed sink; otherwise,
+// the errors are combined with multierr.
+func appendSyncError(left, right error) error {
+	_, lok := left.(*SyncError)
+	_, rok := right.(*SyncError)
+	if !lok && !rok {
+		return multierr.Append(left, right)
+	}
+	combined := &SyncError{}
+	for _, err := range []error{left, right} {
+		if serr, ok := err.(*SyncError); ok {
+			combined.Sinks = append(combined.Sinks, serr.Sinks...)
+		} else if err != nil {
+			combined.Sinks = append(combined.Sinks, SinkSyncError{Err: err})
+		}
+	}
+	return combined
+}
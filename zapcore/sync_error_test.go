@@ -0,0 +1,142 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toujourser/zap/internal/ztest"
+)
+
+// fileSyncStub wraps a file, but fails Sync with a canned error.
+type fileSyncStub struct {
+	f   *os.File
+	err error
+}
+
+func (s fileSyncStub) Write(bs []byte) (int, error) { return s.f.Write(bs) }
+func (s fileSyncStub) File() *os.File               { return s.f }
+func (s fileSyncStub) Sync() error {
+	return &os.PathError{Op: "sync", Path: s.f.Name(), Err: s.err}
+}
+
+func requireSyncError(t testing.TB, err error) *SyncError {
+	var serr *SyncError
+	require.ErrorAs(t, err, &serr, "Expected a *SyncError.")
+	return serr
+}
+
+func TestSyncErrorPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err, "Failed to create pipe.")
+	defer r.Close()
+	defer w.Close()
+
+	// Whether syncing a pipe fails depends on the platform, but failures
+	// must never be fatal.
+	if err := Lock(w).Sync(); err != nil {
+		assert.True(t, requireSyncError(t, err).Ignorable(), "Expected pipe Sync errors to be ignorable.")
+	}
+	for _, errno := range []error{syscall.EINVAL, syscall.ENOTTY} {
+		err := Lock(fileSyncStub{w, errno}).Sync()
+		serr := requireSyncError(t, err)
+		assert.True(t, serr.Ignorable(), "Expected %v on a pipe to be ignorable.", errno)
+		assert.ErrorIs(t, err, errno, "Expected to unwrap the underlying error.")
+	}
+}
+
+func TestSyncErrorCharDevice(t *testing.T) {
+	// Terminals are character devices, as is the null device.
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	require.NoError(t, err, "Failed to open null device.")
+	defer f.Close()
+	fi, err := f.Stat()
+	require.NoError(t, err, "Failed to stat null device.")
+	if fi.Mode()&os.ModeCharDevice == 0 {
+		t.Skip("null device isn't a character device on this platform")
+	}
+
+	serr := requireSyncError(t, Lock(fileSyncStub{f, syscall.ENOTTY}).Sync())
+	assert.True(t, serr.Ignorable(), "Expected ENOTTY on a character device to be ignorable.")
+	require.Len(t, serr.Sinks, 1, "Unexpected per-sink breakdown.")
+	assert.Equal(t, f.Name(), serr.Sinks[0].Name, "Unexpected sink name.")
+}
+
+func TestSyncErrorRegularFile(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "log"))
+	require.NoError(t, err, "Failed to create file.")
+
+	assert.NoError(t, Lock(f).Sync(), "Unexpected error syncing a regular file.")
+	for _, errno := range []error{syscall.EINVAL, syscall.ENOTTY, syscall.EBADF} {
+		serr := requireSyncError(t, Lock(fileSyncStub{f, errno}).Sync())
+		assert.False(t, serr.Ignorable(), "Expected %v on a regular file to be fatal.", errno)
+	}
+
+	require.NoError(t, f.Close(), "Failed to close file.")
+	err = Lock(f).Sync()
+	assert.False(t, requireSyncError(t, err).Ignorable(), "Expected syncing a closed file to be fatal.")
+	assert.ErrorIs(t, err, os.ErrClosed, "Expected to unwrap the underlying error.")
+}
+
+func TestSyncErrorStandardStreams(t *testing.T) {
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		for _, errno := range []error{syscall.EINVAL, syscall.ENOTTY, syscall.EBADF} {
+			serr := requireSyncError(t, Lock(fileSyncStub{f, errno}).Sync())
+			assert.True(t, serr.Ignorable(), "Expected %v on %s to be ignorable.", errno, f.Name())
+		}
+		serr := requireSyncError(t, Lock(fileSyncStub{f, syscall.EIO}).Sync())
+		assert.False(t, serr.Ignorable(), "Expected EIO on %s to be fatal.", f.Name())
+	}
+}
+
+func TestSyncErrorMultipleSinks(t *testing.T) {
+	failed := &ztest.Discarder{}
+	errFailed := errors.New("failed")
+	failed.SetError(errFailed)
+
+	ignorable := NewMultiWriteSyncer(
+		fileSyncStub{os.Stdout, syscall.EINVAL},
+		fileSyncStub{os.Stderr, syscall.ENOTTY},
+	)
+	serr := requireSyncError(t, ignorable.Sync())
+	assert.True(t, serr.Ignorable(), "Expected errors from both standard streams to be ignorable.")
+	assert.Len(t, serr.Sinks, 2, "Unexpected per-sink breakdown.")
+
+	tee := NewTee(
+		NewCore(NewJSONEncoder(EncoderConfig{MessageKey: "msg"}), Lock(ignorable), DebugLevel),
+		NewCore(NewJSONEncoder(EncoderConfig{MessageKey: "msg"}), failed, DebugLevel),
+	)
+	err := tee.Sync()
+	serr = requireSyncError(t, err)
+	assert.False(t, serr.Ignorable(), "Expected a failing sink to make the error fatal.")
+	require.Len(t, serr.Sinks, 3, "Unexpected per-sink breakdown.")
+	assert.Equal(t, os.Stdout.Name(), serr.Sinks[0].Name, "Unexpected first sink.")
+	assert.Equal(t, os.Stderr.Name(), serr.Sinks[1].Name, "Unexpected second sink.")
+	assert.Equal(t, SinkSyncError{Err: errFailed}, serr.Sinks[2], "Unexpected third sink.")
+	assert.ErrorIs(t, err, errFailed, "Expected to unwrap the failing sink's error.")
+	assert.Contains(t, err.Error(), "failed", "Expected the message to include every sink.")
+}
@@ -20,12 +20,71 @@
 
 package zapcore
 
-import "go.uber.org/multierr"
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTeeWriteTimeout is wrapped by the errors a tee returns for children
+// that didn't finish writing within their timeout. See TeeWriteTimeout.
+var ErrTeeWriteTimeout = errors.New("write timed out")
+
+// teeOptionFunc wraps a func so it satisfies the TeeOption interface.
+type teeOptionFunc func(*teeOptions)
+
+func (f teeOptionFunc) apply(o *teeOptions) {
+	f(o)
+}
+
+// TeeOption configures a tee built by NewTeeWithOptions.
+type TeeOption interface {
+	apply(*teeOptions)
+}
+
+type teeOptions struct {
+	timeout  time.Duration
+	timeouts map[int]time.Duration // by child index
+}
+
+// TeeWriteTimeout isolates the tee's children from each other: each write
+// to a child runs on its own goroutine, and if it doesn't finish within the
+// timeout, the tee stops waiting for it, reports an error wrapping
+// ErrTeeWriteTimeout, and moves on to the next child. Until the abandoned
+// write finishes, entries for that child are dropped and reported the same
+// way, so a stalled output delays at most one entry.
+//
+// Since the write may outlive the call to the tee, fields are copied first,
+// as AsyncCore does: byte slices are copied, marshalers and Stringers are run
+// on the calling goroutine, and reflected values are encoded as JSON. Errors
+// and TextMarshalers must still be safe to read concurrently.
+//
+// Sync isn't bounded by the timeout. Values that aren't positive turn
+// isolation off, which is the default.
+func TeeWriteTimeout(timeout time.Duration) TeeOption {
+	return teeOptionFunc(func(o *teeOptions) {
+		o.timeout = timeout
+	})
+}
+
+// TeeChildWriteTimeout is like TeeWriteTimeout, but only sets the timeout
+// of the child at index i, overriding TeeWriteTimeout. Use it to isolate
+// only the slow children, such as network outputs.
+func TeeChildWriteTimeout(i int, timeout time.Duration) TeeOption {
+	return teeOptionFunc(func(o *teeOptions) {
+		if o.timeouts == nil {
+			o.timeouts = make(map[int]time.Duration)
+		}
+		o.timeouts[i] = timeout
+	})
+}
 
 type multiCore []Core
 
 var (
-	_ leveledEnabler = multiCore(nil)
+	_ LeveledEnabler = multiCore(nil)
 	_ Core           = multiCore(nil)
 )
 
@@ -45,6 +104,36 @@ func NewTee(cores ...Core) Core {
 	}
 }
 
+// NewTeeWithOptions is like NewTee, but configures the tee with options.
+//
+// Entries are still offered to the children in order when they're checked,
+// so Cores that make decisions in Check, like samplers, behave just as they
+// do in a plain tee. Only the writes are isolated.
+func NewTeeWithOptions(cores []Core, opts ...TeeOption) Core {
+	var o teeOptions
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+
+	var isolated bool
+	children := make([]*teeChild, len(cores))
+	for i := range cores {
+		timeout := o.timeout
+		if t, ok := o.timeouts[i]; ok {
+			timeout = t
+		}
+		children[i] = &teeChild{index: i, timeout: timeout}
+		isolated = isolated || timeout > 0
+	}
+	if !isolated {
+		return NewTee(cores...)
+	}
+	return &isolatedTee{
+		multiCore: multiCore(cores),
+		children:  children,
+	}
+}
+
 func (mc multiCore) With(fields []Field) Core {
 	clone := make(multiCore, len(mc))
 	for i := range mc {
@@ -79,18 +168,193 @@ func (mc multiCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
 	return ce
 }
 
+// Write writes the entry to every child, even if some of them fail. The
+// error joins the errors of every child that failed.
 func (mc multiCore) Write(ent Entry, fields []Field) error {
-	var err error
+	var errs []error
 	for i := range mc {
-		err = multierr.Append(err, mc[i].Write(ent, fields))
+		if err := mc[i].Write(ent, fields); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	return err
+	return joinTeeErrors(errs)
+}
+
+func (mc multiCore) WithClock(clock Clock) Core {
+	clone := make(multiCore, len(mc))
+	for i := range mc {
+		clone[i] = WithClock(mc[i], clock)
+	}
+	return clone
 }
 
+// Sync syncs every child, even if some of them fail. The error joins the
+// errors of every child that failed, unless one of them is a *SyncError,
+// in which case the result is a *SyncError with every failed sink.
 func (mc multiCore) Sync() error {
-	var err error
+	var errs []error
+	var syncErr bool
 	for i := range mc {
-		err = multierr.Append(err, mc[i].Sync())
+		if err := mc[i].Sync(); err != nil {
+			errs = append(errs, err)
+			_, ok := err.(*SyncError)
+			syncErr = syncErr || ok
+		}
+	}
+	if !syncErr {
+		return joinTeeErrors(errs)
+	}
+
+	var err error
+	for _, e := range errs {
+		err = appendSyncError(err, e)
+	}
+	return err
+}
+
+// joinTeeErrors joins the errors of a tee's children. A single error is
+// returned as-is, so that callers can still compare it to a sentinel.
+func joinTeeErrors(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// isolatedTee is a tee whose writes to its children are bounded by a
+// timeout.
+type isolatedTee struct {
+	multiCore
+
+	// children holds the state of each of the Cores in multiCore, which
+	// is shared by the clones that With creates, since they usually write
+	// to the same output.
+	children []*teeChild
+}
+
+var (
+	_ LeveledEnabler = (*isolatedTee)(nil)
+	_ Core           = (*isolatedTee)(nil)
+)
+
+func (t *isolatedTee) WithClock(clock Clock) Core {
+	return &isolatedTee{
+		multiCore: t.multiCore.WithClock(clock).(multiCore),
+		children:  t.children,
+	}
+}
+
+func (t *isolatedTee) With(fields []Field) Core {
+	return &isolatedTee{
+		multiCore: t.multiCore.With(fields).(multiCore),
+		children:  t.children,
+	}
+}
+
+// Check offers the entry to each child in order, as a plain tee does, then
+// wraps the Cores that each child registered so that writing the entry
+// honors that child's timeout.
+func (t *isolatedTee) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	for i, core := range t.multiCore {
+		var n int
+		if ce != nil {
+			n = len(ce.cores)
+		}
+		ce = core.Check(ent, ce)
+		if ce == nil || t.children[i].timeout <= 0 {
+			continue
+		}
+		for j := n; j < len(ce.cores); j++ {
+			ce.cores[j] = &isolatedCore{Core: ce.cores[j], child: t.children[i]}
+		}
+	}
+	return ce
+}
+
+func (t *isolatedTee) Write(ent Entry, fields []Field) error {
+	var errs []error
+	for i, core := range t.multiCore {
+		if err := t.children[i].write(core, ent, fields); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinTeeErrors(errs)
+}
+
+// teeChild tracks the writes in progress to one of a tee's children.
+type teeChild struct {
+	index   int
+	timeout time.Duration // isolation is off if not positive
+
+	busy atomic.Bool // whether a write outlived its timeout and is still running
+
+	mu      sync.Mutex
+	lateErr error // returned by a write that outlived its timeout
+}
+
+// write writes the entry to core, waiting at most the child's timeout.
+func (c *teeChild) write(core Core, ent Entry, fields []Field) error {
+	if c.timeout <= 0 {
+		return core.Write(ent, fields)
+	}
+	if c.busy.Load() {
+		return fmt.Errorf("tee child %d: dropped entry while an earlier write is running: %w", c.index, ErrTeeWriteTimeout)
 	}
+
+	fields = copyAsyncFields(fields)
+	done := make(chan error, 1)
+	go func() {
+		done <- core.Write(ent, fields)
+	}()
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return joinTeeErrors(nonNil(c.takeLateErr(), err))
+	case <-timer.C:
+	}
+
+	c.busy.Store(true)
+	go func() {
+		if err := <-done; err != nil {
+			c.mu.Lock()
+			c.lateErr = err
+			c.mu.Unlock()
+		}
+		c.busy.Store(false)
+	}()
+	return fmt.Errorf("tee child %d: %w after %v", c.index, ErrTeeWriteTimeout, c.timeout)
+}
+
+func (c *teeChild) takeLateErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.lateErr
+	c.lateErr = nil
 	return err
 }
+
+func nonNil(errs ...error) []error {
+	out := errs[:0]
+	for _, err := range errs {
+		if err != nil {
+			out = append(out, err)
+		}
+	}
+	return out
+}
+
+// isolatedCore is registered with CheckedEntries in place of the Cores that
+// an isolatedTee's children register, so that writes to them honor the
+// child's timeout.
+type isolatedCore struct {
+	Core
+
+	child *teeChild
+}
+
+func (c *isolatedCore) Write(ent Entry, fields []Field) error {
+	return c.child.write(c.Core, ent, fields)
+}
@@ -22,7 +22,9 @@ package zapcore_test
 
 import (
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/toujourser/zap/internal/ztest"
 	//revive:disable:dot-imports
@@ -30,6 +32,7 @@ import (
 	"github.com/toujourser/zap/zaptest/observer"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func withTee(f func(core Core, debugLogs, warnLogs *observer.ObservedLogs)) {
@@ -190,3 +193,196 @@ func TestTeeSync(t *testing.T) {
 	tee = NewTee(tee, noSync)
 	assert.Equal(t, err, tee.Sync(), "Expected an error when part of tee can't Sync.")
 }
+
+func TestTeeWriteJoinsErrors(t *testing.T) {
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	failing := func(err error) Core {
+		return CoreFunc(DebugLevel, func(Entry, []Field) error { return err })
+	}
+	ok := failing(nil)
+
+	err := NewTee(failing(errA), ok, failing(errB)).Write(Entry{}, nil)
+	assert.ErrorIs(t, err, errA, "Expected the first child's error.")
+	assert.ErrorIs(t, err, errB, "Expected the last child's error.")
+	assert.Equal(t, "a failed\nb failed", err.Error(), "Unexpected error message.")
+
+	err = NewTee(ok, failing(errA)).Write(Entry{}, nil)
+	assert.Equal(t, errA, err, "Expected a single error to be returned as-is.")
+}
+
+func TestTeeSyncJoinsErrors(t *testing.T) {
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	failing := func(err error) Core {
+		ws := &ztest.Discarder{}
+		ws.SetError(err)
+		return NewCore(NewJSONEncoder(testEncoderConfig()), ws, DebugLevel)
+	}
+
+	err := NewTee(failing(errA), failing(errB)).Sync()
+	assert.ErrorIs(t, err, errA, "Expected the first child's error.")
+	assert.ErrorIs(t, err, errB, "Expected the second child's error.")
+}
+
+func TestNewTeeWithOptionsWithoutIsolation(t *testing.T) {
+	a, _ := observer.New(DebugLevel)
+	b, _ := observer.New(DebugLevel)
+	assert.Equal(t, NewTee(a, b), NewTeeWithOptions([]Core{a, b}),
+		"Expected a plain tee without options.")
+	assert.Equal(t, NewTee(a, b), NewTeeWithOptions([]Core{a, b}, TeeWriteTimeout(0)),
+		"Expected a plain tee if isolation is off.")
+	assert.Equal(t, a, NewTeeWithOptions([]Core{a}), "Expected to return single inputs unchanged.")
+}
+
+// blockingCore is a Core whose writes block until they're released.
+type blockingCore struct {
+	Core
+
+	release chan struct{}
+	mu      sync.Mutex
+	written []string
+}
+
+func newBlockingCore() *blockingCore {
+	return &blockingCore{
+		Core:    CoreFunc(DebugLevel, nil),
+		release: make(chan struct{}),
+	}
+}
+
+func (c *blockingCore) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *blockingCore) With([]Field) Core { return c }
+
+func (c *blockingCore) Write(ent Entry, _ []Field) error {
+	<-c.release
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.written = append(c.written, ent.Message)
+	return nil
+}
+
+func (c *blockingCore) Written() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.written...)
+}
+
+func TestTeeWriteTimeout(t *testing.T) {
+	slow := newBlockingCore()
+	fast, logs := observer.New(DebugLevel)
+	tee := NewTeeWithOptions([]Core{slow, fast},
+		TeeWriteTimeout(10*time.Millisecond),
+		TeeChildWriteTimeout(1, 0), // don't let a busy machine fail the test
+	)
+
+	write := func(msg string) string {
+		errOut := &ztest.Buffer{}
+		ce := tee.With([]Field{{Key: "k", Type: StringType, String: "v"}}).Check(Entry{Level: InfoLevel, Message: msg}, nil)
+		require.NotNil(t, ce, "Expected the tee to accept the entry.")
+		ce.ErrorOutput = errOut
+		ce.Write()
+		return errOut.String()
+	}
+
+	assert.Contains(t, write("first"), "tee child 0: write timed out after 10ms",
+		"Expected the stalled write to be reported.")
+	assert.Contains(t, write("second"), "tee child 0: dropped entry while an earlier write is running",
+		"Expected entries to be dropped while the write is stalled.")
+	assert.Equal(t, []string{"first", "second"}, messages(logs),
+		"Expected the other child to get every entry.")
+
+	close(slow.release)
+	require.Eventually(t, func() bool {
+		return len(slow.Written()) == 1
+	}, time.Second, time.Millisecond, "Expected the stalled write to finish.")
+	require.Eventually(t, func() bool {
+		return tee.Write(Entry{Message: "third"}, nil) == nil
+	}, time.Second, time.Millisecond, "Expected writes to the child to resume.")
+	assert.Equal(t, "first", slow.Written()[0], "Expected the stalled write to complete.")
+	assert.Equal(t, "third", slow.Written()[len(slow.Written())-1], "Expected writes to resume.")
+}
+
+func TestTeeWriteTimeoutSnapshotsFields(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	gated := newGatedCore(obs)
+	tee := NewTeeWithOptions([]Core{gated, NewNopCore()}, TeeWriteTimeout(time.Millisecond))
+
+	ints := []int{1, 2, 3}
+	fields := []Field{{Key: "ints", Type: ArrayMarshalerType, Interface: ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+		for _, i := range ints {
+			enc.AppendInt(i)
+		}
+		return nil
+	})}}
+	err := tee.Write(Entry{Level: InfoLevel}, fields)
+	require.ErrorIs(t, err, ErrTeeWriteTimeout, "Expected the gated write to time out.")
+
+	// Change the marshaled slice while the abandoned write is still running.
+	ints[0] = 42
+	close(gated.gate)
+	require.Eventually(t, func() bool {
+		return logs.Len() == 1
+	}, time.Second, time.Millisecond, "Expected the abandoned write to finish.")
+	assert.Equal(t, map[string]interface{}{"ints": []interface{}{1, 2, 3}}, logs.All()[0].ContextMap(),
+		"Expected the values at the time of the write.")
+}
+
+func TestTeeChildWriteTimeout(t *testing.T) {
+	errFailed := errors.New("failed")
+	var order []string
+	recording := func(name string, err error) Core {
+		return CoreFunc(DebugLevel, func(Entry, []Field) error {
+			order = append(order, name)
+			return err
+		})
+	}
+
+	tee := NewTeeWithOptions(
+		[]Core{recording("a", nil), recording("b", errFailed), recording("c", nil)},
+		TeeWriteTimeout(time.Minute),
+		TeeChildWriteTimeout(0, 0),
+	)
+	ce := tee.Check(Entry{Level: InfoLevel}, nil)
+	require.NotNil(t, ce, "Expected the tee to accept the entry.")
+	errOut := &ztest.Buffer{}
+	ce.ErrorOutput = errOut
+	ce.Write()
+
+	assert.Equal(t, []string{"a", "b", "c"}, order, "Expected children to be written in order.")
+	assert.Contains(t, errOut.String(), "failed", "Expected the child's error to be reported.")
+}
+
+func TestTeeWriteTimeoutPreservesSampling(t *testing.T) {
+	newTee := func(opts ...TeeOption) (Core, *observer.ObservedLogs, *observer.ObservedLogs) {
+		sampled, sampledLogs := observer.New(DebugLevel)
+		all, allLogs := observer.New(DebugLevel)
+		cores := []Core{NewSamplerWithOptions(sampled, time.Minute, 2, 0), all}
+		return NewTeeWithOptions(cores, opts...), sampledLogs, allLogs
+	}
+
+	plain, plainSampled, plainAll := newTee()
+	isolated, isolatedSampled, isolatedAll := newTee(TeeWriteTimeout(time.Minute))
+	for i := 0; i < 5; i++ {
+		for _, tee := range []Core{plain, isolated} {
+			if ce := tee.Check(Entry{Level: InfoLevel, Message: "msg"}, nil); ce != nil {
+				ce.Write()
+			}
+		}
+	}
+
+	assert.Equal(t, 2, plainSampled.Len(), "Unexpected number of sampled entries.")
+	assert.Equal(t, plainSampled.Len(), isolatedSampled.Len(), "Expected the same sampling decisions.")
+	assert.Equal(t, plainAll.Len(), isolatedAll.Len(), "Expected every entry to reach unsampled children.")
+}
+
+func messages(logs *observer.ObservedLogs) []string {
+	var msgs []string
+	for _, e := range logs.All() {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
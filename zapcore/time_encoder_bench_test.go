@@ -0,0 +1,128 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zapcore"
+)
+
+var _layoutTimeEncoders = []struct {
+	name   string
+	encode TimeEncoder
+}{
+	{"ISO8601", ISO8601TimeEncoder},
+	{"RFC3339", RFC3339TimeEncoder},
+	{"RFC3339Nano", RFC3339NanoTimeEncoder},
+}
+
+func timeEncoders(encodeTime TimeEncoder) []struct {
+	name string
+	enc  Encoder
+} {
+	cfg := EncoderConfig{
+		TimeKey:    "ts",
+		MessageKey: "msg",
+		EncodeTime: encodeTime,
+	}
+	return []struct {
+		name string
+		enc  Encoder
+	}{
+		{"json", NewJSONEncoder(cfg)},
+		{"console", NewConsoleEncoder(cfg)},
+		{"logfmt", NewLogfmtEncoder(cfg)},
+	}
+}
+
+// timeFields holds a time field and an array of times, so that encoding
+// them exercises both top-level and nested time encoding.
+func timeFields(t time.Time) []Field {
+	return []Field{
+		{Key: "t", Type: TimeType, Integer: t.UnixNano(), Interface: t.Location()},
+		{Key: "ts", Type: ArrayMarshalerType, Interface: timeArray{t, t}},
+	}
+}
+
+type timeArray []time.Time
+
+func (ts timeArray) MarshalLogArray(enc ArrayEncoder) error {
+	for _, t := range ts {
+		enc.AppendTime(t)
+	}
+	return nil
+}
+
+func TestLayoutTimeEncodersDoNotAllocate(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are unreliable with the race detector")
+	}
+
+	ent := Entry{
+		Time:    time.Date(2024, time.January, 2, 3, 4, 5, 6000000, time.UTC),
+		Message: "fake",
+	}
+	fields := timeFields(ent.Time)
+
+	for _, te := range _layoutTimeEncoders {
+		for _, tt := range timeEncoders(te.encode) {
+			enc := tt.enc
+			t.Run(te.name+"/"+tt.name, func(t *testing.T) {
+				allocs := testing.AllocsPerRun(100, func() {
+					buf, err := enc.EncodeEntry(ent, fields)
+					if err == nil {
+						buf.Free()
+					}
+				})
+				assert.Zero(t, allocs, "Expected encoding timestamps to not allocate.")
+			})
+		}
+	}
+}
+
+func BenchmarkLayoutTimeEncoders(b *testing.B) {
+	ent := Entry{
+		Time:    time.Date(2024, time.January, 2, 3, 4, 5, 6000000, time.UTC),
+		Message: "fake",
+	}
+	fields := timeFields(ent.Time)
+
+	for _, te := range _layoutTimeEncoders {
+		for _, tt := range timeEncoders(te.encode) {
+			enc := tt.enc
+			b.Run(te.name+"/"+tt.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					buf, err := enc.EncodeEntry(ent, fields)
+					if err != nil {
+						b.Fatal(err)
+					}
+					buf.Free()
+				}
+			})
+		}
+	}
+}
@@ -70,7 +70,7 @@ func (s *lockedWriteSyncer) Write(bs []byte) (int, error) {
 
 func (s *lockedWriteSyncer) Sync() error {
 	s.Lock()
-	err := s.ws.Sync()
+	err := syncWriteSyncer(s.ws)
 	s.Unlock()
 	return err
 }
@@ -116,7 +116,7 @@ func (ws multiWriteSyncer) Write(p []byte) (int, error) {
 func (ws multiWriteSyncer) Sync() error {
 	var err error
 	for _, w := range ws {
-		err = multierr.Append(err, w.Sync())
+		err = appendSyncError(err, syncWriteSyncer(w))
 	}
 	return err
 }
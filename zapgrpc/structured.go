@@ -0,0 +1,180 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapgrpc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/toujourser/zap"
+	"github.com/toujourser/zap/zapcore"
+)
+
+// WithStructured configures a Logger to turn gRPC's print-style arguments
+// into fields, rather than flattening them into the message:
+//
+//   - If the last argument is an error, it's also attached as a zap.Error
+//     field.
+//   - If a format string is followed by more arguments than it has verbs,
+//     and the extra arguments form key-value pairs, they're attached as
+//     fields instead of being appended to the message.
+//
+// Without this option, the Logger's output is unchanged.
+func WithStructured() Option {
+	return optionFunc(func(logger *Logger) {
+		if logger.structured == nil {
+			logger.structured = &structuredLogger{}
+		}
+	})
+}
+
+// WithMessageRewrite installs a function that rewrites each message and
+// returns fields to add to it. See StripComponentPrefix for an example.
+// It implies WithStructured.
+func WithMessageRewrite(rewrite func(msg string) (string, []zap.Field)) Option {
+	return optionFunc(func(logger *Logger) {
+		WithStructured().apply(logger)
+		logger.structured.rewrite = rewrite
+	})
+}
+
+// StripComponentPrefix is a message rewrite function for use with
+// WithMessageRewrite. It moves the component prefixes that gRPC adds to its
+// messages, like "transport: " or "[core] ", into a "component" field.
+func StripComponentPrefix(msg string) (string, []zap.Field) {
+	for _, name := range []string{"grpc", "transport"} {
+		if rest := strings.TrimPrefix(msg, name+": "); len(rest) < len(msg) {
+			return rest, []zap.Field{zap.String("component", name)}
+		}
+	}
+	if strings.HasPrefix(msg, "[") {
+		if end := strings.Index(msg, "] "); end > 1 && !strings.ContainsAny(msg[1:end], " []") {
+			return msg[end+2:], []zap.Field{zap.String("component", msg[1:end])}
+		}
+	}
+	return msg, nil
+}
+
+// structuredLogger logs print-style arguments with WithStructured.
+type structuredLogger struct {
+	enab    zapcore.LevelEnabler
+	logger  *zap.Logger // skips the Logger method and the structuredLogger method
+	rewrite func(string) (string, []zap.Field)
+}
+
+// enabled reports whether to build a message at lvl. Fatal messages always
+// reach the logger, which exits even if the level is disabled.
+func (s *structuredLogger) enabled(lvl zapcore.Level) bool {
+	return lvl > zapcore.ErrorLevel || s.enab.Enabled(lvl)
+}
+
+func (s *structuredLogger) print(lvl zapcore.Level, skip int, args []interface{}) {
+	if s.enabled(lvl) {
+		s.log(lvl, skip, fmt.Sprint(args...), lastError(args))
+	}
+}
+
+func (s *structuredLogger) println(lvl zapcore.Level, skip int, args []interface{}) {
+	if s.enabled(lvl) {
+		s.log(lvl, skip, sprintln(args), lastError(args))
+	}
+}
+
+func (s *structuredLogger) printf(lvl zapcore.Level, skip int, format string, args []interface{}) {
+	if !s.enabled(lvl) {
+		return
+	}
+	if n, ok := countVerbs(format); ok && n < len(args) {
+		if fields, ok := pairFields(args[n:]); ok {
+			args = args[:n]
+			s.log(lvl, skip, fmt.Sprintf(format, args...), append(lastError(args), fields...))
+			return
+		}
+	}
+	s.log(lvl, skip, fmt.Sprintf(format, args...), lastError(args))
+}
+
+func (s *structuredLogger) log(lvl zapcore.Level, skip int, msg string, fields []zap.Field) {
+	if s.rewrite != nil {
+		var extra []zap.Field
+		msg, extra = s.rewrite(msg)
+		fields = append(fields, extra...)
+	}
+	logger := s.logger
+	if skip > 0 {
+		logger = logger.WithOptions(zap.AddCallerSkip(skip))
+	}
+	logger.Log(lvl, msg, fields...)
+}
+
+// lastError returns a zap.Error field if the last argument is an error.
+func lastError(args []interface{}) []zap.Field {
+	if len(args) == 0 {
+		return nil
+	}
+	if err, ok := args[len(args)-1].(error); ok {
+		return []zap.Field{zap.Error(err)}
+	}
+	return nil
+}
+
+// pairFields converts alternating string keys and values to fields. It
+// reports false if args aren't key-value pairs.
+func pairFields(args []interface{}) ([]zap.Field, bool) {
+	if len(args)%2 != 0 {
+		return nil, false
+	}
+	fields := make([]zap.Field, 0, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return nil, false
+		}
+		fields = append(fields, zap.Any(key, args[i+1]))
+	}
+	return fields, true
+}
+
+// countVerbs returns the number of arguments consumed by a format string. It
+// reports false if the format uses explicit argument indexes, since the
+// count is then ambiguous.
+func countVerbs(format string) (int, bool) {
+	n := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		// Flags, width, and precision; '*' consumes an argument.
+		for ; i < len(format) && strings.IndexByte("+-# 0123456789.*[]", format[i]) >= 0; i++ {
+			switch format[i] {
+			case '*':
+				n++
+			case '[':
+				return 0, false
+			}
+		}
+		if i < len(format) && format[i] != '%' {
+			n++
+		}
+	}
+	return n, true
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapgrpc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/toujourser/zap"
+	"github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuredMessagesUnchanged(t *testing.T) {
+	logAll := func(logger *Logger) {
+		logger.Info("s1", "s2", 1, 2, 3, "s3", 4, "s5", 6)
+		logger.Infof("%s world", "hello")
+		logger.Infoln("s1", "s2", 1, 2, 3)
+		logger.Warningf("%d%%", 5)
+		logger.Errorln()
+		logger.Print("hello")
+		logger.Printf("%v", 1, 2)
+		logger.Println("foo", "bar")
+		logger.InfoDepth(0, "s1", "s2", 1)
+		logger.Fatalf("%s", "fatal")
+	}
+
+	var want, got []string
+	withLogger(zapcore.DebugLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+		logAll(logger)
+		for _, e := range logs.AllUntimed() {
+			want = append(want, e.Level.String()+" "+e.Message)
+		}
+	})
+	withLogger(zapcore.DebugLevel, []Option{WithStructured()}, func(logger *Logger, logs *observer.ObservedLogs) {
+		logAll(logger)
+		for _, e := range logs.AllUntimed() {
+			got = append(got, e.Level.String()+" "+e.Message)
+			assert.Empty(t, e.Context, "Unexpected fields for %q.", e.Message)
+		}
+	})
+	assert.Equal(t, want, got, "Expected structured mode to leave messages unchanged.")
+}
+
+func TestStructuredFields(t *testing.T) {
+	errClosed := errors.New("connection closed")
+	tests := []struct {
+		desc       string
+		log        func(*Logger)
+		wantMsg    string
+		wantFields []zapcore.Field
+	}{
+		{
+			desc:       "trailing error",
+			log:        func(l *Logger) { l.Info("transport: closing: ", errClosed) },
+			wantMsg:    "transport: closing: connection closed",
+			wantFields: []zapcore.Field{zap.Error(errClosed)},
+		},
+		{
+			desc:       "trailing error in format",
+			log:        func(l *Logger) { l.Warningf("transport: closing: %v", errClosed) },
+			wantMsg:    "transport: closing: connection closed",
+			wantFields: []zapcore.Field{zap.Error(errClosed)},
+		},
+		{
+			desc:       "trailing error with newline",
+			log:        func(l *Logger) { l.Errorln("closing:", errClosed) },
+			wantMsg:    "closing: connection closed",
+			wantFields: []zapcore.Field{zap.Error(errClosed)},
+		},
+		{
+			desc:    "key-value pairs",
+			log:     func(l *Logger) { l.Infof("connected to %s", "server", "peer", "10.0.0.1", "attempt", 2) },
+			wantMsg: "connected to server",
+			wantFields: []zapcore.Field{
+				zap.String("peer", "10.0.0.1"),
+				zap.Int("attempt", 2),
+			},
+		},
+		{
+			desc:    "key-value pairs with error",
+			log:     func(l *Logger) { l.Infof("closing: %v", errClosed, "reason", errClosed) },
+			wantMsg: "closing: connection closed",
+			wantFields: []zapcore.Field{
+				zap.Error(errClosed),
+				zap.NamedError("reason", errClosed),
+			},
+		},
+		{
+			desc:    "odd extra arguments",
+			log:     func(l *Logger) { l.Infof("%s", "a", "b") },
+			wantMsg: "a%!(EXTRA string=b)",
+		},
+		{
+			desc:    "extra arguments without string keys",
+			log:     func(l *Logger) { l.Infof("%s", "a", 1, 2) },
+			wantMsg: "a%!(EXTRA int=1, int=2)",
+		},
+		{
+			desc:    "explicit argument indexes",
+			log:     func(l *Logger) { l.Infof("%[2]s", "k", "v") },
+			wantMsg: "v",
+		},
+		{
+			desc:    "star width",
+			log:     func(l *Logger) { l.Infof("%*d", 3, 1, "k", "v") },
+			wantMsg: "  1",
+			wantFields: []zapcore.Field{
+				zap.String("k", "v"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			withLogger(zapcore.DebugLevel, []Option{WithStructured()}, func(logger *Logger, logs *observer.ObservedLogs) {
+				tt.log(logger)
+				entries := logs.AllUntimed()
+				require.Len(t, entries, 1, "Expected a single entry.")
+				assert.Equal(t, tt.wantMsg, entries[0].Message, "Unexpected message.")
+				if tt.wantFields == nil {
+					tt.wantFields = []zapcore.Field{}
+				}
+				assert.Equal(t, tt.wantFields, entries[0].Context, "Unexpected fields.")
+			})
+		})
+	}
+}
+
+func TestStructuredMessageRewrite(t *testing.T) {
+	tests := []struct {
+		msg        string
+		wantMsg    string
+		wantFields []zapcore.Field
+	}{
+		{"transport: closing", "closing", []zapcore.Field{zap.String("component", "transport")}},
+		{"grpc: addrConn.createTransport failed", "addrConn.createTransport failed", []zapcore.Field{zap.String("component", "grpc")}},
+		{"[core] Channel Connectivity change", "Channel Connectivity change", []zapcore.Field{zap.String("component", "core")}},
+		{"[not a component] message", "[not a component] message", []zapcore.Field{}},
+		{"[] message", "[] message", []zapcore.Field{}},
+		{"error: failed", "error: failed", []zapcore.Field{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			opts := []Option{WithMessageRewrite(StripComponentPrefix)}
+			withLogger(zapcore.DebugLevel, opts, func(logger *Logger, logs *observer.ObservedLogs) {
+				logger.Info(tt.msg)
+				entries := logs.AllUntimed()
+				require.Len(t, entries, 1, "Expected a single entry.")
+				assert.Equal(t, tt.wantMsg, entries[0].Message, "Unexpected message.")
+				assert.Equal(t, tt.wantFields, entries[0].Context, "Unexpected fields.")
+			})
+		})
+	}
+}
+
+func TestStructuredSuppressed(t *testing.T) {
+	checkMessages(t, zapcore.ErrorLevel, []Option{WithStructured()}, zapcore.InfoLevel, nil, func(logger *Logger) {
+		logger.Info("hello")
+		logger.Infof("%s", "hello", "k", "v")
+		logger.Warningln("hello")
+		logger.Print("hello")
+		logger.InfoDepth(0, "hello")
+	})
+}
+
+func TestStructuredCaller(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(zap.New(core, zap.AddCaller()), WithStructured())
+
+	logger.Info("info")
+	logger.Warningf("%s", "warningf")
+	logger.Errorln("errorln")
+	logger.Print("print")
+	logger.InfoDepth(0, "depth")
+	logAtDepth(logger)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 6)
+	for _, e := range entries {
+		require.True(t, e.Caller.Defined, "Expected caller for %q.", e.Message)
+		assert.Equal(t, "TestStructuredCaller", funcName(e.Caller.Function),
+			"Unexpected caller %v for %q.", e.Caller, e.Message)
+	}
+}
+
+func TestStructuredFatalHook(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(zap.New(core, zap.WithFatalHook(zapcore.WriteThenPanic)), WithStructured())
+
+	require.Panics(t, func() { logger.Fatal("fatal") }, "Expected Fatal to use the fatal hook.")
+	require.Panics(t, func() { logger.Fatalf("%v", errors.New("fatalf")) }, "Expected Fatalf to use the fatal hook.")
+	require.Panics(t, func() { logger.FatalDepth(0, "depth") }, "Expected FatalDepth to use the fatal hook.")
+	assert.Equal(t, 3, logs.Len(), "Expected fatal entries to be logged.")
+}
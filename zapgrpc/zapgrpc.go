@@ -72,6 +72,22 @@ func WithDebug() Option {
 	})
 }
 
+// WithVerbosityLevels configures the zap level consulted by V for each gRPC
+// verbosity level in the provided map. Levels that aren't in the map keep
+// their default mapping; unknown levels are checked against InfoLevel.
+func WithVerbosityLevels(levels map[int]zapcore.Level) Option {
+	return optionFunc(func(logger *Logger) {
+		verbosity := make(map[int]zapcore.Level, len(logger.verbosity)+len(levels))
+		for v, lvl := range logger.verbosity {
+			verbosity[v] = lvl
+		}
+		for v, lvl := range levels {
+			verbosity[v] = lvl
+		}
+		logger.verbosity = verbosity
+	})
+}
+
 // withWarn redirects the fatal level to the warn level, which makes testing
 // easier. This is intentionally unexported.
 func withWarn() Option {
@@ -90,6 +106,7 @@ func NewLogger(l *zap.Logger, options ...Option) *Logger {
 	logger := &Logger{
 		delegate:     l.Sugar(),
 		levelEnabler: l.Core(),
+		verbosity:    _grpcToZapLevel,
 	}
 	logger.print = &printer{
 		enab:   logger.levelEnabler,
@@ -106,6 +123,12 @@ func NewLogger(l *zap.Logger, options ...Option) *Logger {
 	for _, option := range options {
 		option.apply(logger)
 	}
+	if s := logger.structured; s != nil {
+		s.enab = logger.levelEnabler
+		s.logger = l.WithOptions(zap.AddCallerSkip(3))
+		logger.print.structured = s
+		logger.fatal.structured = s
+	}
 	return logger
 }
 
@@ -114,32 +137,48 @@ func NewLogger(l *zap.Logger, options ...Option) *Logger {
 // We use it to customize Debug vs Info, and Warn vs Fatal for Print and Fatal
 // respectively.
 type printer struct {
-	enab   zapcore.LevelEnabler
-	level  zapcore.Level
-	print  func(...interface{})
-	printf func(string, ...interface{})
+	enab       zapcore.LevelEnabler
+	level      zapcore.Level
+	print      func(...interface{})
+	printf     func(string, ...interface{})
+	structured *structuredLogger
 }
 
 func (v *printer) Print(args ...interface{}) {
+	if v.structured != nil {
+		v.structured.print(v.level, 1, args)
+		return
+	}
 	v.print(args...)
 }
 
 func (v *printer) Printf(format string, args ...interface{}) {
+	if v.structured != nil {
+		v.structured.printf(v.level, 1, format, args)
+		return
+	}
 	v.printf(format, args...)
 }
 
 func (v *printer) Println(args ...interface{}) {
+	if v.structured != nil {
+		v.structured.println(v.level, 1, args)
+		return
+	}
 	if v.enab.Enabled(v.level) {
 		v.print(sprintln(args))
 	}
 }
 
-// Logger adapts zap's Logger to be compatible with grpclog.LoggerV2 and the deprecated grpclog.Logger.
+// Logger adapts zap's Logger to be compatible with grpclog.LoggerV2,
+// grpclog.DepthLoggerV2, and the deprecated grpclog.Logger.
 type Logger struct {
 	delegate     *zap.SugaredLogger
 	levelEnabler zapcore.LevelEnabler
+	verbosity    map[int]zapcore.Level
 	print        *printer
 	fatal        *printer
+	structured   *structuredLogger // nil unless WithStructured is used
 	// printToDebug bool
 	// fatalToWarn  bool
 }
@@ -167,11 +206,19 @@ func (l *Logger) Println(args ...interface{}) {
 
 // Info implements grpclog.LoggerV2.
 func (l *Logger) Info(args ...interface{}) {
+	if l.structured != nil {
+		l.structured.print(zapcore.InfoLevel, 0, args)
+		return
+	}
 	l.delegate.Info(args...)
 }
 
 // Infoln implements grpclog.LoggerV2.
 func (l *Logger) Infoln(args ...interface{}) {
+	if l.structured != nil {
+		l.structured.println(zapcore.InfoLevel, 0, args)
+		return
+	}
 	if l.levelEnabler.Enabled(zapcore.InfoLevel) {
 		l.delegate.Info(sprintln(args))
 	}
@@ -179,16 +226,28 @@ func (l *Logger) Infoln(args ...interface{}) {
 
 // Infof implements grpclog.LoggerV2.
 func (l *Logger) Infof(format string, args ...interface{}) {
+	if l.structured != nil {
+		l.structured.printf(zapcore.InfoLevel, 0, format, args)
+		return
+	}
 	l.delegate.Infof(format, args...)
 }
 
 // Warning implements grpclog.LoggerV2.
 func (l *Logger) Warning(args ...interface{}) {
+	if l.structured != nil {
+		l.structured.print(zapcore.WarnLevel, 0, args)
+		return
+	}
 	l.delegate.Warn(args...)
 }
 
 // Warningln implements grpclog.LoggerV2.
 func (l *Logger) Warningln(args ...interface{}) {
+	if l.structured != nil {
+		l.structured.println(zapcore.WarnLevel, 0, args)
+		return
+	}
 	if l.levelEnabler.Enabled(zapcore.WarnLevel) {
 		l.delegate.Warn(sprintln(args))
 	}
@@ -196,16 +255,28 @@ func (l *Logger) Warningln(args ...interface{}) {
 
 // Warningf implements grpclog.LoggerV2.
 func (l *Logger) Warningf(format string, args ...interface{}) {
+	if l.structured != nil {
+		l.structured.printf(zapcore.WarnLevel, 0, format, args)
+		return
+	}
 	l.delegate.Warnf(format, args...)
 }
 
 // Error implements grpclog.LoggerV2.
 func (l *Logger) Error(args ...interface{}) {
+	if l.structured != nil {
+		l.structured.print(zapcore.ErrorLevel, 0, args)
+		return
+	}
 	l.delegate.Error(args...)
 }
 
 // Errorln implements grpclog.LoggerV2.
 func (l *Logger) Errorln(args ...interface{}) {
+	if l.structured != nil {
+		l.structured.println(zapcore.ErrorLevel, 0, args)
+		return
+	}
 	if l.levelEnabler.Enabled(zapcore.ErrorLevel) {
 		l.delegate.Error(sprintln(args))
 	}
@@ -213,6 +284,10 @@ func (l *Logger) Errorln(args ...interface{}) {
 
 // Errorf implements grpclog.LoggerV2.
 func (l *Logger) Errorf(format string, args ...interface{}) {
+	if l.structured != nil {
+		l.structured.printf(zapcore.ErrorLevel, 0, format, args)
+		return
+	}
 	l.delegate.Errorf(format, args...)
 }
 
@@ -231,9 +306,64 @@ func (l *Logger) Fatalf(format string, args ...interface{}) {
 	l.fatal.Printf(format, args...)
 }
 
+// InfoDepth implements grpclog.DepthLoggerV2.
+//
+// The depth is the number of stack frames to skip above the caller of
+// InfoDepth when annotating the entry with caller information, in addition
+// to any skip configured on the wrapped logger.
+func (l *Logger) InfoDepth(depth int, args ...interface{}) {
+	if l.structured != nil {
+		l.structured.println(zapcore.InfoLevel, depth, args)
+		return
+	}
+	if l.levelEnabler.Enabled(zapcore.InfoLevel) {
+		l.withDepth(depth).Logln(zapcore.InfoLevel, args...)
+	}
+}
+
+// WarningDepth implements grpclog.DepthLoggerV2.
+func (l *Logger) WarningDepth(depth int, args ...interface{}) {
+	if l.structured != nil {
+		l.structured.println(zapcore.WarnLevel, depth, args)
+		return
+	}
+	if l.levelEnabler.Enabled(zapcore.WarnLevel) {
+		l.withDepth(depth).Logln(zapcore.WarnLevel, args...)
+	}
+}
+
+// ErrorDepth implements grpclog.DepthLoggerV2.
+func (l *Logger) ErrorDepth(depth int, args ...interface{}) {
+	if l.structured != nil {
+		l.structured.println(zapcore.ErrorLevel, depth, args)
+		return
+	}
+	if l.levelEnabler.Enabled(zapcore.ErrorLevel) {
+		l.withDepth(depth).Logln(zapcore.ErrorLevel, args...)
+	}
+}
+
+// FatalDepth implements grpclog.DepthLoggerV2.
+//
+// Like Fatal, it goes through the wrapped logger's fatal path, so hooks
+// installed with zap.WithFatalHook still apply.
+func (l *Logger) FatalDepth(depth int, args ...interface{}) {
+	if l.structured != nil {
+		l.structured.println(l.fatal.level, depth, args)
+		return
+	}
+	l.withDepth(depth).Logln(l.fatal.level, args...)
+}
+
 // V implements grpclog.LoggerV2.
 func (l *Logger) V(level int) bool {
-	return l.levelEnabler.Enabled(_grpcToZapLevel[level])
+	return l.levelEnabler.Enabled(l.verbosity[level])
+}
+
+// withDepth returns a logger that reports the caller depth frames above the
+// caller of the exported method that invoked it.
+func (l *Logger) withDepth(depth int) *zap.SugaredLogger {
+	return l.delegate.WithOptions(zap.AddCallerSkip(depth + 1))
 }
 
 func sprintln(args []interface{}) string {
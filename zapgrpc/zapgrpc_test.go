@@ -22,6 +22,7 @@ package zapgrpc
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/toujourser/zap"
@@ -261,3 +262,96 @@ func withLogger(
 	core, observedLogs := observer.New(enab)
 	f(NewLogger(zap.New(core), append(opts, withWarn())...), observedLogs)
 }
+
+func TestLoggerDepthExpected(t *testing.T) {
+	tests := []struct {
+		level zapcore.Level
+		log   func(*Logger, int, ...interface{})
+	}{
+		{zapcore.InfoLevel, (*Logger).InfoDepth},
+		{zapcore.WarnLevel, (*Logger).WarningDepth},
+		{zapcore.ErrorLevel, (*Logger).ErrorDepth},
+		{zapcore.WarnLevel, (*Logger).FatalDepth}, // withWarn redirects fatal logs
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			checkMessages(t, zapcore.DebugLevel, nil, tt.level, []string{
+				"hello",
+				"s1 s2 1 2 3",
+			}, func(logger *Logger) {
+				tt.log(logger, 0, "hello")
+				tt.log(logger, 0, "s1", "s2", 1, 2, 3)
+			})
+		})
+	}
+}
+
+func TestLoggerDepthSuppressed(t *testing.T) {
+	checkMessages(t, zapcore.ErrorLevel, nil, zapcore.InfoLevel, nil, func(logger *Logger) {
+		logger.InfoDepth(0, "hello")
+		logger.WarningDepth(0, "hello")
+	})
+}
+
+func TestLoggerDepthCaller(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(zap.New(core, zap.AddCaller()))
+
+	logger.InfoDepth(0, "direct")
+	logAtDepth(logger)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	for _, e := range entries {
+		require.True(t, e.Caller.Defined, "Expected caller for %q.", e.Message)
+		require.True(t, strings.HasPrefix(e.Caller.TrimmedPath(), "zapgrpc/zapgrpc_test.go:"),
+			"Unexpected caller %v for %q.", e.Caller, e.Message)
+	}
+	require.Equal(t, "TestLoggerDepthCaller", funcName(entries[0].Caller.Function),
+		"Depth 0 should report the caller of InfoDepth.")
+	require.Equal(t, "TestLoggerDepthCaller", funcName(entries[1].Caller.Function),
+		"Depth 1 should skip the helper frame.")
+}
+
+func logAtDepth(logger *Logger) {
+	logger.InfoDepth(1, "nested")
+}
+
+func funcName(fn string) string {
+	return fn[strings.LastIndex(fn, ".")+1:]
+}
+
+func TestLoggerFatalHook(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(zap.New(core, zap.WithFatalHook(zapcore.WriteThenPanic)))
+
+	require.Panics(t, func() { logger.Fatal("fatal") }, "Expected Fatal to use the fatal hook.")
+	require.Panics(t, func() { logger.Fatalf("%s", "fatalf") }, "Expected Fatalf to use the fatal hook.")
+	require.Panics(t, func() { logger.FatalDepth(0, "depth") }, "Expected FatalDepth to use the fatal hook.")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 3)
+	for _, e := range entries {
+		require.Equal(t, zapcore.FatalLevel, e.Level)
+	}
+}
+
+func TestLoggerVerbosityLevels(t *testing.T) {
+	levels := WithVerbosityLevels(map[int]zapcore.Level{
+		0: zapcore.InfoLevel,
+		4: zapcore.DebugLevel,
+	})
+
+	withLogger(zapcore.InfoLevel, []Option{levels}, func(logger *Logger, _ *observer.ObservedLogs) {
+		require.True(t, logger.V(0))
+		require.False(t, logger.V(4), "Verbosity 4 should map to DebugLevel.")
+		require.True(t, logger.V(grpcLvlError), "Unmapped levels should keep their defaults.")
+	})
+	withLogger(zapcore.DebugLevel, []Option{levels}, func(logger *Logger, _ *observer.ObservedLogs) {
+		require.True(t, logger.V(4))
+	})
+	withLogger(zapcore.ErrorLevel, nil, func(logger *Logger, _ *observer.ObservedLogs) {
+		require.False(t, logger.V(grpcLvlWarn), "Default mapping should not be modified by options.")
+	})
+}
@@ -23,7 +23,11 @@ package zapio
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"regexp"
+	"sync"
+	"time"
 
 	"github.com/toujourser/zap"
 	"github.com/toujourser/zap/zapcore"
@@ -47,6 +51,19 @@ import (
 //	    return err
 //	}
 //
+// To log lines at the level that the program writing them indicates, set
+// Prefixes:
+//
+//	writer := &zapio.Writer{
+//	    Log:      logger,
+//	    Prefixes: zapio.DefaultLevelPrefixes(),
+//	}
+//
+// Output that updates a line in place, such as a progress bar, rarely ends
+// lines with a newline. Set MaxLineLength or FlushInterval to log it anyway;
+// either also makes carriage returns end lines, so that each update becomes
+// its own entry.
+//
 // Writer must be closed when finished to flush buffered data to the logger.
 type Writer struct {
 	// Log specifies the logger to which the Writer will write messages.
@@ -59,7 +76,83 @@ type Writer struct {
 	// If unspecified, defaults to Info.
 	Level zapcore.Level
 
+	// Prefixes detects the level of each line from its prefix. The first
+	// LevelPrefix whose Pattern matches at the start of a line determines
+	// the level of that line, and the matched prefix is removed from the
+	// message. Lines that no Pattern matches are logged at Level.
+	//
+	// If unspecified, all lines are logged at Level.
+	Prefixes []LevelPrefix
+
+	// MaxLineLength limits the number of bytes buffered for a single line.
+	// Longer lines are split into several messages of at most this many
+	// bytes, each logged at the level detected for the start of the line.
+	//
+	// If unspecified, lines are buffered until they end, however long.
+	MaxLineLength int
+
+	// FlushInterval limits how long the start of a line is buffered. If a
+	// line hasn't ended this long after it started, the part buffered so
+	// far is logged by a background timer, with a "partial" field set to
+	// true, and the rest of the line follows in later messages.
+	//
+	// If unspecified, partial lines are only logged by Sync and Close.
+	FlushInterval time.Duration
+
+	mu   sync.Mutex
 	buff bytes.Buffer
+
+	// skipLF is set when the last write ended with a carriage return, so
+	// that a newline starting the next write completes a CRLF rather than
+	// ending an empty line.
+	skipLF bool
+
+	// timerGen identifies the current FlushInterval timer. It's incremented
+	// whenever the buffer is flushed, so that stale timers do nothing.
+	timerGen uint64
+	timer    *time.Timer
+
+	// continued is set when the part of the current line logged so far
+	// was split off because of MaxLineLength; the rest of the line is
+	// logged at contLevel.
+	continued bool
+	contLevel zapcore.Level
+}
+
+// LevelPrefix maps a line prefix to the level at which the Writer logs lines
+// starting with it.
+type LevelPrefix struct {
+	// Pattern matches the prefix. Matches that don't start at the
+	// beginning of the line are ignored.
+	Pattern *regexp.Regexp
+
+	// Level is the level for lines that Pattern matches.
+	Level zapcore.Level
+}
+
+// DefaultLevelPrefixes returns LevelPrefixes for common ways of marking the
+// level of a line, such as "ERROR: ...", "WARN ..." or "[debug] ...",
+// ignoring case. It recognizes the Debug, Info, Warn and Error levels.
+//
+// The returned slice may be modified or extended freely.
+func DefaultLevelPrefixes() []LevelPrefix {
+	return []LevelPrefix{
+		levelPrefix("debug|dbg", zapcore.DebugLevel),
+		levelPrefix("info", zapcore.InfoLevel),
+		levelPrefix("warning|warn", zapcore.WarnLevel),
+		levelPrefix("error", zapcore.ErrorLevel),
+	}
+}
+
+// levelPrefix builds a LevelPrefix matching any of the given names followed
+// by a colon or a space, or enclosed in brackets.
+func levelPrefix(names string, lvl zapcore.Level) LevelPrefix {
+	return LevelPrefix{
+		Pattern: regexp.MustCompile(fmt.Sprintf(
+			`(?i)^(?:\[(?:%[1]s)\]:?\s*|(?:%[1]s):\s*|(?:%[1]s)\s+)`, names,
+		)),
+		Level: lvl,
+	}
 }
 
 var (
@@ -73,40 +166,70 @@ var (
 // Write will split the input on newlines and post each line as a new log entry
 // to the logger.
 func (w *Writer) Write(bs []byte) (n int, err error) {
-	// Skip all checks if the level isn't enabled.
-	if !w.Log.Core().Enabled(w.Level) {
+	// Skip all checks if the level isn't enabled. Lines may be logged at
+	// other levels if Prefixes is set.
+	if len(w.Prefixes) == 0 && !w.Log.Core().Enabled(w.Level) {
 		return len(bs), nil
 	}
 
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	n = len(bs)
+	if w.skipLF && len(bs) > 0 {
+		w.skipLF = false
+		if bs[0] == '\n' {
+			bs = bs[1:]
+		}
+	}
 	for len(bs) > 0 {
 		bs = w.writeLine(bs)
 	}
+	w.startTimer()
 
 	return n, nil
 }
 
+// splitCR reports whether carriage returns end lines.
+func (w *Writer) splitCR() bool {
+	return w.MaxLineLength > 0 || w.FlushInterval > 0
+}
+
 // writeLine writes a single line from the input, returning the remaining,
 // unconsumed bytes.
 func (w *Writer) writeLine(line []byte) (remaining []byte) {
-	idx := bytes.IndexByte(line, '\n')
+	var idx int
+	if w.splitCR() {
+		idx = bytes.IndexAny(line, "\r\n")
+	} else {
+		idx = bytes.IndexByte(line, '\n')
+	}
 	if idx < 0 {
 		// If there are no newlines, buffer the entire string.
-		w.buff.Write(line)
+		w.buffer(line)
 		return nil
 	}
 
 	// Split on the newline, buffer and flush the left.
-	line, remaining = line[:idx], line[idx+1:]
+	end := idx + 1
+	if line[idx] == '\r' {
+		switch {
+		case end == len(line):
+			w.skipLF = true
+		case line[end] == '\n':
+			end++
+		}
+	}
+	line, remaining = line[:idx], line[end:]
 
 	// Fast path: if we don't have a partial message from a previous write
 	// in the buffer, skip the buffer and log directly.
-	if w.buff.Len() == 0 {
-		w.log(line)
+	if w.buff.Len() == 0 && !w.tooLong(len(line)) {
+		w.log(line, true /* eol */)
 		return
 	}
 
-	w.buff.Write(line)
+	w.buffer(line)
 
 	// Log empty messages in the middle of the stream so that we don't lose
 	// information when the user writes "foo\n\nbar".
@@ -115,6 +238,37 @@ func (w *Writer) writeLine(line []byte) (remaining []byte) {
 	return remaining
 }
 
+// buffer appends b to the partial line in the buffer, logging parts of the
+// line as they exceed MaxLineLength.
+func (w *Writer) buffer(b []byte) {
+	if w.MaxLineLength <= 0 {
+		w.buff.Write(b)
+		return
+	}
+
+	// Copy b in parts so that the buffer never holds much more than
+	// MaxLineLength bytes.
+	for len(b) > 0 {
+		n := len(b)
+		if n > w.MaxLineLength {
+			n = w.MaxLineLength
+		}
+		w.buff.Write(b[:n])
+		b = b[n:]
+
+		// Keep the tail of the line in the buffer even if it's exactly
+		// MaxLineLength bytes long: we don't know yet whether the line
+		// continues.
+		for w.tooLong(w.buff.Len()) {
+			w.log(w.buff.Next(w.MaxLineLength), false /* eol */)
+		}
+	}
+}
+
+func (w *Writer) tooLong(n int) bool {
+	return w.MaxLineLength > 0 && n > w.MaxLineLength
+}
+
 // Close closes the writer, flushing any buffered data in the process.
 //
 // Always call Close once you're done with the Writer to ensure that it flushes
@@ -123,9 +277,38 @@ func (w *Writer) Close() error {
 	return w.Sync()
 }
 
+// startTimer starts the FlushInterval timer if a partial line is buffered
+// and the timer isn't already running. It must be called with w.mu held.
+func (w *Writer) startTimer() {
+	if w.FlushInterval <= 0 || w.buff.Len() == 0 || w.timer != nil {
+		return
+	}
+	gen := w.timerGen
+	w.timer = time.AfterFunc(w.FlushInterval, func() { w.flushPartial(gen) })
+}
+
+// flushPartial logs the partial line in the buffer when the FlushInterval
+// timer fires, unless the buffer has been flushed since the timer started.
+func (w *Writer) flushPartial(gen uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if gen != w.timerGen {
+		return
+	}
+	w.timer = nil
+	if w.buff.Len() > 0 {
+		w.log(w.buff.Bytes(), false /* eol */, zap.Bool("partial", true))
+		w.buff.Reset()
+	}
+}
+
 // Sync flushes buffered data to the logger as a new log entry even if it
 // doesn't contain a newline.
 func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	// Don't allow empty messages on explicit Sync calls or on Close
 	// because we don't want an extraneous empty message at the end of the
 	// stream -- it's common for files to end with a newline.
@@ -137,13 +320,39 @@ func (w *Writer) Sync() error {
 // if the bool is set.
 func (w *Writer) flush(allowEmpty bool) {
 	if allowEmpty || w.buff.Len() > 0 {
-		w.log(w.buff.Bytes())
+		w.log(w.buff.Bytes(), true /* eol */)
 	}
 	w.buff.Reset()
+	w.continued = false
+
+	w.timerGen++
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+// log logs b, which is a line or, if eol is false, the start of a line that
+// continues in the next call.
+func (w *Writer) log(b []byte, eol bool, fields ...zap.Field) {
+	lvl := w.contLevel
+	if !w.continued {
+		lvl, b = w.detectLevel(b)
+	}
+	w.continued, w.contLevel = !eol, lvl
+
+	if ce := w.Log.Check(lvl, string(b)); ce != nil {
+		ce.Write(fields...)
+	}
 }
 
-func (w *Writer) log(b []byte) {
-	if ce := w.Log.Check(w.Level, string(b)); ce != nil {
-		ce.Write()
+// detectLevel returns the level for the line according to Prefixes, and the
+// line without the prefix that determined it.
+func (w *Writer) detectLevel(line []byte) (zapcore.Level, []byte) {
+	for _, p := range w.Prefixes {
+		if loc := p.Pattern.FindIndex(line); loc != nil && loc[0] == 0 {
+			return p.Level, line[loc[1]:]
+		}
 	}
+	return w.Level, line
 }
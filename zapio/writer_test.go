@@ -22,7 +22,9 @@ package zapio
 
 import (
 	"io"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -157,6 +159,243 @@ func TestWriter(t *testing.T) {
 	}
 }
 
+func TestWriterPrefixes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc          string
+		prefixes      []LevelPrefix // defaults to DefaultLevelPrefixes
+		maxLineLength int
+		writes        []string
+		want          []zapcore.Entry
+	}{
+		{
+			desc: "default prefixes",
+			writes: []string{
+				"ERROR: disk full\n",
+				"WARN low memory\n",
+				"[debug] retrying\n",
+				"[Info]: started\n",
+				"warning:no space\n",
+				"plain line\n",
+			},
+			want: []zapcore.Entry{
+				{Level: zap.ErrorLevel, Message: "disk full"},
+				{Level: zap.WarnLevel, Message: "low memory"},
+				{Level: zap.DebugLevel, Message: "retrying"},
+				{Level: zap.InfoLevel, Message: "started"},
+				{Level: zap.WarnLevel, Message: "no space"},
+				{Level: zap.InfoLevel, Message: "plain line"},
+			},
+		},
+		{
+			desc: "prefix must start the line",
+			writes: []string{
+				"got ERROR: disk full\n",
+				"errors: 3\n",
+			},
+			want: []zapcore.Entry{
+				{Level: zap.InfoLevel, Message: "got ERROR: disk full"},
+				{Level: zap.InfoLevel, Message: "errors: 3"},
+			},
+		},
+		{
+			desc: "prefix split across writes",
+			writes: []string{
+				"ERR",
+				"OR: disk",
+				" full\nWA",
+				"RN x\n",
+			},
+			want: []zapcore.Entry{
+				{Level: zap.ErrorLevel, Message: "disk full"},
+				{Level: zap.WarnLevel, Message: "x"},
+			},
+		},
+		{
+			desc: "custom prefixes",
+			prefixes: []LevelPrefix{
+				{Pattern: regexp.MustCompile(`^E\d+ `), Level: zap.ErrorLevel},
+				{Pattern: regexp.MustCompile(`^W\d+ `), Level: zap.WarnLevel},
+			},
+			writes: []string{
+				"E0102 failed\n",
+				"W0102 slow\n",
+				"ERROR: not matched\n",
+			},
+			want: []zapcore.Entry{
+				{Level: zap.ErrorLevel, Message: "failed"},
+				{Level: zap.WarnLevel, Message: "slow"},
+				{Level: zap.InfoLevel, Message: "ERROR: not matched"},
+			},
+		},
+		{
+			desc:          "long lines are split",
+			maxLineLength: 4,
+			writes: []string{
+				"abcdefghij\n",
+				"abcd\n",
+				"ab",
+				"cdef",
+				"gh\n",
+			},
+			want: []zapcore.Entry{
+				{Level: zap.InfoLevel, Message: "abcd"},
+				{Level: zap.InfoLevel, Message: "efgh"},
+				{Level: zap.InfoLevel, Message: "ij"},
+				{Level: zap.InfoLevel, Message: "abcd"},
+				{Level: zap.InfoLevel, Message: "abcd"},
+				{Level: zap.InfoLevel, Message: "efgh"},
+			},
+		},
+		{
+			// MaxLineLength counts the prefix too.
+			desc:          "split lines keep the detected level",
+			maxLineLength: 8,
+			writes: []string{
+				"WARN low memory\n",
+				"ERROR: a",
+				"bcdefghijk",
+			},
+			want: []zapcore.Entry{
+				{Level: zap.WarnLevel, Message: "low"},
+				{Level: zap.WarnLevel, Message: " memory"},
+				{Level: zap.ErrorLevel, Message: "a"},
+				{Level: zap.ErrorLevel, Message: "bcdefghi"},
+				{Level: zap.ErrorLevel, Message: "jk"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // for t.Parallel
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			core, observed := observer.New(zap.DebugLevel)
+
+			prefixes := tt.prefixes
+			if prefixes == nil {
+				prefixes = DefaultLevelPrefixes()
+			}
+			w := Writer{
+				Log:           zap.New(core),
+				Prefixes:      prefixes,
+				MaxLineLength: tt.maxLineLength,
+			}
+
+			for _, s := range tt.writes {
+				_, err := io.WriteString(&w, s)
+				require.NoError(t, err, "Writer.Write failed.")
+			}
+
+			assert.NoError(t, w.Close(), "Writer.Close failed.")
+
+			got := make([]zapcore.Entry, observed.Len())
+			for i, ent := range observed.AllUntimed() {
+				got[i] = ent.Entry
+			}
+			assert.Equal(t, tt.want, got, "Logged entries do not match.")
+		})
+	}
+}
+
+func TestWriterPrefixesDisabledLevel(t *testing.T) {
+	t.Parallel()
+
+	core, observed := observer.New(zap.ErrorLevel)
+	w := Writer{
+		Log:      zap.New(core),
+		Level:    zap.DebugLevel,
+		Prefixes: DefaultLevelPrefixes(),
+	}
+
+	_, err := io.WriteString(&w, "ignored\nERROR: kept\n")
+	require.NoError(t, err, "Writer.Write failed.")
+	assert.NoError(t, w.Close(), "Writer.Close failed.")
+
+	assert.Equal(t, []observer.LoggedEntry{
+		{Entry: zapcore.Entry{Level: zap.ErrorLevel, Message: "kept"}, Context: []zapcore.Field{}},
+	}, observed.AllUntimed(), "Expected lines with enabled prefixes to be logged.")
+}
+
+func TestWriterCarriageReturns(t *testing.T) {
+	t.Parallel()
+
+	writes := []string{"10%\r20%", "\r30%\r", "\ndone\r\n", "a\rb"}
+	tests := []struct {
+		desc          string
+		maxLineLength int
+		want          []string
+	}{
+		{
+			desc: "newlines only",
+			want: []string{"10%\r20%\r30%\r", "done\r", "a\rb"},
+		},
+		{
+			desc:          "with MaxLineLength",
+			maxLineLength: 100,
+			want:          []string{"10%", "20%", "30%", "done", "a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // for t.Parallel
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			core, observed := observer.New(zap.InfoLevel)
+			w := Writer{Log: zap.New(core), MaxLineLength: tt.maxLineLength}
+			for _, s := range writes {
+				_, err := io.WriteString(&w, s)
+				require.NoError(t, err, "Writer.Write failed.")
+			}
+			assert.NoError(t, w.Close(), "Writer.Close failed.")
+
+			var got []string
+			for _, ent := range observed.AllUntimed() {
+				got = append(got, ent.Message)
+			}
+			assert.Equal(t, tt.want, got, "Logged messages do not match.")
+		})
+	}
+}
+
+func TestWriterFlushInterval(t *testing.T) {
+	t.Parallel()
+
+	core, observed := observer.New(zap.DebugLevel)
+	w := Writer{
+		Log:           zap.New(core),
+		Prefixes:      DefaultLevelPrefixes(),
+		FlushInterval: 10 * time.Millisecond,
+	}
+
+	_, err := io.WriteString(&w, "WARN: downloading...")
+	require.NoError(t, err, "Writer.Write failed.")
+	require.Eventually(t, func() bool { return observed.Len() == 1 }, time.Second, time.Millisecond,
+		"Expected the partial line to be flushed.")
+
+	// The rest of the line keeps the level detected at its start.
+	_, err = io.WriteString(&w, " done\nnext")
+	require.NoError(t, err, "Writer.Write failed.")
+	assert.NoError(t, w.Close(), "Writer.Close failed.")
+
+	partial := []zapcore.Field{zap.Bool("partial", true)}
+	assert.Equal(t, []observer.LoggedEntry{
+		{Entry: zapcore.Entry{Level: zap.WarnLevel, Message: "downloading..."}, Context: partial},
+		{Entry: zapcore.Entry{Level: zap.WarnLevel, Message: " done"}, Context: []zapcore.Field{}},
+		{Entry: zapcore.Entry{Level: zap.InfoLevel, Message: "next"}, Context: []zapcore.Field{}},
+	}, observed.AllUntimed(), "Unexpected log output.")
+	observed.TakeAll()
+
+	w.mu.Lock()
+	assert.Nil(t, w.timer, "Expected Close to stop the timer.")
+	w.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	assert.Zero(t, observed.Len(), "Expected nothing to be logged after Close.")
+}
+
 func TestWrite_Sync(t *testing.T) {
 	t.Parallel()
 
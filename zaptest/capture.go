@@ -0,0 +1,55 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zaptest
+
+import (
+	"github.com/toujourser/zap"
+	"github.com/toujourser/zap/zaptest/observer"
+)
+
+// Capture builds a Logger for a test, like NewLogger, and returns the
+// messages that it logs for assertions:
+//
+//	logger, logs := zaptest.Capture(t)
+//	doWork(logger)
+//	assert.Equal(t, 1, logs.FilterMessage("work done").Len())
+//
+// Messages are written to the test's log as well. The Level option sets the
+// level of the messages observed, which defaults to debug; QuietLevel and
+// LevelFromEnv only change which messages are written to the test's log, so
+// that assertions don't depend on how the tests are run.
+//
+// If t supports Cleanup, as *testing.T and *testing.B do, the Logger is
+// synced when the test completes, and the test fails if that returns an
+// error.
+func Capture(t TestingT, opts ...LoggerOption) (*zap.Logger, *observer.ObservedLogs) {
+	cfg := newLoggerOptions(opts)
+	core, logs := observer.New(cfg.Level)
+	logger := cfg.build(t, core)
+
+	if c, ok := t.(interface{ Cleanup(func()) }); ok {
+		c.Cleanup(func() {
+			if err := logger.Sync(); err != nil {
+				t.Errorf("zaptest: can't sync logger: %v", err)
+			}
+		})
+	}
+	return logger, logs
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package zaptest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/toujourser/zap"
+	"github.com/toujourser/zap/zapcore"
+	"github.com/toujourser/zap/zapcore/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapture(t *testing.T) {
+	ts := newTestLogSpy(t)
+	defer ts.AssertPassed()
+
+	logger, logs := Capture(ts)
+	logger.Debug("debug", zap.Int("n", 1))
+	logger.With(zap.String("k", "v")).Info("info")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2, "Unexpected number of observed entries.")
+	assert.Equal(t, "debug", entries[0].Message, "Unexpected message.")
+	assert.Equal(t, map[string]interface{}{"n": int64(1)}, entries[0].ContextMap(), "Unexpected fields.")
+	assert.Equal(t, map[string]interface{}{"k": "v"}, entries[1].ContextMap(), "Expected context to be observed.")
+
+	ts.AssertMessages(
+		`DEBUG	debug	{"n": 1}`,
+		`INFO	info	{"k": "v"}`,
+	)
+}
+
+func TestCaptureLevels(t *testing.T) {
+	t.Run("Level", func(t *testing.T) {
+		logger, logs := Capture(t, Level(zap.WarnLevel))
+		logger.Info("dropped")
+		logger.Warn("kept")
+		assert.Equal(t, 0, logs.FilterMessage("dropped").Len(), "Expected messages below the level to be dropped.")
+		assert.Equal(t, 1, logs.FilterMessage("kept").Len(), "Expected messages at the level to be observed.")
+	})
+
+	t.Run("QuietLevel", func(t *testing.T) {
+		ts := newTestLogSpy(t)
+		logger, logs := Capture(ts, QuietLevel(zap.ErrorLevel))
+		logger.Info("info")
+
+		// Whether the message is written to the test's log depends on -test.v,
+		// but it's always observed.
+		assert.Equal(t, 1, logs.Len(), "Expected QuietLevel not to change observed messages.")
+	})
+}
+
+func TestCaptureSampling(t *testing.T) {
+	mock := clock.NewMock()
+	logger, logs := Capture(t,
+		Clock(mock),
+		Sampling(time.Second, 2, 0),
+	)
+
+	for i := 0; i < 5; i++ {
+		logger.Info("sampled")
+	}
+	assert.Equal(t, 2, logs.Len(), "Expected the first messages in the tick to be observed.")
+
+	mock.Add(time.Second)
+	logger.Info("sampled")
+	assert.Equal(t, 3, logs.Len(), "Expected sampling to restart in the next tick.")
+
+	assert.Equal(t, mock.Now(), logs.All()[2].Time, "Expected entries to be stamped by the clock.")
+}
+
+func TestCaptureSyncOnCleanup(t *testing.T) {
+	syncErr := errors.New("can't sync")
+	ts := &cleanupSpy{testLogSpy: newTestLogSpy(t)}
+
+	logger, _ := Capture(ts, WrapOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return syncErrorCore{core, syncErr}
+	})))
+	logger.Info("hello")
+	ts.AssertPassed()
+
+	ts.runCleanups()
+	ts.AssertFailed()
+	assert.Contains(t, ts.Messages, "zaptest: can't sync logger: can't sync", "Expected the Sync error to be reported.")
+}
+
+// cleanupSpy is a testLogSpy that runs cleanup functions when asked to,
+// rather than when the test completes.
+type cleanupSpy struct {
+	*testLogSpy
+
+	cleanups []func()
+}
+
+func (t *cleanupSpy) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+func (t *cleanupSpy) runCleanups() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+type syncErrorCore struct {
+	zapcore.Core
+
+	err error
+}
+
+func (c syncErrorCore) Sync() error {
+	return c.err
+}
@@ -22,19 +22,33 @@ package zaptest
 
 import (
 	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
 
 	"github.com/toujourser/zap"
 	"github.com/toujourser/zap/zapcore"
 )
 
+// LevelEnv is the environment variable read by the LevelFromEnv option.
+const LevelEnv = "ZAP_TEST_LEVEL"
+
 // LoggerOption configures the test logger built by NewLogger.
 type LoggerOption interface {
 	applyLoggerOption(*loggerOptions)
 }
 
 type loggerOptions struct {
-	Level      zapcore.LevelEnabler
-	zapOptions []zap.Option
+	Level        zapcore.LevelEnabler
+	QuietLevel   zapcore.LevelEnabler // used without -test.v, if set
+	LevelFromEnv bool
+	NameByTest   bool
+	Clock        zapcore.Clock
+	Sampling     func(zapcore.Core) zapcore.Core
+	zapOptions   []zap.Option
 }
 
 type loggerOptionFunc func(*loggerOptions)
@@ -51,6 +65,68 @@ func Level(enab zapcore.LevelEnabler) LoggerOption {
 	})
 }
 
+// QuietLevel controls which messages are logged by a test Logger built by
+// NewLogger when tests run without the -test.v flag. With -test.v, the
+// Logger uses the level set by Level instead.
+//
+//	logger := zaptest.NewLogger(t, zaptest.QuietLevel(zap.WarnLevel))
+func QuietLevel(enab zapcore.LevelEnabler) LoggerOption {
+	return loggerOptionFunc(func(opts *loggerOptions) {
+		opts.QuietLevel = enab
+	})
+}
+
+// LevelFromEnv makes a test Logger built by NewLogger read its level from
+// the ZAP_TEST_LEVEL environment variable, such as "warn", if it's set. The
+// variable takes precedence over the Level and QuietLevel options, so that
+// the level may be changed without editing tests:
+//
+//	ZAP_TEST_LEVEL=debug go test ./...
+//
+// If the variable isn't a valid level, the test fails.
+func LevelFromEnv() LoggerOption {
+	return loggerOptionFunc(func(opts *loggerOptions) {
+		opts.LevelFromEnv = true
+	})
+}
+
+// WithSubtestPrefix names a test Logger built by NewLogger after the test,
+// as reported by its Name method, so that messages logged by parallel
+// subtests can be told apart. The name is joined to any name added later
+// with Logger.Named.
+func WithSubtestPrefix() LoggerOption {
+	return loggerOptionFunc(func(opts *loggerOptions) {
+		opts.NameByTest = true
+	})
+}
+
+// Clock sets the clock of a test Logger built by NewLogger or Capture, so
+// that entries are stamped with a fake time such as that of a clock.Mock.
+// Samplers added with Sampling group entries into ticks by that time.
+func Clock(clock zapcore.Clock) LoggerOption {
+	return loggerOptionFunc(func(opts *loggerOptions) {
+		opts.Clock = clock
+	})
+}
+
+// Sampling samples the messages logged by a test Logger built by NewLogger
+// or Capture, as zapcore.NewSamplerWithOptions does. With Capture, only the
+// messages that the sampler lets through are observed. Combine it with Clock
+// to test sampling deterministically:
+//
+//	clock := clock.NewMock()
+//	logger, logs := zaptest.Capture(t,
+//	  zaptest.Clock(clock),
+//	  zaptest.Sampling(time.Second, 1, 0),
+//	)
+func Sampling(tick time.Duration, first, thereafter int, opts ...zapcore.SamplerOption) LoggerOption {
+	return loggerOptionFunc(func(o *loggerOptions) {
+		o.Sampling = func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewSamplerWithOptions(core, tick, first, thereafter, opts...)
+		}
+	})
+}
+
 // WrapOptions adds zap.Option's to a test Logger built by NewLogger.
 func WrapOptions(zapOpts ...zap.Option) LoggerOption {
 	return loggerOptionFunc(func(opts *loggerOptions) {
@@ -74,30 +150,87 @@ func WrapOptions(zapOpts ...zap.Option) LoggerOption {
 // You may also pass zap.Option's to customize test logger.
 //
 //	logger := zaptest.NewLogger(t, zaptest.WrapOptions(zap.AddCaller()))
+//
+// The testing package attributes each message to zap rather than to the line
+// that logged it; add zap.AddCaller, as above, to annotate each message with
+// the line in the test.
 func NewLogger(t TestingT, opts ...LoggerOption) *zap.Logger {
+	return newLoggerOptions(opts).build(t, nil)
+}
+
+func newLoggerOptions(opts []LoggerOption) loggerOptions {
 	cfg := loggerOptions{
 		Level: zapcore.DebugLevel,
 	}
 	for _, o := range opts {
 		o.applyLoggerOption(&cfg)
 	}
+	return cfg
+}
 
+// testLevel returns the level of messages written to the test's log.
+func (cfg loggerOptions) testLevel(t TestingT) zapcore.LevelEnabler {
+	level := cfg.Level
+	if cfg.QuietLevel != nil && !testVerbose() {
+		level = cfg.QuietLevel
+	}
+	if cfg.LevelFromEnv {
+		if text, ok := os.LookupEnv(LevelEnv); ok && text != "" {
+			lvl, err := zapcore.ParseLevel(text)
+			if err != nil {
+				t.Errorf("invalid %v: %v", LevelEnv, err)
+			} else {
+				level = lvl
+			}
+		}
+	}
+	return level
+}
+
+// build builds a Logger that writes to the test's log and, if it isn't nil,
+// to core.
+func (cfg loggerOptions) build(t TestingT, core zapcore.Core) *zap.Logger {
 	writer := NewTestingWriter(t)
 	zapOptions := []zap.Option{
 		// Send zap errors to the same writer and mark the test as failed if
 		// that happens.
 		zap.ErrorOutput(writer.WithMarkFailed(true)),
 	}
+	if cfg.Clock != nil {
+		zapOptions = append(zapOptions, zap.WithClock(cfg.Clock))
+	}
 	zapOptions = append(zapOptions, cfg.zapOptions...)
 
-	return zap.New(
-		zapcore.NewCore(
-			zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()),
-			writer,
-			cfg.Level,
-		),
-		zapOptions...,
+	testCore := zapcore.NewCore(
+		zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()),
+		writer,
+		cfg.testLevel(t),
 	)
+	if core != nil {
+		testCore = zapcore.NewTee(testCore, core)
+	}
+	if cfg.Sampling != nil {
+		testCore = cfg.Sampling(testCore)
+	}
+
+	logger := zap.New(testCore, zapOptions...)
+	if cfg.NameByTest {
+		logger = logger.Named(t.Name())
+	}
+	return logger
+}
+
+// testVerbose reports whether tests are running with the -test.v flag. It
+// doesn't use testing.Verbose, which panics outside of tests, to avoid
+// importing the testing package.
+func testVerbose() bool {
+	f := flag.Lookup("test.v")
+	if f == nil {
+		return false
+	}
+	// The flag may also be set to "test2json".
+	v := f.Value.String()
+	return v != "" && v != "false"
 }
 
 // TestingWriter is a WriteSyncer that writes to the given testing.TB.
@@ -107,6 +240,12 @@ type TestingWriter struct {
 	// If true, the test will be marked as failed if this TestingWriter is
 	// ever used.
 	markFailed bool
+
+	// done is set once the test completes, if the test supports Cleanup.
+	done *atomic.Bool
+
+	// late receives messages written after the test completed.
+	late io.Writer
 }
 
 // NewTestingWriter builds a new TestingWriter that writes to the given
@@ -124,8 +263,18 @@ type TestingWriter struct {
 //	core := newCustomCore(encoder, writer, level)
 //
 //	logger := zap.New(core, zap.AddCaller())
+//
+// Messages written after the test completes, such as by goroutines the test
+// didn't wait for, can't be reported to the test. The TestingWriter writes
+// them to standard error instead.
 func NewTestingWriter(t TestingT) TestingWriter {
-	return TestingWriter{t: t}
+	w := TestingWriter{t: t, late: os.Stderr}
+	if c, ok := t.(interface{ Cleanup(func()) }); ok {
+		done := new(atomic.Bool)
+		c.Cleanup(func() { done.Store(true) })
+		w.done = done
+	}
+	return w
 }
 
 // WithMarkFailed returns a copy of this TestingWriter with markFailed set to
@@ -137,11 +286,27 @@ func (w TestingWriter) WithMarkFailed(v bool) TestingWriter {
 
 // Write writes bytes from p to the underlying testing.TB.
 func (w TestingWriter) Write(p []byte) (n int, err error) {
+	if h, ok := w.t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
 	n = len(p)
 
 	// Strip trailing newline because t.Log always adds one.
 	p = bytes.TrimRight(p, "\n")
 
+	if w.done != nil && w.done.Load() {
+		w.writeLate(p)
+		return n, nil
+	}
+
+	// The test may complete between the check above and the call to
+	// t.Logf, which then panics.
+	defer func() {
+		if r := recover(); r != nil {
+			w.writeLate(p)
+		}
+	}()
+
 	// Note: t.Log is safe for concurrent use.
 	w.t.Logf("%s", p)
 	if w.markFailed {
@@ -151,6 +316,15 @@ func (w TestingWriter) Write(p []byte) (n int, err error) {
 	return n, nil
 }
 
+// writeLate reports a message written after the test completed.
+func (w TestingWriter) writeLate(p []byte) {
+	late := w.late
+	if late == nil {
+		late = os.Stderr
+	}
+	fmt.Fprintf(late, "zaptest: logged after %v completed: %s\n", w.t.Name(), p)
+}
+
 // Sync commits the current contents (a no-op for TestingWriter).
 func (w TestingWriter) Sync() error {
 	return nil
@@ -21,7 +21,9 @@
 package zaptest
 
 import (
+	"bytes"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"strings"
@@ -32,6 +34,7 @@ import (
 	"github.com/toujourser/zap/zapcore"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTestLogger(t *testing.T) {
@@ -96,14 +99,150 @@ func TestTestLoggerSupportsWrappedZapOptions(t *testing.T) {
 	}, "log.Panic should panic")
 
 	ts.AssertMessages(
-		`INFO	zaptest/logger_test.go:89	received work order	{"k1": "v1"}`,
-		`DEBUG	zaptest/logger_test.go:90	starting work	{"k1": "v1"}`,
-		`WARN	zaptest/logger_test.go:91	work may fail	{"k1": "v1"}`,
-		`ERROR	zaptest/logger_test.go:92	work failed	{"k1": "v1", "error": "great sadness"}`,
-		`PANIC	zaptest/logger_test.go:95	failed to do work	{"k1": "v1"}`,
+		`INFO	zaptest/logger_test.go:92	received work order	{"k1": "v1"}`,
+		`DEBUG	zaptest/logger_test.go:93	starting work	{"k1": "v1"}`,
+		`WARN	zaptest/logger_test.go:94	work may fail	{"k1": "v1"}`,
+		`ERROR	zaptest/logger_test.go:95	work failed	{"k1": "v1", "error": "great sadness"}`,
+		`PANIC	zaptest/logger_test.go:98	failed to do work	{"k1": "v1"}`,
 	)
 }
 
+func TestTestLoggerQuietLevel(t *testing.T) {
+	f := flag.Lookup("test.v")
+	if f == nil {
+		t.Skip("test.v flag isn't registered")
+	}
+	orig := f.Value.String()
+	defer func() { _ = f.Value.Set(orig) }()
+
+	tests := []struct {
+		verbose string
+		want    []string
+	}{
+		{"false", []string{"WARN	work may fail"}},
+		{"true", []string{"DEBUG	starting work", "WARN	work may fail"}},
+	}
+	for _, tt := range tests {
+		t.Run("v="+tt.verbose, func(t *testing.T) {
+			ts := newTestLogSpy(t)
+			defer ts.AssertPassed()
+
+			// This test sets the flag, so it can't run in parallel.
+			require.NoError(t, f.Value.Set(tt.verbose))
+			log := NewLogger(ts, QuietLevel(zap.WarnLevel))
+
+			log.Debug("starting work")
+			log.Warn("work may fail")
+			ts.AssertMessages(tt.want...)
+		})
+	}
+}
+
+func TestTestLoggerLevelFromEnv(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		t.Setenv(LevelEnv, "error")
+		ts := newTestLogSpy(t)
+		defer ts.AssertPassed()
+
+		log := NewLogger(ts, Level(zap.DebugLevel), QuietLevel(zap.DebugLevel), LevelFromEnv())
+		log.Warn("work may fail")
+		log.Error("work failed")
+		ts.AssertMessages("ERROR	work failed")
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		t.Setenv(LevelEnv, "")
+		ts := newTestLogSpy(t)
+		defer ts.AssertPassed()
+
+		log := NewLogger(ts, Level(zap.WarnLevel), LevelFromEnv())
+		log.Info("received work order")
+		log.Warn("work may fail")
+		ts.AssertMessages("WARN	work may fail")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv(LevelEnv, "loud")
+		ts := newTestLogSpy(t)
+		defer ts.AssertFailed()
+
+		log := NewLogger(ts, Level(zap.WarnLevel), LevelFromEnv())
+		log.Warn("work may fail")
+		assert.Equal(t, []string{
+			`invalid ZAP_TEST_LEVEL: unrecognized level: "loud"`,
+			"WARN	work may fail",
+		}, ts.Messages)
+	})
+
+	t.Run("ignored", func(t *testing.T) {
+		t.Setenv(LevelEnv, "error")
+		ts := newTestLogSpy(t)
+		defer ts.AssertPassed()
+
+		log := NewLogger(ts)
+		log.Debug("starting work")
+		ts.AssertMessages("DEBUG	starting work")
+	})
+}
+
+func TestTestLoggerSubtestPrefix(t *testing.T) {
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := newTestLogSpy(t)
+			defer ts.AssertPassed()
+
+			log := NewLogger(ts, WithSubtestPrefix())
+			log.Info("received work order")
+			log.Named("worker").Info("starting work")
+			ts.AssertMessages(
+				"INFO	"+t.Name()+"	received work order",
+				"INFO	"+t.Name()+".worker	starting work",
+			)
+		})
+	}
+}
+
+func TestTestingWriterAfterTestCompleted(t *testing.T) {
+	var (
+		w    TestingWriter
+		late bytes.Buffer
+	)
+	t.Run("sub", func(t *testing.T) {
+		w = NewTestingWriter(t)
+		w.late = &late
+		_, err := io.WriteString(w, "during\n")
+		assert.NoError(t, err)
+	})
+
+	n, err := io.WriteString(w, "after\n")
+	assert.NoError(t, err, "Write must not fail")
+	assert.Equal(t, 6, n)
+	assert.Equal(t, "zaptest: logged after "+t.Name()+"/sub completed: after\n", late.String())
+}
+
+func TestTestingWriterLogfPanics(t *testing.T) {
+	var late bytes.Buffer
+	w := NewTestingWriter(panicLogT{t})
+	w.late = &late
+
+	assert.NotPanics(t, func() {
+		_, _ = io.WriteString(w, "hello\n")
+	})
+	assert.Equal(t, "zaptest: logged after "+t.Name()+" completed: hello\n", late.String())
+}
+
+// panicLogT is a TestingT whose Logf panics, as testing.T's does once the
+// test has completed.
+type panicLogT struct {
+	TestingT
+}
+
+func (panicLogT) Logf(string, ...interface{}) {
+	panic("Log in goroutine after test has completed")
+}
+
 func TestTestingWriter(t *testing.T) {
 	ts := newTestLogSpy(t)
 	w := NewTestingWriter(ts)
@@ -163,6 +302,11 @@ func (t *testLogSpy) FailNow() {
 	t.TB.FailNow()
 }
 
+func (t *testLogSpy) Errorf(format string, args ...interface{}) {
+	t.Messages = append(t.Messages, fmt.Sprintf(format, args...))
+	t.Fail()
+}
+
 func (t *testLogSpy) Logf(format string, args ...interface{}) {
 	// Log messages are in the format,
 	//
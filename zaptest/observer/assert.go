@@ -0,0 +1,199 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observer
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/toujourser/zap/zapcore"
+)
+
+// TestingT is the subset of *testing.T used by AssertLogged.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// A FieldMatcher checks the fields of a LoggedEntry. Use HasField,
+// FieldEquals, and FieldMatches to construct one.
+type FieldMatcher struct {
+	desc  string
+	match func(LoggedEntry) bool
+}
+
+// String describes the field that the matcher expects.
+func (m FieldMatcher) String() string { return m.desc }
+
+// HasField matches entries with a field with the given key, regardless of
+// its value. Fields following a Namespace are matched by their dotted path,
+// as in LoggedEntry.FlatContextMap.
+func HasField(key string) FieldMatcher {
+	return FieldMatcher{
+		desc: fmt.Sprintf("has %q", key),
+		match: func(e LoggedEntry) bool {
+			_, ok := e.Field(key)
+			return ok
+		},
+	}
+}
+
+// FieldEquals matches entries with a field equal to the given one, as
+// reported by zapcore.Field.Equals.
+func FieldEquals(field zapcore.Field) FieldMatcher {
+	return FieldMatcher{
+		desc: fmt.Sprintf("%s == %s", field.Key, formatField(field)),
+		match: func(e LoggedEntry) bool {
+			f, ok := e.Field(field.Key)
+			return ok && f.Equals(field)
+		},
+	}
+}
+
+// FieldMatches matches entries with a field with the given key for which fn
+// returns true. desc describes the condition in failure messages.
+func FieldMatches(key, desc string, fn func(zapcore.Field) bool) FieldMatcher {
+	return FieldMatcher{
+		desc: fmt.Sprintf("%s %s", key, desc),
+		match: func(e LoggedEntry) bool {
+			f, ok := e.Field(key)
+			return ok && fn(f)
+		},
+	}
+}
+
+// AssertLogged asserts that logs contain an entry at the given level, with
+// a message containing msgSubstring and fields satisfying every matcher:
+//
+//	observer.AssertLogged(t, logs, zap.ErrorLevel, "request failed",
+//	  observer.FieldEquals(zap.Int("status", 500)),
+//	  observer.HasField("error"),
+//	)
+//
+// If there's no such entry, it fails the test with a description of the
+// entry that came closest to matching and the checks it failed. It returns
+// whether an entry matched.
+func AssertLogged(t TestingT, logs *ObservedLogs, level zapcore.Level, msgSubstring string, matchers ...FieldMatcher) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	checks := make([]entryCheck, 0, len(matchers)+2)
+	checks = append(checks,
+		entryCheck{
+			desc:  "level " + level.String(),
+			match: func(e LoggedEntry) bool { return e.Level == level },
+		},
+		entryCheck{
+			desc:  fmt.Sprintf("message containing %q", msgSubstring),
+			match: func(e LoggedEntry) bool { return strings.Contains(e.Message, msgSubstring) },
+		},
+	)
+	for _, m := range matchers {
+		checks = append(checks, entryCheck{desc: m.desc, match: m.match})
+	}
+
+	var (
+		closest       LoggedEntry
+		closestFailed []string
+		best          = -1
+	)
+	for _, e := range logs.All() {
+		var failed []string
+		for _, c := range checks {
+			if !c.match(e) {
+				failed = append(failed, c.desc)
+			}
+		}
+		if len(failed) == 0 {
+			return true
+		}
+		if passed := len(checks) - len(failed); passed > best {
+			best, closest, closestFailed = passed, e, failed
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("No logged entry matched:\n")
+	for _, c := range checks {
+		fmt.Fprintf(&sb, "\t%s\n", c.desc)
+	}
+	if best < 0 {
+		sb.WriteString("No entries were logged.")
+		t.Errorf("%s", sb.String())
+		return false
+	}
+
+	fmt.Fprintf(&sb, "Closest entry (%d of %d checks passed):\n", best, len(checks))
+	fmt.Fprintf(&sb, "\tlevel: %v\n", closest.Level)
+	fmt.Fprintf(&sb, "\tmessage: %q\n", closest.Message)
+	for _, p := range closest.ContextMapOrdered() {
+		fmt.Fprintf(&sb, "\t%s: %s\n", p.Key, formatValue(p.Value))
+	}
+	sb.WriteString("Failed checks:")
+	for _, desc := range closestFailed {
+		fmt.Fprintf(&sb, "\n\t%s", desc)
+	}
+	t.Errorf("%s", sb.String())
+	return false
+}
+
+// entryCheck is one of the conditions checked by AssertLogged.
+type entryCheck struct {
+	desc  string
+	match func(LoggedEntry) bool
+}
+
+// formatField returns the encoded value of a field, as it would appear in a
+// failure message.
+func formatField(f zapcore.Field) string {
+	encoder := zapcore.NewMapObjectEncoder()
+	f.AddTo(encoder)
+	return formatValue(encoder.Fields[f.Key])
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = fmt.Sprintf("%s: %s", k, formatValue(v[k]))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+	if v != nil && reflect.TypeOf(v).Kind() == reflect.Slice {
+		rv := reflect.ValueOf(v)
+		parts := make([]string, rv.Len())
+		for i := range parts {
+			parts[i] = formatValue(rv.Index(i).Interface())
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observer_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/toujourser/zap"
+	"github.com/toujourser/zap/zapcore"
+
+	//revive:disable:dot-imports
+	. "github.com/toujourser/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingT records the failures reported to it.
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertLogged(t *testing.T) {
+	core, logs := New(zap.DebugLevel)
+	logger := zap.New(core)
+	logger.Info("request started", zap.String("path", "/"))
+	logger.Error("request failed",
+		zap.String("path", "/foo"),
+		zap.Int("status", 500),
+		zap.Error(errors.New("great sadness")),
+	)
+
+	t.Run("match", func(t *testing.T) {
+		rt := &recordingT{}
+		ok := AssertLogged(rt, logs, zap.ErrorLevel, "failed",
+			FieldEquals(zap.Int("status", 500)),
+			HasField("error"),
+			FieldMatches("path", "starts with /f", func(f zapcore.Field) bool {
+				return f.Type == zapcore.StringType && strings.HasPrefix(f.String, "/f")
+			}),
+		)
+		assert.True(t, ok, "Expected an entry to match.")
+		assert.Empty(t, rt.errors, "Unexpected failures.")
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		rt := &recordingT{}
+		ok := AssertLogged(rt, logs, zap.ErrorLevel, "failed",
+			FieldEquals(zap.Int("status", 503)),
+			HasField("error"),
+		)
+		assert.False(t, ok, "Expected no entry to match.")
+		assert.Equal(t, []string{
+			"No logged entry matched:\n" +
+				"\tlevel error\n" +
+				"\tmessage containing \"failed\"\n" +
+				"\tstatus == 503\n" +
+				"\thas \"error\"\n" +
+				"Closest entry (3 of 4 checks passed):\n" +
+				"\tlevel: error\n" +
+				"\tmessage: \"request failed\"\n" +
+				"\tpath: \"/foo\"\n" +
+				"\tstatus: 500\n" +
+				"\terror: \"great sadness\"\n" +
+				"Failed checks:\n" +
+				"\tstatus == 503",
+		}, rt.errors, "Unexpected failure message.")
+	})
+
+	t.Run("no entries", func(t *testing.T) {
+		_, empty := New(zap.DebugLevel)
+		rt := &recordingT{}
+		assert.False(t, AssertLogged(rt, empty, zap.InfoLevel, ""), "Expected no entry to match.")
+		assert.Equal(t, []string{
+			"No logged entry matched:\n" +
+				"\tlevel info\n" +
+				"\tmessage containing \"\"\n" +
+				"No entries were logged.",
+		}, rt.errors, "Unexpected failure message.")
+	})
+}
+
+func TestFieldMatcherString(t *testing.T) {
+	assert.Equal(t, `has "k"`, HasField("k").String())
+	assert.Equal(t, `k == "v"`, FieldEquals(zap.String("k", "v")).String())
+	assert.Equal(t, `k == [1, 2]`, FieldEquals(zap.Ints("k", []int{1, 2})).String())
+	assert.Equal(t, "k is odd", FieldMatches("k", "is odd", nil).String())
+}
@@ -20,7 +20,12 @@
 
 package observer
 
-import "github.com/toujourser/zap/zapcore"
+import (
+	"fmt"
+	"sort"
+
+	"github.com/toujourser/zap/zapcore"
+)
 
 // A LoggedEntry is an encoding-agnostic representation of a log message.
 // Field availability is context dependent.
@@ -29,7 +34,9 @@ type LoggedEntry struct {
 	Context []zapcore.Field
 }
 
-// ContextMap returns a map for all fields in Context.
+// ContextMap returns a map for all fields in Context. Fields following a
+// Namespace are nested in a map under the namespace's key; see
+// FlatContextMap for an alternative.
 func (e LoggedEntry) ContextMap() map[string]interface{} {
 	encoder := zapcore.NewMapObjectEncoder()
 	for _, f := range e.Context {
@@ -37,3 +44,127 @@ func (e LoggedEntry) ContextMap() map[string]interface{} {
 	}
 	return encoder.Fields
 }
+
+// A ContextPair is a key and its encoded value, as returned by
+// LoggedEntry.ContextMapOrdered.
+type ContextPair struct {
+	Key   string
+	Value interface{}
+}
+
+// ContextMapOrdered returns the fields in Context as key-value pairs, in the
+// order they were added. Values are encoded as in ContextMap, but fields
+// following a Namespace have the namespace prepended to their keys, joined
+// by periods (e.g. "http.status"). Unlike ContextMap, repeated keys are
+// all returned.
+func (e LoggedEntry) ContextMapOrdered() []ContextPair {
+	pairs := make([]ContextPair, 0, len(e.Context))
+	e.walkContext(func(key string, f zapcore.Field) {
+		encoder := zapcore.NewMapObjectEncoder()
+		f.AddTo(encoder)
+		if v, ok := encoder.Fields[f.Key]; ok && len(encoder.Fields) == 1 {
+			pairs = append(pairs, ContextPair{Key: key, Value: v})
+			return
+		}
+
+		// Inline fields add any number of keys.
+		prefix := key[:len(key)-len(f.Key)]
+		keys := make([]string, 0, len(encoder.Fields))
+		for k := range encoder.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			pairs = append(pairs, ContextPair{Key: prefix + k, Value: encoder.Fields[k]})
+		}
+	})
+	return pairs
+}
+
+// FlatContextMap is like ContextMap, but fields following a Namespace are
+// keyed by their dotted path (e.g. "http.status") rather than nested.
+func (e LoggedEntry) FlatContextMap() map[string]interface{} {
+	pairs := e.ContextMapOrdered()
+	m := make(map[string]interface{}, len(pairs))
+	for _, p := range pairs {
+		m[p.Key] = p.Value
+	}
+	return m
+}
+
+// Field returns the field in Context with the given key. Fields following a
+// Namespace are found by their dotted path, as in FlatContextMap. If the key
+// is repeated, the last field wins.
+func (e LoggedEntry) Field(key string) (zapcore.Field, bool) {
+	var (
+		found zapcore.Field
+		ok    bool
+	)
+	e.walkContext(func(k string, f zapcore.Field) {
+		if k == key {
+			found, ok = f, true
+		}
+	})
+	return found, ok
+}
+
+// StringField returns the value of the string field with the given key. It
+// reports false if there's no such field, or if it isn't a String,
+// ByteString, or Stringer field.
+func (e LoggedEntry) StringField(key string) (string, bool) {
+	f, ok := e.Field(key)
+	if !ok {
+		return "", false
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String, true
+	case zapcore.ByteStringType:
+		return string(f.Interface.([]byte)), true
+	case zapcore.StringerType:
+		return fmt.Sprint(f.Interface), true
+	default:
+		return "", false
+	}
+}
+
+// Int64Field returns the value of the signed integer field with the given
+// key. It reports false if there's no such field, or if it isn't an Int64,
+// Int32, Int16, or Int8 field.
+func (e LoggedEntry) Int64Field(key string) (int64, bool) {
+	f, ok := e.Field(key)
+	if !ok {
+		return 0, false
+	}
+	switch f.Type {
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return f.Integer, true
+	default:
+		return 0, false
+	}
+}
+
+// ErrorField returns the error logged with the given key, as by zap.Error or
+// zap.NamedError. It reports false if there's no such field, or if it isn't
+// an error field.
+func (e LoggedEntry) ErrorField(key string) (error, bool) {
+	f, ok := e.Field(key)
+	if !ok || f.Type != zapcore.ErrorType {
+		return nil, false
+	}
+	err, ok := f.Interface.(error)
+	return err, ok
+}
+
+// walkContext calls fn with each field in Context and its key, prefixed by
+// the namespaces that precede it. Namespace fields themselves are skipped.
+func (e LoggedEntry) walkContext(fn func(key string, f zapcore.Field)) {
+	var prefix string
+	for _, f := range e.Context {
+		if f.Type == zapcore.NamespaceType {
+			prefix += f.Key + "."
+			continue
+		}
+		fn(prefix+f.Key, f)
+	}
+}
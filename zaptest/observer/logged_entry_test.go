@@ -21,6 +21,7 @@
 package observer
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/toujourser/zap"
@@ -86,3 +87,99 @@ func TestLoggedEntryContextMap(t *testing.T) {
 		})
 	}
 }
+
+func TestLoggedEntryFlatContext(t *testing.T) {
+	entry := LoggedEntry{
+		Context: []zapcore.Field{
+			zap.String("k1", "v1"),
+			zap.Namespace("http"),
+			zap.Int("status", 200),
+			zap.Namespace("req"),
+			zap.String("k1", "v2"),
+			zap.Inline(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+				enc.AddString("b", "B")
+				enc.AddString("a", "A")
+				return nil
+			})),
+			zap.Skip(),
+		},
+	}
+
+	assert.Equal(t, []ContextPair{
+		{Key: "k1", Value: "v1"},
+		{Key: "http.status", Value: int64(200)},
+		{Key: "http.req.k1", Value: "v2"},
+		{Key: "http.req.a", Value: "A"},
+		{Key: "http.req.b", Value: "B"},
+	}, entry.ContextMapOrdered(), "Unexpected ordered context.")
+
+	assert.Equal(t, map[string]interface{}{
+		"k1":          "v1",
+		"http.status": int64(200),
+		"http.req.k1": "v2",
+		"http.req.a":  "A",
+		"http.req.b":  "B",
+	}, entry.FlatContextMap(), "Unexpected flat context.")
+
+	f, ok := entry.Field("http.req.k1")
+	assert.True(t, ok, "Expected to find a namespaced field.")
+	assert.Equal(t, zap.String("k1", "v2"), f, "Unexpected field.")
+
+	_, ok = entry.Field("status")
+	assert.False(t, ok, "Expected namespaced fields to require their full path.")
+}
+
+func TestLoggedEntryTypedFields(t *testing.T) {
+	err := errors.New("great sadness")
+	entry := LoggedEntry{
+		Context: []zapcore.Field{
+			zap.String("str", "foo"),
+			zap.ByteString("bytes", []byte("bar")),
+			zap.Stringer("stringer", zapcore.InfoLevel),
+			zap.Int64("int64", 42),
+			zap.Int8("int8", -1),
+			zap.Uint("uint", 1),
+			zap.Error(err),
+			zap.String("int64", "shadowed"),
+			zap.Int("repeated", 1),
+			zap.Int("repeated", 2),
+		},
+	}
+
+	tests := []struct {
+		key    string
+		want   interface{}
+		wantOK bool
+		get    func(string) (interface{}, bool)
+	}{
+		{key: "str", want: "foo", wantOK: true, get: stringField(entry)},
+		{key: "bytes", want: "bar", wantOK: true, get: stringField(entry)},
+		{key: "stringer", want: "info", wantOK: true, get: stringField(entry)},
+		{key: "int8", want: "", get: stringField(entry)},
+		{key: "missing", want: "", get: stringField(entry)},
+		{key: "int8", want: int64(-1), wantOK: true, get: int64Field(entry)},
+		{key: "repeated", want: int64(2), wantOK: true, get: int64Field(entry)},
+		{key: "uint", want: int64(0), get: int64Field(entry)},
+		{key: "int64", want: int64(0), get: int64Field(entry)},
+		{key: "error", want: err, wantOK: true, get: errorField(entry)},
+		{key: "str", want: error(nil), get: errorField(entry)},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.get(tt.key)
+		assert.Equal(t, tt.wantOK, ok, "Unexpected ok for %q.", tt.key)
+		assert.Equal(t, tt.want, got, "Unexpected value for %q.", tt.key)
+	}
+}
+
+func stringField(e LoggedEntry) func(string) (interface{}, bool) {
+	return func(key string) (interface{}, bool) { return e.StringField(key) }
+}
+
+func int64Field(e LoggedEntry) func(string) (interface{}, bool) {
+	return func(key string) (interface{}, bool) { return e.Int64Field(key) }
+}
+
+func errorField(e LoggedEntry) func(string) (interface{}, bool) {
+	return func(key string) (interface{}, bool) { return e.ErrorField(key) }
+}
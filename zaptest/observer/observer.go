@@ -25,6 +25,7 @@
 package observer // import "github.com/toujourser/zap/zaptest/observer"
 
 import (
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -66,6 +67,24 @@ func (o *ObservedLogs) TakeAll() []LoggedEntry {
 	return ret
 }
 
+// TakeN returns the first n observed logs, and removes them from the observed
+// slice. If fewer than n logs were observed, it returns all of them.
+func (o *ObservedLogs) TakeN(n int) []LoggedEntry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if n <= 0 {
+		return nil
+	}
+	if n > len(o.logs) {
+		n = len(o.logs)
+	}
+	ret := make([]LoggedEntry, n)
+	copy(ret, o.logs)
+	o.logs = append(o.logs[:0:0], o.logs[n:]...)
+	return ret
+}
+
 // AllUntimed returns a copy of all the observed logs, but overwrites the
 // observed timestamps with time.Time's zero value. This is useful when making
 // assertions in tests.
@@ -84,6 +103,14 @@ func (o *ObservedLogs) FilterLevelExact(level zapcore.Level) *ObservedLogs {
 	})
 }
 
+// FilterLevelRange filters entries to those logged at a level between min and
+// max, inclusive.
+func (o *ObservedLogs) FilterLevelRange(min, max zapcore.Level) *ObservedLogs {
+	return o.Filter(func(e LoggedEntry) bool {
+		return e.Level >= min && e.Level <= max
+	})
+}
+
 // FilterMessage filters entries to those that have the specified message.
 func (o *ObservedLogs) FilterMessage(msg string) *ObservedLogs {
 	return o.Filter(func(e LoggedEntry) bool {
@@ -105,6 +132,14 @@ func (o *ObservedLogs) FilterMessageSnippet(snippet string) *ObservedLogs {
 	})
 }
 
+// FilterMessageRegexp filters entries to those that have a message matching
+// the specified regular expression.
+func (o *ObservedLogs) FilterMessageRegexp(re *regexp.Regexp) *ObservedLogs {
+	return o.Filter(func(e LoggedEntry) bool {
+		return re.MatchString(e.Message)
+	})
+}
+
 // FilterField filters entries to those that have the specified field.
 func (o *ObservedLogs) FilterField(field zapcore.Field) *ObservedLogs {
 	return o.Filter(func(e LoggedEntry) bool {
@@ -21,6 +21,7 @@
 package observer_test
 
 import (
+	"regexp"
 	"testing"
 	"time"
 
@@ -260,10 +261,63 @@ func TestFilters(t *testing.T) {
 			filtered: sink.FilterLoggerName("my.logger"),
 			want:     logs[11:12],
 		},
+		{
+			msg:      "filter by regexp",
+			filtered: sink.FilterMessageRegexp(regexp.MustCompile(`^msg \d$`)),
+			want:     []LoggedEntry{logs[4], logs[7]},
+		},
+		{
+			msg:      "filter by regexp and field key",
+			filtered: sink.FilterMessageRegexp(regexp.MustCompile(`^log [ab]$`)).FilterFieldKey("b"),
+			want:     logs[1:3],
+		},
+		{
+			msg:      "filter level range",
+			filtered: sink.FilterLevelRange(zap.WarnLevel, zap.ErrorLevel),
+			want:     logs[9:12],
+		},
+		{
+			msg:      "filter level range and logger name",
+			filtered: sink.FilterLevelRange(zap.InfoLevel, zap.WarnLevel).FilterLoggerName("my.logger"),
+			want:     []LoggedEntry{},
+		},
 	}
 
 	for _, tt := range tests {
 		got := tt.filtered.AllUntimed()
 		assert.Equal(t, tt.want, got, tt.msg)
 	}
+	assert.Equal(t, len(logs), sink.Len(), "Filters must not modify the observed logs.")
+}
+
+func TestTakeN(t *testing.T) {
+	observer, logs := New(zap.InfoLevel)
+	for _, msg := range []string{"a", "b", "c"} {
+		require.NoError(t, observer.Write(zapcore.Entry{Message: msg}, nil), "Unexpected error writing log entry.")
+	}
+
+	messages := func(entries []LoggedEntry) []string {
+		msgs := make([]string, len(entries))
+		for i, e := range entries {
+			msgs[i] = e.Message
+		}
+		return msgs
+	}
+
+	assert.Empty(t, logs.TakeN(0), "Expected nothing to be taken.")
+	assert.Equal(t, []string{"a", "b"}, messages(logs.TakeN(2)), "Unexpected entries taken.")
+	assert.Equal(t, []string{"c"}, messages(logs.All()), "Expected taken entries to be removed.")
+	assert.Equal(t, []string{"c"}, messages(logs.TakeN(5)), "Expected remaining entries to be taken.")
+	assertEmpty(t, logs)
+}
+
+func TestObserverNamedLoggerAndCaller(t *testing.T) {
+	observer, logs := New(zap.InfoLevel)
+	zap.New(observer, zap.AddCaller()).Named("svc").Info("hello")
+
+	entries := logs.FilterLoggerName("svc").AllUntimed()
+	require.Len(t, entries, 1, "Expected one entry from the named logger.")
+	assert.Equal(t, "svc", entries[0].LoggerName, "Unexpected logger name.")
+	assert.True(t, entries[0].Caller.Defined, "Expected caller to be recorded.")
+	assert.Regexp(t, `observer_test.go:\d+$`, entries[0].Caller.String(), "Unexpected caller.")
 }